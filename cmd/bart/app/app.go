@@ -5,6 +5,7 @@
 package app
 
 import (
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 	"github.com/urfave/cli"
 )
 
@@ -27,16 +28,35 @@ type BartApp struct {
 	requestText2   string
 	commaSepLabels string
 	multiClass     bool
+	generate       generateOptions
+	batching       microbatching.Config
+	quantize       bool
+}
+
+// generateOptions are the decoding options of the generate client command.
+type generateOptions struct {
+	maxLength          int
+	minLength          int
+	numBeams           int
+	numReturnSequences int
+	doSample           bool
+	temperature        float64
+	topK               int
+	topP               float64
+	lengthPenalty      float64
+	earlyStopping      bool
+	noRepeatNGramSize  int
 }
 
 // NewBartApp returns a new BartApp object, which can be used as either client or server.
 func NewBartApp() *BartApp {
 	app := &BartApp{
-		App: cli.NewApp(),
+		App:      cli.NewApp(),
+		batching: microbatching.DefaultConfig(),
 	}
 	app.Name = programName
 	app.HelpName = programName
-	app.Usage = "A demo for sequence-classification based on BART."
+	app.Usage = "A demo for sequence-classification and text generation based on BART."
 	app.Commands = []cli.Command{
 		newServerCommandFor(app),
 		newClientCommandFor(app),
@@ -16,6 +16,7 @@ func newClientCommandFor(app *BartApp) cli.Command {
 		Subcommands: []cli.Command{
 			newClientClassifyCommandFor(app),
 			newClientClassifyNLICommandFor(app),
+			newClientGenerateCommandFor(app),
 		},
 	}
 }
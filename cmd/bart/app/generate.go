@@ -0,0 +1,148 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/nlpodyssey/spago/cmd/clientutils"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartserver/grpcapi"
+	"github.com/urfave/cli"
+)
+
+func newClientGenerateCommandFor(app *BartApp) cli.Command {
+	return cli.Command{
+		Name:  "generate",
+		Usage: "Perform text generation (e.g. summarization or translation) using BART.",
+		UsageText: programName + " client generate --text=<value> [--max-length=<value>] [--min-length=<value>]" +
+			" [--num-beams=<value>] [--num-return-sequences=<value>] [--do-sample] [--temperature=<value>]" +
+			" [--top-k=<value>] [--top-p=<value>] [--length-penalty=<value>] [--early-stopping]" +
+			" [--no-repeat-ngram-size=<value>]" + clientutils.UsageText(),
+		Description: "Run the " + programName + " client for text generation. " +
+			"The decoding options left unset take the default values of the model.",
+		Flags:  newClientGenerateCommandFlagsFor(app),
+		Action: newClientGenerateCommandActionFor(app),
+	}
+}
+
+func newClientGenerateCommandFlagsFor(app *BartApp) []cli.Flag {
+	return clientutils.Flags(&app.grpcAddress, &app.tlsDisable, &app.output, []cli.Flag{
+		cli.StringFlag{
+			Name:        "text",
+			Destination: &app.requestText,
+			Required:    true,
+		},
+		cli.IntFlag{
+			Name:        "max-length",
+			Usage:       "maximum length of the generated sequences",
+			Destination: &app.generate.maxLength,
+		},
+		cli.IntFlag{
+			Name:        "min-length",
+			Usage:       "minimum length of the generated sequences",
+			Destination: &app.generate.minLength,
+		},
+		cli.IntFlag{
+			Name:        "num-beams",
+			Usage:       "number of beams for beam search (1 means no beam search)",
+			Destination: &app.generate.numBeams,
+		},
+		cli.IntFlag{
+			Name:        "num-return-sequences",
+			Usage:       "number of sequences to return",
+			Destination: &app.generate.numReturnSequences,
+		},
+		cli.BoolFlag{
+			Name:        "do-sample",
+			Usage:       "use sampling instead of greedy decoding",
+			Destination: &app.generate.doSample,
+		},
+		cli.Float64Flag{
+			Name:        "temperature",
+			Usage:       "temperature of the next token probabilities when sampling",
+			Destination: &app.generate.temperature,
+		},
+		cli.IntFlag{
+			Name:        "top-k",
+			Usage:       "number of the most probable tokens kept when sampling",
+			Destination: &app.generate.topK,
+		},
+		cli.Float64Flag{
+			Name:        "top-p",
+			Usage:       "cumulative probability of the most probable tokens kept when sampling",
+			Destination: &app.generate.topP,
+		},
+		cli.Float64Flag{
+			Name:        "length-penalty",
+			Usage:       "exponential penalty to the length for beam search",
+			Destination: &app.generate.lengthPenalty,
+		},
+		cli.BoolFlag{
+			Name:        "early-stopping",
+			Usage:       "stop the beam search when at least num-beams sequences are finished",
+			Destination: &app.generate.earlyStopping,
+		},
+		cli.IntFlag{
+			Name:        "no-repeat-ngram-size",
+			Usage:       "size of the n-grams which can occur only once",
+			Destination: &app.generate.noRepeatNGramSize,
+		},
+	})
+}
+
+func newClientGenerateCommandActionFor(app *BartApp) func(c *cli.Context) {
+	return func(c *cli.Context) {
+		clientutils.VerifyFlags(app.output)
+
+		conn := clientutils.OpenConnection(app.grpcAddress, app.tlsDisable)
+		client := grpcapi.NewBARTClient(conn)
+
+		stream, err := client.Generate(context.Background(), &grpcapi.GenerateRequest{
+			Text:               app.requestText,
+			MaxLength:          int32(app.generate.maxLength),
+			MinLength:          int32(app.generate.minLength),
+			NumBeams:           int32(app.generate.numBeams),
+			NumReturnSequences: int32(app.generate.numReturnSequences),
+			DoSample:           app.generate.doSample,
+			Temperature:        app.generate.temperature,
+			TopK:               int32(app.generate.topK),
+			TopP:               app.generate.topP,
+			LengthPenalty:      app.generate.lengthPenalty,
+			EarlyStopping:      app.generate.earlyStopping,
+			NoRepeatNgramSize:  int32(app.generate.noRepeatNGramSize),
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		// The tokens of the first sequence are printed as soon as they are received,
+		// while the finished sequences are printed at the end.
+		streamed := false
+		finished := make([]*grpcapi.GenerateReply, 0)
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				log.Fatalln(err)
+			}
+			if resp.Finished {
+				finished = append(finished, resp)
+			} else if resp.Sequence == 0 {
+				fmt.Print(resp.Text)
+				streamed = true
+			}
+		}
+		if streamed {
+			fmt.Println()
+		}
+
+		clientutils.Println(app.output, finished)
+	}
+}
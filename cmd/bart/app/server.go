@@ -6,10 +6,13 @@ package app
 
 import (
 	"fmt"
-	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/bpetokenizer"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartconfig"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/barthead"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartserver"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/huggingface"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 	"log"
 	"os"
 	"os/user"
@@ -23,7 +26,7 @@ func newServerCommandFor(app *BartApp) cli.Command {
 	return cli.Command{
 		Name:        "server",
 		Usage:       "Run the " + programName + " as gRPC/HTTP server.",
-		UsageText:   programName + " run --model=<name> [--repo=<path>] [--grpc-address=<address>] [--tls-cert-file=<cert>] [--tls-key-file=<key>] [--tls-disable]",
+		UsageText:   programName + " run --model=<name> [--repo=<path>] [--grpc-address=<address>] [--tls-cert-file=<cert>] [--tls-key-file=<key>] [--tls-disable] [--max-batch-size=<size>] [--max-batch-wait=<duration>] [--queue-size=<size>] [--batch-workers=<n>] [--quantize]",
 		Description: "Run the " + programName + " indicating the model path (NOT the model file).",
 		Flags:       newServerCommandFlagsFor(app),
 		Action:      newServerCommandActionFor(app),
@@ -80,11 +83,47 @@ func newServerCommandFlagsFor(app *BartApp) []cli.Flag {
 			Usage:       "Specifies that TLS is disabled.",
 			Destination: &app.tlsDisable,
 		},
+		cli.IntFlag{
+			Name:        "max-batch-size",
+			Usage:       "Specifies the maximum number of requests processed together.",
+			Value:       microbatching.DefaultConfig().MaxBatchSize,
+			Destination: &app.batching.MaxBatchSize,
+		},
+		cli.DurationFlag{
+			Name:        "max-batch-wait",
+			Usage:       "Specifies how long a batch waits for further requests before being processed.",
+			Value:       microbatching.DefaultConfig().MaxWait,
+			Destination: &app.batching.MaxWait,
+		},
+		cli.IntFlag{
+			Name:        "queue-size",
+			Usage:       "Specifies the maximum number of pending requests; further requests are rejected.",
+			Value:       microbatching.DefaultConfig().QueueSize,
+			Destination: &app.batching.QueueSize,
+		},
+		cli.IntFlag{
+			Name:        "batch-workers",
+			Usage:       "Specifies the number of batches processed concurrently.",
+			Value:       microbatching.DefaultConfig().Workers,
+			Destination: &app.batching.Workers,
+		},
+		cli.BoolFlag{
+			Name:        "quantize",
+			Usage:       "Specifies that the weights of the linear layers are quantized to int8 after loading.",
+			Destination: &app.quantize,
+		},
 	}
 }
 
 const defaultModelFile = "spago_model.bin"
 
+// bartServer is implemented by both the sequence classification and the
+// conditional generation servers.
+type bartServer interface {
+	StartDefaultServer(grpcAddress, tlsCert, tlsKey string, tlsDisable bool)
+	StartDefaultHTTPServer(address, tlsCert, tlsKey string, tlsDisable bool)
+}
+
 func newServerCommandActionFor(app *BartApp) func(c *cli.Context) error {
 	return func(c *cli.Context) error {
 		modelPath := filepath.Join(app.repo, app.model)
@@ -116,20 +155,52 @@ func newServerCommandActionFor(app *BartApp) func(c *cli.Context) error {
 			}
 		}
 
-		tokenizer, err := bpetokenizer.NewFromModelFolder(modelPath)
+		tokenizer, err := bartserver.LoadTokenizer(modelPath)
 		if err != nil {
 			log.Fatal(err)
 		}
-		if tokenizer == nil {
-			log.Fatal("expected BPETokenizer, actual nil")
-		}
 
-		model, err := barthead.LoadModelForSequenceClassification(modelPath)
+		config, err := bartconfig.Load(path.Join(modelPath, bartconfig.DefaultConfigurationFile))
 		if err != nil {
 			log.Fatal(err)
 		}
-		defer model.Close()
-		fmt.Printf("Config: %+v\n", model.BART.Config)
+
+		var server bartServer
+		if config.IsConditionalGeneration() {
+			model, err := barthead.LoadModelForConditionalGeneration(modelPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer model.Close()
+			fmt.Printf("Config: %+v\n", model.BART.Config)
+			if app.quantize {
+				fmt.Println("Quantizing the linear layers...")
+				if err := linear.QuantizeLayers(model); err != nil {
+					log.Fatal(err)
+				}
+			}
+			server = bartserver.NewServerForConditionalGeneration(model, tokenizer)
+			utils.ExitOnSignal(model.Close)
+		} else {
+			model, err := barthead.LoadModelForSequenceClassification(modelPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer model.Close()
+			fmt.Printf("Config: %+v\n", model.BART.Config)
+			if app.quantize {
+				fmt.Println("Quantizing the linear layers...")
+				if err := linear.QuantizeLayers(model); err != nil {
+					log.Fatal(err)
+				}
+			}
+			classifier := bartserver.NewServer(model, tokenizer, bartserver.WithBatching(app.batching))
+			utils.ExitOnSignal(func() {
+				classifier.Close()
+				model.Close()
+			})
+			server = classifier
+		}
 
 		if !app.tlsDisable {
 			fmt.Printf("TLS Cert path is %s\n", app.tlsCert)
@@ -150,7 +221,6 @@ func newServerCommandActionFor(app *BartApp) func(c *cli.Context) error {
 			return "TLS"
 		}(), app.address)
 
-		server := bartserver.NewServer(model, tokenizer)
 		server.StartDefaultHTTPServer(app.address, app.tlsCert, app.tlsKey, app.tlsDisable)
 		server.StartDefaultServer(app.grpcAddress, app.tlsCert, app.tlsKey, app.tlsDisable)
 
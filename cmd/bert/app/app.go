@@ -5,6 +5,7 @@
 package app
 
 import (
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 	"github.com/urfave/cli"
 )
 
@@ -27,12 +28,15 @@ type BertApp struct {
 	requestText2 string
 	passage      string
 	question     string
+	batching     microbatching.Config
+	quantize     bool
 }
 
 // NewBertApp returns BertApp objects. The app can be used as both a client and a server.
 func NewBertApp() *BertApp {
 	app := &BertApp{
-		App: cli.NewApp(),
+		App:      cli.NewApp(),
+		batching: microbatching.DefaultConfig(),
 	}
 	app.Name = programName
 	app.HelpName = programName
@@ -6,7 +6,11 @@ package app
 
 import (
 	"fmt"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/huggingface"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 	"log"
 	"os"
 	"os/user"
@@ -21,7 +25,7 @@ func newServerCommandFor(app *BertApp) cli.Command {
 	return cli.Command{
 		Name:        "server",
 		Usage:       "Run the " + programName + " as gRPC/HTTP server.",
-		UsageText:   programName + " run --model=<name> [--repo=<path>] [--address=<address>] [--grpc-address=<address>] [--tls-cert-file=<cert>] [--tls-key-file=<key>] [--tls-disable]",
+		UsageText:   programName + " run --model=<name> [--repo=<path>] [--address=<address>] [--grpc-address=<address>] [--tls-cert-file=<cert>] [--tls-key-file=<key>] [--tls-disable] [--max-batch-size=<size>] [--max-batch-wait=<duration>] [--queue-size=<size>] [--batch-workers=<n>] [--quantize]",
 		Description: "Run the " + programName + " indicating the model path (NOT the model file).",
 		Flags:       newServerCommandFlagsFor(app),
 		Action:      newServerCommandActionFor(app),
@@ -78,6 +82,35 @@ func newServerCommandFlagsFor(app *BertApp) []cli.Flag {
 			Usage:       "Specifies that TLS is disabled.",
 			Destination: &app.tlsDisable,
 		},
+		cli.IntFlag{
+			Name:        "max-batch-size",
+			Usage:       "Specifies the maximum number of requests processed together.",
+			Value:       microbatching.DefaultConfig().MaxBatchSize,
+			Destination: &app.batching.MaxBatchSize,
+		},
+		cli.DurationFlag{
+			Name:        "max-batch-wait",
+			Usage:       "Specifies how long a batch waits for further requests before being processed.",
+			Value:       microbatching.DefaultConfig().MaxWait,
+			Destination: &app.batching.MaxWait,
+		},
+		cli.IntFlag{
+			Name:        "queue-size",
+			Usage:       "Specifies the maximum number of pending requests; further requests are rejected.",
+			Value:       microbatching.DefaultConfig().QueueSize,
+			Destination: &app.batching.QueueSize,
+		},
+		cli.IntFlag{
+			Name:        "batch-workers",
+			Usage:       "Specifies the number of batches processed concurrently.",
+			Value:       microbatching.DefaultConfig().Workers,
+			Destination: &app.batching.Workers,
+		},
+		cli.BoolFlag{
+			Name:        "quantize",
+			Usage:       "Specifies that the weights of the linear layers are quantized to int8 after loading.",
+			Destination: &app.quantize,
+		},
 	}
 }
 
@@ -119,6 +152,16 @@ func newServerCommandActionFor(app *BertApp) func(c *cli.Context) error {
 			log.Fatalf("error during model loading (%v)\n", err)
 		}
 		fmt.Printf("Config: %+v\n", model.Config)
+		tokenizer, err := bert.LoadTokenizer(modelPath, model.Config, model.Vocabulary)
+		if err != nil {
+			log.Fatalf("error during tokenizer loading (%v)\n", err)
+		}
+		if app.quantize {
+			fmt.Println("Quantizing the linear layers...")
+			if err := linear.QuantizeLayers(model); err != nil {
+				log.Fatal(err)
+			}
+		}
 
 		if !app.tlsDisable {
 			fmt.Printf("TLS Cert path is %s\n", app.tlsCert)
@@ -137,7 +180,11 @@ func newServerCommandActionFor(app *BertApp) func(c *cli.Context) error {
 			return "TLS"
 		}(), app.grpcAddress)
 
-		server := bert.NewServer(model)
+		server := bert.NewServer(model, bert.WithTokenizer(tokenizer), bert.WithBatching(app.batching))
+		utils.ExitOnSignal(func() {
+			server.Close()
+			embeddings.Close()
+		})
 		server.StartDefaultServer(app.address, app.grpcAddress, app.tlsCert, app.tlsKey, app.tlsDisable)
 
 		return nil
@@ -0,0 +1,49 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"github.com/urfave/cli"
+)
+
+const (
+	programName = "gpt2"
+)
+
+// GPT2App contains everything needed to run the GPT-2 text generation demo.
+type GPT2App struct {
+	*cli.App
+	model    string
+	repo     string
+	text     string
+	generate generateOptions
+}
+
+// generateOptions are the decoding options of the generate command.
+type generateOptions struct {
+	maxLength          int
+	minLength          int
+	numBeams           int
+	numReturnSequences int
+	doSample           bool
+	temperature        float64
+	topK               int
+	topP               float64
+	noRepeatNGramSize  int
+}
+
+// NewGPT2App returns a new GPT2App object.
+func NewGPT2App() *GPT2App {
+	app := &GPT2App{
+		App: cli.NewApp(),
+	}
+	app.Name = programName
+	app.HelpName = programName
+	app.Usage = "A demo for text generation based on GPT-2."
+	app.Commands = []cli.Command{
+		newGenerateCommandFor(app),
+	}
+	return app
+}
@@ -0,0 +1,225 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/bpetokenizer"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/generation"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/gpt2"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/huggingface"
+	"github.com/urfave/cli"
+)
+
+func newGenerateCommandFor(app *GPT2App) cli.Command {
+	return cli.Command{
+		Name:  "generate",
+		Usage: "Continue a text using GPT-2.",
+		UsageText: programName + " generate --model=<name> [--repo=<path>] [--text=<value>] [--max-length=<value>]" +
+			" [--min-length=<value>] [--num-beams=<value>] [--num-return-sequences=<value>] [--do-sample]" +
+			" [--temperature=<value>] [--top-k=<value>] [--top-p=<value>] [--no-repeat-ngram-size=<value>]",
+		Description: "Run the " + programName + " text generation indicating the model name. " +
+			"The model is downloaded from the Hugging Face models hub and converted, if not found locally. " +
+			"The decoding options left unset take the default values of the model.",
+		Flags:  newGenerateCommandFlagsFor(app),
+		Action: newGenerateCommandActionFor(app),
+	}
+}
+
+func newGenerateCommandFlagsFor(app *GPT2App) []cli.Flag {
+	usr, err := user.Current()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:        "repo",
+			Usage:       "Specifies the path to the models.",
+			EnvVar:      "SPAGO_REPO",
+			Value:       path.Join(usr.HomeDir, ".spago"),
+			Destination: &app.repo,
+		},
+		cli.StringFlag{
+			Name:        "model, m",
+			Required:    true,
+			EnvVar:      "SPAGO_MODEL",
+			Usage:       "Specifies the model name.",
+			Destination: &app.model,
+		},
+		cli.StringFlag{
+			Name:        "text",
+			Usage:       "text to continue (if empty, the generation starts from the BOS token)",
+			Destination: &app.text,
+		},
+		cli.IntFlag{
+			Name:        "max-length",
+			Usage:       "maximum length of the generated sequences, text included",
+			Destination: &app.generate.maxLength,
+		},
+		cli.IntFlag{
+			Name:        "min-length",
+			Usage:       "minimum length of the generated sequences, text included",
+			Destination: &app.generate.minLength,
+		},
+		cli.IntFlag{
+			Name:        "num-beams",
+			Usage:       "number of beams for beam search (1 means no beam search)",
+			Destination: &app.generate.numBeams,
+		},
+		cli.IntFlag{
+			Name:        "num-return-sequences",
+			Usage:       "number of sequences to return",
+			Destination: &app.generate.numReturnSequences,
+		},
+		cli.BoolFlag{
+			Name:        "do-sample",
+			Usage:       "use sampling instead of greedy decoding",
+			Destination: &app.generate.doSample,
+		},
+		cli.Float64Flag{
+			Name:        "temperature",
+			Usage:       "temperature of the next token probabilities when sampling",
+			Destination: &app.generate.temperature,
+		},
+		cli.IntFlag{
+			Name:        "top-k",
+			Usage:       "number of the most probable tokens kept when sampling",
+			Destination: &app.generate.topK,
+		},
+		cli.Float64Flag{
+			Name:        "top-p",
+			Usage:       "cumulative probability of the most probable tokens kept when sampling",
+			Destination: &app.generate.topP,
+		},
+		cli.IntFlag{
+			Name:        "no-repeat-ngram-size",
+			Usage:       "size of the n-grams which can occur only once",
+			Destination: &app.generate.noRepeatNGramSize,
+		},
+	}
+}
+
+const defaultModelFile = "spago_model.bin"
+
+func newGenerateCommandActionFor(app *GPT2App) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		modelPath := filepath.Join(app.repo, app.model)
+		if err := app.prepareModel(modelPath); err != nil {
+			return err
+		}
+
+		model, err := gpt2.LoadModel(modelPath)
+		if err != nil {
+			return err
+		}
+		defer model.Close()
+		tokenizer, err := bpetokenizer.NewFromModelFolder(modelPath)
+		if err != nil {
+			return err
+		}
+
+		var inputIDs []int
+		if app.text != "" {
+			encoded, err := tokenizer.Encode(app.text)
+			if err != nil {
+				return err
+			}
+			inputIDs = encoded.IDs
+		}
+
+		g := ag.NewGraph()
+		defer g.Clear()
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*gpt2.Model)
+
+		// The tokens of the first sequence are printed as soon as they are generated,
+		// while the finished sequences are printed at the end.
+		fmt.Print(app.text)
+		stream := func(sequenceIndex, tokenID int) {
+			if sequenceIndex == 0 {
+				fmt.Print(tokenizer.Decode([]int{tokenID}))
+			}
+		}
+		hyps, err := proc.GenerateStream(inputIDs, app.generationConfig(model.Config), stream)
+		if err != nil {
+			return err
+		}
+		fmt.Println()
+
+		for i, hyp := range hyps {
+			fmt.Printf("\n[%d] (score %.4f)\n%s\n", i, hyp.Score, tokenizer.Decode(hyp.TokenIDs))
+		}
+		return nil
+	}
+}
+
+// prepareModel downloads and converts the model, if it is not found locally.
+func (app *GPT2App) prepareModel(modelPath string) error {
+	if _, err := os.Stat(modelPath); os.IsNotExist(err) {
+		fmt.Printf("Unable to find `%s` locally.\n", modelPath)
+		fmt.Printf("Pulling `%s` from Hugging Face models hub...\n", app.model)
+		// make sure the models path exists
+		if _, err := os.Stat(app.repo); os.IsNotExist(err) {
+			if err := os.MkdirAll(app.repo, 0755); err != nil {
+				return err
+			}
+		}
+		err = huggingface.NewDownloader(app.repo, app.model, false).Download()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Converting model...\n")
+		return huggingface.NewConverter(app.repo, app.model).Convert()
+	} else if _, err := os.Stat(path.Join(modelPath, defaultModelFile)); os.IsNotExist(err) {
+		fmt.Printf("Unable to find `%s` in the model directory.\n", defaultModelFile)
+		fmt.Printf("Assuming there is a Hugging Face model to convert...\n")
+		return huggingface.NewConverter(app.repo, app.model).Convert()
+	}
+	return nil
+}
+
+// generationConfig returns the default generation configuration of the model,
+// overridden by the decoding options which are set.
+func (app *GPT2App) generationConfig(config gpt2.Config) generation.Config {
+	c := gpt2.NewGenerationConfig(config)
+	options := app.generate
+	if options.maxLength > 0 {
+		c.MaxLength = options.maxLength
+	}
+	if options.minLength > 0 {
+		c.MinLength = options.minLength
+	}
+	if options.numBeams > 0 {
+		c.NumBeams = options.numBeams
+	}
+	if options.numReturnSequences > 0 {
+		c.NumReturnSequences = options.numReturnSequences
+	}
+	if options.doSample {
+		c.DoSample = true
+	}
+	if options.temperature > 0 {
+		c.Temperature = mat.Float(options.temperature)
+	}
+	if options.topK > 0 {
+		c.TopK = options.topK
+	}
+	if options.topP > 0 {
+		c.TopP = mat.Float(options.topP)
+	}
+	if options.noRepeatNGramSize > 0 {
+		c.NoRepeatNGramSize = options.noRepeatNGramSize
+	}
+	return c
+}
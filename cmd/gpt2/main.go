@@ -0,0 +1,18 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/nlpodyssey/spago/cmd/gpt2/app"
+)
+
+func main() {
+	if err := app.NewGPT2App().Run(os.Args); err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -5,6 +5,7 @@
 package app
 
 import (
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 	"github.com/urfave/cli"
 )
 
@@ -36,12 +37,14 @@ type NERApp struct {
 	text              string
 	mergeEntities     bool
 	filterNonEntities bool
+	batching          microbatching.Config
 }
 
 // NewNERApp returns NerApp objects.
 func NewNERApp() *NERApp {
 	app := &NERApp{
-		App: cli.NewApp(),
+		App:      cli.NewApp(),
+		batching: microbatching.DefaultConfig(),
 	}
 	app.Name = programName
 	app.HelpName = programName
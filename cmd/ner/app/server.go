@@ -6,6 +6,9 @@ package app
 
 import (
 	"fmt"
+	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 	"log"
 	"os"
 	"os/user"
@@ -21,7 +24,7 @@ func newServerCommandFor(app *NERApp) cli.Command {
 	return cli.Command{
 		Name:        "server",
 		Usage:       "Run the " + programName + " as gRPC/HTTP server.",
-		UsageText:   programName + " server --model=<name> [--repo=<path>] [--address=<address>] [--tls-cert-file=<cert>] [--tls-key-file=<key>] [--tls-disable]",
+		UsageText:   programName + " server --model=<name> [--repo=<path>] [--address=<address>] [--tls-cert-file=<cert>] [--tls-key-file=<key>] [--tls-disable] [--max-batch-size=<size>] [--max-batch-wait=<duration>] [--queue-size=<size>] [--batch-workers=<n>]",
 		Description: "You must indicate the directory that contains the spaGO neural models.",
 		Flags:       newServerCommandFlagsFor(app),
 		Action:      newServerCommandActionFor(app),
@@ -76,6 +79,30 @@ func newServerCommandFlagsFor(app *NERApp) []cli.Flag {
 			Usage:       "Specifies that TLS is disabled.",
 			Destination: &app.tlsDisable,
 		},
+		cli.IntFlag{
+			Name:        "max-batch-size",
+			Usage:       "Specifies the maximum number of requests processed together.",
+			Value:       microbatching.DefaultConfig().MaxBatchSize,
+			Destination: &app.batching.MaxBatchSize,
+		},
+		cli.DurationFlag{
+			Name:        "max-batch-wait",
+			Usage:       "Specifies how long a batch waits for further requests before being processed.",
+			Value:       microbatching.DefaultConfig().MaxWait,
+			Destination: &app.batching.MaxWait,
+		},
+		cli.IntFlag{
+			Name:        "queue-size",
+			Usage:       "Specifies the maximum number of pending requests; further requests are rejected.",
+			Value:       microbatching.DefaultConfig().QueueSize,
+			Destination: &app.batching.QueueSize,
+		},
+		cli.IntFlag{
+			Name:        "batch-workers",
+			Usage:       "Specifies the number of batches processed concurrently.",
+			Value:       microbatching.DefaultConfig().Workers,
+			Destination: &app.batching.Workers,
+		},
 	}
 }
 
@@ -130,7 +157,11 @@ func newServerCommandActionFor(app *NERApp) func(c *cli.Context) {
 			return "TLS"
 		}(), app.grpcAddress)
 
-		server := sequencelabeler.NewServer(model)
+		server := sequencelabeler.NewServer(model, sequencelabeler.WithBatching(app.batching))
+		utils.ExitOnSignal(func() {
+			server.Close()
+			embeddings.Close()
+		})
 		server.Start(app.address, app.grpcAddress, app.tlsCert, app.tlsKey, app.tlsDisable)
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	bartapp "github.com/nlpodyssey/spago/cmd/bart/app"
 	bertapp "github.com/nlpodyssey/spago/cmd/bert/app"
+	gpt2app "github.com/nlpodyssey/spago/cmd/gpt2/app"
 	huggingfaceimporterapp "github.com/nlpodyssey/spago/cmd/huggingfaceimporter/app"
 	nerapp "github.com/nlpodyssey/spago/cmd/ner/app"
 	"log"
@@ -24,6 +25,7 @@ The commands are:
 
     bert-server             gRPC/HTTP server for BERT
     bart-server             gRPC/HTTP server for BART
+    gpt2                    text generation with GPT-2
     huggingface-importer    Hugging Face model importing
     ner-server              gRPC/HTTP server for Sequence Labeling
     help                    print this help text and exit
@@ -51,6 +53,8 @@ func main() {
 		err = bertapp.NewBertApp().Run(args)
 	case "bart-server":
 		err = bartapp.NewBartApp().Run(args)
+	case "gpt2":
+		err = gpt2app.NewGPT2App().Run(args)
 	case "huggingface-importer":
 		err = huggingfaceimporterapp.New().Run(args)
 	case "ner-server":
@@ -551,39 +551,20 @@ func (d *Dense) Mul(other Matrix) Matrix {
 				1.0,             // incY
 			)
 		} else {
-			internal.DgemmSerial(
-				false,
-				false,
+			internal.Gemm(
+				false,    // aTrans
+				false,    // bTrans
 				d.rows,   // m
 				b.cols,   // n
 				d.cols,   // k
+				1.0,      // alpha
 				d.data,   // a
 				d.cols,   // lda
 				b.data,   // b
 				b.cols,   // ldb
 				out.data, // c
 				out.cols, // ldc
-				1.0,      // alpha
 			)
-
-			/*
-				// parallel implementation
-				internal.Dgemm(
-					false,    // aTrans
-					false,    // bTrans
-					d.rows,   // m
-					b.cols,   // n
-					d.cols,   // k
-					1.0,      // alpha
-					d.data,   // a
-					d.cols,   // lda
-					b.data,   // b
-					b.cols,   // ldb
-					0.0,      // beta
-					out.data, // c
-					out.cols, // ldc
-				)
-			*/
 		}
 
 		return out
@@ -622,7 +603,20 @@ func (d *Dense) MulT(other Matrix) Matrix {
 				1.0,             // incY
 			)
 		} else {
-			panic("mat32: matrices with not compatible size")
+			internal.Gemm(
+				true,     // aTrans
+				false,    // bTrans
+				d.cols,   // m
+				b.cols,   // n
+				d.rows,   // k
+				1.0,      // alpha
+				d.data,   // a
+				d.cols,   // lda
+				b.data,   // b
+				b.cols,   // ldb
+				out.data, // c
+				out.cols, // ldc
+			)
 		}
 	case *Sparse:
 		panic("mat32: matrices not compatible")
@@ -275,10 +275,25 @@ func TestDense_MulT(t *testing.T) {
 		assert.Panics(t, func() { d.MulT(other) })
 	})
 
-	t.Run("it panics if the other matrix is not a column vector", func(t *testing.T) {
-		d := NewEmptyDense(3, 1)
-		other := NewEmptyDense(3, 2)
-		assert.Panics(t, func() { d.MulT(other) })
+	t.Run("matrix x matrix", func(t *testing.T) {
+		d := NewDense(3, 2, []Float{
+			1, 2,
+			3, 4,
+			5, 6,
+		})
+		other := NewDense(3, 2, []Float{
+			10, 20,
+			30, 40,
+			50, 60,
+		})
+		expected := []Float{
+			350, 440,
+			440, 560,
+		}
+		result := d.MulT(other)
+		assert.Equal(t, 2, result.Rows())
+		assert.Equal(t, 2, result.Columns())
+		assert.Equal(t, expected, result.Data())
 	})
 
 	t.Run("it panics if the other is Sparse", func(t *testing.T) {
@@ -0,0 +1,34 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package f32
+
+const (
+	// GemmMR is the number of rows of the register block computed by GemmKernel.
+	GemmMR = 6
+	// GemmNR is the number of columns of the register block computed by GemmKernel.
+	GemmNR = 16
+)
+
+// gemmKernelGeneric is the pure Go implementation of GemmKernel.
+func gemmKernelGeneric(kc int, alpha float32, a, b, c []float32, ldc int) {
+	var acc [GemmMR * GemmNR]float32
+	a = a[:kc*GemmMR]
+	b = b[:kc*GemmNR]
+	for p := 0; p < kc; p++ {
+		bp := b[p*GemmNR : p*GemmNR+GemmNR]
+		for i, av := range a[p*GemmMR : p*GemmMR+GemmMR] {
+			row := acc[i*GemmNR : i*GemmNR+GemmNR]
+			for j, bv := range bp {
+				row[j] += av * bv
+			}
+		}
+	}
+	for i := 0; i < GemmMR; i++ {
+		ci := c[i*ldc : i*ldc+GemmNR]
+		for j, v := range acc[i*GemmNR : i*GemmNR+GemmNR] {
+			ci[j] += alpha * v
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !noasm,!gccgo,!safe
+
+package f32
+
+import "golang.org/x/sys/cpu"
+
+// useGemmKernelAVX2 reports whether the CPU (and the OS) support the AVX2 and FMA instructions.
+var useGemmKernelAVX2 = cpu.X86.HasAVX2 && cpu.X86.HasFMA
+
+// GemmKernel computes the GemmMR×GemmNR block
+//  C += alpha * A * B
+// where A is a packed panel of kc columns of GemmMR values each, B is a packed
+// panel of kc rows of GemmNR values each, and the block of C starts at c[0]
+// with leading dimension ldc.
+func GemmKernel(kc int, alpha float32, a, b, c []float32, ldc int) {
+	if !useGemmKernelAVX2 || kc == 0 {
+		gemmKernelGeneric(kc, alpha, a, b, c, ldc)
+		return
+	}
+	_ = a[kc*GemmMR-1]
+	_ = b[kc*GemmNR-1]
+	_ = c[(GemmMR-1)*ldc+GemmNR-1]
+	gemmKernel6x16AVX2(kc, alpha, &a[0], &b[0], &c[0], ldc)
+}
+
+// gemmKernel6x16AVX2 is the AVX2/FMA implementation of GemmKernel.
+//go:noescape
+func gemmKernel6x16AVX2(kc int, alpha float32, a, b, c *float32, ldc int)
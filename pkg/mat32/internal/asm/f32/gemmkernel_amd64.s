@@ -0,0 +1,103 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !noasm,!gccgo,!safe
+
+#include "textflag.h"
+
+// func gemmKernel6x16AVX2(kc int, alpha float32, a, b, c *float32, ldc int)
+// The 6×16 block of C is accumulated in Y4-Y15 (two registers per row).
+TEXT ·gemmKernel6x16AVX2(SB), NOSPLIT, $0-48
+	MOVQ kc+0(FP), CX
+	MOVQ a+16(FP), SI
+	MOVQ b+24(FP), DI
+	MOVQ c+32(FP), R8
+	MOVQ ldc+40(FP), DX
+	SHLQ $2, DX
+
+	VXORPS       Y4, Y4, Y4
+	VXORPS       Y5, Y5, Y5
+	VXORPS       Y6, Y6, Y6
+	VXORPS       Y7, Y7, Y7
+	VXORPS       Y8, Y8, Y8
+	VXORPS       Y9, Y9, Y9
+	VXORPS       Y10, Y10, Y10
+	VXORPS       Y11, Y11, Y11
+	VXORPS       Y12, Y12, Y12
+	VXORPS       Y13, Y13, Y13
+	VXORPS       Y14, Y14, Y14
+	VXORPS       Y15, Y15, Y15
+
+loop:
+	VMOVUPS      (DI), Y0
+	VMOVUPS      32(DI), Y1
+	VBROADCASTSS 0(SI), Y2
+	VFMADD231PS  Y0, Y2, Y4
+	VFMADD231PS  Y1, Y2, Y5
+	VBROADCASTSS 4(SI), Y3
+	VFMADD231PS  Y0, Y3, Y6
+	VFMADD231PS  Y1, Y3, Y7
+	VBROADCASTSS 8(SI), Y2
+	VFMADD231PS  Y0, Y2, Y8
+	VFMADD231PS  Y1, Y2, Y9
+	VBROADCASTSS 12(SI), Y3
+	VFMADD231PS  Y0, Y3, Y10
+	VFMADD231PS  Y1, Y3, Y11
+	VBROADCASTSS 16(SI), Y2
+	VFMADD231PS  Y0, Y2, Y12
+	VFMADD231PS  Y1, Y2, Y13
+	VBROADCASTSS 20(SI), Y3
+	VFMADD231PS  Y0, Y3, Y14
+	VFMADD231PS  Y1, Y3, Y15
+
+	ADDQ         $24, SI
+	ADDQ         $64, DI
+	DECQ         CX
+	JNZ          loop
+
+	VBROADCASTSS alpha+8(FP), Y0
+	VMOVUPS      (R8), Y1
+	VFMADD231PS  Y4, Y0, Y1
+	VMOVUPS      Y1, (R8)
+	VMOVUPS      32(R8), Y2
+	VFMADD231PS  Y5, Y0, Y2
+	VMOVUPS      Y2, 32(R8)
+	ADDQ         DX, R8
+	VMOVUPS      (R8), Y1
+	VFMADD231PS  Y6, Y0, Y1
+	VMOVUPS      Y1, (R8)
+	VMOVUPS      32(R8), Y2
+	VFMADD231PS  Y7, Y0, Y2
+	VMOVUPS      Y2, 32(R8)
+	ADDQ         DX, R8
+	VMOVUPS      (R8), Y1
+	VFMADD231PS  Y8, Y0, Y1
+	VMOVUPS      Y1, (R8)
+	VMOVUPS      32(R8), Y2
+	VFMADD231PS  Y9, Y0, Y2
+	VMOVUPS      Y2, 32(R8)
+	ADDQ         DX, R8
+	VMOVUPS      (R8), Y1
+	VFMADD231PS  Y10, Y0, Y1
+	VMOVUPS      Y1, (R8)
+	VMOVUPS      32(R8), Y2
+	VFMADD231PS  Y11, Y0, Y2
+	VMOVUPS      Y2, 32(R8)
+	ADDQ         DX, R8
+	VMOVUPS      (R8), Y1
+	VFMADD231PS  Y12, Y0, Y1
+	VMOVUPS      Y1, (R8)
+	VMOVUPS      32(R8), Y2
+	VFMADD231PS  Y13, Y0, Y2
+	VMOVUPS      Y2, 32(R8)
+	ADDQ         DX, R8
+	VMOVUPS      (R8), Y1
+	VFMADD231PS  Y14, Y0, Y1
+	VMOVUPS      Y1, (R8)
+	VMOVUPS      32(R8), Y2
+	VFMADD231PS  Y15, Y0, Y2
+	VMOVUPS      Y2, 32(R8)
+
+	VZEROUPPER
+	RET
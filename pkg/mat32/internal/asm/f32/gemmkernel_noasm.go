@@ -0,0 +1,16 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !amd64 noasm gccgo safe
+
+package f32
+
+// GemmKernel computes the GemmMR×GemmNR block
+//  C += alpha * A * B
+// where A is a packed panel of kc columns of GemmMR values each, B is a packed
+// panel of kc rows of GemmNR values each, and the block of C starts at c[0]
+// with leading dimension ldc.
+func GemmKernel(kc int, alpha float32, a, b, c []float32, ldc int) {
+	gemmKernelGeneric(kc, alpha, a, b, c, ldc)
+}
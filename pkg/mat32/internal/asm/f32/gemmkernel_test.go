@@ -0,0 +1,39 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package f32
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestGemmKernel(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, kc := range []int{0, 1, 7, 256} {
+		a := make([]float32, kc*GemmMR)
+		b := make([]float32, kc*GemmNR)
+		for i := range a {
+			a[i] = rnd.Float32()*2 - 1
+		}
+		for i := range b {
+			b[i] = rnd.Float32()*2 - 1
+		}
+		const ldc = GemmNR + 3
+		c := make([]float32, GemmMR*ldc)
+		for i := range c {
+			c[i] = rnd.Float32()
+		}
+		expected := append([]float32(nil), c...)
+
+		gemmKernelGeneric(kc, 1.5, a, b, expected, ldc)
+		GemmKernel(kc, 1.5, a, b, c, ldc)
+
+		for i := range c {
+			if diff := c[i] - expected[i]; diff > 1e-4 || diff < -1e-4 {
+				t.Fatalf("kc=%d, element %d: expected %v, found %v", kc, i, expected[i], c[i])
+			}
+		}
+	}
+}
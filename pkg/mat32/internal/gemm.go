@@ -0,0 +1,201 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nlpodyssey/spago/pkg/mat32/internal/asm/f32"
+)
+
+// Blocking parameters of Gemm. The packed kc×gemmNC panel of B is shared by
+// all the workers, each of them computing gemmMC×gemmNB blocks of C out of a
+// gemmMC×kc block of A (meant to stay in L2) and kc×GemmNR slivers of B
+// (meant to stay in L1).
+const (
+	gemmKC = 256
+	gemmMC = 16 * f32.GemmMR
+	gemmNB = 16 * f32.GemmNR
+	gemmNC = 16 * gemmNB
+
+	// gemmMinParallelWork is the minimum number of multiply-add operations
+	// (m×n×k) for which Gemm spreads the work over several goroutines.
+	gemmMinParallelWork = 1 << 18
+	// gemmMinPackedWork is the minimum number of multiply-add operations for
+	// which packing the matrices pays off; below it Gemm uses DgemmSerial.
+	gemmMinPackedWork = 1 << 13
+)
+
+var gemmBufferPool = sync.Pool{
+	New: func() interface{} {
+		s := make([]float32, 0)
+		return &s
+	},
+}
+
+// Gemm performs the matrix-matrix operation
+//  C += alpha * op(A) * op(B)
+// where op(X) is X or Xᵀ according to aTrans and bTrans, op(A) is m×k, op(B)
+// is k×n, and C is m×n.
+//
+// The matrices are packed into contiguous panels, and the product is computed
+// block by block by a register-blocked micro-kernel (AVX2/FMA on amd64 when
+// available, pure Go otherwise). Large products are spread over up to
+// GOMAXPROCS goroutines.
+func Gemm(aTrans, bTrans bool, m, n, k int, alpha float32, a []float32, lda int, b []float32, ldb int, c []float32, ldc int) {
+	if m == 0 || n == 0 || k == 0 || alpha == 0 {
+		return
+	}
+	if m*n*k < gemmMinPackedWork {
+		DgemmSerial(aTrans, bTrans, m, n, k, a, lda, b, ldb, c, ldc, alpha)
+		return
+	}
+	workers := 1
+	if m*n*k >= gemmMinParallelWork {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	mPanels := blocks(m, f32.GemmMR)
+	aPack := getGemmBuffer(mPanels * f32.GemmMR * gemmKC)
+	defer gemmBufferPool.Put(aPack)
+	bPack := getGemmBuffer(blocks(min(n, gemmNC), f32.GemmNR) * f32.GemmNR * gemmKC)
+	defer gemmBufferPool.Put(bPack)
+
+	for pc := 0; pc < k; pc += gemmKC {
+		kc := min(gemmKC, k-pc)
+		parallelFor(mPanels, workers, func(ip int) {
+			packA(aTrans, a, lda, ip*f32.GemmMR, pc, min(f32.GemmMR, m-ip*f32.GemmMR), kc, (*aPack)[ip*f32.GemmMR*kc:])
+		})
+		for jc := 0; jc < n; jc += gemmNC {
+			nc := min(gemmNC, n-jc)
+			nPanels := blocks(nc, f32.GemmNR)
+			parallelFor(nPanels, workers, func(jp int) {
+				packB(bTrans, b, ldb, pc, jc+jp*f32.GemmNR, kc, min(f32.GemmNR, nc-jp*f32.GemmNR), (*bPack)[jp*f32.GemmNR*kc:])
+			})
+			mBlocks, nBlocks := blocks(m, gemmMC), blocks(nc, gemmNB)
+			parallelFor(mBlocks*nBlocks, workers, func(block int) {
+				ic, jb := (block/nBlocks)*gemmMC, (block%nBlocks)*gemmNB
+				gemmBlock(min(gemmMC, m-ic), min(gemmNB, nc-jb), kc, alpha,
+					(*aPack)[ic*kc:], (*bPack)[jb*kc:], c[ic*ldc+jc+jb:], ldc)
+			})
+		}
+	}
+}
+
+// gemmBlock computes the mc×nc block of C from the packed panels of A and B,
+// one GemmMR×GemmNR micro-block at a time.
+func gemmBlock(mc, nc, kc int, alpha float32, aPack, bPack, c []float32, ldc int) {
+	var tmp [f32.GemmMR * f32.GemmNR]float32
+	for jr := 0; jr < nc; jr += f32.GemmNR {
+		nr := min(f32.GemmNR, nc-jr)
+		bPanel := bPack[jr*kc : (jr+f32.GemmNR)*kc]
+		for ir := 0; ir < mc; ir += f32.GemmMR {
+			mr := min(f32.GemmMR, mc-ir)
+			aPanel := aPack[ir*kc : (ir+f32.GemmMR)*kc]
+			if mr == f32.GemmMR && nr == f32.GemmNR {
+				f32.GemmKernel(kc, alpha, aPanel, bPanel, c[ir*ldc+jr:], ldc)
+				continue
+			}
+			// edge micro-block: compute it aside, then add the valid part
+			for i := range tmp {
+				tmp[i] = 0
+			}
+			f32.GemmKernel(kc, alpha, aPanel, bPanel, tmp[:], f32.GemmNR)
+			for i := 0; i < mr; i++ {
+				ci := c[(ir+i)*ldc+jr : (ir+i)*ldc+jr+nr]
+				for j, v := range tmp[i*f32.GemmNR : i*f32.GemmNR+nr] {
+					ci[j] += v
+				}
+			}
+		}
+	}
+}
+
+// packA copies the mr×kc block of op(A) starting at (i, p) into dst, column
+// by column, padding the rows up to GemmMR with zeros.
+func packA(trans bool, a []float32, lda, i, p, mr, kc int, dst []float32) {
+	dst = dst[:kc*f32.GemmMR]
+	for l := 0; l < kc; l++ {
+		col := dst[l*f32.GemmMR : l*f32.GemmMR+f32.GemmMR]
+		for r := 0; r < mr; r++ {
+			if trans {
+				col[r] = a[(p+l)*lda+i+r]
+			} else {
+				col[r] = a[(i+r)*lda+p+l]
+			}
+		}
+		for r := mr; r < f32.GemmMR; r++ {
+			col[r] = 0
+		}
+	}
+}
+
+// packB copies the kc×nr block of op(B) starting at (p, j) into dst, row by
+// row, padding the columns up to GemmNR with zeros.
+func packB(trans bool, b []float32, ldb, p, j, kc, nr int, dst []float32) {
+	dst = dst[:kc*f32.GemmNR]
+	for l := 0; l < kc; l++ {
+		row := dst[l*f32.GemmNR : l*f32.GemmNR+f32.GemmNR]
+		if trans {
+			for c := 0; c < nr; c++ {
+				row[c] = b[(j+c)*ldb+p+l]
+			}
+		} else {
+			copy(row[:nr], b[(p+l)*ldb+j:(p+l)*ldb+j+nr])
+		}
+		for c := nr; c < f32.GemmNR; c++ {
+			row[c] = 0
+		}
+	}
+}
+
+// parallelFor calls fn for each index in [0, n), using up to the given number
+// of goroutines (the calling one included).
+func parallelFor(n, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	var next int64 = -1
+	work := func() {
+		for i := int(atomic.AddInt64(&next, 1)); i < n; i = int(atomic.AddInt64(&next, 1)) {
+			fn(i)
+		}
+	}
+	var wg sync.WaitGroup
+	wg.Add(workers - 1)
+	for w := 1; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			work()
+		}()
+	}
+	work()
+	wg.Wait()
+}
+
+// getGemmBuffer returns a buffer of the given size from the pool.
+func getGemmBuffer(size int) *[]float32 {
+	buf := gemmBufferPool.Get().(*[]float32)
+	if cap(*buf) < size {
+		*buf = make([]float32, size)
+	}
+	*buf = (*buf)[:size]
+	return buf
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
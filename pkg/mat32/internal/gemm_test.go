@@ -0,0 +1,104 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat32/internal"
+)
+
+func TestGemm(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	sizes := [][3]int{
+		{1, 1, 1}, {5, 7, 3}, {6, 16, 20}, {23, 17, 29},
+		{64, 64, 64}, {97, 131, 300}, {200, 3, 513}, {1, 700, 260}, {130, 4200, 9},
+	}
+	for _, size := range sizes {
+		for _, aTrans := range []bool{false, true} {
+			for _, bTrans := range []bool{false, true} {
+				m, n, k := size[0], size[1], size[2]
+				name := fmt.Sprintf("%dx%dx%d aTrans=%v bTrans=%v", m, n, k, aTrans, bTrans)
+				t.Run(name, func(t *testing.T) {
+					a, lda := randomMatrix(rnd, m, k, aTrans)
+					b, ldb := randomMatrix(rnd, k, n, bTrans)
+					c := randomSlice(rnd, m*n)
+					expected := naiveGemm(aTrans, bTrans, m, n, k, 0.5, a, lda, b, ldb, c, n)
+
+					internal.Gemm(aTrans, bTrans, m, n, k, 0.5, a, lda, b, ldb, c, n)
+
+					for i := range c {
+						if diff := c[i] - expected[i]; diff > 1e-3 || diff < -1e-3 {
+							t.Fatalf("element %d: expected %v, found %v", i, expected[i], c[i])
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func BenchmarkGemm(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, size := range []int{64, 256, 768} {
+		x := randomSlice(rnd, size*size)
+		y := randomSlice(rnd, size*size)
+		z := make([]float32, size*size)
+		b.Run(fmt.Sprintf("DgemmSerial-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				internal.DgemmSerial(false, false, size, size, size, x, size, y, size, z, size, 1)
+			}
+		})
+		b.Run(fmt.Sprintf("Gemm-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				internal.Gemm(false, false, size, size, size, 1, x, size, y, size, z, size)
+			}
+		})
+	}
+}
+
+// randomMatrix returns the data of an r×c matrix, stored transposed if trans
+// is true, and its leading dimension.
+func randomMatrix(rnd *rand.Rand, r, c int, trans bool) ([]float32, int) {
+	if trans {
+		return randomSlice(rnd, r*c), r
+	}
+	return randomSlice(rnd, r*c), c
+}
+
+func randomSlice(rnd *rand.Rand, size int) []float32 {
+	s := make([]float32, size)
+	for i := range s {
+		s[i] = rnd.Float32()*2 - 1
+	}
+	return s
+}
+
+func naiveGemm(aTrans, bTrans bool, m, n, k int, alpha float32, a []float32, lda int, b []float32, ldb int, c []float32, ldc int) []float32 {
+	out := append([]float32(nil), c...)
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			var sum float32
+			for l := 0; l < k; l++ {
+				var av, bv float32
+				if aTrans {
+					av = a[l*lda+i]
+				} else {
+					av = a[i*lda+l]
+				}
+				if bTrans {
+					bv = b[j*ldb+l]
+				} else {
+					bv = b[l*ldb+j]
+				}
+				sum += av * bv
+			}
+			out[i*ldc+j] += alpha * sum
+		}
+	}
+	return out
+}
@@ -12,15 +12,18 @@ import (
 // LockedRand is an implementation of rand.Rand that is concurrency-safe.
 // It is just a wrap of the standard rand.Rand with its operations protected by a sync.Mutex.
 type LockedRand struct {
-	lk sync.Mutex
-	r  *rand.Rand
+	lk  sync.Mutex
+	src *rand.PCGSource
+	r   *rand.Rand
 }
 
 // NewLockedRand creates a new LockedRand that implements all Rand functions that is safe
 // for concurrent use.
 func NewLockedRand(seed uint64) *LockedRand {
+	src := rand.NewSource(seed).(*rand.PCGSource)
 	return &LockedRand{
-		r: rand.New(rand.NewSource(seed)),
+		src: src,
+		r:   rand.New(src),
 	}
 }
 
@@ -167,3 +170,24 @@ func (lr *LockedRand) Shuffle(i int, swap func(i int, j int)) {
 	lr.r.Shuffle(i, swap)
 	lr.lk.Unlock()
 }
+
+// MarshalBinary encodes the state of the generator, so that the sequence of
+// pseudo-random numbers can be resumed.
+func (lr *LockedRand) MarshalBinary() ([]byte, error) {
+	lr.lk.Lock()
+	defer lr.lk.Unlock()
+	return lr.src.MarshalBinary()
+}
+
+// UnmarshalBinary restores the state of the generator.
+func (lr *LockedRand) UnmarshalBinary(data []byte) error {
+	lr.lk.Lock()
+	defer lr.lk.Unlock()
+	src := new(rand.PCGSource)
+	if err := src.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	lr.src = src
+	lr.r = rand.New(src)
+	return nil
+}
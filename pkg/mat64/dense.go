@@ -550,39 +550,20 @@ func (d *Dense) Mul(other Matrix) Matrix {
 				1.0,             // incY
 			)
 		} else {
-			f64.DgemmSerial(
-				false,
-				false,
+			f64.Gemm(
+				false,    // aTrans
+				false,    // bTrans
 				d.rows,   // m
 				b.cols,   // n
 				d.cols,   // k
+				1.0,      // alpha
 				d.data,   // a
 				d.cols,   // lda
 				b.data,   // b
 				b.cols,   // ldb
 				out.data, // c
 				out.cols, // ldc
-				1.0,      // alpha
 			)
-
-			/*
-				// parallel implementation
-				f64.Dgemm(
-					false,    // aTrans
-					false,    // bTrans
-					d.rows,   // m
-					b.cols,   // n
-					d.cols,   // k
-					1.0,      // alpha
-					d.data,   // a
-					d.cols,   // lda
-					b.data,   // b
-					b.cols,   // ldb
-					0.0,      // beta
-					out.data, // c
-					out.cols, // ldc
-				)
-			*/
 		}
 
 		return out
@@ -621,7 +602,20 @@ func (d *Dense) MulT(other Matrix) Matrix {
 				1.0,             // incY
 			)
 		} else {
-			panic("mat64: matrices with not compatible size")
+			f64.Gemm(
+				true,     // aTrans
+				false,    // bTrans
+				d.cols,   // m
+				b.cols,   // n
+				d.rows,   // k
+				1.0,      // alpha
+				d.data,   // a
+				d.cols,   // lda
+				b.data,   // b
+				b.cols,   // ldb
+				out.data, // c
+				out.cols, // ldc
+			)
 		}
 	case *Sparse:
 		panic("mat64: matrices not compatible")
@@ -0,0 +1,130 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package f64
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestGemm(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	sizes := [][3]int{
+		{1, 1, 1}, {5, 7, 3}, {6, 16, 20}, {23, 17, 29},
+		{64, 64, 64}, {97, 131, 300}, {200, 3, 513}, {1, 700, 260}, {130, 4200, 9},
+	}
+	for _, size := range sizes {
+		for _, aTrans := range []bool{false, true} {
+			for _, bTrans := range []bool{false, true} {
+				m, n, k := size[0], size[1], size[2]
+				name := fmt.Sprintf("%dx%dx%d aTrans=%v bTrans=%v", m, n, k, aTrans, bTrans)
+				t.Run(name, func(t *testing.T) {
+					a, lda := gemmRandomMatrix(rnd, m, k, aTrans)
+					b, ldb := gemmRandomMatrix(rnd, k, n, bTrans)
+					c := gemmRandomSlice(rnd, m*n)
+					expected := gemmNaive(aTrans, bTrans, m, n, k, 0.5, a, lda, b, ldb, c, n)
+
+					Gemm(aTrans, bTrans, m, n, k, 0.5, a, lda, b, ldb, c, n)
+
+					for i := range c {
+						if diff := c[i] - expected[i]; diff > 1e-3 || diff < -1e-3 {
+							t.Fatalf("element %d: expected %v, found %v", i, expected[i], c[i])
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func BenchmarkGemm(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, size := range []int{64, 256, 768} {
+		x := gemmRandomSlice(rnd, size*size)
+		y := gemmRandomSlice(rnd, size*size)
+		z := make([]float64, size*size)
+		b.Run(fmt.Sprintf("DgemmSerial-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				DgemmSerial(false, false, size, size, size, x, size, y, size, z, size, 1)
+			}
+		})
+		b.Run(fmt.Sprintf("Gemm-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Gemm(false, false, size, size, size, 1, x, size, y, size, z, size)
+			}
+		})
+	}
+}
+
+// gemmRandomMatrix returns the data of an r×c matrix, stored transposed if trans
+// is true, and its leading dimension.
+func gemmRandomMatrix(rnd *rand.Rand, r, c int, trans bool) ([]float64, int) {
+	if trans {
+		return gemmRandomSlice(rnd, r*c), r
+	}
+	return gemmRandomSlice(rnd, r*c), c
+}
+
+func gemmRandomSlice(rnd *rand.Rand, size int) []float64 {
+	s := make([]float64, size)
+	for i := range s {
+		s[i] = rnd.Float64()*2 - 1
+	}
+	return s
+}
+
+func gemmNaive(aTrans, bTrans bool, m, n, k int, alpha float64, a []float64, lda int, b []float64, ldb int, c []float64, ldc int) []float64 {
+	out := append([]float64(nil), c...)
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			var sum float64
+			for l := 0; l < k; l++ {
+				var av, bv float64
+				if aTrans {
+					av = a[l*lda+i]
+				} else {
+					av = a[i*lda+l]
+				}
+				if bTrans {
+					bv = b[j*ldb+l]
+				} else {
+					bv = b[l*ldb+j]
+				}
+				sum += av * bv
+			}
+			out[i*ldc+j] += alpha * sum
+		}
+	}
+	return out
+}
+func TestGemmKernel(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, kc := range []int{0, 1, 7, 256} {
+		a := make([]float64, kc*gemmMR)
+		b := make([]float64, kc*gemmNR)
+		for i := range a {
+			a[i] = rnd.Float64()*2 - 1
+		}
+		for i := range b {
+			b[i] = rnd.Float64()*2 - 1
+		}
+		const ldc = gemmNR + 3
+		c := make([]float64, gemmMR*ldc)
+		for i := range c {
+			c[i] = rnd.Float64()
+		}
+		expected := append([]float64(nil), c...)
+
+		gemmKernelGeneric(kc, 1.5, a, b, expected, ldc)
+		gemmKernel(kc, 1.5, a, b, c, ldc)
+
+		for i := range c {
+			if diff := c[i] - expected[i]; diff > 1e-4 || diff < -1e-4 {
+				t.Fatalf("kc=%d, element %d: expected %v, found %v", kc, i, expected[i], c[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package f64
+
+const (
+	// gemmMR is the number of rows of the register block computed by gemmKernel.
+	gemmMR = 6
+	// gemmNR is the number of columns of the register block computed by gemmKernel.
+	gemmNR = 8
+)
+
+// gemmKernelGeneric is the pure Go implementation of gemmKernel.
+func gemmKernelGeneric(kc int, alpha float64, a, b, c []float64, ldc int) {
+	var acc [gemmMR * gemmNR]float64
+	a = a[:kc*gemmMR]
+	b = b[:kc*gemmNR]
+	for p := 0; p < kc; p++ {
+		bp := b[p*gemmNR : p*gemmNR+gemmNR]
+		for i, av := range a[p*gemmMR : p*gemmMR+gemmMR] {
+			row := acc[i*gemmNR : i*gemmNR+gemmNR]
+			for j, bv := range bp {
+				row[j] += av * bv
+			}
+		}
+	}
+	for i := 0; i < gemmMR; i++ {
+		ci := c[i*ldc : i*ldc+gemmNR]
+		for j, v := range acc[i*gemmNR : i*gemmNR+gemmNR] {
+			ci[j] += alpha * v
+		}
+	}
+}
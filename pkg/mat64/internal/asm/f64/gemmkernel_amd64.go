@@ -0,0 +1,32 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !noasm,!appengine,!safe
+
+package f64
+
+import "golang.org/x/sys/cpu"
+
+// useGemmKernelAVX2 reports whether the CPU (and the OS) support the AVX2 and FMA instructions.
+var useGemmKernelAVX2 = cpu.X86.HasAVX2 && cpu.X86.HasFMA
+
+// gemmKernel computes the gemmMR×gemmNR block
+//  C += alpha * A * B
+// where A is a packed panel of kc columns of gemmMR values each, B is a packed
+// panel of kc rows of gemmNR values each, and the block of C starts at c[0]
+// with leading dimension ldc.
+func gemmKernel(kc int, alpha float64, a, b, c []float64, ldc int) {
+	if !useGemmKernelAVX2 || kc == 0 {
+		gemmKernelGeneric(kc, alpha, a, b, c, ldc)
+		return
+	}
+	_ = a[kc*gemmMR-1]
+	_ = b[kc*gemmNR-1]
+	_ = c[(gemmMR-1)*ldc+gemmNR-1]
+	gemmKernel6x8AVX2(kc, alpha, &a[0], &b[0], &c[0], ldc)
+}
+
+// gemmKernel6x8AVX2 is the AVX2/FMA implementation of gemmKernel.
+//go:noescape
+func gemmKernel6x8AVX2(kc int, alpha float64, a, b, c *float64, ldc int)
@@ -0,0 +1,103 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !noasm,!appengine,!safe
+
+#include "textflag.h"
+
+// func gemmKernel6x8AVX2(kc int, alpha float64, a, b, c *float64, ldc int)
+// The 6×8 block of C is accumulated in Y4-Y15 (two registers per row).
+TEXT ·gemmKernel6x8AVX2(SB), NOSPLIT, $0-48
+	MOVQ kc+0(FP), CX
+	MOVQ a+16(FP), SI
+	MOVQ b+24(FP), DI
+	MOVQ c+32(FP), R8
+	MOVQ ldc+40(FP), DX
+	SHLQ $3, DX
+
+	VXORPD       Y4, Y4, Y4
+	VXORPD       Y5, Y5, Y5
+	VXORPD       Y6, Y6, Y6
+	VXORPD       Y7, Y7, Y7
+	VXORPD       Y8, Y8, Y8
+	VXORPD       Y9, Y9, Y9
+	VXORPD       Y10, Y10, Y10
+	VXORPD       Y11, Y11, Y11
+	VXORPD       Y12, Y12, Y12
+	VXORPD       Y13, Y13, Y13
+	VXORPD       Y14, Y14, Y14
+	VXORPD       Y15, Y15, Y15
+
+loop:
+	VMOVUPD      (DI), Y0
+	VMOVUPD      32(DI), Y1
+	VBROADCASTSD 0(SI), Y2
+	VFMADD231PD  Y0, Y2, Y4
+	VFMADD231PD  Y1, Y2, Y5
+	VBROADCASTSD 8(SI), Y3
+	VFMADD231PD  Y0, Y3, Y6
+	VFMADD231PD  Y1, Y3, Y7
+	VBROADCASTSD 16(SI), Y2
+	VFMADD231PD  Y0, Y2, Y8
+	VFMADD231PD  Y1, Y2, Y9
+	VBROADCASTSD 24(SI), Y3
+	VFMADD231PD  Y0, Y3, Y10
+	VFMADD231PD  Y1, Y3, Y11
+	VBROADCASTSD 32(SI), Y2
+	VFMADD231PD  Y0, Y2, Y12
+	VFMADD231PD  Y1, Y2, Y13
+	VBROADCASTSD 40(SI), Y3
+	VFMADD231PD  Y0, Y3, Y14
+	VFMADD231PD  Y1, Y3, Y15
+
+	ADDQ         $48, SI
+	ADDQ         $64, DI
+	DECQ         CX
+	JNZ          loop
+
+	VBROADCASTSD alpha+8(FP), Y0
+	VMOVUPD      (R8), Y1
+	VFMADD231PD  Y4, Y0, Y1
+	VMOVUPD      Y1, (R8)
+	VMOVUPD      32(R8), Y2
+	VFMADD231PD  Y5, Y0, Y2
+	VMOVUPD      Y2, 32(R8)
+	ADDQ         DX, R8
+	VMOVUPD      (R8), Y1
+	VFMADD231PD  Y6, Y0, Y1
+	VMOVUPD      Y1, (R8)
+	VMOVUPD      32(R8), Y2
+	VFMADD231PD  Y7, Y0, Y2
+	VMOVUPD      Y2, 32(R8)
+	ADDQ         DX, R8
+	VMOVUPD      (R8), Y1
+	VFMADD231PD  Y8, Y0, Y1
+	VMOVUPD      Y1, (R8)
+	VMOVUPD      32(R8), Y2
+	VFMADD231PD  Y9, Y0, Y2
+	VMOVUPD      Y2, 32(R8)
+	ADDQ         DX, R8
+	VMOVUPD      (R8), Y1
+	VFMADD231PD  Y10, Y0, Y1
+	VMOVUPD      Y1, (R8)
+	VMOVUPD      32(R8), Y2
+	VFMADD231PD  Y11, Y0, Y2
+	VMOVUPD      Y2, 32(R8)
+	ADDQ         DX, R8
+	VMOVUPD      (R8), Y1
+	VFMADD231PD  Y12, Y0, Y1
+	VMOVUPD      Y1, (R8)
+	VMOVUPD      32(R8), Y2
+	VFMADD231PD  Y13, Y0, Y2
+	VMOVUPD      Y2, 32(R8)
+	ADDQ         DX, R8
+	VMOVUPD      (R8), Y1
+	VFMADD231PD  Y14, Y0, Y1
+	VMOVUPD      Y1, (R8)
+	VMOVUPD      32(R8), Y2
+	VFMADD231PD  Y15, Y0, Y2
+	VMOVUPD      Y2, 32(R8)
+
+	VZEROUPPER
+	RET
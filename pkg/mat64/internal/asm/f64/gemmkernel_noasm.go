@@ -0,0 +1,16 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !amd64 noasm appengine safe
+
+package f64
+
+// gemmKernel computes the gemmMR×gemmNR block
+//  C += alpha * A * B
+// where A is a packed panel of kc columns of gemmMR values each, B is a packed
+// panel of kc rows of gemmNR values each, and the block of C starts at c[0]
+// with leading dimension ldc.
+func gemmKernel(kc int, alpha float64, a, b, c []float64, ldc int) {
+	gemmKernelGeneric(kc, alpha, a, b, c, ldc)
+}
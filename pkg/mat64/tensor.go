@@ -0,0 +1,617 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mat64
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/nlpodyssey/spago/pkg/mat64/internal/asm/f64"
+)
+
+// Tensor is an N-dimensional array of Float values.
+//
+// The elements are read from a data slice through the shape and the strides
+// of the tensor, so that views, permutations and broadcasting share the data
+// of the original tensor instead of copying it. A tensor with zero dimensions
+// holds a single (scalar) value.
+type Tensor struct {
+	data    []Float
+	shape   []int
+	strides []int
+	offset  int
+}
+
+// NewTensor returns a new tensor of the given shape, populated with a copy of
+// the elements, in row-major order.
+func NewTensor(shape []int, elements []Float) *Tensor {
+	if len(elements) != shapeSize(shape) {
+		panic(fmt.Sprintf("mat64: wrong tensor dimensions. Elements size must be: %d", shapeSize(shape)))
+	}
+	return NewTensorView(shape, append([]Float(nil), elements...))
+}
+
+// NewTensorView returns a new tensor of the given shape, sharing the elements
+// (in row-major order) instead of copying them.
+func NewTensorView(shape []int, elements []Float) *Tensor {
+	if len(elements) != shapeSize(shape) {
+		panic(fmt.Sprintf("mat64: wrong tensor dimensions. Elements size must be: %d", shapeSize(shape)))
+	}
+	for _, size := range shape {
+		if size < 0 {
+			panic("mat64: negative tensor dimension")
+		}
+	}
+	shape = append([]int(nil), shape...)
+	return &Tensor{
+		data:    elements,
+		shape:   shape,
+		strides: contiguousStrides(shape),
+	}
+}
+
+// NewEmptyTensor returns a new tensor of the given shape, initialized with zeros.
+func NewEmptyTensor(shape ...int) *Tensor {
+	return NewTensorView(shape, make([]Float, shapeSize(shape)))
+}
+
+// NewTensorFromMatrix returns a new two-dimensional tensor with the
+// dimensions and a copy of the values of the matrix.
+func NewTensorFromMatrix(m Matrix) *Tensor {
+	r, c := m.Dims()
+	return NewTensor([]int{r, c}, m.Data())
+}
+
+// Shape returns the size of each dimension of the tensor.
+func (t *Tensor) Shape() []int {
+	return append([]int(nil), t.shape...)
+}
+
+// Strides returns, for each dimension, the distance between two consecutive
+// elements in the underlying data. Broadcast dimensions have stride zero.
+func (t *Tensor) Strides() []int {
+	return append([]int(nil), t.strides...)
+}
+
+// NDims returns the number of dimensions of the tensor.
+func (t *Tensor) NDims() int {
+	return len(t.shape)
+}
+
+// Size returns the number of elements of the tensor.
+func (t *Tensor) Size() int {
+	return shapeSize(t.shape)
+}
+
+// At returns the value at the given indices, one for each dimension.
+func (t *Tensor) At(indices ...int) Float {
+	return t.data[t.index(indices)]
+}
+
+// Set sets the value v at the given indices, one for each dimension.
+// Setting a value of a broadcast tensor affects all the positions sharing it.
+func (t *Tensor) Set(v Float, indices ...int) {
+	t.data[t.index(indices)] = v
+}
+
+func (t *Tensor) index(indices []int) int {
+	if len(indices) != len(t.shape) {
+		panic(fmt.Sprintf("mat64: expected %d indices, got %d", len(t.shape), len(indices)))
+	}
+	pos := t.offset
+	for i, index := range indices {
+		if index < 0 || index >= t.shape[i] {
+			panic("mat64: index out of range")
+		}
+		pos += index * t.strides[i]
+	}
+	return pos
+}
+
+// IsContiguous reports whether the elements of the tensor are stored in
+// row-major order, without gaps, in the underlying data.
+func (t *Tensor) IsContiguous() bool {
+	expected := 1
+	for i := len(t.shape) - 1; i >= 0; i-- {
+		if t.shape[i] != 1 && t.strides[i] != expected {
+			return false
+		}
+		expected *= t.shape[i]
+	}
+	return true
+}
+
+// Data returns the elements of the tensor in row-major order. The result
+// shares the underlying data if the tensor is contiguous, otherwise it is a
+// new slice.
+func (t *Tensor) Data() []Float {
+	if t.IsContiguous() {
+		return t.data[t.offset : t.offset+t.Size()]
+	}
+	out := make([]Float, 0, t.Size())
+	t.forEach(func(_ int, v Float) {
+		out = append(out, v)
+	})
+	return out
+}
+
+// Contiguous returns the tensor itself if it is contiguous, otherwise a
+// contiguous copy.
+func (t *Tensor) Contiguous() *Tensor {
+	if t.IsContiguous() {
+		return t
+	}
+	return t.Clone()
+}
+
+// Copy copies the values of the other tensor, broadcast to the shape of the
+// receiver, into the receiver (which can be a view of another tensor).
+func (t *Tensor) Copy(other *Tensor) {
+	src := other.BroadcastTo(t.shape...)
+	t.forEachIndex(func(indices []int, _ Float) {
+		t.data[t.index(indices)] = src.data[src.index(indices)]
+	})
+}
+
+// Clone returns a new contiguous tensor, copying all the values of the receiver.
+func (t *Tensor) Clone() *Tensor {
+	return NewTensor(t.shape, t.Data())
+}
+
+// ToDense returns a new matrix with a copy of the values of the tensor.
+// A scalar becomes a 1×1 matrix, a one-dimensional tensor a column vector,
+// a two-dimensional tensor a matrix with the same dimensions, and a tensor
+// with more dimensions a matrix with the size of the first dimension as rows
+// and all the others flattened (row-major) into the columns.
+func (t *Tensor) ToDense() *Dense {
+	rows, cols := t.MatrixDims()
+	return NewDense(rows, cols, t.Data())
+}
+
+// MatrixDims returns the dimensions of the matrix returned by ToDense.
+func (t *Tensor) MatrixDims() (rows, cols int) {
+	switch len(t.shape) {
+	case 0:
+		return 1, 1
+	case 1:
+		return t.shape[0], 1
+	default:
+		if t.shape[0] == 0 {
+			return 0, shapeSize(t.shape[1:])
+		}
+		return t.shape[0], t.Size() / t.shape[0]
+	}
+}
+
+// Reshape returns a tensor with the same elements (in row-major order) and
+// the given shape. One dimension can be -1, in which case it is inferred from
+// the size of the tensor. The result is a view if the tensor is contiguous,
+// otherwise a copy.
+func (t *Tensor) Reshape(shape ...int) *Tensor {
+	shape = append([]int(nil), shape...)
+	inferred := -1
+	known := 1
+	for i, size := range shape {
+		if size == -1 {
+			if inferred >= 0 {
+				panic("mat64: only one dimension can be inferred")
+			}
+			inferred = i
+			continue
+		}
+		known *= size
+	}
+	if inferred >= 0 {
+		if known == 0 || t.Size()%known != 0 {
+			panic("mat64: incompatible sizes")
+		}
+		shape[inferred] = t.Size() / known
+	}
+	if shapeSize(shape) != t.Size() {
+		panic("mat64: incompatible sizes")
+	}
+	c := t.Contiguous()
+	return NewTensorView(shape, c.data[c.offset:c.offset+c.Size()])
+}
+
+// Permute returns a view of the tensor with the dimensions reordered: the
+// i-th dimension of the result is the dimension axes[i] of the receiver.
+func (t *Tensor) Permute(axes ...int) *Tensor {
+	if len(axes) != len(t.shape) {
+		panic(fmt.Sprintf("mat64: expected %d axes, got %d", len(t.shape), len(axes)))
+	}
+	seen := make([]bool, len(axes))
+	shape := make([]int, len(axes))
+	strides := make([]int, len(axes))
+	for i, axis := range axes {
+		if axis < 0 || axis >= len(axes) || seen[axis] {
+			panic("mat64: invalid permutation")
+		}
+		seen[axis] = true
+		shape[i] = t.shape[axis]
+		strides[i] = t.strides[axis]
+	}
+	return t.view(shape, strides, t.offset)
+}
+
+// Transpose returns a view of the tensor with the two given dimensions swapped.
+func (t *Tensor) Transpose(axis1, axis2 int) *Tensor {
+	axes := make([]int, len(t.shape))
+	for i := range axes {
+		axes[i] = i
+	}
+	axes[axis1], axes[axis2] = axes[axis2], axes[axis1]
+	return t.Permute(axes...)
+}
+
+// Slice returns a view of the tensor restricted to the indices [start, end)
+// of the given dimension.
+func (t *Tensor) Slice(axis, start, end int) *Tensor {
+	t.checkAxis(axis)
+	if start < 0 || end > t.shape[axis] || start > end {
+		panic("mat64: slice out of range")
+	}
+	shape := t.Shape()
+	shape[axis] = end - start
+	return t.view(shape, t.Strides(), t.offset+start*t.strides[axis])
+}
+
+// Select returns a view of the tensor at the given index of a dimension,
+// with one dimension less.
+func (t *Tensor) Select(axis, index int) *Tensor {
+	t.checkAxis(axis)
+	if index < 0 || index >= t.shape[axis] {
+		panic("mat64: index out of range")
+	}
+	shape := append(t.Shape()[:axis], t.shape[axis+1:]...)
+	strides := append(t.Strides()[:axis], t.strides[axis+1:]...)
+	return t.view(shape, strides, t.offset+index*t.strides[axis])
+}
+
+// Unsqueeze returns a view of the tensor with a new dimension of size one
+// inserted at the given position.
+func (t *Tensor) Unsqueeze(axis int) *Tensor {
+	if axis < 0 || axis > len(t.shape) {
+		panic("mat64: axis out of range")
+	}
+	shape := append(append(t.Shape()[:axis:axis], 1), t.shape[axis:]...)
+	strides := append(append(t.Strides()[:axis:axis], 0), t.strides[axis:]...)
+	return t.view(shape, strides, t.offset)
+}
+
+// Squeeze returns a view of the tensor without the given dimension, which
+// must have size one.
+func (t *Tensor) Squeeze(axis int) *Tensor {
+	t.checkAxis(axis)
+	if t.shape[axis] != 1 {
+		panic("mat64: only dimensions of size one can be squeezed")
+	}
+	return t.Select(axis, 0)
+}
+
+// BroadcastTo returns a view of the tensor expanded to the given shape,
+// following the NumPy broadcasting rules: the dimensions are aligned to the
+// right, and each dimension of the tensor must either match the target one
+// or be one. The broadcast dimensions share the same elements.
+func (t *Tensor) BroadcastTo(shape ...int) *Tensor {
+	if len(shape) < len(t.shape) {
+		panic(fmt.Sprintf("mat64: cannot broadcast shape %v to %v", t.shape, shape))
+	}
+	lead := len(shape) - len(t.shape)
+	strides := make([]int, len(shape))
+	for i := lead; i < len(shape); i++ {
+		switch size := t.shape[i-lead]; {
+		case size == shape[i]:
+			strides[i] = t.strides[i-lead]
+		case size == 1:
+			strides[i] = 0
+		default:
+			panic(fmt.Sprintf("mat64: cannot broadcast shape %v to %v", t.shape, shape))
+		}
+	}
+	return t.view(append([]int(nil), shape...), strides, t.offset)
+}
+
+// BroadcastShapes returns the shape resulting from broadcasting the given
+// shapes together, following the NumPy rules.
+func BroadcastShapes(shapes ...[]int) ([]int, error) {
+	n := 0
+	for _, shape := range shapes {
+		if len(shape) > n {
+			n = len(shape)
+		}
+	}
+	out := make([]int, n)
+	for i := range out {
+		out[i] = 1
+	}
+	for _, shape := range shapes {
+		lead := n - len(shape)
+		for i, size := range shape {
+			switch {
+			case size == out[lead+i] || size == 1:
+			case out[lead+i] == 1:
+				out[lead+i] = size
+			default:
+				return nil, fmt.Errorf("mat64: shapes %v are not broadcastable", shapes)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Add returns the element-wise sum of the receiver and the other tensor,
+// broadcasting their shapes.
+func (t *Tensor) Add(other *Tensor) *Tensor {
+	return t.broadcastBinary(other, func(a, b Float) Float { return a + b })
+}
+
+// Sub returns the element-wise difference between the receiver and the
+// other tensor, broadcasting their shapes.
+func (t *Tensor) Sub(other *Tensor) *Tensor {
+	return t.broadcastBinary(other, func(a, b Float) Float { return a - b })
+}
+
+// Prod returns the element-wise product of the receiver and the other tensor,
+// broadcasting their shapes.
+func (t *Tensor) Prod(other *Tensor) *Tensor {
+	return t.broadcastBinary(other, func(a, b Float) Float { return a * b })
+}
+
+// Div returns the element-wise division of the receiver by the other tensor,
+// broadcasting their shapes.
+func (t *Tensor) Div(other *Tensor) *Tensor {
+	return t.broadcastBinary(other, func(a, b Float) Float { return a / b })
+}
+
+// ProdScalar returns a new tensor with the values of the receiver multiplied by n.
+func (t *Tensor) ProdScalar(n Float) *Tensor {
+	return t.Apply(func(v Float) Float { return v * n })
+}
+
+// Apply returns a new contiguous tensor with the results of fn on each value
+// of the receiver.
+func (t *Tensor) Apply(fn func(v Float) Float) *Tensor {
+	out := NewEmptyTensor(t.shape...)
+	t.forEach(func(i int, v Float) {
+		out.data[i] = fn(v)
+	})
+	return out
+}
+
+func (t *Tensor) broadcastBinary(other *Tensor, fn func(a, b Float) Float) *Tensor {
+	shape, err := BroadcastShapes(t.shape, other.shape)
+	if err != nil {
+		panic(err)
+	}
+	out := NewEmptyTensor(shape...)
+	b := other.BroadcastTo(shape...)
+	t.BroadcastTo(shape...).forEach2(b, func(i int, x, y Float) {
+		out.data[i] = fn(x, y)
+	})
+	return out
+}
+
+// Sum returns the sum of the values along the given dimension, which is removed.
+func (t *Tensor) Sum(axis int) *Tensor {
+	t.checkAxis(axis)
+	shape := t.Shape()
+	shape[axis] = 1
+	return t.SumTo(shape...).Squeeze(axis)
+}
+
+// SumTo returns the tensor of the given shape whose values are the sums of
+// the values of the receiver that are broadcast to them. It reverses
+// BroadcastTo, and it is used to reduce the gradients of broadcasting
+// operations.
+func (t *Tensor) SumTo(shape ...int) *Tensor {
+	if len(shape) > len(t.shape) {
+		panic(fmt.Sprintf("mat64: cannot reduce shape %v to %v", t.shape, shape))
+	}
+	out := NewEmptyTensor(shape...)
+	lead := len(t.shape) - len(shape)
+	strides := make([]int, len(t.shape))
+	for i, size := range shape {
+		switch {
+		case size == t.shape[lead+i]:
+			strides[lead+i] = out.strides[i]
+		case size == 1:
+			strides[lead+i] = 0
+		default:
+			panic(fmt.Sprintf("mat64: cannot reduce shape %v to %v", t.shape, shape))
+		}
+	}
+	// the output is accumulated through a broadcast view of it
+	acc := out.view(t.Shape(), strides, 0)
+	t.forEachIndex(func(indices []int, v Float) {
+		acc.data[acc.index(indices)] += v
+	})
+	return out
+}
+
+// Softmax returns the softmax of the values along the given dimension.
+func (t *Tensor) Softmax(axis int) *Tensor {
+	t.checkAxis(axis)
+	src := t.Contiguous()
+	out := NewEmptyTensor(t.shape...)
+	inner := shapeSize(t.shape[axis+1:])
+	size := t.shape[axis]
+	outer := shapeSize(t.shape[:axis])
+	data := src.data[src.offset : src.offset+src.Size()]
+	for o := 0; o < outer; o++ {
+		for in := 0; in < inner; in++ {
+			base := o*size*inner + in
+			max := Float(math.Inf(-1))
+			for k := 0; k < size; k++ {
+				if v := data[base+k*inner]; v > max {
+					max = v
+				}
+			}
+			var sum Float
+			for k := 0; k < size; k++ {
+				e := Exp(data[base+k*inner] - max)
+				out.data[base+k*inner] = e
+				sum += e
+			}
+			for k := 0; k < size; k++ {
+				out.data[base+k*inner] /= sum
+			}
+		}
+	}
+	return out
+}
+
+// MatMul returns the matrix product of the receiver and the other tensor.
+//
+// Both tensors must have at least two dimensions: the last two are the
+// dimensions of the matrices to multiply, while the leading (batch)
+// dimensions are broadcast, so that e.g. a (batch, seq, hidden) tensor can be
+// multiplied by a (hidden, out) matrix.
+func (t *Tensor) MatMul(other *Tensor) *Tensor {
+	if len(t.shape) < 2 || len(other.shape) < 2 {
+		panic("mat64: MatMul requires tensors with at least two dimensions")
+	}
+	m, k := t.shape[len(t.shape)-2], t.shape[len(t.shape)-1]
+	k2, n := other.shape[len(other.shape)-2], other.shape[len(other.shape)-1]
+	if k != k2 {
+		panic("mat64: matrices with not compatible size")
+	}
+	batch, err := BroadcastShapes(t.shape[:len(t.shape)-2], other.shape[:len(other.shape)-2])
+	if err != nil {
+		panic(err)
+	}
+	a := t.BroadcastTo(append(append([]int(nil), batch...), m, k)...)
+	b := other.BroadcastTo(append(append([]int(nil), batch...), k, n)...)
+	out := NewEmptyTensor(append(append([]int(nil), batch...), m, n)...)
+
+	aBuf := make([]Float, m*k)
+	bBuf := make([]Float, k*n)
+	indices := make([]int, len(batch))
+	for i := 0; i < shapeSize(batch); i++ {
+		a.selectBatch(indices).copyTo(aBuf)
+		b.selectBatch(indices).copyTo(bBuf)
+		f64.Gemm(false, false, m, n, k, 1, aBuf, k, bBuf, n, out.data[i*m*n:(i+1)*m*n], n)
+		nextIndex(indices, batch)
+	}
+	return out
+}
+
+// selectBatch returns a view of the two-dimensional matrix at the given
+// indices of the leading dimensions.
+func (t *Tensor) selectBatch(indices []int) *Tensor {
+	offset := t.offset
+	for i, index := range indices {
+		offset += index * t.strides[i]
+	}
+	n := len(t.shape)
+	return t.view(t.shape[n-2:], t.strides[n-2:], offset)
+}
+
+// copyTo copies the values of the tensor, in row-major order, into dst.
+func (t *Tensor) copyTo(dst []Float) {
+	if t.IsContiguous() {
+		copy(dst, t.data[t.offset:t.offset+t.Size()])
+		return
+	}
+	t.forEach(func(i int, v Float) {
+		dst[i] = v
+	})
+}
+
+// String returns a string representation of the shape and the values of the tensor.
+func (t *Tensor) String() string {
+	return fmt.Sprintf("Tensor%v%v", t.shape, t.Data())
+}
+
+func (t *Tensor) view(shape, strides []int, offset int) *Tensor {
+	return &Tensor{
+		data:    t.data,
+		shape:   shape,
+		strides: strides,
+		offset:  offset,
+	}
+}
+
+func (t *Tensor) checkAxis(axis int) {
+	if axis < 0 || axis >= len(t.shape) {
+		panic("mat64: axis out of range")
+	}
+}
+
+// forEach calls fn for each value of the tensor, in row-major order, along
+// with its position in that order.
+func (t *Tensor) forEach(fn func(i int, v Float)) {
+	t.forEachIndex(func() func([]int, Float) {
+		i := 0
+		return func(_ []int, v Float) {
+			fn(i, v)
+			i++
+		}
+	}())
+}
+
+// forEach2 is like forEach, iterating over two tensors of the same shape at once.
+func (t *Tensor) forEach2(other *Tensor, fn func(i int, a, b Float)) {
+	i := 0
+	t.forEachIndex(func(indices []int, v Float) {
+		fn(i, v, other.data[other.index(indices)])
+		i++
+	})
+}
+
+// forEachIndex calls fn for each value of the tensor, in row-major order,
+// along with its indices.
+func (t *Tensor) forEachIndex(fn func(indices []int, v Float)) {
+	size := t.Size()
+	if size == 0 {
+		return
+	}
+	indices := make([]int, len(t.shape))
+	pos := t.offset
+	for count := 0; count < size; count++ {
+		fn(indices, t.data[pos])
+		// advance the indices like an odometer, updating the position
+		for d := len(indices) - 1; d >= 0; d-- {
+			indices[d]++
+			pos += t.strides[d]
+			if indices[d] < t.shape[d] {
+				break
+			}
+			pos -= indices[d] * t.strides[d]
+			indices[d] = 0
+		}
+	}
+}
+
+// nextIndex advances the indices in row-major order within the given shape.
+func nextIndex(indices, shape []int) {
+	for d := len(indices) - 1; d >= 0; d-- {
+		indices[d]++
+		if indices[d] < shape[d] {
+			return
+		}
+		indices[d] = 0
+	}
+}
+
+// contiguousStrides returns the strides of a row-major tensor of the given shape.
+func contiguousStrides(shape []int) []int {
+	strides := make([]int, len(shape))
+	stride := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		strides[i] = stride
+		stride *= shape[i]
+	}
+	return strides
+}
+
+// shapeSize returns the number of elements of a tensor of the given shape.
+func shapeSize(shape []int) int {
+	size := 1
+	for _, s := range shape {
+		size *= s
+	}
+	return size
+}
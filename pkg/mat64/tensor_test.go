@@ -0,0 +1,193 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mat64
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newRangeTensor(shape ...int) *Tensor {
+	t := NewEmptyTensor(shape...)
+	for i := range t.data {
+		t.data[i] = Float(i)
+	}
+	return t
+}
+
+func TestNewTensor(t *testing.T) {
+	t.Run("simple case", func(t *testing.T) {
+		x := NewTensor([]int{2, 3}, []Float{1, 2, 3, 4, 5, 6})
+		assert.Equal(t, []int{2, 3}, x.Shape())
+		assert.Equal(t, []int{3, 1}, x.Strides())
+		assert.Equal(t, 2, x.NDims())
+		assert.Equal(t, 6, x.Size())
+		assert.Equal(t, Float(6), x.At(1, 2))
+		x.Set(-1, 0, 1)
+		assert.Equal(t, []Float{1, -1, 3, 4, 5, 6}, x.Data())
+	})
+
+	t.Run("scalar", func(t *testing.T) {
+		x := NewTensor(nil, []Float{42})
+		assert.Equal(t, 0, x.NDims())
+		assert.Equal(t, Float(42), x.At())
+	})
+
+	t.Run("it panics if the size is wrong", func(t *testing.T) {
+		assert.Panics(t, func() { NewTensor([]int{2, 3}, []Float{1, 2, 3}) })
+	})
+}
+
+func TestTensor_Views(t *testing.T) {
+	x := newRangeTensor(2, 3, 4)
+
+	t.Run("permute", func(t *testing.T) {
+		y := x.Permute(2, 0, 1)
+		assert.Equal(t, []int{4, 2, 3}, y.Shape())
+		assert.False(t, y.IsContiguous())
+		assert.Equal(t, x.At(1, 2, 3), y.At(3, 1, 2))
+		assert.Equal(t, []Float{0, 4, 8, 12, 16, 20, 1, 5, 9, 13, 17, 21}, y.Data()[:12])
+	})
+
+	t.Run("transpose", func(t *testing.T) {
+		y := x.Transpose(0, 2)
+		assert.Equal(t, []int{4, 3, 2}, y.Shape())
+		assert.Equal(t, x.At(1, 0, 3), y.At(3, 0, 1))
+	})
+
+	t.Run("slice and select", func(t *testing.T) {
+		y := x.Slice(1, 1, 3)
+		assert.Equal(t, []int{2, 2, 4}, y.Shape())
+		assert.Equal(t, Float(16), y.At(1, 0, 0))
+		z := x.Select(2, 1)
+		assert.Equal(t, []int{2, 3}, z.Shape())
+		assert.Equal(t, []Float{1, 5, 9, 13, 17, 21}, z.Data())
+	})
+
+	t.Run("views share the data", func(t *testing.T) {
+		c := x.Clone()
+		c.Permute(1, 0, 2).Set(-1, 2, 1, 3)
+		assert.Equal(t, Float(-1), c.At(1, 2, 3))
+	})
+
+	t.Run("copy into a view", func(t *testing.T) {
+		c := x.Clone()
+		c.Slice(1, 1, 3).Copy(NewTensor([]int{4}, []Float{-1, -2, -3, -4}))
+		assert.Equal(t, []Float{0, 1, 2, 3, -1, -2, -3, -4, -1, -2, -3, -4}, c.Select(0, 0).Data())
+		assert.Equal(t, []Float{12, 13, 14, 15}, c.Select(0, 1).Select(0, 0).Data())
+	})
+
+	t.Run("unsqueeze and squeeze", func(t *testing.T) {
+		y := x.Unsqueeze(1)
+		assert.Equal(t, []int{2, 1, 3, 4}, y.Shape())
+		assert.True(t, y.IsContiguous())
+		assert.Equal(t, []int{2, 3, 4}, y.Squeeze(1).Shape())
+		assert.Panics(t, func() { x.Squeeze(0) })
+	})
+
+	t.Run("reshape", func(t *testing.T) {
+		y := x.Reshape(6, -1)
+		assert.Equal(t, []int{6, 4}, y.Shape())
+		assert.Equal(t, x.Data(), y.Data())
+		z := x.Permute(1, 0, 2).Reshape(3, 8)
+		assert.Equal(t, []Float{0, 1, 2, 3, 12, 13, 14, 15}, z.Select(0, 0).Data())
+		assert.Panics(t, func() { x.Reshape(5, -1) })
+	})
+
+	t.Run("to dense", func(t *testing.T) {
+		d := x.ToDense()
+		assert.Equal(t, 2, d.Rows())
+		assert.Equal(t, 12, d.Columns())
+		assert.Equal(t, x.Data(), d.Data())
+	})
+}
+
+func TestBroadcastShapes(t *testing.T) {
+	shape, err := BroadcastShapes([]int{8, 1, 6, 1}, []int{7, 1, 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{8, 7, 6, 5}, shape)
+
+	_, err = BroadcastShapes([]int{2, 3}, []int{4})
+	assert.Error(t, err)
+}
+
+func TestTensor_BroadcastTo(t *testing.T) {
+	x := NewTensor([]int{3, 1}, []Float{1, 2, 3})
+	y := x.BroadcastTo(2, 3, 4)
+	assert.Equal(t, []int{2, 3, 4}, y.Shape())
+	assert.Equal(t, []int{0, 1, 0}, y.Strides())
+	assert.Equal(t, Float(3), y.At(1, 2, 3))
+	assert.Panics(t, func() { x.BroadcastTo(2, 4) })
+}
+
+func TestTensor_Arithmetic(t *testing.T) {
+	a := NewTensor([]int{2, 3}, []Float{1, 2, 3, 4, 5, 6})
+	b := NewTensor([]int{3}, []Float{10, 20, 30})
+	c := NewTensor([]int{2, 1}, []Float{2, 4})
+
+	assert.Equal(t, []Float{11, 22, 33, 14, 25, 36}, a.Add(b).Data())
+	assert.Equal(t, []Float{-9, -18, -27, -6, -15, -24}, a.Sub(b).Data())
+	assert.Equal(t, []Float{2, 4, 6, 16, 20, 24}, a.Prod(c).Data())
+	assert.Equal(t, []Float{0.5, 1, 1.5, 1, 1.25, 1.5}, a.Div(c).Data())
+	assert.Equal(t, []int{2, 3}, c.Add(b).Shape())
+	assert.Equal(t, []Float{2, 4, 6, 8, 10, 12}, a.ProdScalar(2).Data())
+	assert.Panics(t, func() { a.Add(NewEmptyTensor(2)) })
+}
+
+func TestTensor_Sum(t *testing.T) {
+	x := newRangeTensor(2, 3)
+	assert.Equal(t, []Float{3, 5, 7}, x.Sum(0).Data())
+	assert.Equal(t, []Float{3, 12}, x.Sum(1).Data())
+	assert.Equal(t, []Float{15}, x.SumTo(1).Data())
+
+	y := newRangeTensor(2, 3, 2)
+	s := y.SumTo(3, 1)
+	assert.Equal(t, []int{3, 1}, s.Shape())
+	assert.Equal(t, []Float{0 + 1 + 6 + 7, 2 + 3 + 8 + 9, 4 + 5 + 10 + 11}, s.Data())
+	assert.Panics(t, func() { y.SumTo(2, 1) })
+}
+
+func TestTensor_Softmax(t *testing.T) {
+	x := NewTensor([]int{2, 2}, []Float{1, 2, 3, 3})
+	y := x.Softmax(1)
+	assert.InDeltaSlice(t, []Float{0.268941, 0.731059, 0.5, 0.5}, y.Data(), 1.0e-6)
+	z := x.Softmax(0)
+	assert.InDeltaSlice(t, []Float{0.119203, 0.268941, 0.880797, 0.731059}, z.Data(), 1.0e-6)
+}
+
+func TestTensor_MatMul(t *testing.T) {
+	t.Run("batched", func(t *testing.T) {
+		a := newRangeTensor(2, 2, 3)
+		b := newRangeTensor(2, 3, 2)
+		y := a.MatMul(b)
+		assert.Equal(t, []int{2, 2, 2}, y.Shape())
+		for i := 0; i < 2; i++ {
+			expected := a.Select(0, i).ToDense().Mul(b.Select(0, i).ToDense())
+			assert.InDeltaSlice(t, expected.Data(), y.Select(0, i).Data(), 1.0e-6)
+		}
+	})
+
+	t.Run("broadcast matrix", func(t *testing.T) {
+		a := newRangeTensor(2, 4, 3)
+		w := newRangeTensor(3, 5)
+		y := a.MatMul(w)
+		assert.Equal(t, []int{2, 4, 5}, y.Shape())
+		expected := a.Reshape(8, 3).ToDense().Mul(w.ToDense())
+		assert.InDeltaSlice(t, expected.Data(), y.Data(), 1.0e-6)
+	})
+
+	t.Run("transposed view", func(t *testing.T) {
+		a := newRangeTensor(2, 3, 4)
+		y := a.MatMul(a.Transpose(1, 2))
+		assert.Equal(t, []int{2, 3, 3}, y.Shape())
+		expected := a.Select(0, 1).ToDense().Mul(a.Select(0, 1).ToDense().T())
+		assert.InDeltaSlice(t, expected.Data(), y.Select(0, 1).Data(), 1.0e-6)
+	})
+
+	t.Run("it panics if the sizes are not compatible", func(t *testing.T) {
+		assert.Panics(t, func() { newRangeTensor(2, 3).MatMul(newRangeTensor(2, 3)) })
+		assert.Panics(t, func() { newRangeTensor(3).MatMul(newRangeTensor(3, 1)) })
+	})
+}
@@ -0,0 +1,184 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"sync"
+)
+
+// checkpoint is a segment of the graph whose values are dropped after the
+// forward pass, and recomputed when they are needed again (see Graph.Checkpoint).
+type checkpoint struct {
+	mu sync.Mutex
+	// operators contains the operators of the segment, in order of creation.
+	operators []*operator
+	// outputs contains the IDs of the nodes whose values are kept.
+	outputs map[int]bool
+	// seed of randGen, so that the recomputation draws the same random numbers.
+	seed    uint64
+	randGen *rand.LockedRand
+	// dropped reports whether the values (except the outputs) have been released.
+	dropped bool
+	// pending is the number of operators still to visit during the backward pass.
+	pending int
+}
+
+// Checkpoint calls f and marks the operators created in the meantime as a
+// checkpoint (a.k.a. gradient checkpointing): once f returns, the values of
+// these operators are released, except for the values of the nodes returned by
+// f, and they are recomputed during the backward pass, when they are needed to
+// compute the gradients. The values are released again as soon as the segment
+// has been back-propagated.
+//
+// This trades computation for memory: for example, running each layer of a
+// deep model in a checkpoint, only the values of one layer at a time are kept
+// during the backward pass, in addition to the outputs of the layers.
+//
+// The operators drawing random numbers (e.g. Dropout) use a generator that is
+// reset before each recomputation, so that they repeat the same computation.
+//
+// The values of the nodes of a checkpoint other than its outputs are nil
+// until they are recomputed; if they are used by operators created after the
+// checkpoint, they are recomputed on the spot (and kept).
+// Checkpoint must not be called concurrently with the creation of other
+// nodes of the graph. Nested checkpoints are merged into the outermost one.
+func (g *Graph) Checkpoint(f func() []Node) []Node {
+	g.mu.Lock()
+	if g.curCheckpoint != nil {
+		g.mu.Unlock()
+		return f()
+	}
+	seed := g.randGen.Uint64()
+	c := &checkpoint{
+		seed:    seed,
+		randGen: rand.NewLockedRand(seed),
+	}
+	graphRandGen := g.randGen
+	g.curCheckpoint, g.randGen = c, c.randGen
+	g.mu.Unlock()
+
+	ys := func() []Node {
+		defer func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			g.curCheckpoint, g.randGen = nil, graphRandGen
+		}()
+		return f()
+	}()
+
+	c.outputs = make(map[int]bool, len(ys))
+	for _, y := range ys {
+		c.outputs[y.ID()] = true
+	}
+	if len(c.operators) == 0 {
+		return ys
+	}
+	g.mu.Lock()
+	g.checkpoints = append(g.checkpoints, c)
+	g.mu.Unlock()
+	if g.incrementalForward {
+		c.drop()
+	}
+	return ys
+}
+
+// reseed resets the random generator of the checkpoint.
+func (c *checkpoint) reseed() {
+	c.randGen.Seed(c.seed)
+}
+
+// drop releases the values of the operators, except the outputs.
+func (c *checkpoint) drop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dropped {
+		return
+	}
+	for _, op := range c.operators {
+		if !c.outputs[op.id] {
+			op.graph.releaseValue(op)
+		}
+	}
+	c.dropped = true
+}
+
+// restore recomputes the values of the operators, in order of creation, if
+// they have been dropped. The values that are still there (i.e. the outputs)
+// are not replaced, but their functions are executed all the same, to draw
+// the same random numbers and to restore their internal state.
+func (c *checkpoint) restore() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dropped {
+		return
+	}
+	c.reseed()
+	for _, op := range c.operators {
+		restoreOperands(op)
+		value := op.function.Forward()
+		if op.value == nil {
+			op.value = value
+		} else {
+			mat.ReleaseDense(value.(*mat.Dense))
+		}
+	}
+	c.dropped = false
+}
+
+// visit decrements the number of operators still to visit during the
+// backward pass, and reports whether the segment has been completed.
+func (c *checkpoint) visit() bool {
+	c.pending--
+	return c.pending == 0
+}
+
+// resetCheckpoints prepares the checkpoints for a new backward pass.
+func (g *Graph) resetCheckpoints() {
+	for _, c := range g.checkpoints {
+		c.pending = len(c.operators)
+	}
+}
+
+// dropCheckpoints releases the values of all the checkpoints.
+func (g *Graph) dropCheckpoints() {
+	for _, c := range g.checkpoints {
+		c.drop()
+	}
+}
+
+// restoreValues recomputes the values of the checkpoints the nodes belong to,
+// if the values of the nodes have been dropped.
+func restoreValues(nodes ...Node) {
+	for _, node := range nodes {
+		if op, ok := node.(*operator); ok && op.checkpoint != nil && op.value == nil {
+			op.checkpoint.restore()
+		}
+	}
+}
+
+// restoreOperands recomputes the values of the checkpoints the operands of
+// the operator belong to, except its own checkpoint, if the values of the
+// operands have been dropped.
+func restoreOperands(op *operator) {
+	for _, operand := range op.operands {
+		if o, ok := operand.(*operator); ok && o.checkpoint != nil && o.checkpoint != op.checkpoint && o.value == nil {
+			o.checkpoint.restore()
+		}
+	}
+}
+
+// restoreForBackward recomputes the values required to back-propagate the
+// gradients of the operator, if any.
+func (r *operator) restoreForBackward() {
+	if !r.hasGrad {
+		return
+	}
+	if r.checkpoint != nil {
+		r.checkpoint.restore()
+	}
+	restoreOperands(r)
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// checkpointTestModel computes a loss through three layers (with dropout),
+// each one optionally run in a checkpoint. Without checkpoints, the layers draw
+// the same random numbers anyway.
+func checkpointTestModel(g *Graph, checkpoint bool) (x, w, loss Node, hidden []Node) {
+	x = g.NewVariable(mat.NewVecDense([]mat.Float{0.1, -0.2, 0.3}), true)
+	w = g.NewVariable(mat.NewDense(3, 3, []mat.Float{
+		0.5, -0.1, 0.2,
+		0.3, 0.8, -0.4,
+		-0.6, 0.1, 0.9,
+	}), true)
+	layer := func(h Node) Node {
+		return g.Dropout(g.Tanh(g.Add(g.Mul(w, h), h)), 0.3)
+	}
+	h := x
+	for i := 0; i < 3; i++ {
+		if checkpoint {
+			h = g.Checkpoint(func() []Node { return []Node{layer(h)} })[0]
+		} else {
+			graphRandGen := g.randGen
+			g.randGen = rand.NewLockedRand(g.randGen.Uint64())
+			h = layer(h)
+			g.randGen = graphRandGen
+		}
+		hidden = append(hidden, h)
+	}
+	loss = g.ReduceSum(g.Square(h))
+	return
+}
+
+func TestGraph_Checkpoint(t *testing.T) {
+	for _, concurrency := range []int{1, 4} {
+		g1 := NewGraph(RandSeed(7), ConcurrentComputations(concurrency))
+		x1, w1, loss1, _ := checkpointTestModel(g1, false)
+		g1.Backward(loss1)
+
+		g2 := NewGraph(RandSeed(7), ConcurrentComputations(concurrency))
+		x2, w2, loss2, hidden := checkpointTestModel(g2, true)
+		require.Len(t, g2.checkpoints, 3)
+
+		// only the outputs of the checkpoints are kept
+		for _, op := range g2.checkpoints[0].operators {
+			if op.ID() == hidden[0].ID() {
+				assert.NotNil(t, op.Value())
+			} else {
+				assert.Nil(t, op.Value())
+			}
+		}
+
+		g2.Backward(loss2)
+		for _, c := range g2.checkpoints {
+			assert.True(t, c.dropped)
+		}
+		assert.InDelta(t, loss1.ScalarValue(), loss2.ScalarValue(), 1.0e-6)
+		assert.InDeltaSlice(t, x1.Grad().Data(), x2.Grad().Data(), 1.0e-6)
+		assert.InDeltaSlice(t, w1.Grad().Data(), w2.Grad().Data(), 1.0e-6)
+	}
+}
+
+func TestGraph_Checkpoint_InnerNodeUsedOutside(t *testing.T) {
+	g := NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1, 2}), true)
+	var inner Node
+	y := g.Checkpoint(func() []Node {
+		inner = g.ProdScalar(x, g.Constant(2))
+		return []Node{g.Square(inner)}
+	})[0]
+	assert.Nil(t, inner.Value())
+
+	z := g.ReduceSum(g.Add(y, inner)) // y + 2x = 4x² + 2x
+	assert.Equal(t, mat.Float(26), z.ScalarValue())
+	g.Backward(z)
+	assert.Equal(t, []mat.Float{10, 18}, x.Grad().Data())
+}
+
+func TestGraph_Checkpoint_NonIncrementalForward(t *testing.T) {
+	g1 := NewGraph(RandSeed(7))
+	x1, w1, loss1, _ := checkpointTestModel(g1, false)
+	g1.Backward(loss1)
+
+	g2 := NewGraph(RandSeed(7), IncrementalForward(false))
+	x2, w2, loss2, hidden := checkpointTestModel(g2, true)
+	g2.Forward()
+	assert.NotNil(t, hidden[1].Value())
+	assert.Nil(t, g2.checkpoints[1].operators[0].Value())
+
+	g2.Backward(loss2)
+	assert.InDelta(t, loss1.ScalarValue(), loss2.ScalarValue(), 1.0e-6)
+	assert.InDeltaSlice(t, x1.Grad().Data(), x2.Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, w1.Grad().Data(), w2.Grad().Data(), 1.0e-6)
+}
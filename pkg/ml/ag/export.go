@@ -0,0 +1,230 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Kinds of the exported nodes.
+const (
+	VariableNode = "variable"
+	OperatorNode = "operator"
+	WrapperNode  = "wrapper"
+)
+
+// ExportedNode describes a node of the graph.
+type ExportedNode struct {
+	// ID is the ID of the node in the graph.
+	ID int `json:"id"`
+	// Kind is the kind of the node: VariableNode, OperatorNode or WrapperNode.
+	Kind string `json:"kind"`
+	// Op is the name of the function of an operator (e.g. "Add", "Mul").
+	Op string `json:"op,omitempty"`
+	// Name is the name of the wrapped value, if any (e.g. the name of a parameter).
+	Name string `json:"name,omitempty"`
+	// Operands are the IDs of the operands of an operator.
+	Operands []int `json:"operands,omitempty"`
+	// Shape contains the rows and the columns of the value, if it has been computed.
+	Shape []int `json:"shape,omitempty"`
+	// Height is the length of the longest path from the node to an operator whose
+	// operands are all variables or wrappers (whose height is zero).
+	Height int `json:"height"`
+	// TimeStep is the time-step associated to the node.
+	TimeStep int `json:"time_step"`
+	// RequiresGrad reports whether the node requires gradients.
+	RequiresGrad bool `json:"requires_grad"`
+	// ValueNorm is the Euclidean norm of the value (see ExportNorms).
+	ValueNorm *mat.Float `json:"value_norm,omitempty"`
+	// GradNorm is the Euclidean norm of the gradients (see ExportNorms).
+	GradNorm *mat.Float `json:"grad_norm,omitempty"`
+	// Value contains the values of the node, in row-major order (see ExportValues).
+	Value []mat.Float `json:"value,omitempty"`
+	// Grad contains the gradients of the node, in row-major order (see ExportValues).
+	Grad []mat.Float `json:"grad,omitempty"`
+}
+
+// ExportedGraph describes the nodes of a graph, ordered by ID, for debugging
+// and visualization purposes.
+type ExportedGraph struct {
+	Nodes []ExportedNode `json:"nodes"`
+}
+
+// ExportOption allows to configure the export of a graph.
+type ExportOption func(*exportConfig)
+
+type exportConfig struct {
+	norms  bool
+	values bool
+}
+
+// ExportNorms sets whether to include the norms of the values and of the
+// gradients of the nodes (default false).
+func ExportNorms(value bool) ExportOption {
+	return func(c *exportConfig) {
+		c.norms = value
+	}
+}
+
+// ExportValues sets whether to include the values and the gradients of the
+// nodes (default false). Beware that the result can be very large.
+func ExportValues(value bool) ExportOption {
+	return func(c *exportConfig) {
+		c.values = value
+	}
+}
+
+// Export returns the description of all the nodes of the graph.
+func (g *Graph) Export(opts ...ExportOption) *ExportedGraph {
+	config := exportConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.groupNodesByHeight()
+	height := g.cache.height
+
+	out := &ExportedGraph{Nodes: make([]ExportedNode, len(g.nodes))}
+	for i, node := range g.nodes {
+		en := ExportedNode{
+			ID:           node.ID(),
+			Height:       height[node.ID()],
+			TimeStep:     node.TimeStep(),
+			RequiresGrad: node.RequiresGrad(),
+		}
+		switch n := node.(type) {
+		case *operator:
+			en.Kind = OperatorNode
+			en.Op = functionName(n.function)
+			en.Operands = make([]int, len(n.operands))
+			for j, operand := range n.operands {
+				en.Operands[j] = operand.ID()
+			}
+		case *wrapper:
+			en.Kind = WrapperNode
+			if named, ok := n.GradValue.(interface{ Name() string }); ok {
+				en.Name = named.Name()
+			}
+		default:
+			en.Kind = VariableNode
+		}
+		value, grad := node.Value(), node.Grad()
+		if value != nil {
+			en.Shape = []int{value.Rows(), value.Columns()}
+		}
+		if config.norms {
+			en.ValueNorm = norm(value)
+			en.GradNorm = norm(grad)
+		}
+		if config.values {
+			en.Value = copyData(value)
+			en.Grad = copyData(grad)
+		}
+		out.Nodes[i] = en
+	}
+	return out
+}
+
+// functionName returns the name of the type of the function (e.g. "Add").
+func functionName(f interface{}) string {
+	t := reflect.TypeOf(f)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+func norm(m mat.Matrix) *mat.Float {
+	if m == nil {
+		return nil
+	}
+	var sum mat.Float
+	for _, v := range m.Data() {
+		sum += v * v
+	}
+	n := mat.Sqrt(sum)
+	return &n
+}
+
+func copyData(m mat.Matrix) []mat.Float {
+	if m == nil {
+		return nil
+	}
+	return append([]mat.Float(nil), m.Data()...)
+}
+
+// WriteJSON writes the graph in JSON format.
+func (e *ExportedGraph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(e)
+}
+
+// WriteDOT writes the graph in the DOT language of Graphviz, with an edge from
+// each operand to its operators. Operators are drawn as ellipses, variables and
+// wrappers (e.g. parameters) as boxes; the nodes requiring gradients are filled.
+func (e *ExportedGraph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph {")
+	fmt.Fprintln(bw, "  rankdir=BT;")
+	fmt.Fprintln(bw, "  node [fontname=\"Helvetica\", fontsize=10];")
+	for _, n := range e.Nodes {
+		shape := "box"
+		if n.Kind == OperatorNode {
+			shape = "ellipse"
+		}
+		style := ""
+		if n.RequiresGrad {
+			style = ", style=filled, fillcolor=\"#dae8fc\""
+		}
+		fmt.Fprintf(bw, "  n%d [label=%s, shape=%s%s];\n", n.ID, dotQuote(nodeLabel(n)), shape, style)
+	}
+	for _, n := range e.Nodes {
+		for _, operand := range n.Operands {
+			fmt.Fprintf(bw, "  n%d -> n%d;\n", operand, n.ID)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// nodeLabel returns the text shown in the DOT node.
+func nodeLabel(n ExportedNode) string {
+	title := n.Kind
+	switch {
+	case n.Op != "":
+		title = n.Op
+	case n.Name != "":
+		title = n.Name
+	}
+	lines := []string{fmt.Sprintf("#%d %s", n.ID, title)}
+	if n.Shape != nil {
+		lines = append(lines, fmt.Sprintf("%d×%d", n.Shape[0], n.Shape[1]))
+	}
+	if n.TimeStep != 0 {
+		lines = append(lines, fmt.Sprintf("t=%d", n.TimeStep))
+	}
+	if n.ValueNorm != nil {
+		lines = append(lines, fmt.Sprintf("|v|=%.4g", *n.ValueNorm))
+	}
+	if n.GradNorm != nil {
+		lines = append(lines, fmt.Sprintf("|g|=%.4g", *n.GradNorm))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dotQuote returns the string as a quoted DOT identifier.
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	"bytes"
+	"encoding/json"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newExportTestGraph() *Graph {
+	g := NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{3, 4}), true)
+	w := g.NewVariable(mat.NewDense(2, 2, []mat.Float{1, 0, 0, 1}), false)
+	y := g.Tanh(g.Mul(w, x))
+	g.IncTimeStep()
+	z := g.ReduceSum(g.Add(y, x))
+	g.Backward(z)
+	return g
+}
+
+func TestGraph_Export(t *testing.T) {
+	g := newExportTestGraph()
+
+	t.Run("default", func(t *testing.T) {
+		exported := g.Export()
+		require.Len(t, exported.Nodes, 6)
+		mul := exported.Nodes[2]
+		assert.Equal(t, OperatorNode, mul.Kind)
+		assert.Equal(t, "Mul", mul.Op)
+		assert.Equal(t, []int{1, 0}, mul.Operands)
+		assert.Equal(t, []int{2, 1}, mul.Shape)
+		assert.Equal(t, 0, mul.Height)
+		assert.Equal(t, 3, exported.Nodes[5].Height)
+		assert.True(t, mul.RequiresGrad)
+		assert.Equal(t, VariableNode, exported.Nodes[1].Kind)
+		assert.False(t, exported.Nodes[1].RequiresGrad)
+		assert.Equal(t, 1, exported.Nodes[5].TimeStep)
+		assert.Nil(t, mul.ValueNorm)
+		assert.Nil(t, mul.Value)
+	})
+
+	t.Run("with norms and values", func(t *testing.T) {
+		exported := g.Export(ExportNorms(true), ExportValues(true))
+		x := exported.Nodes[0]
+		assert.InDelta(t, 5.0, *x.ValueNorm, 1.0e-6)
+		assert.NotNil(t, x.GradNorm)
+		assert.Equal(t, []mat.Float{3, 4}, x.Value)
+		assert.Len(t, x.Grad, 2)
+	})
+}
+
+func TestExportedGraph_WriteJSON(t *testing.T) {
+	exported := newExportTestGraph().Export(ExportNorms(true))
+	var buf bytes.Buffer
+	require.NoError(t, exported.WriteJSON(&buf))
+
+	decoded := new(ExportedGraph)
+	require.NoError(t, json.Unmarshal(buf.Bytes(), decoded))
+	assert.Equal(t, exported, decoded)
+}
+
+func TestExportedGraph_WriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, newExportTestGraph().Export().WriteDOT(&buf))
+	dot := buf.String()
+	assert.Contains(t, dot, "digraph {")
+	assert.Contains(t, dot, `n2 [label="#2 Mul\n2×1", shape=ellipse, style=filled`)
+	assert.Contains(t, dot, `n1 [label="#1 variable\n2×2", shape=box];`)
+	assert.Contains(t, dot, "n0 -> n2;")
+	assert.Contains(t, dot, `t=1`)
+}
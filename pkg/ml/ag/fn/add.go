@@ -21,6 +21,8 @@ func NewAdd(x1, x2 Operand) *Add {
 }
 
 // Forward computes the output of the function.
+// The operands are broadcast to the same dimensions, following the NumPy rules
+// for scalars, row vectors and column vectors.
 func (r *Add) Forward() mat.Matrix {
 	x1v := r.x1.Value()
 	x2v := r.x2.Value()
@@ -28,26 +30,19 @@ func (r *Add) Forward() mat.Matrix {
 		x1v = x2v.ZerosLike()
 		defer mat.ReleaseDense(x1v.(*mat.Dense))
 	}
-	if !(mat.SameDims(x1v, x2v) || mat.VectorsOfSameSize(x1v, x2v)) {
-		panic("fn: matrices with not compatible size")
+	if rows, cols := broadcastDims(x1v, x2v); needsBroadcast(x1v, x2v, rows, cols) {
+		return broadcastApply(x1v, x2v, rows, cols, func(a, b mat.Float) mat.Float { return a + b })
 	}
 	return x1v.Add(x2v)
 }
 
 // Backward computes the backward pass.
+// The gradients of broadcast operands are summed along the expanded dimensions.
 func (r *Add) Backward(gy mat.Matrix) {
 	if r.x1.RequiresGrad() {
-		x1v := r.x1.Value()
-		if !(mat.SameDims(x1v, gy) || mat.VectorsOfSameSize(x1v, gy)) {
-			panic("fn: matrices with not compatible size")
-		}
-		r.x1.PropagateGrad(gy)
+		propagateBroadcastGrad(r.x1, gy)
 	}
 	if r.x2.RequiresGrad() {
-		x2v := r.x2.Value()
-		if !(mat.SameDims(x2v, gy) || mat.VectorsOfSameSize(x2v, gy)) {
-			panic("fn: matrices with not compatible size")
-		}
-		r.x2.PropagateGrad(gy)
+		propagateBroadcastGrad(r.x2, gy)
 	}
 }
@@ -32,3 +32,44 @@ func TestAdd_Forward(t *testing.T) {
 	assert.InDeltaSlice(t, []mat.Float{-1.0, 0.5, 0.8, 0.0}, x1.grad.Data(), 1.0e-6)
 	assert.InDeltaSlice(t, []mat.Float{-1.0, 0.5, 0.8, 0.0}, x2.grad.Data(), 1.0e-6)
 }
+
+func TestAdd_Broadcast(t *testing.T) {
+	x1 := &variable{
+		value:        mat.NewDense(2, 3, []mat.Float{1, 2, 3, 4, 5, 6}),
+		requiresGrad: true,
+	}
+
+	t.Run("row vector", func(t *testing.T) {
+		x2 := &variable{
+			value:        mat.NewDense(1, 3, []mat.Float{10, 20, 30}),
+			requiresGrad: true,
+		}
+		f := NewAdd(x1, x2)
+		y := f.Forward()
+		assert.Equal(t, 2, y.Rows())
+		assert.InDeltaSlice(t, []mat.Float{11, 22, 33, 14, 25, 36}, y.Data(), 1.0e-6)
+
+		f.Backward(mat.NewDense(2, 3, []mat.Float{1, 2, 3, 4, 5, 6}))
+		assert.InDeltaSlice(t, []mat.Float{1, 2, 3, 4, 5, 6}, x1.grad.Data(), 1.0e-6)
+		assert.Equal(t, 1, x2.grad.Rows())
+		assert.InDeltaSlice(t, []mat.Float{5, 7, 9}, x2.grad.Data(), 1.0e-6)
+	})
+
+	t.Run("column vector as first operand", func(t *testing.T) {
+		x0 := &variable{
+			value:        mat.NewVecDense([]mat.Float{10, 20}),
+			requiresGrad: true,
+		}
+		f := NewAdd(x0, x1)
+		y := f.Forward()
+		assert.InDeltaSlice(t, []mat.Float{11, 12, 13, 24, 25, 26}, y.Data(), 1.0e-6)
+
+		f.Backward(mat.NewDense(2, 3, []mat.Float{1, 2, 3, 4, 5, 6}))
+		assert.InDeltaSlice(t, []mat.Float{6, 15}, x0.grad.Data(), 1.0e-6)
+	})
+
+	t.Run("it panics if the dimensions are not compatible", func(t *testing.T) {
+		x2 := &variable{value: mat.NewDense(1, 2, []mat.Float{1, 2})}
+		assert.Panics(t, func() { NewAdd(x1, x2).Forward() })
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var _ Function = &AddLayerNorm{}
+
+// AddLayerNorm is a fused operator performing the layer normalization of the
+// element-wise sum of two vectors (e.g. a residual connection).
+// s = x1 + x2
+// y = (s - E\[s\]) / sqrt(VAR\[s\] + eps) * w + b
+// The second operand is optional, so that it can be used as a plain layer normalization.
+type AddLayerNorm struct {
+	x1     Operand
+	x2     Operand // optional
+	w      Operand
+	b      Operand
+	eps    mat.Float
+	xHat   mat.Matrix // normalized input, initialized during the forward pass
+	invStd mat.Float  // initialized during the forward pass
+}
+
+// NewAddLayerNorm returns a new AddLayerNorm Function.
+func NewAddLayerNorm(x1, x2, w, b Operand, eps mat.Float) *AddLayerNorm {
+	return &AddLayerNorm{x1: x1, x2: x2, w: w, b: b, eps: eps}
+}
+
+// NewLayerNorm returns a new AddLayerNorm Function without the second operand.
+func NewLayerNorm(x, w, b Operand, eps mat.Float) *AddLayerNorm {
+	return &AddLayerNorm{x1: x, w: w, b: b, eps: eps}
+}
+
+// Forward computes the output of the function.
+func (r *AddLayerNorm) Forward() mat.Matrix {
+	x1Data := r.x1.Value().Data()
+	n := len(x1Data)
+	if r.w.Value().Size() != n || r.b.Value().Size() != n {
+		panic("fn: matrices with not compatible size")
+	}
+	xHat := mat.GetDenseWorkspace(r.x1.Value().Dims())
+	xHatData := xHat.Data()
+	copy(xHatData, x1Data)
+	if r.x2 != nil {
+		x2Data := r.x2.Value().Data()
+		if len(x2Data) != n {
+			panic("fn: matrices with not compatible size")
+		}
+		for i, v := range x2Data {
+			xHatData[i] += v
+		}
+	}
+	var mean mat.Float = 0.0
+	for _, v := range xHatData {
+		mean += v
+	}
+	mean /= mat.Float(n)
+	var variance mat.Float = 0.0
+	for i, v := range xHatData {
+		d := v - mean
+		xHatData[i] = d
+		variance += d * d
+	}
+	variance /= mat.Float(n)
+	r.invStd = 1.0 / mat.Sqrt(variance+r.eps)
+
+	y := mat.GetDenseWorkspace(xHat.Dims())
+	yData, wData, bData := y.Data(), r.w.Value().Data(), r.b.Value().Data()
+	for i := range xHatData {
+		xHatData[i] *= r.invStd
+		yData[i] = xHatData[i]*wData[i] + bData[i]
+	}
+	if r.requiresGrad() {
+		r.xHat = xHat
+	} else {
+		mat.ReleaseDense(xHat)
+	}
+	return y
+}
+
+func (r *AddLayerNorm) requiresGrad() bool {
+	return r.x1.RequiresGrad() || (r.x2 != nil && r.x2.RequiresGrad()) ||
+		r.w.RequiresGrad() || r.b.RequiresGrad()
+}
+
+// Backward computes the backward pass.
+func (r *AddLayerNorm) Backward(gy mat.Matrix) {
+	if !(mat.SameDims(r.x1.Value(), gy) || mat.VectorsOfSameSize(r.x1.Value(), gy)) {
+		panic("fn: matrices with not compatible size")
+	}
+	gyData, xHatData := gy.Data(), r.xHat.Data()
+	if r.b.RequiresGrad() {
+		r.b.PropagateGrad(gy)
+	}
+	if r.w.RequiresGrad() {
+		gw := mat.GetDenseWorkspace(r.w.Value().Dims())
+		defer mat.ReleaseDense(gw)
+		gwData := gw.Data()
+		for i, v := range gyData {
+			gwData[i] = v * xHatData[i]
+		}
+		r.w.PropagateGrad(gw)
+	}
+	x2RequiresGrad := r.x2 != nil && r.x2.RequiresGrad()
+	if !r.x1.RequiresGrad() && !x2RequiresGrad {
+		return
+	}
+	// gs = invStd * (gxHat - E[gxHat] - xHat * E[gxHat ⊙ xHat]), where gxHat = gy ⊙ w
+	wData := r.w.Value().Data()
+	n := mat.Float(len(gyData))
+	gs := mat.GetDenseWorkspace(r.x1.Value().Dims())
+	defer mat.ReleaseDense(gs)
+	gsData := gs.Data()
+	var meanG, meanGX mat.Float = 0.0, 0.0
+	for i, v := range gyData {
+		gxHat := v * wData[i]
+		gsData[i] = gxHat
+		meanG += gxHat
+		meanGX += gxHat * xHatData[i]
+	}
+	meanG /= n
+	meanGX /= n
+	for i, gxHat := range gsData {
+		gsData[i] = r.invStd * (gxHat - meanG - xHatData[i]*meanGX)
+	}
+	if r.x1.RequiresGrad() {
+		r.x1.PropagateGrad(gs)
+	}
+	if x2RequiresGrad {
+		r.x2.PropagateGrad(gs)
+	}
+}
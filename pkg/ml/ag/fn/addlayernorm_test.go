@@ -0,0 +1,45 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLayerNorm_Forward(t *testing.T) {
+	x := &variable{value: mat.NewVecDense([]mat.Float{0.4, 0.8, -0.7, -0.5}), requiresGrad: true}
+	w := &variable{value: mat.NewVecDense([]mat.Float{0.4, 0.0, -0.3, 0.8}), requiresGrad: true}
+	b := &variable{value: mat.NewVecDense([]mat.Float{0.9, 0.2, -0.9, 0.2}), requiresGrad: true}
+	f := NewLayerNorm(x, w, b, 1e-12)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{1.157863, 0.2, -0.561554, -0.444658}, y.Data(), 1.0e-06)
+
+	f.Backward(mat.NewVecDense([]mat.Float{-1.0, -0.2, 0.4, 0.6}))
+
+	assert.InDeltaSlice(t, []mat.Float{-0.496261, 0.280677, -0.408772, 0.624355}, x.grad.Data(), 1.0e-06)
+	assert.InDeltaSlice(t, []mat.Float{-0.644658, -0.257863, -0.45126, -0.483493}, w.grad.Data(), 1.0e-06)
+	assert.InDeltaSlice(t, []mat.Float{-1.0, -0.2, 0.4, 0.6}, b.grad.Data(), 1.0e-06)
+}
+
+func TestAddLayerNorm_Forward(t *testing.T) {
+	x1 := &variable{value: mat.NewVecDense([]mat.Float{0.1, 0.5, -0.2, -0.5}), requiresGrad: true}
+	x2 := &variable{value: mat.NewVecDense([]mat.Float{0.3, 0.3, -0.5, 0.0}), requiresGrad: true}
+	w := &variable{value: mat.NewVecDense([]mat.Float{0.4, 0.0, -0.3, 0.8}), requiresGrad: false}
+	b := &variable{value: mat.NewVecDense([]mat.Float{0.9, 0.2, -0.9, 0.2}), requiresGrad: false}
+	f := NewAddLayerNorm(x1, x2, w, b, 1e-12)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{1.157863, 0.2, -0.561554, -0.444658}, y.Data(), 1.0e-06)
+
+	f.Backward(mat.NewVecDense([]mat.Float{-1.0, -0.2, 0.4, 0.6}))
+
+	assert.InDeltaSlice(t, []mat.Float{-0.496261, 0.280677, -0.408772, 0.624355}, x1.grad.Data(), 1.0e-06)
+	assert.InDeltaSlice(t, []mat.Float{-0.496261, 0.280677, -0.408772, 0.624355}, x2.grad.Data(), 1.0e-06)
+	assert.Nil(t, w.grad)
+	assert.Nil(t, b.grad)
+}
@@ -18,6 +18,9 @@ type AddScalar struct {
 
 // NewAddScalar returns a new AddScalar Function.
 func NewAddScalar(x1, x2 Operand) *AddScalar {
+	if v := x2.Value(); v != nil && !v.IsScalar() {
+		panic("fn: the second operand must be a scalar")
+	}
 	return &AddScalar{x1: x1, x2: x2}
 }
 
@@ -71,3 +71,8 @@ func TestAddScalar_Forward2(t *testing.T) {
 
 	assert.InDeltaSlice(t, []mat.Float{2.4}, x2.grad.Data(), 1.0e-6)
 }
+
+func TestNewAddScalar_NotScalar(t *testing.T) {
+	x1 := &variable{value: mat.NewVecDense([]mat.Float{1, 2})}
+	assert.Panics(t, func() { NewAddScalar(x1, x1) })
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"sync"
+)
+
+var _ Function = &Affine{}
+
+// Affine is a fused operator to perform an affine transformation, optionally
+// followed by an element-wise activation function.
+// y = f(w (dot) x + b)
+// The bias b is a column vector, which is added to each column of w (dot) x.
+type Affine struct {
+	b  Operand
+	w  Operand
+	x  Operand
+	f  func(i, j int, v mat.Float) mat.Float // activation function (nil for the identity)
+	df func(i, j int, v mat.Float) mat.Float // derivative of the activation function
+	z  mat.Matrix                            // pre-activation, retained only if required by the backward pass
+}
+
+// NewAffine returns a new Affine Function without activation.
+func NewAffine(b, w, x Operand) *Affine {
+	return &Affine{b: b, w: w, x: x}
+}
+
+// NewAffineReLU returns a new Affine Function followed by the ReLU activation.
+func NewAffineReLU(b, w, x Operand) *Affine {
+	return &Affine{b: b, w: w, x: x, f: relu, df: reluDeriv}
+}
+
+// NewAffineGELU returns a new Affine Function followed by the GELU activation.
+func NewAffineGELU(b, w, x Operand) *Affine {
+	return &Affine{b: b, w: w, x: x, f: gelu, df: geluDeriv}
+}
+
+// NewAffineTanh returns a new Affine Function followed by the Tanh activation.
+func NewAffineTanh(b, w, x Operand) *Affine {
+	return &Affine{b: b, w: w, x: x, f: tanh, df: tanhDeriv}
+}
+
+// NewAffineSigmoid returns a new Affine Function followed by the Sigmoid activation.
+func NewAffineSigmoid(b, w, x Operand) *Affine {
+	return &Affine{b: b, w: w, x: x, f: sigmoid, df: sigmoidDeriv}
+}
+
+// Forward computes the output of the function.
+// The activation is applied in place, so that no intermediate matrix is
+// allocated, unless the pre-activation is required by the backward pass.
+func (r *Affine) Forward() mat.Matrix {
+	wv, xv, bv := r.w.Value(), r.x.Value(), r.b.Value()
+	if wv.Columns() != xv.Rows() || bv.Size() != wv.Rows() {
+		panic("fn: matrices with not compatible size")
+	}
+	y := wv.Mul(xv)
+	addBiasInPlace(y, bv)
+	if r.f == nil {
+		return y
+	}
+	if r.requiresGrad() {
+		r.z = y.Clone()
+	}
+	y.Apply(r.f, y)
+	return y
+}
+
+func (r *Affine) requiresGrad() bool {
+	return r.b.RequiresGrad() || r.w.RequiresGrad() || r.x.RequiresGrad()
+}
+
+// addBiasInPlace adds the column vector b to each column of y.
+func addBiasInPlace(y, b mat.Matrix) {
+	yData, bData := y.Data(), b.Data()
+	cols := y.Columns()
+	for i, bi := range bData {
+		row := yData[i*cols : (i+1)*cols]
+		for j := range row {
+			row[j] += bi
+		}
+	}
+}
+
+// Backward computes the backward pass.
+func (r *Affine) Backward(gy mat.Matrix) {
+	if !(r.w.Value().Rows() == gy.Rows() && r.x.Value().Columns() == gy.Columns()) {
+		panic("fn: matrices with not compatible size")
+	}
+	gz := gy
+	if r.f != nil {
+		dz := mat.GetDenseWorkspace(r.z.Dims())
+		defer mat.ReleaseDense(dz)
+		dz.Apply(r.df, r.z)
+		dz.ProdInPlace(gy)
+		gz = dz
+	}
+	var wg sync.WaitGroup
+	if r.b.RequiresGrad() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gb := mat.GetEmptyDenseWorkspace(r.b.Value().Dims())
+			defer mat.ReleaseDense(gb)
+			gzData, gbData := gz.Data(), gb.Data()
+			cols := gz.Columns()
+			for i := range gbData {
+				for _, v := range gzData[i*cols : (i+1)*cols] {
+					gbData[i] += v
+				}
+			}
+			r.b.PropagateGrad(gb)
+		}()
+	}
+	if r.w.RequiresGrad() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			xt := r.x.Value().T()
+			defer mat.ReleaseDense(xt.(*mat.Dense))
+			gw := gz.Mul(xt)
+			defer mat.ReleaseDense(gw.(*mat.Dense))
+			r.w.PropagateGrad(gw)
+		}()
+	}
+	if r.x.RequiresGrad() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gx := r.w.Value().(*mat.Dense).MulT(gz)
+			defer mat.ReleaseDense(gx.(*mat.Dense))
+			r.x.PropagateGrad(gx)
+		}()
+	}
+	wg.Wait()
+}
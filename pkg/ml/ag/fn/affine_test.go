@@ -0,0 +1,73 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newAffineTestOperands() (b, w, x *variable) {
+	b = &variable{value: mat.NewVecDense([]mat.Float{0.1, -0.2}), requiresGrad: true}
+	w = &variable{value: mat.NewDense(2, 3, []mat.Float{
+		0.5, -0.4, 0.3,
+		0.2, 0.8, -0.6,
+	}), requiresGrad: true}
+	x = &variable{value: mat.NewVecDense([]mat.Float{0.7, -0.3, 0.9}), requiresGrad: true}
+	return
+}
+
+func TestAffine_Forward(t *testing.T) {
+	b, w, x := newAffineTestOperands()
+	f := NewAffine(b, w, x)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{0.84, -0.84}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewVecDense([]mat.Float{1.0, -0.5}))
+
+	assert.InDeltaSlice(t, []mat.Float{1.0, -0.5}, b.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{
+		0.7, -0.3, 0.9,
+		-0.35, 0.15, -0.45,
+	}, w.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.4, -0.8, 0.6}, x.grad.Data(), 1.0e-6)
+}
+
+func TestAffine_ForwardMatrix(t *testing.T) {
+	b := &variable{value: mat.NewVecDense([]mat.Float{0.1, -0.2}), requiresGrad: true}
+	w := &variable{value: mat.NewDense(2, 2, []mat.Float{1, 2, 3, 4}), requiresGrad: false}
+	x := &variable{value: mat.NewDense(2, 3, []mat.Float{1, 0, -1, 0, 1, 2}), requiresGrad: false}
+	f := NewAffineReLU(b, w, x)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{1.1, 2.1, 3.1, 2.8, 3.8, 4.8}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(2, 3, []mat.Float{1, 1, 1, 0.5, 0.5, 0.5}))
+
+	assert.InDeltaSlice(t, []mat.Float{3, 1.5}, b.grad.Data(), 1.0e-6)
+}
+
+func TestAffineGELU_Forward(t *testing.T) {
+	b, w, x := newAffineTestOperands()
+	f := NewAffineGELU(b, w, x)
+	y := f.Forward()
+
+	// expected values from the composition of the single functions
+	_, ew, ex := newAffineTestOperands()
+	z := &variable{value: NewMul(ew, ex).Forward().AddInPlace(b.value), requiresGrad: true}
+	gelu := NewGELU(z)
+	assert.InDeltaSlice(t, gelu.Forward().Data(), y.Data(), 1.0e-6)
+
+	gy := mat.NewVecDense([]mat.Float{1.0, -0.5})
+	f.Backward(gy)
+	gelu.Backward(gy)
+	NewMul(ew, ex).Backward(z.grad)
+
+	assert.InDeltaSlice(t, z.grad.Data(), b.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, ew.grad.Data(), w.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, ex.grad.Data(), x.grad.Data(), 1.0e-6)
+}
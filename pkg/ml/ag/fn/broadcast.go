@@ -0,0 +1,105 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+// broadcastDims returns the dimensions of the result of an element-wise
+// operation between a and b, following the NumPy broadcasting rules: each
+// dimension must either match or be one, so that scalars, row vectors and
+// column vectors are expanded to the size of the other operand.
+// Vectors of the same size are operated element-wise, regardless of their
+// orientation, and the result has the dimensions of a.
+// It panics if the dimensions are not compatible.
+func broadcastDims(a, b mat.Matrix) (rows, cols int) {
+	if mat.SameDims(a, b) || mat.VectorsOfSameSize(a, b) {
+		return a.Dims()
+	}
+	ar, ac := a.Dims()
+	br, bc := b.Dims()
+	rows, okRows := broadcastDim(ar, br)
+	cols, okCols := broadcastDim(ac, bc)
+	if !okRows || !okCols {
+		panic(fmt.Sprintf("fn: matrices with not compatible size (%d×%d, %d×%d)", ar, ac, br, bc))
+	}
+	return rows, cols
+}
+
+func broadcastDim(a, b int) (int, bool) {
+	switch {
+	case a == b || b == 1:
+		return a, true
+	case a == 1:
+		return b, true
+	default:
+		return 0, false
+	}
+}
+
+// isBroadcast reports whether m must be expanded to reach the given dimensions.
+func isBroadcast(m mat.Matrix, rows, cols int) bool {
+	return m.Size() != rows*cols
+}
+
+// needsBroadcast reports whether a or b must be expanded to reach the given dimensions.
+func needsBroadcast(a, b mat.Matrix, rows, cols int) bool {
+	return isBroadcast(a, rows, cols) || isBroadcast(b, rows, cols)
+}
+
+// broadcastStrides returns the strides to read m as if it were expanded to the
+// given dimensions: the stride of a broadcast dimension is zero.
+func broadcastStrides(m mat.Matrix, rows, cols int) (rowStride, colStride int) {
+	r, c := m.Dims()
+	if r == rows {
+		rowStride = c
+	}
+	if c == cols {
+		colStride = 1
+	}
+	return
+}
+
+// broadcastApply returns a new matrix with the given dimensions, whose elements are
+// the result of f applied to the elements of a and b expanded to the same dimensions.
+// The operands are read with strided loops, without copying them.
+func broadcastApply(a, b mat.Matrix, rows, cols int, f func(a, b mat.Float) mat.Float) mat.Matrix {
+	aData, bData := a.Data(), b.Data()
+	aRowStride, aColStride := broadcastStrides(a, rows, cols)
+	bRowStride, bColStride := broadcastStrides(b, rows, cols)
+	out := mat.GetDenseWorkspace(rows, cols)
+	outData := out.Data()
+	for i := 0; i < rows; i++ {
+		ai, bi, oi := i*aRowStride, i*bRowStride, i*cols
+		for j := 0; j < cols; j++ {
+			outData[oi+j] = f(aData[ai+j*aColStride], bData[bi+j*bColStride])
+		}
+	}
+	return out
+}
+
+// propagateBroadcastGrad propagates the gradients to the operand, summing
+// them over the dimensions along which the operand value has been broadcast.
+func propagateBroadcastGrad(x Operand, gx mat.Matrix) {
+	rows, cols := gx.Dims()
+	if !isBroadcast(x.Value(), rows, cols) {
+		x.PropagateGrad(gx)
+		return
+	}
+	r, c := x.Value().Dims()
+	rowStride, colStride := broadcastStrides(x.Value(), rows, cols)
+	reduced := mat.GetEmptyDenseWorkspace(r, c)
+	defer mat.ReleaseDense(reduced)
+	gxData, reducedData := gx.Data(), reduced.Data()
+	for i := 0; i < rows; i++ {
+		ri, gi := i*rowStride, i*cols
+		for j := 0; j < cols; j++ {
+			reducedData[ri+j*colStride] += gxData[gi+j]
+		}
+	}
+	x.PropagateGrad(reduced)
+}
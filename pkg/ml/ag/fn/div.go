@@ -22,35 +22,56 @@ func NewDiv(x1, x2 Operand) *Div {
 }
 
 // Forward computes the output of the function.
+// The operands are broadcast to the same dimensions, following the NumPy rules
+// for scalars, row vectors and column vectors.
 func (r *Div) Forward() mat.Matrix {
 	x1v := r.x1.Value()
 	x2v := r.x2.Value()
-	if !(mat.SameDims(x1v, x2v) || mat.VectorsOfSameSize(x1v, x2v)) {
-		panic("fn: matrices with not compatible size")
+	if rows, cols := broadcastDims(x1v, x2v); needsBroadcast(x1v, x2v, rows, cols) {
+		return broadcastApply(x1v, x2v, rows, cols, func(a, b mat.Float) mat.Float { return a / b })
 	}
 	return x1v.Div(x2v)
 }
 
 // Backward computes the backward pass.
+// The gradients of broadcast operands are summed along the expanded dimensions.
 func (r *Div) Backward(gy mat.Matrix) {
 	x1v := r.x1.Value()
 	x2v := r.x2.Value()
-	if !(mat.SameDims(x1v, gy) || mat.VectorsOfSameSize(x1v, gy)) &&
-		!(mat.SameDims(x2v, gy) || mat.VectorsOfSameSize(x2v, gy)) {
-		panic("fn: matrices with not compatible size")
+	rows, cols := broadcastDims(x1v, x2v)
+	if needsBroadcast(x1v, x2v, rows, cols) {
+		r.broadcastBackward(gy, rows, cols)
+		return
 	}
 	if r.x1.RequiresGrad() {
-		gx := gy.Div(r.x2.Value())
+		gx := gy.Div(x2v)
 		defer mat.ReleaseDense(gx.(*mat.Dense))
 		r.x1.PropagateGrad(gx)
 	}
 	if r.x2.RequiresGrad() {
-		x2sq := r.x2.Value().Prod(r.x2.Value())
+		x2sq := x2v.Prod(x2v)
 		defer mat.ReleaseDense(x2sq.(*mat.Dense))
-		gx := r.x1.Value().Prod(gy)
+		gx := x1v.Prod(gy)
 		defer mat.ReleaseDense(gx.(*mat.Dense))
 		gx.ProdScalarInPlace(-1)
 		gx.DivInPlace(x2sq)
 		r.x2.PropagateGrad(gx)
 	}
 }
+
+// broadcastBackward computes the backward pass when the operands are broadcast.
+func (r *Div) broadcastBackward(gy mat.Matrix, rows, cols int) {
+	x2v := r.x2.Value()
+	if r.x1.RequiresGrad() {
+		gx := broadcastApply(gy, x2v, rows, cols, func(g, b mat.Float) mat.Float { return g / b })
+		defer mat.ReleaseDense(gx.(*mat.Dense))
+		propagateBroadcastGrad(r.x1, gx)
+	}
+	if r.x2.RequiresGrad() {
+		gyx1 := broadcastApply(gy, r.x1.Value(), rows, cols, prod)
+		defer mat.ReleaseDense(gyx1.(*mat.Dense))
+		gx := broadcastApply(gyx1, x2v, rows, cols, func(g, b mat.Float) mat.Float { return -g / (b * b) })
+		defer mat.ReleaseDense(gx.(*mat.Dense))
+		propagateBroadcastGrad(r.x2, gx)
+	}
+}
@@ -32,3 +32,21 @@ func TestDiv_Forward(t *testing.T) {
 	assert.InDeltaSlice(t, []mat.Float{-2.5, 1.6666666666666, 1.6, 0}, x1.grad.Data(), 1.0e-6)
 	assert.InDeltaSlice(t, []mat.Float{0.625, -1.11111111111111, -0.96, 0}, x2.grad.Data(), 1.0e-6)
 }
+
+func TestDiv_Broadcast(t *testing.T) {
+	x1 := &variable{
+		value:        mat.NewScalar(2),
+		requiresGrad: true,
+	}
+	x2 := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{1, 2, 4, 8}),
+		requiresGrad: true,
+	}
+	f := NewDiv(x1, x2)
+	y := f.Forward()
+	assert.InDeltaSlice(t, []mat.Float{2, 1, 0.5, 0.25}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(2, 2, []mat.Float{1, 1, 1, 1}))
+	assert.InDeltaSlice(t, []mat.Float{1 + 0.5 + 0.25 + 0.125}, x1.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-2, -0.5, -0.125, -0.03125}, x2.grad.Data(), 1.0e-6)
+}
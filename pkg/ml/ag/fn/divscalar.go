@@ -18,6 +18,9 @@ type DivScalar struct {
 
 // NewDivScalar returns a new DivScalar Function.
 func NewDivScalar(x1, x2 Operand) *DivScalar {
+	if v := x2.Value(); v != nil && !v.IsScalar() {
+		panic("fn: the second operand must be a scalar")
+	}
 	return &DivScalar{x1: x1, x2: x2}
 }
 
@@ -43,6 +43,12 @@ func (r *Dropout) Forward() mat.Matrix {
 	return r.x.Value().Prod(r.mask)
 }
 
+// Mask returns the mask applied by the last forward pass, already scaled by
+// 1/(1-p). It is released by the backward pass.
+func (r *Dropout) Mask() mat.Matrix {
+	return r.mask
+}
+
 // Backward computes the backward pass.
 func (r *Dropout) Backward(gy mat.Matrix) {
 	if !(mat.SameDims(r.x.Value(), gy) || mat.VectorsOfSameSize(r.x.Value(), gy)) {
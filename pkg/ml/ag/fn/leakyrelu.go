@@ -35,10 +35,17 @@ func (r *LeakyReLU) Backward(gy mat.Matrix) {
 		panic("fn: matrices with not compatible size")
 	}
 	if r.x.RequiresGrad() {
-		gx := mat.GetDenseWorkspace(r.x.Value().Dims())
+		gx := r.Derivative()
 		defer mat.ReleaseDense(gx)
-		gx.ApplyWithAlpha(leakyReLUDeriv, r.x.Value(), r.alpha.Value().Scalar())
 		gx.ProdInPlace(gy)
 		r.x.PropagateGrad(gx)
 	}
 }
+
+// Derivative returns the derivative of the function with respect to x,
+// evaluated at the value of x.
+func (r *LeakyReLU) Derivative() *mat.Dense {
+	dx := mat.GetDenseWorkspace(r.x.Value().Dims())
+	dx.ApplyWithAlpha(leakyReLUDeriv, r.x.Value(), r.alpha.Value().Scalar())
+	return dx
+}
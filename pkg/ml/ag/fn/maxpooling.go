@@ -79,3 +79,15 @@ func (r *MaxPooling) Backward(gy mat.Matrix) {
 		r.x.PropagateGrad(gx)
 	}
 }
+
+// Mask returns a matrix with the dimensions of x, whose values are one at the
+// positions of the maximum of each pool, zero elsewhere.
+func (r *MaxPooling) Mask() mat.Matrix {
+	mask := r.x.Value().ZerosLike()
+	for row := range r.argmaxI {
+		for col := range r.argmaxI[row] {
+			mask.Set(r.argmaxI[row][col], r.argmaxJ[row][col], 1)
+		}
+	}
+	return mask
+}
@@ -26,32 +26,47 @@ func NewSquare(x Operand) *Prod {
 	return &Prod{x1: x, x2: x}
 }
 
-// Forward computes the output of the node.
+// Forward computes the output of the function.
+// The operands are broadcast to the same dimensions, following the NumPy rules
+// for scalars, row vectors and column vectors.
 func (r *Prod) Forward() mat.Matrix {
 	x1v := r.x1.Value()
 	x2v := r.x2.Value()
-	if !(mat.SameDims(x1v, x2v) || mat.VectorsOfSameSize(x1v, x2v)) {
-		panic("fn: matrices with not compatible size")
+	if rows, cols := broadcastDims(x1v, x2v); needsBroadcast(x1v, x2v, rows, cols) {
+		return broadcastApply(x1v, x2v, rows, cols, prod)
 	}
 	return x1v.Prod(x2v)
 }
 
 // Backward computes the backward pass.
+// The gradients of broadcast operands are summed along the expanded dimensions.
 func (r *Prod) Backward(gy mat.Matrix) {
 	x1v := r.x1.Value()
 	x2v := r.x2.Value()
-	if !(mat.SameDims(x1v, gy) || mat.VectorsOfSameSize(x1v, gy)) &&
-		!(mat.SameDims(x2v, gy) || mat.VectorsOfSameSize(x2v, gy)) {
-		panic("fn: matrices with not compatible size")
-	}
+	rows, cols := broadcastDims(x1v, x2v)
+	broadcast := needsBroadcast(x1v, x2v, rows, cols)
 	if r.x1.RequiresGrad() {
-		gx := r.x2.Value().Prod(gy)
+		var gx mat.Matrix
+		if broadcast {
+			gx = broadcastApply(x2v, gy, rows, cols, prod)
+		} else {
+			gx = x2v.Prod(gy)
+		}
 		defer mat.ReleaseDense(gx.(*mat.Dense))
-		r.x1.PropagateGrad(gx)
+		propagateBroadcastGrad(r.x1, gx)
 	}
 	if r.x2.RequiresGrad() {
-		gx := r.x1.Value().Prod(gy)
+		var gx mat.Matrix
+		if broadcast {
+			gx = broadcastApply(x1v, gy, rows, cols, prod)
+		} else {
+			gx = x1v.Prod(gy)
+		}
 		defer mat.ReleaseDense(gx.(*mat.Dense))
-		r.x2.PropagateGrad(gx)
+		propagateBroadcastGrad(r.x2, gx)
 	}
 }
+
+func prod(a, b mat.Float) mat.Float {
+	return a * b
+}
@@ -32,3 +32,21 @@ func TestProd_Forward(t *testing.T) {
 	assert.InDeltaSlice(t, []mat.Float{-0.4, 0.15, 0.4, 0}, x1.grad.Data(), 1.0e-6)
 	assert.InDeltaSlice(t, []mat.Float{-0.1, 0.1, 0.24, 0}, x2.grad.Data(), 1.0e-6)
 }
+
+func TestProd_Broadcast(t *testing.T) {
+	x1 := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{1, 2, 3, 4}),
+		requiresGrad: true,
+	}
+	x2 := &variable{
+		value:        mat.NewDense(1, 2, []mat.Float{10, 100}),
+		requiresGrad: true,
+	}
+	f := NewProd(x1, x2)
+	y := f.Forward()
+	assert.InDeltaSlice(t, []mat.Float{10, 200, 30, 400}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(2, 2, []mat.Float{1, 1, 1, 1}))
+	assert.InDeltaSlice(t, []mat.Float{10, 100, 10, 100}, x1.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{4, 6}, x2.grad.Data(), 1.0e-6)
+}
@@ -18,6 +18,9 @@ type ProdScalar struct {
 
 // NewProdScalar returns a new ProdScalar Function.
 func NewProdScalar(x1, x2 Operand) *ProdScalar {
+	if v := x2.Value(); v != nil && !v.IsScalar() {
+		panic("fn: the second operand must be a scalar")
+	}
 	return &ProdScalar{x1: x1, x2: x2}
 }
 
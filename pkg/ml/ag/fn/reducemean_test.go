@@ -25,3 +25,20 @@ func TestReduceMean_Forward(t *testing.T) {
 
 	assert.InDeltaSlice(t, []mat.Float{0.125, 0.125, 0.125, 0.125}, x.grad.Data(), 1.0e-6)
 }
+
+func TestReduceMean_Matrix(t *testing.T) {
+	x := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{0.1, 0.2, 0.3, 0.0}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewReduceMean(x)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{0.15}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewVecDense([]mat.Float{0.5}))
+
+	assert.True(t, mat.SameDims(x.value, x.grad))
+	assert.InDeltaSlice(t, []mat.Float{0.125, 0.125, 0.125, 0.125}, x.grad.Data(), 1.0e-6)
+}
@@ -31,7 +31,7 @@ func (r *ReduceSum) Backward(gy mat.Matrix) {
 		panic("fn: the gradient had to be a scalar")
 	}
 	if r.x.RequiresGrad() {
-		gx := mat.NewInitVecDense(r.x.Value().Size(), gy.Scalar())
+		gx := mat.NewInitDense(r.x.Value().Rows(), r.x.Value().Columns(), gy.Scalar())
 		defer mat.ReleaseDense(gx)
 		r.x.PropagateGrad(gx)
 	}
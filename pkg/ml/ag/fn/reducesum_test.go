@@ -26,3 +26,21 @@ func TestReduceSum_Forward(t *testing.T) {
 
 	assert.InDeltaSlice(t, []mat.Float{0.5, 0.5, 0.5, 0.5}, x.grad.Data(), 1.0e-6)
 }
+
+func TestReduceSum_Matrix(t *testing.T) {
+	x := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{0.1, 0.2, 0.3, 0.0}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+
+	f := NewReduceSum(x)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{0.6}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewVecDense([]mat.Float{0.5}))
+
+	assert.True(t, mat.SameDims(x.value, x.grad))
+	assert.InDeltaSlice(t, []mat.Float{0.5, 0.5, 0.5, 0.5}, x.grad.Data(), 1.0e-6)
+}
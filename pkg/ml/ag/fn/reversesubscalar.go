@@ -16,6 +16,9 @@ type ReverseSubScalar struct {
 
 // NewReverseSubScalar returns a new ReverseSubScalar Function.
 func NewReverseSubScalar(x1, x2 Operand) *ReverseSubScalar {
+	if v := x2.Value(); v != nil && !v.IsScalar() {
+		panic("fn: the second operand must be a scalar")
+	}
 	return &ReverseSubScalar{x1: x1, x2: x2}
 }
 
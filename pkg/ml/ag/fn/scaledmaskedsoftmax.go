@@ -0,0 +1,82 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var _ Function = &ScaledMaskedSoftmax{}
+
+// ScaledMaskedSoftmax is a fused operator computing the softmax of the scaled
+// input vector, after adding an optional mask (e.g. -inf for the positions
+// which must be excluded, as in the attention scores).
+// y = softmax(x * scale + mask)
+type ScaledMaskedSoftmax struct {
+	x     Operand
+	scale mat.Float
+	mask  mat.Matrix // optional
+	y     mat.Matrix // initialized during the forward pass (required by the backward pass)
+}
+
+// NewScaledMaskedSoftmax returns a new ScaledMaskedSoftmax Function.
+// The mask can be nil.
+func NewScaledMaskedSoftmax(x Operand, scale mat.Float, mask mat.Matrix) *ScaledMaskedSoftmax {
+	return &ScaledMaskedSoftmax{x: x, scale: scale, mask: mask}
+}
+
+// Forward computes the output of this function.
+func (r *ScaledMaskedSoftmax) Forward() mat.Matrix {
+	xData := r.x.Value().Data()
+	if r.mask != nil && r.mask.Size() != len(xData) {
+		panic("fn: matrices with not compatible size")
+	}
+	y := mat.GetEmptyDenseWorkspace(len(xData), 1)
+	yData := y.Data()
+	maximum := mat.Inf(-1)
+	for i, v := range xData {
+		v *= r.scale
+		if r.mask != nil {
+			v += r.mask.AtVec(i)
+		}
+		yData[i] = v
+		if v > maximum {
+			maximum = v
+		}
+	}
+	var sum mat.Float = 0.0
+	for i, v := range yData {
+		e := mat.Exp(v - maximum)
+		yData[i] = e
+		sum += e
+	}
+	for i := range yData {
+		yData[i] /= sum
+	}
+	r.y = y
+	return y
+}
+
+// Backward computes the backward pass.
+// gx = scale * y ⊙ (gy - (y (dot) gy))
+func (r *ScaledMaskedSoftmax) Backward(gy mat.Matrix) {
+	if !(mat.SameDims(r.x.Value(), gy) || mat.VectorsOfSameSize(r.x.Value(), gy)) {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		yData, gyData := r.y.Data(), gy.Data()
+		var dot mat.Float = 0.0
+		for i, v := range yData {
+			dot += v * gyData[i]
+		}
+		gx := mat.GetEmptyDenseWorkspace(r.x.Value().Dims())
+		defer mat.ReleaseDense(gx)
+		gxData := gx.Data()
+		for i, v := range yData {
+			gxData[i] = r.scale * v * (gyData[i] - dot)
+		}
+		r.x.PropagateGrad(gx)
+	}
+}
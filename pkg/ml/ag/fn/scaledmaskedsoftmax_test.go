@@ -0,0 +1,52 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestScaledMaskedSoftmax_Forward(t *testing.T) {
+	x := &variable{
+		value:        mat.NewVecDense([]mat.Float{-0.41, -1.08, 0, 0.87, -0.19, -0.75}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewScaledMaskedSoftmax(x, 1.0, nil)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{0.1166451, 0.0596882, 0.1757629, 0.4195304, 0.1453487, 0.083024}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewVecDense([]mat.Float{0.0, 0.0, -5.689482, 0.0, 0.0, 0.0}))
+
+	assert.InDeltaSlice(t, []mat.Float{0.1166451, 0.0596882, -0.8242370, 0.4195304, 0.1453487, 0.083024}, x.grad.Data(), 1.0e-6)
+}
+
+func TestScaledMaskedSoftmax_ForwardWithMask(t *testing.T) {
+	x := &variable{
+		value:        mat.NewVecDense([]mat.Float{0.8, -0.2, 0.5, 1.2}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	mask := mat.NewVecDense([]mat.Float{0, 0, mat.Inf(-1), 0})
+	f := NewScaledMaskedSoftmax(x, 0.5, mask)
+	y := f.Forward()
+
+	// expected values from the softmax of the scaled and masked input
+	z := &variable{
+		value:        mat.NewVecDense([]mat.Float{0.4, -0.1, mat.Inf(-1), 0.6}),
+		requiresGrad: true,
+	}
+	softmax := NewSoftmax(z)
+	assert.InDeltaSlice(t, softmax.Forward().Data(), y.Data(), 1.0e-6)
+	assert.Equal(t, mat.Float(0), y.AtVec(2))
+
+	gy := mat.NewVecDense([]mat.Float{0.3, -0.6, 0.9, 0.2})
+	f.Backward(gy)
+	softmax.Backward(gy)
+	assert.InDeltaSlice(t, z.grad.ProdScalar(0.5).Data(), x.grad.Data(), 1.0e-6)
+}
@@ -34,10 +34,17 @@ func (r *SoftShrink) Backward(gy mat.Matrix) {
 		panic("fn: matrices with not compatible size")
 	}
 	if r.x.RequiresGrad() {
-		gx := mat.GetDenseWorkspace(r.x.Value().Dims())
+		gx := r.Derivative()
 		defer mat.ReleaseDense(gx)
-		gx.ApplyWithAlpha(softShrinkDeriv, r.x.Value(), r.lambda.Value().Scalar())
 		gx.ProdInPlace(gy)
 		r.x.PropagateGrad(gx)
 	}
 }
+
+// Derivative returns the derivative of the function with respect to x,
+// evaluated at the value of x.
+func (r *SoftShrink) Derivative() *mat.Dense {
+	dx := mat.GetDenseWorkspace(r.x.Value().Dims())
+	dx.ApplyWithAlpha(softShrinkDeriv, r.x.Value(), r.lambda.Value().Scalar())
+	return dx
+}
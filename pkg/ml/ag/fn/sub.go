@@ -22,29 +22,26 @@ func NewSub(x1, x2 Operand) *Sub {
 }
 
 // Forward computes the output of the node.
+// The operands are broadcast to the same dimensions, following the NumPy rules
+// for scalars, row vectors and column vectors.
 func (r *Sub) Forward() mat.Matrix {
 	x1v := r.x1.Value()
 	x2v := r.x2.Value()
-	if !(mat.SameDims(x1v, x2v) || mat.VectorsOfSameSize(x1v, x2v)) {
-		panic("fn: matrices with not compatible size")
+	if rows, cols := broadcastDims(x1v, x2v); needsBroadcast(x1v, x2v, rows, cols) {
+		return broadcastApply(x1v, x2v, rows, cols, func(a, b mat.Float) mat.Float { return a - b })
 	}
 	return x1v.Sub(x2v)
 }
 
 // Backward computes the backward pass.
+// The gradients of broadcast operands are summed along the expanded dimensions.
 func (r *Sub) Backward(gy mat.Matrix) {
-	x1v := r.x1.Value()
-	x2v := r.x2.Value()
-	if !(mat.SameDims(x1v, gy) || mat.VectorsOfSameSize(x1v, gy)) &&
-		!(mat.SameDims(x2v, gy) || mat.VectorsOfSameSize(x2v, gy)) {
-		panic("fn: matrices with not compatible size")
-	}
 	if r.x1.RequiresGrad() {
-		r.x1.PropagateGrad(gy)
+		propagateBroadcastGrad(r.x1, gy)
 	}
 	if r.x2.RequiresGrad() {
 		gx := gy.ProdScalar(-1.0)
 		defer mat.ReleaseDense(gx.(*mat.Dense))
-		r.x2.PropagateGrad(gx)
+		propagateBroadcastGrad(r.x2, gx)
 	}
 }
@@ -32,3 +32,21 @@ func TestSub_Forward(t *testing.T) {
 	assert.InDeltaSlice(t, []mat.Float{-1.0, 0.5, 0.8, 0.0}, x1.grad.Data(), 1.0e-6)
 	assert.InDeltaSlice(t, []mat.Float{1.0, -0.5, -0.8, 0.0}, x2.grad.Data(), 1.0e-6)
 }
+
+func TestSub_Broadcast(t *testing.T) {
+	x1 := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{1, 2, 3, 4}),
+		requiresGrad: true,
+	}
+	x2 := &variable{
+		value:        mat.NewVecDense([]mat.Float{1, 2}),
+		requiresGrad: true,
+	}
+	f := NewSub(x1, x2)
+	y := f.Forward()
+	assert.InDeltaSlice(t, []mat.Float{0, 1, 1, 2}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(2, 2, []mat.Float{1, 2, 3, 4}))
+	assert.InDeltaSlice(t, []mat.Float{1, 2, 3, 4}, x1.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-3, -7}, x2.grad.Data(), 1.0e-6)
+}
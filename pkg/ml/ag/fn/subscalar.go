@@ -18,6 +18,9 @@ type SubScalar struct {
 
 // NewSubScalar returns a new SubScalar Function.
 func NewSubScalar(x1, x2 Operand) *SubScalar {
+	if v := x2.Value(); v != nil && !v.IsScalar() {
+		panic("fn: the second operand must be a scalar")
+	}
 	return &SubScalar{x1: x1, x2: x2}
 }
 
@@ -43,7 +43,7 @@ func (r *Swish) Backward(gy mat.Matrix) {
 		r.x.PropagateGrad(gx)
 	}
 	if r.beta.RequiresGrad() {
-		gb := mat.GetDenseWorkspace(r.beta.Value().Dims())
+		gb := mat.GetEmptyDenseWorkspace(r.beta.Value().Dims())
 		defer mat.ReleaseDense(gb)
 		for i, x := range r.x.Value().Data() {
 			gb.AddScalarInPlace(swishBetaDeriv(x, r.beta.Value().Scalar()) * gy.Data()[i])
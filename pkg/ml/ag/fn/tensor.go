@@ -0,0 +1,27 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import mat "github.com/nlpodyssey/spago/pkg/mat32"
+
+// The tensor functions operate on operands holding N-dimensional tensors:
+// the value of each operand contains the elements of the tensor in row-major
+// order, whatever its dimensions, and the shape of the tensor is given to the
+// function. The output values are matrices with the dimensions given by
+// mat.Tensor.MatrixDims.
+
+// tensorOf returns a tensor of the given shape sharing the data of the value.
+func tensorOf(m mat.Matrix, shape []int) *mat.Tensor {
+	return mat.NewTensorView(shape, m.Data())
+}
+
+// propagateTensorGrad propagates the gradient of a tensor operand, giving it
+// the same dimensions of the value of the operand.
+func propagateTensorGrad(x Operand, gx *mat.Tensor) {
+	rows, cols := x.Value().Dims()
+	gxm := mat.NewDense(rows, cols, gx.Data())
+	defer mat.ReleaseDense(gxm)
+	x.PropagateGrad(gxm)
+}
@@ -0,0 +1,161 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import mat "github.com/nlpodyssey/spago/pkg/mat32"
+
+var (
+	_ Function = &TensorAdd{}
+	_ Function = &TensorSub{}
+	_ Function = &TensorProd{}
+	_ Function = &TensorDiv{}
+)
+
+// tensorBinary holds the operands of an element-wise operation between two
+// tensors, whose shapes are broadcast following the NumPy rules.
+type tensorBinary struct {
+	x1     Operand
+	x2     Operand
+	shape1 []int
+	shape2 []int
+	shape  []int
+}
+
+func newTensorBinary(x1, x2 Operand, shape1, shape2 []int) tensorBinary {
+	shape, err := mat.BroadcastShapes(shape1, shape2)
+	if err != nil {
+		panic(err)
+	}
+	return tensorBinary{x1: x1, x2: x2, shape1: shape1, shape2: shape2, shape: shape}
+}
+
+// Shape returns the shape of the output tensor.
+func (r *tensorBinary) Shape() []int {
+	return r.shape
+}
+
+func (r *tensorBinary) values() (*mat.Tensor, *mat.Tensor) {
+	return tensorOf(r.x1.Value(), r.shape1), tensorOf(r.x2.Value(), r.shape2)
+}
+
+// propagate reduces the gradients to the shapes of the operands before propagating them.
+func (r *tensorBinary) propagate(gx1, gx2 func() *mat.Tensor) {
+	if r.x1.RequiresGrad() {
+		propagateTensorGrad(r.x1, gx1().SumTo(r.shape1...))
+	}
+	if r.x2.RequiresGrad() {
+		propagateTensorGrad(r.x2, gx2().SumTo(r.shape2...))
+	}
+}
+
+// TensorAdd is an operator to perform the element-wise sum of two tensors,
+// broadcasting their shapes.
+// y = x1 + x2
+type TensorAdd struct {
+	tensorBinary
+}
+
+// NewTensorAdd returns a new TensorAdd Function.
+func NewTensorAdd(x1, x2 Operand, shape1, shape2 []int) *TensorAdd {
+	return &TensorAdd{tensorBinary: newTensorBinary(x1, x2, shape1, shape2)}
+}
+
+// Forward computes the output of the function.
+func (r *TensorAdd) Forward() mat.Matrix {
+	x1, x2 := r.values()
+	return x1.Add(x2).ToDense()
+}
+
+// Backward computes the backward pass.
+func (r *TensorAdd) Backward(gy mat.Matrix) {
+	gyt := tensorOf(gy, r.shape)
+	r.propagate(
+		func() *mat.Tensor { return gyt },
+		func() *mat.Tensor { return gyt },
+	)
+}
+
+// TensorSub is an operator to perform the element-wise subtraction of two
+// tensors, broadcasting their shapes.
+// y = x1 - x2
+type TensorSub struct {
+	tensorBinary
+}
+
+// NewTensorSub returns a new TensorSub Function.
+func NewTensorSub(x1, x2 Operand, shape1, shape2 []int) *TensorSub {
+	return &TensorSub{tensorBinary: newTensorBinary(x1, x2, shape1, shape2)}
+}
+
+// Forward computes the output of the function.
+func (r *TensorSub) Forward() mat.Matrix {
+	x1, x2 := r.values()
+	return x1.Sub(x2).ToDense()
+}
+
+// Backward computes the backward pass.
+func (r *TensorSub) Backward(gy mat.Matrix) {
+	gyt := tensorOf(gy, r.shape)
+	r.propagate(
+		func() *mat.Tensor { return gyt },
+		func() *mat.Tensor { return gyt.ProdScalar(-1) },
+	)
+}
+
+// TensorProd is an operator to perform the element-wise product of two
+// tensors, broadcasting their shapes.
+// y = x1 * x2
+type TensorProd struct {
+	tensorBinary
+}
+
+// NewTensorProd returns a new TensorProd Function.
+func NewTensorProd(x1, x2 Operand, shape1, shape2 []int) *TensorProd {
+	return &TensorProd{tensorBinary: newTensorBinary(x1, x2, shape1, shape2)}
+}
+
+// Forward computes the output of the function.
+func (r *TensorProd) Forward() mat.Matrix {
+	x1, x2 := r.values()
+	return x1.Prod(x2).ToDense()
+}
+
+// Backward computes the backward pass.
+func (r *TensorProd) Backward(gy mat.Matrix) {
+	gyt := tensorOf(gy, r.shape)
+	x1, x2 := r.values()
+	r.propagate(
+		func() *mat.Tensor { return gyt.Prod(x2) },
+		func() *mat.Tensor { return gyt.Prod(x1) },
+	)
+}
+
+// TensorDiv is an operator to perform the element-wise division of two
+// tensors, broadcasting their shapes.
+// y = x1 / x2
+type TensorDiv struct {
+	tensorBinary
+}
+
+// NewTensorDiv returns a new TensorDiv Function.
+func NewTensorDiv(x1, x2 Operand, shape1, shape2 []int) *TensorDiv {
+	return &TensorDiv{tensorBinary: newTensorBinary(x1, x2, shape1, shape2)}
+}
+
+// Forward computes the output of the function.
+func (r *TensorDiv) Forward() mat.Matrix {
+	x1, x2 := r.values()
+	return x1.Div(x2).ToDense()
+}
+
+// Backward computes the backward pass.
+func (r *TensorDiv) Backward(gy mat.Matrix) {
+	gyt := tensorOf(gy, r.shape)
+	x1, x2 := r.values()
+	r.propagate(
+		func() *mat.Tensor { return gyt.Div(x2) },
+		func() *mat.Tensor { return gyt.Prod(x1).Div(x2.Prod(x2)).ProdScalar(-1) },
+	)
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTensorBinaryOperands() (*variable, *variable) {
+	x1 := &variable{
+		value:        mat.NewDense(2, 3, []mat.Float{1, 2, 3, 4, 5, 6}),
+		requiresGrad: true,
+	}
+	x2 := &variable{
+		value:        mat.NewVecDense([]mat.Float{1, 2, 4}),
+		requiresGrad: true,
+	}
+	return x1, x2
+}
+
+func TestTensorAdd(t *testing.T) {
+	x1, x2 := newTensorBinaryOperands()
+	f := NewTensorAdd(x1, x2, []int{2, 3}, []int{3})
+	assert.Equal(t, []int{2, 3}, f.Shape())
+
+	y := f.Forward()
+	assert.Equal(t, 2, y.Rows())
+	assert.InDeltaSlice(t, []mat.Float{2, 4, 7, 5, 7, 10}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(2, 3, []mat.Float{1, 2, 3, 4, 5, 6}))
+	assert.InDeltaSlice(t, []mat.Float{1, 2, 3, 4, 5, 6}, x1.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{5, 7, 9}, x2.grad.Data(), 1.0e-6)
+	assert.Equal(t, 3, x2.grad.Rows())
+}
+
+func TestTensorSub(t *testing.T) {
+	x1, x2 := newTensorBinaryOperands()
+	f := NewTensorSub(x1, x2, []int{2, 3}, []int{3})
+	y := f.Forward()
+	assert.InDeltaSlice(t, []mat.Float{0, 0, -1, 3, 3, 2}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(2, 3, []mat.Float{1, 2, 3, 4, 5, 6}))
+	assert.InDeltaSlice(t, []mat.Float{1, 2, 3, 4, 5, 6}, x1.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-5, -7, -9}, x2.grad.Data(), 1.0e-6)
+}
+
+func TestTensorProd(t *testing.T) {
+	x1, x2 := newTensorBinaryOperands()
+	f := NewTensorProd(x1, x2, []int{2, 3}, []int{3})
+	y := f.Forward()
+	assert.InDeltaSlice(t, []mat.Float{1, 4, 12, 4, 10, 24}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(2, 3, []mat.Float{1, 1, 1, 1, 1, 1}))
+	assert.InDeltaSlice(t, []mat.Float{1, 2, 4, 1, 2, 4}, x1.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{5, 7, 9}, x2.grad.Data(), 1.0e-6)
+}
+
+func TestTensorDiv(t *testing.T) {
+	x1, x2 := newTensorBinaryOperands()
+	f := NewTensorDiv(x1, x2, []int{2, 3}, []int{3})
+	y := f.Forward()
+	assert.InDeltaSlice(t, []mat.Float{1, 1, 0.75, 4, 2.5, 1.5}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(2, 3, []mat.Float{1, 1, 1, 1, 1, 1}))
+	assert.InDeltaSlice(t, []mat.Float{1, 0.5, 0.25, 1, 0.5, 0.25}, x1.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-5, -1.75, -0.5625}, x2.grad.Data(), 1.0e-6)
+}
+
+func TestNewTensorAdd_NotBroadcastable(t *testing.T) {
+	x1, x2 := newTensorBinaryOperands()
+	assert.Panics(t, func() { NewTensorAdd(x1, x2, []int{2, 3}, []int{2}) })
+}
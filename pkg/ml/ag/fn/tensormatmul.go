@@ -0,0 +1,69 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import mat "github.com/nlpodyssey/spago/pkg/mat32"
+
+var _ Function = &TensorMatMul{}
+
+// TensorMatMul is an operator to perform the (batched) matrix product of two
+// tensors: the last two dimensions are the ones of the matrices, while the
+// leading dimensions are broadcast (see mat.Tensor.MatMul).
+type TensorMatMul struct {
+	x1     Operand
+	x2     Operand
+	shape1 []int
+	shape2 []int
+	shape  []int
+}
+
+// NewTensorMatMul returns a new TensorMatMul Function.
+func NewTensorMatMul(x1, x2 Operand, shape1, shape2 []int) *TensorMatMul {
+	n1, n2 := len(shape1), len(shape2)
+	if n1 < 2 || n2 < 2 {
+		panic("fn: TensorMatMul requires tensors with at least two dimensions")
+	}
+	if shape1[n1-1] != shape2[n2-2] {
+		panic("fn: matrices with not compatible size")
+	}
+	batch, err := mat.BroadcastShapes(shape1[:n1-2], shape2[:n2-2])
+	if err != nil {
+		panic(err)
+	}
+	return &TensorMatMul{
+		x1:     x1,
+		x2:     x2,
+		shape1: shape1,
+		shape2: shape2,
+		shape:  append(batch, shape1[n1-2], shape2[n2-1]),
+	}
+}
+
+// Shape returns the shape of the output tensor.
+func (r *TensorMatMul) Shape() []int {
+	return r.shape
+}
+
+// Forward computes the output of the function.
+func (r *TensorMatMul) Forward() mat.Matrix {
+	x1 := tensorOf(r.x1.Value(), r.shape1)
+	x2 := tensorOf(r.x2.Value(), r.shape2)
+	return x1.MatMul(x2).ToDense()
+}
+
+// Backward computes the backward pass.
+func (r *TensorMatMul) Backward(gy mat.Matrix) {
+	gyt := tensorOf(gy, r.shape)
+	if r.x1.RequiresGrad() {
+		x2 := tensorOf(r.x2.Value(), r.shape2)
+		gx := gyt.MatMul(x2.Transpose(len(r.shape2)-2, len(r.shape2)-1))
+		propagateTensorGrad(r.x1, gx.SumTo(r.shape1...))
+	}
+	if r.x2.RequiresGrad() {
+		x1 := tensorOf(r.x1.Value(), r.shape1)
+		gx := x1.Transpose(len(r.shape1)-2, len(r.shape1)-1).MatMul(gyt)
+		propagateTensorGrad(r.x2, gx.SumTo(r.shape2...))
+	}
+}
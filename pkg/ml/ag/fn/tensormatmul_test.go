@@ -0,0 +1,42 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTensorMatMul(t *testing.T) {
+	// a (2, 2, 3) batch of matrices times a (3, 2) matrix
+	x1 := &variable{
+		value:        mat.NewDense(2, 6, []mat.Float{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}),
+		requiresGrad: true,
+	}
+	x2 := &variable{
+		value:        mat.NewDense(3, 2, []mat.Float{1, 0, 0, 1, 1, 1}),
+		requiresGrad: true,
+	}
+	f := NewTensorMatMul(x1, x2, []int{2, 2, 3}, []int{3, 2})
+	assert.Equal(t, []int{2, 2, 2}, f.Shape())
+
+	y := f.Forward()
+	assert.Equal(t, 2, y.Rows())
+	assert.Equal(t, 4, y.Columns())
+	assert.InDeltaSlice(t, []mat.Float{4, 5, 10, 11, 16, 17, 22, 23}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(2, 4, []mat.Float{1, 0, 0, 1, 1, 1, 0, 0}))
+	// gx1 = gy · x2ᵀ
+	assert.InDeltaSlice(t, []mat.Float{1, 0, 1, 0, 1, 1, 1, 1, 2, 0, 0, 0}, x1.grad.Data(), 1.0e-6)
+	// gx2 = sum over the batch of x1ᵀ · gy
+	assert.InDeltaSlice(t, []mat.Float{8, 11, 10, 13, 12, 15}, x2.grad.Data(), 1.0e-6)
+}
+
+func TestNewTensorMatMul_NotCompatible(t *testing.T) {
+	x := &variable{value: mat.NewEmptyDense(2, 3)}
+	assert.Panics(t, func() { NewTensorMatMul(x, x, []int{2, 3}, []int{2, 3}) })
+	assert.Panics(t, func() { NewTensorMatMul(x, x, []int{6}, []int{6, 1}) })
+}
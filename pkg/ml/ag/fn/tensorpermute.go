@@ -0,0 +1,51 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import mat "github.com/nlpodyssey/spago/pkg/mat32"
+
+var _ Function = &TensorPermute{}
+
+// TensorPermute is an operator to reorder the dimensions of a tensor: the
+// i-th dimension of the output is the dimension axes[i] of the input.
+type TensorPermute struct {
+	x     Operand
+	shape []int
+	axes  []int
+}
+
+// NewTensorPermute returns a new TensorPermute Function.
+func NewTensorPermute(x Operand, shape []int, axes []int) *TensorPermute {
+	if len(axes) != len(shape) {
+		panic("fn: the number of axes must match the dimensions of the tensor")
+	}
+	return &TensorPermute{x: x, shape: shape, axes: axes}
+}
+
+// Shape returns the shape of the output tensor.
+func (r *TensorPermute) Shape() []int {
+	shape := make([]int, len(r.axes))
+	for i, axis := range r.axes {
+		shape[i] = r.shape[axis]
+	}
+	return shape
+}
+
+// Forward computes the output of the function.
+func (r *TensorPermute) Forward() mat.Matrix {
+	return tensorOf(r.x.Value(), r.shape).Permute(r.axes...).ToDense()
+}
+
+// Backward computes the backward pass.
+func (r *TensorPermute) Backward(gy mat.Matrix) {
+	if !r.x.RequiresGrad() {
+		return
+	}
+	inverse := make([]int, len(r.axes))
+	for i, axis := range r.axes {
+		inverse[axis] = i
+	}
+	propagateTensorGrad(r.x, tensorOf(gy, r.Shape()).Permute(inverse...))
+}
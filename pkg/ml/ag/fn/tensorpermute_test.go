@@ -0,0 +1,27 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTensorPermute(t *testing.T) {
+	x := &variable{
+		value:        mat.NewDense(2, 6, []mat.Float{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}),
+		requiresGrad: true,
+	}
+	f := NewTensorPermute(x, []int{2, 3, 2}, []int{2, 0, 1})
+	assert.Equal(t, []int{2, 2, 3}, f.Shape())
+
+	y := f.Forward()
+	assert.InDeltaSlice(t, []mat.Float{0, 2, 4, 6, 8, 10, 1, 3, 5, 7, 9, 11}, y.Data(), 1.0e-6)
+
+	f.Backward(y)
+	assert.InDeltaSlice(t, x.value.Data(), x.grad.Data(), 1.0e-6)
+	assert.Equal(t, 2, x.grad.Rows())
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import mat "github.com/nlpodyssey/spago/pkg/mat32"
+
+var _ Function = &TensorSlice{}
+
+// TensorSlice is an operator to extract the indices [start, end) of a
+// dimension of a tensor.
+type TensorSlice struct {
+	x     Operand
+	shape []int
+	axis  int
+	start int
+	end   int
+}
+
+// NewTensorSlice returns a new TensorSlice Function.
+func NewTensorSlice(x Operand, shape []int, axis, start, end int) *TensorSlice {
+	if axis < 0 || axis >= len(shape) {
+		panic("fn: axis out of range")
+	}
+	if start < 0 || end > shape[axis] || start > end {
+		panic("fn: slice out of range")
+	}
+	return &TensorSlice{x: x, shape: shape, axis: axis, start: start, end: end}
+}
+
+// Shape returns the shape of the output tensor.
+func (r *TensorSlice) Shape() []int {
+	shape := append([]int(nil), r.shape...)
+	shape[r.axis] = r.end - r.start
+	return shape
+}
+
+// Forward computes the output of the function.
+func (r *TensorSlice) Forward() mat.Matrix {
+	return tensorOf(r.x.Value(), r.shape).Slice(r.axis, r.start, r.end).ToDense()
+}
+
+// Backward computes the backward pass.
+func (r *TensorSlice) Backward(gy mat.Matrix) {
+	if r.x.RequiresGrad() {
+		gx := mat.NewEmptyTensor(r.shape...)
+		gx.Slice(r.axis, r.start, r.end).Copy(tensorOf(gy, r.Shape()))
+		propagateTensorGrad(r.x, gx)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTensorSlice(t *testing.T) {
+	x := &variable{
+		value:        mat.NewDense(2, 3, []mat.Float{1, 2, 3, 4, 5, 6}),
+		requiresGrad: true,
+	}
+	f := NewTensorSlice(x, []int{2, 3}, 1, 1, 3)
+	assert.Equal(t, []int{2, 2}, f.Shape())
+
+	y := f.Forward()
+	assert.InDeltaSlice(t, []mat.Float{2, 3, 5, 6}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(2, 2, []mat.Float{1, 2, 3, 4}))
+	assert.InDeltaSlice(t, []mat.Float{0, 1, 2, 0, 3, 4}, x.grad.Data(), 1.0e-6)
+
+	assert.Panics(t, func() { NewTensorSlice(x, []int{2, 3}, 1, 2, 4) })
+}
@@ -0,0 +1,42 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import mat "github.com/nlpodyssey/spago/pkg/mat32"
+
+var _ Function = &TensorSoftmax{}
+
+// TensorSoftmax is an operator to compute the softmax of a tensor along a dimension.
+type TensorSoftmax struct {
+	x     Operand
+	shape []int
+	axis  int
+	y     *mat.Tensor // initialized during the forward pass, required by the backward pass
+}
+
+// NewTensorSoftmax returns a new TensorSoftmax Function.
+func NewTensorSoftmax(x Operand, shape []int, axis int) *TensorSoftmax {
+	if axis < 0 || axis >= len(shape) {
+		panic("fn: axis out of range")
+	}
+	return &TensorSoftmax{x: x, shape: shape, axis: axis}
+}
+
+// Forward computes the output of the function.
+func (r *TensorSoftmax) Forward() mat.Matrix {
+	r.y = tensorOf(r.x.Value(), r.shape).Softmax(r.axis)
+	return r.y.ToDense()
+}
+
+// Backward computes the backward pass.
+func (r *TensorSoftmax) Backward(gy mat.Matrix) {
+	if !r.x.RequiresGrad() {
+		return
+	}
+	// gx = y * (gy - sum(gy * y))
+	gyt := tensorOf(gy, r.shape)
+	dot := gyt.Prod(r.y).Sum(r.axis).Unsqueeze(r.axis)
+	propagateTensorGrad(r.x, r.y.Prod(gyt.Sub(dot)))
+}
@@ -0,0 +1,31 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTensorSoftmax(t *testing.T) {
+	x := &variable{
+		value:        mat.NewDense(2, 3, []mat.Float{-0.41, -1.08, 0, 0.1, 0.2, 0.3}),
+		requiresGrad: true,
+	}
+	f := NewTensorSoftmax(x, []int{2, 3}, 1)
+	y := f.Forward()
+
+	// each row equals the softmax of the vector
+	row := &variable{value: mat.NewVecDense([]mat.Float{-0.41, -1.08, 0}), requiresGrad: true}
+	softmax := NewSoftmax(row)
+	assert.InDeltaSlice(t, softmax.Forward().Data(), y.Data()[:3], 1.0e-6)
+
+	gy := mat.NewDense(2, 3, []mat.Float{0.2, -0.4, 0.1, 0, 0, 0})
+	f.Backward(gy)
+	softmax.Backward(mat.NewVecDense([]mat.Float{0.2, -0.4, 0.1}))
+	assert.InDeltaSlice(t, row.grad.Data(), x.grad.Data()[:3], 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0, 0, 0}, x.grad.Data()[3:], 1.0e-6)
+}
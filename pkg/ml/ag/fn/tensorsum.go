@@ -0,0 +1,43 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import mat "github.com/nlpodyssey/spago/pkg/mat32"
+
+var _ Function = &TensorSum{}
+
+// TensorSum is an operator to sum the values of a tensor along a dimension,
+// which is removed from the output.
+type TensorSum struct {
+	x     Operand
+	shape []int
+	axis  int
+}
+
+// NewTensorSum returns a new TensorSum Function.
+func NewTensorSum(x Operand, shape []int, axis int) *TensorSum {
+	if axis < 0 || axis >= len(shape) {
+		panic("fn: axis out of range")
+	}
+	return &TensorSum{x: x, shape: shape, axis: axis}
+}
+
+// Shape returns the shape of the output tensor.
+func (r *TensorSum) Shape() []int {
+	return append(append([]int(nil), r.shape[:r.axis]...), r.shape[r.axis+1:]...)
+}
+
+// Forward computes the output of the function.
+func (r *TensorSum) Forward() mat.Matrix {
+	return tensorOf(r.x.Value(), r.shape).Sum(r.axis).ToDense()
+}
+
+// Backward computes the backward pass.
+func (r *TensorSum) Backward(gy mat.Matrix) {
+	if r.x.RequiresGrad() {
+		gx := tensorOf(gy, r.Shape()).Unsqueeze(r.axis).BroadcastTo(r.shape...)
+		propagateTensorGrad(r.x, gx)
+	}
+}
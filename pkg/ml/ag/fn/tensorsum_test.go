@@ -0,0 +1,26 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTensorSum(t *testing.T) {
+	x := &variable{
+		value:        mat.NewDense(2, 3, []mat.Float{1, 2, 3, 4, 5, 6}),
+		requiresGrad: true,
+	}
+	f := NewTensorSum(x, []int{2, 3}, 0)
+	assert.Equal(t, []int{3}, f.Shape())
+
+	y := f.Forward()
+	assert.InDeltaSlice(t, []mat.Float{5, 7, 9}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewVecDense([]mat.Float{1, 2, 3}))
+	assert.InDeltaSlice(t, []mat.Float{1, 2, 3, 1, 2, 3}, x.grad.Data(), 1.0e-6)
+}
@@ -30,10 +30,16 @@ func (r *UnaryElementwise) Backward(gy mat.Matrix) {
 		panic("fn: matrices with not compatible size")
 	}
 	if r.x.RequiresGrad() {
-		gx := mat.GetDenseWorkspace(r.x.Value().Dims())
+		gx := r.Derivative()
 		defer mat.ReleaseDense(gx)
-		gx.Apply(r.df, r.x.Value())
 		gx.ProdInPlace(gy)
 		r.x.PropagateGrad(gx)
 	}
 }
+
+// Derivative returns the derivative of the function evaluated at the value of x.
+func (r *UnaryElementwise) Derivative() *mat.Dense {
+	dx := mat.GetDenseWorkspace(r.x.Value().Dims())
+	dx.Apply(r.df, r.x.Value())
+	return dx
+}
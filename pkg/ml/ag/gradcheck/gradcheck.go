@@ -0,0 +1,271 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gradcheck provides a numerical gradient checker, to validate the
+// analytical gradients computed by the backward pass of functions (e.g. custom
+// fn.Function implementations) and models against central differences.
+//
+// A function with a non-scalar output y is checked through the scalar
+// L = sum(r * y), where r is a fixed random tensor: the analytical gradients
+// are computed back-propagating r from y, while the numerical gradient of
+// each input element x is (L(x+h) - L(x-h)) / 2h.
+//
+// The function must be deterministic and differentiable around the given
+// inputs: each evaluation is carried out on a new graph with the same seed
+// (so that e.g. the dropout masks are the same), but the inputs should not
+// be close to the points where the function is not differentiable (e.g. zero
+// for ReLU).
+package gradcheck
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Func is a function of the input nodes, checked by Check.
+type Func func(g *ag.Graph, inputs []ag.Node) ag.Node
+
+// Checker compares analytical and numerical gradients.
+// An analytical gradient a passes the check against the numerical gradient n
+// if |a - n| <= atol + rtol * |n|.
+type Checker struct {
+	epsilon float64
+	atol    float64
+	rtol    float64
+	seed    uint64
+}
+
+// Option allows to configure a new Checker.
+type Option func(*Checker)
+
+// Epsilon sets the perturbation h of the inputs used to compute the central
+// differences.
+func Epsilon(value float64) Option {
+	return func(c *Checker) {
+		c.epsilon = value
+	}
+}
+
+// Tolerance sets the absolute and the relative tolerance of the check.
+func Tolerance(atol, rtol float64) Option {
+	return func(c *Checker) {
+		c.atol = atol
+		c.rtol = rtol
+	}
+}
+
+// Seed sets the seed of the random projection of the output and of the
+// random generator of the graphs.
+func Seed(value uint64) Option {
+	return func(c *Checker) {
+		c.seed = value
+	}
+}
+
+// New returns a new Checker. The default epsilon and tolerances depend on the
+// precision of mat.Float: for float32 they are loose enough to absorb the
+// rounding errors of the forward pass.
+func New(opts ...Option) *Checker {
+	c := &Checker{seed: 42}
+	if reflect.TypeOf(mat.Float(0)).Bits() == 32 {
+		c.epsilon, c.atol, c.rtol = 1e-2, 1e-3, 1e-2
+	} else {
+		c.epsilon, c.atol, c.rtol = 1e-6, 1e-7, 1e-5
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Check compares the gradients of f with respect to the inputs, using the
+// default Checker. It returns an *Error describing the mismatching gradients,
+// if any. The inputs are not modified.
+func Check(f Func, inputs ...mat.Matrix) error {
+	return New().Check(f, inputs...)
+}
+
+// CheckModel compares the gradients of the parameters of the model with
+// respect to the output of f, using the default Checker.
+// See Checker.CheckModel.
+func CheckModel(m nn.Model, f func(proc nn.Model) ag.Node) error {
+	return New().CheckModel(m, f)
+}
+
+// Check compares the gradients of f with respect to the inputs. The inputs
+// are copied to variables requiring gradients on a new graph, which is
+// passed to f. It returns an *Error describing the mismatching gradients, if
+// any. The inputs are not modified.
+func (c *Checker) Check(f Func, inputs ...mat.Matrix) error {
+	targets := make([]target, len(inputs))
+	for i, input := range inputs {
+		targets[i] = target{name: fmt.Sprintf("input %d", i), value: input.Clone()}
+	}
+	var nodes []ag.Node
+	build := func(g *ag.Graph) ag.Node {
+		nodes = make([]ag.Node, len(targets))
+		for i, t := range targets {
+			nodes[i] = g.NewVariable(t.value, true)
+		}
+		return f(g, nodes)
+	}
+	analytical := func() []mat.Matrix {
+		grads := make([]mat.Matrix, len(nodes))
+		for i, n := range nodes {
+			grads[i] = n.Grad()
+		}
+		return grads
+	}
+	return c.run(targets, build, analytical)
+}
+
+// CheckModel compares the gradients of the parameters of the model, which
+// require gradients, with respect to the output of f. The model is reified in
+// training mode on a new graph and passed to f.
+//
+// The values of the parameters are perturbed in place during the check and
+// restored afterwards; their gradients are zeroed.
+// It returns an *Error describing the mismatching gradients, if any.
+func (c *Checker) CheckModel(m nn.Model, f func(proc nn.Model) ag.Node) error {
+	var params []nn.Param
+	var targets []target
+	nn.ForEachParam(m, func(param nn.Param) {
+		if param.RequiresGrad() {
+			params = append(params, param)
+			targets = append(targets, target{name: param.Name(), value: param.Value()})
+		}
+	})
+	zeroGrad := func() {
+		for _, param := range params {
+			param.ZeroGrad()
+		}
+	}
+	zeroGrad()
+	defer zeroGrad()
+	build := func(g *ag.Graph) ag.Node {
+		return f(nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m))
+	}
+	analytical := func() []mat.Matrix {
+		grads := make([]mat.Matrix, len(params))
+		for i, param := range params {
+			grads[i] = param.Grad()
+		}
+		return grads
+	}
+	return c.run(targets, build, analytical)
+}
+
+// target is a matrix whose values are perturbed to compute the numerical gradients.
+type target struct {
+	name  string
+	value mat.Matrix
+}
+
+// run computes the analytical gradients calling build on a new graph and
+// back-propagating the random projection from its output, and compares them
+// with the numerical gradients of each target value.
+func (c *Checker) run(targets []target, build func(g *ag.Graph) ag.Node, analytical func() []mat.Matrix) error {
+	g := ag.NewGraph(ag.RandSeed(c.seed))
+	y := build(g)
+	r := c.projection(y.Value())
+	g.Backward(y, ag.OutputGrad(r))
+	grads := make([][]mat.Float, len(targets))
+	for i, grad := range analytical() {
+		if grad != nil {
+			grads[i] = append([]mat.Float(nil), grad.Data()...)
+		} else {
+			grads[i] = make([]mat.Float, targets[i].value.Size())
+		}
+	}
+	g.Clear()
+
+	loss := func() float64 {
+		g := ag.NewGraph(ag.RandSeed(c.seed))
+		defer g.Clear()
+		y := build(g).Value()
+		if !mat.SameDims(y, r) {
+			panic("gradcheck: the dimensions of the output change across evaluations")
+		}
+		sum := 0.0
+		for i, v := range y.Data() {
+			sum += float64(v) * float64(r.Data()[i])
+		}
+		return sum
+	}
+
+	e := &Error{}
+	for i, t := range targets {
+		data := t.value.Data()
+		for j, v := range data {
+			plus, minus := v+mat.Float(c.epsilon), v-mat.Float(c.epsilon)
+			data[j] = plus
+			lossPlus := loss()
+			data[j] = minus
+			lossMinus := loss()
+			data[j] = v
+			// the actual perturbation may differ from epsilon due to rounding
+			numerical := (lossPlus - lossMinus) / (float64(plus) - float64(minus))
+			a := float64(grads[i][j])
+			if math.Abs(a-numerical) > c.atol+c.rtol*math.Abs(numerical) || math.IsNaN(a) {
+				e.Mismatches = append(e.Mismatches, Mismatch{
+					Name:       t.name,
+					Row:        j / t.value.Columns(),
+					Column:     j % t.value.Columns(),
+					Analytical: a,
+					Numerical:  numerical,
+				})
+			}
+		}
+	}
+	if len(e.Mismatches) > 0 {
+		return e
+	}
+	return nil
+}
+
+// projection returns a random matrix with the same dimensions of y.
+func (c *Checker) projection(y mat.Matrix) mat.Matrix {
+	rnd := rand.NewLockedRand(c.seed)
+	r := mat.NewEmptyDense(y.Dims())
+	for i := range r.Data() {
+		r.Data()[i] = rnd.Float()*2 - 1
+	}
+	return r
+}
+
+// Mismatch describes an element whose analytical gradient differs from the
+// numerical one.
+type Mismatch struct {
+	// Name identifies the input (e.g. "input 0") or the parameter.
+	Name       string
+	Row        int
+	Column     int
+	Analytical float64
+	Numerical  float64
+}
+
+// String returns a description of the mismatch.
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s[%d][%d]: analytical %g, numerical %g", m.Name, m.Row, m.Column, m.Analytical, m.Numerical)
+}
+
+// Error is returned by the checks when some gradients do not match.
+type Error struct {
+	Mismatches []Mismatch
+}
+
+// Error returns the description of the mismatches.
+func (e *Error) Error() string {
+	lines := make([]string, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		lines[i] = m.String()
+	}
+	return fmt.Sprintf("gradcheck: %d mismatching gradients:\n%s", len(e.Mismatches), strings.Join(lines, "\n"))
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gradcheck
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	vec := func() mat.Matrix { return mat.NewVecDense([]mat.Float{0.3, -0.6, 0.9, 0.15}) }
+	pos := func() mat.Matrix { return mat.NewVecDense([]mat.Float{0.3, 0.6, 0.9, 1.5}) }
+	other := func() mat.Matrix { return mat.NewVecDense([]mat.Float{0.5, -0.8, 0.4, 0.2}) }
+	matrix := func() mat.Matrix { return mat.NewDense(2, 3, []mat.Float{0.1, -0.2, 0.3, 0.4, -0.5, 0.6}) }
+	scalar := func(v mat.Float) mat.Matrix { return mat.NewScalar(v) }
+
+	unary := func(op func(g *ag.Graph, x ag.Node) ag.Node) Func {
+		return func(g *ag.Graph, xs []ag.Node) ag.Node { return op(g, xs[0]) }
+	}
+	binary := func(op func(g *ag.Graph, x1, x2 ag.Node) ag.Node) Func {
+		return func(g *ag.Graph, xs []ag.Node) ag.Node { return op(g, xs[0], xs[1]) }
+	}
+
+	cases := []struct {
+		name   string
+		f      Func
+		inputs []mat.Matrix
+	}{
+		{"Add", binary((*ag.Graph).Add), []mat.Matrix{vec(), pos()}},
+		{"Add broadcast", binary((*ag.Graph).Add), []mat.Matrix{matrix(), mat.NewVecDense([]mat.Float{1, 2, 3}).T()}},
+		{"Sub", binary((*ag.Graph).Sub), []mat.Matrix{vec(), pos()}},
+		{"Prod", binary((*ag.Graph).Prod), []mat.Matrix{vec(), pos()}},
+		{"Prod broadcast", binary((*ag.Graph).Prod), []mat.Matrix{matrix(), mat.NewVecDense([]mat.Float{1, 2})}},
+		{"Div", binary((*ag.Graph).Div), []mat.Matrix{vec(), pos()}},
+		{"AddScalar", binary((*ag.Graph).AddScalar), []mat.Matrix{vec(), scalar(0.5)}},
+		{"ProdScalar", binary((*ag.Graph).ProdScalar), []mat.Matrix{vec(), scalar(0.5)}},
+		{"DivScalar", binary((*ag.Graph).DivScalar), []mat.Matrix{vec(), scalar(0.5)}},
+		{"Mul", binary((*ag.Graph).Mul), []mat.Matrix{matrix(), mat.NewVecDense([]mat.Float{0.2, 0.4, -0.1})}},
+		{"Dot", binary((*ag.Graph).Dot), []mat.Matrix{vec(), pos()}},
+		{"Max", binary((*ag.Graph).Max), []mat.Matrix{vec(), other()}},
+		{"Min", binary((*ag.Graph).Min), []mat.Matrix{vec(), other()}},
+		{"T", unary((*ag.Graph).T), []mat.Matrix{matrix()}},
+		{"Reshape", unary(func(g *ag.Graph, x ag.Node) ag.Node { return g.Reshape(x, 3, 2) }), []mat.Matrix{matrix()}},
+		{"RowView", unary(func(g *ag.Graph, x ag.Node) ag.Node { return g.RowView(x, 1) }), []mat.Matrix{matrix()}},
+		{"ColView", unary(func(g *ag.Graph, x ag.Node) ag.Node { return g.ColView(x, 2) }), []mat.Matrix{matrix()}},
+		{"AtVec", unary(func(g *ag.Graph, x ag.Node) ag.Node { return g.AtVec(x, 2) }), []mat.Matrix{vec()}},
+		{"Vec", unary((*ag.Graph).Vec), []mat.Matrix{matrix()}},
+		{"Square", unary((*ag.Graph).Square), []mat.Matrix{vec()}},
+		{"Pow", unary(func(g *ag.Graph, x ag.Node) ag.Node { return g.Pow(x, 3) }), []mat.Matrix{vec()}},
+		{"Sqrt", unary((*ag.Graph).Sqrt), []mat.Matrix{pos()}},
+		{"Tan", unary((*ag.Graph).Tan), []mat.Matrix{vec()}},
+		{"Tanh", unary((*ag.Graph).Tanh), []mat.Matrix{vec()}},
+		{"Sigmoid", unary((*ag.Graph).Sigmoid), []mat.Matrix{vec()}},
+		{"Softsign", unary((*ag.Graph).Softsign), []mat.Matrix{vec()}},
+		{"ReLU", unary((*ag.Graph).ReLU), []mat.Matrix{vec()}},
+		{"GELU", unary((*ag.Graph).GELU), []mat.Matrix{vec()}},
+		{"Mish", unary((*ag.Graph).Mish), []mat.Matrix{vec()}},
+		{"ELU", unary(func(g *ag.Graph, x ag.Node) ag.Node { return g.ELU(x, g.Constant(1.0)) }), []mat.Matrix{vec()}},
+		{"Swish", unary(func(g *ag.Graph, x ag.Node) ag.Node { return g.Swish(x, g.Constant(1.5)) }), []mat.Matrix{vec()}},
+		{"LeakyReLU", unary(func(g *ag.Graph, x ag.Node) ag.Node { return g.LeakyReLU(x, g.Constant(0.1)) }), []mat.Matrix{vec()}},
+		{"Softmax", unary((*ag.Graph).Softmax), []mat.Matrix{vec()}},
+		{"Sin", unary((*ag.Graph).Sin), []mat.Matrix{vec()}},
+		{"Cos", unary((*ag.Graph).Cos), []mat.Matrix{vec()}},
+		{"Exp", unary((*ag.Graph).Exp), []mat.Matrix{vec()}},
+		{"Log", unary((*ag.Graph).Log), []mat.Matrix{pos()}},
+		{"Abs", unary((*ag.Graph).Abs), []mat.Matrix{vec()}},
+		{"Neg", unary((*ag.Graph).Neg), []mat.Matrix{vec()}},
+		{"Reciprocal", unary((*ag.Graph).Reciprocal), []mat.Matrix{pos()}},
+		{"ReduceSum", unary((*ag.Graph).ReduceSum), []mat.Matrix{vec()}},
+		{"ReduceMean", unary((*ag.Graph).ReduceMean), []mat.Matrix{vec()}},
+		{"Concat", binary(func(g *ag.Graph, x1, x2 ag.Node) ag.Node { return g.Concat(x1, x2) }), []mat.Matrix{vec(), pos()}},
+		{"Stack", binary(func(g *ag.Graph, x1, x2 ag.Node) ag.Node { return g.Stack(x1, x2) }), []mat.Matrix{vec(), pos()}},
+		{"Dropout", unary(func(g *ag.Graph, x ag.Node) ag.Node { return g.Dropout(x, 0.5) }), []mat.Matrix{vec()}},
+		{"TensorMatMul", binary(func(g *ag.Graph, x1, x2 ag.Node) ag.Node {
+			return g.TensorMatMul(g.AsTensor(x1, 2, 1, 3), g.AsTensor(x2, 3, 2)).Node
+		}), []mat.Matrix{matrix(), matrix()}},
+		{"TensorSoftmax", unary(func(g *ag.Graph, x ag.Node) ag.Node {
+			return g.TensorSoftmax(g.AsTensor(x, 2, 3), 0).Node
+		}), []mat.Matrix{matrix()}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.NoError(t, Check(c.f, c.inputs...))
+		})
+	}
+}
+
+func TestCheck_SecondOrder(t *testing.T) {
+	vec := func() mat.Matrix { return mat.NewVecDense([]mat.Float{0.3, -0.6, 0.9, 0.15}) }
+	pos := func() mat.Matrix { return mat.NewVecDense([]mat.Float{0.3, 0.6, 0.9, 1.5}) }
+	matrix := func() mat.Matrix { return mat.NewDense(2, 3, []mat.Float{0.1, -0.2, 0.3, 0.4, -0.5, 0.6}) }
+
+	// grad checks the gradients of the sum of the gradients of f with respect to its inputs.
+	grad := func(f Func) Func {
+		return func(g *ag.Graph, xs []ag.Node) ag.Node {
+			var sum ag.Node
+			gxs, err := g.Gradients(f(g, xs), xs...)
+			if err != nil {
+				panic(err)
+			}
+			for _, gx := range gxs {
+				if gx != nil {
+					sum = g.Add(sum, g.ReduceSum(g.Square(gx)))
+				}
+			}
+			return sum
+		}
+	}
+
+	cases := []struct {
+		name   string
+		f      Func
+		inputs []mat.Matrix
+	}{
+		{"Mul Tanh", func(g *ag.Graph, xs []ag.Node) ag.Node {
+			return g.Tanh(g.Mul(xs[0], xs[1]))
+		}, []mat.Matrix{matrix(), mat.NewVecDense([]mat.Float{0.2, 0.4, -0.1})}},
+		{"Prod Div broadcast", func(g *ag.Graph, xs []ag.Node) ag.Node {
+			return g.Div(g.Prod(xs[0], xs[1]), g.AddScalar(xs[1], g.Constant(2)))
+		}, []mat.Matrix{matrix(), mat.NewVecDense([]mat.Float{1, 2})}},
+		{"Sigmoid Exp Log", func(g *ag.Graph, xs []ag.Node) ag.Node {
+			return g.Log(g.Add(g.Sigmoid(xs[0]), g.Exp(xs[1])))
+		}, []mat.Matrix{vec(), pos()}},
+		{"Softmax", func(g *ag.Graph, xs []ag.Node) ag.Node {
+			return g.Dot(g.Softmax(xs[0]), xs[1])
+		}, []mat.Matrix{vec(), pos()}},
+		{"Sqrt Pow Reciprocal", func(g *ag.Graph, xs []ag.Node) ag.Node {
+			return g.Sub(g.Sqrt(xs[0]), g.Prod(g.Pow(xs[0], 3), g.Reciprocal(xs[1])))
+		}, []mat.Matrix{pos(), pos()}},
+		{"Sin Cos Softsign", func(g *ag.Graph, xs []ag.Node) ag.Node {
+			return g.Softsign(g.Prod(g.Sin(xs[0]), g.Cos(xs[1])))
+		}, []mat.Matrix{vec(), pos()}},
+		{"Concat Stack views", func(g *ag.Graph, xs []ag.Node) ag.Node {
+			s := g.Stack(xs[0], g.Tanh(xs[1]))
+			return g.Square(g.Concat(g.Vec(g.T(s)), g.RowView(s, 1), g.T(g.ColView(s, 2)), g.At(s, 0, 3)))
+		}, []mat.Matrix{vec(), pos()}},
+		{"GELU Swish Mish", func(g *ag.Graph, xs []ag.Node) ag.Node {
+			return g.Add(g.GELU(xs[0]), g.Mish(g.Swish(xs[0], xs[1])))
+		}, []mat.Matrix{vec(), mat.NewScalar(0.8)}},
+		{"ELU CELU SELU SoftPlus", func(g *ag.Graph, xs []ag.Node) ag.Node {
+			y := g.Add(g.ELU(xs[0], g.Constant(0.5)), g.CELU(xs[0], g.Constant(0.7)))
+			return g.Prod(g.SELU(y, g.Constant(0.5), g.Constant(1.5)), g.SoftPlus(xs[0], g.Constant(2), g.Constant(0.5)))
+		}, []mat.Matrix{vec()}},
+		{"AffineActivation LayerNorm", func(g *ag.Graph, xs []ag.Node) ag.Node {
+			y := g.AffineActivation(xs[0], xs[1], xs[2], ag.OpGELU)
+			return g.AddLayerNorm(y, xs[0], xs[3], xs[0], 1e-5)
+		}, []mat.Matrix{mat.NewVecDense([]mat.Float{0.1, -0.3}), matrix(), mat.NewVecDense([]mat.Float{0.2, 0.4, -0.1}),
+			mat.NewVecDense([]mat.Float{0.5, 1.5})}},
+		{"ScaledMaskedSoftmax", func(g *ag.Graph, xs []ag.Node) ag.Node {
+			return g.Dot(g.ScaledMaskedSoftmax(xs[0], 0.5, nil), xs[1])
+		}, []mat.Matrix{vec(), pos()}},
+		{"ReduceMean Reshape", func(g *ag.Graph, xs []ag.Node) ag.Node {
+			return g.ReduceMean(g.Square(g.Mul(g.Reshape(xs[0], 3, 2), g.View(xs[0], 0, 0, 2, 2))))
+		}, []mat.Matrix{matrix()}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.NoError(t, Check(grad(c.f), c.inputs...))
+		})
+	}
+}
+
+func TestCheck_DoesNotModifyInputs(t *testing.T) {
+	x := mat.NewVecDense([]mat.Float{1, 2, 3})
+	require.NoError(t, Check(func(g *ag.Graph, xs []ag.Node) ag.Node { return g.Square(xs[0]) }, x))
+	assert.Equal(t, []mat.Float{1, 2, 3}, x.Data())
+}
+
+// wrongSquare is a square function with a wrong backward pass (it lacks the factor 2).
+type wrongSquare struct {
+	x fn.Operand
+}
+
+func (r *wrongSquare) Forward() mat.Matrix {
+	return r.x.Value().Prod(r.x.Value())
+}
+
+func (r *wrongSquare) Backward(gy mat.Matrix) {
+	r.x.PropagateGrad(gy.Prod(r.x.Value()))
+}
+
+func TestCheck_Mismatch(t *testing.T) {
+	f := func(g *ag.Graph, xs []ag.Node) ag.Node {
+		return g.NewOperator(&wrongSquare{x: xs[0]}, xs[0])
+	}
+	err := Check(f, mat.NewVecDense([]mat.Float{1, 2}))
+	require.Error(t, err)
+	e, ok := err.(*Error)
+	require.True(t, ok)
+	require.Len(t, e.Mismatches, 2)
+	m := e.Mismatches[1]
+	assert.Equal(t, "input 0", m.Name)
+	assert.Equal(t, 1, m.Row)
+	assert.Equal(t, 0, m.Column)
+	assert.InDelta(t, 2*m.Analytical, m.Numerical, 1.0e-2)
+}
+
+func TestCheckModel(t *testing.T) {
+	m := linear.New(3, 2)
+	nn.ForEachParam(m, func(param nn.Param) {
+		data := param.Value().Data()
+		for i := range data {
+			data[i] = mat.Float(i+1) * 0.1
+		}
+	})
+	w := m.W.Value().Clone()
+	forward := func(proc nn.Model) ag.Node {
+		g := proc.Graph()
+		x := g.NewVariable(mat.NewVecDense([]mat.Float{0.5, -0.2, 0.8}), false)
+		return g.Tanh(proc.(*linear.Model).Forward(x)[0])
+	}
+	require.NoError(t, CheckModel(m, forward))
+	assert.Equal(t, w.Data(), m.W.Value().Data())
+	assert.Nil(t, m.W.Grad())
+}
@@ -0,0 +1,179 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+)
+
+// GradFunc expresses the backward pass of an operator in terms of new nodes of
+// the graph: given the operator node y and the node gy holding the gradients
+// of y, it returns the gradients of each operand of y, or nil for the operands
+// which don't require gradients.
+//
+// Since the gradients are nodes of the graph, they can be differentiated in
+// turn (see Graph.Gradients).
+type GradFunc func(y, gy Node) []Node
+
+// NewOperatorWithGrad creates a new operator along with its forward pass, like
+// NewOperator, attaching the GradFunc used by Graph.Gradients.
+func (g *Graph) NewOperatorWithGrad(f fn.Function, grad GradFunc, operands ...Node) Node {
+	y := g.NewOperator(f, operands...)
+	y.(*operator).gradFunc = grad
+	return y
+}
+
+// Gradients returns the gradients of y with respect to each of xs, as new nodes
+// of the graph, or nil for the nodes y does not depend on. The gradients of a
+// non-scalar y are the gradients of the sum of its elements (dy/dy = 1).
+//
+// Unlike Backward, which accumulates the gradients as matrices outside the
+// graph, the gradients are computed by operators (see GradFunc), so that they
+// can be used in turn in the definition of a loss, and back-propagated or
+// differentiated again. This allows to compute higher-order derivatives, such as
+// Hessian-vector products, gradient-penalty losses and meta-learning updates.
+//
+// The values of the nodes must have been computed (see IncrementalForward).
+// All the operators of the graph provide a GradFunc, including the fused ones,
+// but the tensor operators (see Tensor). An error is returned if y depends on
+// an operator without a GradFunc, such as a custom fn.Function added by
+// NewOperator.
+func (g *Graph) Gradients(y Node, xs ...Node) ([]Node, error) {
+	// the nodes before the first of xs don't contribute to their gradients
+	first := y.ID()
+	for _, x := range xs {
+		if x.ID() < first {
+			first = x.ID()
+		}
+	}
+	restoreValues(y)
+	return g.gradients(y, g.NewVariable(y.Value().OnesLike(), false), xs, first)
+}
+
+// gradients returns the gradients of y with respect to each of xs, given the
+// gradients gy of y, going through the operators from y back to the node with
+// the given id.
+func (g *Graph) gradients(y, gy Node, xs []Node, first int) ([]Node, error) {
+	g.mu.Lock()
+	nodes := g.nodes[:y.ID()+1]
+	g.mu.Unlock()
+
+	grads := map[int]Node{y.ID(): reshapeLike(gy, y)}
+	for i := len(nodes) - 1; i >= first; i-- {
+		op, ok := nodes[i].(*operator)
+		if !ok || !op.requiresGrad {
+			continue
+		}
+		gy, ok := grads[op.id]
+		if !ok {
+			continue
+		}
+		if op.gradFunc == nil {
+			return nil, fmt.Errorf("ag: higher-order gradients are not supported by %s", functionName(op.function))
+		}
+		restoreValues(op)
+		restoreOperands(op)
+		for j, gx := range op.gradFunc(op, gy) {
+			operand := op.operands[j]
+			if gx == nil || !operand.RequiresGrad() {
+				continue
+			}
+			if acc, ok := grads[operand.ID()]; ok {
+				grads[operand.ID()] = g.Add(acc, gx)
+			} else {
+				grads[operand.ID()] = gx
+			}
+		}
+	}
+
+	out := make([]Node, len(xs))
+	for i, x := range xs {
+		out[i] = grads[x.ID()]
+	}
+	return out, nil
+}
+
+// compositeGrad returns the GradFunc of an operator equivalent to the composition
+// f of other operators of the graph, applied to its operands. The composition is
+// computed again, so that its gradients can be differentiated in turn.
+func compositeGrad(f func(g *Graph, xs []Node) Node) GradFunc {
+	return func(y, gy Node) []Node {
+		g, xs := gy.Graph(), operandsOf(y)
+		g.mu.Lock()
+		first := g.maxID + 1 // the gradients only go through the nodes of the composition
+		g.mu.Unlock()
+		gxs, err := g.gradients(f(g, xs), gy, xs, first)
+		if err != nil {
+			panic(err) // the compositions only use operators with a GradFunc
+		}
+		for i, gx := range gxs {
+			if gx != nil {
+				gxs[i] = reshapeLike(gx, xs[i])
+			}
+		}
+		return gxs
+	}
+}
+
+// operandsOf returns the operands of the operator node.
+func operandsOf(y Node) []Node {
+	return y.(*operator).operands
+}
+
+// gradIf returns the result of f if x requires gradients, nil otherwise.
+func gradIf(x Node, f func() Node) Node {
+	if !x.RequiresGrad() {
+		return nil
+	}
+	return f()
+}
+
+// reshapeLike returns gy with the dimensions of x.
+func reshapeLike(gy, x Node) Node {
+	if mat.SameDims(gy.Value(), x.Value()) {
+		return gy
+	}
+	rows, cols := x.Value().Dims()
+	return gy.Graph().Reshape(gy, rows, cols)
+}
+
+// sumTo returns gy summed over the dimensions along which x has been broadcast
+// by an element-wise operator, with the dimensions of x.
+func sumTo(gy, x Node) Node {
+	g := gy.Graph()
+	xr, xc := x.Value().Dims()
+	gr, gc := gy.Value().Dims()
+	if x.Value().Size() == gy.Value().Size() {
+		return reshapeLike(gy, x)
+	}
+	if xr == 1 && gr != 1 {
+		gy = g.Mul(g.NewVariable(mat.NewInitDense(1, gr, 1), false), gy)
+	}
+	if xc == 1 && gc != 1 {
+		gy = g.Mul(gy, g.NewVariable(mat.NewInitDense(gc, 1, 1), false))
+	}
+	return gy
+}
+
+// mask returns a new constant with the dimensions of m and the value f(v) for
+// each value v of m.
+func (g *Graph) mask(m mat.Matrix, f func(v mat.Float) mat.Float) Node {
+	out := mat.NewEmptyDense(m.Dims())
+	data := out.Data()
+	for i, v := range m.Data() {
+		data[i] = f(v)
+	}
+	return g.NewVariable(out, false)
+}
+
+// oneHot returns a new constant with the given dimensions, all zeros except for
+// the value at (i, j), which is one.
+func (g *Graph) oneHot(rows, cols, i, j int) Node {
+	out := mat.NewEmptyDense(rows, cols)
+	out.Set(i, j, 1)
+	return g.NewVariable(out, false)
+}
@@ -0,0 +1,206 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGraph_Gradients_MatchBackward(t *testing.T) {
+	vec := func() mat.Matrix { return mat.NewVecDense([]mat.Float{0.3, -0.6, 0.9, 0.15}) }
+	pos := func() mat.Matrix { return mat.NewVecDense([]mat.Float{0.3, 0.6, 0.9, 1.5}) }
+	other := func() mat.Matrix { return mat.NewVecDense([]mat.Float{0.5, -0.8, 0.4, 0.2}) }
+	matrix := func() mat.Matrix { return mat.NewDense(2, 3, []mat.Float{0.1, -0.2, 0.3, 0.4, -0.5, 0.6}) }
+
+	cases := []struct {
+		name   string
+		f      func(g *Graph, xs []Node) Node
+		inputs []mat.Matrix
+	}{
+		{"Identity", func(g *Graph, xs []Node) Node { return g.Identity(xs[0]) }, []mat.Matrix{vec()}},
+		{"Dropout", func(g *Graph, xs []Node) Node { return g.Dropout(xs[0], 0.5) }, []mat.Matrix{vec()}},
+		{"AtVec", func(g *Graph, xs []Node) Node { return g.AtVec(xs[0], 2) }, []mat.Matrix{vec()}},
+		{"At", func(g *Graph, xs []Node) Node { return g.At(xs[0], 1, 2) }, []mat.Matrix{matrix()}},
+		{"Add", func(g *Graph, xs []Node) Node { return g.Add(xs[0], xs[1]) }, []mat.Matrix{vec(), pos()}},
+		{"Add broadcast", func(g *Graph, xs []Node) Node { return g.Add(xs[0], xs[1]) },
+			[]mat.Matrix{matrix(), mat.NewVecDense([]mat.Float{1, 2, 3}).T()}},
+		{"Sub broadcast", func(g *Graph, xs []Node) Node { return g.Sub(xs[0], xs[1]) },
+			[]mat.Matrix{matrix(), mat.NewVecDense([]mat.Float{1, 2})}},
+		{"SubScalar", func(g *Graph, xs []Node) Node { return g.SubScalar(xs[0], xs[1]) },
+			[]mat.Matrix{vec(), mat.NewScalar(0.5)}},
+		{"AddScalar", func(g *Graph, xs []Node) Node { return g.AddScalar(xs[0], xs[1]) },
+			[]mat.Matrix{vec(), mat.NewScalar(0.5)}},
+		{"ReverseSub", func(g *Graph, xs []Node) Node { return g.ReverseSub(xs[0], xs[1]) },
+			[]mat.Matrix{vec(), mat.NewScalar(0.5)}},
+		{"Prod broadcast", func(g *Graph, xs []Node) Node { return g.Prod(xs[0], xs[1]) },
+			[]mat.Matrix{matrix(), mat.NewScalar(0.5)}},
+		{"Div", func(g *Graph, xs []Node) Node { return g.Div(xs[0], xs[1]) }, []mat.Matrix{vec(), pos()}},
+		{"ProdScalar", func(g *Graph, xs []Node) Node { return g.ProdScalar(xs[0], xs[1]) },
+			[]mat.Matrix{vec(), mat.NewScalar(0.5)}},
+		{"DivScalar", func(g *Graph, xs []Node) Node { return g.DivScalar(xs[0], xs[1]) },
+			[]mat.Matrix{vec(), mat.NewScalar(0.5)}},
+		{"Mul", func(g *Graph, xs []Node) Node { return g.Mul(xs[0], xs[1]) },
+			[]mat.Matrix{matrix(), mat.NewVecDense([]mat.Float{0.2, 0.4, -0.1})}},
+		{"Dot", func(g *Graph, xs []Node) Node { return g.Dot(xs[0], xs[1]) }, []mat.Matrix{vec(), pos()}},
+		{"Max", func(g *Graph, xs []Node) Node { return g.Max(xs[0], xs[1]) }, []mat.Matrix{vec(), other()}},
+		{"Min", func(g *Graph, xs []Node) Node { return g.Min(xs[0], xs[1]) }, []mat.Matrix{vec(), other()}},
+		{"Reshape", func(g *Graph, xs []Node) Node { return g.Reshape(xs[0], 3, 2) }, []mat.Matrix{matrix()}},
+		{"View", func(g *Graph, xs []Node) Node { return g.View(xs[0], 1, 1, 1, 2) }, []mat.Matrix{matrix()}},
+		{"RowView", func(g *Graph, xs []Node) Node { return g.RowView(xs[0], 1) }, []mat.Matrix{matrix()}},
+		{"ColView", func(g *Graph, xs []Node) Node { return g.ColView(xs[0], 2) }, []mat.Matrix{matrix()}},
+		{"Vec", func(g *Graph, xs []Node) Node { return g.Vec(xs[0]) }, []mat.Matrix{matrix()}},
+		{"T", func(g *Graph, xs []Node) Node { return g.T(xs[0]) }, []mat.Matrix{matrix()}},
+		{"Square", func(g *Graph, xs []Node) Node { return g.Square(xs[0]) }, []mat.Matrix{vec()}},
+		{"Pow", func(g *Graph, xs []Node) Node { return g.Pow(xs[0], 3) }, []mat.Matrix{vec()}},
+		{"Sqrt", func(g *Graph, xs []Node) Node { return g.Sqrt(xs[0]) }, []mat.Matrix{pos()}},
+		{"Tan", func(g *Graph, xs []Node) Node { return g.Tan(xs[0]) }, []mat.Matrix{vec()}},
+		{"Tanh", func(g *Graph, xs []Node) Node { return g.Tanh(xs[0]) }, []mat.Matrix{vec()}},
+		{"Sigmoid", func(g *Graph, xs []Node) Node { return g.Sigmoid(xs[0]) }, []mat.Matrix{vec()}},
+		{"Softsign", func(g *Graph, xs []Node) Node { return g.Softsign(xs[0]) }, []mat.Matrix{vec()}},
+		{"ReLU", func(g *Graph, xs []Node) Node { return g.ReLU(xs[0]) }, []mat.Matrix{vec()}},
+		{"LeakyReLU", func(g *Graph, xs []Node) Node { return g.LeakyReLU(xs[0], g.Constant(0.1)) }, []mat.Matrix{vec()}},
+		{"HardSigmoid", func(g *Graph, xs []Node) Node { return g.HardSigmoid(xs[0]) }, []mat.Matrix{vec()}},
+		{"HardTanh", func(g *Graph, xs []Node) Node { return g.HardTanh(g.ProdScalar(xs[0], g.Constant(2))) }, []mat.Matrix{vec()}},
+		{"CELU", func(g *Graph, xs []Node) Node { return g.CELU(xs[0], g.Constant(0.5)) }, []mat.Matrix{vec()}},
+		{"GELU", func(g *Graph, xs []Node) Node { return g.GELU(xs[0]) }, []mat.Matrix{vec()}},
+		{"ELU", func(g *Graph, xs []Node) Node { return g.ELU(xs[0], g.Constant(0.5)) }, []mat.Matrix{vec()}},
+		{"Swish", func(g *Graph, xs []Node) Node { return g.Swish(xs[0], xs[1]) }, []mat.Matrix{vec(), mat.NewScalar(0.8)}},
+		{"Mish", func(g *Graph, xs []Node) Node { return g.Mish(xs[0]) }, []mat.Matrix{vec()}},
+		{"SELU", func(g *Graph, xs []Node) Node { return g.SELU(xs[0], g.Constant(0.5), g.Constant(1.5)) }, []mat.Matrix{vec()}},
+		{"SoftPlus", func(g *Graph, xs []Node) Node { return g.SoftPlus(xs[0], g.Constant(2), g.Constant(0.5)) }, []mat.Matrix{vec()}},
+		{"SoftShrink", func(g *Graph, xs []Node) Node { return g.SoftShrink(xs[0], g.Constant(0.2)) }, []mat.Matrix{vec()}},
+		{"Threshold", func(g *Graph, xs []Node) Node { return g.Threshold(xs[0], g.Constant(0.2), g.Constant(5)) }, []mat.Matrix{vec()}},
+		{"SparseMax", func(g *Graph, xs []Node) Node { return g.Prod(g.SparseMax(xs[0]), xs[1]) }, []mat.Matrix{vec(), other()}},
+		{"SparseMaxLoss", func(g *Graph, xs []Node) Node { return g.Prod(g.SparseMaxLoss(xs[0]), xs[1]) }, []mat.Matrix{vec(), other()}},
+		{"MaxPooling", func(g *Graph, xs []Node) Node { return g.Prod(g.MaxPooling(xs[0], 2, 2), xs[1]) },
+			[]mat.Matrix{mat.NewDense(4, 4, []mat.Float{
+				0.1, 0.5, -0.2, 0.3, 0.2, -0.4, 0.9, 0.1, 0.7, 0.3, 0.2, 0.6, -0.1, 0.8, 0.4, 0.5}),
+				mat.NewDense(2, 2, []mat.Float{0.5, -1, 2, 0.3})}},
+		{"RotateR", func(g *Graph, xs []Node) Node { return g.Prod(g.RotateR(xs[0], 1), xs[1]) }, []mat.Matrix{vec(), other()}},
+		{"AffineActivation", func(g *Graph, xs []Node) Node { return g.AffineActivation(xs[0], xs[1], xs[2], OpTanh) },
+			[]mat.Matrix{mat.NewVecDense([]mat.Float{0.1, -0.3}), matrix(), mat.NewDense(3, 2, []mat.Float{0.2, 0.4, -0.1, 0.5, 0.3, -0.6})}},
+		{"AffineActivation unfused", func(g *Graph, xs []Node) Node { return g.AffineActivation(xs[0], xs[1], xs[2], OpSoftsign) },
+			[]mat.Matrix{mat.NewVecDense([]mat.Float{0.1, -0.3}), matrix(), mat.NewVecDense([]mat.Float{0.2, 0.4, -0.1})}},
+		{"ScaledMaskedSoftmax", func(g *Graph, xs []Node) Node {
+			mask := mat.NewVecDense([]mat.Float{0, mat.Inf(-1), 0, 0})
+			return g.Prod(g.ScaledMaskedSoftmax(xs[0], 0.5, mask), xs[1])
+		}, []mat.Matrix{vec(), other()}},
+		{"LayerNorm", func(g *Graph, xs []Node) Node { return g.Prod(g.LayerNorm(xs[0], xs[1], xs[2], 1e-5), xs[3]) },
+			[]mat.Matrix{vec(), pos(), other(), mat.NewVecDense([]mat.Float{1, -2, 0.5, 3})}},
+		{"AddLayerNorm", func(g *Graph, xs []Node) Node { return g.Prod(g.AddLayerNorm(xs[0], xs[1], xs[2], xs[3], 1e-5), xs[0]) },
+			[]mat.Matrix{vec(), other(), pos(), vec()}},
+		{"Softmax", func(g *Graph, xs []Node) Node { return g.Prod(g.Softmax(xs[0]), xs[1]) }, []mat.Matrix{vec(), other()}},
+		{"Sin", func(g *Graph, xs []Node) Node { return g.Sin(xs[0]) }, []mat.Matrix{vec()}},
+		{"Cos", func(g *Graph, xs []Node) Node { return g.Cos(xs[0]) }, []mat.Matrix{vec()}},
+		{"Exp", func(g *Graph, xs []Node) Node { return g.Exp(xs[0]) }, []mat.Matrix{vec()}},
+		{"Log", func(g *Graph, xs []Node) Node { return g.Log(xs[0]) }, []mat.Matrix{pos()}},
+		{"Abs", func(g *Graph, xs []Node) Node { return g.Abs(xs[0]) }, []mat.Matrix{vec()}},
+		{"Neg", func(g *Graph, xs []Node) Node { return g.Neg(xs[0]) }, []mat.Matrix{vec()}},
+		{"Reciprocal", func(g *Graph, xs []Node) Node { return g.Reciprocal(xs[0]) }, []mat.Matrix{pos()}},
+		{"ReduceSum", func(g *Graph, xs []Node) Node { return g.ReduceSum(xs[0]) }, []mat.Matrix{vec()}},
+		{"ReduceMean", func(g *Graph, xs []Node) Node { return g.ReduceMean(xs[0]) }, []mat.Matrix{vec()}},
+		{"Concat", func(g *Graph, xs []Node) Node { return g.Prod(g.Concat(xs[0], xs[1]), g.Concat(xs[1], xs[0])) },
+			[]mat.Matrix{vec(), mat.NewVecDense([]mat.Float{1, 2})}},
+		{"Stack", func(g *Graph, xs []Node) Node { return g.Prod(g.Stack(xs[0], xs[1]), g.Stack(xs[1], xs[1])) },
+			[]mat.Matrix{vec(), pos()}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g1 := NewGraph(RandSeed(1))
+			xs1 := newGradientsTestVariables(g1, c.inputs)
+			g1.Backward(c.f(g1, xs1))
+
+			g2 := NewGraph(RandSeed(1))
+			xs2 := newGradientsTestVariables(g2, c.inputs)
+			gxs, err := g2.Gradients(c.f(g2, xs2), xs2...)
+			require.NoError(t, err)
+			for i, gx := range gxs {
+				require.NotNil(t, gx)
+				assert.True(t, mat.SameDims(xs2[i].Value(), gx.Value()))
+				assert.InDeltaSlice(t, xs1[i].Grad().Data(), gx.Value().Data(), 1.0e-6)
+			}
+		})
+	}
+}
+
+func newGradientsTestVariables(g *Graph, values []mat.Matrix) []Node {
+	xs := make([]Node, len(values))
+	for i, v := range values {
+		xs[i] = g.NewVariable(v.Clone(), true)
+	}
+	return xs
+}
+
+func TestGraph_Gradients_HessianVectorProduct(t *testing.T) {
+	g := NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1, 2, 3}), true)
+	v := g.NewVariable(mat.NewVecDense([]mat.Float{0.5, -1, 2}), false)
+
+	y := g.ReduceSum(g.Pow(x, 3))
+	gxs, err := g.Gradients(y, x)
+	require.NoError(t, err)
+	gx := gxs[0] // 3x²
+	assert.InDeltaSlice(t, []mat.Float{3, 12, 27}, gx.Value().Data(), 1.0e-6)
+
+	hvs, err := g.Gradients(g.Dot(gx, v), x)
+	require.NoError(t, err)
+	hv := hvs[0] // 6x * v
+	assert.InDeltaSlice(t, []mat.Float{3, -12, 36}, hv.Value().Data(), 1.0e-5)
+}
+
+func TestGraph_Gradients_GradientPenalty(t *testing.T) {
+	g := NewGraph()
+	w := g.NewVariable(mat.NewVecDense([]mat.Float{0.5, -1}), true)
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{2, 3}), true)
+
+	y := g.ReduceSum(g.Prod(w, g.Square(x)))
+	gxs, err := g.Gradients(y, x)
+	require.NoError(t, err)
+	gx := gxs[0] // 2wx
+	penalty := g.ReduceSum(g.Square(gx))
+	assert.InDelta(t, 4*0.25*4+4*1*9, penalty.ScalarValue(), 1.0e-5)
+
+	g.Backward(penalty)
+	// d/dw (4w²x²) = 8wx²
+	assert.InDeltaSlice(t, []mat.Float{8 * 0.5 * 4, 8 * -1 * 9}, w.Grad().Data(), 1.0e-5)
+	// d/dx (4w²x²) = 8w²x
+	assert.InDeltaSlice(t, []mat.Float{8 * 0.25 * 2, 8 * 1 * 3}, x.Grad().Data(), 1.0e-5)
+}
+
+func TestGraph_Gradients_NoDependency(t *testing.T) {
+	g := NewGraph()
+	x1 := g.NewVariable(mat.NewVecDense([]mat.Float{1, 2}), true)
+	x2 := g.NewVariable(mat.NewVecDense([]mat.Float{1, 2}), true)
+	gxs, err := g.Gradients(g.ReduceSum(x1), x1, x2)
+	require.NoError(t, err)
+	assert.NotNil(t, gxs[0])
+	assert.Nil(t, gxs[1])
+}
+
+func TestGraph_Gradients_Unsupported(t *testing.T) {
+	g := NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1, 2}), true)
+	y := g.ReduceSum(g.NewOperator(fn.NewSqrt(x), x))
+	_, err := g.Gradients(y, x)
+	assert.EqualError(t, err, "ag: higher-order gradients are not supported by UnaryElementwise")
+}
+
+func TestGraph_Gradients_SecondOrderActivation(t *testing.T) {
+	g := NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{-1, 2}), true)
+	gxs, err := g.Gradients(g.ReduceSum(g.ELU(x, g.Constant(0.5))), x)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []mat.Float{0.5 * mat.Exp(-1), 1}, gxs[0].Value().Data(), 1.0e-6)
+
+	// d²/dx² ELU(x) = alpha * exp(x) for x <= 0, 0 otherwise
+	hs, err := g.Gradients(g.ReduceSum(gxs[0]), x)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []mat.Float{0.5 * mat.Exp(-1), 0}, hs[0].Value().Data(), 1.0e-6)
+}
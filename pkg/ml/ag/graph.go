@@ -29,6 +29,8 @@ type Graph struct {
 	constants map[mat.Float]Node
 	// IncrementalForward sets whether to compute the forward during the graph definition (default true).
 	incrementalForward bool
+	// fusedOperators sets whether the models can use fused operators (default false).
+	fusedOperators bool
 	// cache of the support structures created during the last groupNodesByHeight() computation.
 	// Before using it you have to check if the maxID of the graph matches the maxID of the cache.
 	// Otherwise the cache must be invalidated and the values recalculated.
@@ -46,6 +48,10 @@ type Graph struct {
 	// such as forward and backward steps.
 	// The default size is defaultProcessingQueueSize.
 	processingQueue processingqueue.ProcessingQueue
+	// checkpoints contains the segments of the graph whose values are recomputed when needed (see Checkpoint).
+	checkpoints []*checkpoint
+	// curCheckpoint is the checkpoint the new operators are assigned to, if any.
+	curCheckpoint *checkpoint
 }
 
 // defaultProcessingQueueSize is the default size of Graph.processingQueue on a new Graph.
@@ -90,6 +96,15 @@ func ConcurrentComputations(value int) GraphOption {
 	}
 }
 
+// FusedOperators sets whether the models can replace common patterns of operators with fused
+// operators (default false), such as Graph.AffineActivation, Graph.ScaledMaskedSoftmax and
+// Graph.AddLayerNorm. They compute the same values with fewer nodes and allocations.
+func FusedOperators(value bool) GraphOption {
+	return func(g *Graph) {
+		g.fusedOperators = value
+	}
+}
+
 // NewGraph returns a new initialized graph.
 // It can take an optional random generator of type rand.Rand.
 func NewGraph(opts ...GraphOption) *Graph {
@@ -130,6 +145,7 @@ func (g *Graph) Clear() {
 	g.clearCache()
 	g.releaseMemory()
 	g.nodes = nil
+	g.checkpoints = nil
 }
 
 // clearCache cleans the cache.
@@ -162,6 +178,9 @@ func (g *Graph) releaseMemory() {
 			g.releaseGrad(node)
 		}
 	}
+	for _, c := range g.checkpoints {
+		c.dropped = false // the values are recomputed by the next Forward()
+	}
 }
 
 // releaseValue set the node value to nil release the memory.
@@ -234,6 +253,7 @@ func (g *Graph) NewOperator(f fn.Function, operands ...Node) Node {
 	}
 	var value mat.Matrix = nil
 	if g.incrementalForward {
+		restoreValues(operands...)
 		// the calculation is out of the lock so it can run concurrently with other operators
 		g.processingQueue.Run(func() {
 			value = f.Forward()
@@ -258,6 +278,10 @@ func (g *Graph) NewOperator(f fn.Function, operands ...Node) Node {
 		grad:         nil,
 		hasGrad:      false,
 		requiresGrad: requiresGrad,
+		checkpoint:   g.curCheckpoint,
+	}
+	if g.curCheckpoint != nil {
+		g.curCheckpoint.operators = append(g.curCheckpoint.operators, newNode)
 	}
 	// the new ID is sequential so it corresponds to the index in g.nodes
 	g.nodes = append(g.nodes, newNode)
@@ -340,11 +364,13 @@ func (g *Graph) Forward(opts ...ForwardOption) {
 		}
 	}
 
-	if g.processingQueue.Size() > 1 {
+	// the checkpoints are recomputed sequentially, to draw the same random numbers
+	if g.processingQueue.Size() > 1 && len(g.checkpoints) == 0 {
 		handler.runConcurrent()
 	} else {
 		handler.runSerial()
 	}
+	g.dropCheckpoints()
 }
 
 // BackwardOption allows to adapt the Backward() to your specific needs.
@@ -390,6 +416,7 @@ func (g *Graph) Backward(node Node, opts ...BackwardOption) {
 	for _, opt := range opts {
 		opt(handler)
 	}
+	g.resetCheckpoints()
 	if !node.HasGrad() {
 		handler.propagateOutputGrad()
 	}
@@ -398,6 +425,7 @@ func (g *Graph) Backward(node Node, opts ...BackwardOption) {
 	} else {
 		handler.runSerial()
 	}
+	g.dropCheckpoints()
 }
 
 // BackwardAll performs full back-propagation from the last node of the graph.
@@ -409,11 +437,13 @@ func (g *Graph) BackwardAll() {
 		outputGrad:     nil,
 		stopAtTimeStep: -1, // no stop
 	}
+	g.resetCheckpoints()
 	if g.processingQueue.Size() > 1 {
 		handler.runConcurrent()
 	} else {
 		handler.runSerial()
 	}
+	g.dropCheckpoints()
 }
 
 // GetCopiedValue returns a copy of the value of a Node. If the value is nil, GetCopiedValue returns nil as well.
@@ -466,6 +496,11 @@ func (g *Graph) ConcurrentComputations() int {
 	return g.processingQueue.Size()
 }
 
+// FusedOperators reports whether the models can use fused operators (see the FusedOperators option).
+func (g *Graph) FusedOperators() bool {
+	return g.fusedOperators
+}
+
 // newID generates and returns a new incremental sequential ID.
 func (g *Graph) newID() int {
 	g.maxID++
@@ -24,6 +24,10 @@ func (h *forwardHandler) runSerial() {
 			if h.toTimeStep != -1 && op.timeStep > h.toTimeStep {
 				continue
 			}
+			if op.checkpoint != nil && op.checkpoint.operators[0] == op {
+				op.checkpoint.reseed()
+			}
+			restoreOperands(op)
 			op.value = op.function.Forward()
 		}
 	}
@@ -59,6 +63,7 @@ type backwardHandler struct {
 }
 
 func (h *backwardHandler) propagateOutputGrad() {
+	restoreValues(h.node)
 	gx := h.outputGrad
 	if gx == nil {
 		gx = h.node.Value().OnesLike()
@@ -78,7 +83,11 @@ func (h *backwardHandler) runSerial() {
 			break
 		}
 		if node, ok := nodes[i].(*operator); ok {
+			node.restoreForBackward()
 			node.backward()
+			if node.checkpoint != nil && node.checkpoint.visit() {
+				node.checkpoint.drop()
+			}
 		}
 	}
 }
@@ -91,6 +100,7 @@ func (h *backwardHandler) runConcurrent() {
 	lastNodeIndex := h.node.ID()
 	var wg sync.WaitGroup
 	for i := lastGroupIndex; i >= 0; i-- {
+		var visited []*checkpoint
 		for _, node := range groups[i] {
 			if truncated && node.TimeStep() <= stopAtTimeStep {
 				break
@@ -102,6 +112,11 @@ func (h *backwardHandler) runConcurrent() {
 			if op.id > lastNodeIndex {
 				continue
 			}
+			// the values are restored before starting the concurrent computations
+			op.restoreForBackward()
+			if op.checkpoint != nil && op.checkpoint.visit() {
+				visited = append(visited, op.checkpoint)
+			}
 			wg.Add(1)
 			h.g.processingQueue.Go(func() {
 				defer wg.Done()
@@ -109,5 +124,8 @@ func (h *backwardHandler) runConcurrent() {
 			})
 		}
 		wg.Wait()
+		for _, c := range visited {
+			c.drop()
+		}
 	}
 }
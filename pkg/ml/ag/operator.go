@@ -27,6 +27,8 @@ type operator struct {
 	grad         mat.Matrix // TODO: support of sparse gradients
 	hasGrad      bool
 	requiresGrad bool
+	checkpoint   *checkpoint // the segment whose values are recomputed when needed, if any
+	gradFunc     GradFunc    // the backward pass in terms of nodes of the graph, if any (see Graph.Gradients)
 }
 
 // ID returns the ID of the node in the graph.
@@ -238,301 +238,309 @@ func (g *Graph) Invoke(operator OpName, xs ...Node) Node {
 
 // Identity returns a new operator node as a result of the fn.Identity function.
 func (g *Graph) Identity(x Node) Node {
-	return g.NewOperator(fn.NewIdentity(x), x)
+	return g.NewOperatorWithGrad(fn.NewIdentity(x), identityGrad, x)
 }
 
 // Dropout returns a new operator node as a result of the fn.Dropout function.
 func (g *Graph) Dropout(x Node, p mat.Float) Node {
-	return g.NewOperator(fn.NewDropout(x, p, g.randGen), x)
+	return g.NewOperatorWithGrad(fn.NewDropout(x, p, g.randGen), dropoutGrad, x)
 }
 
 // AtVec returns a new operator node as a result of the fn.AtVec function.
 func (g *Graph) AtVec(x Node, i int) Node {
-	return g.NewOperator(fn.NewAtVec(x, i), x)
+	return g.NewOperatorWithGrad(fn.NewAtVec(x, i), atVecGrad(i), x)
 }
 
 // At returns a new operator node as a result of the fn.At function.
 func (g *Graph) At(x Node, i int, j int) Node {
-	return g.NewOperator(fn.NewAt(x, i, j), x)
+	return g.NewOperatorWithGrad(fn.NewAt(x, i, j), atGrad(i, j), x)
 }
 
 // Add returns a new operator node as a result of the fn.Add function.
+// The operands are broadcast following the NumPy rules, so that a scalar, a
+// row vector or a column vector can be combined with a matrix.
 // The first node may be null. This help to keep the code as concise as possible e.g. during accumulation.
 func (g *Graph) Add(x1 Node, x2 Node) Node {
 	if x1 != nil {
-		return g.NewOperator(fn.NewAdd(x1, x2), x1, x2)
+		return g.NewOperatorWithGrad(fn.NewAdd(x1, x2), addGrad, x1, x2)
 	}
 	fake := g.NewVariable(nil, false)
-	return g.NewOperator(fn.NewAdd(fake, x2), fake, x2)
+	return g.NewOperatorWithGrad(fn.NewAdd(fake, x2), addGrad, fake, x2)
 }
 
 // Sub returns a new operator node as a result of the fn.Sub function.
+// The operands are broadcast following the NumPy rules, so that a scalar, a
+// row vector or a column vector can be combined with a matrix.
 func (g *Graph) Sub(x1 Node, x2 Node) Node {
-	return g.NewOperator(fn.NewSub(x1, x2), x1, x2)
+	return g.NewOperatorWithGrad(fn.NewSub(x1, x2), subGrad, x1, x2)
 }
 
 // SubScalar returns a new operator node as a result of the fn.SubScalar function.
 func (g *Graph) SubScalar(x1 Node, x2 Node) Node {
-	return g.NewOperator(fn.NewSubScalar(x1, x2), x1, x2)
+	return g.NewOperatorWithGrad(fn.NewSubScalar(x1, x2), subGrad, x1, x2)
 }
 
 // AddScalar returns a new operator node as a result of the fn.AddScalar function.
 func (g *Graph) AddScalar(x1 Node, x2 Node) Node {
-	return g.NewOperator(fn.NewAddScalar(x1, x2), x1, x2)
+	return g.NewOperatorWithGrad(fn.NewAddScalar(x1, x2), addGrad, x1, x2)
 }
 
 // ReverseSub returns a new operator node as a result of the fn.ReverseSub function.
 func (g *Graph) ReverseSub(x1 Node, x2 Node) Node {
-	return g.NewOperator(fn.NewReverseSubScalar(x1, x2), x1, x2)
+	return g.NewOperatorWithGrad(fn.NewReverseSubScalar(x1, x2), reverseSubGrad, x1, x2)
 }
 
 // Prod returns a new operator node as a result of the fn.Prod function.
+// The operands are broadcast following the NumPy rules, so that a scalar, a
+// row vector or a column vector can be combined with a matrix.
 func (g *Graph) Prod(x1 Node, x2 Node) Node {
-	return g.NewOperator(fn.NewProd(x1, x2), x1, x2)
+	return g.NewOperatorWithGrad(fn.NewProd(x1, x2), prodGrad, x1, x2)
 }
 
 // Div returns a new operator node as a result of the fn.Div function.
+// The operands are broadcast following the NumPy rules, so that a scalar, a
+// row vector or a column vector can be combined with a matrix.
 func (g *Graph) Div(x1 Node, x2 Node) Node {
-	return g.NewOperator(fn.NewDiv(x1, x2), x1, x2)
+	return g.NewOperatorWithGrad(fn.NewDiv(x1, x2), divGrad, x1, x2)
 }
 
 // ProdScalar returns a new operator node as a result of the fn.ProdScalar function.
 func (g *Graph) ProdScalar(x1 Node, x2 Node) Node {
-	return g.NewOperator(fn.NewProdScalar(x1, x2), x1, x2)
+	return g.NewOperatorWithGrad(fn.NewProdScalar(x1, x2), prodGrad, x1, x2)
 }
 
 // DivScalar returns a new operator node as a result of the fn.DivScalar function.
 func (g *Graph) DivScalar(x1 Node, x2 Node) Node {
-	return g.NewOperator(fn.NewDivScalar(x1, x2), x1, x2)
+	return g.NewOperatorWithGrad(fn.NewDivScalar(x1, x2), divGrad, x1, x2)
 }
 
 // Mul returns a new operator node as a result of the fn.Mul function.
 func (g *Graph) Mul(x1 Node, x2 Node) Node {
-	return g.NewOperator(fn.NewMul(x1, x2), x1, x2)
+	return g.NewOperatorWithGrad(fn.NewMul(x1, x2), mulGrad, x1, x2)
 }
 
 // Dot returns a new operator node as a result of the fn.Dot function.
 func (g *Graph) Dot(x1 Node, x2 Node) Node {
-	return g.NewOperator(fn.NewDot(x1, x2), x1, x2)
+	return g.NewOperatorWithGrad(fn.NewDot(x1, x2), dotGrad, x1, x2)
 }
 
 // Max returns a new operator node as a result of the fn.Max function.
 func (g *Graph) Max(x1 Node, x2 Node) Node {
-	return g.NewOperator(fn.NewMax(x1, x2), x1, x2)
+	return g.NewOperatorWithGrad(fn.NewMax(x1, x2), maxGrad, x1, x2)
 }
 
 // Min returns a new operator node as a result of the fn.Min function.
 func (g *Graph) Min(x1 Node, x2 Node) Node {
-	return g.NewOperator(fn.NewMin(x1, x2), x1, x2)
+	return g.NewOperatorWithGrad(fn.NewMin(x1, x2), minGrad, x1, x2)
 }
 
 // Reshape returns a new operator node as a result of the fn.Reshape function.
 func (g *Graph) Reshape(x Node, rows, columns int) Node {
-	return g.NewOperator(fn.NewReshape(x, rows, columns), x)
+	return g.NewOperatorWithGrad(fn.NewReshape(x, rows, columns), reshapeGrad, x)
 }
 
 // MaxPooling returns a new operator node as a result of the fn.MaxPooling function.
 func (g *Graph) MaxPooling(x Node, rows, columns int) Node {
-	return g.NewOperator(fn.NewMaxPooling(x, rows, columns), x)
+	return g.NewOperatorWithGrad(fn.NewMaxPooling(x, rows, columns), maxPoolingGrad(rows, columns), x)
 }
 
 // View returns a new operator node as a result of the fn.View function.
 func (g *Graph) View(x Node, row, column, xStride, yStride int) Node {
-	return g.NewOperator(fn.NewView(x, row, column, xStride, yStride), x)
+	return g.NewOperatorWithGrad(fn.NewView(x, row, column, xStride, yStride), viewGrad(row, column, xStride, yStride), x)
 }
 
 // RowView returns a new operator node as a result of the fn.RowView function.
 func (g *Graph) RowView(x Node, row int) Node {
-	return g.NewOperator(fn.NewRowView(x, row), x)
+	return g.NewOperatorWithGrad(fn.NewRowView(x, row), rowViewGrad(row), x)
 }
 
 // RotateR performs the right circular shift.
 // `i` is the number of places by which the elements are shifted.
 func (g *Graph) RotateR(x Node, i int) Node {
-	return g.NewOperator(fn.NewRotateR(x, i), x)
+	return g.NewOperatorWithGrad(fn.NewRotateR(x, i), rotateRGrad(i), x)
 }
 
 // ColView returns a new operator node as a result of the fn.ColView function.
 func (g *Graph) ColView(x Node, column int) Node {
-	return g.NewOperator(fn.NewColView(x, column), x)
+	return g.NewOperatorWithGrad(fn.NewColView(x, column), colViewGrad(column), x)
 }
 
 // Vec returns a new operator node as a result of the fn.Vec function.
 func (g *Graph) Vec(x Node) Node {
-	return g.NewOperator(fn.NewVec(x), x)
+	return g.NewOperatorWithGrad(fn.NewVec(x), reshapeGrad, x)
 }
 
 // T returns a new operator node as a result of the fn.T function.
 func (g *Graph) T(x Node) Node {
-	return g.NewOperator(fn.NewTranspose(x), x)
+	return g.NewOperatorWithGrad(fn.NewTranspose(x), transposeGrad, x)
 }
 
 // Square returns a new operator node as a result of the fn.Prod(x, x) function.
 func (g *Graph) Square(x Node) Node {
-	return g.NewOperator(fn.NewSquare(x), x)
+	return g.NewOperatorWithGrad(fn.NewSquare(x), squareGrad, x)
 }
 
 // Pow returns a new operator node as a result of the fn.Pow function.
 func (g *Graph) Pow(x Node, power mat.Float) Node {
-	return g.NewOperator(fn.NewPow(x, power), x)
+	return g.NewOperatorWithGrad(fn.NewPow(x, power), powGrad(power), x)
 }
 
 // Sqrt returns a new operator node as a result of the `Sqrt` function.
 func (g *Graph) Sqrt(x Node) Node {
-	return g.NewOperator(fn.NewSqrt(x), x)
+	return g.NewOperatorWithGrad(fn.NewSqrt(x), sqrtGrad, x)
 }
 
 // Tan returns a new operator node as a result of the `Tan` function.
 func (g *Graph) Tan(x Node) Node {
-	return g.NewOperator(fn.NewTan(x), x)
+	return g.NewOperatorWithGrad(fn.NewTan(x), tanGrad, x)
 }
 
 // Tanh returns a new operator node as a result of the `Tanh` function.
 func (g *Graph) Tanh(x Node) Node {
-	return g.NewOperator(fn.NewTanh(x), x)
+	return g.NewOperatorWithGrad(fn.NewTanh(x), tanhGrad, x)
 }
 
 // Sigmoid returns a new operator node as a result of the `Sigmoid` function.
 func (g *Graph) Sigmoid(x Node) Node {
-	return g.NewOperator(fn.NewSigmoid(x), x)
+	return g.NewOperatorWithGrad(fn.NewSigmoid(x), sigmoidGrad, x)
 }
 
 // HardSigmoid returns a new operator node as a result of the `HardSigmoid` function.
 func (g *Graph) HardSigmoid(x Node) Node {
-	return g.NewOperator(fn.NewHardSigmoid(x), x)
+	return g.NewOperatorWithGrad(fn.NewHardSigmoid(x), derivativeGrad, x)
 }
 
 // HardTanh returns a new operator node as a result of the `HardTanh` function.
 func (g *Graph) HardTanh(x Node) Node {
-	return g.NewOperator(fn.NewHardTanh(x), x)
+	return g.NewOperatorWithGrad(fn.NewHardTanh(x), derivativeGrad, x)
 }
 
 // Softsign returns a new operator node as a result of the `SoftSign` function.
 func (g *Graph) Softsign(x Node) Node {
-	return g.NewOperator(fn.NewSoftsign(x), x)
+	return g.NewOperatorWithGrad(fn.NewSoftsign(x), softsignGrad, x)
 }
 
 // ReLU returns a new operator node as a result of the `ReLU` function.
 func (g *Graph) ReLU(x Node) Node {
-	return g.NewOperator(fn.NewReLU(x), x)
+	return g.NewOperatorWithGrad(fn.NewReLU(x), derivativeGrad, x)
 }
 
 // CELU returns a new operator node as a result of the fn.CELU function.
 func (g *Graph) CELU(x Node, alpha Node) Node {
-	return g.NewOperator(fn.NewCELU(x, alpha), x, alpha)
+	return g.NewOperatorWithGrad(fn.NewCELU(x, alpha), celuGrad, x, alpha)
 }
 
 // GELU returns a new operator node as a result of the fn.GELU function.
 func (g *Graph) GELU(x Node) Node {
-	return g.NewOperator(fn.NewGELU(x), x)
+	return g.NewOperatorWithGrad(fn.NewGELU(x), geluGrad, x)
 }
 
 // ELU returns a new operator node as a result of the fn.ELU function.
 func (g *Graph) ELU(x Node, alpha Node) Node {
-	return g.NewOperator(fn.NewELU(x, alpha), x, alpha)
+	return g.NewOperatorWithGrad(fn.NewELU(x, alpha), eluGrad, x, alpha)
 }
 
 // Swish returns a new operator node as a result of the fn.Swish function.
 func (g *Graph) Swish(x Node, beta Node) Node {
-	return g.NewOperator(fn.NewSwish(x, beta), x, beta)
+	return g.NewOperatorWithGrad(fn.NewSwish(x, beta), swishGrad, x, beta)
 }
 
 // Mish returns a new operator node as a result of the `Mish` function.
 func (g *Graph) Mish(x Node) Node {
-	return g.NewOperator(fn.NewMish(x), x)
+	return g.NewOperatorWithGrad(fn.NewMish(x), mishGrad, x)
 }
 
 // LeakyReLU returns a new operator node as a result of the fn.LeakyReLU function.
 func (g *Graph) LeakyReLU(x Node, alpha Node) Node {
-	return g.NewOperator(fn.NewLeakyReLU(x, alpha), x, alpha)
+	return g.NewOperatorWithGrad(fn.NewLeakyReLU(x, alpha), derivativeGrad, x, alpha)
 }
 
 // SELU returns a new operator node as a result of the fn.SELU function.
 func (g *Graph) SELU(x Node, alpha Node, scale Node) Node {
-	return g.NewOperator(fn.NewSELU(x, alpha, scale), x, alpha, scale)
+	return g.NewOperatorWithGrad(fn.NewSELU(x, alpha, scale), seluGrad, x, alpha, scale)
 }
 
 // SoftPlus returns a new operator node as a result of the fn.SoftPlus function.
 func (g *Graph) SoftPlus(x Node, beta Node, threshold Node) Node {
-	return g.NewOperator(fn.NewSoftPlus(x, beta, threshold), x, beta, threshold)
+	return g.NewOperatorWithGrad(fn.NewSoftPlus(x, beta, threshold), softPlusGrad, x, beta, threshold)
 }
 
 // SoftShrink returns a new operator node as a result of the fn.SoftShrink function.
 func (g *Graph) SoftShrink(x Node, lambda Node) Node {
-	return g.NewOperator(fn.NewSoftShrink(x, lambda), x, lambda)
+	return g.NewOperatorWithGrad(fn.NewSoftShrink(x, lambda), derivativeGrad, x, lambda)
 }
 
 // Threshold returns a new operator node as a result of the fn.Threshold function.
 func (g *Graph) Threshold(x Node, threshold Node, k Node) Node {
-	return g.NewOperator(fn.NewThreshold(x, threshold, k), x, threshold, k)
+	return g.NewOperatorWithGrad(fn.NewThreshold(x, threshold, k), derivativeGrad, x, threshold, k)
 }
 
 // Softmax returns a new operator node as a result of the fn.Softmax function.
 func (g *Graph) Softmax(x Node) Node {
-	return g.NewOperator(fn.NewSoftmax(x), x)
+	return g.NewOperatorWithGrad(fn.NewSoftmax(x), softmaxGrad, x)
 }
 
 // SparseMax returns a new operator node as a result of the fn.SparseMax function.
 func (g *Graph) SparseMax(x Node) Node {
-	return g.NewOperator(fn.NewSparseMax(x), x)
+	return g.NewOperatorWithGrad(fn.NewSparseMax(x), sparseMaxGrad, x)
 }
 
 // SparseMaxLoss returns a new operator node as a result of the fn.SparseMaxLoss function.
 func (g *Graph) SparseMaxLoss(x Node) Node {
-	return g.NewOperator(fn.NewSparseMaxLoss(x), x)
+	return g.NewOperatorWithGrad(fn.NewSparseMaxLoss(x), sparseMaxLossGrad, x)
 }
 
 // Sin returns a new operator node as a result of the `Sin` function.
 func (g *Graph) Sin(x Node) Node {
-	return g.NewOperator(fn.NewSin(x), x)
+	return g.NewOperatorWithGrad(fn.NewSin(x), sinGrad, x)
 }
 
 // Cos returns a new operator node as a result of the `Cos` function.
 func (g *Graph) Cos(x Node) Node {
-	return g.NewOperator(fn.NewCos(x), x)
+	return g.NewOperatorWithGrad(fn.NewCos(x), cosGrad, x)
 }
 
 // Exp returns a new operator node as a result of the `Exp` function.
 func (g *Graph) Exp(x Node) Node {
-	return g.NewOperator(fn.NewExp(x), x)
+	return g.NewOperatorWithGrad(fn.NewExp(x), expGrad, x)
 }
 
 // Log returns a new operator node as a result of the `Log` function.
 func (g *Graph) Log(x Node) Node {
-	return g.NewOperator(fn.NewLog(x), x)
+	return g.NewOperatorWithGrad(fn.NewLog(x), logGrad, x)
 }
 
 // Abs returns a new operator node as a result of the `Abs` function.
 func (g *Graph) Abs(x Node) Node {
-	return g.NewOperator(fn.NewAbs(x), x)
+	return g.NewOperatorWithGrad(fn.NewAbs(x), absGrad, x)
 }
 
 // Neg returns a new operator node as a result of the `Neg` function.
 func (g *Graph) Neg(x Node) Node {
-	return g.NewOperator(fn.NewNeg(x), x)
+	return g.NewOperatorWithGrad(fn.NewNeg(x), negGrad, x)
 }
 
 // Reciprocal returns a new operator node as a result of the `Reciprocal` function.
 func (g *Graph) Reciprocal(x Node) Node {
-	return g.NewOperator(fn.NewReciprocal(x), x)
+	return g.NewOperatorWithGrad(fn.NewReciprocal(x), reciprocalGrad, x)
 }
 
 // ReduceSum returns a new operator node as a result of the fn.ReduceSum function.
 func (g *Graph) ReduceSum(x Node) Node {
-	return g.NewOperator(fn.NewReduceSum(x), x)
+	return g.NewOperatorWithGrad(fn.NewReduceSum(x), reduceSumGrad, x)
 }
 
 // ReduceMean returns a new operator node as a result of the fn.ReduceMean function.
 func (g *Graph) ReduceMean(x Node) Node {
-	return g.NewOperator(fn.NewReduceMean(x), x)
+	return g.NewOperatorWithGrad(fn.NewReduceMean(x), reduceMeanGrad, x)
 }
 
 // Concat returns a new operator node as a result of the fn.Concat function.
 func (g *Graph) Concat(xs ...Node) Node {
-	return g.NewOperator(fn.NewConcat(Operands(xs)), xs...)
+	return g.NewOperatorWithGrad(fn.NewConcat(Operands(xs)), concatGrad, xs...)
 }
 
 // Stack returns a new operator node as a result of the fn.Stack function.
 func (g *Graph) Stack(xs ...Node) Node {
-	return g.NewOperator(fn.NewStack(Operands(xs)), xs...)
+	return g.NewOperatorWithGrad(fn.NewStack(Operands(xs)), stackGrad, xs...)
 }
@@ -0,0 +1,60 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+)
+
+// This file contains the fused operators, which replace common patterns of
+// operators with a single node (see the FusedOperators option).
+
+// fusedAffineFuncs maps the activations supported by AffineActivation to the
+// constructors of the corresponding fn.Affine functions.
+var fusedAffineFuncs = map[OpName]func(b, w, x fn.Operand) *fn.Affine{
+	OpIdentity: fn.NewAffine,
+	OpReLU:     fn.NewAffineReLU,
+	OpGELU:     fn.NewAffineGELU,
+	OpTanh:     fn.NewAffineTanh,
+	OpSigmoid:  fn.NewAffineSigmoid,
+}
+
+// IsFusableActivation reports whether the activation can be fused into
+// AffineActivation.
+func IsFusableActivation(activation OpName) bool {
+	_, ok := fusedAffineFuncs[activation]
+	return ok
+}
+
+// AffineActivation returns a new operator node as a result of the fn.Affine
+// function, that is activation(w (dot) x + b).
+// The activations which cannot be fused (see IsFusableActivation) are applied
+// by a separate operator.
+func (g *Graph) AffineActivation(b, w, x Node, activation OpName) Node {
+	if newAffine, ok := fusedAffineFuncs[activation]; ok {
+		return g.NewOperatorWithGrad(newAffine(b, w, x), affineGrad(activation), b, w, x)
+	}
+	return g.Invoke(activation, g.NewOperatorWithGrad(fn.NewAffine(b, w, x), affineGrad(OpIdentity), b, w, x))
+}
+
+// ScaledMaskedSoftmax returns a new operator node as a result of the
+// fn.ScaledMaskedSoftmax function, that is softmax(x * scale + mask).
+// The mask can be nil.
+func (g *Graph) ScaledMaskedSoftmax(x Node, scale mat.Float, mask mat.Matrix) Node {
+	return g.NewOperatorWithGrad(fn.NewScaledMaskedSoftmax(x, scale, mask), scaledMaskedSoftmaxGrad(scale, mask), x)
+}
+
+// LayerNorm returns a new operator node as a result of the layer normalization
+// of x (see fn.NewLayerNorm).
+func (g *Graph) LayerNorm(x, w, b Node, eps mat.Float) Node {
+	return g.NewOperatorWithGrad(fn.NewLayerNorm(x, w, b, eps), addLayerNormGrad(eps), x, w, b)
+}
+
+// AddLayerNorm returns a new operator node as a result of the fn.AddLayerNorm
+// function, that is the layer normalization of x1 + x2.
+func (g *Graph) AddLayerNorm(x1, x2, w, b Node, eps mat.Float) Node {
+	return g.NewOperatorWithGrad(fn.NewAddLayerNorm(x1, x2, w, b, eps), addLayerNormGrad(eps), x1, x2, w, b)
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGraph_AffineActivation(t *testing.T) {
+	for _, activation := range []OpName{OpIdentity, OpReLU, OpGELU, OpTanh, OpSigmoid, OpSoftsign} {
+		composed := NewGraph()
+		fused := NewGraph()
+		newNodes := func(g *Graph) (b, w, x Node) {
+			b = g.NewVariable(mat.NewVecDense([]mat.Float{0.1, -0.2}), true)
+			w = g.NewVariable(mat.NewDense(2, 3, []mat.Float{0.5, -0.4, 0.3, 0.2, 0.8, -0.6}), true)
+			x = g.NewVariable(mat.NewVecDense([]mat.Float{0.7, -0.3, 0.9}), true)
+			return
+		}
+		b1, w1, x1 := newNodes(composed)
+		y1 := composed.Invoke(activation, composed.Add(b1, composed.Mul(w1, x1)))
+		b2, w2, x2 := newNodes(fused)
+		y2 := fused.AffineActivation(b2, w2, x2, activation)
+
+		assert.InDeltaSlice(t, y1.Value().Data(), y2.Value().Data(), 1.0e-6)
+
+		gy := mat.NewVecDense([]mat.Float{1.0, -0.5})
+		composed.Backward(y1, OutputGrad(gy))
+		fused.Backward(y2, OutputGrad(gy))
+		assert.InDeltaSlice(t, b1.Grad().Data(), b2.Grad().Data(), 1.0e-6)
+		assert.InDeltaSlice(t, w1.Grad().Data(), w2.Grad().Data(), 1.0e-6)
+		assert.InDeltaSlice(t, x1.Grad().Data(), x2.Grad().Data(), 1.0e-6)
+	}
+	assert.False(t, IsFusableActivation(OpSoftsign))
+}
+
+func TestGraph_ScaledMaskedSoftmax(t *testing.T) {
+	g := NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{0.8, -0.2, 0.5, 1.2}), true)
+	mask := mat.NewVecDense([]mat.Float{0, 0, mat.Inf(-1), 0})
+	y1 := g.Softmax(g.Add(g.ProdScalar(x, g.NewScalar(0.5)), g.NewVariable(mask, false)))
+	y2 := g.ScaledMaskedSoftmax(x, 0.5, mask)
+	assert.InDeltaSlice(t, y1.Value().Data(), y2.Value().Data(), 1.0e-6)
+}
+
+func TestGraph_AddLayerNorm(t *testing.T) {
+	g := NewGraph()
+	x1 := g.NewVariable(mat.NewVecDense([]mat.Float{0.1, 0.5, -0.2, -0.5}), true)
+	x2 := g.NewVariable(mat.NewVecDense([]mat.Float{0.3, 0.3, -0.5, 0.0}), true)
+	w := g.NewVariable(mat.NewVecDense([]mat.Float{0.4, 0.0, -0.3, 0.8}), true)
+	b := g.NewVariable(mat.NewVecDense([]mat.Float{0.9, 0.2, -0.9, 0.2}), true)
+	y1 := g.AddLayerNorm(x1, x2, w, b, 1e-12)
+	y2 := g.LayerNorm(g.Add(x1, x2), w, b, 1e-12)
+	assert.InDeltaSlice(t, []mat.Float{1.157863, 0.2, -0.561554, -0.444658}, y1.Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, y1.Value().Data(), y2.Value().Data(), 1.0e-6)
+}
+
+func TestFusedOperators(t *testing.T) {
+	assert.False(t, NewGraph().FusedOperators())
+	assert.True(t, NewGraph(FusedOperators(true)).FusedOperators())
+}
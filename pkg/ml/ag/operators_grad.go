@@ -0,0 +1,525 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+)
+
+// This file contains the GradFunc of the operators (see Graph.Gradients).
+
+func identityGrad(y, gy Node) []Node {
+	return []Node{gy}
+}
+
+func dropoutGrad(y, gy Node) []Node {
+	x := operandsOf(y)[0]
+	return []Node{gradIf(x, func() Node {
+		mask := y.(*operator).function.(*fn.Dropout).Mask()
+		return gy.Graph().Prod(gy, gy.Graph().NewVariable(mask.Clone(), false))
+	})}
+}
+
+func atVecGrad(i int) GradFunc {
+	return func(y, gy Node) []Node {
+		x := operandsOf(y)[0]
+		return []Node{gradIf(x, func() Node {
+			g := gy.Graph()
+			rows, cols := x.Value().Dims()
+			if cols == 1 {
+				return g.ProdScalar(g.oneHot(rows, cols, i, 0), gy)
+			}
+			return g.ProdScalar(g.oneHot(rows, cols, 0, i), gy)
+		})}
+	}
+}
+
+func atGrad(i, j int) GradFunc {
+	return func(y, gy Node) []Node {
+		x := operandsOf(y)[0]
+		return []Node{gradIf(x, func() Node {
+			rows, cols := x.Value().Dims()
+			return gy.Graph().ProdScalar(gy.Graph().oneHot(rows, cols, i, j), gy)
+		})}
+	}
+}
+
+func addGrad(y, gy Node) []Node {
+	xs := operandsOf(y)
+	return []Node{
+		gradIf(xs[0], func() Node { return sumTo(gy, xs[0]) }),
+		gradIf(xs[1], func() Node { return sumTo(gy, xs[1]) }),
+	}
+}
+
+func subGrad(y, gy Node) []Node {
+	xs := operandsOf(y)
+	return []Node{
+		gradIf(xs[0], func() Node { return sumTo(gy, xs[0]) }),
+		gradIf(xs[1], func() Node { return sumTo(gy.Graph().Neg(gy), xs[1]) }),
+	}
+}
+
+// reverseSubGrad is the GradFunc of ReverseSub, whose result is x2 - x1.
+func reverseSubGrad(y, gy Node) []Node {
+	xs := operandsOf(y)
+	return []Node{
+		gradIf(xs[0], func() Node { return sumTo(gy.Graph().Neg(gy), xs[0]) }),
+		gradIf(xs[1], func() Node { return sumTo(gy, xs[1]) }),
+	}
+}
+
+func prodGrad(y, gy Node) []Node {
+	g, xs := gy.Graph(), operandsOf(y)
+	return []Node{
+		gradIf(xs[0], func() Node { return sumTo(g.Prod(gy, xs[1]), xs[0]) }),
+		gradIf(xs[1], func() Node { return sumTo(g.Prod(gy, xs[0]), xs[1]) }),
+	}
+}
+
+func divGrad(y, gy Node) []Node {
+	g, xs := gy.Graph(), operandsOf(y)
+	return []Node{
+		gradIf(xs[0], func() Node { return sumTo(g.Div(gy, xs[1]), xs[0]) }),
+		gradIf(xs[1], func() Node { return sumTo(g.Neg(g.Div(g.Prod(gy, xs[0]), g.Square(xs[1]))), xs[1]) }),
+	}
+}
+
+func mulGrad(y, gy Node) []Node {
+	g, xs := gy.Graph(), operandsOf(y)
+	return []Node{
+		gradIf(xs[0], func() Node { return g.Mul(gy, g.T(xs[1])) }),
+		gradIf(xs[1], func() Node { return g.Mul(g.T(xs[0]), gy) }),
+	}
+}
+
+func dotGrad(y, gy Node) []Node {
+	g, xs := gy.Graph(), operandsOf(y)
+	return []Node{
+		gradIf(xs[0], func() Node { return reshapeLike(g.ProdScalar(xs[1], gy), xs[0]) }),
+		gradIf(xs[1], func() Node { return reshapeLike(g.ProdScalar(xs[0], gy), xs[1]) }),
+	}
+}
+
+func maxGrad(y, gy Node) []Node {
+	g, xs := gy.Graph(), operandsOf(y)
+	x1v, x2v := xs[0].Value().Data(), xs[1].Value().Data()
+	return []Node{
+		gradIf(xs[0], func() Node {
+			return g.Prod(gy, g.maskOf(xs[0].Value(), func(i int) bool { return x1v[i] > x2v[i] }))
+		}),
+		gradIf(xs[1], func() Node {
+			return g.Prod(gy, g.maskOf(xs[1].Value(), func(i int) bool { return x2v[i] > x1v[i] }))
+		}),
+	}
+}
+
+func minGrad(y, gy Node) []Node {
+	g, xs := gy.Graph(), operandsOf(y)
+	x1v, x2v := xs[0].Value().Data(), xs[1].Value().Data()
+	return []Node{
+		gradIf(xs[0], func() Node {
+			return g.Prod(gy, g.maskOf(xs[0].Value(), func(i int) bool { return x1v[i] < x2v[i] }))
+		}),
+		gradIf(xs[1], func() Node {
+			return g.Prod(gy, g.maskOf(xs[1].Value(), func(i int) bool { return x2v[i] < x1v[i] }))
+		}),
+	}
+}
+
+// reshapeGrad is the GradFunc of the operators that only change the dimensions
+// of the operand (Reshape and Vec).
+func reshapeGrad(y, gy Node) []Node {
+	x := operandsOf(y)[0]
+	return []Node{gradIf(x, func() Node { return reshapeLike(gy, x) })}
+}
+
+func viewGrad(row, column, xStride, yStride int) GradFunc {
+	return func(y, gy Node) []Node {
+		x := operandsOf(y)[0]
+		return []Node{gradIf(x, func() Node {
+			g := gy.Graph()
+			rows, cols := x.Value().Dims()
+			r := mat.NewEmptyDense(rows, xStride)
+			for i := 0; i < xStride; i++ {
+				r.Set(row+i, i, 1)
+			}
+			c := mat.NewEmptyDense(yStride, cols)
+			for j := 0; j < yStride; j++ {
+				c.Set(j, column+j, 1)
+			}
+			return g.Mul(g.Mul(g.NewVariable(r, false), gy), g.NewVariable(c, false))
+		})}
+	}
+}
+
+func rowViewGrad(row int) GradFunc {
+	return func(y, gy Node) []Node {
+		x := operandsOf(y)[0]
+		return []Node{gradIf(x, func() Node {
+			g := gy.Graph()
+			rows, cols := x.Value().Dims()
+			return g.Mul(g.oneHot(rows, 1, row, 0), g.Reshape(gy, 1, cols))
+		})}
+	}
+}
+
+func colViewGrad(column int) GradFunc {
+	return func(y, gy Node) []Node {
+		x := operandsOf(y)[0]
+		return []Node{gradIf(x, func() Node {
+			g := gy.Graph()
+			rows, cols := x.Value().Dims()
+			return g.Mul(g.Reshape(gy, rows, 1), g.oneHot(1, cols, 0, column))
+		})}
+	}
+}
+
+// rotateRGrad is the GradFunc of RotateR, which rotates the gradients to the left.
+func rotateRGrad(i int) GradFunc {
+	return func(y, gy Node) []Node {
+		x := operandsOf(y)[0]
+		return []Node{gradIf(x, func() Node {
+			n := x.Value().Size()
+			return reshapeLike(gy.Graph().RotateR(gy, (n-i)%n), x)
+		})}
+	}
+}
+
+// maxPoolingGrad is the GradFunc of MaxPooling: each gradient is spread over its
+// pool, then masked out except for the position of the maximum.
+func maxPoolingGrad(rows, cols int) GradFunc {
+	return func(y, gy Node) []Node {
+		x := operandsOf(y)[0]
+		return []Node{gradIf(x, func() Node {
+			g := gy.Graph()
+			xRows, xCols := x.Value().Dims()
+			r := mat.NewEmptyDense(xRows, xRows/rows)
+			for i := 0; i < xRows; i++ {
+				r.Set(i, i/rows, 1)
+			}
+			c := mat.NewEmptyDense(xCols/cols, xCols)
+			for j := 0; j < xCols; j++ {
+				c.Set(j/cols, j, 1)
+			}
+			mask := y.(*operator).function.(*fn.MaxPooling).Mask()
+			spread := g.Mul(g.Mul(g.NewVariable(r, false), gy), g.NewVariable(c, false))
+			return g.Prod(g.NewVariable(mask, false), spread)
+		})}
+	}
+}
+
+func transposeGrad(y, gy Node) []Node {
+	x := operandsOf(y)[0]
+	return []Node{gradIf(x, func() Node { return gy.Graph().T(gy) })}
+}
+
+// elementwiseGrad returns the gradients of an element-wise function, whose
+// derivative is computed by df from the operand x and the output y.
+func elementwiseGrad(y, gy Node, df func(g *Graph, x, y Node) Node) []Node {
+	x := operandsOf(y)[0]
+	return []Node{gradIf(x, func() Node {
+		g := gy.Graph()
+		return reshapeLike(g.Prod(gy, df(g, x, y)), x)
+	})}
+}
+
+func squareGrad(y, gy Node) []Node {
+	return elementwiseGrad(y, gy, func(g *Graph, x, _ Node) Node {
+		return g.ProdScalar(x, g.Constant(2))
+	})
+}
+
+func sqrtGrad(y, gy Node) []Node {
+	return elementwiseGrad(y, gy, func(g *Graph, _, y Node) Node {
+		return g.Reciprocal(g.ProdScalar(y, g.Constant(2)))
+	})
+}
+
+func tanGrad(y, gy Node) []Node {
+	return elementwiseGrad(y, gy, func(g *Graph, _, y Node) Node {
+		return g.AddScalar(g.Square(y), g.Constant(1))
+	})
+}
+
+func tanhGrad(y, gy Node) []Node {
+	return elementwiseGrad(y, gy, func(g *Graph, _, y Node) Node {
+		return g.ReverseSub(g.Square(y), g.Constant(1))
+	})
+}
+
+func sigmoidGrad(y, gy Node) []Node {
+	return elementwiseGrad(y, gy, func(g *Graph, _, y Node) Node {
+		return g.Prod(y, g.ReverseSub(y, g.Constant(1)))
+	})
+}
+
+func softsignGrad(y, gy Node) []Node {
+	return elementwiseGrad(y, gy, func(g *Graph, x, _ Node) Node {
+		return g.Reciprocal(g.Square(g.AddScalar(g.Abs(x), g.Constant(1))))
+	})
+}
+
+func sinGrad(y, gy Node) []Node {
+	return elementwiseGrad(y, gy, func(g *Graph, x, _ Node) Node {
+		return g.Cos(x)
+	})
+}
+
+func cosGrad(y, gy Node) []Node {
+	return elementwiseGrad(y, gy, func(g *Graph, x, _ Node) Node {
+		return g.Neg(g.Sin(x))
+	})
+}
+
+func expGrad(y, gy Node) []Node {
+	return elementwiseGrad(y, gy, func(_ *Graph, _, y Node) Node {
+		return y
+	})
+}
+
+func logGrad(y, gy Node) []Node {
+	return elementwiseGrad(y, gy, func(g *Graph, x, _ Node) Node {
+		return g.Reciprocal(x)
+	})
+}
+
+func absGrad(y, gy Node) []Node {
+	return elementwiseGrad(y, gy, func(g *Graph, x, _ Node) Node {
+		return g.mask(x.Value(), func(v mat.Float) mat.Float {
+			switch {
+			case v > 0:
+				return 1
+			case v < 0:
+				return -1
+			default:
+				return 0
+			}
+		})
+	})
+}
+
+func reciprocalGrad(y, gy Node) []Node {
+	return elementwiseGrad(y, gy, func(g *Graph, _, y Node) Node {
+		return g.Neg(g.Square(y))
+	})
+}
+
+func negGrad(y, gy Node) []Node {
+	x := operandsOf(y)[0]
+	return []Node{gradIf(x, func() Node { return reshapeLike(gy.Graph().Neg(gy), x) })}
+}
+
+func powGrad(power mat.Float) GradFunc {
+	return func(y, gy Node) []Node {
+		return elementwiseGrad(y, gy, func(g *Graph, x, _ Node) Node {
+			return g.ProdScalar(g.Pow(x, power-1), g.Constant(power))
+		})
+	}
+}
+
+// derivable is implemented by the element-wise functions which compute their
+// derivative with respect to the first operand (see fn.UnaryElementwise).
+type derivable interface {
+	Derivative() *mat.Dense
+}
+
+// derivativeGrad is the GradFunc of the element-wise functions whose derivative
+// is piecewise constant, such as ReLU, so that it is a constant of the graph.
+// The other operands, if any, are considered constants.
+func derivativeGrad(y, gy Node) []Node {
+	gxs := make([]Node, len(operandsOf(y)))
+	gxs[0] = elementwiseGrad(y, gy, func(g *Graph, _, _ Node) Node {
+		return g.NewVariable(y.(*operator).function.(derivable).Derivative(), false)
+	})[0]
+	return gxs
+}
+
+// piecewise returns the element-wise composition equal to x where x > t, and to
+// f(x) elsewhere. f is only applied to the latter values, so that it can't overflow.
+func (g *Graph) piecewise(x Node, t mat.Float, f func(x Node) Node) Node {
+	xv := x.Value().Data()
+	above := g.maskOf(x.Value(), func(i int) bool { return xv[i] > t })
+	below := g.maskOf(x.Value(), func(i int) bool { return xv[i] <= t })
+	return g.Add(g.Prod(above, x), g.Prod(below, f(g.Prod(below, x))))
+}
+
+// The GradFunc of the smooth activations are computed from their definition,
+// so that their derivatives can be differentiated in turn. The parameters are
+// considered constants, as in the Backward of the fn functions, except for the
+// beta of Swish.
+
+var geluGrad = compositeGrad(func(g *Graph, xs []Node) Node {
+	// 0.5 * x * (1 + tanh(sqrt(2 / pi) * (x + 0.044715 * x^3)))
+	x := xs[0]
+	z := g.Add(x, g.ProdScalar(g.Pow(x, 3), g.Constant(0.044715)))
+	z = g.ProdScalar(z, g.Constant(mat.Sqrt(2/mat.Pi)))
+	return g.ProdScalar(g.Prod(x, g.AddScalar(g.Tanh(z), g.Constant(1))), g.Constant(0.5))
+})
+
+var swishGrad = compositeGrad(func(g *Graph, xs []Node) Node {
+	return g.Prod(xs[0], g.Sigmoid(g.ProdScalar(xs[0], xs[1])))
+})
+
+var mishGrad = compositeGrad(func(g *Graph, xs []Node) Node {
+	return g.Prod(xs[0], g.Tanh(g.Log(g.AddScalar(g.Exp(xs[0]), g.Constant(1)))))
+})
+
+var eluGrad = compositeGrad(func(g *Graph, xs []Node) Node {
+	return g.elu(xs[0], xs[1].Value().Scalar())
+})
+
+var celuGrad = compositeGrad(func(g *Graph, xs []Node) Node {
+	alpha := g.Constant(xs[1].Value().Scalar())
+	return g.piecewise(xs[0], 0, func(x Node) Node {
+		return g.ProdScalar(g.SubScalar(g.Exp(g.DivScalar(x, alpha)), g.Constant(1)), alpha)
+	})
+})
+
+var seluGrad = compositeGrad(func(g *Graph, xs []Node) Node {
+	return g.ProdScalar(g.elu(xs[0], xs[1].Value().Scalar()), g.Constant(xs[2].Value().Scalar()))
+})
+
+var softPlusGrad = compositeGrad(func(g *Graph, xs []Node) Node {
+	beta := g.Constant(xs[1].Value().Scalar())
+	return g.piecewise(xs[0], xs[2].Value().Scalar(), func(x Node) Node {
+		return g.DivScalar(g.Log(g.AddScalar(g.Exp(g.ProdScalar(x, beta)), g.Constant(1))), beta)
+	})
+})
+
+// elu returns the composition equal to the ELU function of x.
+func (g *Graph) elu(x Node, alpha mat.Float) Node {
+	return g.piecewise(x, 0, func(x Node) Node {
+		return g.ProdScalar(g.SubScalar(g.Exp(x), g.Constant(1)), g.Constant(alpha))
+	})
+}
+
+func softmaxGrad(y, gy Node) []Node {
+	x := operandsOf(y)[0]
+	return []Node{gradIf(x, func() Node {
+		g := gy.Graph()
+		gy := reshapeLike(gy, y)
+		return reshapeLike(g.Prod(y, g.SubScalar(gy, g.Dot(gy, y))), x)
+	})}
+}
+
+// sparseMaxGrad is the GradFunc of SparseMax, whose gradients are
+// gx = s ⊙ (gy - (s (dot) gy) / sum(s)), where s is one for the non-zero values of y.
+func sparseMaxGrad(y, gy Node) []Node {
+	x := operandsOf(y)[0]
+	return []Node{gradIf(x, func() Node {
+		g := gy.Graph()
+		yv := y.Value().Data()
+		n := 0
+		for _, v := range yv {
+			if v != 0 {
+				n++
+			}
+		}
+		s := g.maskOf(y.Value(), func(i int) bool { return yv[i] != 0 })
+		gy := reshapeLike(gy, y)
+		return reshapeLike(g.Prod(s, g.SubScalar(gy, g.DivScalar(g.Dot(s, gy), g.Constant(mat.Float(n))))), x)
+	})}
+}
+
+// sparseMaxLossGrad is the GradFunc of SparseMaxLoss, whose gradients are
+// gx = gy - sum(gy) * sparsemax(x).
+func sparseMaxLossGrad(y, gy Node) []Node {
+	x := operandsOf(y)[0]
+	return []Node{gradIf(x, func() Node {
+		g := gy.Graph()
+		gy := reshapeLike(gy, y)
+		return reshapeLike(g.Sub(gy, g.ProdScalar(g.SparseMax(x), g.ReduceSum(gy))), x)
+	})}
+}
+
+func reduceSumGrad(y, gy Node) []Node {
+	x := operandsOf(y)[0]
+	return []Node{gradIf(x, func() Node {
+		g := gy.Graph()
+		return g.ProdScalar(g.NewVariable(x.Value().OnesLike(), false), gy)
+	})}
+}
+
+func reduceMeanGrad(y, gy Node) []Node {
+	x := operandsOf(y)[0]
+	return []Node{gradIf(x, func() Node {
+		g := gy.Graph()
+		n := mat.Float(x.Value().Size())
+		return g.ProdScalar(g.NewVariable(mat.NewInitDense(x.Value().Rows(), x.Value().Columns(), 1/n), false), gy)
+	})}
+}
+
+func concatGrad(y, gy Node) []Node {
+	xs := operandsOf(y)
+	gxs := make([]Node, len(xs))
+	offset := 0
+	for i, x := range xs {
+		size := x.Value().Size()
+		gxs[i] = gradIf(x, func() Node {
+			return reshapeLike(gy.Graph().View(gy, offset, 0, size, 1), x)
+		})
+		offset += size
+	}
+	return gxs
+}
+
+func stackGrad(y, gy Node) []Node {
+	xs := operandsOf(y)
+	gxs := make([]Node, len(xs))
+	for i, x := range xs {
+		gxs[i] = gradIf(x, func() Node {
+			return reshapeLike(gy.Graph().RowView(gy, i), x)
+		})
+	}
+	return gxs
+}
+
+// maskOf returns a new constant with the dimensions of m, whose i-th value is
+// one if f(i) is true, zero otherwise.
+func (g *Graph) maskOf(m mat.Matrix, f func(i int) bool) Node {
+	i := -1
+	return g.mask(m, func(mat.Float) mat.Float {
+		i++
+		if f(i) {
+			return 1
+		}
+		return 0
+	})
+}
+
+// The GradFunc of the fused operators are computed from the corresponding
+// compositions of operators.
+
+func affineGrad(activation OpName) GradFunc {
+	return compositeGrad(func(g *Graph, xs []Node) Node {
+		return g.Invoke(activation, g.Add(g.Mul(xs[1], xs[2]), xs[0]))
+	})
+}
+
+func scaledMaskedSoftmaxGrad(scale mat.Float, mask mat.Matrix) GradFunc {
+	return compositeGrad(func(g *Graph, xs []Node) Node {
+		z := g.ProdScalar(g.Vec(xs[0]), g.Constant(scale))
+		if mask != nil {
+			z = g.Add(z, g.NewVariable(mat.NewVecDense(mask.Data()), false))
+		}
+		return g.Softmax(z)
+	})
+}
+
+// addLayerNormGrad is the GradFunc of LayerNorm, whose operands are x, w and b,
+// and of AddLayerNorm, whose operands are x1, x2, w and b.
+func addLayerNormGrad(eps mat.Float) GradFunc {
+	return compositeGrad(func(g *Graph, xs []Node) Node {
+		s, w, b := xs[0], xs[1], xs[2]
+		if len(xs) == 4 {
+			s, w, b = g.Add(xs[0], reshapeLike(xs[1], xs[0])), xs[2], xs[3]
+		}
+		d := g.SubScalar(s, g.ReduceMean(s))
+		std := g.Sqrt(g.AddScalar(g.ReduceMean(g.Square(d)), g.Constant(eps)))
+		return g.Add(g.Prod(g.DivScalar(d, std), reshapeLike(w, s)), reshapeLike(b, s))
+	})
+}
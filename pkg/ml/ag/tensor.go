@@ -0,0 +1,187 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+)
+
+// Tensor is a node of the graph seen as an N-dimensional tensor.
+//
+// The value of the node holds the elements of the tensor in row-major order,
+// whatever its dimensions: the nodes created by the tensor operators have the
+// dimensions given by mat.Tensor.MatrixDims, while AsTensor and TensorReshape
+// only change the shape the values are seen through. The element-wise
+// operators of the graph (e.g. Tanh, ProdScalar) can therefore be applied to
+// the Node directly, and their result be seen again as a tensor of the same
+// shape with AsTensor.
+//
+// This allows to express computations on batch×seq×hidden tensors directly,
+// instead of looping over slices of nodes.
+type Tensor struct {
+	// Node holds the values of the tensor.
+	Node  Node
+	shape []int
+}
+
+// Shape returns the size of each dimension of the tensor.
+func (t Tensor) Shape() []int {
+	return append([]int(nil), t.shape...)
+}
+
+// Value returns the value of the tensor, sharing the data of the node value.
+func (t Tensor) Value() *mat.Tensor {
+	return mat.NewTensorView(t.shape, t.Node.Value().Data())
+}
+
+// Grad returns the gradients accumulated during the backward pass, or nil if
+// there are none.
+func (t Tensor) Grad() *mat.Tensor {
+	grad := t.Node.Grad()
+	if grad == nil {
+		return nil
+	}
+	return mat.NewTensorView(t.shape, grad.Data())
+}
+
+// NewTensor creates a new variable holding the value of the tensor.
+func (g *Graph) NewTensor(value *mat.Tensor, requiresGrad bool) Tensor {
+	return Tensor{Node: g.NewVariable(value.ToDense(), requiresGrad), shape: value.Shape()}
+}
+
+// AsTensor returns the node seen as a tensor of the given shape. The size of
+// the node value must match the size of the shape; the values are read in
+// row-major order. For example, the node returned by Stack can be seen as a
+// (len(xs), size) tensor.
+func (g *Graph) AsTensor(x Node, shape ...int) Tensor {
+	if x.Graph() != g {
+		panic("ag: operations cannot be executed among nodes of different graphs.")
+	}
+	if v := x.Value(); v != nil && v.Size() != shapeSize(shape) {
+		panic(fmt.Sprintf("ag: cannot see a value of size %d as a tensor of shape %v", v.Size(), shape))
+	}
+	return Tensor{Node: x, shape: append([]int(nil), shape...)}
+}
+
+// TensorAdd returns a new tensor as a result of the fn.TensorAdd function,
+// which broadcasts the shapes of the operands.
+func (g *Graph) TensorAdd(x1, x2 Tensor) Tensor {
+	f := fn.NewTensorAdd(x1.Node, x2.Node, x1.shape, x2.shape)
+	return Tensor{Node: g.NewOperator(f, x1.Node, x2.Node), shape: f.Shape()}
+}
+
+// TensorSub returns a new tensor as a result of the fn.TensorSub function,
+// which broadcasts the shapes of the operands.
+func (g *Graph) TensorSub(x1, x2 Tensor) Tensor {
+	f := fn.NewTensorSub(x1.Node, x2.Node, x1.shape, x2.shape)
+	return Tensor{Node: g.NewOperator(f, x1.Node, x2.Node), shape: f.Shape()}
+}
+
+// TensorProd returns a new tensor as a result of the fn.TensorProd function,
+// which broadcasts the shapes of the operands.
+func (g *Graph) TensorProd(x1, x2 Tensor) Tensor {
+	f := fn.NewTensorProd(x1.Node, x2.Node, x1.shape, x2.shape)
+	return Tensor{Node: g.NewOperator(f, x1.Node, x2.Node), shape: f.Shape()}
+}
+
+// TensorDiv returns a new tensor as a result of the fn.TensorDiv function,
+// which broadcasts the shapes of the operands.
+func (g *Graph) TensorDiv(x1, x2 Tensor) Tensor {
+	f := fn.NewTensorDiv(x1.Node, x2.Node, x1.shape, x2.shape)
+	return Tensor{Node: g.NewOperator(f, x1.Node, x2.Node), shape: f.Shape()}
+}
+
+// TensorMatMul returns a new tensor as a result of the fn.TensorMatMul
+// function, which multiplies the matrices in the last two dimensions of the
+// operands, broadcasting the leading ones.
+func (g *Graph) TensorMatMul(x1, x2 Tensor) Tensor {
+	f := fn.NewTensorMatMul(x1.Node, x2.Node, x1.shape, x2.shape)
+	return Tensor{Node: g.NewOperator(f, x1.Node, x2.Node), shape: f.Shape()}
+}
+
+// TensorPermute returns a new tensor as a result of the fn.TensorPermute
+// function: the i-th dimension of the output is the dimension axes[i] of x.
+func (g *Graph) TensorPermute(x Tensor, axes ...int) Tensor {
+	f := fn.NewTensorPermute(x.Node, x.shape, axes)
+	return Tensor{Node: g.NewOperator(f, x.Node), shape: f.Shape()}
+}
+
+// TensorTranspose returns a new tensor with the two given dimensions of x swapped.
+func (g *Graph) TensorTranspose(x Tensor, axis1, axis2 int) Tensor {
+	axes := make([]int, len(x.shape))
+	for i := range axes {
+		axes[i] = i
+	}
+	axes[axis1], axes[axis2] = axes[axis2], axes[axis1]
+	return g.TensorPermute(x, axes...)
+}
+
+// TensorReshape returns x seen as a tensor with the given shape, having the
+// same elements in row-major order. One dimension can be -1, in which case it
+// is inferred from the size of x. No new node is created.
+func (g *Graph) TensorReshape(x Tensor, shape ...int) Tensor {
+	return Tensor{Node: x.Node, shape: inferShape(shapeSize(x.shape), shape)}
+}
+
+// TensorSum returns a new tensor as a result of the fn.TensorSum function,
+// which sums the values along the given dimension, removing it.
+func (g *Graph) TensorSum(x Tensor, axis int) Tensor {
+	f := fn.NewTensorSum(x.Node, x.shape, axis)
+	return Tensor{Node: g.NewOperator(f, x.Node), shape: f.Shape()}
+}
+
+// TensorSoftmax returns a new tensor as a result of the fn.TensorSoftmax
+// function, along the given dimension.
+func (g *Graph) TensorSoftmax(x Tensor, axis int) Tensor {
+	f := fn.NewTensorSoftmax(x.Node, x.shape, axis)
+	return Tensor{Node: g.NewOperator(f, x.Node), shape: x.Shape()}
+}
+
+// TensorSlice returns a new tensor as a result of the fn.TensorSlice
+// function, restricted to the indices [start, end) of the given dimension.
+func (g *Graph) TensorSlice(x Tensor, axis, start, end int) Tensor {
+	f := fn.NewTensorSlice(x.Node, x.shape, axis, start, end)
+	return Tensor{Node: g.NewOperator(f, x.Node), shape: f.Shape()}
+}
+
+// TensorSelect returns a new tensor with the values of x at the given index
+// of a dimension, which is removed.
+func (g *Graph) TensorSelect(x Tensor, axis, index int) Tensor {
+	y := g.TensorSlice(x, axis, index, index+1)
+	shape := append(y.Shape()[:axis], y.shape[axis+1:]...)
+	return g.TensorReshape(y, shape...)
+}
+
+// inferShape returns the shape replacing the dimension -1, if any, with the
+// size required to reach the given total size. It panics if the shape is
+// not compatible with the size.
+func inferShape(size int, shape []int) []int {
+	shape = append([]int(nil), shape...)
+	inferred, known := -1, 1
+	for i, s := range shape {
+		if s == -1 && inferred < 0 {
+			inferred = i
+			continue
+		}
+		known *= s
+	}
+	if inferred >= 0 && known != 0 {
+		shape[inferred] = size / known
+	}
+	if shapeSize(shape) != size {
+		panic(fmt.Sprintf("ag: cannot reshape a tensor of size %d to %v", size, shape))
+	}
+	return shape
+}
+
+func shapeSize(shape []int) int {
+	size := 1
+	for _, s := range shape {
+		size *= s
+	}
+	return size
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGraph_AsTensor(t *testing.T) {
+	g := NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1, 2, 3, 4, 5, 6}), true)
+	y := g.AsTensor(x, 2, 3)
+	assert.Equal(t, []int{2, 3}, y.Shape())
+	assert.Equal(t, mat.Float(4), y.Value().At(1, 0))
+	assert.Panics(t, func() { g.AsTensor(x, 4, 2) })
+	assert.Panics(t, func() { NewGraph().AsTensor(x, 6) })
+
+	z := g.TensorReshape(y, 3, -1)
+	assert.Equal(t, []int{3, 2}, z.Shape())
+	assert.Equal(t, x, z.Node)
+	assert.Panics(t, func() { g.TensorReshape(y, 4, -1) })
+}
+
+func TestGraph_TensorSelect(t *testing.T) {
+	g := NewGraph()
+	x := g.NewTensor(mat.NewTensor([]int{2, 3}, []mat.Float{1, 2, 3, 4, 5, 6}), true)
+	y := g.TensorSelect(x, 1, 2)
+	assert.Equal(t, []int{2}, y.Shape())
+	assert.Equal(t, []mat.Float{3, 6}, y.Value().Data())
+
+	g.Backward(y.Node)
+	assert.Equal(t, []mat.Float{0, 0, 1, 0, 0, 1}, x.Grad().Data())
+}
+
+// TestGraph_TensorAttention checks that the attention of a batch of sequences
+// computed on batch×seq×hidden tensors matches the one computed sequence by
+// sequence, row by row.
+func TestGraph_TensorAttention(t *testing.T) {
+	const batch, seq, hidden = 2, 3, 4
+	rndGen := rand.NewLockedRand(42)
+	newData := func() []mat.Float {
+		data := make([]mat.Float, batch*seq*hidden)
+		for i := range data {
+			data[i] = mat.Float(rndGen.Float() - 0.5)
+		}
+		return data
+	}
+	q, k, v := newData(), newData(), newData()
+	scale := mat.Float(0.5)
+
+	g := NewGraph()
+	qt := g.NewTensor(mat.NewTensor([]int{batch, seq, hidden}, q), true)
+	kt := g.NewTensor(mat.NewTensor([]int{batch, seq, hidden}, k), true)
+	vt := g.NewTensor(mat.NewTensor([]int{batch, seq, hidden}, v), true)
+	scores := g.TensorMatMul(qt, g.TensorTranspose(kt, 1, 2))
+	scores = g.AsTensor(g.ProdScalar(scores.Node, g.NewScalar(scale)), scores.Shape()...)
+	context := g.TensorMatMul(g.TensorSoftmax(scores, 2), vt)
+	assert.Equal(t, []int{batch, seq, hidden}, context.Shape())
+	loss := g.TensorSum(g.TensorSum(g.TensorSum(context, 2), 1), 0)
+	assert.Empty(t, loss.Shape())
+	g.Backward(loss.Node)
+
+	for b := 0; b < batch; b++ {
+		g2 := NewGraph()
+		part := func(data []mat.Float) Node {
+			return g2.NewVariable(mat.NewDense(seq, hidden, data[b*seq*hidden:(b+1)*seq*hidden]), true)
+		}
+		qb, kb, vb := part(q), part(k), part(v)
+		scores := g2.ProdScalar(g2.Mul(qb, g2.T(kb)), g2.NewScalar(scale))
+		rows := make([]Node, seq)
+		for i := range rows {
+			rows[i] = g2.Softmax(g2.T(g2.RowView(scores, i)))
+		}
+		contextB := g2.Mul(g2.Stack(rows...), vb)
+		g2.Backward(g2.ReduceSum(g2.Reshape(contextB, seq*hidden, 1)))
+
+		assert.InDeltaSlice(t, contextB.Value().Data(), context.Value().Select(0, b).Data(), 1.0e-5)
+		assert.InDeltaSlice(t, qb.Grad().Data(), qt.Grad().Select(0, b).Data(), 1.0e-5)
+		assert.InDeltaSlice(t, kb.Grad().Data(), kt.Grad().Select(0, b).Data(), 1.0e-5)
+		assert.InDeltaSlice(t, vb.Grad().Data(), vt.Grad().Select(0, b).Data(), 1.0e-5)
+	}
+}
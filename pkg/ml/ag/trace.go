@@ -0,0 +1,293 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"strings"
+	"sync"
+)
+
+// TraceFunc defines a computation on the graph g, from the input nodes xs to
+// the output nodes. It is called once for each new shape of the inputs (see Trace).
+type TraceFunc func(g *Graph, xs []Node) []Node
+
+// Trace records the operators created by a TraceFunc once ("tracing"), then
+// re-executes them with new input values ("replay"), without building the
+// graph again node by node. This is useful for repeated inference, e.g. on
+// a server, where the same computation is run for each request.
+//
+// A graph is traced for each distinct shape of the inputs; the operators of a
+// traced graph are replayed in order of creation, releasing the value of each
+// intermediate node to the matrices pool as soon as it is no longer needed
+// (the release schedule is planned at tracing time), so that the following
+// operators reuse its memory.
+//
+// The structure of the graph must depend only on the shapes of the inputs:
+// the values computed outside the operators while tracing (e.g. the variables
+// created by the TraceFunc, or decisions taken on the values of the nodes)
+// are frozen in the trace. The values of the wrapped nodes (i.e. the
+// parameters of a model) are read again at each replay.
+//
+// When the inputs take more than the maximum number of shapes (see
+// MaxTracedShapes), the computation falls back to building a new graph for
+// each call, like an ordinary graph would do.
+//
+// A Trace is safe for concurrent use. Each execution takes a traced graph of
+// its own: the concurrent executions with the same shape trace more graphs,
+// which are kept for the following ones, so that they run in parallel.
+type Trace struct {
+	mu        sync.Mutex
+	f         TraceFunc
+	graphOpts []GraphOption
+	maxShapes int
+	// traced contains the idle traced graphs of each shape of the inputs.
+	traced map[string][]*tracedGraph
+	// generation is incremented by Reset, to discard the graphs in use.
+	generation int
+}
+
+// tracedGraph is a graph recorded for a given shape of the inputs, with its
+// execution plan.
+type tracedGraph struct {
+	g          *Graph
+	generation int
+	inputs     []*variable
+	outputs    []Node
+	// operators contains the operators to execute, in order of creation.
+	operators []*operator
+	// release contains, for each operator, the operators whose values can be
+	// released once it has been executed.
+	release [][]*operator
+}
+
+// TraceOption allows to configure a new Trace with your specific needs.
+type TraceOption func(*Trace)
+
+// defaultMaxTracedShapes is the default maximum number of shapes traced by a Trace.
+const defaultMaxTracedShapes = 8
+
+// MaxTracedShapes sets the maximum number of shapes of the inputs for which
+// a graph is traced (default 8). Beyond that, the calls with new shapes
+// build a new graph each time.
+func MaxTracedShapes(value int) TraceOption {
+	if value < 0 {
+		panic("ag: MaxTracedShapes value must be greater than or equal to zero")
+	}
+	return func(t *Trace) {
+		t.maxShapes = value
+	}
+}
+
+// TraceGraphOptions sets the options of the graphs created by the Trace.
+func TraceGraphOptions(opts ...GraphOption) TraceOption {
+	return func(t *Trace) {
+		t.graphOpts = opts
+	}
+}
+
+// NewTrace returns a new Trace of the function f. The tracing is deferred
+// to the first execution with a given shape of the inputs.
+func NewTrace(f TraceFunc, opts ...TraceOption) *Trace {
+	t := &Trace{
+		f:         f,
+		maxShapes: defaultMaxTracedShapes,
+		traced:    map[string][]*tracedGraph{},
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Run executes the computation on the input values xs, and returns copies of
+// the values of the output nodes. The input values are not modified.
+func (t *Trace) Run(xs ...mat.Matrix) []mat.Matrix {
+	key := shapeKey(xs)
+	tg, generation, ok := t.acquire(key)
+	if !ok {
+		return t.runEager(xs)
+	}
+	var out []mat.Matrix
+	if tg != nil {
+		out = tg.replay(xs)
+	} else {
+		tg, out = t.trace(xs, generation)
+	}
+	t.release(key, tg)
+	return out
+}
+
+// acquire takes an idle traced graph of the given shape, along with the current
+// generation of the Trace. It returns a nil graph if a new one has to be traced,
+// and false if the shape can't be traced.
+func (t *Trace) acquire(key string) (*tracedGraph, int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	idle, ok := t.traced[key]
+	if !ok {
+		if len(t.traced) >= t.maxShapes {
+			return nil, t.generation, false
+		}
+		t.traced[key] = nil
+		return nil, t.generation, true
+	}
+	if len(idle) == 0 {
+		return nil, t.generation, true
+	}
+	t.traced[key] = idle[:len(idle)-1]
+	return idle[len(idle)-1], t.generation, true
+}
+
+// release makes the traced graph available to the following executions,
+// unless the Trace has been reset in the meantime.
+func (t *Trace) release(key string, tg *tracedGraph) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tg.generation != t.generation {
+		tg.g.Clear()
+		return
+	}
+	t.traced[key] = append(t.traced[key], tg)
+}
+
+// Len returns the number of shapes traced so far.
+func (t *Trace) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.traced)
+}
+
+// Reset discards the traced graphs, releasing their memory. It is necessary
+// when the structure of the graph is no longer valid, e.g. after replacing
+// the parameters of a model (not only their values).
+func (t *Trace) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, idle := range t.traced {
+		for _, tg := range idle {
+			tg.g.Clear()
+		}
+	}
+	t.traced = map[string][]*tracedGraph{}
+	t.generation++
+}
+
+// shapeKey returns a key identifying the dimensions of the matrices.
+func shapeKey(xs []mat.Matrix) string {
+	var sb strings.Builder
+	for _, x := range xs {
+		rows, cols := x.Dims()
+		_, _ = fmt.Fprintf(&sb, "%dx%d;", rows, cols)
+	}
+	return sb.String()
+}
+
+// runEager evaluates the function on a new graph, which is then cleared.
+func (t *Trace) runEager(xs []mat.Matrix) []mat.Matrix {
+	g := NewGraph(t.graphOpts...)
+	defer g.Clear()
+	nodes := make([]Node, len(xs))
+	for i, x := range xs {
+		nodes[i] = g.NewVariable(x, false)
+	}
+	return copyValues(t.f(g, nodes))
+}
+
+// trace evaluates the function on a new graph, recording its execution plan.
+func (t *Trace) trace(xs []mat.Matrix, generation int) (*tracedGraph, []mat.Matrix) {
+	g := NewGraph(append(t.graphOpts, IncrementalForward(true))...)
+	tg := &tracedGraph{
+		g:          g,
+		generation: generation,
+		inputs:     make([]*variable, len(xs)),
+	}
+	nodes := make([]Node, len(xs))
+	for i, x := range xs {
+		v := g.NewVariable(x, false).(*variable)
+		tg.inputs[i], nodes[i] = v, v
+	}
+	tg.outputs = t.f(g, nodes)
+	out := copyValues(tg.outputs)
+	tg.plan()
+	tg.releaseAll()
+	return tg, out
+}
+
+// plan records the operators of the graph and the schedule of the release
+// of their values, based on their last use.
+func (tg *tracedGraph) plan() {
+	isOutput := make(map[int]bool, len(tg.outputs))
+	for _, y := range tg.outputs {
+		isOutput[y.ID()] = true
+	}
+	lastUse := make(map[int]int) // operator ID -> index of the last operator using it
+	for _, node := range tg.g.nodes {
+		op, ok := node.(*operator)
+		if !ok {
+			continue
+		}
+		for _, operand := range op.operands {
+			if _, ok := operand.(*operator); ok {
+				lastUse[operand.ID()] = len(tg.operators)
+			}
+		}
+		tg.operators = append(tg.operators, op)
+	}
+	tg.release = make([][]*operator, len(tg.operators))
+	for i, op := range tg.operators {
+		if isOutput[op.id] {
+			continue
+		}
+		last, ok := lastUse[op.id]
+		if !ok {
+			last = i // the value is never used, it can be released at once
+		}
+		tg.release[last] = append(tg.release[last], op)
+	}
+}
+
+// replay executes the operators of the graph on new input values.
+func (tg *tracedGraph) replay(xs []mat.Matrix) []mat.Matrix {
+	for i, x := range xs {
+		tg.inputs[i].value = x
+	}
+	g := tg.g
+	for i, op := range tg.operators {
+		g.processingQueue.Run(func() {
+			op.value = op.function.Forward()
+		})
+		for _, r := range tg.release[i] {
+			g.releaseValue(r)
+		}
+	}
+	out := copyValues(tg.outputs)
+	tg.releaseAll()
+	return out
+}
+
+// releaseAll releases the values of all the operators, and detaches the
+// input values, so that the traced graph doesn't retain memory between the
+// executions.
+func (tg *tracedGraph) releaseAll() {
+	for _, op := range tg.operators {
+		tg.g.releaseValue(op)
+	}
+	for _, v := range tg.inputs {
+		v.value = nil
+	}
+}
+
+// copyValues returns copies of the values of the nodes.
+func copyValues(nodes []Node) []mat.Matrix {
+	out := make([]mat.Matrix, len(nodes))
+	for i, node := range nodes {
+		if node.Value() != nil {
+			out[i] = node.Value().Clone()
+		}
+	}
+	return out
+}
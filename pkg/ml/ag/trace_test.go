@@ -0,0 +1,180 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// newTraceTestFunc returns a function computing tanh(w·x + b) + x⁺ for a
+// column vector x, where w and b are wrapped from a separate graph
+// like the parameters of a model.
+func newTraceTestFunc() (f TraceFunc, w, b Node) {
+	params := NewGraph()
+	w = params.NewVariable(mat.NewDense(2, 3, []mat.Float{
+		0.1, -0.2, 0.3,
+		0.4, 0.5, -0.6,
+	}), true)
+	b = params.NewVariable(mat.NewVecDense([]mat.Float{0.2, -0.1}), true)
+	f = func(g *Graph, xs []Node) []Node {
+		h := g.Tanh(g.Add(g.Mul(g.NewWrapNoGrad(w), xs[0]), g.NewWrapNoGrad(b)))
+		return []Node{h, g.ReduceSum(g.ReLU(xs[0]))}
+	}
+	return
+}
+
+func runTraceTestFunc(f TraceFunc, x mat.Matrix) []mat.Matrix {
+	g := NewGraph()
+	defer g.Clear()
+	return copyValues(f(g, []Node{g.NewVariable(x, false)}))
+}
+
+func TestTrace_Run(t *testing.T) {
+	f, w, _ := newTraceTestFunc()
+	tr := NewTrace(f)
+
+	inputs := []mat.Matrix{
+		mat.NewVecDense([]mat.Float{0.5, -1, 2}),
+		mat.NewVecDense([]mat.Float{-0.3, 0.8, 0.1}),
+		mat.NewVecDense([]mat.Float{1, 2, 3}),
+	}
+	for _, x := range inputs {
+		expected := runTraceTestFunc(f, x)
+		actual := tr.Run(x)
+		require.Len(t, actual, 2)
+		assert.InDeltaSlice(t, expected[0].Data(), actual[0].Data(), 1.0e-6)
+		assert.InDeltaSlice(t, expected[1].Data(), actual[1].Data(), 1.0e-6)
+	}
+	assert.Equal(t, 1, tr.Len())
+
+	// the values of the parameters are read at each replay
+	w.Value().Data()[0] = 1.5
+	x := mat.NewVecDense([]mat.Float{0.5, -1, 2})
+	assert.InDeltaSlice(t, runTraceTestFunc(f, x)[0].Data(), tr.Run(x)[0].Data(), 1.0e-6)
+	assert.Equal(t, []mat.Float{0.5, -1, 2}, x.Data())
+}
+
+func TestTrace_Shapes(t *testing.T) {
+	f := func(g *Graph, xs []Node) []Node {
+		return []Node{g.ReduceSum(g.Square(xs[0])), g.T(xs[0])}
+	}
+	tr := NewTrace(f, MaxTracedShapes(2))
+	for _, x := range []mat.Matrix{
+		mat.NewVecDense([]mat.Float{1, 2}),
+		mat.NewVecDense([]mat.Float{1, 2, 3}),
+		mat.NewVecDense([]mat.Float{3, 4}),
+		mat.NewDense(2, 2, []mat.Float{1, 2, 3, 4}), // beyond MaxTracedShapes
+		mat.NewDense(2, 2, []mat.Float{1, 1, 1, 1}),
+	} {
+		out := tr.Run(x)
+		sum := mat.Float(0)
+		for _, v := range x.Data() {
+			sum += v * v
+		}
+		assert.Equal(t, sum, out[0].Scalar())
+		assert.Equal(t, x.T().Data(), out[1].Data())
+		assert.Equal(t, x.Columns(), out[1].Rows())
+	}
+	assert.Equal(t, 2, tr.Len())
+
+	tr.Reset()
+	assert.Equal(t, 0, tr.Len())
+}
+
+func TestTrace_Plan(t *testing.T) {
+	f := func(g *Graph, xs []Node) []Node {
+		a := g.Square(xs[0]) // used by c
+		b := g.Exp(xs[0])    // used by c and d
+		c := g.Add(a, b)     // used by d
+		d := g.Prod(c, b)    // output
+		_ = g.Neg(xs[0])     // never used
+		return []Node{d}
+	}
+	tr := NewTrace(f)
+	tr.Run(mat.NewVecDense([]mat.Float{1, 2}))
+	for _, idle := range tr.traced {
+		require.Len(t, idle, 1)
+		tg := idle[0]
+		require.Len(t, tg.operators, 5)
+		ids := func(ops []*operator) []int {
+			var out []int
+			for _, op := range ops {
+				out = append(out, op.id)
+			}
+			return out
+		}
+		a, b, c := tg.operators[0], tg.operators[1], tg.operators[2]
+		assert.Nil(t, ids(tg.release[0]))
+		assert.Nil(t, ids(tg.release[1]))
+		assert.Equal(t, []int{a.id}, ids(tg.release[2]))
+		assert.Equal(t, []int{b.id, c.id}, ids(tg.release[3]))
+		assert.Equal(t, []int{tg.operators[4].id}, ids(tg.release[4]))
+
+		// no memory is retained between the executions
+		for _, op := range tg.operators {
+			assert.Nil(t, op.value)
+		}
+		assert.Nil(t, tg.inputs[0].value)
+	}
+}
+
+func TestTrace_Concurrent(t *testing.T) {
+	f, _, _ := newTraceTestFunc()
+	tr := NewTrace(f)
+	x := mat.NewVecDense([]mat.Float{0.5, -1, 2})
+	expected := runTraceTestFunc(f, x)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.InDeltaSlice(t, expected[0].Data(), tr.Run(x)[0].Data(), 1.0e-6)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTrace_ConcurrentTracing(t *testing.T) {
+	started, resume := make(chan struct{}), make(chan struct{})
+	var calls int32
+	f := func(g *Graph, xs []Node) []Node {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-resume // the first tracing waits for the second execution
+		}
+		return []Node{g.ReduceSum(xs[0])}
+	}
+	tr := NewTrace(f)
+	x := mat.NewVecDense([]mat.Float{1, 2})
+
+	done := make(chan mat.Float)
+	go func() {
+		done <- tr.Run(x)[0].Scalar()
+	}()
+	<-started
+	assert.Equal(t, mat.Float(3), tr.Run(x)[0].Scalar()) // not blocked by the first execution
+	close(resume)
+	assert.Equal(t, mat.Float(3), <-done)
+
+	// both traced graphs are kept for the following executions
+	require.Len(t, tr.traced[shapeKey([]mat.Matrix{x})], 2)
+	assert.Equal(t, mat.Float(3), tr.Run(x)[0].Scalar())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, 1, tr.Len())
+
+	// the graphs in use during a Reset are discarded
+	tg, generation, ok := tr.acquire(shapeKey([]mat.Matrix{x}))
+	require.True(t, ok)
+	tr.Reset()
+	tr.release(shapeKey([]mat.Matrix{x}), tg)
+	assert.NotEqual(t, generation, tr.generation)
+	assert.Equal(t, 0, tr.Len())
+}
@@ -0,0 +1,245 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package checkpoint saves and resumes the whole state of a training process:
+// the params of the model together with the support structures of the
+// optimization methods (e.g. the moments of Adam), the time steps of the
+// methods and of the learning rate schedulers, the state of the random
+// generator, the position of the data iterator, and the tracking of the
+// validation metric.
+//
+// Unlike utils.SerializeToFile, the params are restored in place, so that the
+// references to them (e.g. the parameter groups of the optimizer) are preserved.
+// The word embeddings loaded on demand from a key-value storage (see
+// embeddings.Model) are updated in the storage at each optimization step, so a
+// checkpoint includes a copy of the whole storage of each trainable embeddings
+// model, which is restored together with the other params.
+package checkpoint
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/statedict"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Checkpoint refers to the state of a training process.
+type Checkpoint struct {
+	// Model is the model under training.
+	Model nn.Model
+	// Optimizer is the optimizer of the model (optional).
+	Optimizer *gd.GradientDescent
+	// Rand is the random generator used during the training (optional).
+	Rand *rand.LockedRand
+	// Step is the number of optimization steps already performed.
+	Step int
+	// Epoch is the current epoch.
+	Epoch int
+	// Position is the position of the data iterator within the current epoch
+	// (e.g. the number of examples already processed).
+	Position int
+	// BestMetric is the best validation metric so far, or nil if there is none (optional).
+	BestMetric *mat.Float
+	// BadEpochs is the number of consecutive epochs without improvements of the validation metric.
+	BadEpochs int
+}
+
+// state is the serialized form of a Checkpoint.
+type state struct {
+	Params     map[string]nn.Param
+	Embeddings map[string]map[string][]byte
+	Optimizer  []byte
+	Rand       []byte
+	Step       int
+	Epoch      int
+	Position   int
+	BestMetric *mat.Float
+	BadEpochs  int
+}
+
+// Save writes the checkpoint to the file. The file is replaced atomically, so
+// that a failure during the writing never corrupts a previous checkpoint.
+func (c *Checkpoint) Save(filename string) (err error) {
+	s, err := c.state()
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+	buf := bufio.NewWriter(f)
+	if err = gob.NewEncoder(buf).Encode(s); err != nil {
+		return err
+	}
+	if err = buf.Flush(); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
+
+// Load reads the checkpoint from the file, restoring the state of the model,
+// of the optimizer and of the random generator in place. The model and the
+// optimizer must be created with the same settings as the saved ones.
+func (c *Checkpoint) Load(filename string) (err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+	var s state
+	if err = gob.NewDecoder(bufio.NewReader(f)).Decode(&s); err != nil {
+		return err
+	}
+	return c.restore(s)
+}
+
+// state returns the serializable state of the checkpoint. The params shared by
+// several paths are saved once.
+func (c *Checkpoint) state() (state, error) {
+	s := state{
+		Params:     make(map[string]nn.Param),
+		Embeddings: make(map[string]map[string][]byte),
+		Step:       c.Step,
+		Epoch:      c.Epoch,
+		Position:   c.Position,
+		BestMetric: c.BestMetric,
+		BadEpochs:  c.BadEpochs,
+	}
+	named := namedParams(c.Model)
+	seen := make(map[nn.Param]bool, len(named))
+	for _, path := range sortedPaths(named) {
+		if param := named[path]; !seen[param] {
+			seen[param] = true
+			s.Params[path] = param
+		}
+	}
+	var err error
+	for path, e := range trainableEmbeddings(c.Model) {
+		if s.Embeddings[path], err = e.Snapshot(); err != nil {
+			return state{}, err
+		}
+	}
+	if c.Optimizer != nil {
+		if s.Optimizer, err = c.Optimizer.MarshalBinary(); err != nil {
+			return state{}, err
+		}
+	}
+	if c.Rand != nil {
+		if s.Rand, err = c.Rand.MarshalBinary(); err != nil {
+			return state{}, err
+		}
+	}
+	return s, nil
+}
+
+// restore sets the given state into the checkpoint.
+func (c *Checkpoint) restore(s state) error {
+	if err := restoreEmbeddings(c.Model, s.Embeddings); err != nil {
+		return err
+	}
+	named := namedParams(c.Model)
+	restored := make(map[nn.Param]bool, len(named))
+	for path, saved := range s.Params {
+		param, ok := named[path]
+		if !ok {
+			return fmt.Errorf("checkpoint: param %q not found in the model", path)
+		}
+		param.ReplaceValue(saved.Value()) // it clears the payload
+		if payload := saved.Payload(); payload != nil {
+			param.SetPayload(payload)
+		}
+		restored[param] = true
+	}
+	for _, path := range sortedPaths(named) {
+		if !restored[named[path]] {
+			return fmt.Errorf("checkpoint: param %q not found in the checkpoint", path)
+		}
+	}
+	if c.Optimizer != nil && s.Optimizer != nil {
+		if err := c.Optimizer.UnmarshalBinary(s.Optimizer); err != nil {
+			return err
+		}
+	}
+	if c.Rand != nil && s.Rand != nil {
+		if err := c.Rand.UnmarshalBinary(s.Rand); err != nil {
+			return err
+		}
+	}
+	c.Step = s.Step
+	c.Epoch = s.Epoch
+	c.Position = s.Position
+	c.BestMetric = s.BestMetric
+	c.BadEpochs = s.BadEpochs
+	return nil
+}
+
+// restoreEmbeddings replaces the storage of each trainable embeddings model with
+// the saved one.
+func restoreEmbeddings(m nn.Model, saved map[string]map[string][]byte) error {
+	found := trainableEmbeddings(m)
+	for path := range saved {
+		if _, ok := found[path]; !ok {
+			return fmt.Errorf("checkpoint: embeddings %q not found in the model", path)
+		}
+	}
+	for path, e := range found {
+		snapshot, ok := saved[path]
+		if !ok {
+			return fmt.Errorf("checkpoint: embeddings %q not found in the checkpoint", path)
+		}
+		if err := e.Restore(snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// namedParams returns the params of the model by path (see statedict.NamedParams),
+// except for the embeddings loaded on demand from a storage, which are saved
+// with the storage itself.
+func namedParams(m nn.Model) map[string]nn.Param {
+	params := statedict.NamedParams(m)
+	for path := range params {
+		if strings.Contains("."+path+".", ".usedembeddings.") {
+			delete(params, path)
+		}
+	}
+	return params
+}
+
+func sortedPaths(params map[string]nn.Param) []string {
+	paths := make([]string, 0, len(params))
+	for path := range params {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
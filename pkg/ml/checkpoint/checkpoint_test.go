@@ -0,0 +1,239 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checkpoint
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/lrscheduler"
+	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type trainingTest struct {
+	model     *linear.Model
+	optimizer *gd.GradientDescent
+	rand      *rand.LockedRand
+}
+
+func newTrainingTest(seed uint64) *trainingTest {
+	rndGen := rand.NewLockedRand(seed)
+	model := linear.New(3, 2)
+	initializers.XavierUniform(model.W.Value(), 1.0, rndGen)
+	optimizer := gd.NewOptimizer(
+		adam.New(adam.NewDefaultConfig()),
+		nn.NewDefaultParamsIterator(model),
+		gd.ScheduleLearningRate(lrscheduler.NewLinear(2, 10)),
+		gd.WithParamGroups(gd.NewParamGroup(gd.SelectByModel(model).And(gd.SelectByType(nn.Biases)), nil)),
+	)
+	return &trainingTest{model: model, optimizer: optimizer, rand: rndGen}
+}
+
+// step performs an optimization step on a random example, and returns the loss.
+func (t *trainingTest) step() mat.Float {
+	g := ag.NewGraph(ag.Rand(t.rand))
+	defer g.Clear()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{t.rand.Float(), t.rand.Float(), t.rand.Float()}), false)
+	y := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, t.model).(*linear.Model).Forward(x)[0]
+	loss := g.ReduceSum(g.Square(y))
+	g.Backward(loss)
+	t.optimizer.IncBatch()
+	t.optimizer.Optimize()
+	return loss.ScalarValue()
+}
+
+func (t *trainingTest) checkpoint() *Checkpoint {
+	return &Checkpoint{Model: t.model, Optimizer: t.optimizer, Rand: t.rand}
+}
+
+func TestCheckpoint_SaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-checkpoint-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.ckpt")
+
+	original := newTrainingTest(42)
+	for i := 0; i < 3; i++ {
+		original.step()
+	}
+	c := original.checkpoint()
+	c.Step, c.Epoch, c.Position = 3, 1, 7
+	bestMetric := mat.Float(0.5)
+	c.BestMetric, c.BadEpochs = &bestMetric, 2
+	require.NoError(t, c.Save(filename))
+
+	var expected []mat.Float
+	for i := 0; i < 4; i++ {
+		expected = append(expected, original.step())
+	}
+
+	resumed := newTrainingTest(1) // different params and random state
+	c = resumed.checkpoint()
+	require.NoError(t, c.Load(filename))
+	assert.Equal(t, 3, c.Step)
+	assert.Equal(t, 1, c.Epoch)
+	assert.Equal(t, 7, c.Position)
+	assert.Equal(t, mat.Float(0.5), *c.BestMetric)
+	assert.Equal(t, 2, c.BadEpochs)
+	assert.NotNil(t, resumed.model.W.Payload())
+
+	var actual []mat.Float
+	for i := 0; i < 4; i++ {
+		actual = append(actual, resumed.step())
+	}
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, original.model.W.Value().Data(), resumed.model.W.Value().Data())
+	assert.Equal(t, original.model.B.Value().Data(), resumed.model.B.Value().Data())
+}
+
+type embeddingsModel struct {
+	nn.BaseModel
+	Embeddings *embeddings.Model
+	Layer      *linear.Model
+}
+
+type embeddingsTrainingTest struct {
+	model     *embeddingsModel
+	optimizer *gd.GradientDescent
+	rand      *rand.LockedRand
+}
+
+var embeddingsTestWords = []string{"a", "b", "c"}
+
+// newEmbeddingsTrainingTest returns a training test whose embeddings are stored in
+// the given folder. The embeddings of the test words are set with random values.
+func newEmbeddingsTrainingTest(dbPath string, seed uint64) *embeddingsTrainingTest {
+	rndGen := rand.NewLockedRand(seed)
+	model := &embeddingsModel{
+		Embeddings: embeddings.New(embeddings.Config{Size: 3, DBPath: dbPath}),
+		Layer:      linear.New(3, 2),
+	}
+	initializers.XavierUniform(model.Layer.W.Value(), 1.0, rndGen)
+	for _, word := range embeddingsTestWords {
+		embedding := mat.NewEmptyVecDense(3)
+		initializers.Normal(embedding, 0, 1, rndGen)
+		model.Embeddings.SetEmbedding(word, embedding)
+	}
+	optimizer := gd.NewOptimizer(adam.New(adam.NewDefaultConfig()), nn.NewDefaultParamsIterator(model))
+	return &embeddingsTrainingTest{model: model, optimizer: optimizer, rand: rndGen}
+}
+
+// step performs an optimization step on the embedding of the given word plus
+// some random noise, and returns the loss.
+func (t *embeddingsTrainingTest) step(word string) mat.Float {
+	g := ag.NewGraph(ag.Rand(t.rand))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, t.model).(*embeddingsModel)
+	noise := g.NewVariable(mat.NewVecDense([]mat.Float{t.rand.Float(), t.rand.Float(), t.rand.Float()}), false)
+	x := g.Add(proc.Embeddings.Encode([]string{word})[0], noise)
+	loss := g.ReduceSum(g.Square(proc.Layer.Forward(x)[0]))
+	g.Backward(loss)
+	t.optimizer.Optimize()
+	return loss.ScalarValue()
+}
+
+func (t *embeddingsTrainingTest) checkpoint() *Checkpoint {
+	return &Checkpoint{Model: t.model, Optimizer: t.optimizer, Rand: t.rand}
+}
+
+func TestCheckpoint_SaveLoadEmbeddings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-checkpoint-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.ckpt")
+	dbPath := filepath.Join(dir, "embeddings")
+
+	original := newEmbeddingsTrainingTest(dbPath, 42)
+	for _, word := range []string{"a", "b", "a"} {
+		original.step(word)
+	}
+	require.NoError(t, original.checkpoint().Save(filename))
+
+	// "c" is updated for the first time after the checkpoint
+	steps := []string{"b", "c", "a", "c"}
+	var expected []mat.Float
+	for _, word := range steps {
+		expected = append(expected, original.step(word))
+	}
+	var expectedEmbeddings [][]mat.Float
+	for _, word := range embeddingsTestWords {
+		expectedEmbeddings = append(expectedEmbeddings, original.model.Embeddings.GetStoredEmbedding(word).Value().Clone().Data())
+	}
+	original.model.Embeddings.Close()
+
+	// the resumed training uses the same storage, already updated after the checkpoint
+	resumed := newEmbeddingsTrainingTest(dbPath, 1)
+	defer resumed.model.Embeddings.Close()
+	require.NoError(t, resumed.checkpoint().Load(filename))
+
+	var actual []mat.Float
+	for _, word := range steps {
+		actual = append(actual, resumed.step(word))
+	}
+	assert.Equal(t, expected, actual)
+	for i, word := range embeddingsTestWords {
+		assert.Equal(t, expectedEmbeddings[i], resumed.model.Embeddings.GetStoredEmbedding(word).Value().Data(), word)
+	}
+}
+
+func TestCheckpoint_LoadMismatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-checkpoint-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.ckpt")
+
+	require.NoError(t, (&Checkpoint{Model: linear.New(3, 2)}).Save(filename))
+
+	other := &struct {
+		nn.BaseModel
+		Layer *linear.Model
+	}{Layer: linear.New(3, 2)}
+	assert.Error(t, (&Checkpoint{Model: other}).Load(filename))
+}
+
+func TestManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-checkpoint-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m, err := NewManager(filepath.Join(dir, "checkpoints"), 2)
+	require.NoError(t, err)
+
+	latest, err := m.Latest()
+	require.NoError(t, err)
+	assert.Equal(t, "", latest)
+
+	tt := newTrainingTest(42)
+	c := tt.checkpoint()
+	for _, step := range []int{5, 10, 100} {
+		c.Step = step
+		_, err := m.Save(c)
+		require.NoError(t, err)
+	}
+
+	filenames, err := m.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "checkpoints", "checkpoint-000000000010.ckpt"),
+		filepath.Join(dir, "checkpoints", "checkpoint-000000000100.ckpt"),
+	}, filenames)
+
+	c = newTrainingTest(1).checkpoint()
+	found, err := m.LoadLatest(c)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 100, c.Step)
+}
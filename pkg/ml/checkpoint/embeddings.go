@@ -0,0 +1,82 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checkpoint
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// trainableEmbeddings returns the embeddings models of the model whose storage is
+// updated during the training (i.e. neither read-only nor quantized), by path.
+// The paths are built like the ones of the params (see statedict.NamedParams),
+// following the struct fields, slices and arrays.
+func trainableEmbeddings(m nn.Model) map[string]*embeddings.Model {
+	w := embeddingsWalker{
+		found:   make(map[string]*embeddings.Model),
+		visited: make(map[uintptr]bool),
+	}
+	w.walk(reflect.ValueOf(m), "")
+	return w.found
+}
+
+// embeddingsWalker collects the embeddings models of a model by path.
+type embeddingsWalker struct {
+	found   map[string]*embeddings.Model
+	visited map[uintptr]bool
+}
+
+var contextType = reflect.TypeOf(nn.Context{})
+
+func (w embeddingsWalker) walk(v reflect.Value, path string) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			w.walk(v.Elem(), path)
+		}
+	case reflect.Ptr:
+		if v.IsNil() || w.visited[v.Pointer()] {
+			return
+		}
+		w.visited[v.Pointer()] = true
+		if e, ok := v.Interface().(*embeddings.Model); ok {
+			if !e.ReadOnly && !e.Quantized {
+				w.found[path] = e
+			}
+			return
+		}
+		w.walk(v.Elem(), path)
+	case reflect.Struct:
+		t := v.Type()
+		if t == contextType {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			switch {
+			case !v.Field(i).CanInterface():
+				continue // unexported
+			case field.Anonymous:
+				w.walk(v.Field(i), path)
+			default:
+				w.walk(v.Field(i), joinPath(path, strings.ToLower(field.Name)))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			w.walk(v.Index(i), joinPath(path, strconv.Itoa(i)))
+		}
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checkpoint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Manager saves the checkpoints of a training process into a directory,
+// keeping only the most recent ones.
+type Manager struct {
+	// Dir is the directory of the checkpoints.
+	Dir string
+	// Prefix is the prefix of the names of the checkpoint files.
+	Prefix string
+	// KeepLast is the number of checkpoints to keep; zero keeps all of them.
+	KeepLast int
+}
+
+// NewManager returns a new Manager, creating the directory if it does not exist.
+func NewManager(dir string, keepLast int) (*Manager, error) {
+	if keepLast < 0 {
+		panic("checkpoint: the number of checkpoints to keep must be non-negative")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		Dir:      dir,
+		Prefix:   "checkpoint",
+		KeepLast: keepLast,
+	}, nil
+}
+
+// Save saves the checkpoint into a new file, named after its step, and
+// removes the oldest checkpoints in excess. It returns the name of the file.
+func (m *Manager) Save(c *Checkpoint) (string, error) {
+	filename := filepath.Join(m.Dir, fmt.Sprintf("%s-%012d.ckpt", m.Prefix, c.Step))
+	if err := c.Save(filename); err != nil {
+		return "", err
+	}
+	return filename, m.rotate()
+}
+
+// Latest returns the name of the most recent checkpoint file, or an empty
+// string if there are none.
+func (m *Manager) Latest() (string, error) {
+	filenames, err := m.List()
+	if err != nil || len(filenames) == 0 {
+		return "", err
+	}
+	return filenames[len(filenames)-1], nil
+}
+
+// LoadLatest loads the most recent checkpoint into c (see Checkpoint.Load).
+// It reports whether a checkpoint has been found.
+func (m *Manager) LoadLatest(c *Checkpoint) (bool, error) {
+	filename, err := m.Latest()
+	if err != nil || filename == "" {
+		return false, err
+	}
+	if err := c.Load(filename); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// List returns the names of the checkpoint files, from the oldest to the most recent.
+func (m *Manager) List() ([]string, error) {
+	filenames, err := filepath.Glob(filepath.Join(m.Dir, m.Prefix+"-*.ckpt"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(filenames) // the steps are zero-padded
+	return filenames, nil
+}
+
+// rotate removes the oldest checkpoints in excess.
+func (m *Manager) rotate() error {
+	if m.KeepLast == 0 {
+		return nil
+	}
+	filenames, err := m.List()
+	if err != nil {
+		return err
+	}
+	for len(filenames) > m.KeepLast {
+		if err := os.Remove(filenames[0]); err != nil {
+			return err
+		}
+		filenames = filenames[1:]
+	}
+	return nil
+}
@@ -16,6 +16,9 @@ type QKV struct {
 	Queries []ag.Node
 	Keys    []ag.Node
 	Values  []ag.Node
+	// KeysMask optionally marks the keys which must not be attended (e.g. padding).
+	// If not nil, it has the same length as Keys, and true means that the key is masked.
+	KeysMask []bool
 }
 
 // ToQKV create a new QKV struct with queries = keys = values = xs.
@@ -31,23 +34,44 @@ func ToQKV(xs []ag.Node) QKV {
 // sequence to compute a representation of the same sequence.
 // This method requires that the query, the key and the value vectors have already been obtained
 // from the input sequence. The scaled factor is the square root of the dimension of the key vectors.
+// If the graph enables the fused operators (see ag.FusedOperators), the scaling, the masking and
+// the softmax are fused into a single operator.
 func ScaledDotProductAttention(g *ag.Graph, attIn QKV, scaleFactor mat.Float, useCausalMask bool) (context []ag.Node, prob []mat.Matrix) {
 	context = make([]ag.Node, len(attIn.Queries))
 	prob = make([]mat.Matrix, len(attIn.Queries))
 	keys := g.Stack(attIn.Keys...)
 	values := g.T(g.Stack(attIn.Values...))
 	factor := g.NewScalar(scaleFactor)
-	seqLen := len(attIn.Queries)
+	seqLen := len(attIn.Keys)
+	// The queries are aligned to the last keys, so that the past keys (e.g. cached
+	// during incremental decoding) are always visible through the causal mask.
+	offset := seqLen - len(attIn.Queries)
+	var keysMask ag.Node
+	if !useCausalMask && hasMaskedKeys(attIn.KeysMask) {
+		keysMask = g.NewVariable(attentionMask(attIn.KeysMask, seqLen, seqLen), false)
+	}
 	for i, q := range attIn.Queries {
+		if g.FusedOperators() {
+			var mask mat.Matrix
+			if useCausalMask {
+				mask = attentionMask(attIn.KeysMask, seqLen, offset+i+1)
+			} else if keysMask != nil {
+				mask = keysMask.Value()
+			}
+			attProb := g.ScaledMaskedSoftmax(g.Mul(keys, q), scaleFactor, mask)
+			context[i] = g.Mul(values, attProb)
+			prob[i] = attProb.Value()
+			continue
+		}
+
 		attScores := g.ProdScalar(g.Mul(keys, q), factor)
 
 		if useCausalMask {
 			// TODO: use external cache for causal mask?
-			causalMask := make([]mat.Float, seqLen)
-			for k := i + 1; k < len(causalMask); k++ {
-				causalMask[k] = mat.Inf(-1)
-			}
-			attScores = g.Add(attScores, g.NewVariable(mat.NewVecDense(causalMask), false))
+			causalMask := attentionMask(attIn.KeysMask, seqLen, offset+i+1)
+			attScores = g.Add(attScores, g.NewVariable(causalMask, false))
+		} else if keysMask != nil {
+			attScores = g.Add(attScores, keysMask)
 		}
 
 		attProb := g.Softmax(attScores)
@@ -57,6 +81,28 @@ func ScaledDotProductAttention(g *ag.Graph, attIn QKV, scaleFactor mat.Float, us
 	return
 }
 
+// hasMaskedKeys reports whether at least one key is masked.
+func hasMaskedKeys(keysMask []bool) bool {
+	for _, masked := range keysMask {
+		if masked {
+			return true
+		}
+	}
+	return false
+}
+
+// attentionMask returns the vector to be added to the attention scores, which is -inf for the
+// masked keys and for all the keys from the given visible length onward, and 0 otherwise.
+func attentionMask(keysMask []bool, seqLen, visible int) *mat.Dense {
+	mask := make([]mat.Float, seqLen)
+	for k := range mask {
+		if k >= visible || (keysMask != nil && keysMask[k]) {
+			mask[k] = mat.Inf(-1)
+		}
+	}
+	return mat.NewVecDense(mask)
+}
+
 // ScaledDotProductAttentionConcurrent does the same thing as ScaledDotProductAttention but processes input concurrently.
 func ScaledDotProductAttentionConcurrent(g *ag.Graph, attIn QKV, scaleFactor mat.Float) (context []ag.Node, prob []mat.Matrix) {
 	context = make([]ag.Node, len(attIn.Queries))
@@ -64,13 +110,29 @@ func ScaledDotProductAttentionConcurrent(g *ag.Graph, attIn QKV, scaleFactor mat
 	keys := g.Stack(attIn.Keys...)
 	values := g.T(g.Stack(attIn.Values...))
 	factor := g.NewScalar(scaleFactor)
+	var keysMask ag.Node
+	if hasMaskedKeys(attIn.KeysMask) {
+		keysMask = g.NewVariable(attentionMask(attIn.KeysMask, len(attIn.Keys), len(attIn.Keys)), false)
+	}
 	var wg sync.WaitGroup
 	wg.Add(len(attIn.Queries))
 	for i, q := range attIn.Queries {
 		go func(i int, q ag.Node) {
 			defer wg.Done()
-			attScores := g.ProdScalar(g.Mul(keys, q), factor)
-			attProb := g.Softmax(attScores)
+			var attProb ag.Node
+			if g.FusedOperators() {
+				var mask mat.Matrix
+				if keysMask != nil {
+					mask = keysMask.Value()
+				}
+				attProb = g.ScaledMaskedSoftmax(g.Mul(keys, q), scaleFactor, mask)
+			} else {
+				attScores := g.ProdScalar(g.Mul(keys, q), factor)
+				if keysMask != nil {
+					attScores = g.Add(attScores, keysMask)
+				}
+				attProb = g.Softmax(attScores)
+			}
 			context[i] = g.Mul(values, attProb)
 			prob[i] = attProb.Value()
 		}(i, q)
@@ -42,9 +42,17 @@ func TestScaledDotProductAttention(t *testing.T) {
 	assert.InDeltaSlice(t, []mat.Float{2.20423303670527, 8.41210390591632, 0.152898186332002}, context[2].Value().Data(), 1.0e-6)
 }
 
-//gocyclo:ignore
 func TestScaledDotProductAttention2(t *testing.T) {
-	g := ag.NewGraph()
+	t.Run("composed operators", func(t *testing.T) {
+		testScaledDotProductAttention2(t, ag.NewGraph())
+	})
+	t.Run("fused operators", func(t *testing.T) {
+		testScaledDotProductAttention2(t, ag.NewGraph(ag.FusedOperators(true)))
+	})
+}
+
+//gocyclo:ignore
+func testScaledDotProductAttention2(t *testing.T, g *ag.Graph) {
 
 	attIn := QKV{
 		Queries: []ag.Node{
@@ -99,6 +107,54 @@ func TestScaledDotProductAttention2(t *testing.T) {
 	assert.InDeltaSlice(t, []mat.Float{-0.31458, -0.432022, -0.289395, -0.410987}, attIn.Values[2].Grad().Data(), 1.0e-6)
 }
 
+func TestScaledDotProductAttention_KeysMask(t *testing.T) {
+	t.Run("composed operators", func(t *testing.T) {
+		testScaledDotProductAttentionKeysMask(t, ag.NewGraph())
+	})
+	t.Run("fused operators", func(t *testing.T) {
+		testScaledDotProductAttentionKeysMask(t, ag.NewGraph(ag.FusedOperators(true)))
+	})
+}
+
+func testScaledDotProductAttentionKeysMask(t *testing.T, g *ag.Graph) {
+
+	newVec := func(data ...mat.Float) ag.Node {
+		return g.NewVariable(mat.NewVecDense(data), true)
+	}
+	queries := []ag.Node{newVec(1.1, 0.0, 2.3), newVec(2.2, -0.5, 0.3)}
+	keys := []ag.Node{newVec(0.0, 1.2, 1.3), newVec(4.5, 4.3, 0.2), newVec(2.7, 3.6, 2.1)}
+	values := []ag.Node{newVec(1.2, 2.3, 3.4), newVec(2.2, 8.5, 0.0), newVec(2.3, 6.5, 3.5)}
+
+	// The masked (e.g. padding) key must be ignored, as if it were not present at all.
+	expected, _ := ScaledDotProductAttention(g, QKV{
+		Queries: queries,
+		Keys:    []ag.Node{keys[0], keys[2]},
+		Values:  []ag.Node{values[0], values[2]},
+	}, 1.0/mat.Sqrt(3), false)
+
+	masked := QKV{
+		Queries:  queries,
+		Keys:     keys,
+		Values:   values,
+		KeysMask: []bool{false, true, false},
+	}
+	actual, probs := ScaledDotProductAttention(g, masked, 1.0/mat.Sqrt(3), false)
+	actualConcurrent, _ := ScaledDotProductAttentionConcurrent(g, masked, 1.0/mat.Sqrt(3))
+
+	for i := range queries {
+		assert.InDeltaSlice(t, expected[i].Value().Data(), actual[i].Value().Data(), 1.0e-6)
+		assert.InDeltaSlice(t, expected[i].Value().Data(), actualConcurrent[i].Value().Data(), 1.0e-6)
+		assert.Equal(t, mat.Float(0), probs[i].AtVec(1))
+	}
+
+	// Combined with the causal mask (the queries are aligned to the last keys),
+	// the first query can only attend to the first key.
+	causal, probs := ScaledDotProductAttention(g, masked, 1.0/mat.Sqrt(3), true)
+	assert.InDeltaSlice(t, values[0].Value().Data(), causal[0].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{1, 0, 0}, probs[0].Data(), 1.0e-6)
+	assert.InDeltaSlice(t, expected[1].Value().Data(), causal[1].Value().Data(), 1.0e-6)
+}
+
 func TestLinearAttention(t *testing.T) {
 	g := ag.NewGraph()
 
@@ -59,10 +59,23 @@ func New(size, numOfHeads int, useCausalMask bool) *Model {
 
 // Forward performs the forward step for each input node and returns the result.
 func (m *Model) Forward(attIn attention.QKV) []ag.Node {
+	out, _ := m.ForwardWithPastKeysValues(attIn, nil)
+	return out
+}
+
+// ForwardWithPastKeysValues performs the forward step for each input node and returns the result
+// together with the keys and values of each head, which include the past ones (if any).
+// The past keys and values can be nil; otherwise they must contain one pair for each head.
+func (m *Model) ForwardWithPastKeysValues(attIn attention.QKV, past []selfattention.KeysValuesPair) ([]ag.Node, []selfattention.KeysValuesPair) {
 	g := m.Graph()
 	headsAttention := make([][]ag.Node, m.NumOfHeads)
+	keysValues := make([]selfattention.KeysValuesPair, m.NumOfHeads)
 	for h, proc := range m.Attention {
-		headsAttention[h] = proc.Forward(attIn)
+		var pastKV selfattention.KeysValuesPair
+		if past != nil {
+			pastKV = past[h]
+		}
+		headsAttention[h], keysValues[h] = proc.ForwardWithPastKeysValues(attIn, pastKV)
 	}
 	concatHeads := make([]ag.Node, len(attIn.Queries))
 	for i := 0; i < len(concatHeads); i++ {
@@ -72,5 +85,27 @@ func (m *Model) Forward(attIn attention.QKV) []ag.Node {
 		}
 		concatHeads[i] = g.Concat(buf...)
 	}
-	return m.OutputMerge.Forward(concatHeads...)
+	return m.OutputMerge.Forward(concatHeads...), keysValues
+}
+
+// ForwardBatch performs the forward step of a batch of independent sequences, and returns
+// the result for each of them (see selfattention.Model.ForwardBatch).
+func (m *Model) ForwardBatch(attIns []attention.QKV) [][]ag.Node {
+	g := m.Graph()
+	headsAttention := make([][][]ag.Node, m.NumOfHeads)
+	for h, proc := range m.Attention {
+		headsAttention[h] = proc.ForwardBatch(attIns)
+	}
+	concatHeads := make(nn.Batch, len(attIns))
+	for b, attIn := range attIns {
+		concatHeads[b] = make([]ag.Node, len(attIn.Queries))
+		for i := range concatHeads[b] {
+			buf := make([]ag.Node, m.NumOfHeads)
+			for j := 0; j < m.NumOfHeads; j++ {
+				buf[j] = headsAttention[j][b][i]
+			}
+			concatHeads[b][i] = g.Concat(buf...)
+		}
+	}
+	return nn.ForwardBatch(m.OutputMerge, concatHeads)
 }
@@ -59,20 +59,74 @@ func New(config Config) *Model {
 	}
 }
 
+// KeysValuesPair contains the projected keys and values of the Self-Attention.
+// It is used to cache the past computations during incremental decoding.
+type KeysValuesPair struct {
+	Keys   []ag.Node
+	Values []ag.Node
+}
+
 // Forward performs the forward step for each input node and returns the result.
 // It generates the queries, keys and values from the same input xs.
 //
 // Valid input types: []ag.Node or nn.AttentionInput.
 func (m *Model) Forward(attIn attention.QKV) []ag.Node {
+	context, _ := m.ForwardWithPastKeysValues(attIn, KeysValuesPair{})
+	return context
+}
+
+// ForwardWithPastKeysValues performs the forward step for each input node and returns the result
+// together with the projected keys and values, which include the past ones.
+// The new keys and values (if any) are appended to the past keys and values; if attIn has no
+// keys and values, the past ones are used as they are (e.g. cached encoder-decoder attention).
+func (m *Model) ForwardWithPastKeysValues(attIn attention.QKV, past KeysValuesPair) ([]ag.Node, KeysValuesPair) {
 	projAtt := attention.QKV{
 		Queries: m.Query.Forward(attIn.Queries...),
-		Keys:    m.Key.Forward(attIn.Keys...),
-		Values:  m.Value.Forward(attIn.Values...),
+		Keys:    append(append([]ag.Node{}, past.Keys...), m.Key.Forward(attIn.Keys...)...),
+		Values:  append(append([]ag.Node{}, past.Values...), m.Value.Forward(attIn.Values...)...),
 	}
+	projAtt.KeysMask = keysMask(attIn.KeysMask, len(projAtt.Keys))
 	context, prob := attention.ScaledDotProductAttention(m.Graph(), projAtt, m.ScaleFactor, m.UseCausalMask)
 	m.Attention = &ContextProb{
 		Context: context,
 		Prob:    prob,
 	}
-	return context
+	return context, KeysValuesPair{
+		Keys:   projAtt.Keys,
+		Values: projAtt.Values,
+	}
+}
+
+// keysMask aligns the mask to the last keys, so that the past keys (not covered by
+// the mask) are never masked. It returns nil if there is no mask.
+func keysMask(mask []bool, numOfKeys int) []bool {
+	if mask == nil || len(mask) == numOfKeys {
+		return mask
+	}
+	return append(make([]bool, numOfKeys-len(mask)), mask...)
+}
+
+// ForwardBatch performs the forward step of a batch of independent sequences, and returns
+// the result for each of them. The queries, keys and values of all the sequences are
+// projected at once, then each sequence attends to its own keys only, except for the
+// masked ones (see attention.QKV).
+func (m *Model) ForwardBatch(attIns []attention.QKV) [][]ag.Node {
+	queries, keys, values := make(nn.Batch, len(attIns)), make(nn.Batch, len(attIns)), make(nn.Batch, len(attIns))
+	for i, attIn := range attIns {
+		queries[i], keys[i], values[i] = attIn.Queries, attIn.Keys, attIn.Values
+	}
+	projQueries := queries.Split(m.Query.Forward(queries.Flatten()...))
+	projKeys := keys.Split(m.Key.Forward(keys.Flatten()...))
+	projValues := values.Split(m.Value.Forward(values.Flatten()...))
+
+	contexts := make([][]ag.Node, len(attIns))
+	for i, attIn := range attIns {
+		contexts[i], _ = attention.ScaledDotProductAttention(m.Graph(), attention.QKV{
+			Queries:  projQueries[i],
+			Keys:     projKeys[i],
+			Values:   projValues[i],
+			KeysMask: attIn.KeysMask,
+		}, m.ScaleFactor, m.UseCausalMask)
+	}
+	return contexts
 }
@@ -92,3 +92,57 @@ func newTestModel() *Model {
 	model.Query.B.Value().SetData([]mat.Float{0.3, 0.5, -0.7})
 	return model
 }
+
+func TestModel_ForwardWithPastKeysValues(t *testing.T) {
+	model := newTestModel()
+	model.UseCausalMask = true
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+
+	x1 := g.NewVariable(mat.NewVecDense([]mat.Float{-0.8, -0.9, -0.9, 1.0}), false)
+	x2 := g.NewVariable(mat.NewVecDense([]mat.Float{0.8, -0.3, 0.5, 0.3}), false)
+	x3 := g.NewVariable(mat.NewVecDense([]mat.Float{-0.2, 0.7, 0.2, 0.4}), false)
+
+	expected := proc.Forward(attention.ToQKV([]ag.Node{x1, x2, x3}))
+
+	var past KeysValuesPair
+	for i, x := range []ag.Node{x1, x2, x3} {
+		var output []ag.Node
+		output, past = proc.ForwardWithPastKeysValues(attention.ToQKV([]ag.Node{x}), past)
+		assert.Len(t, output, 1)
+		assert.Len(t, past.Keys, i+1)
+		assert.Len(t, past.Values, i+1)
+		assert.InDeltaSlice(t, expected[i].Value().Data(), output[0].Value().Data(), 1.0e-06)
+	}
+}
+
+func TestModel_ForwardBatch(t *testing.T) {
+	model := newTestModel()
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+
+	x1 := g.NewVariable(mat.NewVecDense([]mat.Float{-0.8, -0.9, -0.9, 1.0}), false)
+	x2 := g.NewVariable(mat.NewVecDense([]mat.Float{0.8, -0.3, 0.5, 0.3}), false)
+	x3 := g.NewVariable(mat.NewVecDense([]mat.Float{-0.2, 0.7, 0.2, 0.4}), false)
+	pad := g.NewVariable(mat.NewVecDense([]mat.Float{0.0, 0.0, 0.0, 0.0}), false)
+
+	expected1 := proc.Forward(attention.ToQKV([]ag.Node{x1, x2, x3}))
+	expected2 := proc.Forward(attention.ToQKV([]ag.Node{x3, x1}))
+
+	padded := attention.ToQKV([]ag.Node{x3, x1, pad})
+	padded.KeysMask = []bool{false, false, true}
+	actual := proc.ForwardBatch([]attention.QKV{
+		attention.ToQKV([]ag.Node{x1, x2, x3}),
+		padded,
+	})
+
+	assert.Len(t, actual, 2)
+	assert.Len(t, actual[0], 3)
+	assert.Len(t, actual[1], 3)
+	for i := range expected1 {
+		assert.InDeltaSlice(t, expected1[i].Value().Data(), actual[0][i].Value().Data(), 1.0e-06)
+	}
+	for i := range expected2 {
+		assert.InDeltaSlice(t, expected2[i].Value().Data(), actual[1][i].Value().Data(), 1.0e-06)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+)
+
+// Batch is a set of independent sequences of nodes, possibly of different lengths,
+// which share the same graph.
+//
+// Position-wise transformations (e.g. linear layers, feed-forward networks and
+// normalizations) can be applied to all the nodes of the batch at once, by
+// flattening the sequences into a single slice and splitting the result back.
+// This way, concurrent requests amortize the cost of a single forward step.
+type Batch [][]ag.Node
+
+// Flatten returns all the nodes of the batch in a single slice, one sequence after the other.
+func (b Batch) Flatten() []ag.Node {
+	out := make([]ag.Node, 0, b.Size())
+	for _, xs := range b {
+		out = append(out, xs...)
+	}
+	return out
+}
+
+// Split splits the given nodes, e.g. obtained transforming the flattened batch, into
+// sequences with the same lengths as the sequences of the batch.
+// It panics if the number of nodes is not equal to the total size of the batch.
+func (b Batch) Split(xs []ag.Node) Batch {
+	if len(xs) != b.Size() {
+		panic("nn: the number of nodes doesn't match the batch size")
+	}
+	out := make(Batch, len(b))
+	start := 0
+	for i, seq := range b {
+		end := start + len(seq)
+		out[i] = xs[start:end:end]
+		start = end
+	}
+	return out
+}
+
+// Size returns the total number of nodes of the batch.
+func (b Batch) Size() int {
+	size := 0
+	for _, xs := range b {
+		size += len(xs)
+	}
+	return size
+}
+
+// ForwardBatch applies the position-wise model to all the nodes of the batch at once,
+// and returns the result for each sequence.
+func ForwardBatch(m StandardModel, b Batch) Batch {
+	return b.Split(m.Forward(b.Flatten()...))
+}
@@ -0,0 +1,54 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBatch_FlattenSplit(t *testing.T) {
+	g := ag.NewGraph()
+	a, b, c := g.NewScalar(1), g.NewScalar(2), g.NewScalar(3)
+
+	batch := Batch{{a, b}, {}, {c}}
+	assert.Equal(t, 3, batch.Size())
+	assert.Equal(t, []ag.Node{a, b, c}, batch.Flatten())
+
+	split := batch.Split([]ag.Node{c, b, a})
+	assert.Equal(t, Batch{{c, b}, {}, {a}}, split)
+	assert.Panics(t, func() { batch.Split([]ag.Node{a, b}) })
+}
+
+type doubler struct {
+	BaseModel
+}
+
+func (m *doubler) Forward(xs ...ag.Node) []ag.Node {
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		ys[i] = m.Graph().ProdScalar(x, m.Graph().NewScalar(2))
+	}
+	return ys
+}
+
+func TestForwardBatch(t *testing.T) {
+	g := ag.NewGraph()
+	m := Reify(Context{Graph: g, Mode: Inference}, &doubler{}).(*doubler)
+
+	batch := Batch{
+		{g.NewScalar(1), g.NewScalar(2)},
+		{g.NewScalar(3)},
+	}
+	ys := ForwardBatch(m, batch)
+	assert.Len(t, ys, 2)
+	assert.Len(t, ys[0], 2)
+	assert.Len(t, ys[1], 1)
+	assert.Equal(t, mat.Float(2), ys[0][0].ScalarValue())
+	assert.Equal(t, mat.Float(4), ys[0][1].ScalarValue())
+	assert.Equal(t, mat.Float(6), ys[1][0].ScalarValue())
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/nlpodyssey/spago/pkg/ml/nn/birnn"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/crf"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"sync"
 )
 
 var (
@@ -55,6 +56,28 @@ func (m *Model) Predict(xs []ag.Node) []int {
 	return m.Decode(m.Forward(xs...))
 }
 
+// PredictBatch performs the prediction of a batch of independent sequences, and returns the
+// result for each of them. The recurrent network processes the sequences concurrently, while
+// the scorer transforms all the nodes of the batch at once.
+func (m *Model) PredictBatch(xs nn.Batch) [][]int {
+	hiddens := make(nn.Batch, len(xs))
+	var wg sync.WaitGroup
+	wg.Add(len(xs))
+	for i := range xs {
+		go func(i int) {
+			defer wg.Done()
+			hiddens[i] = m.BiRNN.Forward(xs[i]...)
+		}(i)
+	}
+	wg.Wait()
+	emissionScores := nn.ForwardBatch(m.Scorer, hiddens)
+	out := make([][]int, len(xs))
+	for i, scores := range emissionScores {
+		out[i] = m.Decode(scores)
+	}
+	return out
+}
+
 // NegativeLogLoss computes the negative log loss with respect to the targets.
 // TODO: the CRF backward tests are still missing
 func (m *Model) NegativeLogLoss(emissionScores []ag.Node, targets []int) ag.Node {
@@ -0,0 +1,22 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import "github.com/nlpodyssey/spago/pkg/ml/ag"
+
+// ExportGraph reifies the model on a new graph, in the given mode, calls
+// forward with the resulting processor and returns the export of the graph
+// built along the way (see ag.Graph.Export). The forward function can also
+// perform the backward pass, to include the gradients in the export.
+//
+// The parameters without a name are named after their fields, so that they
+// can be recognized in the exported graph.
+func ExportGraph(m Model, mode ProcessingMode, forward func(proc Model), opts ...ag.ExportOption) *ag.ExportedGraph {
+	ForEachParam(m, func(param Param) {}) // names the parameters
+	g := ag.NewGraph()
+	defer g.Clear()
+	forward(Reify(Context{Graph: g, Mode: mode}, m))
+	return g.Export(opts...)
+}
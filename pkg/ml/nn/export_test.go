@@ -0,0 +1,49 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type exportTestModel struct {
+	BaseModel
+	W Param
+	B Param
+}
+
+func (m *exportTestModel) Forward(x ag.Node) ag.Node {
+	g := m.Graph()
+	return g.Add(g.Mul(m.W, x), m.B)
+}
+
+func TestExportGraph(t *testing.T) {
+	m := &exportTestModel{
+		W: NewParam(mat.NewDense(2, 3, []mat.Float{1, 2, 3, 4, 5, 6})),
+		B: NewParam(mat.NewVecDense([]mat.Float{1, 1})),
+	}
+	exported := ExportGraph(m, Training, func(proc Model) {
+		p := proc.(*exportTestModel)
+		g := p.Graph()
+		y := p.Forward(g.NewVariable(mat.NewVecDense([]mat.Float{1, 0, -1}), false))
+		g.Backward(g.ReduceSum(y))
+	}, ag.ExportNorms(true))
+
+	var names, ops []string
+	for _, node := range exported.Nodes {
+		switch node.Kind {
+		case ag.WrapperNode:
+			names = append(names, node.Name)
+			assert.NotNil(t, node.GradNorm)
+		case ag.OperatorNode:
+			ops = append(ops, node.Op)
+		}
+	}
+	assert.ElementsMatch(t, []string{"w", "b"}, names)
+	assert.Equal(t, []string{"Mul", "Add", "ReduceSum"}, ops)
+}
@@ -11,10 +11,12 @@ import (
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/quantization"
 )
 
 var (
-	_ nn.Model = &Model{}
+	_ nn.Model                 = &Model{}
+	_ quantization.Quantizable = &Model{}
 )
 
 // Model contains the serializable parameters.
@@ -22,6 +24,8 @@ type Model struct {
 	nn.BaseModel
 	W nn.Param `spago:"type:weights"`
 	B nn.Param `spago:"type:biases"`
+	// QW holds the quantized weights, which replace W once the model has been quantized.
+	QW *quantization.Matrix
 }
 
 // Option allows to configure a new Model with your specific needs.
@@ -51,29 +55,89 @@ func New(in, out int, options ...Option) *Model {
 }
 
 // Forward performs the forward step for each input node and returns the result.
+//
+// In inference mode, several input nodes are stacked into a single matrix, so that
+// they are transformed by one matrix product (see nn.ForwardBatch). In training
+// mode, each node is transformed separately, since the backward step of the split
+// would cost the square of the number of nodes.
 func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	if m.isStackable(xs) {
+		return m.forwardStacked(xs, ag.OpIdentity)
+	}
+	return m.forwardEach(xs, m.forward)
+}
+
+// ForwardWithActivation performs the forward step for each input node, followed by the
+// given activation function, and returns the result. If the graph enables the fused
+// operators (see ag.FusedOperators), the activation is fused into the affine transformation.
+// The input nodes are stacked as in Forward.
+func (m *Model) ForwardWithActivation(activation ag.OpName, xs ...ag.Node) []ag.Node {
+	g := m.Graph()
+	if m.isStackable(xs) {
+		return m.forwardStacked(xs, activation)
+	}
+	if !g.FusedOperators() || m.QW != nil {
+		return m.forwardEach(xs, func(x ag.Node) ag.Node {
+			return g.Invoke(activation, m.forward(x))
+		})
+	}
+	return m.forwardEach(xs, func(x ag.Node) ag.Node {
+		return g.AffineActivation(m.B, m.W, x, activation)
+	})
+}
+
+func (m *Model) isStackable(xs []ag.Node) bool {
+	return len(xs) > 1 && m.Mode() == nn.Inference
+}
+
+// forwardStacked transforms the input nodes, stacked as the columns of a single
+// matrix, and splits the result into column vectors. The activation is fused into
+// the affine transformation if possible, otherwise it is applied to each output.
+func (m *Model) forwardStacked(xs []ag.Node, activation ag.OpName) []ag.Node {
+	g := m.Graph()
+	x := g.T(g.Stack(xs...))
+	var y ag.Node
+	switch {
+	case m.QW != nil:
+		y = g.Add(m.B, quantization.Mul(g, m.QW, x))
+	case g.FusedOperators() && ag.IsFusableActivation(activation):
+		y, activation = g.AffineActivation(m.B, m.W, x, activation), ag.OpIdentity
+	default:
+		y = g.AffineActivation(m.B, m.W, x, ag.OpIdentity)
+	}
+	ys := make([]ag.Node, len(xs))
+	for i := range ys {
+		ys[i] = g.Vec(g.ColView(y, i))
+		if activation != ag.OpIdentity {
+			ys[i] = g.Invoke(activation, ys[i])
+		}
+	}
+	return ys
+}
+
+func (m *Model) forwardEach(xs []ag.Node, f func(x ag.Node) ag.Node) []ag.Node {
 	if len(xs) > 1 && m.Graph().ConcurrentComputations() > 1 {
-		return m.fwdConcurrent(xs)
+		return m.fwdConcurrent(xs, f)
 	}
-	return m.fwdSerial(xs)
+	return m.fwdSerial(xs, f)
 }
 
-func (m *Model) fwdSerial(xs []ag.Node) []ag.Node {
+func (m *Model) fwdSerial(xs []ag.Node, f func(x ag.Node) ag.Node) []ag.Node {
 	ys := make([]ag.Node, len(xs))
 	for i, x := range xs {
-		ys[i] = m.forward(x)
+		ys[i] = f(x)
 	}
 	return ys
 }
 
-func (m *Model) fwdConcurrent(xs []ag.Node) []ag.Node {
+func (m *Model) fwdConcurrent(xs []ag.Node, f func(x ag.Node) ag.Node) []ag.Node {
 	ys := make([]ag.Node, len(xs))
 	var wg sync.WaitGroup
 	wg.Add(len(xs))
 	for i := range xs {
 		go func(i int) {
 			defer wg.Done()
-			ys[i] = m.forward(xs[i])
+			ys[i] = f(xs[i])
 		}(i)
 	}
 	wg.Wait()
@@ -82,5 +146,33 @@ func (m *Model) fwdConcurrent(xs []ag.Node) []ag.Node {
 
 // y = w (dot) x + b
 func (m *Model) forward(x ag.Node) ag.Node {
-	return nn.Affine(m.Graph(), m.B, m.W, x)
+	g := m.Graph()
+	if m.QW != nil {
+		return g.Add(m.B, quantization.Mul(g, m.QW, x))
+	}
+	if g.FusedOperators() {
+		return g.AffineActivation(m.B, m.W, x, ag.OpIdentity)
+	}
+	return nn.Affine(g, m.B, m.W, x)
+}
+
+// Quantize replaces the weights with their int8 quantization (see the
+// quantization package). The biases are kept as they are.
+func (m *Model) Quantize() error {
+	if m.QW != nil {
+		return nil
+	}
+	m.QW = quantization.NewMatrix(m.W.Value())
+	m.W = nil
+	return nil
+}
+
+// QuantizeLayers quantizes the weights of all the linear sub-models of m,
+// leaving the other Quantizable sub-models untouched (e.g. the embeddings,
+// whose DB is often read-only).
+func QuantizeLayers(m nn.Model) error {
+	return quantization.QuantizeSelected(m, func(q quantization.Quantizable) bool {
+		_, isLinear := q.(*Model)
+		return isLinear
+	})
 }
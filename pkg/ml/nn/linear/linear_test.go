@@ -51,6 +51,61 @@ func TestModel_Forward(t *testing.T) {
 	}, model.B.Grad().Data(), 1.0e-05)
 }
 
+func TestModel_ForwardStacked(t *testing.T) {
+	inputs := [][]mat.Float{
+		{-0.8, -0.9, -0.9, 1.0},
+		{0.8, -0.3, 0.5, 0.3},
+		{-0.2, 0.7, 0.2, 0.4},
+	}
+	// forward returns the outputs of the inputs, transformed one at a time in
+	// training mode, or stacked into one matrix in inference mode.
+	forward := func(model *Model, mode nn.ProcessingMode, fused bool, f func(m *Model, xs []ag.Node) []ag.Node) [][]mat.Float {
+		g := ag.NewGraph(ag.FusedOperators(fused))
+		proc := nn.Reify(nn.Context{Graph: g, Mode: mode}, model).(*Model)
+		xs := make([]ag.Node, len(inputs))
+		for i, data := range inputs {
+			xs[i] = g.NewVariable(mat.NewVecDense(data), false)
+		}
+		var out [][]mat.Float
+		for _, y := range f(proc, xs) {
+			assert.Equal(t, 5, y.Value().Rows())
+			assert.Equal(t, 1, y.Value().Columns())
+			out = append(out, y.Value().Data())
+		}
+		return out
+	}
+	cases := map[string]func(m *Model, xs []ag.Node) []ag.Node{
+		"Forward": func(m *Model, xs []ag.Node) []ag.Node {
+			return m.Forward(xs...)
+		},
+		"ForwardWithActivation fusable": func(m *Model, xs []ag.Node) []ag.Node {
+			return m.ForwardWithActivation(ag.OpTanh, xs...)
+		},
+		"ForwardWithActivation not fusable": func(m *Model, xs []ag.Node) []ag.Node {
+			return m.ForwardWithActivation(ag.OpSoftmax, xs...)
+		},
+		"ForwardBatch": func(m *Model, xs []ag.Node) []ag.Node {
+			return nn.Batch(nn.ForwardBatch(m, nn.Batch{xs[:2], xs[2:]})).Flatten()
+		},
+	}
+	for name, f := range cases {
+		for _, fused := range []bool{false, true} {
+			model := newTestModel()
+			expected := forward(model, nn.Training, fused, f)
+			actual := forward(model, nn.Inference, fused, f)
+			for i := range expected {
+				assert.InDeltaSlice(t, expected[i], actual[i], 1.0e-06, name)
+			}
+
+			quantized := newTestModel()
+			assert.NoError(t, quantized.Quantize())
+			for i, y := range forward(quantized, nn.Inference, fused, f) {
+				assert.InDeltaSlice(t, expected[i], y, 0.05, name)
+			}
+		}
+	}
+}
+
 func newTestModel() *Model {
 	model := New(4, 5)
 	model.W.Value().SetData([]mat.Float{
@@ -19,6 +19,9 @@ var (
 	_ nn.Model = &Model{}
 )
 
+// epsilon is added to the variance to avoid underflow errors.
+const epsilon = 1e-12
+
 // Model contains the serializable parameters.
 type Model struct {
 	nn.BaseModel
@@ -42,7 +45,12 @@ func New(size int) *Model {
 // y = (x - E\[x\]) / sqrt(VAR\[x\] + [EPS]) * g + b
 func (m *Model) Forward(xs ...ag.Node) []ag.Node {
 	g := m.Graph()
-	eps := g.Constant(1e-12) // avoid underflow errors
+	if g.FusedOperators() {
+		return ag.Map(func(x ag.Node) ag.Node {
+			return g.LayerNorm(x, m.W, m.B, epsilon)
+		}, xs)
+	}
+	eps := g.Constant(epsilon) // avoid underflow errors
 	ys := make([]ag.Node, len(xs))
 	for i, x := range xs {
 		mean := g.ReduceMean(x)
@@ -52,3 +60,23 @@ func (m *Model) Forward(xs ...ag.Node) []ag.Node {
 	}
 	return ys
 }
+
+// ForwardWithResidual performs the forward step on the sum of each input node
+// and the corresponding residual (e.g. a residual connection), and returns the result.
+// If the graph enables the fused operators (see ag.FusedOperators), the sum is fused
+// into the normalization.
+func (m *Model) ForwardWithResidual(xs, residuals []ag.Node) []ag.Node {
+	g := m.Graph()
+	if !g.FusedOperators() {
+		sums := make([]ag.Node, len(xs))
+		for i, x := range xs {
+			sums[i] = g.Add(residuals[i], x)
+		}
+		return m.Forward(sums...)
+	}
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		ys[i] = g.AddLayerNorm(residuals[i], x, m.W, m.B, epsilon)
+	}
+	return ys
+}
@@ -38,3 +38,27 @@ func newTestModel() *Model {
 	model.B.Value().SetData([]mat.Float{0.9, 0.2, -0.9, 0.2})
 	return model
 }
+
+func TestModel_ForwardWithResidual(t *testing.T) {
+	for _, fused := range []bool{false, true} {
+		model := newTestModel()
+		g := ag.NewGraph(ag.FusedOperators(fused))
+		ctx := nn.Context{Graph: g, Mode: nn.Training}
+
+		// == Forward
+		x := g.NewVariable(mat.NewVecDense([]mat.Float{0.1, 0.5, -0.2, -0.5}), true)
+		r := g.NewVariable(mat.NewVecDense([]mat.Float{0.3, 0.3, -0.5, 0.0}), true)
+		y := nn.ToNode(nn.Reify(ctx, model).(*Model).ForwardWithResidual([]ag.Node{x}, []ag.Node{r}))
+
+		assert.InDeltaSlice(t, []mat.Float{1.157863, 0.2, -0.561554, -0.444658}, y.Value().Data(), 1.0e-06)
+
+		// == Backward
+		y.PropagateGrad(mat.NewVecDense([]mat.Float{-1.0, -0.2, 0.4, 0.6}))
+		g.BackwardAll()
+
+		assert.InDeltaSlice(t, []mat.Float{-0.496261, 0.280677, -0.408772, 0.624355}, x.Grad().Data(), 1.0e-06)
+		assert.InDeltaSlice(t, []mat.Float{-0.496261, 0.280677, -0.408772, 0.624355}, r.Grad().Data(), 1.0e-06)
+		assert.InDeltaSlice(t, []mat.Float{-0.644658, -0.257863, -0.45126, -0.483493}, model.W.Grad().Data(), 1.0e-06)
+		assert.InDeltaSlice(t, []mat.Float{-1.0, -0.2, 0.4, 0.6}, model.B.Grad().Data(), 1.0e-06)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/gob"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
 )
 
 var (
@@ -47,9 +48,61 @@ func (m *Model) LastLayer() nn.Model {
 
 // Forward performs the forward step for each input node and returns the result.
 func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	if m.Graph().FusedOperators() {
+		return m.forwardFused(xs)
+	}
 	ys := m.Layers[0].Forward(xs...)
 	for i := 1; i < len(m.Layers); i++ {
 		ys = m.Layers[i].Forward(ys...)
 	}
 	return ys
 }
+
+// ForwardCheckpointed is like Forward, but it runs each layer in a checkpoint
+// of the graph (see ag.Graph.Checkpoint): only the outputs of the layers are
+// kept after the forward step, while the intermediate values of each layer
+// are recomputed during the backward step, saving memory during training.
+func (m *Model) ForwardCheckpointed(xs ...ag.Node) []ag.Node {
+	ys := xs
+	for _, layer := range m.Layers {
+		in := ys
+		ys = m.Graph().Checkpoint(func() []ag.Node {
+			return layer.Forward(in...)
+		})
+	}
+	return ys
+}
+
+// ActivationFuser is implemented by the layers which can fuse the activation
+// function of the following layer into their own operators (e.g. linear.Model).
+type ActivationFuser interface {
+	ForwardWithActivation(activation ag.OpName, xs ...ag.Node) []ag.Node
+}
+
+// forwardFused performs the forward step, fusing each ActivationFuser layer
+// with the following activation layer, if the activation has no parameters
+// and can be fused (see ag.IsFusableActivation).
+func (m *Model) forwardFused(xs []ag.Node) []ag.Node {
+	ys := xs
+	for i := 0; i < len(m.Layers); i++ {
+		fuser, isFuser := m.Layers[i].(ActivationFuser)
+		if act, ok := m.fusableActivation(i + 1); ok && isFuser {
+			ys = fuser.ForwardWithActivation(act, ys...)
+			i++
+			continue
+		}
+		ys = m.Layers[i].Forward(ys...)
+	}
+	return ys
+}
+
+func (m *Model) fusableActivation(i int) (ag.OpName, bool) {
+	if i >= len(m.Layers) {
+		return 0, false
+	}
+	act, ok := m.Layers[i].(*activation.Model)
+	if !ok || len(act.Params) > 0 || !ag.IsFusableActivation(act.Activation) {
+		return 0, false
+	}
+	return act.Activation, true
+}
@@ -0,0 +1,44 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stack
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestModel() *Model {
+	l1, l2 := linear.New(3, 2), linear.New(2, 2)
+	l1.W.Value().SetData([]mat.Float{0.5, -0.4, 0.3, 0.2, 0.8, -0.6})
+	l1.B.Value().SetData([]mat.Float{0.1, -0.2})
+	l2.W.Value().SetData([]mat.Float{0.3, -0.7, 0.9, 0.1})
+	l2.B.Value().SetData([]mat.Float{-0.4, 0.6})
+	return New(l1, activation.New(ag.OpGELU), l2, activation.New(ag.OpSoftsign))
+}
+
+func TestModel_ForwardFused(t *testing.T) {
+	forward := func(g *ag.Graph) (ag.Node, ag.Node) {
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, newTestModel()).(*Model)
+		x := g.NewVariable(mat.NewVecDense([]mat.Float{0.7, -0.3, 0.9}), true)
+		y := proc.Forward(x)[0]
+		g.Backward(y, ag.OutputGrad(mat.NewVecDense([]mat.Float{1.0, -0.5})))
+		return x, y
+	}
+	composed := ag.NewGraph()
+	x1, y1 := forward(composed)
+	fused := ag.NewGraph(ag.FusedOperators(true))
+	x2, y2 := forward(fused)
+
+	assert.InDeltaSlice(t, y1.Value().Data(), y2.Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, x1.Grad().Data(), x2.Grad().Data(), 1.0e-6)
+	// linear+GELU and linear are fused, softsign is not fusable: the ID of a new
+	// node reveals the number of nodes in the graph
+	assert.Less(t, fused.NewScalar(0).ID(), composed.NewScalar(0).ID())
+}
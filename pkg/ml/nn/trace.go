@@ -0,0 +1,16 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import "github.com/nlpodyssey/spago/pkg/ml/ag"
+
+// NewTrace returns an ag.Trace of the forward function of the model, for
+// repeated inference: the model is reified in Inference mode on the graph of
+// each trace, so that the replays read the current values of its parameters.
+func NewTrace(m Model, forward func(proc Model, xs []ag.Node) []ag.Node, opts ...ag.TraceOption) *ag.Trace {
+	return ag.NewTrace(func(g *ag.Graph, xs []ag.Node) []ag.Node {
+		return forward(Reify(Context{Graph: g, Mode: Inference}, m), xs)
+	}, opts...)
+}
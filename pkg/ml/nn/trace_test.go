@@ -0,0 +1,29 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewTrace(t *testing.T) {
+	m := &exportTestModel{
+		W: NewParam(mat.NewDense(2, 3, []mat.Float{1, 2, 3, 4, 5, 6})),
+		B: NewParam(mat.NewVecDense([]mat.Float{1, 1})),
+	}
+	tr := NewTrace(m, func(proc Model, xs []ag.Node) []ag.Node {
+		return []ag.Node{proc.(*exportTestModel).Forward(xs[0])}
+	})
+
+	x := mat.NewVecDense([]mat.Float{1, 0, -1})
+	assert.Equal(t, []mat.Float{-1, -1}, tr.Run(x)[0].Data())
+
+	m.B.Value().Data()[0] = 3
+	assert.Equal(t, []mat.Float{1, -1}, tr.Run(x)[0].Data())
+	assert.Equal(t, 1, tr.Len())
+}
@@ -0,0 +1,250 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package adafactor implements the Adafactor gradient descent optimization method.
+//
+// The second moments of the gradients of a matrix are factored into the moving
+// averages of the means of its rows and columns, so that the memory required by
+// the method is sublinear in the number of parameters.
+//
+// Reference: "Adafactor: Adaptive Learning Rates with Sublinear Memory Cost"
+// by Noam Shazeer and Mitchell Stern (2018).
+// (https://arxiv.org/pdf/1804.04235.pdf)
+package adafactor
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+)
+
+var _ gd.MethodConfig = &Config{}
+
+// Config provides configuration settings for an Adafactor optimizer.
+type Config struct {
+	gd.MethodConfig
+	// StepSize is the learning rate, or its upper bound if RelativeStep is true.
+	StepSize mat.Float
+	// Beta1 is the decay rate of the first moments; if zero, the first moments are not used.
+	Beta1 mat.Float
+	// DecayRate is the exponent of the decay rate of the second moments, that is 1 - t^DecayRate.
+	DecayRate mat.Float
+	// Epsilon1 is added to the squared gradients.
+	Epsilon1 mat.Float
+	// Epsilon2 is the lower bound of the root mean square of the params, when ScaleParameter is true.
+	Epsilon2 mat.Float
+	// ClipThreshold is the threshold of the root mean square of the final update.
+	ClipThreshold mat.Float
+	// WeightDecay is the coefficient of the decoupled weight decay.
+	WeightDecay mat.Float
+	// RelativeStep sets whether the learning rate decays as 1/sqrt(t), up to StepSize.
+	RelativeStep bool
+	// ScaleParameter sets whether the learning rate is scaled by the root mean square of the params.
+	ScaleParameter bool
+}
+
+// NewConfig returns a new Adafactor Config, with an explicit learning rate
+// (i.e. no relative step) scaled by the root mean square of the params.
+// It panics if beta1 is not in the range [0.0, 1.0).
+func NewConfig(stepSize, beta1, decayRate, clipThreshold, weightDecay mat.Float) Config {
+	if !(beta1 >= 0.0 && beta1 < 1.0) {
+		panic("adafactor: `beta1` must be in the range [0.0, 1.0)")
+	}
+	return Config{
+		StepSize:       stepSize,
+		Beta1:          beta1,
+		DecayRate:      decayRate,
+		Epsilon1:       1.0e-30,
+		Epsilon2:       1.0e-3,
+		ClipThreshold:  clipThreshold,
+		WeightDecay:    weightDecay,
+		RelativeStep:   false,
+		ScaleParameter: true,
+	}
+}
+
+// NewDefaultConfig returns a new Config with generically reasonable default values.
+func NewDefaultConfig() Config {
+	return Config{
+		StepSize:       0.01,
+		Beta1:          0.0,
+		DecayRate:      -0.8,
+		Epsilon1:       1.0e-30,
+		Epsilon2:       1.0e-3,
+		ClipThreshold:  1.0,
+		WeightDecay:    0.0,
+		RelativeStep:   true,
+		ScaleParameter: true,
+	}
+}
+
+var _ gd.Method = &Adafactor{}
+
+// Adafactor implements the Adafactor gradient descent optimization method.
+type Adafactor struct {
+	Config
+	TimeStep int
+}
+
+// New returns a new Adafactor optimizer, initialized according to the given configuration.
+func New(c Config) *Adafactor {
+	return &Adafactor{
+		Config:   c,
+		TimeStep: 1,
+	}
+}
+
+// Label returns the enumeration-like value which identifies this gradient descent method.
+func (o *Adafactor) Label() int {
+	return gd.Adafactor
+}
+
+// The support structure contains the delta, followed by either the factored
+// second moments (row and column vectors) of a matrix or the second moments
+// of a vector, followed by the first moments if Beta1 is not zero.
+const (
+	delta int = 0
+	v     int = 1 // second moments (not factored)
+	vr    int = 1 // second moments of the rows (factored)
+	vc    int = 2 // second moments of the columns (factored)
+)
+
+// isFactored reports whether the second moments of a matrix with the given
+// dimensions are factored.
+func isFactored(r, c int) bool {
+	return r > 1 && c > 1
+}
+
+// NewSupport returns a new support structure with the given dimensions.
+func (o *Adafactor) NewSupport(r, c int) *nn.Payload {
+	supp := []mat.Matrix{mat.NewEmptyDense(r, c)}
+	if isFactored(r, c) {
+		supp = append(supp, mat.NewEmptyVecDense(r), mat.NewEmptyDense(1, c))
+	} else {
+		supp = append(supp, mat.NewEmptyDense(r, c))
+	}
+	if o.Beta1 != 0.0 {
+		supp = append(supp, mat.NewEmptyDense(r, c))
+	}
+	return &nn.Payload{
+		Label: o.Label(),
+		Data:  supp,
+	}
+}
+
+// IncBatch beats the occurrence of a new batch.
+func (o *Adafactor) IncBatch() {
+	o.TimeStep++
+}
+
+// Delta returns the difference between the current params and where the method wants it to be.
+func (o *Adafactor) Delta(param nn.Param) mat.Matrix {
+	return o.calcDelta(param.Value(), param.Grad(), gd.GetOrSetPayload(param, o).Data)
+}
+
+// ScaledDelta is like Delta, with the learning rate scaled by the given factor (see gd.ScalableMethod).
+func (o *Adafactor) ScaledDelta(param nn.Param, lrFactor mat.Float) mat.Matrix {
+	return o.calcScaledDelta(param.Value(), param.Grad(), gd.GetOrSetPayload(param, o).Data, lrFactor)
+}
+
+func (o *Adafactor) calcDelta(params, grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
+	return o.calcScaledDelta(params, grads, supp, 1.0)
+}
+
+// beta2 = 1.0 - t^decayRate
+// v = v*beta2 + (grads*grads + eps1)*(1.0-beta2) (factored into rows and columns for matrices)
+// u = grads / sqrt(v)
+// u = u / max(1, rms(u) / clipThreshold) * alpha
+// m = m*beta1 + u*(1.0-beta1) (if beta1 is not zero)
+// d = m + params * alpha * weightDecay
+func (o *Adafactor) calcScaledDelta(params, grads mat.Matrix, supp []mat.Matrix, lrFactor mat.Float) mat.Matrix {
+	timeStep := mat.Float(o.TimeStep)
+	beta2 := 1.0 - mat.Pow(timeStep, o.DecayRate)
+	alpha := o.calcAlpha(params) * lrFactor
+
+	u := supp[delta]
+	if rows, cols := grads.Dims(); isFactored(rows, cols) {
+		o.calcFactoredUpdate(grads, supp[vr], supp[vc], u, beta2)
+	} else {
+		o.calcUpdate(grads, supp[v], u, beta2)
+	}
+	u.ProdScalarInPlace(alpha / mat.Max(1.0, rms(u)/o.ClipThreshold))
+
+	if o.Beta1 != 0.0 {
+		m := supp[len(supp)-1]
+		m.ProdScalarInPlace(o.Beta1)
+		u.ProdScalarInPlace(1.0 - o.Beta1)
+		m.AddInPlace(u)
+		u.Copy(m)
+	}
+	if o.WeightDecay != 0.0 {
+		uData, pData := u.Data(), params.Data()
+		for i, p := range pData {
+			uData[i] += p * alpha * o.WeightDecay
+		}
+	}
+	return u
+}
+
+// calcAlpha returns the learning rate of the current time step.
+func (o *Adafactor) calcAlpha(params mat.Matrix) mat.Float {
+	alpha := o.StepSize
+	if relativeStep := 1.0 / mat.Sqrt(mat.Float(o.TimeStep)); o.RelativeStep && relativeStep < alpha {
+		alpha = relativeStep
+	}
+	if o.ScaleParameter {
+		alpha *= mat.Max(o.Epsilon2, rms(params))
+	}
+	return alpha
+}
+
+// calcUpdate updates the second moments v and writes the update into u.
+func (o *Adafactor) calcUpdate(grads, v, u mat.Matrix, beta2 mat.Float) {
+	gData, vData, uData := grads.Data(), v.Data(), u.Data()
+	for i, g := range gData {
+		vData[i] = vData[i]*beta2 + (g*g+o.Epsilon1)*(1.0-beta2)
+		uData[i] = g / mat.Sqrt(vData[i])
+	}
+}
+
+// calcFactoredUpdate updates the second moments of the rows vr and of the
+// columns vc, and writes the update into u.
+func (o *Adafactor) calcFactoredUpdate(grads, vr, vc, u mat.Matrix, beta2 mat.Float) {
+	rows, cols := grads.Dims()
+	gData, vrData, vcData, uData := grads.Data(), vr.Data(), vc.Data(), u.Data()
+	for i := range vrData {
+		vrData[i] *= beta2
+	}
+	for j := range vcData {
+		vcData[j] *= beta2
+	}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			g := gData[i*cols+j]
+			sq := (g*g + o.Epsilon1) * (1.0 - beta2)
+			vrData[i] += sq / mat.Float(cols)
+			vcData[j] += sq / mat.Float(rows)
+		}
+	}
+	// v is approximated by the outer product of vr and vc, normalized by the mean of vr
+	var vrMean mat.Float = 0.0
+	for _, x := range vrData {
+		vrMean += x
+	}
+	vrMean /= mat.Float(rows)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			uData[i*cols+j] = gData[i*cols+j] / mat.Sqrt(vrData[i]/vrMean*vcData[j])
+		}
+	}
+}
+
+// rms returns the root mean square of the values of the matrix.
+func rms(m mat.Matrix) mat.Float {
+	var sum mat.Float = 0.0
+	for _, x := range m.Data() {
+		sum += x * x
+	}
+	return mat.Sqrt(sum / mat.Float(m.Size()))
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package adafactor
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_UpdateVector(t *testing.T) {
+	updater := New(NewConfig(
+		0.01, // step size
+		0.0,  // beta1
+		-0.8, // decay rate
+		1.0,  // clip threshold
+		0.0,  // weight decay
+	))
+
+	params := mat.NewVecDense([]mat.Float{3.0, 4.0})
+	grads := mat.NewVecDense([]mat.Float{0.5, -2.0})
+	supp := updater.NewSupport(params.Dims()).Data
+	assert.Len(t, supp, 2)
+
+	// at the first step the second moments are the squared gradients, and the
+	// step size is scaled by the root mean square of the params (sqrt(12.5))
+	assert.InDeltaSlice(t, []mat.Float{0.0353553, -0.0353553}, updater.calcDelta(params, grads, supp).Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.25, 4.0}, supp[v].Data(), 1.0e-6)
+
+	// beta2 = 1 - 2^-0.8
+	updater.IncBatch()
+	updater.calcDelta(params, grads, supp)
+	assert.InDeltaSlice(t, []mat.Float{0.25, 4.0}, supp[v].Data(), 1.0e-6)
+	updater.calcDelta(params, mat.NewVecDense([]mat.Float{1.0, 0.0}), supp)
+	assert.InDeltaSlice(t, []mat.Float{0.25*0.425651 + 0.574349, 4.0 * 0.425651}, supp[v].Data(), 1.0e-5)
+}
+
+func Test_UpdateFactored(t *testing.T) {
+	config := NewConfig(1.0, 0.0, -0.8, 1.0, 0.0)
+	config.ScaleParameter = false
+	updater := New(config)
+
+	params := mat.NewDense(2, 2, []mat.Float{0.1, 0.2, 0.3, 0.4})
+	grads := mat.NewDense(2, 2, []mat.Float{1.0, 2.0, 3.0, 4.0})
+	supp := updater.NewSupport(params.Dims()).Data
+	assert.Len(t, supp, 3)
+
+	d := updater.calcDelta(params, grads, supp)
+	assert.InDeltaSlice(t, []mat.Float{2.5, 12.5}, supp[vr].Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{5.0, 10.0}, supp[vc].Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.774597, 1.095445, 1.039230, 0.979796}, d.Data(), 1.0e-6)
+}
+
+func Test_UpdateClipMomentumWeightDecay(t *testing.T) {
+	config := NewConfig(0.1, 0.9, -0.8, 0.5, 0.2)
+	config.ScaleParameter = false
+	updater := New(config)
+
+	params := mat.NewVecDense([]mat.Float{3.0, 4.0})
+	grads := mat.NewVecDense([]mat.Float{0.5, -2.0})
+	supp := updater.NewSupport(params.Dims()).Data
+	assert.Len(t, supp, 3)
+
+	// u = sign(grads) / (rms / clip) * alpha = [0.05, -0.05]
+	// m = u * 0.1
+	// d = m + params * alpha * weightDecay
+	d := updater.calcDelta(params, grads, supp)
+	assert.InDeltaSlice(t, []mat.Float{0.005, -0.005}, supp[2].Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.065, 0.075}, d.Data(), 1.0e-6)
+}
+
+func Test_RelativeStep(t *testing.T) {
+	config := NewDefaultConfig()
+	config.ScaleParameter = false
+	updater := New(config)
+	params := mat.NewVecDense([]mat.Float{3.0, 4.0})
+	assert.InDelta(t, 0.01, updater.calcAlpha(params), 1.0e-6)
+	updater.TimeStep = 40000
+	assert.InDelta(t, 0.005, updater.calcAlpha(params), 1.0e-6)
+}
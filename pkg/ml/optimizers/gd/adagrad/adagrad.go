@@ -70,13 +70,22 @@ func (o *AdaGrad) Delta(param nn.Param) mat.Matrix {
 	return o.calcDelta(param.Grad(), gd.GetOrSetPayload(param, o).Data)
 }
 
+// ScaledDelta is like Delta, with the learning rate scaled by the given factor (see gd.ScalableMethod).
+func (o *AdaGrad) ScaledDelta(param nn.Param, lrFactor mat.Float) mat.Matrix {
+	return o.calcScaledDelta(param.Grad(), gd.GetOrSetPayload(param, o).Data, lrFactor)
+}
+
+func (o *AdaGrad) calcDelta(grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
+	return o.calcScaledDelta(grads, supp, 1.0)
+}
+
 // m = m + grads*grads
 // delta = (grads / (sqrt(m) + eps)) * lr
-func (o *AdaGrad) calcDelta(grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
+func (o *AdaGrad) calcScaledDelta(grads mat.Matrix, supp []mat.Matrix, lrFactor mat.Float) mat.Matrix {
 	supp[m].AddInPlace(grads.Prod(grads))
 	buf := mat.SqrtMatrix(supp[m])
 	buf.AddScalarInPlace(o.Epsilon)
 	delta := grads.Div(buf)
-	delta.ProdScalarInPlace(o.LR)
+	delta.ProdScalarInPlace(o.LR * lrFactor)
 	return delta
 }
@@ -108,32 +108,25 @@ func (o *Adam) Delta(param nn.Param) mat.Matrix {
 	return o.calcDelta(param.Grad(), gd.GetOrSetPayload(param, o).Data)
 }
 
+// ScaledDelta is like Delta, with the learning rate scaled by the given factor (see gd.ScalableMethod).
+func (o *Adam) ScaledDelta(param nn.Param, lrFactor mat.Float) mat.Matrix {
+	return o.calcScaledDelta(param.Grad(), gd.GetOrSetPayload(param, o).Data, lrFactor)
+}
+
+func (o *Adam) calcDelta(grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
+	return o.calcScaledDelta(grads, supp, 1.0)
+}
+
 // v = v*beta1 + grads*(1.0-beta1)
 // m = m*beta2 + (grads*grads)*(1.0-beta2)
 // d = (v / (sqrt(m) + eps)) * alpha
-func (o *Adam) calcDelta(grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
-	updateV(grads, supp, o.Beta1)
-	updateM(grads, supp, o.Beta2)
+func (o *Adam) calcScaledDelta(grads mat.Matrix, supp []mat.Matrix, lrFactor mat.Float) mat.Matrix {
+	gd.UpdateFirstMoment(supp[v], supp[buf1], grads, o.Beta1)
+	gd.UpdateSecondMoment(supp[m], supp[buf2], grads, o.Beta2)
 	buf := supp[m].Sqrt().AddScalarInPlace(o.Epsilon)
 	defer mat.ReleaseDense(buf.(*mat.Dense))
 	suppDiv := supp[v].Div(buf)
 	defer mat.ReleaseDense(suppDiv.(*mat.Dense))
-	supp[buf3].ProdMatrixScalarInPlace(suppDiv, o.Alpha)
+	supp[buf3].ProdMatrixScalarInPlace(suppDiv, o.Alpha*lrFactor)
 	return supp[buf3]
 }
-
-// v = v*beta1 + grads*(1.0-beta1)
-func updateV(grads mat.Matrix, supp []mat.Matrix, beta1 mat.Float) {
-	supp[v].ProdScalarInPlace(beta1)
-	supp[buf1].ProdMatrixScalarInPlace(grads, 1.0-beta1)
-	supp[v].AddInPlace(supp[buf1])
-}
-
-// m = m*beta2 + (grads*grads)*(1.0-beta2)
-func updateM(grads mat.Matrix, supp []mat.Matrix, beta2 mat.Float) {
-	supp[m].ProdScalarInPlace(beta2)
-	sqGrad := grads.Prod(grads)
-	defer mat.ReleaseDense(sqGrad.(*mat.Dense))
-	supp[buf2].ProdMatrixScalarInPlace(sqGrad, 1.0-beta2)
-	supp[m].AddInPlace(supp[buf2])
-}
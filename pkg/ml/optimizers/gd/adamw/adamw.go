@@ -0,0 +1,143 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package adamw implements the AdamW gradient descent optimization method,
+// that is Adam with decoupled weight decay.
+//
+// Reference: "Decoupled Weight Decay Regularization" by Ilya Loshchilov and Frank Hutter (2019).
+// (https://arxiv.org/pdf/1711.05101.pdf)
+package adamw
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+)
+
+var _ gd.MethodConfig = &Config{}
+
+// Config provides configuration settings for an AdamW optimizer.
+type Config struct {
+	gd.MethodConfig
+	StepSize    mat.Float
+	Beta1       mat.Float
+	Beta2       mat.Float
+	Epsilon     mat.Float
+	WeightDecay mat.Float
+}
+
+// NewConfig returns a new AdamW Config.
+// It panics if beta1 or beta2 are not in the range [0.0, 1.0).
+func NewConfig(stepSize, beta1, beta2, epsilon, weightDecay mat.Float) Config {
+	if !(beta1 >= 0.0 && beta1 < 1.0) {
+		panic("adamw: `beta1` must be in the range [0.0, 1.0)")
+	}
+	if !(beta2 >= 0.0 && beta2 < 1.0) {
+		panic("adamw: `beta2` must be in the range [0.0, 1.0)")
+	}
+	return Config{
+		StepSize:    stepSize,
+		Beta1:       beta1,
+		Beta2:       beta2,
+		Epsilon:     epsilon,
+		WeightDecay: weightDecay,
+	}
+}
+
+// NewDefaultConfig returns a new Config with generically reasonable default values.
+func NewDefaultConfig() Config {
+	return Config{
+		StepSize:    0.001,
+		Beta1:       0.9,
+		Beta2:       0.999,
+		Epsilon:     1.0e-8,
+		WeightDecay: 0.01,
+	}
+}
+
+var _ gd.Method = &AdamW{}
+
+// AdamW implements the AdamW gradient descent optimization method.
+type AdamW struct {
+	Config
+	TimeStep int
+}
+
+// New returns a new AdamW optimizer, initialized according to the given configuration.
+func New(c Config) *AdamW {
+	return &AdamW{
+		Config:   c,
+		TimeStep: 1,
+	}
+}
+
+// Label returns the enumeration-like value which identifies this gradient descent method.
+func (o *AdamW) Label() int {
+	return gd.AdamW
+}
+
+const (
+	m    int = 0
+	v    int = 1
+	buf1 int = 2 // contains 'grads.ProdScalar(1.0 - beta1)'
+	buf2 int = 3 // contains 'grads.Prod(grads).ProdScalar(1.0 - beta2)'
+	buf3 int = 4
+)
+
+// NewSupport returns a new support structure with the given dimensions.
+func (o *AdamW) NewSupport(r, c int) *nn.Payload {
+	supp := make([]mat.Matrix, 5)
+	supp[m] = mat.NewEmptyDense(r, c)
+	supp[v] = mat.NewEmptyDense(r, c)
+	supp[buf1] = mat.NewEmptyDense(r, c)
+	supp[buf2] = mat.NewEmptyDense(r, c)
+	supp[buf3] = mat.NewEmptyDense(r, c)
+	return &nn.Payload{
+		Label: o.Label(),
+		Data:  supp,
+	}
+}
+
+// IncBatch beats the occurrence of a new batch.
+func (o *AdamW) IncBatch() {
+	o.TimeStep++
+}
+
+// Delta returns the difference between the current params and where the method wants it to be.
+func (o *AdamW) Delta(param nn.Param) mat.Matrix {
+	return o.calcDelta(param.Value(), param.Grad(), gd.GetOrSetPayload(param, o).Data)
+}
+
+// ScaledDelta is like Delta, with the learning rate scaled by the given factor (see gd.ScalableMethod).
+func (o *AdamW) ScaledDelta(param nn.Param, lrFactor mat.Float) mat.Matrix {
+	return o.calcScaledDelta(param.Value(), param.Grad(), gd.GetOrSetPayload(param, o).Data, lrFactor)
+}
+
+func (o *AdamW) calcDelta(params, grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
+	return o.calcScaledDelta(params, grads, supp, 1.0)
+}
+
+// m = m*beta1 + grads*(1.0-beta1)
+// v = v*beta2 + (grads*grads)*(1.0-beta2)
+// d = (m / (sqrt(v) + eps)) * alpha + params * stepSize * weightDecay
+func (o *AdamW) calcScaledDelta(params, grads mat.Matrix, supp []mat.Matrix, lrFactor mat.Float) mat.Matrix {
+	gd.UpdateFirstMoment(supp[m], supp[buf1], grads, o.Beta1)
+	gd.UpdateSecondMoment(supp[v], supp[buf2], grads, o.Beta2)
+	buf := supp[v].Sqrt().AddScalarInPlace(o.Epsilon)
+	defer mat.ReleaseDense(buf.(*mat.Dense))
+	suppDiv := supp[m].Div(buf)
+	defer mat.ReleaseDense(suppDiv.(*mat.Dense))
+	supp[buf3].ProdMatrixScalarInPlace(suppDiv, o.calcAlpha()*lrFactor)
+	if o.WeightDecay != 0.0 {
+		supp[buf1].ProdMatrixScalarInPlace(params, o.StepSize*lrFactor*o.WeightDecay)
+		supp[buf3].AddInPlace(supp[buf1])
+	}
+	return supp[buf3]
+}
+
+// calcAlpha returns the step size with the bias corrections of the moments.
+func (o *AdamW) calcAlpha() mat.Float {
+	timeStep := mat.Float(o.TimeStep)
+	return o.StepSize * mat.Sqrt(1.0-mat.Pow(o.Beta2, timeStep)) / (1.0 - mat.Pow(o.Beta1, timeStep))
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package adamw
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Update(t *testing.T) {
+	updater := New(NewConfig(
+		0.001,  // step size
+		0.9,    // beta1
+		0.999,  // beta2
+		1.0e-8, // epsilon
+		0.0,    // weight decay
+	))
+
+	params := mat.NewVecDense([]mat.Float{0.4, 0.4, 0.5, 1.0, 0.8})
+	grads := mat.NewVecDense([]mat.Float{0.9, 0.7, 0.4, 0.8, 0.1})
+
+	supp := updater.NewSupport(params.Dims()).Data
+	supp[m].SetData([]mat.Float{0.7, 0.8, 0.5, 0.3, 0.2})
+	supp[v].SetData([]mat.Float{1.0, 0.4, 0.7, 0.0, 0.2})
+
+	params.SubInPlace(updater.calcDelta(params, grads, supp))
+
+	// same as Adam without weight decay
+	assert.InDeltaSlice(t, []mat.Float{0.399772, 0.399605, 0.4998147, 0.995625, 0.799865}, params.Data(), 1.0e-6)
+}
+
+func Test_UpdateWeightDecay(t *testing.T) {
+	updater := New(NewConfig(
+		0.001,  // step size
+		0.9,    // beta1
+		0.999,  // beta2
+		1.0e-8, // epsilon
+		0.1,    // weight decay
+	))
+
+	params := mat.NewVecDense([]mat.Float{0.4, 0.4, 0.5, 1.0, 0.8})
+	grads := mat.NewVecDense([]mat.Float{0.9, 0.7, 0.4, 0.8, 0.1})
+
+	supp := updater.NewSupport(params.Dims()).Data
+	supp[m].SetData([]mat.Float{0.7, 0.8, 0.5, 0.3, 0.2})
+	supp[v].SetData([]mat.Float{1.0, 0.4, 0.7, 0.0, 0.2})
+
+	params.SubInPlace(updater.calcDelta(params, grads, supp))
+
+	// the decay (step size * weight decay * params) is decoupled from the gradients
+	assert.InDeltaSlice(t, []mat.Float{
+		0.399772 - 0.00004,
+		0.399605 - 0.00004,
+		0.4998147 - 0.00005,
+		0.995625 - 0.0001,
+		0.799865 - 0.00008,
+	}, params.Data(), 1.0e-6)
+}
+
+func Test_IncBatch(t *testing.T) {
+	updater := New(NewDefaultConfig())
+	assert.InDelta(t, 3.1623e-4, updater.calcAlpha(), 1.0e-08)
+	updater.IncBatch()
+	assert.Equal(t, 2, updater.TimeStep)
+	assert.InDelta(t, 2.3531e-4, updater.calcAlpha(), 1.0e-08)
+}
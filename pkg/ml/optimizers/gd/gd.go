@@ -5,6 +5,8 @@
 package gd
 
 import (
+	"bytes"
+	"encoding/gob"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/clipper"
@@ -17,6 +19,8 @@ import (
 type GradientDescent struct {
 	method           Method // optimization method (SGD, AdaGrad, Adam, ...)
 	gradClipper      clipper.GradClipper
+	lrScheduler      LearningRateScheduler
+	paramGroups      []ParamGroup
 	paramsGetter     nn.ParamsGetter
 	paramsToOptimize []nn.Param
 	// processingQueue allows proper handling for computationally heavy operations
@@ -49,6 +53,16 @@ func ClipGradByNorm(max, normType mat.Float) Option {
 	}
 }
 
+// ScheduleLearningRate is an option to scale the learning rate of the optimization method
+// by the factor given by the scheduler at each update of the params.
+// The method must implement ScalableMethod, as all the methods of this module do;
+// otherwise NewOptimizer panics.
+func ScheduleLearningRate(scheduler LearningRateScheduler) Option {
+	return func(f *GradientDescent) {
+		f.lrScheduler = scheduler
+	}
+}
+
 // ConcurrentComputations sets the maximum number of concurrent computations handled by the GradientDescent
 // for heavy tasks such as the params update steps.
 // The value 1 corresponds to sequential execution.
@@ -72,13 +86,35 @@ func NewOptimizer(method Method, paramsIterator nn.ParamsGetter, opts ...Option)
 	for _, opt := range opts {
 		opt(optimizer)
 	}
+	optimizer.checkScalableMethods()
 	return optimizer
 }
 
+// checkScalableMethods panics if the learning rate of a method which does not
+// implement ScalableMethod is scheduled or scaled by a parameter group.
+func (o *GradientDescent) checkScalableMethods() {
+	check := func(method Method, scaled bool) {
+		if _, ok := method.(ScalableMethod); scaled && !ok {
+			panic("gd: the optimization method does not support the scaling of the learning rate")
+		}
+	}
+	check(o.method, o.lrScheduler != nil)
+	for _, group := range o.paramGroups {
+		if group.Frozen {
+			continue
+		}
+		method := group.Method
+		if method == nil {
+			method = o.method
+		}
+		check(method, group.LRScheduler != nil || o.lrScheduler != nil || group.LRFactor != 1.0)
+	}
+}
+
 // Optimize optimize the params, applying the optional gradient clipping.
 // After the optimization the params have zero gradients.
 func (o *GradientDescent) Optimize() {
-	o.paramsToOptimize = o.paramsGetter.Params()
+	o.paramsToOptimize = o.discardFrozen(o.paramsGetter.Params())
 	if o.paramsToOptimize == nil {
 		return
 	}
@@ -91,8 +127,7 @@ func (o *GradientDescent) Optimize() {
 func (o *GradientDescent) updateParamsSerial() {
 	for _, param := range o.paramsToOptimize {
 		if param.HasGrad() {
-			delta := o.method.Delta(param) // important: don't release delta here
-			param.ApplyDelta(delta)
+			o.updateParam(param)
 			param.ZeroGrad()
 		}
 	}
@@ -109,8 +144,7 @@ func (o *GradientDescent) updateParams() {
 		go func(param nn.Param) {
 			defer wg.Done()
 			o.processingQueue.Run(func() {
-				delta := o.method.Delta(param)
-				param.ApplyDelta(delta)
+				o.updateParam(param)
 			})
 			param.ZeroGrad()
 		}(param)
@@ -118,6 +152,79 @@ func (o *GradientDescent) updateParams() {
 	wg.Wait()
 }
 
+// updateParam applies the optimization method of the param, according to its group.
+func (o *GradientDescent) updateParam(param nn.Param) {
+	group := o.groupOf(param)
+	method, scheduler := group.Method, group.LRScheduler
+	if method == nil {
+		method = o.method
+	}
+	if scheduler == nil {
+		scheduler = o.lrScheduler
+	}
+	lrFactor := group.LRFactor
+	if scheduler != nil {
+		lrFactor *= scheduler.LearningRateFactor()
+	}
+	delta := ScaledDelta(method, param, lrFactor) // important: don't release delta here
+	param.ApplyDelta(delta)
+}
+
+// groupOf returns the first group which selects the param, or a group with
+// the default settings of the GradientDescent.
+func (o *GradientDescent) groupOf(param nn.Param) ParamGroup {
+	for _, group := range o.paramGroups {
+		if group.Selector(param) {
+			return group
+		}
+	}
+	return ParamGroup{LRFactor: 1.0}
+}
+
+// discardFrozen zeroes the gradients of the params of the frozen groups, and
+// returns the remaining params.
+func (o *GradientDescent) discardFrozen(params []nn.Param) []nn.Param {
+	if params == nil || len(o.paramGroups) == 0 {
+		return params
+	}
+	ret := make([]nn.Param, 0, len(params))
+	for _, param := range params {
+		if o.groupOf(param).Frozen {
+			param.ZeroGrad()
+			continue
+		}
+		ret = append(ret, param)
+	}
+	return ret
+}
+
+// schedulers returns the distinct optimization methods and learning rate
+// schedulers of the GradientDescent and of its groups.
+func (o *GradientDescent) schedulers() []interface{} {
+	var ret []interface{}
+	add := func(item interface{}) {
+		for _, other := range ret {
+			if other == item {
+				return
+			}
+		}
+		ret = append(ret, item)
+	}
+	add(o.method)
+	if o.lrScheduler != nil {
+		add(o.lrScheduler)
+	}
+	for _, group := range o.paramGroups {
+		if group.Method != nil {
+			add(group.Method)
+		}
+		if group.LRScheduler != nil {
+			add(group.LRScheduler)
+		}
+	}
+	return ret
+}
+
 // clipGrad applies the gradient clipping to all the observed parameters.
 func (o *GradientDescent) clipGrads() {
 	if o.gradClipper == nil {
@@ -134,21 +241,53 @@ func (o *GradientDescent) clipGrads() {
 
 // IncExample beats the occurrence of a new example.
 func (o *GradientDescent) IncExample() {
-	if method, ok := o.method.(ExampleScheduler); ok {
-		method.IncExample()
+	for _, item := range o.schedulers() {
+		if scheduler, ok := item.(ExampleScheduler); ok {
+			scheduler.IncExample()
+		}
 	}
 }
 
 // IncBatch beats the occurrence of a new batch.
 func (o *GradientDescent) IncBatch() {
-	if method, ok := o.method.(BatchScheduler); ok {
-		method.IncBatch()
+	for _, item := range o.schedulers() {
+		if scheduler, ok := item.(BatchScheduler); ok {
+			scheduler.IncBatch()
+		}
 	}
 }
 
 // IncEpoch beats the occurrence of a new epoch.
 func (o *GradientDescent) IncEpoch() {
-	if method, ok := o.method.(EpochScheduler); ok {
-		method.IncEpoch()
+	for _, item := range o.schedulers() {
+		if scheduler, ok := item.(EpochScheduler); ok {
+			scheduler.IncEpoch()
+		}
+	}
+}
+
+// MarshalBinary encodes the state of the optimization methods and of the learning
+// rate schedulers (e.g. their time steps), so that a training process can be resumed.
+// The support structures of the methods are part of the params, instead.
+func (o *GradientDescent) MarshalBinary() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	enc := gob.NewEncoder(buf)
+	for _, item := range o.schedulers() {
+		if err := enc.Encode(item); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary restores the state of the optimization methods and of the learning
+// rate schedulers, from the encoding of an optimizer created with the same settings.
+func (o *GradientDescent) UnmarshalBinary(data []byte) error {
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	for _, item := range o.schedulers() {
+		if err := dec.Decode(item); err != nil {
+			return err
+		}
 	}
+	return nil
 }
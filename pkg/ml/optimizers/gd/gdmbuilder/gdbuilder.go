@@ -6,15 +6,19 @@ package gdmbuilder
 
 import (
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adafactor"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adagrad"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adamw"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/lamb"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/lookahead"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/radam"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/rmsprop"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
 )
 
 // NewMethod returns a new gd.Method, chosen and initialized according to
-// the given config.
+// the given config. The method wrapped by a lookahead.Config is built in turn.
 // It panics if the config type is unknown or unsupported.
 func NewMethod(config gd.MethodConfig) gd.Method {
 	switch config := config.(type) {
@@ -28,6 +32,14 @@ func NewMethod(config gd.MethodConfig) gd.Method {
 		return rmsprop.New(config)
 	case sgd.Config:
 		return sgd.New(config)
+	case adamw.Config:
+		return adamw.New(config)
+	case lamb.Config:
+		return lamb.New(config)
+	case adafactor.Config:
+		return adafactor.New(config)
+	case lookahead.Config:
+		return lookahead.New(config, NewMethod(config.Method))
 	default:
 		panic("gd: unknown method configuration")
 	}
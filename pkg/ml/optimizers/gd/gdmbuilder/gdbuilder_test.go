@@ -0,0 +1,45 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdmbuilder
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adafactor"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adagrad"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adamw"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/lamb"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/lookahead"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/radam"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/rmsprop"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewMethod(t *testing.T) {
+	testCases := []struct {
+		config gd.MethodConfig
+		label  int
+	}{
+		{sgd.NewConfig(0.1, 0.9, false), gd.SGD},
+		{adagrad.NewDefaultConfig(), gd.AdaGrad},
+		{adam.NewDefaultConfig(), gd.Adam},
+		{radam.NewDefaultConfig(), gd.RAdam},
+		{rmsprop.NewDefaultConfig(), gd.RMSProp},
+		{adamw.NewDefaultConfig(), gd.AdamW},
+		{lamb.NewDefaultConfig(), gd.LAMB},
+		{adafactor.NewDefaultConfig(), gd.Adafactor},
+		{lookahead.NewDefaultConfig(adamw.NewDefaultConfig()), gd.Lookahead},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.label, NewMethod(tc.config).Label())
+	}
+
+	method := NewMethod(lookahead.NewDefaultConfig(lamb.NewDefaultConfig())).(*lookahead.Lookahead)
+	assert.Equal(t, gd.LAMB, method.Inner.Label())
+
+	assert.Panics(t, func() { NewMethod(nil) })
+}
@@ -0,0 +1,153 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lamb implements the LAMB (Layer-wise Adaptive Moments optimizer for
+// Batch training) gradient descent optimization method.
+//
+// Reference: "Large Batch Optimization for Deep Learning: Training BERT in 76 minutes"
+// by Yang You et al. (2020).
+// (https://arxiv.org/pdf/1904.00962.pdf)
+package lamb
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+)
+
+var _ gd.MethodConfig = &Config{}
+
+// Config provides configuration settings for a LAMB optimizer.
+type Config struct {
+	gd.MethodConfig
+	StepSize    mat.Float
+	Beta1       mat.Float
+	Beta2       mat.Float
+	Epsilon     mat.Float
+	WeightDecay mat.Float
+}
+
+// NewConfig returns a new LAMB Config.
+// It panics if beta1 or beta2 are not in the range [0.0, 1.0).
+func NewConfig(stepSize, beta1, beta2, epsilon, weightDecay mat.Float) Config {
+	if !(beta1 >= 0.0 && beta1 < 1.0) {
+		panic("lamb: `beta1` must be in the range [0.0, 1.0)")
+	}
+	if !(beta2 >= 0.0 && beta2 < 1.0) {
+		panic("lamb: `beta2` must be in the range [0.0, 1.0)")
+	}
+	return Config{
+		StepSize:    stepSize,
+		Beta1:       beta1,
+		Beta2:       beta2,
+		Epsilon:     epsilon,
+		WeightDecay: weightDecay,
+	}
+}
+
+// NewDefaultConfig returns a new Config with generically reasonable default values.
+func NewDefaultConfig() Config {
+	return Config{
+		StepSize:    0.001,
+		Beta1:       0.9,
+		Beta2:       0.999,
+		Epsilon:     1.0e-6,
+		WeightDecay: 0.01,
+	}
+}
+
+var _ gd.Method = &LAMB{}
+
+// LAMB implements the LAMB gradient descent optimization method.
+type LAMB struct {
+	Config
+	TimeStep int
+}
+
+// New returns a new LAMB optimizer, initialized according to the given configuration.
+func New(c Config) *LAMB {
+	return &LAMB{
+		Config:   c,
+		TimeStep: 1,
+	}
+}
+
+// Label returns the enumeration-like value which identifies this gradient descent method.
+func (o *LAMB) Label() int {
+	return gd.LAMB
+}
+
+const (
+	m    int = 0
+	v    int = 1
+	buf1 int = 2
+	buf2 int = 3
+	buf3 int = 4
+)
+
+// NewSupport returns a new support structure with the given dimensions.
+func (o *LAMB) NewSupport(r, c int) *nn.Payload {
+	supp := make([]mat.Matrix, 5)
+	supp[m] = mat.NewEmptyDense(r, c)
+	supp[v] = mat.NewEmptyDense(r, c)
+	supp[buf1] = mat.NewEmptyDense(r, c)
+	supp[buf2] = mat.NewEmptyDense(r, c)
+	supp[buf3] = mat.NewEmptyDense(r, c)
+	return &nn.Payload{
+		Label: o.Label(),
+		Data:  supp,
+	}
+}
+
+// IncBatch beats the occurrence of a new batch.
+func (o *LAMB) IncBatch() {
+	o.TimeStep++
+}
+
+// Delta returns the difference between the current params and where the method wants it to be.
+func (o *LAMB) Delta(param nn.Param) mat.Matrix {
+	return o.calcDelta(param.Value(), param.Grad(), gd.GetOrSetPayload(param, o).Data)
+}
+
+// ScaledDelta is like Delta, with the learning rate scaled by the given factor (see gd.ScalableMethod).
+func (o *LAMB) ScaledDelta(param nn.Param, lrFactor mat.Float) mat.Matrix {
+	return o.calcScaledDelta(param.Value(), param.Grad(), gd.GetOrSetPayload(param, o).Data, lrFactor)
+}
+
+func (o *LAMB) calcDelta(params, grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
+	return o.calcScaledDelta(params, grads, supp, 1.0)
+}
+
+// m = m*beta1 + grads*(1.0-beta1)
+// v = v*beta2 + (grads*grads)*(1.0-beta2)
+// r = (m / (1.0-beta1^t)) / (sqrt(v / (1.0-beta2^t)) + eps) + params * weightDecay
+// d = r * stepSize * (||params|| / ||r||)
+func (o *LAMB) calcScaledDelta(params, grads mat.Matrix, supp []mat.Matrix, lrFactor mat.Float) mat.Matrix {
+	gd.UpdateFirstMoment(supp[m], supp[buf1], grads, o.Beta1)
+	gd.UpdateSecondMoment(supp[v], supp[buf2], grads, o.Beta2)
+	timeStep := mat.Float(o.TimeStep)
+	buf := supp[v].ProdScalar(1.0 / (1.0 - mat.Pow(o.Beta2, timeStep)))
+	defer mat.ReleaseDense(buf.(*mat.Dense))
+	sqrtV := buf.Sqrt().AddScalarInPlace(o.Epsilon)
+	defer mat.ReleaseDense(sqrtV.(*mat.Dense))
+	supp[buf3].ProdMatrixScalarInPlace(supp[m], 1.0/(1.0-mat.Pow(o.Beta1, timeStep)))
+	supp[buf3].DivInPlace(sqrtV)
+	if o.WeightDecay != 0.0 {
+		supp[buf1].ProdMatrixScalarInPlace(params, o.WeightDecay)
+		supp[buf3].AddInPlace(supp[buf1])
+	}
+	supp[buf3].ProdScalarInPlace(o.StepSize * lrFactor * trustRatio(params, supp[buf3]))
+	return supp[buf3]
+}
+
+// trustRatio returns the ratio between the norm of the params and the norm of
+// the update, or 1 if either of them is zero.
+func trustRatio(params, update mat.Matrix) mat.Float {
+	paramsNorm := params.Norm(2.0)
+	updateNorm := update.Norm(2.0)
+	if paramsNorm == 0.0 || updateNorm == 0.0 {
+		return 1.0
+	}
+	return paramsNorm / updateNorm
+}
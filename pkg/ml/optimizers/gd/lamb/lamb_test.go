@@ -0,0 +1,55 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lamb
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Update(t *testing.T) {
+	updater := New(NewConfig(
+		0.001,  // step size
+		0.9,    // beta1
+		0.999,  // beta2
+		1.0e-6, // epsilon
+		0.0,    // weight decay
+	))
+
+	params := mat.NewVecDense([]mat.Float{3.0, 4.0})
+	grads := mat.NewVecDense([]mat.Float{0.5, -2.0})
+	supp := updater.NewSupport(params.Dims()).Data
+
+	// at the first step the bias-corrected update is sign(grads), rescaled by the trust ratio 5/sqrt(2)
+	assert.InDeltaSlice(t, []mat.Float{0.0035355, -0.0035355}, updater.calcDelta(params, grads, supp).Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.05, -0.2}, supp[m].Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.00025, 0.004}, supp[v].Data(), 1.0e-6)
+}
+
+func Test_UpdateWeightDecay(t *testing.T) {
+	updater := New(NewConfig(
+		0.001,  // step size
+		0.9,    // beta1
+		0.999,  // beta2
+		1.0e-6, // epsilon
+		0.1,    // weight decay
+	))
+
+	params := mat.NewVecDense([]mat.Float{3.0, 4.0})
+	grads := mat.NewVecDense([]mat.Float{0.5, -2.0})
+	supp := updater.NewSupport(params.Dims()).Data
+
+	// r = [1.3, -0.6], trust ratio = 5 / ||r||
+	assert.InDeltaSlice(t, []mat.Float{0.0045398, -0.0020953}, updater.calcDelta(params, grads, supp).Data(), 1.0e-6)
+}
+
+func Test_TrustRatio(t *testing.T) {
+	zeros := mat.NewEmptyVecDense(2)
+	update := mat.NewVecDense([]mat.Float{0.3, 0.4})
+	assert.Equal(t, mat.Float(1.0), trustRatio(zeros, update))
+	assert.Equal(t, mat.Float(1.0), trustRatio(update, zeros))
+	assert.InDelta(t, 10.0, trustRatio(mat.NewVecDense([]mat.Float{3.0, 4.0}), update), 1.0e-6)
+}
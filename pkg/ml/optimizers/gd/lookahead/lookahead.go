@@ -0,0 +1,197 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lookahead implements the Lookahead optimizer, which wraps any
+// gradient descent optimization method.
+//
+// The wrapped method updates the params ("fast weights") as usual; every k
+// steps, the "slow weights" are moved towards the fast weights, which are
+// then reset to the slow weights.
+//
+// Reference: "Lookahead Optimizer: k steps forward, 1 step back"
+// by Michael R. Zhang et al. (2019).
+// (https://arxiv.org/pdf/1907.08610.pdf)
+package lookahead
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+)
+
+var _ gd.MethodConfig = &Config{}
+
+// Config provides configuration settings for a Lookahead optimizer.
+type Config struct {
+	gd.MethodConfig
+	// Method is the configuration of the wrapped optimization method.
+	Method gd.MethodConfig
+	// K is the number of steps between two synchronizations of the slow weights.
+	K int
+	// Alpha is the step size of the slow weights.
+	Alpha mat.Float
+}
+
+// NewConfig returns a new Lookahead Config.
+// It panics if k is not positive or alpha is not in the range (0.0, 1.0].
+func NewConfig(method gd.MethodConfig, k int, alpha mat.Float) Config {
+	if k < 1 {
+		panic("lookahead: `k` must be greater than zero")
+	}
+	if !(alpha > 0.0 && alpha <= 1.0) {
+		panic("lookahead: `alpha` must be in the range (0.0, 1.0]")
+	}
+	return Config{
+		Method: method,
+		K:      k,
+		Alpha:  alpha,
+	}
+}
+
+// NewDefaultConfig returns a new Config with generically reasonable default
+// values, wrapping the given optimization method.
+func NewDefaultConfig(method gd.MethodConfig) Config {
+	return Config{
+		Method: method,
+		K:      5,
+		Alpha:  0.5,
+	}
+}
+
+var _ gd.Method = &Lookahead{}
+
+// Lookahead implements the Lookahead optimization method.
+type Lookahead struct {
+	Config
+	// Inner is the wrapped optimization method.
+	Inner    gd.Method
+	TimeStep int
+}
+
+// New returns a new Lookahead optimizer wrapping the given method, initialized
+// according to the given configuration. The configuration of the wrapped
+// method is ignored (see gdmbuilder.NewMethod).
+func New(c Config, method gd.Method) *Lookahead {
+	return &Lookahead{
+		Config:   c,
+		Inner:    method,
+		TimeStep: 1,
+	}
+}
+
+// Label returns the enumeration-like value which identifies this gradient descent method.
+func (o *Lookahead) Label() int {
+	return gd.Lookahead
+}
+
+// The support structure contains the slow weights and the delta, followed by
+// the support structure of the wrapped method.
+const (
+	slow  int = 0
+	delta int = 1
+	inner int = 2 // the support structure of the wrapped method
+)
+
+// NewSupport returns a new support structure with the given dimensions.
+// The slow weights are initialized with the params on their first update.
+func (o *Lookahead) NewSupport(r, c int) *nn.Payload {
+	supp := []mat.Matrix{mat.NewEmptyDense(r, c), mat.NewEmptyDense(r, c)}
+	return &nn.Payload{
+		Label: o.Label(),
+		Data:  append(supp, o.Inner.NewSupport(r, c).Data...),
+	}
+}
+
+// IncExample beats the occurrence of a new example.
+func (o *Lookahead) IncExample() {
+	if method, ok := o.Inner.(gd.ExampleScheduler); ok {
+		method.IncExample()
+	}
+}
+
+// IncBatch beats the occurrence of a new batch.
+func (o *Lookahead) IncBatch() {
+	o.TimeStep++
+	if method, ok := o.Inner.(gd.BatchScheduler); ok {
+		method.IncBatch()
+	}
+}
+
+// IncEpoch beats the occurrence of a new epoch.
+func (o *Lookahead) IncEpoch() {
+	if method, ok := o.Inner.(gd.EpochScheduler); ok {
+		method.IncEpoch()
+	}
+}
+
+// Delta returns the difference between the current params and where the method wants it to be.
+func (o *Lookahead) Delta(param nn.Param) mat.Matrix {
+	return o.ScaledDelta(param, 1.0)
+}
+
+// ScaledDelta is like Delta, with the learning rate of the wrapped method scaled
+// by the given factor (see gd.ScalableMethod). The synchronization of the slow
+// weights does not depend on the learning rate: the params are always reset
+// to the slow weights.
+func (o *Lookahead) ScaledDelta(param nn.Param, lrFactor mat.Float) mat.Matrix {
+	if payload := param.Payload(); payload == nil || payload.Label == gd.None {
+		param.SetPayload(o.NewSupport(param.Value().Dims()))
+		param.Payload().Data[slow].Copy(param.Value())
+	}
+	payload := gd.GetOrSetPayload(param, o)
+
+	// the wrapped method finds its own support structure in the payload of the param
+	param.SetPayload(&nn.Payload{Label: o.Inner.Label(), Data: payload.Data[inner:]})
+	innerDelta := gd.ScaledDelta(o.Inner, param, lrFactor)
+	param.SetPayload(payload)
+
+	return o.calcDelta(param.Value(), innerDelta, payload.Data)
+}
+
+// fast = params - innerDelta
+// slow = slow + (fast - slow) * alpha (every k steps)
+// d = params - slow (every k steps), innerDelta otherwise
+func (o *Lookahead) calcDelta(params, innerDelta mat.Matrix, supp []mat.Matrix) mat.Matrix {
+	if o.TimeStep%o.K != 0 {
+		return innerDelta
+	}
+	supp[delta].Copy(params)
+	supp[delta].SubInPlace(innerDelta) // fast weights
+	supp[delta].SubInPlace(supp[slow])
+	supp[delta].ProdScalarInPlace(o.Alpha)
+	supp[slow].AddInPlace(supp[delta])
+	supp[delta].Copy(params)
+	supp[delta].SubInPlace(supp[slow])
+	return supp[delta]
+}
+
+// GobEncode encodes the time step of the method, followed by the state of the
+// wrapped method (see gd.GradientDescent.MarshalBinary).
+func (o *Lookahead) GobEncode() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(o.TimeStep); err != nil {
+		return nil, err
+	}
+	if err := enc.Encode(o.Inner); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode restores the time step of the method and the state of the wrapped
+// method, which must be already set.
+func (o *Lookahead) GobDecode(data []byte) error {
+	if o.Inner == nil {
+		return errors.New("lookahead: the wrapped method must be set before decoding")
+	}
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&o.TimeStep); err != nil {
+		return err
+	}
+	return dec.Decode(o.Inner)
+}
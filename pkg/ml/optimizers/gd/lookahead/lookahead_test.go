@@ -0,0 +1,99 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lookahead
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adamw"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Delta(t *testing.T) {
+	config := NewConfig(sgd.NewConfig(0.1, 0.0, false), 2, 0.25)
+	updater := New(config, sgd.New(config.Method.(sgd.Config)))
+
+	param := nn.NewParam(mat.NewVecDense([]mat.Float{1.0, -2.0}))
+	step := func() {
+		param.PropagateGrad(mat.NewVecDense([]mat.Float{1.0, -1.0}))
+		param.ApplyDelta(updater.Delta(param))
+		param.ZeroGrad()
+		updater.IncBatch()
+	}
+
+	// fast weights update
+	step()
+	assert.InDeltaSlice(t, []mat.Float{0.9, -1.9}, param.Value().Data(), 1.0e-6)
+	assert.Equal(t, gd.Lookahead, param.Payload().Label)
+	assert.InDeltaSlice(t, []mat.Float{1.0, -2.0}, param.Payload().Data[slow].Data(), 1.0e-6)
+
+	// fast weights: [0.8, -1.8]; slow weights: [1.0, -2.0] + ([0.8, -1.8] - [1.0, -2.0]) * 0.25
+	step()
+	assert.InDeltaSlice(t, []mat.Float{0.95, -1.95}, param.Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.95, -1.95}, param.Payload().Data[slow].Data(), 1.0e-6)
+
+	step()
+	assert.InDeltaSlice(t, []mat.Float{0.85, -1.85}, param.Value().Data(), 1.0e-6)
+}
+
+type constantScheduler mat.Float
+
+func (s constantScheduler) LearningRateFactor() mat.Float { return mat.Float(s) }
+
+type testModel struct {
+	nn.BaseModel
+	W nn.Param `spago:"type:weights"`
+}
+
+func Test_ScheduledLearningRate(t *testing.T) {
+	config := NewConfig(sgd.NewConfig(0.1, 0.0, false), 2, 0.25)
+	model := &testModel{W: nn.NewParam(mat.NewVecDense([]mat.Float{1.0, -2.0}))}
+	optimizer := gd.NewOptimizer(New(config, sgd.New(config.Method.(sgd.Config))),
+		nn.NewDefaultParamsIterator(model), gd.ScheduleLearningRate(constantScheduler(0.5)),
+		gd.ConcurrentComputations(1))
+	step := func() {
+		model.W.PropagateGrad(mat.NewVecDense([]mat.Float{1.0, -1.0}))
+		optimizer.Optimize()
+		optimizer.IncBatch()
+	}
+
+	// the learning rate of the wrapped method is halved
+	step()
+	assert.InDeltaSlice(t, []mat.Float{0.95, -1.95}, model.W.Value().Data(), 1.0e-6)
+
+	// fast weights: [0.9, -1.9]; slow weights: [1.0, -2.0] + ([0.9, -1.9] - [1.0, -2.0]) * 0.25
+	// the params are reset to the slow weights, regardless of the learning rate
+	step()
+	assert.InDeltaSlice(t, []mat.Float{0.975, -1.975}, model.W.Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.975, -1.975}, model.W.Payload().Data[slow].Data(), 1.0e-6)
+}
+
+func Test_IncBatch(t *testing.T) {
+	inner := adamw.New(adamw.NewDefaultConfig())
+	updater := New(NewDefaultConfig(inner.Config), inner)
+	updater.IncBatch()
+	assert.Equal(t, 2, updater.TimeStep)
+	assert.Equal(t, 2, inner.TimeStep)
+}
+
+func Test_GobEncoding(t *testing.T) {
+	config := NewDefaultConfig(adamw.NewDefaultConfig())
+	updater := New(config, adamw.New(config.Method.(adamw.Config)))
+	updater.IncBatch()
+	updater.IncBatch()
+
+	data, err := updater.GobEncode()
+	assert.NoError(t, err)
+
+	restored := New(config, adamw.New(config.Method.(adamw.Config)))
+	assert.NoError(t, restored.GobDecode(data))
+	assert.Equal(t, 3, restored.TimeStep)
+	assert.Equal(t, 3, restored.Inner.(*adamw.AdamW).TimeStep)
+
+	assert.Error(t, (&Lookahead{}).GobDecode(data))
+}
@@ -0,0 +1,174 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lrscheduler provides learning rate schedulers, which plug into
+// gd.GradientDescent through the gd.ScheduleLearningRate option.
+//
+// The step-based schedulers advance at each batch (see gd.BatchScheduler),
+// and scale the learning rate of any optimization method by a factor that
+// usually grows linearly from 0 to 1 during the warmup steps, and then
+// decays until the total number of steps.
+package lrscheduler
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+)
+
+var (
+	_ gd.LearningRateScheduler = &Linear{}
+	_ gd.LearningRateScheduler = &Cosine{}
+	_ gd.LearningRateScheduler = &CosineWithRestarts{}
+	_ gd.LearningRateScheduler = &Polynomial{}
+	_ gd.BatchScheduler        = &Steps{}
+)
+
+// Steps counts the optimization steps of the step-based schedulers.
+type Steps struct {
+	Step int
+}
+
+// IncBatch beats the occurrence of a new batch.
+func (s *Steps) IncBatch() {
+	s.Step++
+}
+
+// Warmup defines the linear warmup of the learning rate, followed by its decay.
+type Warmup struct {
+	Steps
+	WarmupSteps int
+	TotalSteps  int
+}
+
+// newWarmup returns a new Warmup.
+// It panics if the number of warmup steps is negative or greater than the total steps.
+func newWarmup(warmupSteps, totalSteps int) Warmup {
+	if warmupSteps < 0 || warmupSteps > totalSteps {
+		panic("lrscheduler: the warmup steps must be in the range [0, total steps]")
+	}
+	return Warmup{
+		WarmupSteps: warmupSteps,
+		TotalSteps:  totalSteps,
+	}
+}
+
+// warmupFactor returns the factor of the learning rate during the warmup, and
+// whether the warmup is still in progress.
+func (w *Warmup) warmupFactor() (mat.Float, bool) {
+	if w.Step < w.WarmupSteps {
+		return mat.Float(w.Step) / mat.Float(w.WarmupSteps), true
+	}
+	return 1.0, false
+}
+
+// progress returns the progress of the decay after the warmup, in the range [0, 1].
+func (w *Warmup) progress() mat.Float {
+	decaySteps := w.TotalSteps - w.WarmupSteps
+	if decaySteps <= 0 {
+		return 1.0
+	}
+	p := mat.Float(w.Step-w.WarmupSteps) / mat.Float(decaySteps)
+	if p > 1.0 {
+		return 1.0
+	}
+	return p
+}
+
+// Linear defines a linear warmup, followed by a linear decay to 0.
+type Linear struct {
+	Warmup
+}
+
+// NewLinear returns a new Linear scheduler.
+func NewLinear(warmupSteps, totalSteps int) *Linear {
+	return &Linear{Warmup: newWarmup(warmupSteps, totalSteps)}
+}
+
+// LearningRateFactor returns the factor of the learning rate at the current step.
+func (s *Linear) LearningRateFactor() mat.Float {
+	if f, ok := s.warmupFactor(); ok {
+		return f
+	}
+	return 1.0 - s.progress()
+}
+
+// Cosine defines a linear warmup, followed by a decay along a cosine curve,
+// from 1 to 0 with the default half cycle.
+type Cosine struct {
+	Warmup
+	Cycles mat.Float
+}
+
+// NewCosine returns a new Cosine scheduler, with the given number of cosine
+// waves after the warmup (0.5 decreases from the max value to 0).
+func NewCosine(warmupSteps, totalSteps int, cycles mat.Float) *Cosine {
+	return &Cosine{Warmup: newWarmup(warmupSteps, totalSteps), Cycles: cycles}
+}
+
+// LearningRateFactor returns the factor of the learning rate at the current step.
+func (s *Cosine) LearningRateFactor() mat.Float {
+	if f, ok := s.warmupFactor(); ok {
+		return f
+	}
+	return mat.Max(0.0, 0.5*(1.0+mat.Cos(mat.Pi*s.Cycles*2.0*s.progress())))
+}
+
+// CosineWithRestarts defines a linear warmup, followed by several decays along
+// a cosine curve, from 1 to 0, with hard restarts.
+type CosineWithRestarts struct {
+	Warmup
+	Cycles int
+}
+
+// NewCosineWithRestarts returns a new CosineWithRestarts scheduler, with the given
+// number of hard restarts after the warmup.
+func NewCosineWithRestarts(warmupSteps, totalSteps int, cycles int) *CosineWithRestarts {
+	if cycles < 1 {
+		panic("lrscheduler: the cycles must be greater than zero")
+	}
+	return &CosineWithRestarts{Warmup: newWarmup(warmupSteps, totalSteps), Cycles: cycles}
+}
+
+// LearningRateFactor returns the factor of the learning rate at the current step.
+func (s *CosineWithRestarts) LearningRateFactor() mat.Float {
+	if f, ok := s.warmupFactor(); ok {
+		return f
+	}
+	p := s.progress()
+	if p >= 1.0 {
+		return 0.0
+	}
+	cycleProgress := mat.Float(s.Cycles) * p
+	cycleProgress -= mat.Floor(cycleProgress)
+	return 0.5 * (1.0 + mat.Cos(mat.Pi*cycleProgress))
+}
+
+// Polynomial defines a linear warmup, followed by a polynomial decay, from 1
+// to the final factor.
+type Polynomial struct {
+	Warmup
+	Power       mat.Float
+	FinalFactor mat.Float
+}
+
+// NewPolynomial returns a new Polynomial scheduler (the power 1 corresponds to
+// a linear decay).
+func NewPolynomial(warmupSteps, totalSteps int, power, finalFactor mat.Float) *Polynomial {
+	if !(finalFactor >= 0.0 && finalFactor <= 1.0) {
+		panic("lrscheduler: the final factor must be in the range [0.0, 1.0]")
+	}
+	return &Polynomial{
+		Warmup:      newWarmup(warmupSteps, totalSteps),
+		Power:       power,
+		FinalFactor: finalFactor,
+	}
+}
+
+// LearningRateFactor returns the factor of the learning rate at the current step.
+func (s *Polynomial) LearningRateFactor() mat.Float {
+	if f, ok := s.warmupFactor(); ok {
+		return f
+	}
+	return (1.0-s.FinalFactor)*mat.Pow(1.0-s.progress(), s.Power) + s.FinalFactor
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lrscheduler
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// factors returns the factors of the learning rate of the first n steps.
+func factors(s interface {
+	gd.LearningRateScheduler
+	gd.BatchScheduler
+}, n int) []mat.Float {
+	out := make([]mat.Float, n)
+	for i := range out {
+		out[i] = s.LearningRateFactor()
+		s.IncBatch()
+	}
+	return out
+}
+
+func TestLinear(t *testing.T) {
+	s := NewLinear(2, 6)
+	assert.InDeltaSlice(t, []mat.Float{0.0, 0.5, 1.0, 0.75, 0.5, 0.25, 0.0, 0.0}, factors(s, 8), 1.0e-6)
+}
+
+func TestCosine(t *testing.T) {
+	s := NewCosine(2, 6, 0.5)
+	assert.InDeltaSlice(t, []mat.Float{0.0, 0.5, 1.0, 0.853553, 0.5, 0.146447, 0.0, 0.0}, factors(s, 8), 1.0e-6)
+}
+
+func TestCosineWithRestarts(t *testing.T) {
+	s := NewCosineWithRestarts(1, 9, 2)
+	assert.InDeltaSlice(t, []mat.Float{0.0, 1.0, 0.853553, 0.5, 0.146447, 1.0, 0.853553, 0.5, 0.146447, 0.0}, factors(s, 10), 1.0e-6)
+}
+
+func TestPolynomial(t *testing.T) {
+	s := NewPolynomial(1, 5, 2.0, 0.2)
+	assert.InDeltaSlice(t, []mat.Float{0.0, 1.0, 0.65, 0.4, 0.25, 0.2, 0.2}, factors(s, 7), 1.0e-6)
+}
+
+func TestPolynomialPanics(t *testing.T) {
+	assert.Panics(t, func() { NewPolynomial(1, 5, 2.0, 1.5) })
+	assert.Panics(t, func() { NewPolynomial(6, 5, 2.0, 0.0) })
+}
+
+func TestOneCycle(t *testing.T) {
+	s := NewOneCycle(10, 0.2, 10.0, 100.0)
+	f := factors(s, 11)
+	assert.InDelta(t, 0.1, f[0], 1.0e-6)
+	assert.InDelta(t, 0.55, f[1], 1.0e-6)
+	assert.InDelta(t, 1.0, f[2], 1.0e-6)
+	assert.InDelta(t, 0.5005, f[6], 1.0e-6)
+	assert.InDelta(t, 0.001, f[10], 1.0e-6)
+	for i := 3; i < 11; i++ {
+		assert.Less(t, f[i], f[i-1])
+	}
+}
+
+func TestReduceOnPlateau(t *testing.T) {
+	s := NewReduceOnPlateau(Min, 0.5, 1, 0.0, 0.2)
+	metrics := []mat.Float{1.0, 0.8, 0.9, 0.85, 0.7, 0.75, 0.75, 0.8, 0.9, 0.9, 0.9}
+	expected := []mat.Float{1.0, 1.0, 1.0, 0.5, 0.5, 0.5, 0.25, 0.25, 0.2, 0.2, 0.2}
+	for i, metric := range metrics {
+		s.Report(metric)
+		s.IncEpoch()
+		assert.InDelta(t, expected[i], s.LearningRateFactor(), 1.0e-6, "epoch %d", i)
+	}
+}
+
+func TestReduceOnPlateauMax(t *testing.T) {
+	s := NewReduceOnPlateau(Max, 0.1, 0, 0.0, 0.0)
+	s.Report(0.5)
+	s.IncEpoch()
+	assert.Equal(t, mat.Float(1.0), s.LearningRateFactor())
+	s.IncEpoch() // no metric reported
+	assert.Equal(t, mat.Float(1.0), s.LearningRateFactor())
+	s.Report(0.4)
+	s.IncEpoch()
+	assert.InDelta(t, 0.1, s.LearningRateFactor(), 1.0e-6)
+}
+
+func TestGradientDescentWithScheduler(t *testing.T) {
+	param := nn.NewParam(mat.NewVecDense([]mat.Float{1.0, 2.0}))
+	model := &struct {
+		nn.BaseModel
+		W nn.Param `spago:"type:weights"`
+	}{W: param}
+
+	optimizer := gd.NewOptimizer(sgd.New(sgd.NewConfig(0.1, 0.0, false)), nn.NewDefaultParamsIterator(model),
+		gd.ScheduleLearningRate(NewLinear(1, 3)), gd.ConcurrentComputations(1))
+
+	for _, expected := range [][]mat.Float{{0.9, 1.9}, {0.85, 1.85}, {0.85, 1.85}} {
+		optimizer.IncBatch()
+		param.PropagateGrad(mat.NewVecDense([]mat.Float{1.0, 1.0}))
+		optimizer.Optimize()
+		assert.InDeltaSlice(t, expected, param.Value().Data(), 1.0e-6)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lrscheduler
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+)
+
+var _ gd.LearningRateScheduler = &OneCycle{}
+
+// OneCycle implements the 1cycle policy: the factor of the learning rate is
+// annealed along a cosine curve from 1/DivFactor up to 1, and then down to
+// 1/(DivFactor*FinalDivFactor).
+//
+// Reference: "Super-Convergence: Very Fast Training of Neural Networks Using Large Learning Rates"
+// by Leslie N. Smith and Nicholay Topin (2018).
+// (https://arxiv.org/pdf/1708.07120.pdf)
+type OneCycle struct {
+	Steps
+	TotalSteps int
+	// PctStart is the percentage of the steps spent increasing the learning rate.
+	PctStart mat.Float
+	// DivFactor determines the initial factor, as 1/DivFactor.
+	DivFactor mat.Float
+	// FinalDivFactor determines the final factor, as 1/(DivFactor*FinalDivFactor).
+	FinalDivFactor mat.Float
+}
+
+// NewOneCycle returns a new OneCycle scheduler.
+// It panics if pctStart is not in the range (0.0, 1.0).
+func NewOneCycle(totalSteps int, pctStart, divFactor, finalDivFactor mat.Float) *OneCycle {
+	if totalSteps < 1 {
+		panic("lrscheduler: the total steps must be greater than zero")
+	}
+	if !(pctStart > 0.0 && pctStart < 1.0) {
+		panic("lrscheduler: `pctStart` must be in the range (0.0, 1.0)")
+	}
+	return &OneCycle{
+		TotalSteps:     totalSteps,
+		PctStart:       pctStart,
+		DivFactor:      divFactor,
+		FinalDivFactor: finalDivFactor,
+	}
+}
+
+// NewDefaultOneCycle returns a new OneCycle scheduler with generically reasonable default values.
+func NewDefaultOneCycle(totalSteps int) *OneCycle {
+	return NewOneCycle(totalSteps, 0.3, 25.0, 1.0e4)
+}
+
+// LearningRateFactor returns the factor of the learning rate at the current step.
+func (s *OneCycle) LearningRateFactor() mat.Float {
+	initial := 1.0 / s.DivFactor
+	final := initial / s.FinalDivFactor
+	upSteps := s.PctStart * mat.Float(s.TotalSteps)
+	step := mat.Float(s.Step)
+	if step < upSteps {
+		return cosineAnnealing(initial, 1.0, step/upSteps)
+	}
+	p := (step - upSteps) / (mat.Float(s.TotalSteps) - upSteps)
+	if p > 1.0 {
+		p = 1.0
+	}
+	return cosineAnnealing(1.0, final, p)
+}
+
+// cosineAnnealing returns the value between start and end along a cosine curve,
+// at the given progress in the range [0, 1].
+func cosineAnnealing(start, end, progress mat.Float) mat.Float {
+	return end + (start-end)/2.0*(1.0+mat.Cos(mat.Pi*progress))
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lrscheduler
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+)
+
+var (
+	_ gd.LearningRateScheduler = &ReduceOnPlateau{}
+	_ gd.EpochScheduler        = &ReduceOnPlateau{}
+)
+
+// PlateauMode defines whether the metric monitored by ReduceOnPlateau is
+// expected to decrease (e.g. a loss) or to increase (e.g. an accuracy).
+type PlateauMode int
+
+const (
+	// Min reduces the learning rate when the metric has stopped decreasing.
+	Min PlateauMode = iota
+	// Max reduces the learning rate when the metric has stopped increasing.
+	Max
+)
+
+// ReduceOnPlateau reduces the learning rate when a validation metric has
+// stopped improving for a number of epochs.
+//
+// The metric is given with Report, and evaluated at the end of each epoch.
+type ReduceOnPlateau struct {
+	Mode PlateauMode
+	// Factor is multiplied by the current factor of the learning rate on each reduction.
+	Factor mat.Float
+	// Patience is the number of epochs without improvements after which the learning rate is reduced.
+	Patience int
+	// Threshold is the minimum relative change of the metric which counts as an improvement.
+	Threshold mat.Float
+	// MinFactor is the lower bound of the factor of the learning rate.
+	MinFactor mat.Float
+
+	CurrentFactor mat.Float
+	Best          mat.Float
+	BadEpochs     int
+	Metric        mat.Float
+	HasMetric     bool
+}
+
+// NewReduceOnPlateau returns a new ReduceOnPlateau scheduler.
+// It panics if factor is not in the range (0.0, 1.0).
+func NewReduceOnPlateau(mode PlateauMode, factor mat.Float, patience int, threshold, minFactor mat.Float) *ReduceOnPlateau {
+	if !(factor > 0.0 && factor < 1.0) {
+		panic("lrscheduler: `factor` must be in the range (0.0, 1.0)")
+	}
+	s := &ReduceOnPlateau{
+		Mode:          mode,
+		Factor:        factor,
+		Patience:      patience,
+		Threshold:     threshold,
+		MinFactor:     minFactor,
+		CurrentFactor: 1.0,
+	}
+	s.Best = s.worst()
+	return s
+}
+
+// NewDefaultReduceOnPlateau returns a new ReduceOnPlateau scheduler with generically
+// reasonable default values, monitoring a metric which is expected to decrease.
+func NewDefaultReduceOnPlateau() *ReduceOnPlateau {
+	return NewReduceOnPlateau(Min, 0.1, 10, 1.0e-4, 0.0)
+}
+
+// Report records the value of the validation metric of the current epoch.
+func (s *ReduceOnPlateau) Report(metric mat.Float) {
+	s.Metric = metric
+	s.HasMetric = true
+}
+
+// IncEpoch beats the occurrence of a new epoch, evaluating the last reported metric.
+// Epochs without a reported metric are ignored.
+func (s *ReduceOnPlateau) IncEpoch() {
+	if !s.HasMetric {
+		return
+	}
+	s.HasMetric = false
+	if s.isBetter(s.Metric) {
+		s.Best = s.Metric
+		s.BadEpochs = 0
+		return
+	}
+	s.BadEpochs++
+	if s.BadEpochs > s.Patience {
+		s.CurrentFactor = mat.Max(s.CurrentFactor*s.Factor, s.MinFactor)
+		s.BadEpochs = 0
+	}
+}
+
+// LearningRateFactor returns the current factor of the learning rate.
+func (s *ReduceOnPlateau) LearningRateFactor() mat.Float {
+	return s.CurrentFactor
+}
+
+func (s *ReduceOnPlateau) isBetter(metric mat.Float) bool {
+	if s.Mode == Max {
+		return metric > s.Best*(1.0+s.Threshold)
+	}
+	return metric < s.Best*(1.0-s.Threshold)
+}
+
+func (s *ReduceOnPlateau) worst() mat.Float {
+	if s.Mode == Max {
+		return mat.Inf(-1)
+	}
+	return mat.Inf(1)
+}
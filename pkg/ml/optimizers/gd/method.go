@@ -22,10 +22,18 @@ const (
 	RAdam
 	// RMSProp represents the RMSProp gradient descent optimization method.
 	RMSProp
+	// AdamW represents the AdamW gradient descent optimization method.
+	AdamW
+	// LAMB represents the LAMB gradient descent optimization method.
+	LAMB
+	// Adafactor represents the Adafactor gradient descent optimization method.
+	Adafactor
+	// Lookahead represents the Lookahead gradient descent optimization method.
+	Lookahead
 )
 
 // MethodConfig is an empty interface implemented by the configuration structures of
-// AdaGrad, Adam, RMSProp and SGD.
+// the gradient descent optimization methods (e.g. SGD, AdaGrad, Adam, RMSProp).
 type MethodConfig interface{}
 
 // Method is implemented by any optimization method.
@@ -38,6 +46,32 @@ type Method interface {
 	NewSupport(r, c int) *nn.Payload
 }
 
+// ScalableMethod is implemented by the optimization methods whose learning rate
+// can be scaled at each update of the params, as required by the learning rate
+// schedulers and by the parameter groups (see ScheduleLearningRate and ParamGroup).
+// The factor must be applied to the learning rate wherever it is used, including
+// the support structures (e.g. the velocity of SGD with momentum), so it is
+// generally not equivalent to scaling the delta.
+type ScalableMethod interface {
+	Method
+	// ScaledDelta is like Delta, with the learning rate scaled by the given factor.
+	ScaledDelta(param nn.Param, lrFactor mat.Float) mat.Matrix
+}
+
+// ScaledDelta returns the delta of the method for the param, with the learning
+// rate scaled by the given factor. It panics if the factor is not 1 and the
+// method does not implement ScalableMethod.
+func ScaledDelta(method Method, param nn.Param, lrFactor mat.Float) mat.Matrix {
+	if lrFactor == 1.0 {
+		return method.Delta(param)
+	}
+	scalable, ok := method.(ScalableMethod)
+	if !ok {
+		panic("gd: the optimization method does not support the scaling of the learning rate")
+	}
+	return scalable.ScaledDelta(param, lrFactor)
+}
+
 // GetOrSetPayload returns the payload from param, if it already exists, otherwise
 // a new payload is created, assigned to the param, and returned.
 func GetOrSetPayload(param nn.Param, m Method) *nn.Payload {
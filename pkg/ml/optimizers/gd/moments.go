@@ -0,0 +1,29 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gd
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+// UpdateFirstMoment updates in place the exponential moving average of the gradients
+// used by the Adam family of methods, using buf as temporary storage:
+// m = m*beta1 + grads*(1.0-beta1)
+func UpdateFirstMoment(m, buf, grads mat.Matrix, beta1 mat.Float) {
+	m.ProdScalarInPlace(beta1)
+	buf.ProdMatrixScalarInPlace(grads, 1.0-beta1)
+	m.AddInPlace(buf)
+}
+
+// UpdateSecondMoment updates in place the exponential moving average of the squared
+// gradients used by the Adam family of methods, using buf as temporary storage:
+// v = v*beta2 + (grads*grads)*(1.0-beta2)
+func UpdateSecondMoment(v, buf, grads mat.Matrix, beta2 mat.Float) {
+	v.ProdScalarInPlace(beta2)
+	sqGrad := grads.Prod(grads)
+	defer mat.ReleaseDense(sqGrad.(*mat.Dense))
+	buf.ProdMatrixScalarInPlace(sqGrad, 1.0-beta2)
+	v.AddInPlace(buf)
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gd
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"path"
+)
+
+// ParamSelector reports whether a param belongs to a ParamGroup.
+type ParamSelector func(param nn.Param) bool
+
+// SelectAll returns a ParamSelector which selects every param.
+func SelectAll() ParamSelector {
+	return func(nn.Param) bool {
+		return true
+	}
+}
+
+// SelectByName returns a ParamSelector which selects the params whose name
+// matches any of the given shell patterns (see path.Match).
+// It panics if a pattern is malformed.
+func SelectByName(patterns ...string) ParamSelector {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			panic("gd: malformed param name pattern")
+		}
+	}
+	return func(param nn.Param) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, param.Name()); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SelectByType returns a ParamSelector which selects the params of any of the given types.
+func SelectByType(types ...nn.ParamsType) ParamSelector {
+	return func(param nn.Param) bool {
+		for _, t := range types {
+			if param.Type() == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SelectByModel returns a ParamSelector which selects the params of the given
+// models, including the params of their sub-models.
+// The params are collected when the selector is created.
+func SelectByModel(models ...nn.Model) ParamSelector {
+	params := make(map[nn.Param]struct{})
+	for _, model := range models {
+		nn.ForEachParam(model, func(param nn.Param) {
+			params[param] = struct{}{}
+		})
+	}
+	return func(param nn.Param) bool {
+		_, ok := params[param]
+		return ok
+	}
+}
+
+// And returns a ParamSelector which selects the params selected by both s and other.
+func (s ParamSelector) And(other ParamSelector) ParamSelector {
+	return func(param nn.Param) bool {
+		return s(param) && other(param)
+	}
+}
+
+// Or returns a ParamSelector which selects the params selected by either s or other.
+func (s ParamSelector) Or(other ParamSelector) ParamSelector {
+	return func(param nn.Param) bool {
+		return s(param) || other(param)
+	}
+}
+
+// Not returns a ParamSelector which selects the params not selected by s.
+func (s ParamSelector) Not() ParamSelector {
+	return func(param nn.Param) bool {
+		return !s(param)
+	}
+}
+
+// ParamGroup defines a group of params which are optimized with their own
+// settings, instead of the ones of the GradientDescent (see WithParamGroups).
+type ParamGroup struct {
+	// Selector reports whether a param belongs to the group.
+	Selector ParamSelector
+	// Method is the optimization method of the group. If nil, the method of the GradientDescent is used.
+	Method Method
+	// LRScheduler schedules the learning rate of the group. If nil, the scheduler of the GradientDescent is used.
+	LRScheduler LearningRateScheduler
+	// LRFactor is a constant factor of the learning rate of the group (e.g. for a layer-wise decay).
+	// A factor other than 1 requires a method implementing ScalableMethod.
+	LRFactor mat.Float
+	// Frozen sets whether the params of the group are not optimized at all.
+	Frozen bool
+}
+
+// NewParamGroup returns a new ParamGroup, optimized with the given method.
+// A nil method stands for the method of the GradientDescent.
+func NewParamGroup(selector ParamSelector, method Method) ParamGroup {
+	return ParamGroup{
+		Selector: selector,
+		Method:   method,
+		LRFactor: 1.0,
+	}
+}
+
+// NewFrozenParamGroup returns a new ParamGroup whose params are not optimized:
+// their gradients are discarded at each optimization step.
+func NewFrozenParamGroup(selector ParamSelector) ParamGroup {
+	return ParamGroup{
+		Selector: selector,
+		LRFactor: 1.0,
+		Frozen:   true,
+	}
+}
+
+// WithParamGroups is an option to optimize the params of the given groups with
+// their own settings. Each param belongs to the first group that selects it;
+// the params which don't belong to any group are optimized with the method and
+// the learning rate scheduler of the GradientDescent.
+func WithParamGroups(groups ...ParamGroup) Option {
+	return func(f *GradientDescent) {
+		f.paramGroups = append(f.paramGroups, groups...)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gd
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// plainMethod is a gradient descent method without momentum, used for testing.
+type plainMethod struct {
+	lr      mat.Float
+	batches int
+}
+
+func (o *plainMethod) Label() int { return SGD }
+
+func (o *plainMethod) NewSupport(r, c int) *nn.Payload {
+	return &nn.Payload{Label: o.Label(), Data: []mat.Matrix{mat.NewEmptyDense(r, c)}}
+}
+
+func (o *plainMethod) Delta(param nn.Param) mat.Matrix {
+	return o.ScaledDelta(param, 1.0)
+}
+
+func (o *plainMethod) ScaledDelta(param nn.Param, lrFactor mat.Float) mat.Matrix {
+	supp := GetOrSetPayload(param, o).Data
+	supp[0].ProdMatrixScalarInPlace(param.Grad(), o.lr*lrFactor)
+	return supp[0]
+}
+
+func (o *plainMethod) IncBatch() { o.batches++ }
+
+type constantScheduler mat.Float
+
+func (s constantScheduler) LearningRateFactor() mat.Float { return mat.Float(s) }
+
+type groupsTestModel struct {
+	nn.BaseModel
+	W nn.Param `spago:"type:weights"`
+	B nn.Param `spago:"type:biases"`
+	E nn.Param `spago:"type:weights"`
+}
+
+func newGroupsTestModel() *groupsTestModel {
+	return &groupsTestModel{
+		W: nn.NewParam(mat.NewScalar(1.0)),
+		B: nn.NewParam(mat.NewScalar(1.0)),
+		E: nn.NewParam(mat.NewScalar(1.0)),
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	model := newGroupsTestModel()
+	nn.ForEachParam(model, func(nn.Param) {}) // assigns names and types
+
+	assert.True(t, SelectAll()(model.W))
+	assert.True(t, SelectByName("w", "e")(model.W))
+	assert.False(t, SelectByName("w", "e")(model.B))
+	assert.True(t, SelectByName("[a-c]")(model.B))
+	assert.True(t, SelectByType(nn.Biases)(model.B))
+	assert.False(t, SelectByType(nn.Biases)(model.W))
+	assert.True(t, SelectByModel(model)(model.E))
+	assert.False(t, SelectByModel(model)(nn.NewParam(mat.NewScalar(1.0))))
+	assert.True(t, SelectByType(nn.Weights).And(SelectByName("e"))(model.E))
+	assert.False(t, SelectByType(nn.Weights).And(SelectByName("e"))(model.W))
+	assert.True(t, SelectByType(nn.Biases).Or(SelectByName("e"))(model.E))
+	assert.True(t, SelectByType(nn.Biases).Not()(model.W))
+}
+
+func TestParamGroups(t *testing.T) {
+	model := newGroupsTestModel()
+	method := &plainMethod{lr: 0.1}
+	noDecay := &plainMethod{lr: 0.2}
+	optimizer := NewOptimizer(method, nn.NewDefaultParamsIterator(model),
+		ScheduleLearningRate(constantScheduler(0.5)),
+		WithParamGroups(
+			NewFrozenParamGroup(SelectByName("e")),
+			ParamGroup{Selector: SelectByType(nn.Biases), Method: noDecay, LRFactor: 2.0},
+		),
+	)
+
+	optimizer.IncBatch()
+	for _, p := range []nn.Param{model.W, model.B, model.E} {
+		p.PropagateGrad(mat.NewScalar(1.0))
+	}
+	optimizer.Optimize()
+
+	assert.InDelta(t, 0.95, model.W.ScalarValue(), 1.0e-6) // 1 - 0.1 * 0.5
+	assert.InDelta(t, 0.8, model.B.ScalarValue(), 1.0e-6)  // 1 - 0.2 * 0.5 * 2
+	assert.InDelta(t, 1.0, model.E.ScalarValue(), 1.0e-6)  // frozen
+	assert.False(t, model.E.HasGrad())
+	assert.Equal(t, 1, method.batches)
+	assert.Equal(t, 1, noDecay.batches)
+}
+
+func TestParamGroupsSharedMethod(t *testing.T) {
+	model := newGroupsTestModel()
+	method := &plainMethod{lr: 0.1}
+	optimizer := NewOptimizer(method, nn.NewDefaultParamsIterator(model),
+		WithParamGroups(
+			ParamGroup{Selector: SelectByName("w"), Method: method, LRFactor: 0.5},
+			NewParamGroup(SelectByName("b"), nil),
+		),
+	)
+
+	optimizer.IncBatch()
+	for _, p := range []nn.Param{model.W, model.B, model.E} {
+		p.PropagateGrad(mat.NewScalar(1.0))
+	}
+	optimizer.Optimize()
+
+	assert.InDelta(t, 0.95, model.W.ScalarValue(), 1.0e-6)
+	assert.InDelta(t, 0.9, model.B.ScalarValue(), 1.0e-6)
+	assert.InDelta(t, 0.9, model.E.ScalarValue(), 1.0e-6)
+	assert.Equal(t, 1, method.batches) // the shared method is incremented once
+}
+
+// unscalableMethod is a method whose learning rate can't be scaled.
+type unscalableMethod struct{}
+
+func (unscalableMethod) Label() int                      { return SGD }
+func (unscalableMethod) NewSupport(r, c int) *nn.Payload { return nil }
+func (unscalableMethod) Delta(param nn.Param) mat.Matrix { return param.Grad() }
+
+func TestUnscalableMethod(t *testing.T) {
+	model := newGroupsTestModel()
+	params := nn.NewDefaultParamsIterator(model)
+	assert.NotPanics(t, func() {
+		NewOptimizer(unscalableMethod{}, params, WithParamGroups(NewFrozenParamGroup(SelectByName("e"))))
+	})
+	assert.Panics(t, func() {
+		NewOptimizer(unscalableMethod{}, params, ScheduleLearningRate(constantScheduler(0.5)))
+	})
+	assert.Panics(t, func() {
+		NewOptimizer(&plainMethod{lr: 0.1}, params, WithParamGroups(ParamGroup{
+			Selector: SelectByName("b"),
+			Method:   unscalableMethod{},
+			LRFactor: 2.0,
+		}))
+	})
+}
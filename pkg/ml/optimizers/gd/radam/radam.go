@@ -104,11 +104,20 @@ func (o *RAdam) Delta(param nn.Param) mat.Matrix {
 	return o.calcDelta(param.Grad(), gd.GetOrSetPayload(param, o).Data)
 }
 
+// ScaledDelta is like Delta, with the learning rate scaled by the given factor (see gd.ScalableMethod).
+func (o *RAdam) ScaledDelta(param nn.Param, lrFactor mat.Float) mat.Matrix {
+	return o.calcScaledDelta(param.Grad(), gd.GetOrSetPayload(param, o).Data, lrFactor)
+}
+
 func (o *RAdam) calcDelta(grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
-	updateM(grads, supp, o.Beta1)
-	updateV(grads, supp, o.Beta2)
+	return o.calcScaledDelta(grads, supp, 1.0)
+}
+
+func (o *RAdam) calcScaledDelta(grads mat.Matrix, supp []mat.Matrix, lrFactor mat.Float) mat.Matrix {
+	gd.UpdateFirstMoment(supp[m], supp[buf1], grads, o.Beta1)
+	gd.UpdateSecondMoment(supp[v], supp[buf2], grads, o.Beta2)
 	sqrtB2T := mat.Sqrt(1.0 - mat.Pow(o.Beta2, mat.Float(o.TimeStep)))
-	alpha := o.calcAlpha()
+	alpha := o.calcAlpha() * lrFactor
 	buf := supp[v].Sqrt().AddScalarInPlace(o.Epsilon * sqrtB2T)
 	defer mat.ReleaseDense(buf.(*mat.Dense))
 	suppDiv := supp[m].Div(buf)
@@ -117,22 +126,6 @@ func (o *RAdam) calcDelta(grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
 	return supp[buf3]
 }
 
-// m = m*beta1 + grads*(1.0-beta1)
-func updateM(grads mat.Matrix, supp []mat.Matrix, beta1 mat.Float) {
-	supp[m].ProdScalarInPlace(beta1)
-	supp[buf1].ProdMatrixScalarInPlace(grads, 1.0-beta1)
-	supp[m].AddInPlace(supp[buf1])
-}
-
-// v = v*beta2 + (grads*grads)*(1.0-beta2)
-func updateV(grads mat.Matrix, supp []mat.Matrix, beta2 mat.Float) {
-	supp[v].ProdScalarInPlace(beta2)
-	sqGrad := grads.Prod(grads)
-	defer mat.ReleaseDense(sqGrad.(*mat.Dense))
-	supp[buf2].ProdMatrixScalarInPlace(sqGrad, 1.0-beta2)
-	supp[v].AddInPlace(supp[buf2])
-}
-
 func (o *RAdam) calcAlpha() mat.Float {
 	timeStep := mat.Float(o.TimeStep)
 	b1T := mat.Pow(o.Beta1, timeStep)
@@ -73,7 +73,16 @@ func (o *RMSProp) Delta(param nn.Param) mat.Matrix {
 	return o.calcDelta(param.Grad(), gd.GetOrSetPayload(param, o).Data)
 }
 
+// ScaledDelta is like Delta, with the learning rate scaled by the given factor (see gd.ScalableMethod).
+func (o *RMSProp) ScaledDelta(param nn.Param, lrFactor mat.Float) mat.Matrix {
+	return o.calcScaledDelta(param.Grad(), gd.GetOrSetPayload(param, o).Data, lrFactor)
+}
+
 func (o *RMSProp) calcDelta(grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
+	return o.calcScaledDelta(grads, supp, 1.0)
+}
+
+func (o *RMSProp) calcScaledDelta(grads mat.Matrix, supp []mat.Matrix, lrFactor mat.Float) mat.Matrix {
 	supp[v].ProdScalarInPlace(o.Decay)
 	buf := grads.Prod(grads)
 	buf.ProdScalarInPlace(1.0 - o.Decay)
@@ -81,6 +90,6 @@ func (o *RMSProp) calcDelta(grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
 	buf2 := mat.SqrtMatrix(supp[v])
 	buf2.AddScalarInPlace(o.Epsilon)
 	delta := grads.Div(buf2)
-	delta.ProdScalarInPlace(o.LR)
+	delta.ProdScalarInPlace(o.LR * lrFactor)
 	return delta
 }
@@ -4,6 +4,8 @@
 
 package gd
 
+import mat "github.com/nlpodyssey/spago/pkg/mat32"
+
 // EpochScheduler is implemented by any value that has the IncEpoch method.
 type EpochScheduler interface {
 	// IncEpoch beats the occurrence of a new epoch.
@@ -21,3 +23,12 @@ type ExampleScheduler interface {
 	// IncExample beats the occurrence of a new example.
 	IncExample()
 }
+
+// LearningRateScheduler is implemented by any value that schedules the learning
+// rate of an optimization method during the training, as a factor of the learning
+// rate of its configuration (see ScheduleLearningRate). The schedule usually
+// advances through the EpochScheduler, BatchScheduler or ExampleScheduler methods.
+type LearningRateScheduler interface {
+	// LearningRateFactor returns the factor of the learning rate at the current time.
+	LearningRateFactor() mat.Float
+}
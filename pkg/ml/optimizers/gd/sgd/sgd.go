@@ -88,30 +88,40 @@ func (o *SGD) Delta(param nn.Param) mat.Matrix {
 	return o.calcDelta(param.Grad(), gd.GetOrSetPayload(param, o).Data)
 }
 
+// ScaledDelta is like Delta, with the learning rate scaled by the given factor (see gd.ScalableMethod).
+func (o *SGD) ScaledDelta(param nn.Param, lrFactor mat.Float) mat.Matrix {
+	return o.calcScaledDelta(param.Grad(), gd.GetOrSetPayload(param, o).Data, lrFactor)
+}
+
 func (o *SGD) calcDelta(grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
+	return o.calcScaledDelta(grads, supp, 1.0)
+}
+
+func (o *SGD) calcScaledDelta(grads mat.Matrix, supp []mat.Matrix, lrFactor mat.Float) mat.Matrix {
+	alpha := o.Alpha * lrFactor
 	if o.Mu == 0.0 {
-		return o.calcVanillaSGD(grads, supp)
+		return o.calcVanillaSGD(grads, supp, alpha)
 	} else if o.Nesterov {
-		return o.calcNesterovMomentumDelta(grads, supp)
+		return o.calcNesterovMomentumDelta(grads, supp, alpha)
 	} else {
-		return o.calcMomentumDelta(grads, supp)
+		return o.calcMomentumDelta(grads, supp, alpha)
 	}
 }
 
-func (o *SGD) calcVanillaSGD(grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
-	supp[v].ProdMatrixScalarInPlace(grads, o.Alpha)
+func (o *SGD) calcVanillaSGD(grads mat.Matrix, supp []mat.Matrix, alpha mat.Float) mat.Matrix {
+	supp[v].ProdMatrixScalarInPlace(grads, alpha)
 	return supp[v]
 }
 
-func (o *SGD) calcMomentumDelta(grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
-	supp[buf].ProdMatrixScalarInPlace(grads, o.Alpha)
+func (o *SGD) calcMomentumDelta(grads mat.Matrix, supp []mat.Matrix, alpha mat.Float) mat.Matrix {
+	supp[buf].ProdMatrixScalarInPlace(grads, alpha)
 	supp[v].ProdScalarInPlace(o.Mu)
 	supp[v].AddInPlace(supp[buf])
 	return supp[v]
 }
 
-func (o *SGD) calcNesterovMomentumDelta(grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
-	supp[buf].ProdMatrixScalarInPlace(grads, o.Alpha)
+func (o *SGD) calcNesterovMomentumDelta(grads mat.Matrix, supp []mat.Matrix, alpha mat.Float) mat.Matrix {
+	supp[buf].ProdMatrixScalarInPlace(grads, alpha)
 	supp[vPrev].ProdMatrixScalarInPlace(supp[v], o.Mu)
 	supp[v].ProdScalarInPlace(o.Mu)
 	supp[v].AddInPlace(supp[buf]) // += grad * alpha
@@ -44,6 +44,25 @@ func TestSGDMomentum_Update(t *testing.T) {
 	assert.InDeltaSlice(t, []mat.Float{-0.2309, -0.3207, 0.0496, 0.7292, 0.6199}, params.Data(), 1.0e-6)
 }
 
+func TestSGDMomentum_ScaledUpdate(t *testing.T) {
+	updater := New(NewConfig(
+		0.001, // learning rate
+		0.9,   // momentum
+		false, // nesterov
+	))
+
+	params := mat.NewVecDense([]mat.Float{0.4, 0.4, 0.5, 1.0, 0.8})
+	grads := mat.NewVecDense([]mat.Float{0.9, 0.7, 0.4, 0.8, 0.1})
+
+	supp := updater.NewSupport(params.Dims()).Data
+	supp[v].SetData([]mat.Float{0.7, 0.8, 0.5, 0.3, 0.2})
+
+	// the learning rate is halved, not the velocity
+	params.SubInPlace(updater.calcScaledDelta(grads, supp, 0.5))
+
+	assert.InDeltaSlice(t, []mat.Float{-0.23045, -0.32035, 0.0498, 0.7296, 0.61995}, params.Data(), 1.0e-6)
+}
+
 func TestSGDMomentum_Update2(t *testing.T) {
 	updater := New(NewConfig(
 		0.001, // learning rate
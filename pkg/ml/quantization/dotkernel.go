@@ -0,0 +1,33 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quantization
+
+// dotBlockRows is the number of rows of the matrix processed together by dotsInt8x4.
+const dotBlockRows = 4
+
+// dotInt8 returns the dot product of two int8 vectors of the same length,
+// accumulated in int32.
+func dotInt8(a, b []int8) int32 {
+	b = b[:len(a)]
+	var s0, s1, s2, s3 int32
+	i := 0
+	for ; i <= len(a)-4; i += 4 {
+		s0 += int32(a[i]) * int32(b[i])
+		s1 += int32(a[i+1]) * int32(b[i+1])
+		s2 += int32(a[i+2]) * int32(b[i+2])
+		s3 += int32(a[i+3]) * int32(b[i+3])
+	}
+	for ; i < len(a); i++ {
+		s0 += int32(a[i]) * int32(b[i])
+	}
+	return s0 + s1 + s2 + s3
+}
+
+// dotsInt8x4Generic is the pure Go implementation of dotsInt8x4.
+func dotsInt8x4Generic(a []int8, lda int, b []int8, dst *[dotBlockRows]int32) {
+	for r := range dst {
+		dst[r] = dotInt8(a[r*lda:r*lda+len(b)], b)
+	}
+}
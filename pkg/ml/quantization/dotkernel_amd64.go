@@ -0,0 +1,36 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !noasm,!gccgo,!safe
+
+package quantization
+
+import "golang.org/x/sys/cpu"
+
+// useDotKernelAVX2 reports whether the CPU (and the OS) support the AVX2 instructions.
+var useDotKernelAVX2 = cpu.X86.HasAVX2
+
+// dotsInt8x4 computes the dot products of b and each of the dotBlockRows rows of a
+// with leading dimension lda, accumulated in int32. The values must be in the
+// symmetric range [-127, 127] produced by quantizeTo.
+func dotsInt8x4(a []int8, lda int, b []int8, dst *[dotBlockRows]int32) {
+	k := len(b) &^ 31 // the kernel processes 32 values at a time
+	if !useDotKernelAVX2 || k == 0 {
+		dotsInt8x4Generic(a, lda, b, dst)
+		return
+	}
+	_ = a[(dotBlockRows-1)*lda+len(b)-1]
+	dotsInt8x4AVX2(k, &a[0], lda, &b[0], dst)
+	if k == len(b) {
+		return
+	}
+	for r := range dst {
+		dst[r] += dotInt8(a[r*lda+k:r*lda+len(b)], b[k:])
+	}
+}
+
+// dotsInt8x4AVX2 is the AVX2 implementation of dotsInt8x4, for a length k
+// multiple of 32.
+//go:noescape
+func dotsInt8x4AVX2(k int, a *int8, lda int, b *int8, dst *[dotBlockRows]int32)
@@ -0,0 +1,75 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !noasm,!gccgo,!safe
+
+#include "textflag.h"
+
+// func dotsInt8x4AVX2(k int, a *int8, lda int, b *int8, dst *[4]int32)
+// The products of unsigned and signed bytes (VPMADDUBSW) are computed on |b|
+// and on the rows of a with the sign of b, then widened to int32 (VPMADDWD).
+// The four rows are accumulated in Y4-Y7.
+TEXT ·dotsInt8x4AVX2(SB), NOSPLIT, $0-40
+	MOVQ k+0(FP), CX
+	MOVQ a+8(FP), SI
+	MOVQ lda+16(FP), DX
+	MOVQ b+24(FP), DI
+	MOVQ dst+32(FP), R8
+
+	LEAQ (SI)(DX*1), R9
+	LEAQ (R9)(DX*1), R10
+	LEAQ (R10)(DX*1), R11
+
+	VPCMPEQW Y15, Y15, Y15
+	VPABSW   Y15, Y15      // sixteen int16 ones
+	VPXOR    Y4, Y4, Y4
+	VPXOR    Y5, Y5, Y5
+	VPXOR    Y6, Y6, Y6
+	VPXOR    Y7, Y7, Y7
+
+loop:
+	VMOVDQU    (DI), Y0
+	VPABSB     Y0, Y1
+
+	VMOVDQU    (SI), Y2
+	VPSIGNB    Y0, Y2, Y2
+	VPMADDUBSW Y2, Y1, Y2
+	VPMADDWD   Y15, Y2, Y2
+	VPADDD     Y2, Y4, Y4
+
+	VMOVDQU    (R9), Y3
+	VPSIGNB    Y0, Y3, Y3
+	VPMADDUBSW Y3, Y1, Y3
+	VPMADDWD   Y15, Y3, Y3
+	VPADDD     Y3, Y5, Y5
+
+	VMOVDQU    (R10), Y2
+	VPSIGNB    Y0, Y2, Y2
+	VPMADDUBSW Y2, Y1, Y2
+	VPMADDWD   Y15, Y2, Y2
+	VPADDD     Y2, Y6, Y6
+
+	VMOVDQU    (R11), Y3
+	VPSIGNB    Y0, Y3, Y3
+	VPMADDUBSW Y3, Y1, Y3
+	VPMADDWD   Y15, Y3, Y3
+	VPADDD     Y3, Y7, Y7
+
+	ADDQ       $32, DI
+	ADDQ       $32, SI
+	ADDQ       $32, R9
+	ADDQ       $32, R10
+	ADDQ       $32, R11
+	SUBQ       $32, CX
+	JNZ        loop
+
+	VPHADDD      Y5, Y4, Y4
+	VPHADDD      Y7, Y6, Y6
+	VPHADDD      Y6, Y4, Y4
+	VEXTRACTI128 $1, Y4, X5
+	VPADDD       X5, X4, X4
+	VMOVDQU      X4, (R8)
+
+	VZEROUPPER
+	RET
@@ -0,0 +1,14 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !amd64 noasm gccgo safe
+
+package quantization
+
+// dotsInt8x4 computes the dot products of b and each of the dotBlockRows rows of a
+// with leading dimension lda, accumulated in int32. The values must be in the
+// symmetric range [-127, 127] produced by quantizeTo.
+func dotsInt8x4(a []int8, lda int, b []int8, dst *[dotBlockRows]int32) {
+	dotsInt8x4Generic(a, lda, b, dst)
+}
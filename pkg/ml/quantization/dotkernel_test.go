@@ -0,0 +1,55 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quantization
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestDotInt8(t *testing.T) {
+	a := []int8{1, -2, 3, 127, -128, 5, 7}
+	b := []int8{4, 5, -6, 127, -128, 2, 1}
+	assert.Equal(t, int32(4-10-18+16129+16384+10+7), dotInt8(a, b))
+}
+
+func TestDotsInt8x4(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	randomInt8 := func(size int) []int8 {
+		s := make([]int8, size)
+		for i := range s {
+			s[i] = int8(rnd.Intn(2*maxInt8+1) - maxInt8)
+		}
+		return s
+	}
+	for _, k := range []int{0, 1, 31, 32, 33, 96, 257} {
+		lda := k + 3
+		a := randomInt8((dotBlockRows-1)*lda + k)
+		b := randomInt8(k)
+		var expected, actual [dotBlockRows]int32
+		dotsInt8x4Generic(a, lda, b, &expected)
+		dotsInt8x4(a, lda, b, &actual)
+		assert.Equal(t, expected, actual, "k=%d", k)
+	}
+
+	// the largest magnitudes don't saturate the int16 intermediate sums
+	a := make([]int8, dotBlockRows*64)
+	b := make([]int8, 64)
+	for i := range a {
+		a[i] = -maxInt8
+	}
+	for i := range b {
+		b[i] = int8(maxInt8 * (1 - 2*(i%2)))
+	}
+	var dots [dotBlockRows]int32
+	dotsInt8x4(a, 64, b, &dots)
+	assert.Equal(t, [dotBlockRows]int32{}, dots)
+	for i := range b {
+		b[i] = -maxInt8
+	}
+	dotsInt8x4(a, 64, b, &dots)
+	assert.Equal(t, [dotBlockRows]int32{64 * 127 * 127, 64 * 127 * 127, 64 * 127 * 127, 64 * 127 * 127}, dots)
+}
@@ -0,0 +1,230 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quantization
+
+import (
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"math"
+	"runtime"
+	"sync"
+)
+
+func init() {
+	gob.Register(&Matrix{})
+}
+
+// maxInt8 is the largest magnitude of the quantized values (the range is symmetric).
+const maxInt8 = 127
+
+// Matrix is a matrix quantized to int8 values, with one scale for each row
+// (i.e. for each output channel of a weights matrix):
+//  value(i, j) = Scales[i] * Data[i*Cols+j]
+type Matrix struct {
+	Rows   int
+	Cols   int
+	Data   []int8
+	Scales []mat.Float
+}
+
+// NewMatrix returns the symmetric per-row int8 quantization of m.
+func NewMatrix(m mat.Matrix) *Matrix {
+	rows, cols := m.Dims()
+	q := &Matrix{
+		Rows:   rows,
+		Cols:   cols,
+		Data:   make([]int8, rows*cols),
+		Scales: make([]mat.Float, rows),
+	}
+	data := m.Data()
+	for i := 0; i < rows; i++ {
+		q.Scales[i] = quantizeTo(q.Data[i*cols:(i+1)*cols], data[i*cols:(i+1)*cols])
+	}
+	return q
+}
+
+// Dims returns the number of rows and columns of the matrix.
+func (q *Matrix) Dims() (rows, cols int) {
+	return q.Rows, q.Cols
+}
+
+// Dequantize returns the values of the matrix as a new Dense matrix.
+func (q *Matrix) Dequantize() *mat.Dense {
+	out := mat.NewEmptyDense(q.Rows, q.Cols)
+	data := out.Data()
+	for i, scale := range q.Scales {
+		row := data[i*q.Cols : (i+1)*q.Cols]
+		for j, v := range q.Data[i*q.Cols : (i+1)*q.Cols] {
+			row[j] = scale * mat.Float(v)
+		}
+	}
+	return out
+}
+
+// Mul returns the product of the matrix and the dense matrix x.
+//
+// The columns of x are dynamically quantized to int8 too, so that the
+// products are accumulated in int32 and rescaled only once per element of
+// the result. The dot products are computed on blocks of rows by a SIMD
+// kernel (AVX2), where available. Large products are spread over up to
+// GOMAXPROCS goroutines.
+func (q *Matrix) Mul(x mat.Matrix) *mat.Dense {
+	if q.Cols != x.Rows() {
+		panic("quantization: matrices with not compatible size")
+	}
+	n := x.Columns()
+	xt := x.T()
+	if d, ok := xt.(*mat.Dense); ok {
+		defer mat.ReleaseDense(d)
+	}
+	xData := xt.Data()
+	qx := make([]int8, len(xData))
+	xScales := make([]mat.Float, n)
+	for j := 0; j < n; j++ {
+		xScales[j] = quantizeTo(qx[j*q.Cols:(j+1)*q.Cols], xData[j*q.Cols:(j+1)*q.Cols])
+	}
+
+	out := mat.NewEmptyDense(q.Rows, n)
+	outData := out.Data()
+	mulRows := func(start, end int) {
+		var dots [dotBlockRows]int32
+		i := start
+		// the rows of each block stay in cache while all the columns of x go through
+		for ; i+dotBlockRows <= end; i += dotBlockRows {
+			wi := q.Data[i*q.Cols : (i+dotBlockRows)*q.Cols]
+			for j := 0; j < n; j++ {
+				dotsInt8x4(wi, q.Cols, qx[j*q.Cols:(j+1)*q.Cols], &dots)
+				for r, acc := range dots {
+					outData[(i+r)*n+j] = q.Scales[i+r] * xScales[j] * mat.Float(acc)
+				}
+			}
+		}
+		for ; i < end; i++ {
+			wi := q.Data[i*q.Cols : (i+1)*q.Cols]
+			for j := 0; j < n; j++ {
+				acc := dotInt8(wi, qx[j*q.Cols:(j+1)*q.Cols])
+				outData[i*n+j] = q.Scales[i] * xScales[j] * mat.Float(acc)
+			}
+		}
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers == 1 || q.Rows*q.Cols*n < minParallelWork {
+		mulRows(0, q.Rows)
+		return out
+	}
+	chunk := (q.Rows + workers - 1) / workers
+	chunk = (chunk + dotBlockRows - 1) / dotBlockRows * dotBlockRows // whole blocks of rows
+	var wg sync.WaitGroup
+	for start := 0; start < q.Rows; start += chunk {
+		end := start + chunk
+		if end > q.Rows {
+			end = q.Rows
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			mulRows(start, end)
+		}(start, end)
+	}
+	wg.Wait()
+	return out
+}
+
+// MulT returns the product of the transpose of the matrix and the dense
+// matrix x. The matrix is dequantized row by row, without quantizing x; it is
+// meant for the backward pass.
+func (q *Matrix) MulT(x mat.Matrix) *mat.Dense {
+	if q.Rows != x.Rows() {
+		panic("quantization: matrices with not compatible size")
+	}
+	n := x.Columns()
+	xData := x.Data()
+	out := mat.NewEmptyDense(q.Cols, n)
+	outData := out.Data()
+	for i, scale := range q.Scales {
+		wi := q.Data[i*q.Cols : (i+1)*q.Cols]
+		for j := 0; j < n; j++ {
+			xv := scale * xData[i*n+j]
+			if xv == 0 {
+				continue
+			}
+			for k, w := range wi {
+				outData[k*n+j] += xv * mat.Float(w)
+			}
+		}
+	}
+	return out
+}
+
+// minParallelWork is the minimum number of multiply-add operations for which
+// Mul spreads the work over several goroutines.
+const minParallelWork = 1 << 16
+
+// quantizeTo quantizes the values into dst, and returns the scale.
+func quantizeTo(dst []int8, values []mat.Float) mat.Float {
+	var maxAbs mat.Float
+	for _, v := range values {
+		if v < 0 {
+			v = -v
+		}
+		if v > maxAbs {
+			maxAbs = v
+		}
+	}
+	if maxAbs == 0 {
+		for i := range dst {
+			dst[i] = 0
+		}
+		return 0
+	}
+	scale := maxAbs / maxInt8
+	for i, v := range values {
+		dst[i] = int8(math.Round(float64(v / scale)))
+	}
+	return scale
+}
+
+// MarshalBinary marshals the matrix into binary form: the number of rows and
+// columns, the scales (as float32) and the quantized values.
+func (q *Matrix) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 8+len(q.Scales)*4+len(q.Data))
+	binary.LittleEndian.PutUint32(data, uint32(q.Rows))
+	binary.LittleEndian.PutUint32(data[4:], uint32(q.Cols))
+	for i, s := range q.Scales {
+		binary.LittleEndian.PutUint32(data[8+i*4:], math.Float32bits(float32(s)))
+	}
+	offset := 8 + len(q.Scales)*4
+	for i, v := range q.Data {
+		data[offset+i] = byte(v)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary unmarshals a binary representation of the matrix.
+func (q *Matrix) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return fmt.Errorf("quantization: binary data too short")
+	}
+	rows := int(binary.LittleEndian.Uint32(data))
+	cols := int(binary.LittleEndian.Uint32(data[4:]))
+	offset := 8 + rows*4
+	if len(data) != offset+rows*cols {
+		return fmt.Errorf("quantization: expected %d bytes for a %dx%d matrix, found %d",
+			offset+rows*cols, rows, cols, len(data))
+	}
+	q.Rows, q.Cols = rows, cols
+	q.Scales = make([]mat.Float, rows)
+	for i := range q.Scales {
+		q.Scales[i] = mat.Float(math.Float32frombits(binary.LittleEndian.Uint32(data[8+i*4:])))
+	}
+	q.Data = make([]int8, rows*cols)
+	for i := range q.Data {
+		q.Data[i] = int8(data[offset+i])
+	}
+	return nil
+}
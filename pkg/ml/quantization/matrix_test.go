@@ -0,0 +1,109 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quantization
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"testing"
+)
+
+func randomDense(rnd *rand.Rand, rows, cols int) *mat.Dense {
+	data := make([]mat.Float, rows*cols)
+	for i := range data {
+		data[i] = mat.Float(rnd.Float64()*2 - 1)
+	}
+	return mat.NewDense(rows, cols, data)
+}
+
+func TestNewMatrix(t *testing.T) {
+	m := mat.NewDense(3, 4, []mat.Float{
+		0.1, -0.2, 0.3, 0.0,
+		1.27, 0.5, -0.6, 0.7,
+		0.0, 0.0, 0.0, 0.0,
+	})
+	q := NewMatrix(m)
+
+	assert.Equal(t, 3, q.Rows)
+	assert.Equal(t, 4, q.Cols)
+	assert.InDeltaSlice(t, []mat.Float{0.3 / 127, 0.01, 0}, q.Scales, 1.0e-6)
+	assert.Equal(t, []int8{42, -85, 127, 0, 127, 50, -60, 70, 0, 0, 0, 0}, q.Data)
+
+	d := q.Dequantize()
+	assert.Equal(t, 3, d.Rows())
+	assert.Equal(t, 4, d.Columns())
+	assert.InDeltaSlice(t, m.Data(), d.Data(), 0.002)
+}
+
+func TestMatrix_Mul(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 5} {
+		w := randomDense(rnd, 300, 257)
+		x := randomDense(rnd, 257, n)
+		expected := w.Mul(x)
+
+		actual := NewMatrix(w).Mul(x)
+
+		assert.Equal(t, 300, actual.Rows())
+		assert.Equal(t, n, actual.Columns())
+		assert.InDeltaSlice(t, expected.Data(), actual.Data(), 0.25)
+	}
+	assert.Panics(t, func() { NewMatrix(mat.NewEmptyDense(2, 3)).Mul(mat.NewEmptyVecDense(2)) })
+}
+
+func TestMatrix_MulT(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	w := randomDense(rnd, 7, 5)
+	x := randomDense(rnd, 7, 2)
+	q := NewMatrix(w)
+	expected := q.Dequantize().T().Mul(x)
+
+	actual := q.MulT(x)
+
+	assert.Equal(t, 5, actual.Rows())
+	assert.Equal(t, 2, actual.Columns())
+	assert.InDeltaSlice(t, expected.Data(), actual.Data(), 1.0e-5)
+}
+
+func TestMatrix_MarshalBinary(t *testing.T) {
+	q := NewMatrix(mat.NewDense(2, 3, []mat.Float{1, -2, 3, 0.5, 0.25, -1}))
+	data, err := q.MarshalBinary()
+	require.NoError(t, err)
+	assert.Len(t, data, 8+2*4+6)
+
+	decoded := new(Matrix)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, q, decoded)
+
+	assert.Error(t, decoded.UnmarshalBinary(data[:10]))
+}
+
+func BenchmarkMatrix_Mul(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, size := range []struct{ rows, cols, n int }{
+		{768, 768, 1},
+		{3072, 768, 1},
+		{768, 768, 32},
+		{3072, 768, 32},
+	} {
+		w := randomDense(rnd, size.rows, size.cols)
+		x := randomDense(rnd, size.cols, size.n)
+		q := NewMatrix(w)
+		name := fmt.Sprintf("%dx%dx%d", size.rows, size.cols, size.n)
+		b.Run("Dense-"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				mat.ReleaseDense(w.Mul(x).(*mat.Dense))
+			}
+		})
+		b.Run("Quantized-"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				q.Mul(x)
+			}
+		})
+	}
+}
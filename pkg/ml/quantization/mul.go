@@ -0,0 +1,80 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quantization
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+)
+
+var _ fn.Function = &mulFn{}
+
+// mulFn is an operator to perform the multiplication of a quantized matrix
+// and a node. The quantized matrix is a constant: the gradients are only
+// propagated to the node.
+type mulFn struct {
+	w *Matrix
+	x fn.Operand
+}
+
+// Mul returns a new operator node as the result of the multiplication of
+// the quantized matrix w and x.
+func Mul(g *ag.Graph, w *Matrix, x ag.Node) ag.Node {
+	return g.NewOperatorWithGrad(&mulFn{w: w, x: x}, func(_, gy ag.Node) []ag.Node {
+		return []ag.Node{mulT(g, w, gy)}
+	}, x)
+}
+
+// mulT returns a new operator node as the result of the multiplication of the
+// transpose of the quantized matrix w and x, which is the gradient of Mul.
+func mulT(g *ag.Graph, w *Matrix, x ag.Node) ag.Node {
+	return g.NewOperatorWithGrad(&mulTFn{w: w, x: x}, func(_, gy ag.Node) []ag.Node {
+		return []ag.Node{Mul(g, w, gy)}
+	}, x)
+}
+
+// Forward computes the output of the function.
+func (r *mulFn) Forward() mat.Matrix {
+	return r.w.Mul(r.x.Value())
+}
+
+// Backward computes the backward pass.
+func (r *mulFn) Backward(gy mat.Matrix) {
+	if !(r.w.Rows == gy.Rows() && r.x.Value().Columns() == gy.Columns()) {
+		panic("quantization: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := r.w.MulT(gy)
+		defer mat.ReleaseDense(gx)
+		r.x.PropagateGrad(gx)
+	}
+}
+
+var _ fn.Function = &mulTFn{}
+
+// mulTFn is an operator to perform the multiplication of the transpose of a
+// quantized matrix and a node (see mulFn).
+type mulTFn struct {
+	w *Matrix
+	x fn.Operand
+}
+
+// Forward computes the output of the function.
+func (r *mulTFn) Forward() mat.Matrix {
+	return r.w.MulT(r.x.Value())
+}
+
+// Backward computes the backward pass.
+func (r *mulTFn) Backward(gy mat.Matrix) {
+	if !(r.w.Cols == gy.Rows() && r.x.Value().Columns() == gy.Columns()) {
+		panic("quantization: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := r.w.Mul(gy)
+		defer mat.ReleaseDense(gx)
+		r.x.PropagateGrad(gx)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package quantization implements the int8 post-training quantization of
+// models, to reduce their memory footprint (about a quarter of the float32
+// weights) and to speed up the inference on CPU.
+//
+// The weights are quantized per output channel (one scale for each row), and
+// the activations are quantized dynamically, so that the matrix products are
+// computed on int8 values with int32 accumulators. On amd64 the products run
+// on an AVX2 kernel (detected at runtime), with a pure Go fallback.
+// A quantized model is meant for inference only.
+package quantization
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"reflect"
+)
+
+// Quantizable is implemented by the models which can replace their
+// parameters with quantized ones (e.g. linear.Model and embeddings.Model).
+type Quantizable interface {
+	// Quantize replaces the parameters of the model with their quantized
+	// version. It must be called on the model, not on a processor.
+	Quantize() error
+}
+
+// Quantize applies the post-training quantization to the model and to all
+// its sub-models (e.g. the layers of a stack, the encoders of a transformer)
+// which implement Quantizable. The other parameters (biases, normalization
+// parameters, etc.) are left untouched.
+func Quantize(m nn.Model) error {
+	return QuantizeSelected(m, nil)
+}
+
+// QuantizeSelected is like Quantize, but it only quantizes the sub-models for
+// which selected returns true (all of them if selected is nil). For example,
+// it allows to quantize the linear layers while keeping read-only embeddings.
+func QuantizeSelected(m nn.Model, selected func(q Quantizable) bool) error {
+	w := quantizer{visited: make(map[uintptr]bool), selected: selected}
+	return w.walk(reflect.ValueOf(m))
+}
+
+// quantizer visits a model looking for Quantizable sub-models.
+type quantizer struct {
+	visited  map[uintptr]bool
+	selected func(q Quantizable) bool
+}
+
+var contextType = reflect.TypeOf(nn.Context{})
+
+func (q quantizer) walk(v reflect.Value) error {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return q.walk(v.Elem())
+	case reflect.Ptr:
+		if v.IsNil() || q.visited[v.Pointer()] {
+			return nil
+		}
+		q.visited[v.Pointer()] = true
+		if qm, ok := v.Interface().(Quantizable); ok {
+			if q.selected != nil && !q.selected(qm) {
+				return nil
+			}
+			return qm.Quantize()
+		}
+		return q.walk(v.Elem())
+	case reflect.Struct:
+		if v.Type() == contextType {
+			return nil
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Field(i).CanInterface() {
+				continue // unexported
+			}
+			if err := q.walk(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := q.walk(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for it := v.MapRange(); it.Next(); {
+			if err := q.walk(it.Value()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quantization_test
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/nlpodyssey/spago/pkg/ml/quantization"
+	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func newTestStack(rnd *rand.Rand) *stack.Model {
+	l1, l2 := linear.New(16, 32), linear.New(32, 8)
+	for _, p := range []nn.Param{l1.W, l1.B, l2.W, l2.B} {
+		data := p.Value().Data()
+		for i := range data {
+			data[i] = mat.Float(rnd.Float64() - 0.5)
+		}
+	}
+	return stack.New(l1, activation.New(ag.OpTanh), l2)
+}
+
+func forward(m *stack.Model, x []mat.Float) []mat.Float {
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, m).(*stack.Model)
+	y := proc.Forward(g.NewVariable(mat.NewVecDense(x), false))
+	return y[0].Value().Data()
+}
+
+func TestQuantize(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	m := newTestStack(rnd)
+	x := make([]mat.Float, 16)
+	for i := range x {
+		x[i] = mat.Float(rnd.Float64()*2 - 1)
+	}
+	expected := forward(m, x)
+	bias := append([]mat.Float(nil), m.Layers[0].(*linear.Model).B.Value().Data()...)
+
+	require.NoError(t, quantization.Quantize(m))
+
+	for _, i := range []int{0, 2} {
+		layer := m.Layers[i].(*linear.Model)
+		assert.Nil(t, layer.W)
+		assert.NotNil(t, layer.QW)
+	}
+	assert.Equal(t, bias, m.Layers[0].(*linear.Model).B.Value().Data())
+	assert.InDeltaSlice(t, expected, forward(m, x), 0.05)
+
+	params := 0
+	nn.ForEachParam(m, func(param nn.Param) { params++ })
+	assert.Equal(t, 2, params) // the biases only
+}
+
+func TestQuantizeSelected(t *testing.T) {
+	m := newTestStack(rand.New(rand.NewSource(1)))
+	first := m.Layers[0].(*linear.Model)
+	require.NoError(t, quantization.QuantizeSelected(m, func(q quantization.Quantizable) bool {
+		return q == first
+	}))
+	assert.NotNil(t, first.QW)
+	assert.Nil(t, m.Layers[2].(*linear.Model).QW)
+	assert.NotNil(t, m.Layers[2].(*linear.Model).W)
+}
+
+func TestQuantizeLayers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-quantization-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := &testModel{
+		Stack:      newTestStack(rand.New(rand.NewSource(1))),
+		Embeddings: embeddings.New(embeddings.Config{Size: 4, DBPath: dir, ForceNewDB: true}),
+	}
+	defer m.Embeddings.Close()
+	require.NoError(t, linear.QuantizeLayers(m))
+
+	for _, i := range []int{0, 2} {
+		assert.NotNil(t, m.Stack.Layers[i].(*linear.Model).QW)
+	}
+	assert.False(t, m.Embeddings.Quantized)
+}
+
+type testModel struct {
+	nn.BaseModel
+	Stack      *stack.Model
+	Embeddings *embeddings.Model
+}
+
+func TestQuantize_Gradients(t *testing.T) {
+	m := linear.New(2, 2)
+	m.W.Value().SetData([]mat.Float{1, 2, 3, 4})
+	require.NoError(t, quantization.Quantize(m))
+
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m).(*linear.Model)
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1, 1}), true)
+	y := proc.Forward(x)[0]
+	g.Backward(g.ReduceSum(y))
+
+	assert.InDeltaSlice(t, []mat.Float{3, 7}, y.Value().Data(), 0.05)
+	assert.InDeltaSlice(t, []mat.Float{4, 6}, x.Grad().Data(), 0.05)
+
+	gxs, err := g.Gradients(g.ReduceSum(g.Square(y)), x)
+	require.NoError(t, err)
+	// 2 * w^T (w x + b)
+	assert.InDeltaSlice(t, []mat.Float{2 * (3 + 21), 2 * (6 + 28)}, gxs[0].Value().Data(), 0.5)
+
+	// the Hessian 2 w^T w times a vector of ones
+	hvs, err := g.Gradients(g.ReduceSum(gxs[0]), x)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []mat.Float{2 * (10 + 14), 2 * (14 + 20)}, hvs[0].Value().Data(), 0.5)
+}
+
+func TestQuantize_Embeddings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-quantization-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := embeddings.New(embeddings.Config{Size: 4, DBPath: dir, ForceNewDB: true})
+	defer m.Close()
+	m.SetEmbeddingFromData("foo", []mat.Float{0.1, -0.2, 0.3, 0.4})
+	m.SetEmbeddingFromData("bar", []mat.Float{1, 2, 3, 4})
+	assert.Equal(t, []mat.Float{1, 2, 3, 4}, m.GetStoredEmbedding("bar").Value().Data())
+
+	require.NoError(t, quantization.Quantize(m))
+
+	assert.True(t, m.Quantized)
+	assert.Equal(t, 2, m.Count())
+	foo := m.GetStoredEmbedding("foo")
+	_, cached := m.UsedEmbeddings.Load("foo")
+	assert.False(t, cached, "the quantized embeddings must not be cached")
+	assert.InDeltaSlice(t, []mat.Float{0.1, -0.2, 0.3, 0.4}, foo.Value().Data(), 0.002)
+	assert.False(t, foo.RequiresGrad())
+	assert.InDeltaSlice(t, []mat.Float{1, 2, 3, 4}, m.GetStoredEmbedding("bar").Value().Data(), 0.02)
+
+	m.SetEmbeddingFromData("baz", []mat.Float{0, 0, -1, 0.5})
+	assert.InDeltaSlice(t, []mat.Float{0, 0, -1, 0.5}, m.GetStoredEmbedding("baz").Value().Data(), 0.005)
+}
@@ -0,0 +1,280 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statedict
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/embeddings/syncmap"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rule maps the tensors whose name matches Source to the parameter at the
+// path given by Target.
+type Rule struct {
+	// Source is a regular expression matched against the whole tensor name.
+	Source *regexp.Regexp
+	// Target is the path of the parameter, which can refer to the submatches
+	// of Source (e.g. "$1" or "${name}"). An empty Target discards the tensor.
+	Target string
+	// Transform, if not nil, is applied to the tensor before the binding
+	// (e.g. Transpose).
+	Transform func(m mat.Matrix) mat.Matrix
+}
+
+// NewRule returns a new Rule. The source expression is implicitly anchored at
+// both ends. It panics if the source expression is not valid.
+func NewRule(source, target string) Rule {
+	return Rule{
+		Source: regexp.MustCompile("^(?:" + source + ")$"),
+		Target: target,
+	}
+}
+
+// WithTransform returns a copy of the rule applying the given transformation.
+func (r Rule) WithTransform(transform func(m mat.Matrix) mat.Matrix) Rule {
+	r.Transform = transform
+	return r
+}
+
+// Mapping is a list of rules. For each tensor, the first matching rule is
+// applied; a tensor not matched by any rule is bound to the parameter having
+// its same name, if any.
+type Mapping []Rule
+
+// target returns the parameter path for the tensor name, and the rule which produced it (if any).
+func (mp Mapping) target(name string) (string, *Rule) {
+	for i := range mp {
+		rule := &mp[i]
+		match := rule.Source.FindStringSubmatchIndex(name)
+		if match == nil {
+			continue
+		}
+		return string(rule.Source.ExpandString(nil, rule.Target, name, match)), rule
+	}
+	return name, nil
+}
+
+// Transpose returns the transpose of the matrix. It is meant to be used as
+// Rule.Transform, e.g. for the weights of Conv1D layers, which are stored as
+// (in, out) instead of (out, in).
+func Transpose(m mat.Matrix) mat.Matrix {
+	return m.T()
+}
+
+// BindReport describes the outcome of Bind.
+type BindReport struct {
+	// Bound maps each tensor name to the path of the parameter it has been bound to.
+	Bound map[string]string
+	// Unused are the (sorted) names of the tensors not bound to any parameter,
+	// excluding the ones discarded on purpose by a rule with an empty Target.
+	Unused []string
+	// Missing are the (sorted) paths of the parameters which have not been
+	// set by any tensor.
+	Missing []string
+}
+
+// Bind sets the values of the parameters of the model from the tensors of
+// the state dict, according to the mapping. The parameters are identified by
+// their path (see NamedParams).
+//
+// It returns an error if a tensor and its parameter have different shapes,
+// or if two tensors are bound to the same parameter; the tensors and the
+// parameters left out are listed in the report, for the caller to decide
+// whether they are acceptable.
+func Bind(m nn.Model, sd StateDict, mapping Mapping) (*BindReport, error) {
+	params := NamedParams(m)
+	report := &BindReport{
+		Bound:   make(map[string]string),
+		Unused:  make([]string, 0),
+		Missing: make([]string, 0),
+	}
+	boundParams := make(map[nn.Param]string)
+
+	for _, name := range sd.Names() {
+		path, rule := mapping.target(name)
+		if rule != nil && path == "" {
+			continue
+		}
+		param, ok := params[path]
+		if !ok {
+			report.Unused = append(report.Unused, name)
+			continue
+		}
+		if other, ok := boundParams[param]; ok {
+			return nil, fmt.Errorf("statedict: tensors %s and %s both bound to parameter %s", other, name, path)
+		}
+		value := sd[name]
+		if rule != nil && rule.Transform != nil {
+			value = rule.Transform(value)
+		}
+		pv := param.Value()
+		if value.Rows() != pv.Rows() || value.Columns() != pv.Columns() {
+			return nil, fmt.Errorf("statedict: tensor %s has shape (%d, %d), parameter %s has shape (%d, %d)",
+				name, value.Rows(), value.Columns(), path, pv.Rows(), pv.Columns())
+		}
+		pv.SetData(value.Data())
+		boundParams[param] = name
+		report.Bound[name] = path
+	}
+
+	for path, param := range params {
+		if _, ok := boundParams[param]; !ok {
+			report.Missing = append(report.Missing, path)
+		}
+	}
+	sort.Strings(report.Missing)
+	return report, nil
+}
+
+// NamedParams returns the parameters of the model (and of all its
+// sub-models) by path. A path joins with dots the lowercased names of the
+// struct fields, the indices of slices and arrays, and the keys of maps,
+// from the model down to the parameter (e.g. "layers.0.w"). The fields of
+// embedded structs are treated as fields of the embedding struct, and the
+// fields with the `spago:"scope:processor"` tag are ignored.
+//
+// A parameter shared by several fields is reported under each of their paths.
+func NamedParams(m nn.Model) map[string]nn.Param {
+	w := paramsWalker{
+		params:  make(map[string]nn.Param),
+		visited: make(map[uintptr]bool),
+	}
+	w.walk(reflect.ValueOf(m), "")
+	return w.params
+}
+
+// paramsWalker collects the parameters of a model by path.
+type paramsWalker struct {
+	params  map[string]nn.Param
+	visited map[uintptr]bool
+}
+
+var (
+	paramType   = reflect.TypeOf((*nn.Param)(nil)).Elem()
+	contextType = reflect.TypeOf(nn.Context{})
+	syncMapType = reflect.TypeOf(&syncmap.Map{})
+)
+
+func (w paramsWalker) walk(v reflect.Value, path string) {
+	if !v.IsValid() {
+		return
+	}
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Type().Implements(paramType) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return
+		}
+		w.params[path] = v.Interface().(nn.Param)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		if v.Type() == syncMapType {
+			w.walkSyncMap(v.Interface().(*syncmap.Map), path)
+			return
+		}
+		if w.visited[v.Pointer()] {
+			return
+		}
+		w.visited[v.Pointer()] = true
+		w.walk(v.Elem(), path)
+		delete(w.visited, v.Pointer())
+	case reflect.Struct:
+		w.walkStruct(v, path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			w.walk(v.Index(i), joinPath(path, strconv.Itoa(i)))
+		}
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+		for it := v.MapRange(); it.Next(); {
+			key, ok := mapKey(it.Key().Interface())
+			if !ok {
+				return // skip maps whose keys are not strings or integers
+			}
+			keys = append(keys, key)
+			values[key] = it.Value()
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			w.walk(values[key], joinPath(path, key))
+		}
+	}
+}
+
+func (w paramsWalker) walkStruct(v reflect.Value, path string) {
+	t := v.Type()
+	if t == contextType {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue // unexported
+		}
+		if strings.Contains(field.Tag.Get("spago"), "scope:processor") {
+			continue
+		}
+		if field.Anonymous {
+			w.walk(v.Field(i), path)
+			continue
+		}
+		w.walk(v.Field(i), joinPath(path, strings.ToLower(field.Name)))
+	}
+}
+
+func (w paramsWalker) walkSyncMap(m *syncmap.Map, path string) {
+	if m.Map == nil {
+		return
+	}
+	entries := make(map[string]interface{})
+	m.Range(func(key, value interface{}) bool {
+		if k, ok := mapKey(key); ok {
+			entries[k] = value
+		}
+		return true
+	})
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		w.walk(reflect.ValueOf(entries[key]), joinPath(path, key))
+	}
+}
+
+func mapKey(key interface{}) (string, bool) {
+	switch k := key.(type) {
+	case string:
+		return k, true
+	case int:
+		return strconv.Itoa(k), true
+	default:
+		return "", false
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
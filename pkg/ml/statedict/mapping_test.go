@@ -0,0 +1,108 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statedict
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testModel struct {
+	nn.BaseModel
+	Encoder *stack.Model
+	Head    *linear.Model
+	Extra   map[string]nn.Param `spago:"type:weights"`
+	Tied    nn.Param            `spago:"type:weights"`
+}
+
+func newTestModel() *testModel {
+	head := linear.New(3, 2)
+	return &testModel{
+		Encoder: stack.New(linear.New(2, 3), linear.New(3, 3)),
+		Head:    head,
+		Extra:   map[string]nn.Param{"scale": nn.NewParam(mat.NewScalar(0))},
+		Tied:    head.W,
+	}
+}
+
+func TestNamedParams(t *testing.T) {
+	params := NamedParams(newTestModel())
+	paths := make([]string, 0, len(params))
+	for path := range params {
+		paths = append(paths, path)
+	}
+	assert.ElementsMatch(t, []string{
+		"encoder.layers.0.w", "encoder.layers.0.b",
+		"encoder.layers.1.w", "encoder.layers.1.b",
+		"head.w", "head.b",
+		"extra.scale",
+		"tied",
+	}, paths)
+	assert.Same(t, params["head.w"], params["tied"])
+}
+
+func TestBind(t *testing.T) {
+	m := newTestModel()
+	sd := StateDict{
+		"encoder.layer.0.dense.weight": mat.NewDense(3, 2, []mat.Float{1, 2, 3, 4, 5, 6}),
+		"encoder.layer.0.dense.bias":   mat.NewVecDense([]mat.Float{1, 2, 3}),
+		"encoder.layer.1.dense.weight": mat.NewDense(3, 3, []mat.Float{1, 2, 3, 4, 5, 6, 7, 8, 9}),
+		"classifier.weight":            mat.NewDense(3, 2, []mat.Float{1, 2, 3, 4, 5, 6}),
+		"classifier.bias":              mat.NewVecDense([]mat.Float{7, 8}),
+		"extra.scale":                  mat.NewScalar(0.5),
+		"pooler.weight":                mat.NewDense(1, 1, []mat.Float{1}),
+		"position_ids":                 mat.NewVecDense([]mat.Float{0, 1}),
+	}
+	mapping := Mapping{
+		NewRule(`encoder\.layer\.(\d+)\.dense\.weight`, "encoder.layers.$1.w"),
+		NewRule(`encoder\.layer\.(\d+)\.dense\.bias`, "encoder.layers.$1.b"),
+		NewRule(`classifier\.weight`, "head.w").WithTransform(Transpose),
+		NewRule(`classifier\.bias`, "head.b"),
+		NewRule(`position_ids`, ""),
+	}
+
+	report, err := Bind(m, sd, mapping)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"encoder.layer.0.dense.weight": "encoder.layers.0.w",
+		"encoder.layer.0.dense.bias":   "encoder.layers.0.b",
+		"encoder.layer.1.dense.weight": "encoder.layers.1.w",
+		"classifier.weight":            "head.w",
+		"classifier.bias":              "head.b",
+		"extra.scale":                  "extra.scale",
+	}, report.Bound)
+	assert.Equal(t, []string{"pooler.weight"}, report.Unused)
+	assert.Equal(t, []string{"encoder.layers.1.b"}, report.Missing)
+
+	layer0 := m.Encoder.Layers[0].(*linear.Model)
+	assert.Equal(t, []mat.Float{1, 2, 3, 4, 5, 6}, layer0.W.Value().Data())
+	assert.Equal(t, []mat.Float{1, 2, 3}, layer0.B.Value().Data())
+	assert.Equal(t, []mat.Float{1, 3, 5, 2, 4, 6}, m.Head.W.Value().Data())
+	assert.Equal(t, []mat.Float{1, 3, 5, 2, 4, 6}, m.Tied.Value().Data())
+	assert.Equal(t, mat.Float(0.5), m.Extra["scale"].Value().Scalar())
+}
+
+func TestBindErrors(t *testing.T) {
+	t.Run("shape mismatch", func(t *testing.T) {
+		_, err := Bind(newTestModel(), StateDict{
+			"head.w": mat.NewDense(3, 2, []mat.Float{1, 2, 3, 4, 5, 6}),
+		}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("parameter bound twice", func(t *testing.T) {
+		_, err := Bind(newTestModel(), StateDict{
+			"head.w": mat.NewDense(2, 3, []mat.Float{1, 2, 3, 4, 5, 6}),
+			"tied":   mat.NewDense(2, 3, []mat.Float{1, 2, 3, 4, 5, 6}),
+		}, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,150 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statedict
+
+import (
+	"fmt"
+	"github.com/nlpodyssey/gopickle/pytorch"
+	"github.com/nlpodyssey/gopickle/types"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+// stateDictKey is the key under which training checkpoints usually nest the model's state dict.
+const stateDictKey = "state_dict"
+
+// LoadPyTorch reads a state dict saved with `torch.save`, either in the zip
+// or in the legacy format. If the file contains a dictionary with a
+// "state_dict" entry (as many training checkpoints do), that entry is read.
+// Any value which is not a tensor is ignored.
+func LoadPyTorch(filename string) (StateDict, error) {
+	result, err := pytorch.Load(filename)
+	if err != nil {
+		return nil, fmt.Errorf("statedict: loading PyTorch file %s: %w", filename, err)
+	}
+	sd, err := FromPyTorch(result)
+	if err != nil {
+		return nil, fmt.Errorf("%w (file %s)", err, filename)
+	}
+	return sd, nil
+}
+
+// FromPyTorch converts the object unpickled from a PyTorch file (a
+// *types.OrderedDict or *types.Dict of tensors) into a StateDict.
+func FromPyTorch(obj interface{}) (StateDict, error) {
+	entries, err := dictEntries(obj)
+	if err != nil {
+		return nil, err
+	}
+	if nested, ok := entries[stateDictKey]; ok {
+		if _, isTensor := nested.(*pytorch.Tensor); !isTensor {
+			return FromPyTorch(nested)
+		}
+	}
+	sd := make(StateDict, len(entries))
+	for name, value := range entries {
+		t, ok := value.(*pytorch.Tensor)
+		if !ok {
+			continue
+		}
+		data, err := TensorData(t)
+		if err != nil {
+			return nil, fmt.Errorf("statedict: tensor %s: %w", name, err)
+		}
+		sd[name] = newMatrix(t.Size, data)
+	}
+	return sd, nil
+}
+
+// dictEntries returns the string-keyed entries of a pickled dictionary.
+func dictEntries(obj interface{}) (map[string]interface{}, error) {
+	entries := make(map[string]interface{})
+	switch d := obj.(type) {
+	case *types.OrderedDict:
+		for key, entry := range d.Map {
+			if name, ok := key.(string); ok {
+				entries[name] = entry.Value
+			}
+		}
+	case *types.Dict:
+		for _, entry := range *d {
+			if name, ok := entry.Key.(string); ok {
+				entries[name] = entry.Value
+			}
+		}
+	default:
+		return nil, fmt.Errorf("statedict: expected a dictionary of tensors, found %T", obj)
+	}
+	return entries, nil
+}
+
+// TensorData returns the data of a PyTorch tensor of any shape and storage
+// type as a mat.Float slice, in row-major order. The strides are taken into
+// account, so that transposed or otherwise non-contiguous views are read
+// correctly.
+func TensorData(t *pytorch.Tensor) ([]mat.Float, error) {
+	at, length, err := storageAccessor(t.Source)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Stride) != len(t.Size) {
+		return nil, fmt.Errorf("mismatching size %v and stride %v", t.Size, t.Stride)
+	}
+	data := make([]mat.Float, numElements(t.Size))
+	if len(data) == 0 {
+		return data, nil
+	}
+	index := make([]int, len(t.Size))
+	for i := range data {
+		offset := t.StorageOffset
+		for d, k := range index {
+			offset += k * t.Stride[d]
+		}
+		if offset < 0 || offset >= length {
+			return nil, fmt.Errorf("storage offset %d out of bounds", offset)
+		}
+		data[i] = at(offset)
+		// increment the multi-dimensional index, last dimension first
+		for d := len(index) - 1; d >= 0; d-- {
+			index[d]++
+			if index[d] < t.Size[d] {
+				break
+			}
+			index[d] = 0
+		}
+	}
+	return data, nil
+}
+
+// storageAccessor returns a function reading the storage element at the
+// given position as a mat.Float, and the length of the storage.
+func storageAccessor(source pytorch.StorageInterface) (func(i int) mat.Float, int, error) {
+	switch s := source.(type) {
+	case *pytorch.HalfStorage:
+		return func(i int) mat.Float { return mat.Float(s.Data[i]) }, len(s.Data), nil
+	case *pytorch.FloatStorage:
+		return func(i int) mat.Float { return mat.Float(s.Data[i]) }, len(s.Data), nil
+	case *pytorch.DoubleStorage:
+		return func(i int) mat.Float { return mat.Float(s.Data[i]) }, len(s.Data), nil
+	case *pytorch.CharStorage:
+		return func(i int) mat.Float { return mat.Float(s.Data[i]) }, len(s.Data), nil
+	case *pytorch.ShortStorage:
+		return func(i int) mat.Float { return mat.Float(s.Data[i]) }, len(s.Data), nil
+	case *pytorch.IntStorage:
+		return func(i int) mat.Float { return mat.Float(s.Data[i]) }, len(s.Data), nil
+	case *pytorch.LongStorage:
+		return func(i int) mat.Float { return mat.Float(s.Data[i]) }, len(s.Data), nil
+	case *pytorch.ByteStorage:
+		return func(i int) mat.Float { return mat.Float(s.Data[i]) }, len(s.Data), nil
+	case *pytorch.BoolStorage:
+		return func(i int) mat.Float {
+			if s.Data[i] {
+				return 1
+			}
+			return 0
+		}, len(s.Data), nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported storage type %T", source)
+	}
+}
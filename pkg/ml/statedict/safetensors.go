@@ -0,0 +1,126 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statedict
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/nlpodyssey/gopickle/pytorch"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"io/ioutil"
+	"math"
+)
+
+// safetensorsMetadataKey is the header entry holding free-form metadata instead of a tensor.
+const safetensorsMetadataKey = "__metadata__"
+
+// safetensorsInfo describes a tensor in the header of a safetensors file.
+type safetensorsInfo struct {
+	DType       string `json:"dtype"`
+	Shape       []int  `json:"shape"`
+	DataOffsets [2]int `json:"data_offsets"`
+}
+
+// LoadSafetensors reads a state dict from a safetensors file.
+func LoadSafetensors(filename string) (StateDict, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("statedict: reading safetensors file %s: %w", filename, err)
+	}
+	sd, err := ReadSafetensors(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w (file %s)", err, filename)
+	}
+	return sd, nil
+}
+
+// ReadSafetensors decodes the content of a safetensors file: an 8-byte
+// little-endian header size, the JSON header describing each tensor, and the
+// tensors data.
+func ReadSafetensors(data []byte) (StateDict, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("statedict: safetensors data too short")
+	}
+	headerSize := binary.LittleEndian.Uint64(data[:8])
+	if headerSize > uint64(len(data)-8) {
+		return nil, fmt.Errorf("statedict: safetensors header size %d out of bounds", headerSize)
+	}
+	header := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data[8:8+headerSize], &header); err != nil {
+		return nil, fmt.Errorf("statedict: decoding safetensors header: %w", err)
+	}
+	buffer := data[8+headerSize:]
+
+	sd := make(StateDict, len(header))
+	for name, raw := range header {
+		if name == safetensorsMetadataKey {
+			continue
+		}
+		info := safetensorsInfo{}
+		if err := json.Unmarshal(raw, &info); err != nil {
+			return nil, fmt.Errorf("statedict: decoding safetensors info of %s: %w", name, err)
+		}
+		values, err := decodeSafetensorsData(buffer, info)
+		if err != nil {
+			return nil, fmt.Errorf("statedict: tensor %s: %w", name, err)
+		}
+		sd[name] = newMatrix(info.Shape, values)
+	}
+	return sd, nil
+}
+
+// decodeSafetensorsData converts the (little-endian) data of a tensor to mat.Float values.
+func decodeSafetensorsData(buffer []byte, info safetensorsInfo) ([]mat.Float, error) {
+	start, end := info.DataOffsets[0], info.DataOffsets[1]
+	if start < 0 || start > end || end > len(buffer) {
+		return nil, fmt.Errorf("data offsets [%d, %d] out of bounds", start, end)
+	}
+	raw := buffer[start:end]
+	size := numElements(info.Shape)
+
+	var decode func(b []byte) mat.Float
+	var width int
+	switch info.DType {
+	case "F64":
+		width, decode = 8, func(b []byte) mat.Float {
+			return mat.Float(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		}
+	case "F32":
+		width, decode = 4, func(b []byte) mat.Float {
+			return mat.Float(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		}
+	case "F16":
+		width, decode = 2, func(b []byte) mat.Float {
+			return mat.Float(math.Float32frombits(pytorch.FloatBits16to32(binary.LittleEndian.Uint16(b))))
+		}
+	case "BF16":
+		width, decode = 2, func(b []byte) mat.Float {
+			return mat.Float(math.Float32frombits(uint32(binary.LittleEndian.Uint16(b)) << 16))
+		}
+	case "I64":
+		width, decode = 8, func(b []byte) mat.Float { return mat.Float(int64(binary.LittleEndian.Uint64(b))) }
+	case "I32":
+		width, decode = 4, func(b []byte) mat.Float { return mat.Float(int32(binary.LittleEndian.Uint32(b))) }
+	case "I16":
+		width, decode = 2, func(b []byte) mat.Float { return mat.Float(int16(binary.LittleEndian.Uint16(b))) }
+	case "I8":
+		width, decode = 1, func(b []byte) mat.Float { return mat.Float(int8(b[0])) }
+	case "U8", "BOOL":
+		width, decode = 1, func(b []byte) mat.Float { return mat.Float(b[0]) }
+	default:
+		return nil, fmt.Errorf("unsupported dtype `%s`", info.DType)
+	}
+
+	if len(raw) != size*width {
+		return nil, fmt.Errorf("expected %d bytes for shape %v and dtype %s, found %d",
+			size*width, info.Shape, info.DType, len(raw))
+	}
+	values := make([]mat.Float, size)
+	for i := range values {
+		values[i] = decode(raw[i*width:])
+	}
+	return values, nil
+}
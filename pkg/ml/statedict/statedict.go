@@ -0,0 +1,72 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package statedict reads the parameters of pre-trained models saved with
+// PyTorch (`torch.save` of a state dict) or in the safetensors format, and
+// binds them to the parameters of any nn.Model through declarative
+// name-mapping rules.
+package statedict
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// StateDict maps the names of the tensors to their values.
+//
+// The tensors are converted to matrices as follows: a scalar becomes a 1x1
+// matrix, a one-dimensional tensor a column vector, a two-dimensional tensor
+// a (rows, columns) matrix, and a tensor with more dimensions a matrix with
+// the size of the first dimension as rows and all the others flattened
+// (row-major) into the columns.
+type StateDict map[string]mat.Matrix
+
+// Load reads a state dict from file, choosing the format from the file
+// extension: safetensors for ".safetensors", PyTorch otherwise.
+func Load(filename string) (StateDict, error) {
+	if strings.EqualFold(filepath.Ext(filename), ".safetensors") {
+		return LoadSafetensors(filename)
+	}
+	return LoadPyTorch(filename)
+}
+
+// Names returns the sorted names of the tensors.
+func (sd StateDict) Names() []string {
+	names := make([]string, 0, len(sd))
+	for name := range sd {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newMatrix builds the matrix for a tensor of the given shape, whose data are in row-major order.
+func newMatrix(shape []int, data []mat.Float) mat.Matrix {
+	switch len(shape) {
+	case 0:
+		return mat.NewScalar(data[0])
+	case 1:
+		return mat.NewVecDense(data)
+	default:
+		return mat.NewDense(shape[0], len(data)/max(shape[0], 1), data)
+	}
+}
+
+// numElements returns the number of elements of a tensor of the given shape.
+func numElements(shape []int) int {
+	n := 1
+	for _, size := range shape {
+		n *= size
+	}
+	return n
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
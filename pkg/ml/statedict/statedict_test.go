@@ -0,0 +1,190 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statedict
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/nlpodyssey/gopickle/pytorch"
+	"github.com/nlpodyssey/gopickle/types"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type safetensorsEntry struct {
+	name  string
+	dtype string
+	shape []int
+	data  []byte
+}
+
+func buildSafetensors(t *testing.T, entries ...safetensorsEntry) []byte {
+	t.Helper()
+	header := map[string]interface{}{
+		"__metadata__": map[string]string{"format": "pt"},
+	}
+	var buffer bytes.Buffer
+	for _, e := range entries {
+		start := buffer.Len()
+		buffer.Write(e.data)
+		header[e.name] = map[string]interface{}{
+			"dtype":        e.dtype,
+			"shape":        e.shape,
+			"data_offsets": []int{start, buffer.Len()},
+		}
+	}
+	jsonHeader, err := json.Marshal(header)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, binary.Write(&out, binary.LittleEndian, uint64(len(jsonHeader))))
+	out.Write(jsonHeader)
+	out.Write(buffer.Bytes())
+	return out.Bytes()
+}
+
+func float32Bytes(values ...float32) []byte {
+	var b bytes.Buffer
+	for _, v := range values {
+		_ = binary.Write(&b, binary.LittleEndian, math.Float32bits(v))
+	}
+	return b.Bytes()
+}
+
+func TestReadSafetensors(t *testing.T) {
+	data := buildSafetensors(t,
+		safetensorsEntry{name: "w", dtype: "F32", shape: []int{2, 3}, data: float32Bytes(1, 2, 3, 4, 5, 6)},
+		safetensorsEntry{name: "b", dtype: "F32", shape: []int{2}, data: float32Bytes(7, 8)},
+		safetensorsEntry{name: "scale", dtype: "F64", shape: []int{}, data: func() []byte {
+			b := make([]byte, 8)
+			binary.LittleEndian.PutUint64(b, math.Float64bits(0.5))
+			return b
+		}()},
+		safetensorsEntry{name: "half", dtype: "F16", shape: []int{2}, data: []byte{0x00, 0x3c, 0x00, 0xc0}},
+		safetensorsEntry{name: "bf16", dtype: "BF16", shape: []int{1}, data: []byte{0x80, 0x3f}},
+		safetensorsEntry{name: "ids", dtype: "I64", shape: []int{2}, data: []byte{
+			3, 0, 0, 0, 0, 0, 0, 0,
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		}},
+		safetensorsEntry{name: "conv", dtype: "U8", shape: []int{2, 2, 2}, data: []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+	)
+
+	sd, err := ReadSafetensors(data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "bf16", "conv", "half", "ids", "scale", "w"}, sd.Names())
+
+	assert.Equal(t, 2, sd["w"].Rows())
+	assert.Equal(t, 3, sd["w"].Columns())
+	assert.Equal(t, []mat.Float{1, 2, 3, 4, 5, 6}, sd["w"].Data())
+	assert.True(t, sd["b"].IsVector())
+	assert.Equal(t, []mat.Float{7, 8}, sd["b"].Data())
+	assert.True(t, sd["scale"].IsScalar())
+	assert.Equal(t, mat.Float(0.5), sd["scale"].Scalar())
+	assert.Equal(t, []mat.Float{1, -2}, sd["half"].Data())
+	assert.Equal(t, []mat.Float{1}, sd["bf16"].Data())
+	assert.Equal(t, []mat.Float{3, -1}, sd["ids"].Data())
+	assert.Equal(t, 2, sd["conv"].Rows())
+	assert.Equal(t, 4, sd["conv"].Columns())
+}
+
+func TestReadSafetensorsErrors(t *testing.T) {
+	_, err := ReadSafetensors([]byte{1, 2})
+	assert.Error(t, err)
+
+	_, err = ReadSafetensors(buildSafetensors(t,
+		safetensorsEntry{name: "w", dtype: "F32", shape: []int{2, 2}, data: float32Bytes(1, 2, 3)}))
+	assert.Error(t, err)
+
+	_, err = ReadSafetensors(buildSafetensors(t,
+		safetensorsEntry{name: "w", dtype: "C64", shape: []int{1}, data: float32Bytes(1, 2)}))
+	assert.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-statedict-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "model.safetensors")
+	data := buildSafetensors(t,
+		safetensorsEntry{name: "w", dtype: "F32", shape: []int{1, 2}, data: float32Bytes(1, 2)})
+	require.NoError(t, ioutil.WriteFile(filename, data, 0644))
+
+	sd, err := Load(filename)
+	require.NoError(t, err)
+	assert.Equal(t, []mat.Float{1, 2}, sd["w"].Data())
+
+	_, err = Load(filepath.Join(dir, "missing.bin"))
+	assert.Error(t, err)
+}
+
+func TestTensorData(t *testing.T) {
+	storage := &pytorch.FloatStorage{Data: []float32{0, 1, 2, 3, 4, 5, 6}}
+
+	t.Run("contiguous", func(t *testing.T) {
+		data, err := TensorData(&pytorch.Tensor{Source: storage, StorageOffset: 1, Size: []int{2, 3}, Stride: []int{3, 1}})
+		require.NoError(t, err)
+		assert.Equal(t, []mat.Float{1, 2, 3, 4, 5, 6}, data)
+	})
+
+	t.Run("transposed", func(t *testing.T) {
+		data, err := TensorData(&pytorch.Tensor{Source: storage, StorageOffset: 1, Size: []int{3, 2}, Stride: []int{1, 3}})
+		require.NoError(t, err)
+		assert.Equal(t, []mat.Float{1, 4, 2, 5, 3, 6}, data)
+	})
+
+	t.Run("three dimensions", func(t *testing.T) {
+		data, err := TensorData(&pytorch.Tensor{Source: storage, Size: []int{2, 1, 3}, Stride: []int{3, 3, 1}})
+		require.NoError(t, err)
+		assert.Equal(t, []mat.Float{0, 1, 2, 3, 4, 5}, data)
+	})
+
+	t.Run("integer storage", func(t *testing.T) {
+		data, err := TensorData(&pytorch.Tensor{
+			Source: &pytorch.LongStorage{Data: []int64{-1, 2}}, Size: []int{2}, Stride: []int{1}})
+		require.NoError(t, err)
+		assert.Equal(t, []mat.Float{-1, 2}, data)
+	})
+
+	t.Run("out of bounds", func(t *testing.T) {
+		_, err := TensorData(&pytorch.Tensor{Source: storage, StorageOffset: 5, Size: []int{3}, Stride: []int{1}})
+		assert.Error(t, err)
+	})
+}
+
+func TestFromPyTorch(t *testing.T) {
+	storage := &pytorch.FloatStorage{Data: []float32{1, 2, 3, 4}}
+	stateDict := types.NewOrderedDict()
+	stateDict.Set("linear.weight", &pytorch.Tensor{Source: storage, Size: []int{2, 2}, Stride: []int{1, 2}})
+	stateDict.Set("linear.bias", &pytorch.Tensor{Source: storage, StorageOffset: 2, Size: []int{2}, Stride: []int{1}})
+
+	t.Run("state dict", func(t *testing.T) {
+		sd, err := FromPyTorch(stateDict)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"linear.bias", "linear.weight"}, sd.Names())
+		assert.Equal(t, []mat.Float{1, 3, 2, 4}, sd["linear.weight"].Data())
+		assert.Equal(t, []mat.Float{3, 4}, sd["linear.bias"].Data())
+	})
+
+	t.Run("checkpoint", func(t *testing.T) {
+		checkpoint := types.NewDict()
+		checkpoint.Set("epoch", 3)
+		checkpoint.Set("state_dict", stateDict)
+		sd, err := FromPyTorch(checkpoint)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"linear.bias", "linear.weight"}, sd.Names())
+	})
+
+	t.Run("not a dictionary", func(t *testing.T) {
+		_, err := FromPyTorch(42)
+		assert.Error(t, err)
+	})
+}
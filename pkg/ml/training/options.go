@@ -0,0 +1,119 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package training
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/checkpoint"
+)
+
+// Option allows to configure a new Trainer with your specific needs.
+type Option func(*Trainer)
+
+// Epochs sets the number of epochs of the training. The default is 1.
+func Epochs(value int) Option {
+	if value < 1 {
+		panic("training: the number of epochs must be greater than zero")
+	}
+	return func(t *Trainer) {
+		t.epochs = value
+	}
+}
+
+// BatchSize sets the number of examples of each mini-batch. The default is 1.
+func BatchSize(value int) Option {
+	if value < 1 {
+		panic("training: the batch size must be greater than zero")
+	}
+	return func(t *Trainer) {
+		t.batchSize = value
+	}
+}
+
+// GradientAccumulation sets the number of mini-batches whose gradients are
+// accumulated before each optimization step. The loss of each mini-batch is
+// scaled accordingly, so that the gradients are averaged; the last step of an
+// epoch may accumulate fewer mini-batches. The default is 1.
+func GradientAccumulation(steps int) Option {
+	if steps < 1 {
+		panic("training: the gradient accumulation steps must be greater than zero")
+	}
+	return func(t *Trainer) {
+		t.accumulationSteps = steps
+	}
+}
+
+// Shuffle is an option to shuffle the examples at each epoch, with the given generator.
+func Shuffle(generator *rand.LockedRand) Option {
+	return func(t *Trainer) {
+		t.rand = generator
+	}
+}
+
+// GraphOptions sets the options of the graphs used to compute the losses.
+func GraphOptions(opts ...ag.GraphOption) Option {
+	return func(t *Trainer) {
+		t.graphOptions = opts
+	}
+}
+
+// Validation is an option to validate the model at the end of each epoch.
+// If maximize is true, a greater metric is better (e.g. an accuracy);
+// otherwise a lower metric is better (e.g. a loss).
+func Validation(validate ValidationFunc, maximize bool) Option {
+	return func(t *Trainer) {
+		t.validate = validate
+		t.maximize = maximize
+	}
+}
+
+// EarlyStopping is an option to stop the training when the validation metric
+// has not improved for the given number of epochs. It requires Validation.
+func EarlyStopping(patience int) Option {
+	if patience < 1 {
+		panic("training: the patience must be greater than zero")
+	}
+	return func(t *Trainer) {
+		t.patience = patience
+	}
+}
+
+// SaveBestModel is an option to serialize the model to file each time the
+// validation metric improves (see utils.SerializeToFile). It requires Validation.
+func SaveBestModel(filename string) Option {
+	return func(t *Trainer) {
+		t.bestModelPath = filename
+	}
+}
+
+// Checkpoints is an option to save a checkpoint of the training process at the
+// end of each epoch, and to resume the training from the latest one.
+func Checkpoints(manager *checkpoint.Manager) Option {
+	return func(t *Trainer) {
+		t.checkpoints = manager
+	}
+}
+
+// OnBatchEnd adds a callback invoked at the end of each mini-batch.
+func OnBatchEnd(callback Callback) Option {
+	return func(t *Trainer) {
+		t.onBatchEnd = append(t.onBatchEnd, callback)
+	}
+}
+
+// OnEpochEnd adds a callback invoked at the end of each epoch, after the validation.
+func OnEpochEnd(callback Callback) Option {
+	return func(t *Trainer) {
+		t.onEpochEnd = append(t.onEpochEnd, callback)
+	}
+}
+
+// OnImprovement adds a callback invoked each time the validation metric improves.
+func OnImprovement(callback Callback) Option {
+	return func(t *Trainer) {
+		t.onImprovement = append(t.onImprovement, callback)
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package training provides a reusable supervised training loop, which
+// optimizes a model over the mini-batches of a dataset for a number of epochs,
+// with optional gradient accumulation, validation, early stopping, saving of
+// the best model, checkpoints and callbacks.
+package training
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/checkpoint"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"github.com/nlpodyssey/spago/pkg/utils/data"
+)
+
+// Dataset is implemented by any value that provides a fixed number of examples,
+// which are identified by their index.
+type Dataset interface {
+	// Len returns the number of examples.
+	Len() int
+}
+
+// LossFunc returns the loss of a mini-batch, whose examples are given by their
+// indices in the dataset. The model is reified in training mode on a new graph.
+type LossFunc func(model nn.Model, batch []int) ag.Node
+
+// ValidationFunc returns the validation metric of the model (e.g. an accuracy).
+type ValidationFunc func(model nn.Model) mat.Float
+
+// Callback is invoked by the Trainer on the occurrence of an event.
+type Callback func(t *Trainer)
+
+// State is the state of a training process.
+type State struct {
+	// Epoch is the current epoch, starting from zero.
+	Epoch int
+	// Batch is the index of the current mini-batch within the epoch.
+	Batch int
+	// Step is the number of optimization steps performed.
+	Step int
+	// Loss is the loss of the last mini-batch.
+	Loss mat.Float
+	// Metric is the last validation metric.
+	Metric mat.Float
+	// BestMetric is the best validation metric so far.
+	BestMetric mat.Float
+	// BadEpochs is the number of consecutive epochs without improvements of the validation metric.
+	BadEpochs int
+}
+
+// Trainer implements a supervised training process.
+type Trainer struct {
+	State
+	model             nn.Model
+	loss              LossFunc
+	dataset           Dataset
+	optimizer         *gd.GradientDescent
+	epochs            int
+	batchSize         int
+	accumulationSteps int
+	rand              *rand.LockedRand
+	graphOptions      []ag.GraphOption
+	validate          ValidationFunc
+	maximize          bool
+	hasMetric         bool
+	patience          int
+	bestModelPath     string
+	checkpoints       *checkpoint.Manager
+	onBatchEnd        []Callback
+	onEpochEnd        []Callback
+	onImprovement     []Callback
+	stopped           bool
+}
+
+// New returns a new Trainer, which optimizes the model with the given optimizer,
+// minimizing the loss over the examples of the dataset.
+func New(model nn.Model, loss LossFunc, dataset Dataset, optimizer *gd.GradientDescent, opts ...Option) *Trainer {
+	t := &Trainer{
+		model:             model,
+		loss:              loss,
+		dataset:           dataset,
+		optimizer:         optimizer,
+		epochs:            1,
+		batchSize:         1,
+		accumulationSteps: 1,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.validate == nil && (t.patience > 0 || t.bestModelPath != "") {
+		panic("training: early stopping and best model saving require validation")
+	}
+	return t
+}
+
+// Model returns the model under training.
+func (t *Trainer) Model() nn.Model {
+	return t.model
+}
+
+// Stop stops the training at the end of the current mini-batch. It is meant
+// to be invoked by the callbacks.
+func (t *Trainer) Stop() {
+	t.stopped = true
+}
+
+// Train executes the training process, resuming it from the latest checkpoint, if any.
+func (t *Trainer) Train() error {
+	if err := t.resume(); err != nil {
+		return err
+	}
+	for ; t.Epoch < t.epochs && !t.stopped; t.Epoch++ {
+		t.trainEpoch()
+		if t.stopped {
+			break
+		}
+		if err := t.validateEpoch(); err != nil {
+			return err
+		}
+		t.invoke(t.onEpochEnd)
+		t.optimizer.IncEpoch()
+		if t.patience > 0 && t.BadEpochs >= t.patience {
+			t.stopped = true
+		}
+		if err := t.saveCheckpoint(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trainEpoch optimizes the model over all the mini-batches of the dataset.
+func (t *Trainer) trainEpoch() {
+	indices := utils.MakeIndices(t.dataset.Len())
+	if t.rand != nil {
+		rand.ShuffleInPlace(indices, t.rand)
+	}
+	t.Batch = 0
+	accumulated := 0
+	numOfBatches := (len(indices) + t.batchSize - 1) / t.batchSize
+	data.ForEachBatch(len(indices), t.batchSize, func(start, end int) {
+		if t.stopped {
+			return
+		}
+		// the last group of the epoch may accumulate fewer batches
+		groupSize := t.accumulationSteps
+		if groupStart := t.Batch - accumulated; numOfBatches-groupStart < groupSize {
+			groupSize = numOfBatches - groupStart
+		}
+		t.Loss = t.backward(indices[start:end], groupSize)
+		accumulated++
+		if accumulated == groupSize {
+			t.optimize()
+			accumulated = 0
+		}
+		t.invoke(t.onBatchEnd)
+		t.Batch++
+	})
+	if accumulated > 0 {
+		nn.ZeroGrad(t.model) // discard the gradients of a stopped epoch
+	}
+}
+
+// backward computes the loss of the mini-batch and accumulates its gradients,
+// averaged over the given number of accumulated mini-batches.
+func (t *Trainer) backward(batch []int, accumulated int) mat.Float {
+	g := ag.NewGraph(t.graphOptions...)
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, t.model)
+	loss := t.loss(proc, batch)
+	if accumulated == 1 {
+		g.Backward(loss)
+	} else {
+		g.Backward(loss, ag.OutputGrad(mat.NewScalar(1.0/mat.Float(accumulated))))
+	}
+	return loss.ScalarValue()
+}
+
+// optimize performs an optimization step.
+func (t *Trainer) optimize() {
+	t.optimizer.IncBatch()
+	t.optimizer.IncExample()
+	t.optimizer.Optimize()
+	t.Step++
+}
+
+// validateEpoch validates the model, tracking the improvements of the metric.
+func (t *Trainer) validateEpoch() error {
+	if t.validate == nil {
+		return nil
+	}
+	t.Metric = t.validate(t.model)
+	if t.hasMetric && !t.isBetter(t.Metric) {
+		t.BadEpochs++
+		return nil
+	}
+	t.hasMetric = true
+	t.BestMetric = t.Metric
+	t.BadEpochs = 0
+	if t.bestModelPath != "" {
+		if err := utils.SerializeToFile(t.bestModelPath, t.model); err != nil {
+			return fmt.Errorf("training: error during model serialization: %w", err)
+		}
+	}
+	t.invoke(t.onImprovement)
+	return nil
+}
+
+func (t *Trainer) isBetter(metric mat.Float) bool {
+	if t.maximize {
+		return metric > t.BestMetric
+	}
+	return metric < t.BestMetric
+}
+
+func (t *Trainer) invoke(callbacks []Callback) {
+	for _, callback := range callbacks {
+		callback(t)
+	}
+}
+
+// newCheckpoint returns the checkpoint of the training process.
+func (t *Trainer) newCheckpoint() *checkpoint.Checkpoint {
+	c := &checkpoint.Checkpoint{
+		Model:     t.model,
+		Optimizer: t.optimizer,
+		Rand:      t.rand,
+		Step:      t.Step,
+		Epoch:     t.Epoch,
+		BadEpochs: t.BadEpochs,
+	}
+	if t.hasMetric {
+		bestMetric := t.BestMetric
+		c.BestMetric = &bestMetric
+	}
+	return c
+}
+
+// resume restores the latest checkpoint, if any.
+func (t *Trainer) resume() error {
+	if t.checkpoints == nil {
+		return nil
+	}
+	c := t.newCheckpoint()
+	found, err := t.checkpoints.LoadLatest(c)
+	if err != nil || !found {
+		return err
+	}
+	t.Step = c.Step
+	t.Epoch = c.Epoch
+	t.BadEpochs = c.BadEpochs
+	t.hasMetric = c.BestMetric != nil
+	if t.hasMetric {
+		t.BestMetric = *c.BestMetric
+	}
+	return nil
+}
+
+// saveCheckpoint saves the checkpoint at the end of the current epoch.
+func (t *Trainer) saveCheckpoint() error {
+	if t.checkpoints == nil {
+		return nil
+	}
+	c := t.newCheckpoint()
+	c.Epoch++ // the next epoch to train
+	_, err := t.checkpoints.Save(c)
+	return err
+}
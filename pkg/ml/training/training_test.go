@@ -0,0 +1,223 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package training
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/checkpoint"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// lineDataset contains the points of the line y = 2x + 1.
+type lineDataset []mat.Float
+
+func (d lineDataset) Len() int {
+	return len(d)
+}
+
+func newLineDataset(n int) lineDataset {
+	xs := make(lineDataset, n)
+	for i := range xs {
+		xs[i] = mat.Float(i)/mat.Float(n) - 0.5
+	}
+	return xs
+}
+
+// loss returns the mean squared error of the batch.
+func (d lineDataset) loss(model nn.Model, batch []int) ag.Node {
+	m := model.(*linear.Model)
+	g := m.Graph()
+	var loss ag.Node
+	for _, i := range batch {
+		x := g.NewScalar(d[i])
+		y := m.Forward(x)[0]
+		loss = g.Add(loss, g.Square(g.SubScalar(y, g.NewScalar(2.0*d[i]+1.0))))
+	}
+	return g.DivScalar(loss, g.NewScalar(mat.Float(len(batch))))
+}
+
+// validate returns the mean squared error of the model on the dataset.
+func (d lineDataset) validate(model nn.Model) mat.Float {
+	m := model.(*linear.Model)
+	var loss mat.Float
+	for _, x := range d {
+		y := m.W.Value().Scalar()*x + m.B.Value().Scalar()
+		loss += (y - (2.0*x + 1.0)) * (y - (2.0*x + 1.0))
+	}
+	return loss / mat.Float(len(d))
+}
+
+func newTrainer(dataset lineDataset, opts ...Option) (*Trainer, *linear.Model) {
+	model := linear.New(1, 1)
+	optimizer := gd.NewOptimizer(sgd.New(sgd.NewConfig(0.5, 0.0, false)), nn.NewDefaultParamsIterator(model))
+	return New(model, dataset.loss, dataset, optimizer, opts...), model
+}
+
+func TestTrainer_Train(t *testing.T) {
+	dataset := newLineDataset(10)
+	var batches, epochs, improvements int
+	trainer, model := newTrainer(dataset,
+		Epochs(30),
+		BatchSize(4),
+		Shuffle(rand.NewLockedRand(42)),
+		Validation(dataset.validate, false),
+		OnBatchEnd(func(*Trainer) { batches++ }),
+		OnEpochEnd(func(*Trainer) { epochs++ }),
+		OnImprovement(func(*Trainer) { improvements++ }),
+	)
+	require.NoError(t, trainer.Train())
+
+	assert.Equal(t, 30*3, batches)
+	assert.Equal(t, 30, epochs)
+	assert.Equal(t, 30*3, trainer.Step)
+	assert.Greater(t, improvements, 1)
+	assert.InDelta(t, 2.0, model.W.Value().Scalar(), 1.0e-2)
+	assert.InDelta(t, 1.0, model.B.Value().Scalar(), 1.0e-2)
+	assert.False(t, model.W.HasGrad())
+}
+
+func TestTrainer_GradientAccumulation(t *testing.T) {
+	dataset := newLineDataset(10)
+	trainer, _ := newTrainer(dataset, BatchSize(2), GradientAccumulation(2))
+	require.NoError(t, trainer.Train())
+	assert.Equal(t, 3, trainer.Step) // 5 mini-batches
+
+	// the accumulation of two halves is equivalent to the whole batch
+	accumulated, model1 := newTrainer(dataset, BatchSize(5), GradientAccumulation(2))
+	whole, model2 := newTrainer(dataset, BatchSize(10))
+	model1.W.Value().SetData([]mat.Float{0.3})
+	model2.W.Value().SetData([]mat.Float{0.3})
+	require.NoError(t, accumulated.Train())
+	require.NoError(t, whole.Train())
+	assert.InDelta(t, model2.W.Value().Scalar(), model1.W.Value().Scalar(), 1.0e-6)
+	assert.InDelta(t, model2.B.Value().Scalar(), model1.B.Value().Scalar(), 1.0e-6)
+
+	// the last step accumulates a single mini-batch, which is not under-weighted
+	dataset = newLineDataset(6)
+	uneven, model3 := newTrainer(dataset, BatchSize(2), GradientAccumulation(2))
+	larger, model4 := newTrainer(dataset, BatchSize(4))
+	model3.W.Value().SetData([]mat.Float{0.3})
+	model4.W.Value().SetData([]mat.Float{0.3})
+	require.NoError(t, uneven.Train())
+	require.NoError(t, larger.Train())
+	assert.Equal(t, 2, uneven.Step)
+	assert.InDelta(t, model4.W.Value().Scalar(), model3.W.Value().Scalar(), 1.0e-6)
+	assert.InDelta(t, model4.B.Value().Scalar(), model3.B.Value().Scalar(), 1.0e-6)
+}
+
+func TestTrainer_EarlyStopping(t *testing.T) {
+	dataset := newLineDataset(10)
+	metrics := []mat.Float{3.0, 2.0, 2.5, 2.1, 1.0}
+	epoch := 0
+	validate := func(nn.Model) mat.Float {
+		metric := metrics[epoch]
+		epoch++
+		return metric
+	}
+	trainer, _ := newTrainer(dataset, Epochs(5), Validation(validate, false), EarlyStopping(2))
+	require.NoError(t, trainer.Train())
+	assert.Equal(t, 4, epoch)
+	assert.Equal(t, mat.Float(2.0), trainer.BestMetric)
+	assert.Equal(t, 2, trainer.BadEpochs)
+}
+
+func TestTrainer_Stop(t *testing.T) {
+	dataset := newLineDataset(10)
+	trainer, model := newTrainer(dataset, Epochs(3), BatchSize(2), GradientAccumulation(2),
+		OnBatchEnd(func(t *Trainer) {
+			if t.Batch == 2 {
+				t.Stop()
+			}
+		}))
+	require.NoError(t, trainer.Train())
+	assert.Equal(t, 0, trainer.Epoch)
+	assert.Equal(t, 1, trainer.Step)
+	assert.False(t, model.W.HasGrad())
+}
+
+func TestTrainer_SaveBestModel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-training-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "model.bin")
+
+	dataset := newLineDataset(10)
+	trainer, model := newTrainer(dataset, Epochs(3), Validation(dataset.validate, false), SaveBestModel(filename))
+	require.NoError(t, trainer.Train())
+
+	var saved *linear.Model
+	require.NoError(t, utils.DeserializeFromFile(filename, &saved))
+	assert.Equal(t, model.W.Value().Data(), saved.W.Value().Data())
+}
+
+func TestTrainer_Checkpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-training-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dataset := newLineDataset(10)
+	manager, err := checkpoint.NewManager(dir, 1)
+	require.NoError(t, err)
+
+	interrupted, _ := newTrainer(dataset, Epochs(2), BatchSize(3), Shuffle(rand.NewLockedRand(1)), Checkpoints(manager))
+	require.NoError(t, interrupted.Train())
+
+	resumed, model1 := newTrainer(dataset, Epochs(4), BatchSize(3), Shuffle(rand.NewLockedRand(2)), Checkpoints(manager))
+	require.NoError(t, resumed.Train())
+	assert.Equal(t, 4, resumed.Epoch)
+	assert.Equal(t, 16, resumed.Step)
+
+	uninterrupted, model2 := newTrainer(dataset, Epochs(4), BatchSize(3), Shuffle(rand.NewLockedRand(1)))
+	require.NoError(t, uninterrupted.Train())
+	assert.Equal(t, model2.W.Value().Data(), model1.W.Value().Data())
+	assert.Equal(t, model2.B.Value().Data(), model1.B.Value().Data())
+}
+
+func TestTrainer_ResumeValidation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-training-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dataset := newLineDataset(10)
+	manager, err := checkpoint.NewManager(dir, 1)
+	require.NoError(t, err)
+	metrics := []mat.Float{3.0, 2.0, 2.5, 2.1, 1.0}
+	epoch := 0
+	validate := func(nn.Model) mat.Float {
+		metric := metrics[epoch]
+		epoch++
+		return metric
+	}
+	improvements := 0
+	newValidatedTrainer := func(epochs int) *Trainer {
+		trainer, _ := newTrainer(dataset, Epochs(epochs), Checkpoints(manager),
+			Validation(validate, false), EarlyStopping(2), SaveBestModel(filepath.Join(dir, "model.bin")),
+			OnImprovement(func(*Trainer) { improvements++ }))
+		return trainer
+	}
+
+	interrupted := newValidatedTrainer(3)
+	require.NoError(t, interrupted.Train())
+	assert.Equal(t, 2, improvements)
+
+	resumed := newValidatedTrainer(5)
+	require.NoError(t, resumed.Train())
+	assert.Equal(t, 4, epoch) // stopped after the fourth epoch, as if not interrupted
+	assert.Equal(t, 2, improvements)
+	assert.Equal(t, mat.Float(2.0), resumed.BestMetric)
+	assert.Equal(t, 2, resumed.BadEpochs)
+}
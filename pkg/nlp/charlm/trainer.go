@@ -9,12 +9,14 @@ import (
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/mat32/rand"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/checkpoint"
 	"github.com/nlpodyssey/spago/pkg/ml/losses"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/gdmbuilder"
 	"github.com/nlpodyssey/spago/pkg/nlp/corpora"
 	"github.com/nlpodyssey/spago/pkg/utils"
+	"log"
 	"runtime"
 )
 
@@ -28,6 +30,11 @@ type TrainingConfig struct {
 	SerializationInterval int
 	UpdateMethod          gd.MethodConfig
 	ModelPath             string
+	// CheckpointDir optionally sets the directory of the training checkpoints,
+	// from which the training is resumed.
+	CheckpointDir string
+	// KeepCheckpoints is the number of most recent checkpoints to keep (zero keeps all).
+	KeepCheckpoints int
 }
 
 // Trainer implements the training process for a Character-level Language Model.
@@ -40,6 +47,7 @@ type Trainer struct {
 	bestLoss      mat.Float
 	lastBatchLoss mat.Float
 	curPerplexity mat.Float
+	checkpoints   *checkpoint.Manager
 }
 
 // NewTrainer returns a new Trainer.
@@ -56,21 +64,74 @@ func NewTrainer(config TrainingConfig, corpus corpora.TextCorpusIterator, model
 	}
 }
 
-// Train executes the training process.
+// Train executes the training process, resuming it from the latest checkpoint, if any.
 func (t *Trainer) Train() {
+	processed := t.resume()
+	position := 0 // the number of lines read so far
 	t.corpus.ForEachLine(func(i int, line string) {
+		position++
+		if position <= processed {
+			return // already processed before the last checkpoint
+		}
 		t.trainPassage(i, line)
 		// TODO: save the model only if it is better against a validation criterion (yet to be defined)
-		if i > 0 && i%t.SerializationInterval == 0 {
+		if position%t.SerializationInterval == 0 {
 			fmt.Println("=== MODEL SERIALIZATION")
 			err := utils.SerializeToFile(t.ModelPath, t.model)
 			if err != nil {
 				panic("charlm: error during model serialization.")
 			}
+			t.saveCheckpoint(position)
 		}
 	})
 }
 
+// newCheckpoint returns the checkpoint of the training process, where position
+// is the number of lines already processed.
+func (t *Trainer) newCheckpoint(position int) *checkpoint.Checkpoint {
+	return &checkpoint.Checkpoint{
+		Model:     t.model,
+		Optimizer: t.optimizer,
+		Rand:      t.randGen,
+		Position:  position,
+	}
+}
+
+// resume restores the latest checkpoint, if any, and returns the number of
+// lines already processed.
+func (t *Trainer) resume() int {
+	if t.CheckpointDir == "" {
+		return 0
+	}
+	var err error
+	t.checkpoints, err = checkpoint.NewManager(t.CheckpointDir, t.KeepCheckpoints)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c := t.newCheckpoint(0)
+	found, err := t.checkpoints.LoadLatest(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if found {
+		fmt.Printf("=== RESUMED AFTER %d LINES\n", c.Position)
+	}
+	return c.Position
+}
+
+// saveCheckpoint saves the checkpoint of the training process, where position
+// is the number of lines already processed.
+func (t *Trainer) saveCheckpoint(position int) {
+	if t.checkpoints == nil {
+		return
+	}
+	c := t.newCheckpoint(position)
+	c.Step = position
+	if _, err := t.checkpoints.Save(c); err != nil {
+		panic("charlm: error during checkpoint serialization.")
+	}
+}
+
 func (t *Trainer) trainPassage(index int, text string) {
 	// This is a particular case where computing the forward after the graph definition can be more efficient.
 	g := ag.NewGraph(
@@ -0,0 +1,92 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package charlm
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// sliceCorpus is a corpora.TextCorpusIterator over a slice of lines.
+type sliceCorpus []string
+
+func (c sliceCorpus) ForEachLine(callback func(i int, line string)) {
+	for i, line := range c {
+		callback(i+1, line)
+	}
+}
+
+func newTrainerTestModel() *Model {
+	model := New(Config{
+		VocabularySize: 5,
+		EmbeddingSize:  3,
+		HiddenSize:     4,
+	})
+	model.Vocabulary = vocabulary.New([]string{"a", "b", "c", DefaultSequenceSeparator, DefaultUnknownToken})
+	Initialize(model, rand.NewLockedRand(42))
+	return model
+}
+
+func newTrainerTestConfig(dir, checkpointDir string) TrainingConfig {
+	return TrainingConfig{
+		Seed:                  1,
+		BatchSize:             2,
+		BackStep:              4,
+		GradientClipping:      5.0,
+		SerializationInterval: 2,
+		UpdateMethod:          sgd.NewConfig(0.1, 0.0, false),
+		ModelPath:             filepath.Join(dir, "model.bin"),
+		CheckpointDir:         checkpointDir,
+		KeepCheckpoints:       1,
+	}
+}
+
+func paramsData(m nn.Model) [][]mat.Float {
+	var data [][]mat.Float
+	nn.ForEachParam(m, func(param nn.Param) {
+		data = append(data, append([]mat.Float(nil), param.Value().Data()...))
+	})
+	return data
+}
+
+func TestTrainer_TrainFromScratch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-charlm-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	model := newTrainerTestModel()
+	initial := paramsData(model)
+	NewTrainer(newTrainerTestConfig(dir, ""), sliceCorpus{"abc"}, model).Train()
+	assert.NotEqual(t, initial, paramsData(model), "the first line must be consumed")
+}
+
+func TestTrainer_Resume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-charlm-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	checkpointDir := filepath.Join(dir, "checkpoints")
+	corpus := sliceCorpus{"abc", "bca", "cab", "abca", "cc"}
+
+	// interrupted after the third line, with a checkpoint after the second one
+	interrupted := newTrainerTestModel()
+	NewTrainer(newTrainerTestConfig(dir, checkpointDir), corpus[:3], interrupted).Train()
+
+	resumed := newTrainerTestModel()
+	NewTrainer(newTrainerTestConfig(dir, checkpointDir), corpus, resumed).Train()
+
+	uninterrupted := newTrainerTestModel()
+	NewTrainer(newTrainerTestConfig(dir, ""), corpus, uninterrupted).Train()
+
+	assert.Equal(t, paramsData(uninterrupted), paramsData(resumed))
+}
@@ -7,9 +7,11 @@ package embeddings
 import (
 	"bytes"
 	"encoding/gob"
+	"fmt"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/quantization"
 	"github.com/nlpodyssey/spago/pkg/nlp/embeddings/syncmap"
 	"github.com/nlpodyssey/spago/pkg/utils/kvdb"
 	"log"
@@ -17,7 +19,8 @@ import (
 )
 
 var (
-	_ nn.Model = &Model{}
+	_ nn.Model                 = &Model{}
+	_ quantization.Quantizable = &Model{}
 )
 
 var allModels []*Model
@@ -44,6 +47,9 @@ type Config struct {
 	ReadOnly bool
 	// Whether to force the deletion of any existing DB to start with an empty embeddings map.
 	ForceNewDB bool
+	// Whether the embeddings are stored quantized to int8 (see Model.Quantize).
+	// Quantized embeddings are not updated during training, nor cached.
+	Quantized bool
 }
 
 func init() {
@@ -121,19 +127,118 @@ func (m *Model) SetEmbedding(word string, value *mat.Dense) {
 		log.Fatal("embedding: set operation not permitted in read-only mode")
 	}
 
+	var data []byte
+	var err error
+	if m.Quantized {
+		data, err = marshalQuantizedEmbedding(value)
+	} else {
+		data, err = marshalEmbedding(value)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = m.Storage.Put([]byte(word), data)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func marshalEmbedding(value *mat.Dense) ([]byte, error) {
 	embedding := nn.NewParam(value)
 	embedding.SetPayload(nn.NewPayload())
 
 	buf := new(bytes.Buffer)
-	err := nn.MarshalBinaryParam(embedding, buf)
+	if err := nn.MarshalBinaryParam(embedding, buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func marshalQuantizedEmbedding(value mat.Matrix) ([]byte, error) {
+	row := mat.NewDense(1, value.Size(), value.Data())
+	defer mat.ReleaseDense(row)
+	return quantization.NewMatrix(row).MarshalBinary()
+}
+
+// Quantize converts all the stored embeddings to int8 vectors, each with
+// its own scale, reducing the size of the DB. From now on, the embeddings are
+// read-only: they are dequantized at each lookup and no longer kept in the
+// cache of the used embeddings, so that only the int8 values stay in memory.
+// It requires the DB to be writable.
+func (m *Model) Quantize() error {
+	if m.Quantized {
+		return nil
+	}
+	if m.ReadOnly {
+		return fmt.Errorf("embeddings: quantization not permitted in read-only mode")
+	}
+	keys, err := m.Storage.Keys()
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	for _, key := range keys {
+		data, ok, err := m.Storage.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		embedding, err := nn.UnmarshalBinaryParam(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("embeddings: decoding embedding of %#v: %w", key, err)
+		}
+		quantized, err := marshalQuantizedEmbedding(embedding.Value())
+		if err != nil {
+			return err
+		}
+		if err := m.Storage.Put([]byte(key), quantized); err != nil {
+			return err
+		}
 	}
+	m.ClearUsedEmbeddings()
+	m.Quantized = true
+	return nil
+}
 
-	err = m.Storage.Put([]byte(word), buf.Bytes())
+// Snapshot returns all the key-value pairs of the storage, that is the values of the
+// embeddings together with their support structures (e.g. to be saved within a
+// training checkpoint). The whole content of the storage is loaded in memory.
+func (m *Model) Snapshot() (map[string][]byte, error) {
+	keys, err := m.Storage.Keys()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	snapshot := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		data, ok, err := m.Storage.Get([]byte(key))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			snapshot[key] = data
+		}
+	}
+	return snapshot, nil
+}
+
+// Restore replaces the content of the storage with the given snapshot (see Snapshot),
+// and clears the cache of the used embeddings, so that they are read again from the
+// storage. It requires the DB to be writable.
+func (m *Model) Restore(snapshot map[string][]byte) error {
+	if m.ReadOnly {
+		return fmt.Errorf("embeddings: restore not permitted in read-only mode")
+	}
+	if err := m.DropAll(); err != nil {
+		return err
+	}
+	for key, data := range snapshot {
+		if err := m.Storage.Put([]byte(key), data); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // SetEmbeddingFromData inserts a new word embeddings.
@@ -167,6 +272,7 @@ func (m *Model) GetStoredEmbedding(word string) nn.Param {
 // The returned embedding is also cached in m.UsedEmbeddings for two reasons:
 //     - to allow a faster recovery;
 //     - to keep track of used embeddings, should they be optimized.
+// The quantized embeddings are not cached, since they are never optimized.
 // It panics in case of Storage errors.
 func (m *Model) getStoredEmbedding(word string) nn.Param {
 	if embedding, ok := m.getUsedEmbedding(word); ok {
@@ -180,12 +286,26 @@ func (m *Model) getStoredEmbedding(word string) nn.Param {
 		return nil // embedding not found
 	}
 
+	if m.Quantized {
+		q := new(quantization.Matrix)
+		if err := q.UnmarshalBinary(data); err != nil {
+			log.Fatal(err)
+		}
+		embedding := nn.NewParam(mat.NewVecDense(q.Dequantize().Data()), nn.RequiresGrad(false))
+		embedding.SetName(word)
+		return embedding
+	}
+
 	tmp, err := nn.UnmarshalBinaryParam(bytes.NewReader(data))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	embedding := nn.NewParam(tmp.Value(), nn.SetStorage(m.Storage), nn.RequiresGrad(!m.ReadOnly))
+	opts := []nn.ParamOption{nn.RequiresGrad(!m.ReadOnly)}
+	if !m.ReadOnly {
+		opts = append(opts, nn.SetStorage(m.Storage)) // the read-only embeddings are never written back
+	}
+	embedding := nn.NewParam(tmp.Value(), opts...)
 	embedding.SetName(word)
 	embedding.SetPayload(tmp.Payload())
 
@@ -193,6 +313,13 @@ func (m *Model) getStoredEmbedding(word string) nn.Param {
 	return embedding
 }
 
+// IsUsedEmbedding reports whether the param is one of the word embeddings
+// loaded so far from the storage (see GetStoredEmbedding).
+func (m *Model) IsUsedEmbedding(param nn.Param) bool {
+	embedding, ok := m.getUsedEmbedding(param.Name())
+	return ok && embedding == param
+}
+
 func (m *Model) getUsedEmbedding(word string) (nn.Param, bool) {
 	if value, ok := m.UsedEmbeddings.Load(word); ok {
 		return value.(nn.Param), true
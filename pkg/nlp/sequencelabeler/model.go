@@ -139,6 +139,27 @@ func (m *Model) Forward(tokens []tokenizers.StringOffsetsPair) []TokenLabel {
 	return result
 }
 
+// ForwardBatch performs the forward step of a batch of independent sequences, sharing
+// the same graph, and returns the result for each of them.
+func (m *Model) ForwardBatch(tokens [][]tokenizers.StringOffsetsPair) [][]TokenLabel {
+	words := make([][]string, len(tokens))
+	for i, seq := range tokens {
+		words[i] = tokenizers.GetStrings(seq)
+	}
+	predictions := m.TaggerLayer.PredictBatch(m.EmbeddingsLayer.EncodeBatch(words))
+	result := make([][]TokenLabel, len(tokens))
+	for i, prediction := range predictions {
+		result[i] = make([]TokenLabel, len(tokens[i]))
+		for j, labelIndex := range prediction {
+			result[i][j] = TokenLabel{
+				StringOffsetsPair: tokens[i][j],
+				Label:             m.Labels[labelIndex],
+			}
+		}
+	}
+	return result
+}
+
 // NegativeLogLoss computes the negative log loss with respect to the targets.
 // TODO: it could be more consistent if the targets were the string labels
 func (m *Model) NegativeLogLoss(emissionScores []ag.Node, targets []int) ag.Node {
@@ -10,23 +10,40 @@ import (
 	"github.com/nlpodyssey/spago/pkg/nlp/sequencelabeler/grpcapi"
 	"github.com/nlpodyssey/spago/pkg/utils/grpcutils"
 	"github.com/nlpodyssey/spago/pkg/utils/httputils"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 	"github.com/nlpodyssey/spago/pkg/webui/ner"
 )
 
 // Server is the spaGO built-in implementation of HTTP and gRPC server for
 // sequence labeling.
 type Server struct {
-	model *Model
+	model     *Model
+	batching  microbatching.Config
+	scheduler *microbatching.Scheduler
 
 	// UnimplementedSequenceLabelerServer must be embedded to have forward compatible implementations for gRPC.
 	grpcapi.UnimplementedSequenceLabelerServer
 }
 
 // NewServer returns a new Server.
-func NewServer(model *Model) *Server {
-	return &Server{
-		model: model,
+// The requests are processed in batches by a micro-batching scheduler, shared
+// by the HTTP and gRPC handlers (see WithBatching).
+func NewServer(model *Model, opts ...ServerOption) *Server {
+	s := &Server{
+		model:    model,
+		batching: microbatching.DefaultConfig(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.scheduler = microbatching.New(s.batching, s.processBatch)
+	return s
+}
+
+// Close stops the micro-batching scheduler: the pending and the following
+// requests fail with microbatching.ErrClosed.
+func (s *Server) Close() {
+	s.scheduler.Close()
 }
 
 // Start starts the HTTP and gRPC servers.
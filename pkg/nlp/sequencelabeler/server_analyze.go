@@ -9,13 +9,11 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"runtime"
 	"time"
 
-	"github.com/nlpodyssey/spago/pkg/ml/ag"
-	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/nlp/sequencelabeler/grpcapi"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/basetokenizer"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 )
 
 // OptionsType provides JSON-serializable options for the sequence labeling Server.
@@ -41,7 +39,11 @@ func (s *Server) analyze(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	analysis, took := s.process(body.Text, body.Options.MergeEntities)
+	analysis, took, err := s.process(req.Context(), body.Text, body.Options.MergeEntities)
+	if err != nil {
+		http.Error(w, err.Error(), microbatching.HTTPStatusCode(err))
+		return
+	}
 	if body.Options.FilterNotEntities {
 		analysis = filterNotEntities(analysis)
 	}
@@ -64,7 +66,10 @@ func (s *Server) analyze(w http.ResponseWriter, req *http.Request) {
 // Analyze sends a request to /analyze.
 // TODO(evanmcclure@gmail.com) Reuse the gRPC message type for HTTP requests.
 func (s *Server) Analyze(ctx context.Context, req *grpcapi.AnalyzeRequest) (*grpcapi.AnalyzeReply, error) {
-	analysis, took := s.process(req.GetText(), req.GetMergeEntities())
+	analysis, took, err := s.process(ctx, req.GetText(), req.GetMergeEntities())
+	if err != nil {
+		return nil, microbatching.GRPCError(err)
+	}
 	if req.GetFilterNotEntities() {
 		analysis = filterNotEntities(analysis)
 	}
@@ -91,17 +96,24 @@ func tokensFrom(resp *Response) []*grpcapi.Token {
 	return result
 }
 
-func (s *Server) process(text string, merge bool) ([]TokenLabel, time.Duration) {
+// process labels the tokens of the text within a batch, together with the texts
+// of the other requests. It fails with microbatching.ErrQueueFull when the server
+// is overloaded.
+func (s *Server) process(ctx context.Context, text string, merge bool) ([]TokenLabel, time.Duration, error) {
 	start := time.Now()
-	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
-	defer g.Clear()
-	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
 	tokenized := basetokenizer.New().Tokenize(text)
-	predicted := proc.Forward(tokenized)
+	if len(tokenized) == 0 {
+		return []TokenLabel{}, time.Since(start), nil
+	}
+	result, err := s.scheduler.Submit(ctx, tokenized)
+	if err != nil {
+		return nil, 0, err
+	}
+	predicted := result.([]TokenLabel)
 	if merge {
 		predicted = mergeEntities(predicted)
 	}
-	return predicted, time.Since(start)
+	return predicted, time.Since(start), nil
 }
 
 func prepareResponse(tokens []TokenLabel, took time.Duration) *Response {
@@ -0,0 +1,43 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sequencelabeler
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
+	"runtime"
+)
+
+// ServerOption allows to configure a new Server with your specific needs.
+type ServerOption func(*Server)
+
+// WithBatching sets the configuration of the micro-batching scheduler shared by the
+// HTTP and gRPC handlers. The default is microbatching.DefaultConfig().
+func WithBatching(config microbatching.Config) ServerOption {
+	return func(s *Server) {
+		s.batching = config
+	}
+}
+
+// processBatch is the microbatching.ProcessFunc of the Server.
+// Each input is a tokenized text, and each output its sequence of labels.
+func (s *Server) processBatch(inputs []interface{}) ([]interface{}, []error) {
+	tokens := make([][]tokenizers.StringOffsetsPair, len(inputs))
+	for i, input := range inputs {
+		tokens[i] = input.([]tokenizers.StringOffsetsPair)
+	}
+
+	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
+
+	outputs := make([]interface{}, len(inputs))
+	for i, predicted := range proc.ForwardBatch(tokens) {
+		outputs[i] = predicted
+	}
+	return outputs, nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"sync"
 )
 
 // WordsEncoderProcessor extends an nn.Processor providing the Encode method to
@@ -41,6 +42,28 @@ func init() {
 
 // Encode transforms a string sequence into an encoded representation.
 func (m *Model) Encode(words []string) []ag.Node {
+	return m.ProjectionLayer.Forward(m.concatEncodings(words)...)
+}
+
+// EncodeBatch transforms a batch of string sequences into their encoded representations.
+// The sequences are encoded concurrently, then the projection layer transforms all the
+// words of the batch at once.
+func (m *Model) EncodeBatch(words [][]string) [][]ag.Node {
+	intermediateEncodings := make(nn.Batch, len(words))
+	var wg sync.WaitGroup
+	wg.Add(len(words))
+	for i := range words {
+		go func(i int) {
+			defer wg.Done()
+			intermediateEncodings[i] = m.concatEncodings(words[i])
+		}(i)
+	}
+	wg.Wait()
+	return nn.ForwardBatch(m.ProjectionLayer, intermediateEncodings)
+}
+
+// concatEncodings returns the concatenation of the encodings of each word.
+func (m *Model) concatEncodings(words []string) []ag.Node {
 	encodingsPerWord := make([][]ag.Node, len(words))
 	for _, encoder := range m.WordsEncoders {
 		for wordIndex, encoding := range encoder.Encode(words) {
@@ -55,5 +78,5 @@ func (m *Model) Encode(words []string) []ag.Node {
 			intermediateEncoding[wordIndex] = m.Graph().Concat(encoding...)
 		}
 	}
-	return m.ProjectionLayer.Forward(intermediateEncoding...)
+	return intermediateEncoding
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/nlpodyssey/gotokenizers/vocabulary"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
 	"path/filepath"
+	"strings"
 )
 
 // var _ tokenizers.Tokenizer = &BPETokenizer{} // TODO: update Tokenizer interface to return errors
@@ -24,16 +25,20 @@ import (
 type BPETokenizer struct {
 	preTokenizer *bytelevelpretokenizer.ByteLevelPreTokenizer
 	model        *bpemodel.BPEModel
+	vocab        *vocabulary.Vocabulary
 }
 
 // New returns a new BPETokenizer.
+// The vocabulary must be the same used by the model; it is required to decode the token IDs.
 func New(
 	preTokenizer *bytelevelpretokenizer.ByteLevelPreTokenizer,
 	model *bpemodel.BPEModel,
+	vocab *vocabulary.Vocabulary,
 ) *BPETokenizer {
 	return &BPETokenizer{
 		preTokenizer: preTokenizer,
 		model:        model,
+		vocab:        vocab,
 	}
 }
 
@@ -85,7 +90,7 @@ func NewFromModelFolder(path string) (*BPETokenizer, error) {
 		defaultUnknownFusionEnabled,
 	)
 
-	return New(preTokenizer, model), nil
+	return New(preTokenizer, model, vocab), nil
 }
 
 // Tokenize performs byte-level pre-tokenization and BPE tokenization.
@@ -169,3 +174,40 @@ func (t *BPETokenizer) Encode(text string) (*encodings.Encoding, error) {
 	}
 	return encoding, nil
 }
+
+// Decode converts the given token IDs back into text, reverting the byte-level
+// pre-tokenization. The IDs which are not in the vocabulary are ignored.
+// Invalid UTF-8 sequences (e.g. a multi-byte character whose tokens are not
+// all included) are kept as they are.
+func (t *BPETokenizer) Decode(ids []int) string {
+	var sb strings.Builder
+	for _, id := range ids {
+		token, ok := t.vocab.GetString(id)
+		if !ok {
+			continue
+		}
+		for _, r := range token {
+			if b, ok := runeToByte[r]; ok {
+				sb.WriteByte(b)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// runeToByte is the inverse of the byte-to-rune mapping applied by the byte-level pre-tokenizer.
+var runeToByte = make(map[rune]byte, 0x100)
+
+func init() {
+	n := 0
+	for i := 0; i < 0x100; i++ {
+		if (i >= '!' && i <= '~') || (i >= 0xA1 && i <= 0xAC) || (i >= 0xAE && i <= 0xFF) {
+			runeToByte[rune(i)] = byte(i)
+		} else {
+			runeToByte[rune(0x100+n)] = byte(i)
+			n++
+		}
+	}
+}
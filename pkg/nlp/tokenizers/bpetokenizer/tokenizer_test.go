@@ -5,6 +5,7 @@
 package bpetokenizer
 
 import (
+	"github.com/nlpodyssey/gotokenizers/vocabulary"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
 	"reflect"
 	"testing"
@@ -35,3 +36,35 @@ func TestNewFromModelFolder(t *testing.T) {
 		t.Errorf("expected:\n  %#v\nactual:\n  %#v\n", expected, actual)
 	}
 }
+
+func TestBPETokenizer_Decode(t *testing.T) {
+	tokenizer, err := NewFromModelFolder("testdata/dummy-roberta-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := tokenizer.Encode("related unrelated")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := tokenizer.Decode(encoded.IDs)
+	expected := "relatedunrelated" // the dummy vocabulary has no space token
+	if actual != expected {
+		t.Errorf("expected %q, actual %q", expected, actual)
+	}
+}
+
+func TestBPETokenizer_DecodeByteLevel(t *testing.T) {
+	vocab := vocabulary.NewVocabulary()
+	vocab.AddTerm("Hello")
+	vocab.AddTerm("Ġworld")
+	vocab.AddTerm("Ċ")
+	tokenizer := New(nil, nil, vocab)
+
+	actual := tokenizer.Decode([]int{0, 1, 2, 42})
+	expected := "Hello world\n"
+	if actual != expected {
+		t.Errorf("expected %q, actual %q", expected, actual)
+	}
+}
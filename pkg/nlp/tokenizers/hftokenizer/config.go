@@ -0,0 +1,165 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hftokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Config is the content of a Hugging Face `tokenizer.json` file.
+// The pipeline components are kept in their raw JSON form, since their
+// fields depend on the "type" of each of them.
+type Config struct {
+	Version       string          `json:"version"`
+	AddedTokens   []AddedToken    `json:"added_tokens"`
+	Normalizer    json.RawMessage `json:"normalizer"`
+	PreTokenizer  json.RawMessage `json:"pre_tokenizer"`
+	Model         json.RawMessage `json:"model"`
+	PostProcessor json.RawMessage `json:"post_processor"`
+}
+
+// AddedToken is a token which is never split by the tokenization pipeline.
+type AddedToken struct {
+	ID      int    `json:"id"`
+	Content string `json:"content"`
+	// SingleWord requires the token to be surrounded by word boundaries.
+	SingleWord bool `json:"single_word"`
+	// LStrip and RStrip extend the match to the whitespaces on the left and on the right.
+	LStrip     bool `json:"lstrip"`
+	RStrip     bool `json:"rstrip"`
+	Normalized bool `json:"normalized"`
+	Special    bool `json:"special"`
+}
+
+// LoadConfig reads a Config from a `tokenizer.json` file.
+func LoadConfig(filename string) (*Config, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig decodes a Config from the JSON content of a `tokenizer.json` file.
+func ParseConfig(data []byte) (*Config, error) {
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("hftokenizer: decoding tokenizer configuration: %w", err)
+	}
+	if isNull(config.Model) {
+		return nil, fmt.Errorf("hftokenizer: missing model in tokenizer configuration")
+	}
+	return config, nil
+}
+
+// componentType returns the "type" field of a pipeline component.
+func componentType(data json.RawMessage) (string, error) {
+	var component struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &component); err != nil {
+		return "", err
+	}
+	return component.Type, nil
+}
+
+// isNull reports whether the raw JSON value is missing or null.
+func isNull(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
+
+// pattern is a pattern of the "Split" pre-tokenizer and of the "Replace" normalizer,
+// given either as a literal string or as a regular expression.
+type pattern struct {
+	String *string `json:"String"`
+	Regex  *string `json:"Regex"`
+}
+
+type normalizerConfig struct {
+	Type        string            `json:"type"`
+	Normalizers []json.RawMessage `json:"normalizers"`
+	// BertNormalizer settings; a null StripAccents follows Lowercase.
+	CleanText          bool  `json:"clean_text"`
+	HandleChineseChars bool  `json:"handle_chinese_chars"`
+	StripAccents       *bool `json:"strip_accents"`
+	Lowercase          bool  `json:"lowercase"`
+	// Strip settings.
+	StripLeft  bool `json:"strip_left"`
+	StripRight bool `json:"strip_right"`
+	// Replace settings.
+	Pattern pattern `json:"pattern"`
+	Content string  `json:"content"`
+	// Prepend settings.
+	Prepend string `json:"prepend"`
+}
+
+type preTokenizerConfig struct {
+	Type          string            `json:"type"`
+	PreTokenizers []json.RawMessage `json:"pretokenizers"`
+	// ByteLevel and Metaspace settings.
+	AddPrefixSpace bool  `json:"add_prefix_space"`
+	TrimOffsets    bool  `json:"trim_offsets"`
+	UseRegex       *bool `json:"use_regex"`
+	// Metaspace settings; PrependScheme replaces AddPrefixSpace in the recent versions.
+	Replacement   string `json:"replacement"`
+	PrependScheme string `json:"prepend_scheme"`
+	// CharDelimiterSplit settings.
+	Delimiter string `json:"delimiter"`
+	// Digits settings.
+	IndividualDigits bool `json:"individual_digits"`
+	// Split and Punctuation settings.
+	Pattern  pattern `json:"pattern"`
+	Behavior string  `json:"behavior"`
+	Invert   bool    `json:"invert"`
+}
+
+type modelConfig struct {
+	Type string `json:"type"`
+	// Vocab is a map from token to ID for WordPiece and BPE,
+	// and a list of (piece, score) pairs for Unigram.
+	Vocab json.RawMessage `json:"vocab"`
+	// WordPiece settings.
+	UnkToken                *string `json:"unk_token"`
+	ContinuingSubwordPrefix *string `json:"continuing_subword_prefix"`
+	MaxInputCharsPerWord    int     `json:"max_input_chars_per_word"`
+	// BPE settings; Merges are either "left right" strings or [left, right] pairs.
+	Merges          []json.RawMessage `json:"merges"`
+	Dropout         *float64          `json:"dropout"`
+	EndOfWordSuffix *string           `json:"end_of_word_suffix"`
+	FuseUnk         bool              `json:"fuse_unk"`
+	// Unigram settings.
+	UnkID        *int `json:"unk_id"`
+	ByteFallback bool `json:"byte_fallback"`
+}
+
+type postProcessorConfig struct {
+	Type       string            `json:"type"`
+	Processors []json.RawMessage `json:"processors"`
+	// TemplateProcessing settings.
+	Single        []templatePieceConfig         `json:"single"`
+	Pair          []templatePieceConfig         `json:"pair"`
+	SpecialTokens map[string]specialTokenConfig `json:"special_tokens"`
+	// BertProcessing and RobertaProcessing settings, as (token, ID) pairs.
+	Sep [2]json.RawMessage `json:"sep"`
+	Cls [2]json.RawMessage `json:"cls"`
+}
+
+type templatePieceConfig struct {
+	SpecialToken *templateItemConfig `json:"SpecialToken"`
+	Sequence     *templateItemConfig `json:"Sequence"`
+}
+
+type templateItemConfig struct {
+	ID     string `json:"id"`
+	TypeID int    `json:"type_id"`
+}
+
+type specialTokenConfig struct {
+	ID     string   `json:"id"`
+	IDs    []int    `json:"ids"`
+	Tokens []string `json:"tokens"`
+}
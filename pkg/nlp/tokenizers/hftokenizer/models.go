@@ -0,0 +1,232 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hftokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nlpodyssey/gotokenizers/models"
+	"github.com/nlpodyssey/gotokenizers/models/bpemodel"
+	"github.com/nlpodyssey/gotokenizers/models/wordpiecemodel"
+	"github.com/nlpodyssey/gotokenizers/strutils"
+	"github.com/nlpodyssey/gotokenizers/vocabulary"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/sentencepiece"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultUnknownToken            = "[UNK]"
+	defaultContinuingSubwordPrefix = "##"
+	defaultMaxInputCharsPerWord    = 100
+	defaultCacheCapacity           = 0
+)
+
+// bytePiecePattern matches the byte pieces of the byte fallback, e.g. "<0x0A>".
+var bytePiecePattern = regexp.MustCompile(`^<0x[0-9A-F]{2}>$`)
+
+// newModel builds the model described by the configuration, returning it
+// together with its vocabulary.
+func newModel(data json.RawMessage) (models.Model, *vocabulary.Vocabulary, error) {
+	config := modelConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, nil, err
+	}
+	switch config.Type {
+	case "WordPiece":
+		return newWordPieceModel(config)
+	case "BPE":
+		return newBPEModel(config)
+	case "Unigram":
+		return newUnigramModel(config)
+	default:
+		return nil, nil, fmt.Errorf("hftokenizer: unsupported model type: `%s`", config.Type)
+	}
+}
+
+func newWordPieceModel(config modelConfig) (models.Model, *vocabulary.Vocabulary, error) {
+	vocab, err := vocabularyFromMap(config.Vocab)
+	if err != nil {
+		return nil, nil, err
+	}
+	maxInputCharsPerWord := config.MaxInputCharsPerWord
+	if maxInputCharsPerWord == 0 {
+		maxInputCharsPerWord = defaultMaxInputCharsPerWord
+	}
+	model := wordpiecemodel.New(
+		vocab,
+		stringOrDefault(config.UnkToken, defaultUnknownToken),
+		stringOrDefault(config.ContinuingSubwordPrefix, defaultContinuingSubwordPrefix),
+		maxInputCharsPerWord,
+	)
+	return model, vocab, nil
+}
+
+func newBPEModel(config modelConfig) (models.Model, *vocabulary.Vocabulary, error) {
+	vocab, err := vocabularyFromMap(config.Vocab)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefix := stringOrDefault(config.ContinuingSubwordPrefix, "")
+	merges := bpemodel.NewMergeMap()
+	for rank, item := range config.Merges {
+		left, right, err := decodeMerge(item)
+		if err != nil {
+			return nil, nil, fmt.Errorf("hftokenizer: merge %d: %w", rank, err)
+		}
+		leftID, leftOK := vocab.GetID(left)
+		rightID, rightOK := vocab.GetID(right)
+		if !leftOK || !rightOK {
+			return nil, nil, fmt.Errorf("hftokenizer: merge %d: token out of vocabulary", rank)
+		}
+		mergedID, ok := vocab.GetID(left + strings.TrimPrefix(right, prefix))
+		if !ok {
+			return nil, nil, fmt.Errorf("hftokenizer: merge %d: merged token out of vocabulary", rank)
+		}
+		merges.Set(leftID, rightID, bpemodel.MergeValue{Rank: rank, ID: mergedID})
+	}
+	dropout := 0.0
+	if config.Dropout != nil {
+		dropout = *config.Dropout
+	}
+	model := bpemodel.New(
+		vocab,
+		merges,
+		defaultCacheCapacity,
+		dropout,
+		stringOrDefault(config.UnkToken, ""),
+		prefix,
+		stringOrDefault(config.EndOfWordSuffix, ""),
+		config.FuseUnk,
+	)
+	return model, vocab, nil
+}
+
+// decodeMerge decodes a merge, given either as a "left right" string or as a [left, right] pair.
+func decodeMerge(data json.RawMessage) (string, string, error) {
+	var pair []string
+	if err := json.Unmarshal(data, &pair); err == nil {
+		if len(pair) != 2 {
+			return "", "", fmt.Errorf("malformed merge %s", data)
+		}
+		return pair[0], pair[1], nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", "", err
+	}
+	parts := strings.Split(s, " ")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("malformed merge %q", s)
+	}
+	return parts[0], parts[1], nil
+}
+
+// vocabularyFromMap builds a vocabulary from a JSON map of tokens to IDs.
+// The IDs must be contiguous, starting from zero.
+func vocabularyFromMap(data json.RawMessage) (*vocabulary.Vocabulary, error) {
+	var termToID map[string]int
+	if err := json.Unmarshal(data, &termToID); err != nil {
+		return nil, fmt.Errorf("hftokenizer: decoding vocabulary: %w", err)
+	}
+	terms := make([]string, len(termToID))
+	seen := make([]bool, len(termToID))
+	for term, id := range termToID {
+		if id < 0 || id >= len(terms) || seen[id] {
+			return nil, fmt.Errorf("hftokenizer: vocabulary IDs are not contiguous (token %q, ID %d)", term, id)
+		}
+		terms[id] = term
+		seen[id] = true
+	}
+	vocab := vocabulary.NewVocabulary()
+	for _, term := range terms {
+		vocab.AddTerm(term)
+	}
+	return vocab, nil
+}
+
+// unigramModel is a Unigram model, delegating the segmentation to a SentencePiece
+// tokenizer with no normalization of its own.
+type unigramModel struct {
+	tokenizer *sentencepiece.Tokenizer
+}
+
+var _ models.Model = &unigramModel{}
+
+func newUnigramModel(config modelConfig) (models.Model, *vocabulary.Vocabulary, error) {
+	var items [][2]json.RawMessage
+	if err := json.Unmarshal(config.Vocab, &items); err != nil {
+		return nil, nil, fmt.Errorf("hftokenizer: decoding vocabulary: %w", err)
+	}
+	unkID := -1
+	if config.UnkID != nil {
+		unkID = *config.UnkID
+	}
+	model := &sentencepiece.Model{
+		Pieces: make([]sentencepiece.Piece, len(items)),
+		TrainerSpec: sentencepiece.TrainerSpec{
+			ModelType:    sentencepiece.Unigram,
+			ByteFallback: config.ByteFallback,
+			UnkID:        unkID,
+			BOSID:        -1,
+			EOSID:        -1,
+			PadID:        -1,
+		},
+		NormalizerSpec: sentencepiece.NormalizerSpec{Name: "identity"},
+	}
+	vocab := vocabulary.NewVocabulary()
+	for i, item := range items {
+		piece := sentencepiece.Piece{Type: sentencepiece.Normal}
+		if err := json.Unmarshal(item[0], &piece.Piece); err != nil {
+			return nil, nil, fmt.Errorf("hftokenizer: decoding piece %d: %w", i, err)
+		}
+		if err := json.Unmarshal(item[1], &piece.Score); err != nil {
+			return nil, nil, fmt.Errorf("hftokenizer: decoding score of piece %d: %w", i, err)
+		}
+		switch {
+		case i == unkID:
+			piece.Type = sentencepiece.Unknown
+		case config.ByteFallback && bytePiecePattern.MatchString(piece.Piece):
+			piece.Type = sentencepiece.Byte
+		}
+		model.Pieces[i] = piece
+		vocab.AddTerm(piece.Piece)
+	}
+	return &unigramModel{tokenizer: sentencepiece.New(model)}, vocab, nil
+}
+
+// Tokenize segments the sequence, converting the rune offsets of the
+// SentencePiece tokens to byte offsets.
+func (m *unigramModel) Tokenize(sequence string) ([]models.Token, error) {
+	byteOffsets := make([]int, 0, len(sequence)+1)
+	for i := range sequence {
+		byteOffsets = append(byteOffsets, i)
+	}
+	byteOffsets = append(byteOffsets, len(sequence))
+
+	pieces := m.tokenizer.Encode(sequence)
+	tokens := make([]models.Token, len(pieces))
+	for i, piece := range pieces {
+		if piece.ID < 0 {
+			return nil, fmt.Errorf("hftokenizer: unknown token in %q, but the model has no unk_id", sequence)
+		}
+		tokens[i] = models.Token{
+			ID:    piece.ID,
+			Value: piece.Piece,
+			Offsets: strutils.ByteOffsets{
+				Start: byteOffsets[piece.Offsets.Start],
+				End:   byteOffsets[piece.Offsets.End],
+			},
+		}
+	}
+	return tokens, nil
+}
+
+func stringOrDefault(s *string, def string) string {
+	if s == nil {
+		return def
+	}
+	return *s
+}
@@ -0,0 +1,145 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hftokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nlpodyssey/gotokenizers/normalizedstring"
+	"github.com/nlpodyssey/gotokenizers/normalizers"
+	"github.com/nlpodyssey/gotokenizers/normalizers/bertnormalizer"
+	"github.com/nlpodyssey/gotokenizers/normalizers/lowercasenormalizer"
+	"github.com/nlpodyssey/gotokenizers/normalizers/sequencenormalizer"
+	"github.com/nlpodyssey/gotokenizers/normalizers/stripnormalizer"
+	"golang.org/x/text/unicode/norm"
+	"unicode"
+	"unicode/utf8"
+)
+
+// newNormalizer builds the normalizer described by the configuration.
+// The "Precompiled" normalizer of the SentencePiece-based tokenizers is
+// approximated with the Unicode NFKC normalization, as done by the
+// sentencepiece package.
+func newNormalizer(data json.RawMessage) (normalizers.Normalizer, error) {
+	config := normalizerConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	switch config.Type {
+	case "Sequence":
+		items := make([]normalizers.Normalizer, len(config.Normalizers))
+		for i, item := range config.Normalizers {
+			n, err := newNormalizer(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = n
+		}
+		return sequencenormalizer.NewSequenceNormalizer(items), nil
+	case "BertNormalizer":
+		accentsStripping := config.Lowercase
+		if config.StripAccents != nil {
+			accentsStripping = *config.StripAccents
+		}
+		bert := bertnormalizer.NewBertNormalizer(
+			config.CleanText, config.HandleChineseChars, false, config.Lowercase)
+		if !accentsStripping {
+			return bert, nil
+		}
+		// the accents are stripped here, since the BertNormalizer does not decompose the text first
+		return sequencenormalizer.NewSequenceNormalizer([]normalizers.Normalizer{
+			bert, unicodeNormalizer(norm.NFD), normalizerFunc(stripAccents),
+		}), nil
+	case "Lowercase":
+		return lowercasenormalizer.NewLowerCaseNormalizer(), nil
+	case "Strip":
+		return stripnormalizer.NewStripNormalizer(config.StripLeft, config.StripRight), nil
+	case "StripAccents":
+		return normalizerFunc(stripAccents), nil
+	case "NFC":
+		return unicodeNormalizer(norm.NFC), nil
+	case "NFD":
+		return unicodeNormalizer(norm.NFD), nil
+	case "NFKC", "Precompiled":
+		return unicodeNormalizer(norm.NFKC), nil
+	case "NFKD":
+		return unicodeNormalizer(norm.NFKD), nil
+	case "Replace":
+		p, err := newSplitPattern(config.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return normalizerFunc(func(ns *normalizedstring.NormalizedString) error {
+			return ns.Replace(p, config.Content)
+		}), nil
+	case "Prepend":
+		return normalizerFunc(func(ns *normalizedstring.NormalizedString) error {
+			if !ns.IsEmpty() {
+				ns.Prepend(config.Prepend)
+			}
+			return nil
+		}), nil
+	default:
+		return nil, fmt.Errorf("hftokenizer: unsupported normalizer type: `%s`", config.Type)
+	}
+}
+
+// normalizerFunc is a function satisfying the normalizers.Normalizer interface.
+type normalizerFunc func(ns *normalizedstring.NormalizedString) error
+
+// Normalize transforms the NormalizedString in place.
+func (f normalizerFunc) Normalize(ns *normalizedstring.NormalizedString) error {
+	return f(ns)
+}
+
+// stripAccents removes the combining marks; it is meant to follow an NFD normalization.
+func stripAccents(ns *normalizedstring.NormalizedString) error {
+	ns.Filter(func(r rune) bool {
+		return !unicode.Is(unicode.Mn, r)
+	})
+	return nil
+}
+
+// unicodeNormalizer returns a normalizer applying the Unicode normalization form.
+// The text is normalized segment by segment, so that each new rune is aligned
+// to the original segment it comes from.
+func unicodeNormalizer(form norm.Form) normalizerFunc {
+	return func(ns *normalizedstring.NormalizedString) error {
+		s := ns.Get()
+		if form.IsNormalString(s) {
+			return nil
+		}
+		changes := make([]normalizedstring.RuneChange, 0, len(s))
+		initialOffset := 0
+		var it norm.Iter
+		it.InitString(form, s)
+		for !it.Done() {
+			start := it.Pos()
+			segment := it.Next()
+			original := utf8.RuneCountInString(s[start:it.Pos()])
+			runes := []rune(string(segment))
+			if len(runes) == 0 {
+				if len(changes) == 0 {
+					initialOffset = it.Pos()
+				} else {
+					changes[len(changes)-1].Change -= original
+				}
+				continue
+			}
+			for i, r := range runes {
+				change := 0
+				if i >= original {
+					change = 1
+				}
+				changes = append(changes, normalizedstring.RuneChange{Rune: r, Change: change})
+			}
+			if len(runes) < original {
+				changes[len(changes)-1].Change -= original - len(runes)
+			}
+		}
+		ns.Transform(changes, initialOffset)
+		return nil
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hftokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nlpodyssey/gotokenizers/encodings"
+	"github.com/nlpodyssey/gotokenizers/strutils"
+)
+
+// templatePiece is an item of a special-tokens template: either a special
+// token or one of the input sequences (0 for "A", 1 for "B").
+type templatePiece struct {
+	special  string
+	sequence int
+	typeID   int
+}
+
+// postProcessor adds the special tokens to the encoded sequences,
+// following the single or pair template.
+type postProcessor struct {
+	single        []templatePiece
+	pair          []templatePiece
+	specialTokens map[string]specialTokenConfig
+}
+
+// newPostProcessor builds the post-processor described by the configuration.
+// It returns nil if the configuration does not add any special token (e.g. "ByteLevel").
+// The BertProcessing and RobertaProcessing types are converted into the equivalent templates.
+func newPostProcessor(data json.RawMessage) (*postProcessor, error) {
+	config := postProcessorConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	switch config.Type {
+	case "TemplateProcessing":
+		return newTemplateProcessor(config)
+	case "BertProcessing":
+		cls, sep, err := clsAndSep(config)
+		if err != nil {
+			return nil, err
+		}
+		return &postProcessor{
+			single: []templatePiece{{special: cls.ID}, {sequence: 0}, {special: sep.ID}},
+			pair: []templatePiece{{special: cls.ID}, {sequence: 0}, {special: sep.ID},
+				{sequence: 1, typeID: 1}, {special: sep.ID, typeID: 1}},
+			specialTokens: map[string]specialTokenConfig{cls.ID: cls, sep.ID: sep},
+		}, nil
+	case "RobertaProcessing":
+		cls, sep, err := clsAndSep(config)
+		if err != nil {
+			return nil, err
+		}
+		return &postProcessor{
+			single: []templatePiece{{special: cls.ID}, {sequence: 0}, {special: sep.ID}},
+			pair: []templatePiece{{special: cls.ID}, {sequence: 0}, {special: sep.ID},
+				{special: sep.ID}, {sequence: 1}, {special: sep.ID}},
+			specialTokens: map[string]specialTokenConfig{cls.ID: cls, sep.ID: sep},
+		}, nil
+	case "ByteLevel":
+		return nil, nil
+	case "Sequence":
+		var result *postProcessor
+		for _, item := range config.Processors {
+			p, err := newPostProcessor(item)
+			if err != nil {
+				return nil, err
+			}
+			if p == nil {
+				continue
+			}
+			if result != nil {
+				return nil, fmt.Errorf("hftokenizer: multiple special-tokens templates in post-processor sequence")
+			}
+			result = p
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("hftokenizer: unsupported post-processor type: `%s`", config.Type)
+	}
+}
+
+func newTemplateProcessor(config postProcessorConfig) (*postProcessor, error) {
+	p := &postProcessor{specialTokens: config.SpecialTokens}
+	var err error
+	if p.single, err = p.convertTemplate(config.Single); err != nil {
+		return nil, err
+	}
+	if p.pair, err = p.convertTemplate(config.Pair); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *postProcessor) convertTemplate(items []templatePieceConfig) ([]templatePiece, error) {
+	pieces := make([]templatePiece, len(items))
+	for i, item := range items {
+		switch {
+		case item.SpecialToken != nil:
+			token, ok := p.specialTokens[item.SpecialToken.ID]
+			if !ok {
+				return nil, fmt.Errorf("hftokenizer: missing special token `%s` in template", item.SpecialToken.ID)
+			}
+			if len(token.IDs) != len(token.Tokens) {
+				return nil, fmt.Errorf("hftokenizer: special token `%s` has mismatching IDs and tokens", token.ID)
+			}
+			pieces[i] = templatePiece{special: token.ID, typeID: item.SpecialToken.TypeID}
+		case item.Sequence != nil && item.Sequence.ID == "A":
+			pieces[i] = templatePiece{sequence: 0, typeID: item.Sequence.TypeID}
+		case item.Sequence != nil && item.Sequence.ID == "B":
+			pieces[i] = templatePiece{sequence: 1, typeID: item.Sequence.TypeID}
+		default:
+			return nil, fmt.Errorf("hftokenizer: malformed template piece %d", i)
+		}
+	}
+	return pieces, nil
+}
+
+// clsAndSep decodes the (token, ID) pairs of the BertProcessing and RobertaProcessing types.
+func clsAndSep(config postProcessorConfig) (cls, sep specialTokenConfig, err error) {
+	if cls, err = specialTokenFromPair(config.Cls); err != nil {
+		return
+	}
+	sep, err = specialTokenFromPair(config.Sep)
+	return
+}
+
+func specialTokenFromPair(pair [2]json.RawMessage) (specialTokenConfig, error) {
+	var token string
+	var id int
+	if err := json.Unmarshal(pair[0], &token); err != nil {
+		return specialTokenConfig{}, fmt.Errorf("hftokenizer: decoding special token: %w", err)
+	}
+	if err := json.Unmarshal(pair[1], &id); err != nil {
+		return specialTokenConfig{}, fmt.Errorf("hftokenizer: decoding special token ID: %w", err)
+	}
+	return specialTokenConfig{ID: token, IDs: []int{id}, Tokens: []string{token}}, nil
+}
+
+// process merges the encoded sequences (one or two) following the template.
+// The special tokens have empty offsets and no word index.
+func (p *postProcessor) process(sequences ...*encodings.Encoding) *encodings.Encoding {
+	template := p.single
+	if len(sequences) > 1 {
+		template = p.pair
+	}
+	result := encodings.NewDefaultEncoding()
+	for _, piece := range template {
+		if piece.special == "" {
+			appendSequence(result, sequences[piece.sequence], piece.typeID)
+			continue
+		}
+		token := p.specialTokens[piece.special]
+		for i, id := range token.IDs {
+			result.IDs = append(result.IDs, id)
+			result.Tokens = append(result.Tokens, token.Tokens[i])
+			result.Offsets = append(result.Offsets, strutils.ByteOffsets{})
+			result.TypeIDs = append(result.TypeIDs, piece.typeID)
+			result.Words = append(result.Words, -1)
+			result.SpecialTokensMask = append(result.SpecialTokensMask, 1)
+			result.AttentionMask = append(result.AttentionMask, 1)
+		}
+	}
+	return result
+}
+
+// appendSequence appends the encoded sequence to dst, setting its type ID.
+func appendSequence(dst, src *encodings.Encoding, typeID int) {
+	dst.IDs = append(dst.IDs, src.IDs...)
+	dst.Tokens = append(dst.Tokens, src.Tokens...)
+	dst.Offsets = append(dst.Offsets, src.Offsets...)
+	dst.Words = append(dst.Words, src.Words...)
+	dst.SpecialTokensMask = append(dst.SpecialTokensMask, src.SpecialTokensMask...)
+	dst.AttentionMask = append(dst.AttentionMask, src.AttentionMask...)
+	for range src.IDs {
+		dst.TypeIDs = append(dst.TypeIDs, typeID)
+	}
+}
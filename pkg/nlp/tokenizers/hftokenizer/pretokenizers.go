@@ -0,0 +1,173 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hftokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/dlclark/regexp2"
+	"github.com/nlpodyssey/gotokenizers/normalizedstring"
+	"github.com/nlpodyssey/gotokenizers/pretokenizedstring"
+	"github.com/nlpodyssey/gotokenizers/pretokenizers"
+	"github.com/nlpodyssey/gotokenizers/pretokenizers/bertpretokenizer"
+	"github.com/nlpodyssey/gotokenizers/pretokenizers/bytelevelpretokenizer"
+	"github.com/nlpodyssey/gotokenizers/pretokenizers/metaspacepretokenizer"
+	"github.com/nlpodyssey/gotokenizers/pretokenizers/runedelimiterpretokenizer"
+	"github.com/nlpodyssey/gotokenizers/pretokenizers/whitespacepretokenizer"
+	"github.com/nlpodyssey/gotokenizers/pretokenizers/whitespacesplitpretokenizer"
+	"github.com/nlpodyssey/gotokenizers/splitpattern"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wholeStringRegexp matches the whole string, and it is used by the ByteLevel
+// pre-tokenizer when the splitting regular expression is disabled.
+var wholeStringRegexp = regexp2.MustCompile(`[\s\S]+`, regexp2.None)
+
+// newPreTokenizer builds the pre-tokenizer described by the configuration.
+func newPreTokenizer(data json.RawMessage) (pretokenizers.PreTokenizer, error) {
+	config := preTokenizerConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	switch config.Type {
+	case "Sequence":
+		items := make(sequencePreTokenizer, len(config.PreTokenizers))
+		for i, item := range config.PreTokenizers {
+			p, err := newPreTokenizer(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = p
+		}
+		return items, nil
+	case "BertPreTokenizer":
+		return bertpretokenizer.New(), nil
+	case "ByteLevel":
+		splittingRegexp := bytelevelpretokenizer.DefaultSplittingRegexp
+		if config.UseRegex != nil && !*config.UseRegex {
+			splittingRegexp = wholeStringRegexp
+		}
+		return bytelevelpretokenizer.New(splittingRegexp, config.AddPrefixSpace, config.TrimOffsets), nil
+	case "Metaspace":
+		replacement, _ := utf8.DecodeRuneInString(config.Replacement)
+		if replacement == utf8.RuneError {
+			replacement = metaspacepretokenizer.DefaultReplacementCharacter
+		}
+		prefixSpace := config.AddPrefixSpace
+		if config.PrependScheme != "" {
+			prefixSpace = config.PrependScheme != "never"
+		}
+		return metaspacepretokenizer.New(replacement, prefixSpace), nil
+	case "Whitespace":
+		return whitespacepretokenizer.NewDefault(), nil
+	case "WhitespaceSplit":
+		return whitespacesplitpretokenizer.New(), nil
+	case "CharDelimiterSplit":
+		delimiter, _ := utf8.DecodeRuneInString(config.Delimiter)
+		return runedelimiterpretokenizer.New(delimiter), nil
+	case "Punctuation":
+		behavior, err := splitBehavior(config.Behavior, normalizedstring.SplitDelimiterIsolated)
+		if err != nil {
+			return nil, err
+		}
+		return splitPreTokenizer{pattern: splitpattern.FromFunc(isPunctuation), behavior: behavior}, nil
+	case "Digits":
+		behavior := normalizedstring.SplitDelimiterBehavior(normalizedstring.SplitDelimiterContiguous)
+		if config.IndividualDigits {
+			behavior = normalizedstring.SplitDelimiterIsolated
+		}
+		return splitPreTokenizer{pattern: splitpattern.FromFunc(unicode.IsDigit), behavior: behavior}, nil
+	case "Split":
+		p, err := newSplitPattern(config.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		if config.Invert {
+			p = splitpattern.Invert(p)
+		}
+		behavior, err := splitBehavior(config.Behavior, normalizedstring.SplitDelimiterRemoved)
+		if err != nil {
+			return nil, err
+		}
+		return splitPreTokenizer{pattern: p, behavior: behavior}, nil
+	default:
+		return nil, fmt.Errorf("hftokenizer: unsupported pre-tokenizer type: `%s`", config.Type)
+	}
+}
+
+// sequencePreTokenizer applies a list of pre-tokenizers in order.
+type sequencePreTokenizer []pretokenizers.PreTokenizer
+
+// PreTokenize applies all the pre-tokenizers to the PreTokenizedString.
+func (s sequencePreTokenizer) PreTokenize(pts *pretokenizedstring.PreTokenizedString) error {
+	for _, p := range s {
+		if err := p.PreTokenize(pts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitPreTokenizer splits the string on a pattern, handling the delimiters
+// according to the given behavior.
+type splitPreTokenizer struct {
+	pattern  splitpattern.SplitPattern
+	behavior normalizedstring.SplitDelimiterBehavior
+}
+
+// PreTokenize splits all the splits of the PreTokenizedString on the pattern.
+func (s splitPreTokenizer) PreTokenize(pts *pretokenizedstring.PreTokenizedString) error {
+	return pts.Split(
+		func(_ int, ns *normalizedstring.NormalizedString) ([]pretokenizedstring.Split, error) {
+			nss, err := ns.Split(s.pattern, s.behavior)
+			if err != nil {
+				return nil, err
+			}
+			return pretokenizedstring.SplitsFromNormalizedStrings(nss), nil
+		},
+	)
+}
+
+// newSplitPattern compiles the pattern of a "Split" pre-tokenizer or of a "Replace" normalizer.
+func newSplitPattern(p pattern) (splitpattern.SplitPattern, error) {
+	switch {
+	case p.String != nil:
+		return splitpattern.FromString(*p.String), nil
+	case p.Regex != nil:
+		r, err := regexp2.Compile(*p.Regex, regexp2.None)
+		if err != nil {
+			return nil, fmt.Errorf("hftokenizer: compiling pattern %q: %w", *p.Regex, err)
+		}
+		return splitpattern.FromRegexp2(r), nil
+	default:
+		return nil, fmt.Errorf("hftokenizer: missing pattern")
+	}
+}
+
+// splitBehavior converts the name of a split behavior; an empty name resolves to the default value.
+func splitBehavior(name string, def normalizedstring.SplitDelimiterBehavior) (normalizedstring.SplitDelimiterBehavior, error) {
+	switch name {
+	case "":
+		return def, nil
+	case "Removed":
+		return normalizedstring.SplitDelimiterRemoved, nil
+	case "Isolated":
+		return normalizedstring.SplitDelimiterIsolated, nil
+	case "MergedWithPrevious":
+		return normalizedstring.SplitDelimiterMergedWithPrevious, nil
+	case "MergedWithNext":
+		return normalizedstring.SplitDelimiterMergedWithNext, nil
+	case "Contiguous":
+		return normalizedstring.SplitDelimiterContiguous, nil
+	default:
+		return 0, fmt.Errorf("hftokenizer: unsupported split behavior: `%s`", name)
+	}
+}
+
+// isPunctuation reports whether the rune is an ASCII or a Unicode punctuation character.
+func isPunctuation(r rune) bool {
+	return r <= unicode.MaxASCII && unicode.IsSymbol(r) || unicode.IsPunct(r)
+}
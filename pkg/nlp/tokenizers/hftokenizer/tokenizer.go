@@ -0,0 +1,334 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hftokenizer builds a tokenization pipeline from a Hugging Face
+// `tokenizer.json` file, as produced by the "fast" tokenizers.
+//
+// The pipeline is made of the added tokens, a normalizer, a pre-tokenizer,
+// a model (WordPiece, BPE or Unigram) and a post-processor which adds the
+// special tokens to single and pair inputs.
+package hftokenizer
+
+import (
+	"fmt"
+	"github.com/nlpodyssey/gotokenizers/encodings"
+	"github.com/nlpodyssey/gotokenizers/models"
+	"github.com/nlpodyssey/gotokenizers/normalizedstring"
+	"github.com/nlpodyssey/gotokenizers/normalizers"
+	"github.com/nlpodyssey/gotokenizers/pretokenizedstring"
+	"github.com/nlpodyssey/gotokenizers/pretokenizers"
+	"github.com/nlpodyssey/gotokenizers/strutils"
+	"github.com/nlpodyssey/gotokenizers/vocabulary"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultConfigFilename is the name of the tokenizer file in a model folder.
+const DefaultConfigFilename = "tokenizer.json"
+
+// var _ tokenizers.Tokenizer = &Tokenizer{} // TODO: update Tokenizer interface to return errors
+
+// Tokenizer is a tokenization pipeline built from a `tokenizer.json` file.
+type Tokenizer struct {
+	// addedTokens are sorted by decreasing length, so that the longest match wins.
+	addedTokens   []AddedToken
+	addedIDs      map[string]int
+	addedStrings  map[int]string
+	specialIDs    map[int]bool
+	normalizer    normalizers.Normalizer
+	preTokenizer  pretokenizers.PreTokenizer
+	model         models.Model
+	vocab         *vocabulary.Vocabulary
+	postProcessor *postProcessor
+}
+
+// New returns a new Tokenizer built from the configuration.
+func New(config *Config) (*Tokenizer, error) {
+	t := &Tokenizer{
+		addedTokens:  append([]AddedToken(nil), config.AddedTokens...),
+		addedIDs:     make(map[string]int, len(config.AddedTokens)),
+		addedStrings: make(map[int]string, len(config.AddedTokens)),
+		specialIDs:   make(map[int]bool),
+	}
+	sort.SliceStable(t.addedTokens, func(i, j int) bool {
+		return len(t.addedTokens[i].Content) > len(t.addedTokens[j].Content)
+	})
+	for _, token := range config.AddedTokens {
+		t.addedIDs[token.Content] = token.ID
+		t.addedStrings[token.ID] = token.Content
+		if token.Special {
+			t.specialIDs[token.ID] = true
+		}
+	}
+
+	var err error
+	if !isNull(config.Normalizer) {
+		if t.normalizer, err = newNormalizer(config.Normalizer); err != nil {
+			return nil, err
+		}
+	}
+	if !isNull(config.PreTokenizer) {
+		if t.preTokenizer, err = newPreTokenizer(config.PreTokenizer); err != nil {
+			return nil, err
+		}
+	}
+	if t.model, t.vocab, err = newModel(config.Model); err != nil {
+		return nil, err
+	}
+	if !isNull(config.PostProcessor) {
+		if t.postProcessor, err = newPostProcessor(config.PostProcessor); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// NewFromFile returns a new Tokenizer built from a `tokenizer.json` file.
+func NewFromFile(filename string) (*Tokenizer, error) {
+	config, err := LoadConfig(filename)
+	if err != nil {
+		return nil, fmt.Errorf("loading tokenizer from file %s: %w", filename, err)
+	}
+	return New(config)
+}
+
+// NewFromModelFolder returns a new Tokenizer built from the `tokenizer.json`
+// file contained in the given model folder.
+func NewFromModelFolder(path string) (*Tokenizer, error) {
+	return NewFromFile(filepath.Join(path, DefaultConfigFilename))
+}
+
+// Tokenize splits the text into tokens, without adding any special token.
+// The offsets refer to the runes of the original text.
+func (t *Tokenizer) Tokenize(text string) ([]tokenizers.StringOffsetsPair, error) {
+	encoding, err := t.encodeSequence(text)
+	if err != nil {
+		return nil, err
+	}
+	runeOffsets := runeOffsetsOf(text)
+	result := make([]tokenizers.StringOffsetsPair, encoding.Len())
+	for i, token := range encoding.Tokens {
+		offsets := encoding.Offsets[i]
+		result[i] = tokenizers.StringOffsetsPair{
+			String: token,
+			Offsets: tokenizers.OffsetsType{
+				Start: runeOffsets[offsets.Start],
+				End:   runeOffsets[offsets.End],
+			},
+		}
+	}
+	return result, nil
+}
+
+// runeOffsetsOf maps each byte position of the text (and its length) to the
+// position of the rune it belongs to.
+func runeOffsetsOf(text string) []int {
+	offsets := make([]int, len(text)+1)
+	runeIndex := 0
+	for i := range text {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		for j := 0; j < size; j++ {
+			offsets[i+j] = runeIndex
+		}
+		runeIndex++
+	}
+	offsets[len(text)] = runeIndex
+	return offsets
+}
+
+// Encode converts the text into an encoded tokens representation, adding the
+// special tokens of the single-input template, if any.
+// Offset indices are based on bytes (not runes).
+func (t *Tokenizer) Encode(text string) (*encodings.Encoding, error) {
+	encoding, err := t.encodeSequence(text)
+	if err != nil {
+		return nil, err
+	}
+	if t.postProcessor == nil {
+		return encoding, nil
+	}
+	return t.postProcessor.process(encoding), nil
+}
+
+// EncodePair converts a pair of texts into an encoded tokens representation,
+// adding the special tokens of the pair template, if any. Without a template,
+// the two sequences are concatenated, and the second one has type ID 1.
+// The offsets of each token refer to the bytes of the text it comes from.
+func (t *Tokenizer) EncodePair(text, pair string) (*encodings.Encoding, error) {
+	first, err := t.encodeSequence(text)
+	if err != nil {
+		return nil, err
+	}
+	second, err := t.encodeSequence(pair)
+	if err != nil {
+		return nil, err
+	}
+	if t.postProcessor != nil {
+		return t.postProcessor.process(first, second), nil
+	}
+	result := encodings.NewDefaultEncoding()
+	appendSequence(result, first, 0)
+	appendSequence(result, second, 1)
+	return result, nil
+}
+
+// TokenToID returns the ID of the token, looking in the added tokens and in
+// the vocabulary of the model.
+func (t *Tokenizer) TokenToID(token string) (int, bool) {
+	if id, ok := t.addedIDs[token]; ok {
+		return id, true
+	}
+	return t.vocab.GetID(token)
+}
+
+// IDToToken returns the token with the given ID, looking in the added tokens
+// and in the vocabulary of the model.
+func (t *Tokenizer) IDToToken(id int) (string, bool) {
+	if token, ok := t.addedStrings[id]; ok {
+		return token, true
+	}
+	return t.vocab.GetString(id)
+}
+
+// encodeSequence runs the whole pipeline on a single sequence, except the post-processing.
+func (t *Tokenizer) encodeSequence(text string) (*encodings.Encoding, error) {
+	pts := pretokenizedstring.FromString(text)
+	if err := pts.Split(t.splitAddedTokens); err != nil {
+		return nil, fmt.Errorf("hftokenizer: splitting added tokens for %s: %w", text, err)
+	}
+	if t.normalizer != nil {
+		if err := pts.Normalize(t.normalizer.Normalize); err != nil {
+			return nil, fmt.Errorf("hftokenizer: normalizing %s: %w", text, err)
+		}
+	}
+	if t.preTokenizer != nil {
+		if err := t.preTokenizer.PreTokenize(pts); err != nil {
+			return nil, fmt.Errorf("hftokenizer: pre-tokenizing %s: %w", text, err)
+		}
+	}
+	err := pts.Tokenize(func(ns *normalizedstring.NormalizedString) ([]models.Token, error) {
+		return t.model.Tokenize(ns.Get())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hftokenizer: tokenizing %s: %w", text, err)
+	}
+	encoding, err := pts.IntoEncoding(-1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("hftokenizer: encoding %s: %w", text, err)
+	}
+	for i, id := range encoding.IDs {
+		if t.specialIDs[id] {
+			encoding.SpecialTokensMask[i] = 1
+		}
+	}
+	return encoding, nil
+}
+
+// splitAddedTokens isolates the added tokens, assigning them their ID, so that
+// the following steps of the pipeline leave them untouched.
+// The added tokens are matched on the original text, even if they are marked as normalized.
+func (t *Tokenizer) splitAddedTokens(_ int, ns *normalizedstring.NormalizedString) ([]pretokenizedstring.Split, error) {
+	s := ns.Get()
+	splits := make([]pretokenizedstring.Split, 0)
+	appendSlice := func(start, end int, token *AddedToken) error {
+		if start == end {
+			return nil
+		}
+		slice, ok := ns.Slice(normalizedstring.NewNormalizedRange(start, end))
+		if !ok {
+			return fmt.Errorf("bad split [%d, %d)", start, end)
+		}
+		split := pretokenizedstring.Split{NormalizedString: slice}
+		if token != nil {
+			split.Tokens = &[]models.Token{{
+				ID:      token.ID,
+				Value:   token.Content,
+				Offsets: strutils.ByteOffsets{Start: 0, End: slice.Len()},
+			}}
+		}
+		splits = append(splits, split)
+		return nil
+	}
+
+	last := 0
+	for i := 0; i < len(s); {
+		token, start, end, ok := t.matchAddedToken(s, i, last)
+		if !ok {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			i += size
+			continue
+		}
+		if err := appendSlice(last, start, nil); err != nil {
+			return nil, err
+		}
+		if err := appendSlice(start, end, token); err != nil {
+			return nil, err
+		}
+		last, i = end, end
+	}
+	if err := appendSlice(last, len(s), nil); err != nil {
+		return nil, err
+	}
+	return splits, nil
+}
+
+// matchAddedToken looks for an added token starting at the byte position i of s.
+// It returns the token and the boundaries of the match, extended to the
+// surrounding whitespaces (not before the lower bound) if required by the token.
+func (t *Tokenizer) matchAddedToken(s string, i, lowerBound int) (*AddedToken, int, int, bool) {
+	for k := range t.addedTokens {
+		token := &t.addedTokens[k]
+		if token.Content == "" || !strings.HasPrefix(s[i:], token.Content) {
+			continue
+		}
+		start, end := i, i+len(token.Content)
+		if token.SingleWord && (isWordRuneBefore(s, start) || isWordRuneAfter(s, end)) {
+			continue
+		}
+		if token.LStrip {
+			for start > lowerBound {
+				r, size := utf8.DecodeLastRuneInString(s[:start])
+				if !unicode.IsSpace(r) {
+					break
+				}
+				start -= size
+			}
+		}
+		if token.RStrip {
+			for end < len(s) {
+				r, size := utf8.DecodeRuneInString(s[end:])
+				if !unicode.IsSpace(r) {
+					break
+				}
+				end += size
+			}
+		}
+		return token, start, end, true
+	}
+	return nil, 0, 0, false
+}
+
+func isWordRuneBefore(s string, i int) bool {
+	if i == 0 {
+		return false
+	}
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return isWordRune(r)
+}
+
+func isWordRuneAfter(s string, i int) bool {
+	if i == len(s) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return isWordRune(r)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
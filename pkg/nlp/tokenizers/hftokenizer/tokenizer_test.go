@@ -0,0 +1,232 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hftokenizer
+
+import (
+	"github.com/nlpodyssey/gotokenizers/strutils"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const wordPieceConfig = `{
+  "version": "1.0",
+  "added_tokens": [
+    {"id": 0, "content": "[PAD]", "single_word": false, "lstrip": false, "rstrip": false, "normalized": false, "special": true},
+    {"id": 1, "content": "[UNK]", "single_word": false, "lstrip": false, "rstrip": false, "normalized": false, "special": true},
+    {"id": 2, "content": "[CLS]", "single_word": false, "lstrip": false, "rstrip": false, "normalized": false, "special": true},
+    {"id": 3, "content": "[SEP]", "single_word": false, "lstrip": false, "rstrip": false, "normalized": false, "special": true},
+    {"id": 4, "content": "[MASK]", "single_word": false, "lstrip": false, "rstrip": false, "normalized": false, "special": true}
+  ],
+  "normalizer": {"type": "BertNormalizer", "clean_text": true, "handle_chinese_chars": true, "strip_accents": null, "lowercase": true},
+  "pre_tokenizer": {"type": "BertPreTokenizer"},
+  "post_processor": {
+    "type": "TemplateProcessing",
+    "single": [
+      {"SpecialToken": {"id": "[CLS]", "type_id": 0}},
+      {"Sequence": {"id": "A", "type_id": 0}},
+      {"SpecialToken": {"id": "[SEP]", "type_id": 0}}
+    ],
+    "pair": [
+      {"SpecialToken": {"id": "[CLS]", "type_id": 0}},
+      {"Sequence": {"id": "A", "type_id": 0}},
+      {"SpecialToken": {"id": "[SEP]", "type_id": 0}},
+      {"Sequence": {"id": "B", "type_id": 1}},
+      {"SpecialToken": {"id": "[SEP]", "type_id": 1}}
+    ],
+    "special_tokens": {
+      "[CLS]": {"id": "[CLS]", "ids": [2], "tokens": ["[CLS]"]},
+      "[SEP]": {"id": "[SEP]", "ids": [3], "tokens": ["[SEP]"]}
+    }
+  },
+  "decoder": {"type": "WordPiece", "prefix": "##", "cleanup": true},
+  "model": {
+    "type": "WordPiece",
+    "unk_token": "[UNK]",
+    "continuing_subword_prefix": "##",
+    "max_input_chars_per_word": 100,
+    "vocab": {
+      "[PAD]": 0, "[UNK]": 1, "[CLS]": 2, "[SEP]": 3, "[MASK]": 4,
+      "hello": 5, "world": 6, "##s": 7, ",": 8, "!": 9, "cafe": 10
+    }
+  }
+}`
+
+const byteLevelBPEConfig = `{
+  "version": "1.0",
+  "added_tokens": [
+    {"id": 0, "content": "<s>", "single_word": false, "lstrip": false, "rstrip": false, "normalized": true, "special": true},
+    {"id": 1, "content": "</s>", "single_word": false, "lstrip": false, "rstrip": false, "normalized": true, "special": true},
+    {"id": 2, "content": "<mask>", "single_word": false, "lstrip": true, "rstrip": false, "normalized": false, "special": true}
+  ],
+  "normalizer": null,
+  "pre_tokenizer": {"type": "ByteLevel", "add_prefix_space": false, "trim_offsets": true},
+  "post_processor": {"type": "RobertaProcessing", "sep": ["</s>", 1], "cls": ["<s>", 0], "trim_offsets": true, "add_prefix_space": false},
+  "model": {
+    "type": "BPE",
+    "dropout": null,
+    "unk_token": null,
+    "continuing_subword_prefix": "",
+    "end_of_word_suffix": "",
+    "fuse_unk": false,
+    "vocab": {
+      "<s>": 0, "</s>": 1, "<mask>": 2, "h": 3, "e": 4, "l": 5, "o": 6, "Ġ": 7, "w": 8, "r": 9, "d": 10,
+      "he": 11, "ll": 12, "hell": 13, "hello": 14, "Ġw": 15, "or": 16, "Ġwor": 17, "ld": 18, "Ġworld": 19
+    },
+    "merges": ["h e", "l l", "he ll", "hell o", "Ġ w", "o r", "Ġw or", "l d", "Ġwor ld"]
+  }
+}`
+
+const unigramConfig = `{
+  "version": "1.0",
+  "added_tokens": [
+    {"id": 0, "content": "<pad>", "single_word": false, "lstrip": false, "rstrip": false, "normalized": false, "special": true},
+    {"id": 1, "content": "</s>", "single_word": false, "lstrip": false, "rstrip": false, "normalized": false, "special": true},
+    {"id": 2, "content": "<unk>", "single_word": false, "lstrip": false, "rstrip": false, "normalized": false, "special": true}
+  ],
+  "normalizer": {"type": "Sequence", "normalizers": [
+    {"type": "Precompiled", "precompiled_charsmap": ""},
+    {"type": "Lowercase"}
+  ]},
+  "pre_tokenizer": {"type": "Sequence", "pretokenizers": [
+    {"type": "WhitespaceSplit"},
+    {"type": "Metaspace", "replacement": "▁", "add_prefix_space": true}
+  ]},
+  "post_processor": {
+    "type": "TemplateProcessing",
+    "single": [{"Sequence": {"id": "A", "type_id": 0}}, {"SpecialToken": {"id": "</s>", "type_id": 0}}],
+    "pair": [
+      {"Sequence": {"id": "A", "type_id": 0}}, {"SpecialToken": {"id": "</s>", "type_id": 0}},
+      {"Sequence": {"id": "B", "type_id": 0}}, {"SpecialToken": {"id": "</s>", "type_id": 0}}
+    ],
+    "special_tokens": {"</s>": {"id": "</s>", "ids": [1], "tokens": ["</s>"]}}
+  },
+  "model": {
+    "type": "Unigram",
+    "unk_id": 2,
+    "vocab": [
+      ["<pad>", 0.0], ["</s>", 0.0], ["<unk>", 0.0],
+      ["▁", -2.0], ["▁hello", -1.0], ["▁world", -1.5], ["▁wor", -3.0], ["ld", -3.0],
+      ["h", -4.0], ["e", -4.0], ["l", -4.0], ["o", -4.0], ["s", -4.0]
+    ]
+  }
+}`
+
+func mustNewTokenizer(t *testing.T, config string) *Tokenizer {
+	t.Helper()
+	c, err := ParseConfig([]byte(config))
+	require.NoError(t, err)
+	tokenizer, err := New(c)
+	require.NoError(t, err)
+	return tokenizer
+}
+
+func TestTokenizer_WordPiece(t *testing.T) {
+	tokenizer := mustNewTokenizer(t, wordPieceConfig)
+
+	tokens, err := tokenizer.Tokenize("Hello, Worlds! [MASK] Café")
+	require.NoError(t, err)
+	assert.Equal(t, []tokenizers.StringOffsetsPair{
+		{String: "hello", Offsets: tokenizers.OffsetsType{Start: 0, End: 5}},
+		{String: ",", Offsets: tokenizers.OffsetsType{Start: 5, End: 6}},
+		{String: "world", Offsets: tokenizers.OffsetsType{Start: 7, End: 12}},
+		{String: "##s", Offsets: tokenizers.OffsetsType{Start: 12, End: 13}},
+		{String: "!", Offsets: tokenizers.OffsetsType{Start: 13, End: 14}},
+		{String: "[MASK]", Offsets: tokenizers.OffsetsType{Start: 15, End: 21}},
+		{String: "cafe", Offsets: tokenizers.OffsetsType{Start: 22, End: 26}},
+	}, tokens)
+
+	encoding, err := tokenizer.Encode("hello worlds")
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 5, 6, 7, 3}, encoding.IDs)
+	assert.Equal(t, []string{"[CLS]", "hello", "world", "##s", "[SEP]"}, encoding.Tokens)
+	assert.Equal(t, []int{1, 0, 0, 0, 1}, encoding.SpecialTokensMask)
+	assert.Equal(t, []strutils.ByteOffsets{
+		{Start: 0, End: 0}, {Start: 0, End: 5}, {Start: 6, End: 11}, {Start: 11, End: 12}, {Start: 0, End: 0}}, encoding.Offsets)
+
+	encoding, err = tokenizer.EncodePair("hello", "world!")
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 5, 3, 6, 9, 3}, encoding.IDs)
+	assert.Equal(t, []int{0, 0, 0, 1, 1, 1}, encoding.TypeIDs)
+	assert.Equal(t, []strutils.ByteOffsets{
+		{Start: 0, End: 0}, {Start: 0, End: 5}, {Start: 0, End: 0}, {Start: 0, End: 5}, {Start: 5, End: 6}, {Start: 0, End: 0}}, encoding.Offsets)
+
+	encoding, err = tokenizer.Encode("hello xyz")
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 5, 1, 3}, encoding.IDs)
+}
+
+func TestTokenizer_ByteLevelBPE(t *testing.T) {
+	tokenizer := mustNewTokenizer(t, byteLevelBPEConfig)
+
+	tokens, err := tokenizer.Tokenize("hello world <mask>")
+	require.NoError(t, err)
+	assert.Equal(t, []tokenizers.StringOffsetsPair{
+		{String: "hello", Offsets: tokenizers.OffsetsType{Start: 0, End: 5}},
+		{String: "Ġworld", Offsets: tokenizers.OffsetsType{Start: 5, End: 11}},
+		{String: "<mask>", Offsets: tokenizers.OffsetsType{Start: 11, End: 18}},
+	}, tokens)
+
+	encoding, err := tokenizer.Encode("hello world")
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 14, 19, 1}, encoding.IDs)
+
+	encoding, err = tokenizer.EncodePair("hello", "world")
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 14, 1, 1, 8, 16, 18, 1}, encoding.IDs)
+	assert.Equal(t, []int{0, 0, 0, 0, 0, 0, 0, 0}, encoding.TypeIDs)
+
+	id, ok := tokenizer.TokenToID("Ġworld")
+	assert.True(t, ok)
+	assert.Equal(t, 19, id)
+	token, ok := tokenizer.IDToToken(2)
+	assert.True(t, ok)
+	assert.Equal(t, "<mask>", token)
+}
+
+func TestTokenizer_Unigram(t *testing.T) {
+	tokenizer := mustNewTokenizer(t, unigramConfig)
+
+	tokens, err := tokenizer.Tokenize("ＨＥＬＬＯ  worlds</s>")
+	require.NoError(t, err)
+	assert.Equal(t, []tokenizers.StringOffsetsPair{
+		{String: "▁hello", Offsets: tokenizers.OffsetsType{Start: 0, End: 5}},
+		{String: "▁world", Offsets: tokenizers.OffsetsType{Start: 7, End: 12}},
+		{String: "s", Offsets: tokenizers.OffsetsType{Start: 12, End: 13}},
+		{String: "</s>", Offsets: tokenizers.OffsetsType{Start: 13, End: 17}},
+	}, tokens)
+
+	encoding, err := tokenizer.EncodePair("hello", "wor!d")
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 1, 6, 2, 1}, encoding.IDs)
+	assert.Equal(t, []string{"▁hello", "</s>", "▁wor", "<unk>", "</s>"}, encoding.Tokens)
+}
+
+func TestNewFromModelFolder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hftokenizer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, DefaultConfigFilename), []byte(wordPieceConfig), 0644))
+
+	tokenizer, err := NewFromModelFolder(dir)
+	require.NoError(t, err)
+	encoding, err := tokenizer.Encode("hello")
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 5, 3}, encoding.IDs)
+}
+
+func TestNew_UnsupportedComponent(t *testing.T) {
+	c, err := ParseConfig([]byte(`{"pre_tokenizer": {"type": "Foo"}, "model": {"type": "WordPiece", "vocab": {}}}`))
+	require.NoError(t, err)
+	_, err = New(c)
+	assert.Error(t, err)
+
+	_, err = ParseConfig([]byte(`{"normalizer": null}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,276 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sentencepiece provides a pure Go implementation of the SentencePiece
+// tokenizer, reading the SentencePiece models (e.g. "spiece.model" or
+// "sentencepiece.bpe.model") without depending on the original C++ library.
+package sentencepiece
+
+import (
+	"fmt"
+	"google.golang.org/protobuf/encoding/protowire"
+	"io/ioutil"
+	"math"
+)
+
+// PieceType is the type of a Piece.
+type PieceType int
+
+const (
+	// Normal is a normal symbol.
+	Normal PieceType = 1
+	// Unknown is the unknown symbol; only one is allowed.
+	Unknown PieceType = 2
+	// Control is a control symbol, e.g. "<s>" or "</s>".
+	Control PieceType = 3
+	// UserDefined is a symbol defined by the user, always treated as one token.
+	UserDefined PieceType = 4
+	// Unused is a symbol which is never produced.
+	Unused PieceType = 5
+	// Byte is a byte symbol of the byte-fallback, e.g. "<0x0A>".
+	Byte PieceType = 6
+)
+
+// Piece is a symbol of the vocabulary, with its score.
+type Piece struct {
+	Piece string
+	Score float32
+	Type  PieceType
+}
+
+// ModelType is the segmentation algorithm of a Model.
+type ModelType int
+
+const (
+	// Unigram is the unigram language model segmentation.
+	Unigram ModelType = 1
+	// BPE is the byte-pair-encoding segmentation.
+	BPE ModelType = 2
+	// Word splits the text into whitespace-delimited words.
+	Word ModelType = 3
+	// Char splits the text into characters.
+	Char ModelType = 4
+)
+
+// TrainerSpec contains the training settings of a Model which are relevant for the tokenization.
+type TrainerSpec struct {
+	ModelType ModelType
+	// ByteFallback decomposes the unknown characters into UTF-8 byte pieces.
+	ByteFallback bool
+	UnkID        int
+	BOSID        int
+	EOSID        int
+	// PadID is negative if the model has no padding piece.
+	PadID int
+}
+
+// NormalizerSpec contains the normalization settings of a Model.
+type NormalizerSpec struct {
+	// Name is the name of the normalization rule (e.g. "nmt_nfkc" or "identity").
+	Name string
+	// AddDummyPrefix adds a whitespace at the beginning of the text.
+	AddDummyPrefix bool
+	// RemoveExtraWhitespaces removes the leading, trailing and duplicate whitespaces.
+	RemoveExtraWhitespaces bool
+	// EscapeWhitespaces replaces the whitespaces with the meta symbol "▁" (U+2581).
+	EscapeWhitespaces bool
+}
+
+// Model is a SentencePiece model: the pieces, in the order of their IDs,
+// with the settings required for the tokenization.
+type Model struct {
+	Pieces         []Piece
+	TrainerSpec    TrainerSpec
+	NormalizerSpec NormalizerSpec
+}
+
+// ModelProto field numbers.
+const (
+	modelPiecesField         = 1
+	modelTrainerSpecField    = 2
+	modelNormalizerSpecField = 3
+)
+
+// TrainerSpec field numbers.
+const (
+	trainerModelTypeField    = 3
+	trainerByteFallbackField = 35
+	trainerUnkIDField        = 40
+	trainerBOSIDField        = 41
+	trainerEOSIDField        = 42
+	trainerPadIDField        = 43
+)
+
+// NormalizerSpec field numbers.
+const (
+	normalizerNameField                   = 1
+	normalizerAddDummyPrefixField         = 3
+	normalizerRemoveExtraWhitespacesField = 4
+	normalizerEscapeWhitespacesField      = 5
+)
+
+// SentencePiece field numbers.
+const (
+	pieceStringField = 1
+	pieceScoreField  = 2
+	pieceTypeField   = 3
+)
+
+// LoadModel reads a SentencePiece model from file.
+func LoadModel(filename string) (*Model, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseModel(data)
+}
+
+// ParseModel parses a serialized SentencePiece model (a ModelProto message).
+func ParseModel(data []byte) (*Model, error) {
+	model := &Model{
+		TrainerSpec:    defaultTrainerSpec(),
+		NormalizerSpec: defaultNormalizerSpec(),
+	}
+	err := forEachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case modelPiecesField:
+			piece, err := parsePiece(value)
+			if err != nil {
+				return err
+			}
+			model.Pieces = append(model.Pieces, piece)
+		case modelTrainerSpecField:
+			return parseTrainerSpec(value, &model.TrainerSpec)
+		case modelNormalizerSpecField:
+			return parseNormalizerSpec(value, &model.NormalizerSpec)
+		}
+		return nil // skip the unknown fields
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sentencepiece: %w", err)
+	}
+	return model, nil
+}
+
+// defaultTrainerSpec returns the TrainerSpec with the default values of SentencePiece.
+func defaultTrainerSpec() TrainerSpec {
+	return TrainerSpec{
+		ModelType: Unigram,
+		UnkID:     0,
+		BOSID:     1,
+		EOSID:     2,
+		PadID:     -1,
+	}
+}
+
+// defaultNormalizerSpec returns the NormalizerSpec with the default values of SentencePiece.
+func defaultNormalizerSpec() NormalizerSpec {
+	return NormalizerSpec{
+		Name:                   "nmt_nfkc",
+		AddDummyPrefix:         true,
+		RemoveExtraWhitespaces: true,
+		EscapeWhitespaces:      true,
+	}
+}
+
+func parseTrainerSpec(data []byte, spec *TrainerSpec) error {
+	return forEachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.VarintType {
+			return nil
+		}
+		v, _ := protowire.ConsumeVarint(value)
+		switch num {
+		case trainerModelTypeField:
+			spec.ModelType = ModelType(v)
+		case trainerByteFallbackField:
+			spec.ByteFallback = protowire.DecodeBool(v)
+		case trainerUnkIDField:
+			spec.UnkID = int(int32(v))
+		case trainerBOSIDField:
+			spec.BOSID = int(int32(v))
+		case trainerEOSIDField:
+			spec.EOSID = int(int32(v))
+		case trainerPadIDField:
+			spec.PadID = int(int32(v))
+		}
+		return nil
+	})
+}
+
+func parseNormalizerSpec(data []byte, spec *NormalizerSpec) error {
+	return forEachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num == normalizerNameField && typ == protowire.BytesType {
+			spec.Name = string(value)
+			return nil
+		}
+		if typ != protowire.VarintType {
+			return nil
+		}
+		v, _ := protowire.ConsumeVarint(value)
+		switch num {
+		case normalizerAddDummyPrefixField:
+			spec.AddDummyPrefix = protowire.DecodeBool(v)
+		case normalizerRemoveExtraWhitespacesField:
+			spec.RemoveExtraWhitespaces = protowire.DecodeBool(v)
+		case normalizerEscapeWhitespacesField:
+			spec.EscapeWhitespaces = protowire.DecodeBool(v)
+		}
+		return nil
+	})
+}
+
+func parsePiece(data []byte) (Piece, error) {
+	piece := Piece{Type: Normal}
+	err := forEachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		switch {
+		case num == pieceStringField && typ == protowire.BytesType:
+			piece.Piece = string(value)
+		case num == pieceScoreField && typ == protowire.Fixed32Type:
+			v, _ := protowire.ConsumeFixed32(value)
+			piece.Score = math.Float32frombits(v)
+		case num == pieceTypeField && typ == protowire.VarintType:
+			v, _ := protowire.ConsumeVarint(value)
+			piece.Type = PieceType(v)
+		}
+		return nil
+	})
+	return piece, err
+}
+
+// forEachField calls fn for each field of the serialized message. The value is the
+// content of the field for length-delimited fields, and its raw encoding otherwise.
+func forEachField(data []byte, fn func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		m := protowire.ConsumeFieldValue(num, typ, data)
+		if m < 0 {
+			return protowire.ParseError(m)
+		}
+		value := data[:m]
+		if typ == protowire.BytesType {
+			value, _ = protowire.ConsumeBytes(value)
+		}
+		if err := fn(num, typ, value); err != nil {
+			return err
+		}
+		data = data[m:]
+	}
+	return nil
+}
+
+// Vocabulary returns the pieces, in the order of their IDs.
+func (m *Model) Vocabulary() []string {
+	terms := make([]string, len(m.Pieces))
+	for i, p := range m.Pieces {
+		terms[i] = p.Piece
+	}
+	return terms
+}
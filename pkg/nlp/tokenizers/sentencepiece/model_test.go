@@ -0,0 +1,98 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sentencepiece
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"math"
+	"testing"
+)
+
+func TestParseModel(t *testing.T) {
+	data := encodeModel([]Piece{
+		{Piece: "<unk>", Score: 0, Type: Unknown},
+		{Piece: "<s>", Score: 0, Type: Control},
+		{Piece: "▁the", Score: -3.5, Type: Normal},
+	})
+	// self test data (field 4), which must be skipped
+	data = protowire.AppendTag(data, 4, protowire.BytesType)
+	data = protowire.AppendBytes(data, []byte{0x18, 0x01})
+
+	model, err := ParseModel(data)
+	require.NoError(t, err)
+	assert.Equal(t, []Piece{
+		{Piece: "<unk>", Score: 0, Type: Unknown},
+		{Piece: "<s>", Score: 0, Type: Control},
+		{Piece: "▁the", Score: -3.5, Type: Normal},
+	}, model.Pieces)
+	assert.Equal(t, []string{"<unk>", "<s>", "▁the"}, model.Vocabulary())
+}
+
+func TestParseModel_DefaultType(t *testing.T) {
+	var piece []byte
+	piece = protowire.AppendTag(piece, pieceStringField, protowire.BytesType)
+	piece = protowire.AppendString(piece, "a")
+	var data []byte
+	data = protowire.AppendTag(data, modelPiecesField, protowire.BytesType)
+	data = protowire.AppendBytes(data, piece)
+
+	model, err := ParseModel(data)
+	require.NoError(t, err)
+	assert.Equal(t, []Piece{{Piece: "a", Type: Normal}}, model.Pieces)
+}
+
+func TestParseModel_Specs(t *testing.T) {
+	var trainer []byte
+	trainer = protowire.AppendTag(trainer, trainerModelTypeField, protowire.VarintType)
+	trainer = protowire.AppendVarint(trainer, uint64(BPE))
+	trainer = protowire.AppendTag(trainer, trainerByteFallbackField, protowire.VarintType)
+	trainer = protowire.AppendVarint(trainer, 1)
+	trainer = protowire.AppendTag(trainer, trainerPadIDField, protowire.VarintType)
+	trainer = protowire.AppendVarint(trainer, uint64(3))
+	var normalizer []byte
+	normalizer = protowire.AppendTag(normalizer, normalizerNameField, protowire.BytesType)
+	normalizer = protowire.AppendString(normalizer, "identity")
+	normalizer = protowire.AppendTag(normalizer, normalizerAddDummyPrefixField, protowire.VarintType)
+	normalizer = protowire.AppendVarint(normalizer, 0)
+
+	data := encodeModel([]Piece{{Piece: "<unk>", Type: Unknown}})
+	data = protowire.AppendTag(data, modelTrainerSpecField, protowire.BytesType)
+	data = protowire.AppendBytes(data, trainer)
+	data = protowire.AppendTag(data, modelNormalizerSpecField, protowire.BytesType)
+	data = protowire.AppendBytes(data, normalizer)
+
+	model, err := ParseModel(data)
+	require.NoError(t, err)
+	assert.Equal(t, TrainerSpec{ModelType: BPE, ByteFallback: true, UnkID: 0, BOSID: 1, EOSID: 2, PadID: 3}, model.TrainerSpec)
+	assert.Equal(t, NormalizerSpec{
+		Name:                   "identity",
+		AddDummyPrefix:         false,
+		RemoveExtraWhitespaces: true,
+		EscapeWhitespaces:      true,
+	}, model.NormalizerSpec)
+}
+
+func TestParseModel_Invalid(t *testing.T) {
+	_, err := ParseModel([]byte{0x0a, 0x05, 0x01})
+	assert.Error(t, err)
+}
+
+func encodeModel(pieces []Piece) []byte {
+	var data []byte
+	for _, p := range pieces {
+		var piece []byte
+		piece = protowire.AppendTag(piece, pieceStringField, protowire.BytesType)
+		piece = protowire.AppendString(piece, p.Piece)
+		piece = protowire.AppendTag(piece, pieceScoreField, protowire.Fixed32Type)
+		piece = protowire.AppendFixed32(piece, math.Float32bits(p.Score))
+		piece = protowire.AppendTag(piece, pieceTypeField, protowire.VarintType)
+		piece = protowire.AppendVarint(piece, uint64(p.Type))
+		data = protowire.AppendTag(data, modelPiecesField, protowire.BytesType)
+		data = protowire.AppendBytes(data, piece)
+	}
+	return data
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sentencepiece
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WhitespaceSymbol is the meta symbol replacing the whitespaces (U+2581).
+const WhitespaceSymbol = '▁'
+
+// normalized is a normalized text, aligned to the original one.
+type normalized struct {
+	runes []rune
+	// offsets contains, for each normalized rune, the (start, end) positions of the
+	// original runes it comes from.
+	offsets [][2]int
+}
+
+// normalize applies the normalization of the NormalizerSpec to the text.
+// The rules of the "nfkc" family are approximated with the Unicode NFKC normalization
+// (and case folding for the "_cf" variants), in place of the precompiled character maps.
+func normalize(spec NormalizerSpec, text string) normalized {
+	n := normalized{}
+	if strings.Contains(spec.Name, "nfkc") {
+		n.appendNFKC(text, strings.HasSuffix(spec.Name, "_cf"))
+	} else {
+		n.appendIdentity(text)
+	}
+	if spec.RemoveExtraWhitespaces {
+		n.removeExtraWhitespaces()
+	}
+	if spec.AddDummyPrefix && len(n.runes) > 0 {
+		n.runes = append([]rune{' '}, n.runes...)
+		start := n.offsets[0][0]
+		n.offsets = append([][2]int{{start, start}}, n.offsets...)
+	}
+	if spec.EscapeWhitespaces {
+		for i, r := range n.runes {
+			if r == ' ' {
+				n.runes[i] = WhitespaceSymbol
+			}
+		}
+	}
+	return n
+}
+
+func (n *normalized) appendIdentity(text string) {
+	i := 0
+	for _, r := range text {
+		n.append(r, i, i+1)
+		i++
+	}
+}
+
+// appendNFKC normalizes the text segment by segment, so that each normalized rune can be
+// aligned to the original segment it comes from.
+func (n *normalized) appendNFKC(text string, caseFold bool) {
+	var it norm.Iter
+	it.InitString(norm.NFKC, text)
+	runePos := 0
+	for !it.Done() {
+		bytePos := it.Pos()
+		segment := it.Next()
+		start := runePos
+		runePos += utf8.RuneCountInString(text[bytePos:it.Pos()])
+		for _, r := range string(segment) {
+			if caseFold {
+				r = unicode.ToLower(r)
+			}
+			n.append(r, start, runePos)
+		}
+	}
+}
+
+func (n *normalized) append(r rune, start, end int) {
+	if unicode.IsSpace(r) {
+		r = ' '
+	}
+	n.runes = append(n.runes, r)
+	n.offsets = append(n.offsets, [2]int{start, end})
+}
+
+// removeExtraWhitespaces removes the leading and trailing whitespaces, and collapses
+// the consecutive ones into one.
+func (n *normalized) removeExtraWhitespaces() {
+	runes := n.runes[:0]
+	offsets := n.offsets[:0]
+	for i, r := range n.runes {
+		if r == ' ' && (len(runes) == 0 || runes[len(runes)-1] == ' ') {
+			continue
+		}
+		runes = append(runes, r)
+		offsets = append(offsets, n.offsets[i])
+	}
+	if len(runes) > 0 && runes[len(runes)-1] == ' ' {
+		runes = runes[:len(runes)-1]
+		offsets = offsets[:len(offsets)-1]
+	}
+	n.runes, n.offsets = runes, offsets
+}
+
+// span returns the original (start, end) positions of the normalized runes in [from, to).
+// A leading whitespace symbol is excluded, unless it is the only rune.
+func (n *normalized) span(from, to int) (int, int) {
+	if to-from > 1 && n.runes[from] == WhitespaceSymbol {
+		from++
+	}
+	return n.offsets[from][0], n.offsets[to-1][1]
+}
@@ -0,0 +1,334 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sentencepiece
+
+import (
+	"fmt"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"strings"
+	"unicode/utf8"
+)
+
+var _ tokenizers.Tokenizer = &Tokenizer{}
+
+// unknownPenalty is subtracted from the lowest score to obtain the score of the unknown
+// characters in the unigram segmentation, as done by SentencePiece.
+const unknownPenalty = 10.0
+
+// Tokenizer is a SentencePiece tokenizer, supporting the unigram and the BPE models.
+type Tokenizer struct {
+	model *Model
+	// ids maps the pieces which can be produced by the segmentation to their IDs.
+	ids map[string]int
+	// userDefined contains the pieces which are always treated as one token.
+	userDefined map[string]bool
+	// maxPieceLength is the length in runes of the longest piece.
+	maxPieceLength int
+	minScore       float32
+	maxScore       float32
+}
+
+// New returns a new Tokenizer for the given SentencePiece model.
+func New(model *Model) *Tokenizer {
+	t := &Tokenizer{
+		model:       model,
+		ids:         make(map[string]int),
+		userDefined: make(map[string]bool),
+	}
+	first := true
+	for id, p := range model.Pieces {
+		if p.Type != Normal && p.Type != UserDefined && p.Type != Byte {
+			continue
+		}
+		t.ids[p.Piece] = id
+		if p.Type == Byte {
+			continue // byte pieces are only used by the byte fallback
+		}
+		if p.Type == UserDefined {
+			t.userDefined[p.Piece] = true
+		}
+		if n := utf8.RuneCountInString(p.Piece); n > t.maxPieceLength {
+			t.maxPieceLength = n
+		}
+		if p.Type == Normal {
+			if first || p.Score < t.minScore {
+				t.minScore = p.Score
+			}
+			if first || p.Score > t.maxScore {
+				t.maxScore = p.Score
+			}
+			first = false
+		}
+	}
+	return t
+}
+
+// NewFromFile returns a new Tokenizer for the SentencePiece model read from file.
+func NewFromFile(filename string) (*Tokenizer, error) {
+	model, err := LoadModel(filename)
+	if err != nil {
+		return nil, err
+	}
+	return New(model), nil
+}
+
+// Model returns the SentencePiece model of the tokenizer.
+func (t *Tokenizer) Model() *Model {
+	return t.model
+}
+
+// Token is a piece of the tokenized text.
+type Token struct {
+	// ID is the ID of the piece.
+	ID int
+	// Piece is the string of the piece.
+	Piece string
+	// Offsets are the positions of the runes of the original text covered by the piece,
+	// excluding the leading whitespace. The bytes of the same character produced by the
+	// byte fallback share the same offsets.
+	Offsets tokenizers.OffsetsType
+}
+
+// Tokenize splits the text into pieces, satisfying the tokenizers.Tokenizer interface.
+// The offsets refer to the runes of the original text.
+func (t *Tokenizer) Tokenize(text string) []tokenizers.StringOffsetsPair {
+	tokens := t.Encode(text)
+	result := make([]tokenizers.StringOffsetsPair, len(tokens))
+	for i, token := range tokens {
+		result[i] = tokenizers.StringOffsetsPair{
+			String:  token.Piece,
+			Offsets: token.Offsets,
+		}
+	}
+	return result
+}
+
+// EncodeAsIDs returns the IDs of the pieces of the text.
+func (t *Tokenizer) EncodeAsIDs(text string) []int {
+	tokens := t.Encode(text)
+	ids := make([]int, len(tokens))
+	for i, token := range tokens {
+		ids[i] = token.ID
+	}
+	return ids
+}
+
+// Encode splits the text into pieces, according to the model type.
+func (t *Tokenizer) Encode(text string) []Token {
+	n := normalize(t.model.NormalizerSpec, text)
+	if len(n.runes) == 0 {
+		return nil
+	}
+	var spans [][2]int
+	switch t.model.TrainerSpec.ModelType {
+	case BPE:
+		spans = t.segmentBPE(n.runes)
+	case Word:
+		spans = segmentWords(n.runes)
+	case Char:
+		spans = segmentChars(n.runes)
+	default:
+		spans = t.segmentUnigram(n.runes)
+	}
+	return t.toTokens(n, spans)
+}
+
+// toTokens converts the segments of the normalized text into tokens, decomposing the unknown
+// segments into bytes (if the byte fallback is enabled) or merging them into unknown tokens.
+func (t *Tokenizer) toTokens(n normalized, spans [][2]int) []Token {
+	tokens := make([]Token, 0, len(spans))
+	unkID := t.model.TrainerSpec.UnkID
+	for _, span := range spans {
+		piece := string(n.runes[span[0]:span[1]])
+		start, end := n.span(span[0], span[1])
+		if id, ok := t.ids[piece]; ok {
+			tokens = append(tokens, Token{ID: id, Piece: piece, Offsets: tokenizers.OffsetsType{Start: start, End: end}})
+			continue
+		}
+		if bytes, ok := t.byteFallback(piece, start, end); ok {
+			tokens = append(tokens, bytes...)
+			continue
+		}
+		if last := len(tokens) - 1; last >= 0 && tokens[last].ID == unkID {
+			tokens[last].Offsets.End = end // merge the consecutive unknown segments
+			continue
+		}
+		tokens = append(tokens, Token{ID: unkID, Piece: t.pieceOf(unkID), Offsets: tokenizers.OffsetsType{Start: start, End: end}})
+	}
+	return tokens
+}
+
+// byteFallback decomposes the piece into the byte pieces of its UTF-8 encoding,
+// if the byte fallback is enabled and all the byte pieces exist.
+func (t *Tokenizer) byteFallback(piece string, start, end int) ([]Token, bool) {
+	if !t.model.TrainerSpec.ByteFallback {
+		return nil, false
+	}
+	tokens := make([]Token, len(piece))
+	for i := 0; i < len(piece); i++ {
+		bytePiece := fmt.Sprintf("<0x%02X>", piece[i])
+		id, ok := t.ids[bytePiece]
+		if !ok {
+			return nil, false
+		}
+		tokens[i] = Token{ID: id, Piece: bytePiece, Offsets: tokenizers.OffsetsType{Start: start, End: end}}
+	}
+	return tokens, true
+}
+
+func (t *Tokenizer) pieceOf(id int) string {
+	if id < 0 || id >= len(t.model.Pieces) {
+		return ""
+	}
+	return t.model.Pieces[id].Piece
+}
+
+// segmentUnigram returns the segmentation of the runes with the highest score (Viterbi).
+// The user defined pieces are preferred to any other segmentation of the same runes.
+func (t *Tokenizer) segmentUnigram(runes []rune) [][2]int {
+	size := len(runes)
+	bestScores := make([]float32, size+1)
+	bestStarts := make([]int, size+1)
+	for i := 1; i <= size; i++ {
+		bestStarts[i] = -1
+	}
+	unknownScore := t.minScore - unknownPenalty
+	for start := 0; start < size; start++ {
+		if bestStarts[start] < 0 && start > 0 {
+			continue
+		}
+		hasSingle := false
+		for end := start + 1; end <= size && end-start <= t.maxPieceLength; end++ {
+			piece := string(runes[start:end])
+			id, ok := t.ids[piece]
+			if !ok || t.model.Pieces[id].Type == Byte {
+				continue
+			}
+			score := t.model.Pieces[id].Score
+			if t.userDefined[piece] {
+				score = float32(end-start)*t.maxScore - 0.1
+			}
+			t.relax(bestScores, bestStarts, start, end, score)
+			hasSingle = hasSingle || end == start+1
+		}
+		if !hasSingle {
+			t.relax(bestScores, bestStarts, start, start+1, unknownScore)
+		}
+	}
+	var spans [][2]int
+	for end := size; end > 0; end = bestStarts[end] {
+		spans = append(spans, [2]int{bestStarts[end], end})
+	}
+	for i, j := 0, len(spans)-1; i < j; i, j = i+1, j-1 {
+		spans[i], spans[j] = spans[j], spans[i]
+	}
+	return spans
+}
+
+func (t *Tokenizer) relax(bestScores []float32, bestStarts []int, start, end int, score float32) {
+	score += bestScores[start]
+	if bestStarts[end] < 0 || score > bestScores[end] {
+		bestScores[end] = score
+		bestStarts[end] = start
+	}
+}
+
+// segmentBPE merges the pair of adjacent symbols which forms the piece with the highest
+// score, until no more pairs can be merged. The initial symbols are the characters,
+// except for the user defined pieces, which are never merged.
+func (t *Tokenizer) segmentBPE(runes []rune) [][2]int {
+	var spans [][2]int
+	var frozen []bool
+	for start := 0; start < len(runes); {
+		end := t.matchUserDefined(runes, start)
+		spans = append(spans, [2]int{start, end})
+		frozen = append(frozen, end > start+1 || t.userDefined[string(runes[start:end])])
+		start = end
+	}
+	for {
+		best := -1
+		var bestScore float32
+		for i := 0; i < len(spans)-1; i++ {
+			if frozen[i] || frozen[i+1] {
+				continue
+			}
+			id, ok := t.ids[string(runes[spans[i][0]:spans[i+1][1]])]
+			if !ok || t.model.Pieces[id].Type != Normal {
+				continue
+			}
+			if score := t.model.Pieces[id].Score; best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			return spans
+		}
+		spans[best][1] = spans[best+1][1]
+		spans = append(spans[:best+1], spans[best+2:]...)
+		frozen = append(frozen[:best+1], frozen[best+2:]...)
+	}
+}
+
+// matchUserDefined returns the end of the longest user defined piece starting at the
+// given position, or the position of the next rune if there is none.
+func (t *Tokenizer) matchUserDefined(runes []rune, start int) int {
+	for end := start + t.maxPieceLength; end > start+1; end-- {
+		if end <= len(runes) && t.userDefined[string(runes[start:end])] {
+			return end
+		}
+	}
+	return start + 1
+}
+
+// segmentWords splits the runes before each whitespace symbol.
+func segmentWords(runes []rune) [][2]int {
+	var spans [][2]int
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if runes[i] == WhitespaceSymbol {
+			spans = append(spans, [2]int{start, i})
+			start = i
+		}
+	}
+	return append(spans, [2]int{start, len(runes)})
+}
+
+func segmentChars(runes []rune) [][2]int {
+	spans := make([][2]int, len(runes))
+	for i := range runes {
+		spans[i] = [2]int{i, i + 1}
+	}
+	return spans
+}
+
+// Decode converts the IDs back into text, joining the byte pieces and restoring the
+// whitespaces. The control pieces are skipped, while the unknown ones become " ⁇ ".
+func (t *Tokenizer) Decode(ids []int) string {
+	var sb strings.Builder
+	for _, id := range ids {
+		if id < 0 || id >= len(t.model.Pieces) {
+			continue
+		}
+		p := t.model.Pieces[id]
+		switch p.Type {
+		case Control, Unused:
+			continue
+		case Unknown:
+			sb.WriteString(" ⁇ ")
+		case Byte:
+			var b byte
+			if _, err := fmt.Sscanf(p.Piece, "<0x%02X>", &b); err == nil {
+				sb.WriteByte(b)
+			}
+		default:
+			sb.WriteString(strings.Replace(p.Piece, string(WhitespaceSymbol), " ", -1))
+		}
+	}
+	text := sb.String()
+	if t.model.NormalizerSpec.AddDummyPrefix {
+		text = strings.TrimPrefix(text, " ")
+	}
+	return text
+}
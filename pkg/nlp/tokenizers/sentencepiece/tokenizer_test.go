@@ -0,0 +1,87 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sentencepiece
+
+import (
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestModel(modelType ModelType, byteFallback bool) *Model {
+	pieces := []Piece{
+		{Piece: "<unk>", Type: Unknown},
+		{Piece: "<s>", Type: Control},
+		{Piece: "</s>", Type: Control},
+		{Piece: "<mask>", Type: UserDefined},
+		{Piece: "▁", Score: -2, Type: Normal},
+		{Piece: "▁the", Score: -1, Type: Normal},
+		{Piece: "▁cat", Score: -3, Type: Normal},
+		{Piece: "▁ca", Score: -4, Type: Normal},
+		{Piece: "t", Score: -5, Type: Normal},
+		{Piece: "c", Score: -5, Type: Normal},
+		{Piece: "a", Score: -5, Type: Normal},
+		{Piece: "h", Score: -5, Type: Normal},
+		{Piece: "e", Score: -5, Type: Normal},
+		{Piece: "s", Score: -5, Type: Normal},
+		{Piece: "▁t", Score: -6, Type: Normal},
+		{Piece: "▁th", Score: -7, Type: Normal},
+		{Piece: "ca", Score: -8, Type: Normal},
+		{Piece: "cats", Score: -9, Type: Normal},
+		{Piece: "<0xC3>", Type: Byte},
+		{Piece: "<0xA8>", Type: Byte},
+	}
+	spec := defaultTrainerSpec()
+	spec.ModelType = modelType
+	spec.ByteFallback = byteFallback
+	return &Model{
+		Pieces:         pieces,
+		TrainerSpec:    spec,
+		NormalizerSpec: defaultNormalizerSpec(),
+	}
+}
+
+func TestTokenizer_Unigram(t *testing.T) {
+	tokenizer := New(newTestModel(Unigram, false))
+	assert.Equal(t, []tokenizers.StringOffsetsPair{
+		{String: "▁the", Offsets: tokenizers.OffsetsType{Start: 2, End: 5}},
+		{String: "▁cat", Offsets: tokenizers.OffsetsType{Start: 7, End: 10}},
+		{String: "s", Offsets: tokenizers.OffsetsType{Start: 10, End: 11}},
+		{String: "<mask>", Offsets: tokenizers.OffsetsType{Start: 11, End: 17}},
+	}, tokenizer.Tokenize("  the  cats<mask> "))
+	assert.Equal(t, []int{5, 6, 13, 3}, tokenizer.EncodeAsIDs("  the  cats<mask> "))
+}
+
+func TestTokenizer_UnknownAndByteFallback(t *testing.T) {
+	tokenizer := New(newTestModel(Unigram, false))
+	assert.Equal(t, []Token{
+		{ID: 5, Piece: "▁the", Offsets: tokenizers.OffsetsType{Start: 0, End: 3}},
+		{ID: 4, Piece: "▁", Offsets: tokenizers.OffsetsType{Start: 3, End: 4}},
+		{ID: 0, Piece: "<unk>", Offsets: tokenizers.OffsetsType{Start: 4, End: 6}},
+	}, tokenizer.Encode("the èè"))
+
+	tokenizer = New(newTestModel(Unigram, true))
+	assert.Equal(t, []int{5, 4, 18, 19}, tokenizer.EncodeAsIDs("the è"))
+	assert.Equal(t, "the è", tokenizer.Decode([]int{1, 5, 4, 18, 19, 2}))
+}
+
+func TestTokenizer_BPE(t *testing.T) {
+	tokenizer := New(newTestModel(BPE, false))
+	// "c" + "a", then "▁" + "ca", then "▁ca" + "t"; "▁cat" + "s" is not a piece.
+	assert.Equal(t, []string{"▁cat", "s"}, tokenizers.GetStrings(tokenizer.Tokenize("cats")))
+	// "cats" is never reached: after "c" + "a", neither "cat" nor "ts" is a piece.
+	assert.Equal(t, []string{"▁", "<mask>", "ca", "t", "s"}, tokenizers.GetStrings(tokenizer.Tokenize("<mask>cats")))
+}
+
+func TestTokenizer_Decode(t *testing.T) {
+	tokenizer := New(newTestModel(Unigram, false))
+	assert.Equal(t, "the cats ⁇ ", tokenizer.Decode([]int{5, 6, 13, 0}))
+}
+
+func TestNormalize(t *testing.T) {
+	n := normalize(defaultNormalizerSpec(), " Ａ\tb ")
+	assert.Equal(t, "▁A▁b", string(n.runes))
+	assert.Equal(t, [][2]int{{1, 1}, {1, 2}, {2, 3}, {3, 4}}, n.offsets)
+}
@@ -20,6 +20,8 @@ const (
 	DefaultUnknownToken = "[UNK]"
 	// DefaultMaskToken is the default mask token value for the WordPiece tokenizer.
 	DefaultMaskToken = "[MASK]"
+	// DefaultPadToken is the default padding token value for the WordPiece tokenizer.
+	DefaultPadToken = "[PAD]"
 	// DefaultSplitPrefix is the default split prefix value for the WordPiece tokenizer.
 	DefaultSplitPrefix = "##"
 	// DefaultMaxWordChars is the default maximum word length for the WordPiece tokenizer.
@@ -31,6 +33,7 @@ var defaultNeverSplit = []string{
 	DefaultSequenceSeparator,
 	DefaultUnknownToken,
 	DefaultMaskToken,
+	DefaultPadToken,
 }
 
 var _ tokenizers.Tokenizer = &WordPieceTokenizer{}
@@ -36,7 +36,9 @@ type Config struct {
 	DecoderFFNDim              int               `json:"decoder_ffn_dim"`
 	DecoderLayerDrop           mat.Float         `json:"decoder_layerdrop"`
 	DecoderLayers              int               `json:"decoder_layers"`
+	DecoderStartTokenID        int               `json:"decoder_start_token_id"`
 	Dropout                    mat.Float         `json:"dropout"`
+	EarlyStopping              bool              `json:"early_stopping"`
 	EncoderAttentionHeads      int               `json:"encoder_attention_heads"`
 	EncoderFFNDim              int               `json:"encoder_ffn_dim"`
 	EncoderLayerDrop           mat.Float         `json:"encoder_layerdrop"`
@@ -49,10 +51,15 @@ type Config struct {
 	InitStd                    mat.Float         `json:"init_std"`
 	IsEncoderDecoder           bool              `json:"is_encoder_decoder"`
 	Label2ID                   map[string]int    `json:"label2id"`
+	LengthPenalty              mat.Float         `json:"length_penalty"`
+	MaxLength                  int               `json:"max_length"`
 	MaxPositionEmbeddings      int               `json:"max_position_embeddings"`
+	MinLength                  int               `json:"min_length"`
 	ModelType                  string            `json:"model_type"`
+	NoRepeatNGramSize          int               `json:"no_repeat_ngram_size"`
 	NormalizeBefore            bool              `json:"normalize_before"`
 	NormalizeEmbedding         bool              `json:"normalize_embedding"`
+	NumBeams                   int               `json:"num_beams"`
 	NumHiddenLayers            int               `json:"num_hidden_layers"`
 	OutputPast                 bool              `json:"output_past"`
 	PadTokenID                 int               `json:"pad_token_id"`
@@ -64,8 +71,11 @@ type Config struct {
 }
 
 // Load loads a BART model Config from file.
+// The missing values are set to the same defaults used by Hugging Face.
 func Load(file string) (Config, error) {
-	var config Config
+	config := Config{
+		NormalizeEmbedding: true,
+	}
 	configFile, err := os.Open(file)
 	if err != nil {
 		return Config{}, err
@@ -77,3 +87,21 @@ func Load(file string) (Config, error) {
 	}
 	return config, nil
 }
+
+// conditionalGenerationArchitectures are the Hugging Face architectures of the
+// models for conditional generation.
+var conditionalGenerationArchitectures = map[string]bool{
+	"BartForConditionalGeneration": true,
+	"MarianMTModel":                true,
+}
+
+// IsConditionalGeneration reports whether the configuration describes a model
+// for conditional generation (e.g. summarization or translation).
+func (c Config) IsConditionalGeneration() bool {
+	for _, architecture := range c.Architecture {
+		if conditionalGenerationArchitectures[architecture] {
+			return true
+		}
+	}
+	return c.ModelType == "marian"
+}
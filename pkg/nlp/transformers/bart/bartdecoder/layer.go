@@ -8,14 +8,15 @@ import (
 	"encoding/gob"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/attention"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/multiheadattention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/selfattention"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartconfig"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartencoder"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/transformerutils"
 )
 
 var (
@@ -58,7 +59,7 @@ func NewLayer(config bartconfig.Config) *Layer {
 		EncoderAttentionLayerNorm: layernorm.New(config.DModel),
 		FFN: stack.New(
 			linear.New(config.DModel, config.DecoderFFNDim),
-			activation.New(ag.OpGELU), // TODO: config.ActivationFunction
+			transformerutils.NewActivation(config.ActivationFunction),
 			// dropout.New(config.ActivationDropout)
 			linear.New(config.DecoderFFNDim, config.DModel),
 			// dropout.New(config.Dropout)
@@ -67,45 +68,120 @@ func NewLayer(config bartconfig.Config) *Layer {
 	}
 }
 
+// LayerCache contains the keys and values of the self-attention and of the
+// encoder-decoder attention computed by a Layer, one pair for each head.
+type LayerCache struct {
+	SelfAttention    []selfattention.KeysValuesPair
+	EncoderAttention []selfattention.KeysValuesPair
+}
+
 // Forward performs the forward step for each input and returns the result.
 // Valid input type: LayerInput.
 func (m *Layer) Forward(xs, encoderHiddenStates []ag.Node) []ag.Node {
-	selfAtt := m.selfAttentionBlock(xs)
-	crossAtt := m.crossAttentionBlock(selfAtt, encoderHiddenStates)
-	out := m.fullyConnectedBlock(crossAtt)
+	out, _ := m.ForwardWithCache(xs, encoderHiddenStates, LayerCache{})
 	return out
 }
 
-func (m *Layer) selfAttentionBlock(xs []ag.Node) []ag.Node {
+// ForwardWithCache performs the forward step for each input and returns the result
+// together with the updated cache.
+// The xs are the decoder inputs following the ones already processed in the given cache.
+// The encoder hidden states are only projected when the cache of the encoder-decoder
+// attention is empty; otherwise the cached keys and values are reused.
+func (m *Layer) ForwardWithCache(xs, encoderHiddenStates []ag.Node, cache LayerCache) ([]ag.Node, LayerCache) {
+	selfAtt, selfAttCache := m.selfAttentionBlock(xs, cache.SelfAttention)
+	crossAtt, crossAttCache := m.crossAttentionBlock(selfAtt, encoderHiddenStates, cache.EncoderAttention)
+	out := m.fullyConnectedBlock(crossAtt)
+	return out, LayerCache{
+		SelfAttention:    selfAttCache,
+		EncoderAttention: crossAttCache,
+	}
+}
+
+// ForwardBatch performs the forward step of a batch of independent sequences, each one
+// attending to its own encoder hidden states, and returns the result for each of them.
+// The encoder keys masks (if any) exclude the padding tokens of the source sequences
+// from the encoder-decoder attention.
+func (m *Layer) ForwardBatch(xs, encoderHiddenStates nn.Batch, encoderKeysMasks [][]bool) nn.Batch {
+	selfAttention := func(ys []ag.Node) []ag.Node {
+		attIns := make([]attention.QKV, len(xs))
+		for i, seq := range xs.Split(ys) {
+			attIns[i] = attention.ToQKV(seq)
+		}
+		return nn.Batch(m.SelfAttention.ForwardBatch(attIns)).Flatten()
+	}
+	crossAttention := func(ys []ag.Node) []ag.Node {
+		attIns := make([]attention.QKV, len(xs))
+		for i, seq := range xs.Split(ys) {
+			attIns[i] = attention.QKV{
+				Queries: seq,
+				Keys:    encoderHiddenStates[i],
+				Values:  encoderHiddenStates[i],
+			}
+			if encoderKeysMasks != nil {
+				attIns[i].KeysMask = encoderKeysMasks[i]
+			}
+		}
+		return nn.Batch(m.EncoderAttention.ForwardBatch(attIns)).Flatten()
+	}
+	ys := m.residualBlock(xs.Flatten(), m.SelfAttentionLayerNorm, selfAttention)
+	ys = m.residualBlock(ys, m.EncoderAttentionLayerNorm, crossAttention)
+	return xs.Split(m.fullyConnectedBlock(ys))
+}
+
+// residualBlock wraps the given attention block with the residual connection
+// and the layer normalization.
+func (m *Layer) residualBlock(xs []ag.Node, norm *layernorm.Model, block func(xs []ag.Node) []ag.Node) []ag.Node {
+	residual := m.copy(xs)
+	if m.Config.NormalizeBefore {
+		xs = norm.Forward(xs...)
+	}
+	xs = block(xs)
+	// TODO: xs = m.Dropout(xs)
+	xs = m.add(residual, xs)
+	if !m.Config.NormalizeBefore {
+		xs = norm.Forward(xs...)
+	}
+	return xs
+}
+
+func (m *Layer) selfAttentionBlock(
+	xs []ag.Node,
+	past []selfattention.KeysValuesPair,
+) ([]ag.Node, []selfattention.KeysValuesPair) {
 	residual := m.copy(xs)
 	if m.Config.NormalizeBefore {
 		xs = m.SelfAttentionLayerNorm.Forward(xs...)
 	}
-	xs = m.SelfAttention.Forward(attention.ToQKV(xs))
+	xs, keysValues := m.SelfAttention.ForwardWithPastKeysValues(attention.ToQKV(xs), past)
 	// TODO: xs = m.Dropout(xs)
 	xs = m.add(residual, xs)
 	if !m.Config.NormalizeBefore {
 		xs = m.SelfAttentionLayerNorm.Forward(xs...)
 	}
-	return xs
+	return xs, keysValues
 }
 
-func (m *Layer) crossAttentionBlock(xs []ag.Node, encoderHiddenStates []ag.Node) []ag.Node {
+func (m *Layer) crossAttentionBlock(
+	xs []ag.Node,
+	encoderHiddenStates []ag.Node,
+	past []selfattention.KeysValuesPair,
+) ([]ag.Node, []selfattention.KeysValuesPair) {
 	residual := m.copy(xs)
 	if m.Config.NormalizeBefore {
 		xs = m.EncoderAttentionLayerNorm.Forward(xs...)
 	}
-	xs = m.EncoderAttention.Forward(attention.QKV{
-		Queries: xs,
-		Keys:    encoderHiddenStates,
-		Values:  encoderHiddenStates,
-	})
+	attIn := attention.QKV{Queries: xs}
+	if past == nil {
+		attIn.Keys = encoderHiddenStates
+		attIn.Values = encoderHiddenStates
+	}
+	xs, keysValues := m.EncoderAttention.ForwardWithPastKeysValues(attIn, past)
 	// TODO: xs = m.Dropout(xs)
 	xs = m.add(residual, xs)
 	if !m.Config.NormalizeBefore {
 		xs = m.EncoderAttentionLayerNorm.Forward(xs...)
 	}
-	return xs
+	return xs, keysValues
 }
 
 func (m *Layer) fullyConnectedBlock(xs []ag.Node) []ag.Node {
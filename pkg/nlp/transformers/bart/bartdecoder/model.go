@@ -24,9 +24,12 @@ type Model struct {
 	nn.BaseModel
 	Config                      bartconfig.Config
 	LearnedPositionalEmbeddings *posembeddings.LearnedPositionalEmbeddings
-	Layers                      []*Layer
-	EmbeddingLayerNorm          *layernorm.Model
-	LayerNorm                   *layernorm.Model
+	// SinusoidalPositionalEmbeddings are used in place of the learned ones
+	// when the configuration requires static position embeddings.
+	SinusoidalPositionalEmbeddings *posembeddings.SinusoidalPositionalEmbeddings
+	Layers                         []*Layer
+	EmbeddingLayerNorm             *layernorm.Model
+	LayerNorm                      *layernorm.Model
 }
 
 func init() {
@@ -35,29 +38,33 @@ func init() {
 
 // New returns a new BART decoder Model.
 func New(config bartconfig.Config) *Model {
-	if config.StaticPositionEmbeddings {
-		panic("bart: static position embeddings not implemented.")
-	}
-	if config.ScaleEmbedding {
-		panic("bart: scale embedding not implemented.")
-	}
 	learnedPositionalEmbeddings := make([]nn.Param, config.MaxPositionEmbeddings+config.ExtraPosEmbedding)
 	for i := 0; i < len(learnedPositionalEmbeddings); i++ {
 		learnedPositionalEmbeddings[i] = nn.NewParam(mat.NewEmptyVecDense(config.DModel))
 	}
-	return &Model{
-		Config: config,
-		LearnedPositionalEmbeddings: posembeddings.NewLearnedPositionalEmbeddings(
+	model := &Model{
+		Config:             config,
+		EmbeddingLayerNorm: layernorm.New(config.DModel),
+		Layers:             makeLayers(config),
+		LayerNorm:          layernorm.New(config.DModel),
+	}
+	if config.StaticPositionEmbeddings {
+		model.SinusoidalPositionalEmbeddings = posembeddings.NewSinusoidalPositionalEmbeddings(
+			posembeddings.Config{
+				NumEmbeddings: config.MaxPositionEmbeddings,
+				EmbeddingDim:  config.DModel,
+				PaddingIDX:    config.PadTokenID,
+			})
+	} else {
+		model.LearnedPositionalEmbeddings = posembeddings.NewLearnedPositionalEmbeddings(
 			posembeddings.Config{
 				NumEmbeddings: config.VocabSize,
 				EmbeddingDim:  config.DModel,
 				PaddingIDX:    0, // TODO
 				Offset:        config.ExtraPosEmbedding,
-			}),
-		EmbeddingLayerNorm: layernorm.New(config.DModel),
-		Layers:             makeLayers(config),
-		LayerNorm:          layernorm.New(config.DModel),
+			})
 	}
+	return model
 }
 
 func makeLayers(config bartconfig.Config) []*Layer {
@@ -69,24 +76,83 @@ func makeLayers(config bartconfig.Config) []*Layer {
 	return layers
 }
 
+// Cache contains the cached keys and values of each decoder Layer.
+type Cache []LayerCache
+
+// Len returns the number of decoder positions already processed in the cache.
+func (c Cache) Len() int {
+	if len(c) == 0 || len(c[0].SelfAttention) == 0 {
+		return 0
+	}
+	return len(c[0].SelfAttention[0].Keys)
+}
+
 // Decode performs the forward step for each input and returns the result.
 func (m *Model) Decode(xs, encoderHiddenStates []ag.Node) []ag.Node {
-	embedPos := m.LearnedPositionalEmbeddings.Encode(utils.MakeIndices(len(xs)))
-	ys := m.add(xs, embedPos)
-	ys = m.EmbeddingLayerNorm.Forward(ys...)
+	ys, _ := m.DecodeWithCache(xs, encoderHiddenStates, nil)
+	return ys
+}
+
+// DecodeWithCache performs the forward step for each input and returns the result
+// together with the updated cache of keys and values.
+// The xs are the decoder inputs following the positions already processed in the
+// cache, which is nil at the first decoding step. This allows for incremental
+// (i.e. autoregressive) decoding without recomputing the past.
+func (m *Model) DecodeWithCache(xs, encoderHiddenStates []ag.Node, cache Cache) ([]ag.Node, Cache) {
+	offset := cache.Len()
+	positions := utils.MakeIndices(len(xs))
+	for i := range positions {
+		positions[i] += offset
+	}
+	ys := m.add(xs, m.encodePositions(positions))
+	if m.Config.NormalizeEmbedding {
+		ys = m.EmbeddingLayerNorm.Forward(ys...)
+	}
 	// TODO: ys = m.Dropout(ys)
 
-	for _, layer := range m.Layers {
-		ys = layer.Forward(ys, encoderHiddenStates)
+	nextCache := make(Cache, len(m.Layers))
+	for i, layer := range m.Layers {
+		var layerCache LayerCache
+		if cache != nil {
+			layerCache = cache[i]
+		}
+		ys, nextCache[i] = layer.ForwardWithCache(ys, encoderHiddenStates, layerCache)
 		// TODO: save all hidden states into the processor to allow a later access
 	}
 
 	if m.Config.FinalLayerNorm {
 		ys = m.LayerNorm.Forward(ys...)
 	}
+	return ys, nextCache
+}
+
+// DecodeBatch performs the forward step of a batch of independent sequences, each one
+// attending to its own encoder hidden states, and returns the result for each of them.
+// The encoder keys masks (if any) exclude the padding tokens of the source sequences.
+func (m *Model) DecodeBatch(xs, encoderHiddenStates nn.Batch, encoderKeysMasks [][]bool) nn.Batch {
+	ys := make(nn.Batch, len(xs))
+	for i, seq := range xs {
+		ys[i] = m.add(seq, m.encodePositions(utils.MakeIndices(len(seq))))
+	}
+	if m.Config.NormalizeEmbedding {
+		ys = nn.ForwardBatch(m.EmbeddingLayerNorm, ys)
+	}
+	for _, layer := range m.Layers {
+		ys = layer.ForwardBatch(ys, encoderHiddenStates, encoderKeysMasks)
+	}
+	if m.Config.FinalLayerNorm {
+		ys = nn.ForwardBatch(m.LayerNorm, ys)
+	}
 	return ys
 }
 
+func (m *Model) encodePositions(positions []int) []ag.Node {
+	if m.Config.StaticPositionEmbeddings {
+		return m.SinusoidalPositionalEmbeddings.Encode(positions)
+	}
+	return m.LearnedPositionalEmbeddings.Encode(positions)
+}
+
 func (m *Model) add(a []ag.Node, b []ag.Node) []ag.Node {
 	c := make([]ag.Node, len(a))
 	for i := 0; i < len(a); i++ {
@@ -8,13 +8,13 @@ import (
 	"encoding/gob"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/attention"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/multiheadattention"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartconfig"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/transformerutils"
 )
 
 var (
@@ -43,7 +43,7 @@ func NewLayer(config bartconfig.Config) *Layer {
 		SelfAttentionLayerNorm: layernorm.New(config.DModel),
 		FFN: stack.New(
 			linear.New(config.DModel, config.EncoderFFNDim),
-			activation.New(ag.OpGELU), // TODO: config.ActivationFunction
+			transformerutils.NewActivation(config.ActivationFunction),
 			// dropout.New(config.ActivationDropout)
 			linear.New(config.EncoderFFNDim, config.DModel),
 			// dropout.New(config.Dropout)
@@ -60,18 +60,43 @@ func (m *Layer) Forward(xs ...ag.Node) []ag.Node {
 	return out
 }
 
+// ForwardBatch performs the forward step of a batch of independent sequences, and returns
+// the result for each of them. The keys masks (if any) exclude the padding tokens from
+// the self-attention.
+func (m *Layer) ForwardBatch(xs nn.Batch, keysMasks [][]bool) nn.Batch {
+	selfAttention := func(ys []ag.Node) []ag.Node {
+		attIns := make([]attention.QKV, len(xs))
+		for i, seq := range xs.Split(ys) {
+			attIns[i] = attention.ToQKV(seq)
+			if keysMasks != nil {
+				attIns[i].KeysMask = keysMasks[i]
+			}
+		}
+		return nn.Batch(m.SelfAttention.ForwardBatch(attIns)).Flatten()
+	}
+	selfAtt := m.selfAttentionBlockWith(xs.Flatten(), selfAttention)
+	return xs.Split(m.fullyConnectedBlock(selfAtt))
+}
+
 func (m *Layer) selfAttentionBlock(xs []ag.Node) []ag.Node {
+	return m.selfAttentionBlockWith(xs, func(ys []ag.Node) []ag.Node {
+		return m.SelfAttention.Forward(attention.ToQKV(ys))
+	})
+}
+
+// selfAttentionBlockWith wraps the given self-attention function with the residual
+// connection and the layer normalization.
+func (m *Layer) selfAttentionBlockWith(xs []ag.Node, selfAttention func(xs []ag.Node) []ag.Node) []ag.Node {
 	residual := m.copy(xs)
 	if m.Config.NormalizeBefore {
 		xs = m.SelfAttentionLayerNorm.Forward(xs...)
 	}
-	xs = m.SelfAttention.Forward(attention.ToQKV(xs)) // TODO: key_padding_mask
+	xs = selfAttention(xs)
 	// TODO: xs = m.Dropout(xs) // config.Dropout
-	xs = add(m.Graph(), residual, xs)
 	if !m.Config.NormalizeBefore {
-		xs = m.SelfAttentionLayerNorm.Forward(xs...)
+		return m.SelfAttentionLayerNorm.ForwardWithResidual(xs, residual)
 	}
-	return xs
+	return add(m.Graph(), residual, xs)
 }
 
 func (m *Layer) fullyConnectedBlock(xs []ag.Node) []ag.Node {
@@ -80,11 +105,10 @@ func (m *Layer) fullyConnectedBlock(xs []ag.Node) []ag.Node {
 		xs = m.LayerNorm.Forward(xs...)
 	}
 	xs = m.FFN.Forward(xs...)
-	xs = add(m.Graph(), residual, xs)
 	if !m.Config.NormalizeBefore {
-		xs = m.LayerNorm.Forward(xs...)
+		return m.LayerNorm.ForwardWithResidual(xs, residual)
 	}
-	return xs
+	return add(m.Graph(), residual, xs)
 }
 
 func (m *Layer) copy(xs []ag.Node) []ag.Node {
@@ -25,8 +25,11 @@ type Model struct {
 	Config                      bartconfig.Config
 	Layers                      *stack.Model
 	LearnedPositionalEmbeddings *posembeddings.LearnedPositionalEmbeddings
-	EmbeddingLayerNorm          *layernorm.Model
-	LayerNorm                   *layernorm.Model
+	// SinusoidalPositionalEmbeddings are used in place of the learned ones
+	// when the configuration requires static position embeddings.
+	SinusoidalPositionalEmbeddings *posembeddings.SinusoidalPositionalEmbeddings
+	EmbeddingLayerNorm             *layernorm.Model
+	LayerNorm                      *layernorm.Model
 }
 
 func init() {
@@ -35,22 +38,9 @@ func init() {
 
 // New returns a new BART encoder Model.
 func New(config bartconfig.Config) *Model {
-	if config.StaticPositionEmbeddings {
-		panic("bart: static position embeddings not implemented.")
-	}
-	if config.ScaleEmbedding {
-		panic("bart: scale embedding not implemented.")
-	}
 
-	return &Model{
-		Config: config,
-		LearnedPositionalEmbeddings: posembeddings.NewLearnedPositionalEmbeddings(
-			posembeddings.Config{
-				NumEmbeddings: config.VocabSize,
-				EmbeddingDim:  config.DModel,
-				PaddingIDX:    config.PadTokenID,
-				Offset:        config.ExtraPosEmbedding,
-			}),
+	model := &Model{
+		Config:             config,
 		EmbeddingLayerNorm: layernorm.New(config.DModel),
 		Layers: stack.Make(config.EncoderLayers, func(_ int) nn.StandardModel {
 			return NewLayer(config)
@@ -58,13 +48,31 @@ func New(config bartconfig.Config) *Model {
 		}),
 		LayerNorm: layernorm.New(config.DModel),
 	}
+	if config.StaticPositionEmbeddings {
+		model.SinusoidalPositionalEmbeddings = posembeddings.NewSinusoidalPositionalEmbeddings(
+			posembeddings.Config{
+				NumEmbeddings: config.MaxPositionEmbeddings,
+				EmbeddingDim:  config.DModel,
+				PaddingIDX:    config.PadTokenID,
+			})
+	} else {
+		model.LearnedPositionalEmbeddings = posembeddings.NewLearnedPositionalEmbeddings(
+			posembeddings.Config{
+				NumEmbeddings: config.VocabSize,
+				EmbeddingDim:  config.DModel,
+				PaddingIDX:    config.PadTokenID,
+				Offset:        config.ExtraPosEmbedding,
+			})
+	}
+	return model
 }
 
 // Encode performs the forward step for each input node and returns the result.
 func (m *Model) Encode(xs []ag.Node) []ag.Node {
-	embedPos := m.LearnedPositionalEmbeddings.Encode(utils.MakeIndices(len(xs)))
-	ys := add(m.Graph(), xs, embedPos)
-	ys = m.EmbeddingLayerNorm.Forward(ys...)
+	ys := add(m.Graph(), xs, m.encodePositions(utils.MakeIndices(len(xs))))
+	if m.Config.NormalizeEmbedding {
+		ys = m.EmbeddingLayerNorm.Forward(ys...)
+	}
 	// TODO: ys = m.Dropout(ys)
 
 	ys = m.Layers.Forward(ys...)
@@ -74,6 +82,33 @@ func (m *Model) Encode(xs []ag.Node) []ag.Node {
 	return ys // TODO: return all hidden states?
 }
 
+// EncodeBatch performs the forward step of a batch of independent sequences, and returns
+// the result for each of them. The keys masks (if any) exclude the padding tokens from
+// the self-attention.
+func (m *Model) EncodeBatch(xs nn.Batch, keysMasks [][]bool) nn.Batch {
+	ys := make(nn.Batch, len(xs))
+	for i, seq := range xs {
+		ys[i] = add(m.Graph(), seq, m.encodePositions(utils.MakeIndices(len(seq))))
+	}
+	if m.Config.NormalizeEmbedding {
+		ys = nn.ForwardBatch(m.EmbeddingLayerNorm, ys)
+	}
+	for _, layer := range m.Layers.Layers {
+		ys = layer.(*Layer).ForwardBatch(ys, keysMasks)
+	}
+	if m.Config.FinalLayerNorm {
+		ys = nn.ForwardBatch(m.LayerNorm, ys)
+	}
+	return ys
+}
+
+func (m *Model) encodePositions(positions []int) []ag.Node {
+	if m.Config.StaticPositionEmbeddings {
+		return m.SinusoidalPositionalEmbeddings.Encode(positions)
+	}
+	return m.LearnedPositionalEmbeddings.Encode(positions)
+}
+
 func add(g *ag.Graph, a []ag.Node, b []ag.Node) []ag.Node {
 	c := make([]ag.Node, len(a))
 	for i := 0; i < len(a); i++ {
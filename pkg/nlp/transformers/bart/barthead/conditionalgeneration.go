@@ -0,0 +1,109 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package barthead
+
+import (
+	"encoding/gob"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartconfig"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/generation"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/transformerutils"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"log"
+	"path"
+)
+
+var (
+	_ nn.Model = &ConditionalGeneration{}
+)
+
+// ConditionalGeneration is a model for conditional generation tasks (e.g. summarization
+// and translation) which embeds a BART pre-trained model.
+// The language modeling head is tied to the shared embeddings.
+type ConditionalGeneration struct {
+	nn.BaseModel
+	BART *bart.Model
+	// FinalLogitsBias is added to the logits of the language modeling head.
+	FinalLogitsBias nn.Param `spago:"type:biases"`
+	// LMHead lazily loads the shared embeddings to be used as weights of the
+	// language modeling head. It is never serialized.
+	LMHead *transformerutils.TiedLMHead `spago:"scope:model"`
+}
+
+func init() {
+	gob.Register(&ConditionalGeneration{})
+}
+
+// NewConditionalGeneration returns a new ConditionalGeneration.
+func NewConditionalGeneration(config bartconfig.Config, embeddingsPath string) *ConditionalGeneration {
+	return &ConditionalGeneration{
+		BART:            bart.New(config, embeddingsPath),
+		FinalLogitsBias: nn.NewParam(mat.NewEmptyVecDense(config.VocabSize), nn.RequiresGrad(false)),
+		LMHead:          &transformerutils.TiedLMHead{},
+	}
+}
+
+// Close closes the BART model's embeddings DB.
+func (m *ConditionalGeneration) Close() {
+	m.BART.Close()
+}
+
+// LoadModelForConditionalGeneration loads a ConditionalGeneration model from file.
+func LoadModelForConditionalGeneration(modelPath string) (*ConditionalGeneration, error) {
+	configFilename := path.Join(modelPath, bartconfig.DefaultConfigurationFile)
+	embeddingsPath := path.Join(modelPath, bartconfig.DefaultEmbeddingsStorage)
+	modelFilename := path.Join(modelPath, bartconfig.DefaultModelFile)
+
+	fmt.Printf("Start loading pre-trained model from \"%s\"\n", modelPath)
+	fmt.Printf("[1/2] Loading configuration... ")
+	config, err := bartconfig.Load(configFilename)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("ok\n")
+	model := NewConditionalGeneration(config, embeddingsPath)
+
+	fmt.Printf("[2/2] Loading model weights... ")
+	err = utils.DeserializeFromFile(modelFilename, model)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("bart: error during model deserialization (%s)", err.Error()))
+	}
+	if model.LMHead == nil {
+		model.LMHead = &transformerutils.TiedLMHead{}
+	}
+	fmt.Println("ok")
+
+	return model, nil
+}
+
+// Logits returns the scores over the vocabulary of the given decoder hidden state.
+func (m *ConditionalGeneration) Logits(x ag.Node) ag.Node {
+	g := m.Graph()
+	weights := g.NewVariable(m.LMHead.Weights(m.BART.Embeddings, m.BART.Config.VocabSize), false)
+	return g.Add(g.Mul(weights, x), g.NewWrapNoGrad(m.FinalLogitsBias))
+}
+
+// Generate encodes the input sequence and generates the target sequences according to
+// the given generation configuration. Use bart.NewGenerationConfig to start from the
+// default settings of the model.
+// The processor must operate on a graph with incremental forward (default).
+func (m *ConditionalGeneration) Generate(inputIDs []int, config generation.Config) ([]generation.Hypothesis, error) {
+	return m.GenerateStream(inputIDs, config, nil)
+}
+
+// GenerateStream is the same as Generate, but it also passes each generated token to the
+// given function as soon as it is available (see generation.Generator.GenerateStream).
+func (m *ConditionalGeneration) GenerateStream(inputIDs []int, config generation.Config, fn generation.StreamFunc) ([]generation.Hypothesis, error) {
+	decoder := &bart.Decoder{
+		Model:               m.BART,
+		EncoderHiddenStates: m.BART.EncodeSource(inputIDs),
+		Projection:          m.Logits,
+	}
+	return generation.New(config, decoder, nil).GenerateStream(fn)
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package barthead
+
+import (
+	"encoding/json"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartconfig"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+)
+
+func TestConditionalGeneration_Logits(t *testing.T) {
+	model := newTestConditionalGeneration(newTempDir(t))
+	defer model.Close()
+
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*ConditionalGeneration)
+
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1, 2, 3, 4, 5, 6, 7, 8}), false)
+	logits := proc.Logits(x).Value().Data()
+	require.Len(t, logits, model.BART.Config.VocabSize)
+
+	bias := model.FinalLogitsBias.Value().Data()
+	for i := 0; i < model.BART.Config.VocabSize-1; i++ {
+		embedding := model.BART.Embeddings.GetStoredEmbedding(strconv.Itoa(i)).Value()
+		expected := embedding.DotUnitary(x.Value()) + bias[i]
+		assert.InDelta(t, expected, logits[i], 1.0e-5)
+	}
+	// the last token has no embedding: its weights are zero
+	assert.InDelta(t, bias[len(bias)-1], logits[len(logits)-1], 1.0e-6)
+}
+
+func TestConditionalGeneration_Generate(t *testing.T) {
+	model := newTestConditionalGeneration(newTempDir(t))
+	defer model.Close()
+
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*ConditionalGeneration)
+
+	config := bart.NewGenerationConfig(model.BART.Config)
+	config.NumBeams = 1 // greedy search streams a single sequence
+	var streamed []int
+	hyps, err := proc.GenerateStream([]int{0, 3, 4, 2}, config, func(_, tokenID int) {
+		streamed = append(streamed, tokenID)
+	})
+	require.NoError(t, err)
+	require.Len(t, hyps, 1)
+	best := hyps[0].TokenIDs
+	assert.Equal(t, model.BART.Config.DecoderStartTokenID, best[0])
+	assert.LessOrEqual(t, len(best), config.MaxLength)
+	assert.Equal(t, best[1:], streamed)
+}
+
+func TestLoadModelForConditionalGeneration(t *testing.T) {
+	dir := newTempDir(t)
+	model := newTestConditionalGeneration(path.Join(dir, bartconfig.DefaultEmbeddingsStorage))
+	config := model.BART.Config
+	config.Training = false
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, bartconfig.DefaultConfigurationFile), data, 0644))
+	require.NoError(t, utils.SerializeToFile(path.Join(dir, bartconfig.DefaultModelFile), model))
+	bias := model.FinalLogitsBias.Value().Clone()
+	model.Close()
+
+	loaded, err := LoadModelForConditionalGeneration(dir)
+	require.NoError(t, err)
+	defer loaded.Close()
+	assert.Equal(t, bias.Data(), loaded.FinalLogitsBias.Value().Data())
+	require.NotNil(t, loaded.LMHead) // never serialized
+	weights := loaded.LMHead.Weights(loaded.BART.Embeddings, config.VocabSize)
+	rows, cols := weights.Dims()
+	assert.Equal(t, config.VocabSize, rows)
+	assert.Equal(t, config.DModel, cols)
+}
+
+func newTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "spago-bart-")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+// newTestConditionalGeneration returns a small model with random weights, whose
+// embeddings are stored in the given folder. The last token has no embedding.
+func newTestConditionalGeneration(embeddingsPath string) *ConditionalGeneration {
+	config := bartconfig.Config{
+		ActivationFunction:    "gelu",
+		Architecture:          []string{"BartForConditionalGeneration"},
+		BosTokenID:            0,
+		PadTokenID:            1,
+		EosTokenID:            2,
+		DecoderStartTokenID:   2,
+		DModel:                8,
+		EncoderLayers:         1,
+		DecoderLayers:         1,
+		EncoderAttentionHeads: 2,
+		DecoderAttentionHeads: 2,
+		EncoderFFNDim:         16,
+		DecoderFFNDim:         16,
+		ExtraPosEmbedding:     2,
+		MaxPositionEmbeddings: 8,
+		MaxLength:             5,
+		NumBeams:              2,
+		NormalizeEmbedding:    true,
+		VocabSize:             6,
+		Training:              true,
+	}
+	model := NewConditionalGeneration(config, embeddingsPath)
+	rndGen := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		initializers.Normal(param.Value(), 0, 0.5, rndGen)
+	})
+	for i := 0; i < config.VocabSize-1; i++ {
+		embedding := mat.NewEmptyVecDense(config.DModel)
+		initializers.Normal(embedding, 0, 0.5, rndGen)
+		model.BART.Embeddings.SetEmbedding(strconv.Itoa(i), embedding)
+	}
+	return model
+}
@@ -82,3 +82,20 @@ func (m *SequenceClassification) Classify(in interface{}) ag.Node {
 	sentenceRepresentation := transformed[len(transformed)-1]
 	return nn.ToNode(m.Classification.Forward(sentenceRepresentation))
 }
+
+// ClassifyBatch performs the classification of a batch of independent sequences of
+// token IDs, sharing the same forward step, and returns the logits for each of them.
+// The sequences can have different lengths; the trailing padding tokens (if any) are
+// skipped to take the representation of the last token of each sequence.
+func (m *SequenceClassification) ClassifyBatch(inputIDs [][]int) []ag.Node {
+	transformed := m.BART.EncodeBatch(inputIDs)
+	logits := make([]ag.Node, len(inputIDs))
+	for i, ids := range inputIDs {
+		last := len(ids) - 1
+		for last > 0 && ids[last] == m.BART.Config.PadTokenID {
+			last--
+		}
+		logits[i] = nn.ToNode(m.Classification.Forward(transformed[i][last]))
+	}
+	return logits
+}
@@ -21,6 +21,9 @@ type BARTClient interface {
 	Classify(ctx context.Context, in *ClassifyRequest, opts ...grpc.CallOption) (*ClassifyReply, error)
 	// Sends a request to classify-nli.
 	ClassifyNLI(ctx context.Context, in *ClassifyNLIRequest, opts ...grpc.CallOption) (*ClassifyReply, error)
+	// Sends a request to generate text (e.g. summarization or translation).
+	// The generated tokens are streamed as soon as they are available.
+	Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (BART_GenerateClient, error)
 }
 
 type bARTClient struct {
@@ -49,6 +52,38 @@ func (c *bARTClient) ClassifyNLI(ctx context.Context, in *ClassifyNLIRequest, op
 	return out, nil
 }
 
+func (c *bARTClient) Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (BART_GenerateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_BART_serviceDesc.Streams[0], "/bart.grpcapi.BART/Generate", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bARTGenerateClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BART_GenerateClient interface {
+	Recv() (*GenerateReply, error)
+	grpc.ClientStream
+}
+
+type bARTGenerateClient struct {
+	grpc.ClientStream
+}
+
+func (x *bARTGenerateClient) Recv() (*GenerateReply, error) {
+	m := new(GenerateReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // BARTServer is the server API for BART service.
 // All implementations must embed UnimplementedBARTServer
 // for forward compatibility
@@ -57,6 +92,9 @@ type BARTServer interface {
 	Classify(context.Context, *ClassifyRequest) (*ClassifyReply, error)
 	// Sends a request to classify-nli.
 	ClassifyNLI(context.Context, *ClassifyNLIRequest) (*ClassifyReply, error)
+	// Sends a request to generate text (e.g. summarization or translation).
+	// The generated tokens are streamed as soon as they are available.
+	Generate(*GenerateRequest, BART_GenerateServer) error
 	mustEmbedUnimplementedBARTServer()
 }
 
@@ -70,6 +108,9 @@ func (UnimplementedBARTServer) Classify(context.Context, *ClassifyRequest) (*Cla
 func (UnimplementedBARTServer) ClassifyNLI(context.Context, *ClassifyNLIRequest) (*ClassifyReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ClassifyNLI not implemented")
 }
+func (UnimplementedBARTServer) Generate(*GenerateRequest, BART_GenerateServer) error {
+	return status.Errorf(codes.Unimplemented, "method Generate not implemented")
+}
 func (UnimplementedBARTServer) mustEmbedUnimplementedBARTServer() {}
 
 // UnsafeBARTServer may be embedded to opt out of forward compatibility for this service.
@@ -119,6 +160,27 @@ func _BART_ClassifyNLI_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BART_Generate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GenerateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BARTServer).Generate(m, &bARTGenerateServer{stream})
+}
+
+type BART_GenerateServer interface {
+	Send(*GenerateReply) error
+	grpc.ServerStream
+}
+
+type bARTGenerateServer struct {
+	grpc.ServerStream
+}
+
+func (x *bARTGenerateServer) Send(m *GenerateReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _BART_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "bart.grpcapi.BART",
 	HandlerType: (*BARTServer)(nil),
@@ -132,6 +194,12 @@ var _BART_serviceDesc = grpc.ServiceDesc{
 			Handler:    _BART_ClassifyNLI_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Generate",
+			Handler:       _BART_Generate_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "bart.proto",
 }
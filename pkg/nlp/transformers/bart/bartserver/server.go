@@ -9,11 +9,11 @@ import (
 	"context"
 	"encoding/json"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
-	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/bpetokenizer"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/barthead"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartserver/grpcapi"
 	"github.com/nlpodyssey/spago/pkg/utils/grpcutils"
 	"github.com/nlpodyssey/spago/pkg/utils/httputils"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 	"github.com/nlpodyssey/spago/pkg/webui/bartnli"
 	"net/http"
 )
@@ -21,21 +21,38 @@ import (
 // ServerForSequenceClassification contains everything needed to run a BART server.
 type ServerForSequenceClassification struct {
 	model     *barthead.SequenceClassification
-	tokenizer *bpetokenizer.BPETokenizer
+	tokenizer Tokenizer
+	batching  microbatching.Config
+	scheduler *microbatching.Scheduler
 
 	// UnimplementedBARTServer must be embedded to have forward compatible implementations for gRPC.
 	grpcapi.UnimplementedBARTServer
 }
 
 // NewServer returns a new ServerForSequenceClassification.
+// The requests are processed in batches by a micro-batching scheduler, shared
+// by the HTTP and gRPC handlers (see WithBatching).
 func NewServer(
 	model *barthead.SequenceClassification,
-	tokenizer *bpetokenizer.BPETokenizer,
+	tokenizer Tokenizer,
+	opts ...ServerOption,
 ) *ServerForSequenceClassification {
-	return &ServerForSequenceClassification{
+	s := &ServerForSequenceClassification{
 		model:     model,
 		tokenizer: tokenizer,
+		batching:  microbatching.DefaultConfig(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.scheduler = microbatching.New(s.batching, s.processBatch)
+	return s
+}
+
+// Close stops the micro-batching scheduler: the pending and the following
+// requests fail with microbatching.ErrClosed.
+func (s *ServerForSequenceClassification) Close() {
+	s.scheduler.Close()
 }
 
 // StartDefaultServer is used to start a basic BART gRPC server.
@@ -57,21 +74,25 @@ func (s *ServerForSequenceClassification) StartDefaultHTTPServer(address, tlsCer
 }
 
 // Classify handles a classification request over gRPC.
-func (s *ServerForSequenceClassification) Classify(_ context.Context, req *grpcapi.ClassifyRequest) (*grpcapi.ClassifyReply, error) {
-	result := s.classify(req.GetText(), req.GetText2())
+func (s *ServerForSequenceClassification) Classify(ctx context.Context, req *grpcapi.ClassifyRequest) (*grpcapi.ClassifyReply, error) {
+	result, err := s.classify(ctx, req.GetText(), req.GetText2())
+	if err != nil {
+		return nil, microbatching.GRPCError(err)
+	}
 	return classificationFrom(result), nil
 }
 
 // ClassifyNLI handles a zero-shot classification request over gRPC.
-func (s *ServerForSequenceClassification) ClassifyNLI(_ context.Context, req *grpcapi.ClassifyNLIRequest) (*grpcapi.ClassifyReply, error) {
+func (s *ServerForSequenceClassification) ClassifyNLI(ctx context.Context, req *grpcapi.ClassifyNLIRequest) (*grpcapi.ClassifyReply, error) {
 	result, err := s.classifyNLI(
+		ctx,
 		req.GetText(),
 		req.GetHypothesisTemplate(),
 		req.GetPossibleLabels(),
 		req.MultiClass,
 	)
 	if err != nil {
-		return nil, err
+		return nil, microbatching.GRPCError(err)
 	}
 	return classificationFrom(result), nil
 }
@@ -97,7 +118,11 @@ func (s *ServerForSequenceClassification) ClassifyHandler(w http.ResponseWriter,
 		return
 	}
 
-	result := s.classify(content.Text, content.Text2)
+	result, err := s.classify(req.Context(), content.Text, content.Text2)
+	if err != nil {
+		http.Error(w, err.Error(), microbatching.HTTPStatusCode(err))
+		return
+	}
 	_, pretty := req.URL.Query()["pretty"]
 	response, err := Dump(result, pretty)
 	if err != nil {
@@ -125,13 +150,14 @@ func (s *ServerForSequenceClassification) ClassifyNLIHandler(w http.ResponseWrit
 	}
 
 	result, err := s.classifyNLI(
+		req.Context(),
 		content.Text,
 		content.HypothesisTemplate,
 		content.PossibleLabels,
 		content.MultiClass,
 	)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), microbatching.HTTPStatusCode(err))
 		return
 	}
 
@@ -186,7 +212,7 @@ const (
 	defaultEndSequenceTokenID   = 2
 )
 
-func getInputIDs(tokenizer *bpetokenizer.BPETokenizer, text, text2 string) []int {
+func getInputIDs(tokenizer Tokenizer, text, text2 string) []int {
 	encoded, _ := tokenizer.Encode(text) // TODO: error handling
 	inputIds := append(append([]int{defaultStartSequenceTokenID}, encoded.IDs...), defaultEndSequenceTokenID)
 	if text2 != "" {
@@ -0,0 +1,64 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bartserver
+
+import (
+	"context"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/barthead"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
+	"runtime"
+)
+
+// ServerOption allows to configure a new ServerForSequenceClassification with your specific needs.
+type ServerOption func(*ServerForSequenceClassification)
+
+// WithBatching sets the configuration of the micro-batching scheduler shared by the
+// HTTP and gRPC handlers. The default is microbatching.DefaultConfig().
+func WithBatching(config microbatching.Config) ServerOption {
+	return func(s *ServerForSequenceClassification) {
+		s.batching = config
+	}
+}
+
+// submitClassify classifies the sequences of token IDs within a batch, together with
+// the ones of the other requests, and returns the logits of each sequence.
+// It fails with microbatching.ErrQueueFull when the server is overloaded.
+func (s *ServerForSequenceClassification) submitClassify(ctx context.Context, inputIDs ...[]int) ([]*mat.Dense, error) {
+	result, err := s.scheduler.Submit(ctx, inputIDs)
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*mat.Dense), nil
+}
+
+// processBatch is the microbatching.ProcessFunc of the ServerForSequenceClassification.
+// Each input is a group of sequences of token IDs (see submitClassify).
+func (s *ServerForSequenceClassification) processBatch(inputs []interface{}) ([]interface{}, []error) {
+	var sequences [][]int
+	for _, input := range inputs {
+		sequences = append(sequences, input.([][]int)...)
+	}
+
+	g := ag.NewGraph(ag.IncrementalForward(false), ag.ConcurrentComputations(runtime.NumCPU()), ag.FusedOperators(true))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*barthead.SequenceClassification)
+	logits := proc.ClassifyBatch(sequences)
+	g.Forward()
+
+	outputs := make([]interface{}, len(inputs))
+	offset := 0
+	for i, input := range inputs {
+		group := make([]*mat.Dense, len(input.([][]int)))
+		for j := range group {
+			group[j] = g.GetCopiedValue(logits[offset+j]).(*mat.Dense)
+		}
+		outputs[i] = group
+		offset += len(group)
+	}
+	return outputs, nil
+}
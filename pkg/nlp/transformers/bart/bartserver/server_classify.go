@@ -5,27 +5,23 @@
 package bartserver
 
 import (
+	"context"
 	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
-	"github.com/nlpodyssey/spago/pkg/ml/ag"
-	"github.com/nlpodyssey/spago/pkg/ml/nn"
-	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/barthead"
-	"runtime"
 	"sort"
 	"strconv"
 	"time"
 )
 
-func (s *ServerForSequenceClassification) classify(text string, text2 string) *ClassifyResponse {
+func (s *ServerForSequenceClassification) classify(ctx context.Context, text string, text2 string) (*ClassifyResponse, error) {
 	start := time.Now()
 
-	g := ag.NewGraph(ag.IncrementalForward(false), ag.ConcurrentComputations(runtime.NumCPU()))
-	defer g.Clear()
-	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*barthead.SequenceClassification)
 	inputIds := getInputIDs(s.tokenizer, text, text2)
-	logits := proc.Classify(inputIds)
-	g.Forward()
+	logits, err := s.submitClassify(ctx, inputIds)
+	if err != nil {
+		return nil, err
+	}
 
-	probs := floatutils.SoftMax(g.GetCopiedValue(logits).Data())
+	probs := floatutils.SoftMax(logits[0].Data())
 	best := floatutils.ArgMax(probs)
 	classes := s.model.BART.Config.ID2Label
 	class := classes[strconv.Itoa(best)]
@@ -47,5 +43,5 @@ func (s *ServerForSequenceClassification) classify(text string, text2 string) *C
 		Confidence:   probs[best],
 		Distribution: distribution,
 		Took:         time.Since(start).Milliseconds(),
-	}
+	}, nil
 }
@@ -5,30 +5,19 @@
 package bartserver
 
 import (
+	"context"
 	"fmt"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
-	"github.com/nlpodyssey/spago/pkg/ml/ag"
-	"github.com/nlpodyssey/spago/pkg/ml/nn"
-	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/bpetokenizer"
-	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/barthead"
-	"github.com/nlpodyssey/spago/pkg/utils/workerpool"
-	"runtime"
 	"sort"
 	"strings"
-	"sync"
 	"time"
 )
 
-type premiseHypothesisPair struct {
-	index      int
-	premise    string
-	hypothesis string
-}
-
 const defaultHypothesisTemplate = "This text is about {}."
 
 func (s *ServerForSequenceClassification) classifyNLI(
+	ctx context.Context,
 	text string,
 	hypothesisTemplate string,
 	candidateLabels []string,
@@ -46,27 +35,20 @@ func (s *ServerForSequenceClassification) classifyNLI(
 	}
 
 	numOfCandidateLabels := len(candidateLabels)
-	logits := make([]*mat.Dense, numOfCandidateLabels)
-
-	numWorkers := runtime.NumCPU() / 2 // leave some space for other concurrent computations
-	wp := workerpool.New(numWorkers)
-	workers := s.newWorkers(numWorkers)
-	wg := sync.WaitGroup{}
-	go wp.Run(func(workerID int, jobData interface{}) {
-		data := jobData.(premiseHypothesisPair)
-		logits[data.index] = workers[workerID].process(data)
-		wg.Done()
-	})
+	if numOfCandidateLabels == 0 {
+		return nil, fmt.Errorf("bartserver: no candidate labels")
+	}
 
+	// all the premise-hypothesis pairs are classified within the same batch
+	inputIDs := make([][]int, numOfCandidateLabels)
 	for i, label := range candidateLabels {
-		wg.Add(1)
-		wp.PublishJobData(premiseHypothesisPair{
-			index:      i,
-			premise:    text,
-			hypothesis: strings.Replace(hypothesisTemplate, "{}", label, -1),
-		})
+		hypothesis := strings.Replace(hypothesisTemplate, "{}", label, -1)
+		inputIDs[i] = getInputIDs(s.tokenizer, text, hypothesis)
+	}
+	logits, err := s.submitClassify(ctx, inputIDs...)
+	if err != nil {
+		return nil, err
 	}
-	wg.Wait()
 
 	if numOfCandidateLabels == 1 {
 		multiClass = true
@@ -134,29 +116,3 @@ func (s *ServerForSequenceClassification) getEntailmentAndContradictionIDs() (
 	}
 	return
 }
-
-func (s *ServerForSequenceClassification) newWorkers(workersSize int) []*worker {
-	workers := make([]*worker, workersSize)
-	for i := range workers {
-		workers[i] = &worker{
-			tokenizer: s.tokenizer,
-			model:     s.model,
-		}
-	}
-	return workers
-}
-
-type worker struct {
-	tokenizer *bpetokenizer.BPETokenizer
-	model     *barthead.SequenceClassification
-}
-
-func (w *worker) process(input premiseHypothesisPair) *mat.Dense {
-	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()), ag.IncrementalForward(false))
-	defer g.Clear()
-	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, w.model).(*barthead.SequenceClassification)
-	inputIds := getInputIDs(w.tokenizer, input.premise, input.hypothesis)
-	logits := proc.Classify(inputIds)
-	g.Forward()
-	return g.GetCopiedValue(logits).(*mat.Dense)
-}
@@ -0,0 +1,370 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bartserver
+
+import (
+	"encoding/json"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/barthead"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartserver/grpcapi"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/generation"
+	"github.com/nlpodyssey/spago/pkg/utils/grpcutils"
+	"github.com/nlpodyssey/spago/pkg/utils/httputils"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ServerForConditionalGeneration contains everything needed to run a BART server
+// for conditional generation (e.g. summarization or translation).
+type ServerForConditionalGeneration struct {
+	model     *barthead.ConditionalGeneration
+	tokenizer Tokenizer
+
+	// UnimplementedBARTServer must be embedded to have forward compatible implementations for gRPC.
+	grpcapi.UnimplementedBARTServer
+}
+
+// NewServerForConditionalGeneration returns a new ServerForConditionalGeneration.
+func NewServerForConditionalGeneration(
+	model *barthead.ConditionalGeneration,
+	tokenizer Tokenizer,
+) *ServerForConditionalGeneration {
+	return &ServerForConditionalGeneration{
+		model:     model,
+		tokenizer: tokenizer,
+	}
+}
+
+// StartDefaultServer is used to start a basic BART gRPC server.
+func (s *ServerForConditionalGeneration) StartDefaultServer(grpcAddress, tlsCert, tlsKey string, tlsDisable bool) {
+	grpcServer := grpcutils.NewGRPCServer(tlsDisable, tlsCert, tlsKey)
+	grpcapi.RegisterBARTServer(grpcServer, s)
+	grpcutils.RunGRPCServer(grpcAddress, grpcServer)
+}
+
+// StartDefaultHTTPServer is used to start a basic BART HTTP server.
+// If you want more control of the HTTP server you can run your own
+// HTTP router using the public handler functions
+func (s *ServerForConditionalGeneration) StartDefaultHTTPServer(address, tlsCert, tlsKey string, tlsDisable bool) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/generate", s.GenerateHandler)
+	go httputils.RunHTTPServer(address, tlsDisable, tlsCert, tlsKey, mux)
+}
+
+// Generate handles a generation request over gRPC, streaming the generated tokens.
+func (s *ServerForConditionalGeneration) Generate(req *grpcapi.GenerateRequest, stream grpcapi.BART_GenerateServer) error {
+	options := GenerateOptions{
+		MaxLength:          int(req.GetMaxLength()),
+		MinLength:          int(req.GetMinLength()),
+		NumBeams:           int(req.GetNumBeams()),
+		NumReturnSequences: int(req.GetNumReturnSequences()),
+		DoSample:           req.GetDoSample(),
+		Temperature:        mat.Float(req.GetTemperature()),
+		TopK:               int(req.GetTopK()),
+		TopP:               mat.Float(req.GetTopP()),
+		LengthPenalty:      mat.Float(req.GetLengthPenalty()),
+		EarlyStopping:      req.GetEarlyStopping(),
+		NoRepeatNGramSize:  int(req.GetNoRepeatNgramSize()),
+	}
+	_, err := s.generate(req.GetText(), options, func(chunk GenerateChunk) error {
+		return stream.Send(&grpcapi.GenerateReply{
+			Sequence: int32(chunk.Sequence),
+			Text:     chunk.Text,
+			Finished: chunk.Finished,
+			Score:    float64(chunk.Score),
+			Took:     chunk.Took,
+		})
+	})
+	return err
+}
+
+// GenerateOptions are the decoding options of a generation request, which mirror the
+// arguments of the Hugging Face generate() method. The zero values (false for the
+// booleans) leave the default settings of the model unchanged.
+type GenerateOptions struct {
+	MaxLength          int       `json:"max_length"`
+	MinLength          int       `json:"min_length"`
+	NumBeams           int       `json:"num_beams"`
+	NumReturnSequences int       `json:"num_return_sequences"`
+	DoSample           bool      `json:"do_sample"`
+	Temperature        mat.Float `json:"temperature"`
+	TopK               int       `json:"top_k"`
+	TopP               mat.Float `json:"top_p"`
+	LengthPenalty      mat.Float `json:"length_penalty"`
+	EarlyStopping      bool      `json:"early_stopping"`
+	NoRepeatNGramSize  int       `json:"no_repeat_ngram_size"`
+}
+
+type generateBody struct {
+	Text string `json:"text"`
+	GenerateOptions
+}
+
+// GeneratedSequence is a JSON-serializable generated text with its score.
+type GeneratedSequence struct {
+	Text  string    `json:"text"`
+	Score mat.Float `json:"score"`
+}
+
+// GenerateResponse is a JSON-serializable structure which holds server
+// generation response data.
+type GenerateResponse struct {
+	// Sequences are sorted by score, from the best one.
+	Sequences []GeneratedSequence `json:"sequences"`
+	// Took is the number of milliseconds it took the server to execute the request.
+	Took int64 `json:"took"`
+}
+
+// GenerateChunk is a JSON-serializable piece of a streamed generation response.
+// While decoding, each chunk carries the text of a new token of a sequence. At the
+// end, one chunk with Finished set to true is sent for each generated sequence,
+// from the best one, carrying its whole text. The Sequence index refers to the
+// order of generation while streaming, and to the rank of the finished sequences.
+type GenerateChunk struct {
+	Sequence int       `json:"sequence"`
+	Text     string    `json:"text"`
+	Finished bool      `json:"finished"`
+	Score    mat.Float `json:"score"`
+	// Took is the number of milliseconds it took the server to execute the request.
+	Took int64 `json:"took"`
+	// Error is set by the last chunk of a stream interrupted by an error.
+	Error string `json:"error,omitempty"`
+}
+
+// GenerateHandler handles a generate request over HTTP.
+// If the "stream" query parameter is present, the response is streamed as a
+// sequence of newline-delimited GenerateChunk JSON objects. An error occurring
+// after the first chunk is reported by a last chunk with the Error field set.
+func (s *ServerForConditionalGeneration) GenerateHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
+	w.Header().Set("Content-Type", "application/json")
+
+	var content generateBody
+	err := json.NewDecoder(req.Body).Decode(&content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, pretty := req.URL.Query()["pretty"]
+	_, stream := req.URL.Query()["stream"]
+
+	var fn func(chunk GenerateChunk) error
+	streaming := false
+	if stream {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		flusher, _ := w.(http.Flusher)
+		fn = func(chunk GenerateChunk) error {
+			streaming = true
+			response, err := Dump(chunk, false)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(response); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return nil
+		}
+	}
+
+	result, err := s.generate(content.Text, content.GenerateOptions, fn)
+	if err != nil && streaming {
+		// the status code has already been sent
+		_ = fn(GenerateChunk{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if stream {
+		return
+	}
+
+	response, err := Dump(result, pretty)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// generationConfig returns the generation configuration of the model updated with the given options.
+func (s *ServerForConditionalGeneration) generationConfig(options GenerateOptions) generation.Config {
+	config := bart.NewGenerationConfig(s.model.BART.Config)
+	if options.MaxLength > 0 {
+		config.MaxLength = options.MaxLength
+	}
+	if options.MinLength > 0 {
+		config.MinLength = options.MinLength
+	}
+	if options.NumBeams > 0 {
+		config.NumBeams = options.NumBeams
+	}
+	if options.NumReturnSequences > 0 {
+		config.NumReturnSequences = options.NumReturnSequences
+	}
+	if options.DoSample {
+		config.DoSample = true
+	}
+	if options.Temperature > 0 {
+		config.Temperature = options.Temperature
+	}
+	if options.TopK > 0 {
+		config.TopK = options.TopK
+	}
+	if options.TopP > 0 {
+		config.TopP = options.TopP
+	}
+	if options.LengthPenalty != 0 {
+		config.LengthPenalty = options.LengthPenalty
+	}
+	if options.EarlyStopping {
+		config.EarlyStopping = true
+	}
+	if options.NoRepeatNGramSize > 0 {
+		config.NoRepeatNGramSize = options.NoRepeatNGramSize
+	}
+	return config
+}
+
+// generate generates the target sequences of the given text. If fn is not nil, the text of
+// each new token is passed to it as soon as it is available, followed by the whole text of
+// the generated sequences. The streaming stops at the first error returned by fn.
+func (s *ServerForConditionalGeneration) generate(text string, options GenerateOptions, fn func(chunk GenerateChunk) error) (*GenerateResponse, error) {
+	start := time.Now()
+
+	config := s.generationConfig(options)
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()), ag.FusedOperators(true))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*barthead.ConditionalGeneration)
+	inputIDs, err := s.inputIDs(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var streamErr error
+	send := func(chunk GenerateChunk) {
+		if fn == nil || streamErr != nil {
+			return
+		}
+		chunk.Took = time.Since(start).Milliseconds()
+		streamErr = fn(chunk)
+	}
+
+	var onToken generation.StreamFunc
+	if fn != nil {
+		streamed := make([]*textStream, config.NumReturnSequences)
+		onToken = func(sequence, tokenID int) {
+			if streamed[sequence] == nil {
+				streamed[sequence] = &textStream{decode: s.decodeIDs}
+			}
+			if delta := streamed[sequence].add(tokenID); delta != "" {
+				send(GenerateChunk{Sequence: sequence, Text: delta})
+			}
+		}
+	}
+
+	hyps, err := proc.GenerateStream(inputIDs, config, onToken)
+	if err != nil {
+		return nil, err
+	}
+
+	sequences := make([]GeneratedSequence, len(hyps))
+	for i, hyp := range hyps {
+		sequences[i] = GeneratedSequence{
+			Text:  s.decode(hyp.TokenIDs),
+			Score: hyp.Score,
+		}
+		send(GenerateChunk{Sequence: i, Text: sequences[i].Text, Finished: true, Score: hyp.Score})
+	}
+	if streamErr != nil {
+		return nil, streamErr
+	}
+
+	return &GenerateResponse{
+		Sequences: sequences,
+		Took:      time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// inputIDs returns the token IDs of the source text, followed by the end of sequence
+// token and, except for Marian, preceded by the beginning of sequence token.
+func (s *ServerForConditionalGeneration) inputIDs(text string) ([]int, error) {
+	encoded, err := s.tokenizer.Encode(text)
+	if err != nil {
+		return nil, err
+	}
+	config := s.model.BART.Config
+	ids := append(encoded.IDs, config.EosTokenID)
+	if config.ModelType == "marian" {
+		return ids, nil
+	}
+	return append([]int{config.BosTokenID}, ids...), nil
+}
+
+// decode converts the token IDs into text, skipping the special tokens.
+func (s *ServerForConditionalGeneration) decode(tokenIDs []int) string {
+	return strings.TrimSpace(s.decodeIDs(tokenIDs))
+}
+
+// decodeIDs converts the token IDs into text, skipping the special tokens,
+// without trimming the whitespaces.
+func (s *ServerForConditionalGeneration) decodeIDs(tokenIDs []int) string {
+	config := s.model.BART.Config
+	ids := make([]int, 0, len(tokenIDs))
+	for _, id := range tokenIDs {
+		switch id {
+		case config.BosTokenID, config.EosTokenID, config.PadTokenID, config.DecoderStartTokenID:
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return s.tokenizer.Decode(ids)
+}
+
+// textStream turns a sequence of tokens into a sequence of text deltas, decoding each
+// token once. The tokens of the UTF-8 characters split across byte-level tokens are
+// held back until the characters are complete.
+type textStream struct {
+	decode  func(tokenIDs []int) string
+	pending []int
+	started bool
+}
+
+// add appends the token to the sequence and returns the new text, if any.
+// The leading whitespaces of the sequence are trimmed.
+func (t *textStream) add(tokenID int) string {
+	t.pending = append(t.pending, tokenID)
+	text := t.decode(t.pending)
+	if !utf8.ValidString(text) && len(t.pending) < utf8.UTFMax {
+		return ""
+	}
+	t.pending = t.pending[:0]
+	if !t.started {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		t.started = text != ""
+	}
+	return text
+}
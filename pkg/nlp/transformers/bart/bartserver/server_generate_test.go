@@ -0,0 +1,43 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bartserver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTextStream(t *testing.T) {
+	// the byte-level tokens of "é" (0xC3 0xA9) are decoded separately
+	tokens := map[int]string{0: " caf", 1: "\xc3", 2: "\xa9", 3: " au", 4: " lait"}
+	var decoded [][]int
+	stream := &textStream{decode: func(tokenIDs []int) string {
+		decoded = append(decoded, append([]int(nil), tokenIDs...))
+		text := ""
+		for _, id := range tokenIDs {
+			text += tokens[id]
+		}
+		return text
+	}}
+
+	var deltas []string
+	for id := 0; id < len(tokens); id++ {
+		deltas = append(deltas, stream.add(id))
+	}
+	assert.Equal(t, []string{"caf", "", "é", " au", " lait"}, deltas)
+	// each token is decoded once, along with the held back ones
+	assert.Equal(t, [][]int{{0}, {1}, {1, 2}, {3}, {4}}, decoded)
+}
+
+func TestTextStream_InvalidUTF8(t *testing.T) {
+	stream := &textStream{decode: func(tokenIDs []int) string {
+		return "\xff"
+	}}
+	assert.Equal(t, "", stream.add(0))
+	assert.Equal(t, "", stream.add(1))
+	assert.Equal(t, "", stream.add(2))
+	assert.Equal(t, "\xff", stream.add(3)) // released after utf8.UTFMax tokens
+	assert.Empty(t, stream.pending)
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bartserver
+
+import (
+	"fmt"
+	"github.com/nlpodyssey/gotokenizers/encodings"
+	"github.com/nlpodyssey/gotokenizers/vocabulary"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/bpetokenizer"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/sentencepiece"
+	"os"
+	"path"
+	"strings"
+)
+
+// Tokenizer converts the texts into the token IDs of the vocabulary of the model,
+// and back. The special tokens are added by the servers.
+// It is implemented by the byte-level bpetokenizer.BPETokenizer of BART and by the
+// SentencePiece tokenizer of Marian (see LoadTokenizer).
+type Tokenizer interface {
+	Encode(text string) (*encodings.Encoding, error)
+	Decode(ids []int) string
+}
+
+const (
+	defaultVocabularyFile      = "vocab.json"
+	defaultMergesFile          = "merges.txt"
+	defaultSourceSentencePiece = "source.spm"
+	defaultUnknownToken        = "<unk>"
+	sentencePieceWhitespace    = "▁"
+)
+
+// LoadTokenizer returns the tokenizer of the model, according to the files found in
+// the model folder: the byte-level BPE ("vocab.json" and "merges.txt") of BART, or
+// the SentencePiece model of the source language ("source.spm") of Marian, whose
+// pieces are mapped to the IDs of "vocab.json".
+func LoadTokenizer(modelPath string) (Tokenizer, error) {
+	if fileExists(path.Join(modelPath, defaultMergesFile)) {
+		tokenizer, err := bpetokenizer.NewFromModelFolder(modelPath)
+		if err != nil {
+			return nil, err
+		}
+		return tokenizer, nil
+	}
+	if filename := path.Join(modelPath, defaultSourceSentencePiece); fileExists(filename) {
+		sp, err := sentencepiece.NewFromFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		vocab, err := vocabulary.FromJSONFile(path.Join(modelPath, defaultVocabularyFile))
+		if err != nil {
+			return nil, err
+		}
+		return NewSentencePieceTokenizer(sp, vocab), nil
+	}
+	return nil, fmt.Errorf("bartserver: no tokenizer found in `%s`", modelPath)
+}
+
+// SentencePieceTokenizer is a Tokenizer splitting the texts with a SentencePiece model,
+// whose pieces are mapped to the IDs of a distinct vocabulary (e.g. the one shared by the
+// source and the target languages of Marian).
+type SentencePieceTokenizer struct {
+	sp    *sentencepiece.Tokenizer
+	vocab *vocabulary.Vocabulary
+}
+
+// NewSentencePieceTokenizer returns a new SentencePieceTokenizer.
+func NewSentencePieceTokenizer(sp *sentencepiece.Tokenizer, vocab *vocabulary.Vocabulary) *SentencePieceTokenizer {
+	return &SentencePieceTokenizer{
+		sp:    sp,
+		vocab: vocab,
+	}
+}
+
+// Encode splits the text into pieces. The pieces missing in the vocabulary are
+// replaced by the unknown token.
+func (t *SentencePieceTokenizer) Encode(text string) (*encodings.Encoding, error) {
+	unknownID, ok := t.vocab.GetID(defaultUnknownToken)
+	if !ok {
+		return nil, fmt.Errorf("bartserver: unknown token `%s` not found in the vocabulary", defaultUnknownToken)
+	}
+	tokens := t.sp.Tokenize(text)
+	encoding := &encodings.Encoding{
+		IDs:    make([]int, len(tokens)),
+		Tokens: make([]string, len(tokens)),
+	}
+	for i, token := range tokens {
+		id, ok := t.vocab.GetID(token.String)
+		if !ok {
+			id = unknownID
+		}
+		encoding.IDs[i] = id
+		encoding.Tokens[i] = token.String
+	}
+	return encoding, nil
+}
+
+// Decode converts the token IDs back into text, restoring the whitespaces.
+// The IDs which are not in the vocabulary are ignored.
+func (t *SentencePieceTokenizer) Decode(ids []int) string {
+	var sb strings.Builder
+	for _, id := range ids {
+		if piece, ok := t.vocab.GetString(id); ok {
+			sb.WriteString(piece)
+		}
+	}
+	return strings.ReplaceAll(sb.String(), sentencePieceWhitespace, " ")
+}
+
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bartserver
+
+import (
+	"github.com/nlpodyssey/gotokenizers/vocabulary"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/sentencepiece"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func newTestSentencePieceTokenizer() *SentencePieceTokenizer {
+	sp := sentencepiece.New(&sentencepiece.Model{
+		Pieces: []sentencepiece.Piece{
+			{Piece: "<unk>", Type: sentencepiece.Unknown},
+			{Piece: "</s>", Type: sentencepiece.Control},
+			{Piece: "▁the", Score: -1, Type: sentencepiece.Normal},
+			{Piece: "▁cat", Score: -2, Type: sentencepiece.Normal},
+			{Piece: "s", Score: -3, Type: sentencepiece.Normal},
+			{Piece: "▁dog", Score: -2, Type: sentencepiece.Normal},
+		},
+		TrainerSpec: sentencepiece.TrainerSpec{ModelType: sentencepiece.Unigram, UnkID: 0, BOSID: -1, EOSID: 1, PadID: -1},
+		NormalizerSpec: sentencepiece.NormalizerSpec{
+			Name:                   "identity",
+			AddDummyPrefix:         true,
+			RemoveExtraWhitespaces: true,
+			EscapeWhitespaces:      true,
+		},
+	})
+	// the vocabulary shared by the source and target languages has its own IDs
+	vocab := vocabulary.NewVocabulary()
+	for _, term := range []string{"</s>", "<unk>", "▁cat", "s", "▁the", "<pad>"} {
+		vocab.AddTerm(term)
+	}
+	return NewSentencePieceTokenizer(sp, vocab)
+}
+
+func TestSentencePieceTokenizer(t *testing.T) {
+	tokenizer := newTestSentencePieceTokenizer()
+
+	encoding, err := tokenizer.Encode("the cats dog")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"▁the", "▁cat", "s", "▁dog"}, encoding.Tokens)
+	assert.Equal(t, []int{4, 2, 3, 1}, encoding.IDs) // "▁dog" is not in the vocabulary
+
+	assert.Equal(t, " the cats", tokenizer.Decode([]int{4, 2, 3}))
+	assert.Equal(t, " cat", tokenizer.Decode([]int{2, 42}))
+}
+
+func TestLoadTokenizer_NotFound(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-bart-")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	_, err = LoadTokenizer(dir)
+	assert.Error(t, err)
+}
@@ -50,24 +50,10 @@ func ConvertHuggingFacePreTrained(modelPath string) error {
 	// (for example, for embeddings storage files).
 	config.Training = true
 
-	model := bart.New(config, path.Join(modelPath, bartconfig.DefaultEmbeddingsStorage))
-	defer model.Close()
-	classification := barthead.NewClassification(barthead.ClassificationConfig{
-		InputSize:     config.DModel,
-		HiddenSize:    config.DModel,
-		OutputSize:    config.NumLabels,
-		PoolerDropout: config.ClassifierDropout,
-	})
-	handler := &huggingFacePreTrainedConverter{
-		config:               config,
-		modelPath:            modelPath,
-		configFilename:       configFilename,
-		pyTorchModelFilename: pyTorchModelFilename,
-		modelFilename:        path.Join(modelPath, bartconfig.DefaultModelFile),
-		model:                model,
-		classificationHead:   classification,
-		modelMapping:         make(map[string]*mappedParam), // lazy initialization
-	}
+	handler := newHuggingFacePreTrainedConverter(config, modelPath)
+	handler.configFilename = configFilename
+	handler.pyTorchModelFilename = pyTorchModelFilename
+	defer handler.model.Close()
 	err = handler.convert()
 	if err != nil {
 		return err
@@ -82,8 +68,36 @@ type huggingFacePreTrainedConverter struct {
 	pyTorchModelFilename string
 	modelFilename        string
 	model                *bart.Model
-	classificationHead   *barthead.Classification
-	modelMapping         map[string]*mappedParam
+	// classificationHead is set for sequence classification models only.
+	classificationHead *barthead.Classification
+	// conditionalGeneration is set for conditional generation models only.
+	conditionalGeneration *barthead.ConditionalGeneration
+	modelMapping          map[string]*mappedParam
+}
+
+// newHuggingFacePreTrainedConverter returns a converter to a new model in the given folder,
+// with a language modeling head for conditional generation or else a classification head.
+func newHuggingFacePreTrainedConverter(config bartconfig.Config, modelPath string) *huggingFacePreTrainedConverter {
+	c := &huggingFacePreTrainedConverter{
+		config:        config,
+		modelPath:     modelPath,
+		modelFilename: path.Join(modelPath, bartconfig.DefaultModelFile),
+		modelMapping:  make(map[string]*mappedParam), // lazy initialization
+	}
+	embeddingsPath := path.Join(modelPath, bartconfig.DefaultEmbeddingsStorage)
+	if config.IsConditionalGeneration() {
+		c.conditionalGeneration = barthead.NewConditionalGeneration(config, embeddingsPath)
+		c.model = c.conditionalGeneration.BART
+	} else {
+		c.model = bart.New(config, embeddingsPath)
+		c.classificationHead = barthead.NewClassification(barthead.ClassificationConfig{
+			InputSize:     config.DModel,
+			HiddenSize:    config.DModel,
+			OutputSize:    config.NumLabels,
+			PoolerDropout: config.ClassifierDropout,
+		})
+	}
+	return c
 }
 
 type mappedParam struct {
@@ -102,31 +116,52 @@ func (c *huggingFacePreTrainedConverter) convert() error {
 	log.Printf("Start converting `%s`\nConfiguration: %+v\n", c.pyTorchModelFilename, c.config)
 	log.Printf("Extracting Hugging Face params from the PyTorch model...")
 	pyTorchParams := c.extractHuggingFaceParams()
+	c.convertParams(pyTorchParams)
 
+	fmt.Printf("Serializing model to \"%s\"... ", c.modelFilename)
+	if err := c.serializeModel(); err != nil {
+		return err
+	}
+	fmt.Printf("BART has been converted successfully!\n")
+	return nil
+}
+
+// convertParams assigns the Hugging Face parameters, indexed by their names, to the
+// parameters of the model.
+func (c *huggingFacePreTrainedConverter) convertParams(pyTorchParams map[string][]mat.Float) {
 	log.Printf("Convert embeddings... ")
 	dumpWordEmbeddings(pyTorchParams["model.shared.weight"], c.model.Embeddings, c.model.Config.VocabSize)
 	log.Printf("Ok\n")
 
 	c.addToModelMapping(mapBartEncoder(c.model.Encoder))
 	c.addToModelMapping(mapBartDecoder(c.model.Decoder))
-	c.addToModelMapping(mapClassificationHead(c.classificationHead))
-	// TODO: convert other Heads
-
-	fmt.Printf("Setting model.encoder.embed_positions.weight.... ")
-	assignToParamsList(
-		pyTorchParams["model.encoder.embed_positions.weight"],
-		c.model.Encoder.LearnedPositionalEmbeddings.Vectors,
-		c.model.Encoder.Config.MaxPositionEmbeddings+c.model.Decoder.Config.ExtraPosEmbedding,
-		c.model.Encoder.Config.DModel)
-	fmt.Print("ok\n")
-
-	fmt.Printf("Setting model.decoder.embed_positions.weight.... ")
-	assignToParamsList(
-		pyTorchParams["model.decoder.embed_positions.weight"],
-		c.model.Decoder.LearnedPositionalEmbeddings.Vectors,
-		c.model.Decoder.Config.MaxPositionEmbeddings+c.model.Decoder.Config.ExtraPosEmbedding,
-		c.model.Decoder.Config.DModel)
-	fmt.Print("ok\n")
+	if c.classificationHead != nil {
+		c.addToModelMapping(mapClassificationHead(c.classificationHead))
+	}
+	if c.conditionalGeneration != nil {
+		c.addToModelMapping(mapConditionalGenerationHead(c.conditionalGeneration))
+	}
+
+	if c.config.StaticPositionEmbeddings {
+		fmt.Println("Static position embeddings: nothing to set for model.encoder.embed_positions.weight")
+		fmt.Println("Static position embeddings: nothing to set for model.decoder.embed_positions.weight")
+	} else {
+		fmt.Printf("Setting model.encoder.embed_positions.weight.... ")
+		assignToParamsList(
+			pyTorchParams["model.encoder.embed_positions.weight"],
+			c.model.Encoder.LearnedPositionalEmbeddings.Vectors,
+			c.model.Encoder.Config.MaxPositionEmbeddings+c.model.Decoder.Config.ExtraPosEmbedding,
+			c.model.Encoder.Config.DModel)
+		fmt.Print("ok\n")
+
+		fmt.Printf("Setting model.decoder.embed_positions.weight.... ")
+		assignToParamsList(
+			pyTorchParams["model.decoder.embed_positions.weight"],
+			c.model.Decoder.LearnedPositionalEmbeddings.Vectors,
+			c.model.Decoder.Config.MaxPositionEmbeddings+c.model.Decoder.Config.ExtraPosEmbedding,
+			c.model.Decoder.Config.DModel)
+		fmt.Print("ok\n")
+	}
 
 	log.Printf("Search for matches with the mapped model to import weights...")
 	for paramName, preTrainedWeights := range pyTorchParams {
@@ -147,13 +182,6 @@ func (c *huggingFacePreTrainedConverter) convert() error {
 			log.Printf("WARNING!! `%s` not initialized", key)
 		}
 	}
-
-	fmt.Printf("Serializing model to \"%s\"... ", c.modelFilename)
-	if err := c.serializeModel(); err != nil {
-		return err
-	}
-	fmt.Printf("BART has been converted successfully!\n")
-	return nil
 }
 
 func dumpWordEmbeddings(source []mat.Float, dest *embeddings.Model, vocabSize int) {
@@ -167,12 +195,15 @@ func dumpWordEmbeddings(source []mat.Float, dest *embeddings.Model, vocabSize in
 }
 
 func (c *huggingFacePreTrainedConverter) serializeModel() error {
-	// TODO: handle different heads and base BART model alone as well
-	sequenceClassificationModel := &barthead.SequenceClassification{
+	// TODO: handle the base BART model alone as well
+	var model interface{} = &barthead.SequenceClassification{
 		BART:           c.model,
 		Classification: c.classificationHead,
 	}
-	err := utils.SerializeToFile(c.modelFilename, sequenceClassificationModel)
+	if c.conditionalGeneration != nil {
+		model = c.conditionalGeneration
+	}
+	err := utils.SerializeToFile(c.modelFilename, model)
 	if err != nil {
 		return fmt.Errorf("bert: error during model serialization: %w", err)
 	}
@@ -209,10 +240,14 @@ func mapBartEncoder(model *bartencoder.Model) map[string]mat.Matrix {
 		paramsMap[fmt.Sprintf("%s.final_layer_norm.bias", prefixBase)] = layer.LayerNorm.B.Value()
 	}
 
-	paramsMap["model.encoder.layernorm_embedding.weight"] = model.EmbeddingLayerNorm.W.Value()
-	paramsMap["model.encoder.layernorm_embedding.bias"] = model.EmbeddingLayerNorm.B.Value()
-	paramsMap["model.encoder.layer_norm.weight"] = model.LayerNorm.W.Value()
-	paramsMap["model.encoder.layer_norm.bias"] = model.LayerNorm.B.Value()
+	if model.Config.NormalizeEmbedding {
+		paramsMap["model.encoder.layernorm_embedding.weight"] = model.EmbeddingLayerNorm.W.Value()
+		paramsMap["model.encoder.layernorm_embedding.bias"] = model.EmbeddingLayerNorm.B.Value()
+	}
+	if model.Config.FinalLayerNorm {
+		paramsMap["model.encoder.layer_norm.weight"] = model.LayerNorm.W.Value()
+		paramsMap["model.encoder.layer_norm.bias"] = model.LayerNorm.B.Value()
+	}
 
 	return paramsMap
 }
@@ -263,10 +298,14 @@ func mapBartDecoder(model *bartdecoder.Model) map[string]mat.Matrix {
 		paramsMap[fmt.Sprintf("%s.final_layer_norm.bias", prefixBase)] = layer.LayerNorm.B.Value()
 	}
 
-	paramsMap["model.decoder.layernorm_embedding.weight"] = model.EmbeddingLayerNorm.W.Value()
-	paramsMap["model.decoder.layernorm_embedding.bias"] = model.EmbeddingLayerNorm.B.Value()
-	paramsMap["model.decoder.layer_norm.weight"] = model.LayerNorm.W.Value()
-	paramsMap["model.decoder.layer_norm.bias"] = model.LayerNorm.B.Value()
+	if model.Config.NormalizeEmbedding {
+		paramsMap["model.decoder.layernorm_embedding.weight"] = model.EmbeddingLayerNorm.W.Value()
+		paramsMap["model.decoder.layernorm_embedding.bias"] = model.EmbeddingLayerNorm.B.Value()
+	}
+	if model.Config.FinalLayerNorm {
+		paramsMap["model.decoder.layer_norm.weight"] = model.LayerNorm.W.Value()
+		paramsMap["model.decoder.layer_norm.bias"] = model.LayerNorm.B.Value()
+	}
 	return paramsMap
 }
 
@@ -279,6 +318,12 @@ func mapClassificationHead(model *barthead.Classification) map[string]mat.Matrix
 	return paramsMap
 }
 
+func mapConditionalGenerationHead(model *barthead.ConditionalGeneration) map[string]mat.Matrix {
+	paramsMap := make(map[string]mat.Matrix)
+	paramsMap["final_logits_bias"] = model.FinalLogitsBias.Value()
+	return paramsMap
+}
+
 func assignToParamsList(source []mat.Float, dest []nn.Param, rows, cols int) {
 	for i := 0; i < rows; i++ {
 		dest[i].Value().SetData(source[i*cols : (i+1)*cols])
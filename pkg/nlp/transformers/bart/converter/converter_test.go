@@ -0,0 +1,140 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package converter
+
+import (
+	"encoding/json"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartconfig"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartencoder"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/barthead"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestConvertParams_MarianMT(t *testing.T) {
+	dir := newTempDir(t)
+	config := newTestConfig()
+	config.Architecture = []string{"MarianMTModel"}
+	config.ModelType = "marian"
+	config.StaticPositionEmbeddings = true
+
+	c := newHuggingFacePreTrainedConverter(config, dir)
+	require.NotNil(t, c.conditionalGeneration)
+	assert.Nil(t, c.classificationHead)
+	assert.Same(t, c.conditionalGeneration.BART, c.model)
+	assert.Nil(t, c.model.Encoder.LearnedPositionalEmbeddings)
+	assert.NotNil(t, c.model.Encoder.SinusoidalPositionalEmbeddings)
+	assert.Nil(t, c.model.Decoder.LearnedPositionalEmbeddings)
+	assert.NotNil(t, c.model.Decoder.SinusoidalPositionalEmbeddings)
+
+	params := newTestParams(config)
+	c.convertParams(params)
+	assert.Equal(t, params["final_logits_bias"], c.conditionalGeneration.FinalLogitsBias.Value().Data())
+	assert.Equal(t, params["model.encoder.layers.0.fc1.bias"],
+		c.model.Encoder.Layers.Layers[0].(*bartencoder.Layer).FFN.Layers[0].(*linear.Model).B.Value().Data())
+}
+
+func TestConvertParams_BartForConditionalGeneration(t *testing.T) {
+	dir := newTempDir(t)
+	config := newTestConfig()
+	config.Architecture = []string{"BartForConditionalGeneration"}
+
+	c := newHuggingFacePreTrainedConverter(config, dir)
+	require.NotNil(t, c.conditionalGeneration)
+	params := newTestParams(config)
+	c.convertParams(params)
+
+	size := config.DModel
+	for i, vector := range c.model.Encoder.LearnedPositionalEmbeddings.Vectors[:config.MaxPositionEmbeddings+config.ExtraPosEmbedding] {
+		assert.Equal(t, params["model.encoder.embed_positions.weight"][i*size:(i+1)*size], vector.Value().Data())
+	}
+	for i, vector := range c.model.Decoder.LearnedPositionalEmbeddings.Vectors[:config.MaxPositionEmbeddings+config.ExtraPosEmbedding] {
+		assert.Equal(t, params["model.decoder.embed_positions.weight"][i*size:(i+1)*size], vector.Value().Data())
+	}
+	for name, param := range c.modelMapping {
+		if _, ok := params[name]; ok {
+			assert.True(t, param.used, name)
+		}
+	}
+
+	// the converted model is loaded with the tied language modeling head
+	require.NoError(t, c.serializeModel())
+	c.model.Close()
+	config.Training = false
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, bartconfig.DefaultConfigurationFile), data, 0644))
+
+	model, err := barthead.LoadModelForConditionalGeneration(dir)
+	require.NoError(t, err)
+	defer model.Close()
+	assert.Equal(t, params["final_logits_bias"], model.FinalLogitsBias.Value().Data())
+	weights := model.LMHead.Weights(model.BART.Embeddings, config.VocabSize)
+	assert.Equal(t, params["model.shared.weight"], weights.Data())
+}
+
+func TestMapConditionalGenerationHead(t *testing.T) {
+	config := newTestConfig()
+	model := barthead.NewConditionalGeneration(config, newTempDir(t))
+	defer model.Close()
+	paramsMap := mapConditionalGenerationHead(model)
+	assert.Len(t, paramsMap, 1)
+	assert.Same(t, model.FinalLogitsBias.Value(), paramsMap["final_logits_bias"])
+}
+
+func newTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "spago-bart-")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+func newTestConfig() bartconfig.Config {
+	return bartconfig.Config{
+		ActivationFunction:    "gelu",
+		DModel:                4,
+		EncoderLayers:         1,
+		DecoderLayers:         1,
+		EncoderAttentionHeads: 2,
+		DecoderAttentionHeads: 2,
+		EncoderFFNDim:         8,
+		DecoderFFNDim:         8,
+		ExtraPosEmbedding:     2,
+		MaxPositionEmbeddings: 4,
+		NormalizeEmbedding:    true,
+		VocabSize:             5,
+		Training:              true,
+	}
+}
+
+// newTestParams returns the Hugging Face parameters found in the checkpoint of
+// a model with the given configuration, but the ones of the attention.
+func newTestParams(config bartconfig.Config) map[string][]mat.Float {
+	next := mat.Float(0)
+	values := func(size int) []mat.Float {
+		data := make([]mat.Float, size)
+		for i := range data {
+			next += 0.01
+			data[i] = next
+		}
+		return data
+	}
+	positions := config.MaxPositionEmbeddings + config.ExtraPosEmbedding
+	return map[string][]mat.Float{
+		"model.shared.weight":                    values(config.VocabSize * config.DModel),
+		"model.encoder.embed_positions.weight":   values(positions * config.DModel),
+		"model.decoder.embed_positions.weight":   values(positions * config.DModel),
+		"model.encoder.layers.0.fc1.weight":      values(config.EncoderFFNDim * config.DModel),
+		"model.encoder.layers.0.fc1.bias":        values(config.EncoderFFNDim),
+		"model.encoder.layernorm_embedding.bias": values(config.DModel),
+		"final_logits_bias":                      values(config.VocabSize),
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartconfig"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartdecoder"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/generation"
+)
+
+var (
+	_ generation.Decoder = &Decoder{}
+)
+
+// NewGenerationConfig returns a new generation.Config initialized from the BART
+// configuration, falling back to the Hugging Face defaults for the missing values.
+func NewGenerationConfig(config bartconfig.Config) generation.Config {
+	c := generation.DefaultConfig()
+	c.DecoderStartTokenID = config.DecoderStartTokenID
+	c.BOSTokenID = config.BosTokenID
+	c.EOSTokenID = config.EosTokenID
+	c.ForceBOSTokenToBeGenerated = config.ForceBosTokenToBeGenerated
+	c.ForceEOSTokenAtMaxLength = true
+	c.EarlyStopping = config.EarlyStopping
+	c.NoRepeatNGramSize = config.NoRepeatNGramSize
+	c.MinLength = config.MinLength
+	if config.MaxLength > 0 {
+		c.MaxLength = config.MaxLength
+	}
+	if config.NumBeams > 0 {
+		c.NumBeams = config.NumBeams
+	}
+	if config.LengthPenalty != 0 {
+		c.LengthPenalty = config.LengthPenalty
+	}
+	return c
+}
+
+// Decoder adapts a reified BART Model to the generation.Decoder interface, in order
+// to generate the target sequence of an already encoded source sequence.
+// It requires a graph with incremental forward (default), since the scores of
+// the next token are read as soon as they are defined.
+type Decoder struct {
+	// Model is the reified BART Model.
+	Model *Model
+	// EncoderHiddenStates are the encoded source sequence (see Model.EncodeSource).
+	EncoderHiddenStates []ag.Node
+	// Projection maps the last decoder hidden state to the scores over the vocabulary
+	// (e.g. a language modeling head).
+	Projection func(x ag.Node) ag.Node
+}
+
+// Decode processes the given token IDs, following the ones already processed in the cache, and
+// returns the scores of the next token together with the updated cache of keys and values.
+func (d *Decoder) Decode(tokenIDs []int, cache generation.Cache) (mat.Matrix, generation.Cache) {
+	var past bartdecoder.Cache
+	if cache != nil {
+		past = cache.(bartdecoder.Cache)
+	}
+	ys, next := d.Model.DecodeNext(tokenIDs, d.EncoderHiddenStates, past)
+	return d.Projection(ys[len(ys)-1]).Value(), next
+}
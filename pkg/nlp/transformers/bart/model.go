@@ -9,6 +9,7 @@ package bart
 
 import (
 	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
@@ -57,13 +58,89 @@ func (m *Model) Close() {
 
 // Encode performs the forward step for each input and returns the result.
 func (m *Model) Encode(inputIDs []int) []ag.Node {
-	encoderInput := m.Embeddings.Encode(intToStringSlice(inputIDs))
+	encoderInput := m.embed(inputIDs)
 	encoderOutput := m.Encoder.Encode(encoderInput)
-	decoderInput := m.Embeddings.Encode(intToStringSlice(shiftR(inputIDs, 1)))
+	decoderInput := m.embed(shiftR(inputIDs, 1))
 	decoderOutput := m.Decoder.Decode(decoderInput, encoderOutput)
 	return decoderOutput
 }
 
+// EncodeBatch performs the forward step of a batch of independent sequences, sharing the
+// same graph, and returns the result for each of them. The sequences can have different
+// lengths; if padded, the padding tokens are ignored by the encoder self-attention and by
+// the encoder-decoder attention, as done by the attention mask of Hugging Face.
+func (m *Model) EncodeBatch(inputIDs [][]int) [][]ag.Node {
+	encoderInput := make(nn.Batch, len(inputIDs))
+	decoderInput := make(nn.Batch, len(inputIDs))
+	keysMasks := make([][]bool, len(inputIDs))
+	for i, ids := range inputIDs {
+		encoderInput[i] = m.embed(ids)
+		decoderInput[i] = m.embed(shiftTokensRight(ids, m.Config.PadTokenID))
+		keysMasks[i] = paddingMask(ids, m.Config.PadTokenID)
+	}
+	encoderOutput := m.Encoder.EncodeBatch(encoderInput, keysMasks)
+	return m.Decoder.DecodeBatch(decoderInput, encoderOutput, keysMasks)
+}
+
+// paddingMask returns the keys mask of the padding tokens, or nil if there are none.
+func paddingMask(ids []int, padTokenID int) []bool {
+	var mask []bool
+	for i, id := range ids {
+		if id != padTokenID {
+			continue
+		}
+		if mask == nil {
+			mask = make([]bool, len(ids))
+		}
+		mask[i] = true
+	}
+	return mask
+}
+
+// shiftTokensRight shifts the input IDs one token to the right, wrapping the last
+// non-padding token (usually the end of sequence) around to the first position.
+// Without padding, it is the same as shiftR(ids, 1).
+func shiftTokensRight(ids []int, padTokenID int) []int {
+	last := len(ids) - 1
+	for last > 0 && ids[last] == padTokenID {
+		last--
+	}
+	return append([]int{ids[last]}, ids[:len(ids)-1]...)
+}
+
+// EncodeSource performs the forward step of the encoder only and returns the
+// encoder hidden states, to be used for the (incremental) decoding.
+func (m *Model) EncodeSource(inputIDs []int) []ag.Node {
+	return m.Encoder.Encode(m.embed(inputIDs))
+}
+
+// DecodeNext performs the forward step of the decoder for the given token IDs, following
+// the ones already processed in the cache (nil at the first step), and returns the decoder
+// hidden states of the new tokens together with the updated cache.
+func (m *Model) DecodeNext(
+	tokenIDs []int,
+	encoderHiddenStates []ag.Node,
+	cache bartdecoder.Cache,
+) ([]ag.Node, bartdecoder.Cache) {
+	decoderInput := m.embed(tokenIDs)
+	return m.Decoder.DecodeWithCache(decoderInput, encoderHiddenStates, cache)
+}
+
+// embed returns the embeddings of the given token IDs, scaled by the square root
+// of the model dimension if required by the configuration.
+func (m *Model) embed(tokenIDs []int) []ag.Node {
+	xs := m.Embeddings.Encode(intToStringSlice(tokenIDs))
+	if !m.Config.ScaleEmbedding {
+		return xs
+	}
+	g := m.Graph()
+	scale := g.NewScalar(mat.Sqrt(mat.Float(m.Config.DModel)))
+	for i, x := range xs {
+		xs[i] = g.ProdScalar(x, scale)
+	}
+	return xs
+}
+
 func intToStringSlice(a []int) []string {
 	out := make([]string, len(a))
 	for i, num := range a {
@@ -13,6 +13,7 @@ import (
 
 var (
 	_ nn.Model = &LearnedPositionalEmbeddings{}
+	_ nn.Model = &SinusoidalPositionalEmbeddings{}
 )
 
 // Config provides configuration settings for a LearnedPositionalEmbeddings Model.
@@ -33,6 +34,7 @@ type LearnedPositionalEmbeddings struct {
 
 func init() {
 	gob.Register(&LearnedPositionalEmbeddings{})
+	gob.Register(&SinusoidalPositionalEmbeddings{})
 }
 
 // NewLearnedPositionalEmbeddings returns a new LearnedPositionalEmbeddings.
@@ -57,3 +59,43 @@ func (m *LearnedPositionalEmbeddings) Encode(positions []int) []ag.Node {
 	}
 	return embeddings
 }
+
+// SinusoidalPositionalEmbeddings contains static positional embeddings, composed
+// by the sine (first half) and the cosine (second half) of different frequencies,
+// as in Marian models.
+type SinusoidalPositionalEmbeddings struct {
+	nn.BaseModel
+	Config  Config
+	Vectors []*mat.Dense
+}
+
+// NewSinusoidalPositionalEmbeddings returns a new SinusoidalPositionalEmbeddings.
+// The Offset of the configuration is ignored.
+func NewSinusoidalPositionalEmbeddings(config Config) *SinusoidalPositionalEmbeddings {
+	dim := config.EmbeddingDim
+	half := dim / 2
+	vectors := make([]*mat.Dense, config.NumEmbeddings)
+	for pos := range vectors {
+		data := make([]mat.Float, dim)
+		for i := 0; i < half; i++ {
+			angle := mat.Float(pos) / mat.Pow(10000, mat.Float(2*i)/mat.Float(dim))
+			data[i] = mat.Sin(angle)
+			data[half+i] = mat.Cos(angle)
+		}
+		vectors[pos] = mat.NewVecDense(data)
+	}
+	return &SinusoidalPositionalEmbeddings{
+		Config:  config,
+		Vectors: vectors,
+	}
+}
+
+// Encode performs the forward step for each input and returns the result.
+func (m *SinusoidalPositionalEmbeddings) Encode(positions []int) []ag.Node {
+	g := m.Graph()
+	embeddings := make([]ag.Node, len(positions))
+	for i, pos := range positions {
+		embeddings[i] = g.NewVariable(m.Vectors[pos], false)
+	}
+	return embeddings
+}
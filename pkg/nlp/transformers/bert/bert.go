@@ -13,8 +13,8 @@ import (
 	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
 	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
 	"github.com/nlpodyssey/spago/pkg/utils"
+	"io/ioutil"
 	"log"
-	"os"
 	"path"
 	"strconv"
 )
@@ -35,6 +35,8 @@ var (
 )
 
 // Config provides configuration settings for a BERT Model.
+// The same configuration covers the BERT variants sharing the encoder
+// architecture: ELECTRA, RoBERTa, XLM-RoBERTa, DistilBERT and ALBERT.
 type Config struct {
 	HiddenAct             string            `json:"hidden_act"`
 	HiddenSize            int               `json:"hidden_size"`
@@ -46,6 +48,24 @@ type Config struct {
 	VocabSize             int               `json:"vocab_size"`
 	ID2Label              map[string]string `json:"id2label"`
 	ReadOnly              bool              `json:"read_only"`
+	ModelType             string            `json:"model_type"`
+	PadTokenID            int               `json:"pad_token_id"`
+	// EmbeddingSize is the size of the factorized embeddings of ALBERT and ELECTRA,
+	// which are projected to the hidden size. Zero means the hidden size.
+	EmbeddingSize int `json:"embedding_size"`
+	// NumHiddenGroups and InnerGroupNum describe the parameter sharing of ALBERT.
+	NumHiddenGroups int `json:"num_hidden_groups"`
+	InnerGroupNum   int `json:"inner_group_num"`
+}
+
+// distilBertConfig contains the settings of a DistilBERT configuration file,
+// whose names differ from the BERT ones.
+type distilBertConfig struct {
+	Dim        int    `json:"dim"`
+	HiddenDim  int    `json:"hidden_dim"`
+	NumLayers  int    `json:"n_layers"`
+	NumHeads   int    `json:"n_heads"`
+	Activation string `json:"activation"`
 }
 
 func init() {
@@ -53,20 +73,76 @@ func init() {
 }
 
 // LoadConfig loads a BERT model Config from file.
+// The DistilBERT settings are translated into the BERT ones.
 func LoadConfig(file string) (Config, error) {
-	var config Config
-	configFile, err := os.Open(file)
+	data, err := ioutil.ReadFile(file)
 	if err != nil {
 		return Config{}, err
 	}
-	defer configFile.Close()
-	err = json.NewDecoder(configFile).Decode(&config)
-	if err != nil {
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
 		return Config{}, err
 	}
+	if config.ModelType == "distilbert" {
+		var distilConfig distilBertConfig
+		if err := json.Unmarshal(data, &distilConfig); err != nil {
+			return Config{}, err
+		}
+		config.HiddenSize = distilConfig.Dim
+		config.IntermediateSize = distilConfig.HiddenDim
+		config.NumHiddenLayers = distilConfig.NumLayers
+		config.NumAttentionHeads = distilConfig.NumHeads
+		config.HiddenAct = distilConfig.Activation
+		config.TypeVocabSize = 0 // no token type embeddings
+	}
 	return config, nil
 }
 
+// IsRoBERTa reports whether the model is RoBERTa or one of its variants (e.g. XLM-RoBERTa),
+// whose position embeddings start after the padding index.
+func (c Config) IsRoBERTa() bool {
+	switch c.ModelType {
+	case "roberta", "xlm-roberta":
+		return true
+	default:
+		return false
+	}
+}
+
+// PositionOffset returns the number of leading rows of the pre-trained position
+// embeddings which are not used for the actual positions (RoBERTa only).
+func (c Config) PositionOffset() int {
+	if c.IsRoBERTa() {
+		return c.PadTokenID + 1
+	}
+	return 0
+}
+
+// UnknownToken returns the unknown token of the vocabulary of the model.
+func (c Config) UnknownToken() string {
+	return c.SpecialTokens().Unknown
+}
+
+// embeddingSize returns the size of the word embeddings.
+func (c Config) embeddingSize() int {
+	if c.EmbeddingSize > 0 {
+		return c.EmbeddingSize
+	}
+	return c.HiddenSize
+}
+
+// activationOp returns the operator of the given activation function.
+func activationOp(name string) ag.OpName {
+	switch name {
+	case "relu":
+		return ag.OpReLU
+	case "tanh":
+		return ag.OpTanh
+	default:
+		return ag.OpGELU // gelu, gelu_new
+	}
+}
+
 // Model implements a BERT model.
 type Model struct {
 	nn.BaseModel
@@ -83,31 +159,47 @@ type Model struct {
 }
 
 // NewDefaultBERT returns a new model based on the original BERT architecture.
+// The variants of the architecture are selected by the configuration: ALBERT
+// shares the parameters of the encoder layers and, like ELECTRA, projects the
+// factorized embeddings to the hidden size.
 func NewDefaultBERT(config Config, embeddingsStoragePath string) *Model {
+	encoderConfig := EncoderConfig{
+		Size:                   config.HiddenSize,
+		NumOfAttentionHeads:    config.NumAttentionHeads,
+		IntermediateSize:       config.IntermediateSize,
+		IntermediateActivation: activationOp(config.HiddenAct),
+		NumOfLayers:            config.NumHiddenLayers,
+	}
+	newEncoder := NewBertEncoder
+	predictorHiddenSize := config.HiddenSize
+	if config.ModelType == "albert" {
+		newEncoder = NewAlbertEncoder
+		predictorHiddenSize = config.embeddingSize()
+	}
+	poolerActivation := ag.OpTanh
+	if config.ModelType == "distilbert" {
+		poolerActivation = ag.OpReLU // the pre-classifier of DistilBERT
+	}
+
 	return &Model{
 		Config:     config,
 		Vocabulary: nil,
 		Embeddings: NewEmbeddings(EmbeddingsConfig{
-			Size:                config.HiddenSize,
+			Size:                config.embeddingSize(),
 			OutputSize:          config.HiddenSize,
 			MaxPositions:        config.MaxPositionEmbeddings,
 			TokenTypes:          config.TypeVocabSize,
 			WordsMapFilename:    embeddingsStoragePath,
 			WordsMapReadOnly:    config.ReadOnly,
 			DeletePreEmbeddings: false,
+			UnknownToken:        config.UnknownToken(),
 		}),
-		Encoder: NewBertEncoder(EncoderConfig{
-			Size:                   config.HiddenSize,
-			NumOfAttentionHeads:    config.NumAttentionHeads,
-			IntermediateSize:       config.IntermediateSize,
-			IntermediateActivation: ag.OpGELU,
-			NumOfLayers:            config.NumHiddenLayers,
-		}),
+		Encoder: newEncoder(encoderConfig),
 		Predictor: NewPredictor(PredictorConfig{
 			InputSize:        config.HiddenSize,
-			HiddenSize:       config.HiddenSize,
+			HiddenSize:       predictorHiddenSize,
 			OutputSize:       config.VocabSize,
-			HiddenActivation: ag.OpGELU,
+			HiddenActivation: activationOp(config.HiddenAct),
 			OutputActivation: ag.OpIdentity, // implicit Softmax (trained with CrossEntropyLoss)
 		}),
 		Discriminator: NewDiscriminator(DiscriminatorConfig{
@@ -119,6 +211,7 @@ func NewDefaultBERT(config Config, embeddingsStoragePath string) *Model {
 		Pooler: NewPooler(PoolerConfig{
 			InputSize:  config.HiddenSize,
 			OutputSize: config.HiddenSize,
+			Activation: poolerActivation,
 		}),
 		SeqRelationship: linear.New(config.HiddenSize, 2),
 		SpanClassifier: NewSpanClassifier(SpanClassifierConfig{
@@ -147,7 +240,6 @@ func NewDefaultBERT(config Config, embeddingsStoragePath string) *Model {
 // LoadModel loads a BERT Model from file.
 func LoadModel(modelPath string) (*Model, error) {
 	configFilename := path.Join(modelPath, DefaultConfigurationFile)
-	vocabFilename := path.Join(modelPath, DefaultVocabularyFile)
 	embeddingsFilename := path.Join(modelPath, DefaultEmbeddingsStorage)
 	modelFilename := path.Join(modelPath, DefaultModelFile)
 
@@ -161,7 +253,7 @@ func LoadModel(modelPath string) (*Model, error) {
 	model := NewDefaultBERT(config, embeddingsFilename)
 
 	fmt.Printf("[2/3] Loading vocabulary... ")
-	vocab, err := vocabulary.NewFromFile(vocabFilename)
+	vocab, err := loadVocabulary(modelPath)
 	if err != nil {
 		return nil, err
 	}
@@ -184,6 +276,34 @@ func (m *Model) Encode(tokens []string) []ag.Node {
 	return m.Encoder.Forward(tokensEncoding...)
 }
 
+// EncodeBatch transforms a batch of string sequences into their encoded representations,
+// sharing the same forward step. The sequences can have different lengths; padding tokens
+// (if any) are ignored by the self-attention, as done by the attention mask of Hugging Face.
+func (m *Model) EncodeBatch(tokens [][]string) [][]ag.Node {
+	tokensEncoding := make(nn.Batch, len(tokens))
+	keysMasks := make([][]bool, len(tokens))
+	for i, seq := range tokens {
+		tokensEncoding[i] = m.Embeddings.Encode(seq)
+		keysMasks[i] = paddingMask(seq, m.Config.SpecialTokens().Pad)
+	}
+	return m.Encoder.ForwardBatch(tokensEncoding, keysMasks)
+}
+
+// paddingMask returns the keys mask of the padding tokens, or nil if there are none.
+func paddingMask(tokens []string, padToken string) []bool {
+	var mask []bool
+	for i, token := range tokens {
+		if token != padToken {
+			continue
+		}
+		if mask == nil {
+			mask = make([]bool, len(tokens))
+		}
+		mask[i] = true
+	}
+	return mask
+}
+
 // PredictMasked performs a masked prediction task. It returns the predictions
 // for indices associated to the masked nodes.
 func (m *Model) PredictMasked(transformed []ag.Node, masked []int) map[int]ag.Node {
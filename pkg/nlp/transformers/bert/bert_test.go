@@ -0,0 +1,55 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestModel_EncodeBatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-bert-")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) }) // after closing the embeddings storage
+	model := newTestModel(t, dir)
+
+	sequences := [][]string{
+		{"[CLS]", "the", "cat", "sat", "on", "the", "mat", "[SEP]"},
+		{"[CLS]", "the", "mat", "[SEP]"},
+	}
+	encode := func(tokens []string) [][]mat.Float {
+		g := ag.NewGraph()
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+		return nodesData(proc.Encode(tokens))
+	}
+
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+	padded := append(append([]string(nil), sequences[1]...), "[PAD]", "[PAD]", "[PAD]", "[PAD]")
+	actual := proc.EncodeBatch([][]string{sequences[0], padded})
+
+	require.Len(t, actual, 2)
+	assert.Len(t, actual[1], len(padded))
+	for i, seq := range sequences {
+		expected := encode(seq)
+		for j := range expected {
+			assert.InDeltaSlice(t, expected[j], actual[i][j].Value().Data(), 1.0e-05)
+		}
+	}
+}
+
+func nodesData(nodes []ag.Node) [][]mat.Float {
+	out := make([][]mat.Float, len(nodes))
+	for i, n := range nodes {
+		out[i] = n.Value().Data()
+	}
+	return out
+}
@@ -34,10 +34,6 @@ func ConvertHuggingFacePreTrained(modelPath string) error {
 	if err != nil {
 		return err
 	}
-	vocabFilename, err := exists(path.Join(modelPath, DefaultVocabularyFile))
-	if err != nil {
-		return err
-	}
 	pyTorchModelFilename, err := exists(path.Join(modelPath, defaultHuggingFaceModelFile))
 	if err != nil {
 		return err
@@ -46,7 +42,10 @@ func ConvertHuggingFacePreTrained(modelPath string) error {
 	if err != nil {
 		return err
 	}
-	vocab, err := vocabulary.NewFromFile(vocabFilename)
+	if config.ModelType == "albert" && (config.NumHiddenGroups > 1 || config.InnerGroupNum > 1) {
+		return fmt.Errorf("bert: ALBERT with multiple layer groups is not supported")
+	}
+	vocab, err := loadVocabulary(modelPath)
 	if err != nil {
 		return err
 	}
@@ -58,7 +57,6 @@ func ConvertHuggingFacePreTrained(modelPath string) error {
 		modelPath:            modelPath,
 		configFilename:       configFilename,
 		pyTorchModelFilename: pyTorchModelFilename,
-		vocabFilename:        vocabFilename,
 		modelFilename:        path.Join(modelPath, DefaultModelFile),
 		model:                model,
 		modelMapping:         make(map[string]*mappedParam), // lazy initialization
@@ -75,7 +73,6 @@ type huggingFacePreTrainedConverter struct {
 	modelPath            string
 	configFilename       string
 	pyTorchModelFilename string
-	vocabFilename        string
 	modelFilename        string
 	model                *Model
 	modelMapping         map[string]*mappedParam
@@ -99,7 +96,9 @@ func (c *huggingFacePreTrainedConverter) convert() error {
 	pyTorchParams := c.extractHuggingFaceParams()
 
 	log.Printf("Convert word/positional/type embeddings...")
-	c.convertEmbeddings(pyTorchParams)
+	if err := c.convertEmbeddings(pyTorchParams); err != nil {
+		return err
+	}
 
 	log.Printf("Create model mapping...")
 	c.addToModelMapping(mapPredictor(c.model.Predictor))
@@ -155,7 +154,7 @@ func (c *huggingFacePreTrainedConverter) extractHuggingFaceParams() map[string][
 	od := result.(*types.OrderedDict)
 	for key, entry := range od.Map {
 		t := entry.Value.(*pytorch.Tensor)
-		paramName := normalizeParamName(key.(string))
+		paramName := normalizeParamName(c.config.ModelType, key.(string))
 		fmt.Printf("Reading %s.... ", paramName)
 		switch t.Source.(type) {
 		case *pytorch.FloatStorage:
@@ -172,7 +171,10 @@ func (c *huggingFacePreTrainedConverter) extractHuggingFaceParams() map[string][
 func (c *huggingFacePreTrainedConverter) enrichHuggingFaceParams(paramsMap map[string][]mat.Float) {
 	for i := 0; i < c.config.NumHiddenLayers; i++ {
 		prefix := fmt.Sprintf("bert.encoder.layer.%d.attention.self", i)
-		queryWeight := paramsMap[fmt.Sprintf("%s.query.weight", prefix)]
+		queryWeight, ok := paramsMap[fmt.Sprintf("%s.query.weight", prefix)]
+		if !ok {
+			continue // e.g. the layers of ALBERT following the first (shared) one
+		}
 		queryBias := paramsMap[fmt.Sprintf("%s.query.bias", prefix)]
 		keyWeight := paramsMap[fmt.Sprintf("%s.key.weight", prefix)]
 		keyBias := paramsMap[fmt.Sprintf("%s.key.bias", prefix)]
@@ -192,17 +194,29 @@ func (c *huggingFacePreTrainedConverter) enrichHuggingFaceParams(paramsMap map[s
 			paramsMap[fmt.Sprintf("%s.value.bias", newPrefix)] = valueBias[from:to]
 		}
 	}
+
+	// the decoder of the language modeling head is tied to the word embeddings
+	if _, ok := paramsMap["cls.predictions.decoder.weight"]; !ok {
+		if wordEmbeddings, ok := paramsMap["bert.embeddings.word_embeddings.weight"]; ok {
+			paramsMap["cls.predictions.decoder.weight"] = wordEmbeddings
+		}
+	}
 }
 
 // normalizeParamName applies the following transformation:
 //    electra -> bert
 //    gamma -> weight
 //    beta -> bias
-func normalizeParamName(orig string) (normalized string) {
+// Then, the names of the parameters of the other architectures (RoBERTa, XLM-RoBERTa,
+// DistilBERT and ALBERT) are translated into the corresponding BERT ones.
+func normalizeParamName(modelType, orig string) (normalized string) {
 	normalized = orig
 	normalized = strings.Replace(normalized, "electra.", "bert.", -1)
 	normalized = strings.Replace(normalized, ".gamma", ".weight", -1)
 	normalized = strings.Replace(normalized, ".beta", ".bias", -1)
+	for _, replacer := range paramNameReplacers[modelType] {
+		normalized = replacer.Replace(normalized)
+	}
 	if strings.HasPrefix(normalized, "embeddings.") {
 		normalized = fmt.Sprintf("bert.%s", normalized)
 	}
@@ -215,18 +229,85 @@ func normalizeParamName(orig string) (normalized string) {
 	return
 }
 
-func (c *huggingFacePreTrainedConverter) convertEmbeddings(pyTorchParams map[string][]mat.Float) {
-	assignToParamsList(
-		pyTorchParams["bert.embeddings.position_embeddings.weight"],
+// robertaParamNameReplacer translates the names of the parameters of RoBERTa
+// and its variants. The dense layer of the sequence classification head is
+// mapped to the pooler, which is applied to the first token in the same way.
+var robertaParamNameReplacer = strings.NewReplacer(
+	"roberta.", "bert.",
+	"lm_head.dense.", "cls.predictions.transform.dense.",
+	"lm_head.layer_norm.", "cls.predictions.transform.LayerNorm.",
+	"lm_head.decoder.", "cls.predictions.decoder.",
+	"lm_head.bias", "cls.predictions.decoder.bias",
+	"classifier.dense.", "bert.pooler.dense.",
+	"classifier.out_proj.", "classifier.",
+)
+
+// paramNameReplacers contains the translations of the parameter names of each
+// supported model type into the BERT ones (see normalizeParamName), applied in order.
+var paramNameReplacers = map[string][]*strings.Replacer{
+	"roberta":     {robertaParamNameReplacer},
+	"xlm-roberta": {robertaParamNameReplacer},
+	"distilbert": {strings.NewReplacer(
+		"distilbert.transformer.layer.", "bert.encoder.layer.",
+		"distilbert.", "bert.",
+		".attention.q_lin.", ".attention.self.query.",
+		".attention.k_lin.", ".attention.self.key.",
+		".attention.v_lin.", ".attention.self.value.",
+		".attention.out_lin.", ".attention.output.dense.",
+		".sa_layer_norm.", ".attention.output.LayerNorm.",
+		".ffn.lin1.", ".intermediate.dense.",
+		".ffn.lin2.", ".output.dense.",
+		".output_layer_norm.", ".output.LayerNorm.",
+		"vocab_transform.", "cls.predictions.transform.dense.",
+		"vocab_layer_norm.", "cls.predictions.transform.LayerNorm.",
+		"vocab_projector.", "cls.predictions.decoder.",
+		"pre_classifier.", "bert.pooler.dense.",
+	)},
+	"albert": {strings.NewReplacer(
+		"albert.encoder.albert_layer_groups.0.albert_layers.0.", "bert.encoder.layer.0.",
+		"albert.encoder.embedding_hidden_mapping_in.", "bert.embeddings_project.",
+		"albert.", "bert.",
+	), strings.NewReplacer(
+		".attention.query.", ".attention.self.query.",
+		".attention.key.", ".attention.self.key.",
+		".attention.value.", ".attention.self.value.",
+		".attention.dense.", ".attention.output.dense.",
+		".attention.LayerNorm.", ".attention.output.LayerNorm.",
+		".ffn.", ".intermediate.dense.",
+		".ffn_output.", ".output.dense.",
+		".full_layer_layer_norm.", ".output.LayerNorm.",
+		"predictions.dense.", "cls.predictions.transform.dense.",
+		"predictions.LayerNorm.", "cls.predictions.transform.LayerNorm.",
+		"predictions.decoder.", "cls.predictions.decoder.",
+		"predictions.bias", "cls.predictions.decoder.bias",
+	)},
+}
+
+func (c *huggingFacePreTrainedConverter) convertEmbeddings(pyTorchParams map[string][]mat.Float) error {
+	// the position embeddings of RoBERTa start after the padding index
+	offset := c.config.PositionOffset() * c.model.Embeddings.Size
+	positionEmbeddings := pyTorchParams["bert.embeddings.position_embeddings.weight"]
+	if len(positionEmbeddings) < offset {
+		return fmt.Errorf("bert: expected at least %d position embeddings, found %d",
+			c.config.PositionOffset(), len(positionEmbeddings)/c.model.Embeddings.Size)
+	}
+	err := assignToParamsList(
+		positionEmbeddings[offset:],
 		c.model.Embeddings.Position,
-		c.config.MaxPositionEmbeddings,
+		c.config.MaxPositionEmbeddings-c.config.PositionOffset(),
 		c.model.Embeddings.Size)
+	if err != nil {
+		return fmt.Errorf("bert: position embeddings: %w", err)
+	}
 
-	assignToParamsList(
+	err = assignToParamsList(
 		pyTorchParams["bert.embeddings.token_type_embeddings.weight"],
 		c.model.Embeddings.TokenType,
 		c.config.TypeVocabSize,
 		c.model.Embeddings.Size)
+	if err != nil {
+		return fmt.Errorf("bert: token type embeddings: %w", err)
+	}
 
 	dumpWordEmbeddings(
 		pyTorchParams["bert.embeddings.word_embeddings.weight"],
@@ -234,12 +315,19 @@ func (c *huggingFacePreTrainedConverter) convertEmbeddings(pyTorchParams map[str
 		c.model.Vocabulary)
 
 	c.model.Embeddings.Words.Close()
+	return nil
 }
 
-func assignToParamsList(source []mat.Float, dest []nn.Param, rows, cols int) {
+// assignToParamsList sets the values of the first rows params from the source,
+// which must contain exactly rows vectors of size cols.
+func assignToParamsList(source []mat.Float, dest []nn.Param, rows, cols int) error {
+	if len(source) != rows*cols {
+		return fmt.Errorf("expected %d embeddings of size %d, found %d values", rows, cols, len(source))
+	}
 	for i := 0; i < rows; i++ {
 		dest[i].Value().SetData(source[i*cols : (i+1)*cols])
 	}
+	return nil
 }
 
 func dumpWordEmbeddings(source []mat.Float, dest *embeddings.Model, vocabulary *vocabulary.Vocabulary) {
@@ -255,8 +343,13 @@ func dumpWordEmbeddings(source []mat.Float, dest *embeddings.Model, vocabulary *
 
 func mapBertEncoder(model *Encoder) map[string]mat.Matrix {
 	paramsMap := make(map[string]mat.Matrix)
+	mapped := make(map[*EncoderLayer]bool)
 	for i := 0; i < model.NumOfLayers; i++ {
 		layer := model.Layers[i].(*EncoderLayer)
+		if mapped[layer] {
+			continue // the layers of ALBERT share the parameters of the first one
+		}
+		mapped[layer] = true
 		prefixBase := fmt.Sprintf("bert.encoder.layer.%d", i)
 		// Sublayer 1
 		for j := 0; j < model.EncoderConfig.NumOfAttentionHeads; j++ {
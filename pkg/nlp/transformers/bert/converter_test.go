@@ -0,0 +1,54 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNormalizeParamName(t *testing.T) {
+	tests := []struct {
+		modelType string
+		name      string
+		expected  string
+	}{
+		{"bert", "bert.encoder.layer.0.attention.output.LayerNorm.gamma", "bert.encoder.layer.0.attention.output.LayerNorm.weight"},
+		{"electra", "electra.embeddings.word_embeddings.weight", "bert.embeddings.word_embeddings.weight"},
+		{"roberta", "roberta.encoder.layer.3.attention.self.query.bias", "bert.encoder.layer.3.attention.self.query.bias"},
+		{"roberta", "lm_head.layer_norm.weight", "cls.predictions.transform.LayerNorm.weight"},
+		{"roberta", "lm_head.bias", "cls.predictions.decoder.bias"},
+		{"roberta", "classifier.dense.weight", "bert.pooler.dense.weight"},
+		{"xlm-roberta", "classifier.out_proj.bias", "classifier.bias"},
+		{"distilbert", "distilbert.transformer.layer.2.attention.q_lin.weight", "bert.encoder.layer.2.attention.self.query.weight"},
+		{"distilbert", "distilbert.transformer.layer.2.sa_layer_norm.bias", "bert.encoder.layer.2.attention.output.LayerNorm.bias"},
+		{"distilbert", "distilbert.transformer.layer.2.ffn.lin2.weight", "bert.encoder.layer.2.output.dense.weight"},
+		{"distilbert", "vocab_projector.bias", "cls.predictions.decoder.bias"},
+		{"albert", "albert.encoder.albert_layer_groups.0.albert_layers.0.attention.dense.weight", "bert.encoder.layer.0.attention.output.dense.weight"},
+		{"albert", "albert.encoder.albert_layer_groups.0.albert_layers.0.ffn_output.bias", "bert.encoder.layer.0.output.dense.bias"},
+		{"albert", "albert.encoder.embedding_hidden_mapping_in.weight", "bert.embeddings_project.weight"},
+		{"albert", "predictions.LayerNorm.bias", "cls.predictions.transform.LayerNorm.bias"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, normalizeParamName(tt.modelType, tt.name), tt.name)
+	}
+}
+
+func TestFairseqVocabulary(t *testing.T) {
+	pieces := []string{"<unk>", "<s>", "</s>", ",", "▁the"}
+	assert.Equal(t, []string{"<s>", "<pad>", "</s>", "<unk>", ",", "▁the", "<mask>"}, fairseqVocabulary(pieces))
+}
+
+func TestAssignToParamsList(t *testing.T) {
+	params := []nn.Param{nn.NewParam(mat.NewEmptyVecDense(2)), nn.NewParam(mat.NewEmptyVecDense(2))}
+	require.NoError(t, assignToParamsList([]mat.Float{1, 2, 3, 4}, params, 2, 2))
+	assert.Equal(t, []mat.Float{3, 4}, params[1].Value().Data())
+
+	err := assignToParamsList([]mat.Float{1, 2, 3}, params, 2, 2)
+	assert.EqualError(t, err, "expected 2 embeddings of size 2, found 3 values")
+}
@@ -28,6 +28,9 @@ type EmbeddingsConfig struct {
 	WordsMapFilename    string
 	WordsMapReadOnly    bool
 	DeletePreEmbeddings bool
+	// UnknownToken is the token whose embedding is used for the words not found.
+	// If empty, wordpiecetokenizer.DefaultUnknownToken is used.
+	UnknownToken string
 }
 
 // Embeddings is a BERT Embeddings model.
@@ -65,7 +68,11 @@ func NewEmbeddings(config EmbeddingsConfig) *Embeddings {
 
 // InitProcessor initializes the unknown embeddings.
 func (m *Embeddings) InitProcessor() {
-	m.UnknownEmbedding = m.Graph().NewWrap(m.Words.GetStoredEmbedding(wordpiecetokenizer.DefaultUnknownToken))
+	unknownToken := m.UnknownToken
+	if unknownToken == "" {
+		unknownToken = wordpiecetokenizer.DefaultUnknownToken
+	}
+	m.UnknownEmbedding = m.Graph().NewWrap(m.Words.GetStoredEmbedding(unknownToken))
 }
 
 func newPositionEmbeddings(size, maxPositions int) []nn.Param {
@@ -99,8 +106,10 @@ func (m *Embeddings) Encode(words []string) []ag.Node {
 	for i := 0; i < len(words); i++ {
 		encoded[i] = wordEmbeddings[i]
 		encoded[i] = m.Graph().Add(encoded[i], m.Graph().NewWrap(m.Position[i]))
-		encoded[i] = m.Graph().Add(encoded[i], m.TokenType[sequenceIndex])
-		if words[i] == wordpiecetokenizer.DefaultSequenceSeparator {
+		if len(m.TokenType) > 0 { // e.g. DistilBERT has no token types
+			encoded[i] = m.Graph().Add(encoded[i], m.TokenType[sequenceIndex])
+		}
+		if words[i] == wordpiecetokenizer.DefaultSequenceSeparator && sequenceIndex < len(m.TokenType)-1 {
 			sequenceIndex++
 		}
 	}
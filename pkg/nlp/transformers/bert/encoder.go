@@ -95,3 +95,13 @@ func NewAlbertEncoder(config EncoderConfig) *Encoder {
 		}),
 	}
 }
+
+// ForwardBatch performs the forward step of a batch of independent sequences through
+// all the layers, and returns the result for each of them (see EncoderLayer.ForwardBatch).
+func (m *Encoder) ForwardBatch(xs nn.Batch, keysMasks [][]bool) nn.Batch {
+	ys := xs
+	for _, layer := range m.Layers {
+		ys = layer.(*EncoderLayer).ForwardBatch(ys, keysMasks)
+	}
+	return ys
+}
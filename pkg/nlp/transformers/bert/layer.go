@@ -39,17 +39,25 @@ func (m *EncoderLayer) Forward(xs ...ag.Node) []ag.Node {
 
 func (m *EncoderLayer) selfAttentionBlock(xs []ag.Node) []ag.Node {
 	selfAtt := m.MultiHeadAttention.Forward(attention.ToQKV(xs))
-	return m.NormAttention.Forward(m.add(xs, selfAtt)...)
+	return m.NormAttention.ForwardWithResidual(selfAtt, xs)
 }
 
 func (m *EncoderLayer) fullyConnectedBlock(xs []ag.Node) []ag.Node {
-	return m.NormFFN.Forward(m.add(xs, m.FFN.Forward(xs...))...)
+	return m.NormFFN.ForwardWithResidual(m.FFN.Forward(xs...), xs)
 }
 
-func (m *EncoderLayer) add(a, b []ag.Node) []ag.Node {
-	c := make([]ag.Node, len(a))
-	for i := 0; i < len(a); i++ {
-		c[i] = m.Graph().Add(a[i], b[i])
+// ForwardBatch performs the forward step of a batch of independent sequences, and returns
+// the result for each of them. The keys masks (if any) exclude the padding tokens from
+// the self-attention.
+func (m *EncoderLayer) ForwardBatch(xs nn.Batch, keysMasks [][]bool) nn.Batch {
+	attIns := make([]attention.QKV, len(xs))
+	for i, x := range xs {
+		attIns[i] = attention.ToQKV(x)
+		if keysMasks != nil {
+			attIns[i].KeysMask = keysMasks[i]
+		}
 	}
-	return c
+	selfAtt := nn.Batch(m.MultiHeadAttention.ForwardBatch(attIns))
+	normAtt := xs.Split(m.NormAttention.ForwardWithResidual(selfAtt.Flatten(), xs.Flatten()))
+	return xs.Split(m.fullyConnectedBlock(normAtt.Flatten()))
 }
@@ -0,0 +1,101 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+)
+
+// ParamGroupsConfig provides configuration settings for the parameter groups
+// commonly used to fine-tune a BERT Model (see ParamGroups).
+type ParamGroupsConfig struct {
+	// NoDecayMethod is the optimization method of the biases and of the layer
+	// normalizations, usually configured without weight decay.
+	// If nil, they are optimized with the default method.
+	NoDecayMethod gd.Method
+	// LayerwiseLRDecay is the factor of the learning rate of each encoder layer
+	// with respect to the layer above; the embeddings are below the first layer.
+	// The values 0 and 1 disable the layer-wise decay.
+	LayerwiseLRDecay mat.Float
+	// FreezeEmbeddings sets whether the embeddings are not optimized at all.
+	FreezeEmbeddings bool
+}
+
+// ParamGroups returns the parameter groups of the model for a gd.GradientDescent
+// (see gd.WithParamGroups), according to the given configuration.
+// The params which are not part of the embeddings or of the encoder (e.g. the
+// task heads) are left to the default settings of the optimizer.
+func ParamGroups(m *Model, config ParamGroupsConfig) []gd.ParamGroup {
+	var groups []gd.ParamGroup
+	if config.FreezeEmbeddings {
+		groups = append(groups, gd.NewFrozenParamGroup(m.selectEmbeddings()))
+	}
+
+	noDecay := gd.SelectByType(nn.Biases).Or(gd.SelectByModel(m.layerNorms()...))
+	if config.LayerwiseLRDecay == 0.0 || config.LayerwiseLRDecay == 1.0 {
+		if config.NoDecayMethod != nil {
+			groups = append(groups, gd.NewParamGroup(noDecay, config.NoDecayMethod))
+		}
+		return groups
+	}
+
+	// From the top layer down to the embeddings. The params shared by several
+	// layers (e.g. ALBERT) belong to the group of the top layer.
+	depth := m.Encoder.NumOfLayers
+	lrFactor := mat.Float(1.0)
+	addGroupsOf := func(selector gd.ParamSelector) {
+		lrFactor *= config.LayerwiseLRDecay
+		if config.NoDecayMethod != nil {
+			group := gd.NewParamGroup(selector.And(noDecay), config.NoDecayMethod)
+			group.LRFactor = lrFactor
+			groups = append(groups, group)
+		}
+		group := gd.NewParamGroup(selector, nil)
+		group.LRFactor = lrFactor
+		groups = append(groups, group)
+	}
+	for i := depth - 1; i >= 0; i-- {
+		addGroupsOf(gd.SelectByModel(m.Encoder.Layers[i]))
+	}
+	addGroupsOf(m.selectEmbeddings())
+
+	if config.NoDecayMethod != nil {
+		groups = append(groups, gd.NewParamGroup(noDecay, config.NoDecayMethod))
+	}
+	return groups
+}
+
+// selectEmbeddings returns a selector of the params of the embeddings.
+// The word embeddings are loaded on demand, so they are selected by their
+// membership to the word embeddings model at the time of each update.
+func (m *Model) selectEmbeddings() gd.ParamSelector {
+	return gd.SelectByModel(m.Embeddings).Or(m.Embeddings.Words.IsUsedEmbedding)
+}
+
+// layerNorms returns the layer normalization models of the embeddings, of the
+// encoder and of the predictor.
+func (m *Model) layerNorms() []nn.Model {
+	var models []nn.Model
+	if m.Embeddings != nil {
+		models = append(models, m.Embeddings.Norm)
+	}
+	if m.Encoder != nil {
+		for _, layer := range m.Encoder.Layers {
+			l := layer.(*EncoderLayer)
+			models = append(models, l.NormAttention, l.NormFFN)
+		}
+	}
+	if m.Predictor != nil {
+		for _, layer := range m.Predictor.Layers {
+			if norm, ok := layer.(*layernorm.Model); ok {
+				models = append(models, norm)
+			}
+		}
+	}
+	return models
+}
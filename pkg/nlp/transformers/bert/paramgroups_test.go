@@ -0,0 +1,120 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func newParamGroupsTestModel(t *testing.T) *Model {
+	dir, err := ioutil.TempDir("", "spago-bert-")
+	require.NoError(t, err)
+	words := embeddings.New(embeddings.Config{Size: 4, DBPath: dir, ForceNewDB: true})
+	t.Cleanup(func() {
+		words.Close()
+		_ = os.RemoveAll(dir)
+	})
+	return &Model{
+		Embeddings: &Embeddings{
+			Words:    words,
+			Position: []nn.Param{nn.NewParam(mat.NewEmptyVecDense(4))},
+			Norm:     layernorm.New(4),
+		},
+		Encoder: NewBertEncoder(EncoderConfig{
+			Size:                   4,
+			NumOfAttentionHeads:    2,
+			IntermediateSize:       8,
+			IntermediateActivation: ag.OpGELU,
+			NumOfLayers:            2,
+		}),
+	}
+}
+
+// firstGroup returns the index of the first group which selects the param, or -1.
+func firstGroup(groups []gd.ParamGroup, param nn.Param) int {
+	for i, group := range groups {
+		if group.Selector(param) {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestParamGroups(t *testing.T) {
+	model := newParamGroupsTestModel(t)
+	nn.ForEachParam(model, func(nn.Param) {}) // assigns names and types
+	noDecay := sgd.New(sgd.NewConfig(0.1, 0.0, false))
+
+	groups := ParamGroups(model, ParamGroupsConfig{NoDecayMethod: noDecay, LayerwiseLRDecay: 0.5})
+
+	layer0 := model.Encoder.Layers[0].(*EncoderLayer)
+	layer1 := model.Encoder.Layers[1].(*EncoderLayer)
+	cases := []struct {
+		param    nn.Param
+		lrFactor mat.Float
+		noDecay  bool
+	}{
+		{layer1.NormFFN.W, 0.5, true},
+		{layer1.MultiHeadAttention.Attention[0].Query.W, 0.5, false},
+		{layer0.NormAttention.B, 0.25, true},
+		{layer0.MultiHeadAttention.OutputMerge.W, 0.25, false},
+		{model.Embeddings.Position[0], 0.125, false},
+		{model.Embeddings.Norm.W, 0.125, true},
+	}
+	for _, c := range cases {
+		i := firstGroup(groups, c.param)
+		if !assert.NotEqual(t, -1, i) {
+			continue
+		}
+		assert.Equal(t, c.lrFactor, groups[i].LRFactor)
+		assert.Equal(t, c.noDecay, groups[i].Method == noDecay)
+	}
+
+	// a param outside the embeddings and the encoder keeps the default settings
+	assert.Equal(t, -1, firstGroup(groups, nn.NewParam(mat.NewScalar(0.0))))
+}
+
+func TestParamGroupsFrozenEmbeddings(t *testing.T) {
+	model := newParamGroupsTestModel(t)
+	nn.ForEachParam(model, func(nn.Param) {})
+
+	groups := ParamGroups(model, ParamGroupsConfig{FreezeEmbeddings: true})
+
+	assert.Len(t, groups, 1)
+	assert.True(t, groups[0].Frozen)
+	assert.True(t, groups[0].Selector(model.Embeddings.Norm.B))
+	assert.False(t, groups[0].Selector(model.Encoder.Layers[0].(*EncoderLayer).NormFFN.B))
+
+	// the word embeddings are loaded after the creation of the groups
+	model.Embeddings.Words.SetEmbeddingFromData("foo", []mat.Float{1, 2, 3, 4})
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+	g.Backward(g.ReduceSum(proc.Embeddings.Words.Encode([]string{"foo"})[0]))
+	word := model.Embeddings.Words.GetStoredEmbedding("foo")
+	require.True(t, word.HasGrad())
+	assert.True(t, groups[0].Selector(word))
+
+	unused := nn.NewParam(mat.NewEmptyVecDense(4))
+	unused.SetName("foo")
+	assert.False(t, groups[0].Selector(unused))
+
+	optimizer := gd.NewOptimizer(sgd.New(sgd.NewConfig(0.1, 0.0, false)), nn.NewDefaultParamsIterator(model),
+		gd.WithParamGroups(groups...))
+	optimizer.Optimize()
+	assert.Equal(t, []mat.Float{1, 2, 3, 4}, word.Value().Data())
+	assert.False(t, word.HasGrad())
+}
@@ -21,6 +21,7 @@ var (
 type PoolerConfig struct {
 	InputSize  int
 	OutputSize int
+	Activation ag.OpName
 }
 
 // Pooler is a BERT Pooler model.
@@ -37,7 +38,7 @@ func NewPooler(config PoolerConfig) *Pooler {
 	return &Pooler{
 		Model: stack.New(
 			linear.New(config.InputSize, config.OutputSize),
-			activation.New(ag.OpTanh),
+			activation.New(config.Activation),
 		),
 	}
 }
@@ -14,10 +14,10 @@ import (
 	"sort"
 
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
-	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert/grpcapi"
 	"github.com/nlpodyssey/spago/pkg/utils/grpcutils"
 	"github.com/nlpodyssey/spago/pkg/utils/httputils"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 	"github.com/nlpodyssey/spago/pkg/webui/bertqa"
 )
 
@@ -25,17 +25,45 @@ import (
 
 // Server contains everything needed to run a BERT server.
 type Server struct {
-	model *Model
+	model     *Model
+	tokenizer Tokenizer
+	batching  microbatching.Config
+	scheduler *microbatching.Scheduler
 
 	// UnimplementedBERTServer must be embedded to have forward compatible implementations for gRPC.
 	grpcapi.UnimplementedBERTServer
 }
 
 // NewServer returns Server objects.
-func NewServer(model *Model) *Server {
-	return &Server{
-		model: model,
+// The requests are processed in batches by a micro-batching scheduler, shared
+// by the HTTP and gRPC handlers (see WithBatching). The texts are split by the
+// WordPiece tokenizer over the vocabulary of the model, unless a different
+// tokenizer is set (see WithTokenizer).
+func NewServer(model *Model, opts ...ServerOption) *Server {
+	s := &Server{
+		model:     model,
+		tokenizer: NewWordPieceTokenizer(model.Vocabulary),
+		batching:  microbatching.DefaultConfig(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.scheduler = microbatching.New(s.batching, s.processBatch)
+	return s
+}
+
+// WithTokenizer sets the tokenizer of the texts, which must produce the tokens of
+// the vocabulary of the model (see LoadTokenizer).
+func WithTokenizer(tokenizer Tokenizer) ServerOption {
+	return func(s *Server) {
+		s.tokenizer = tokenizer
+	}
+}
+
+// Close stops the micro-batching scheduler: the pending and the following
+// requests fail with microbatching.ErrClosed.
+func (s *Server) Close() {
+	s.scheduler.Close()
 }
 
 // StartDefaultServer is used to start a basic BERT HTTP server.
@@ -71,10 +99,21 @@ type QABody struct {
 	Passage  string `json:"passage"`
 }
 
-func pad(words []string) []string {
-	leftPad := wordpiecetokenizer.DefaultClassToken
-	rightPad := wordpiecetokenizer.DefaultSequenceSeparator
-	return append([]string{leftPad}, append(words, rightPad)...)
+// pad surrounds the words with the class token and the separator of the model.
+func (s *Server) pad(words []string) []string {
+	specialTokens := s.model.Config.SpecialTokens()
+	return append([]string{specialTokens.Class}, append(words, specialTokens.Separator)...)
+}
+
+// padPair returns the tokens of a pair of sequences, after the class token and each followed
+// by the separator, which is repeated between the sequences of RoBERTa.
+func (s *Server) padPair(first, second []string) []string {
+	separator := s.model.Config.SpecialTokens().Separator
+	tokens := s.pad(first)
+	if s.model.Config.IsRoBERTa() {
+		tokens = append(tokens, separator)
+	}
+	return append(append(tokens, second...), separator)
 }
 
 // DefaultRealLabel is the default value for the real label used for BERT
@@ -9,17 +9,15 @@ import (
 	"encoding/json"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"net/http"
-	"runtime"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
-	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
-	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert/grpcapi"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 )
 
 // QaHandler is the HTTP server handler function for BERT question-answering requests.
@@ -34,7 +32,11 @@ func (s *Server) QaHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	result := s.answer(body.Question, body.Passage)
+	result, err := s.answer(req.Context(), body.Question, body.Passage)
+	if err != nil {
+		http.Error(w, err.Error(), microbatching.HTTPStatusCode(err))
+		return
+	}
 	_, pretty := req.URL.Query()["pretty"]
 	response, err := Dump(result, pretty)
 	if err != nil {
@@ -52,7 +54,10 @@ func (s *Server) QaHandler(w http.ResponseWriter, req *http.Request) {
 // Answer handles a question-answering request over gRPC.
 // TODO(evanmcclure@gmail.com) Reuse the gRPC message type for HTTP requests.
 func (s *Server) Answer(ctx context.Context, req *grpcapi.AnswerRequest) (*grpcapi.AnswerReply, error) {
-	result := s.answer(req.GetQuestion(), req.GetPassage())
+	result, err := s.answer(ctx, req.GetQuestion(), req.GetPassage())
+	if err != nil {
+		return nil, microbatching.GRPCError(err)
+	}
 
 	return &grpcapi.AnswerReply{
 		Answers: answersFrom(result),
@@ -76,30 +81,34 @@ func answersFrom(resp *QuestionAnsweringResponse) []*grpcapi.Answer {
 }
 
 // TODO: This method is too long; it needs to be refactored.
-func (s *Server) answer(question string, passage string) *QuestionAnsweringResponse {
+func (s *Server) answer(ctx context.Context, question string, passage string) (*QuestionAnsweringResponse, error) {
 	start := time.Now()
 
-	tokenizer := wordpiecetokenizer.New(s.model.Vocabulary)
-	origQuestionTokens := tokenizer.Tokenize(question)
-	origPassageTokens := tokenizer.Tokenize(passage)
+	origQuestionTokens, err := s.tokenizer.Tokenize(question)
+	if err != nil {
+		return nil, err
+	}
+	origPassageTokens, err := s.tokenizer.Tokenize(passage)
+	if err != nil {
+		return nil, err
+	}
+	tokenized := s.padPair(tokenizers.GetStrings(origQuestionTokens), tokenizers.GetStrings(origPassageTokens))
 
-	cls := wordpiecetokenizer.DefaultClassToken
-	sep := wordpiecetokenizer.DefaultSequenceSeparator
-	tokenized := append([]string{cls}, append(tokenizers.GetStrings(origQuestionTokens), sep)...)
-	tokenized = append(tokenized, append(tokenizers.GetStrings(origPassageTokens), sep)...)
+	passageEndIndex := len(tokenized) - 1 // -1 because of the final separator
+	passageStartIndex := passageEndIndex - len(origPassageTokens)
 
-	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
-	defer g.Clear()
-	ctx := nn.Context{Graph: g, Mode: nn.Inference}
-	proc := nn.Reify(ctx, s.model).(*Model)
-	encoded := proc.Encode(tokenized)
+	result, err := s.submit(ctx, tokenized, func(proc *Model, encoded []ag.Node) interface{} {
+		startLogits, endLogits := proc.SpanClassifier.Classify(encoded)
+		startLogits, endLogits = startLogits[passageStartIndex:passageEndIndex], endLogits[passageStartIndex:passageEndIndex] // cut invalid positions
+		return [2][]mat.Float{extractScores(startLogits), extractScores(endLogits)}
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	passageStartIndex := len(origQuestionTokens) + 2 // +2 because of [CLS] and [SEP]
-	passageEndIndex := passageStartIndex + len(origPassageTokens)
-	startLogits, endLogits := proc.SpanClassifier.Classify(encoded)
-	startLogits, endLogits = startLogits[passageStartIndex:passageEndIndex], endLogits[passageStartIndex:passageEndIndex] // cut invalid positions
-	startIndices := getBestIndices(extractScores(startLogits), defaultMaxCandidateLogits)
-	endIndices := getBestIndices(extractScores(endLogits), defaultMaxCandidateLogits)
+	startScores, endScores := result.([2][]mat.Float)[0], result.([2][]mat.Float)[1]
+	startIndices := getBestIndices(startScores, defaultMaxCandidateLogits)
+	endIndices := getBestIndices(endScores, defaultMaxCandidateLogits)
 
 	candidateAnswers := make([]Answer, 0)
 	scores := make([]mat.Float, 0) // the scores are aligned with the candidateAnswers
@@ -113,7 +122,7 @@ func (s *Server) answer(question string, passage string) *QuestionAnsweringRespo
 			default:
 				startOffset := origPassageTokens[startIndex].Offsets.Start
 				endOffset := origPassageTokens[endIndex].Offsets.End
-				scores = append(scores, startLogits[startIndex].ScalarValue()+endLogits[endIndex].ScalarValue())
+				scores = append(scores, startScores[startIndex]+endScores[endIndex])
 				candidateAnswers = append(candidateAnswers, Answer{
 					Text:  strings.Trim(string([]rune(passage)[startOffset:endOffset]), " "),
 					Start: startOffset,
@@ -126,7 +135,7 @@ func (s *Server) answer(question string, passage string) *QuestionAnsweringRespo
 	if len(candidateAnswers) == 0 {
 		return &QuestionAnsweringResponse{
 			Answers: AnswerSlice{},
-		}
+		}, nil
 	}
 
 	probs := floatutils.SoftMax(scores)
@@ -146,5 +155,5 @@ func (s *Server) answer(question string, passage string) *QuestionAnsweringRespo
 	return &QuestionAnsweringResponse{
 		Answers: answers,
 		Took:    time.Since(start).Milliseconds(),
-	}
+	}, nil
 }
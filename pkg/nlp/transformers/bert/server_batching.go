@@ -0,0 +1,63 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"context"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
+	"runtime"
+)
+
+// ServerOption allows to configure a new Server with your specific needs.
+type ServerOption func(*Server)
+
+// WithBatching sets the configuration of the micro-batching scheduler shared by the
+// HTTP and gRPC handlers. The default is microbatching.DefaultConfig().
+func WithBatching(config microbatching.Config) ServerOption {
+	return func(s *Server) {
+		s.batching = config
+	}
+}
+
+// encodingJob is a request to be processed by the micro-batching scheduler: the
+// tokens are encoded together with the ones of the other jobs of the batch, then
+// respond computes the result from their encoding, using the same processor.
+type encodingJob struct {
+	tokens  []string
+	respond func(proc *Model, encoded []ag.Node) interface{}
+}
+
+// submit encodes the tokens within a batch and returns the result of respond.
+// It fails with microbatching.ErrQueueFull when the server is overloaded.
+func (s *Server) submit(
+	ctx context.Context,
+	tokens []string,
+	respond func(proc *Model, encoded []ag.Node) interface{},
+) (interface{}, error) {
+	return s.scheduler.Submit(ctx, encodingJob{tokens: tokens, respond: respond})
+}
+
+// processBatch is the microbatching.ProcessFunc of the Server.
+func (s *Server) processBatch(inputs []interface{}) ([]interface{}, []error) {
+	jobs := make([]encodingJob, len(inputs))
+	tokens := make([][]string, len(inputs))
+	for i, input := range inputs {
+		jobs[i] = input.(encodingJob)
+		tokens[i] = jobs[i].tokens
+	}
+
+	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()), ag.FusedOperators(true))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
+	encoded := proc.EncodeBatch(tokens)
+
+	outputs := make([]interface{}, len(jobs))
+	for i, job := range jobs {
+		outputs[i] = job.respond(proc, encoded[i])
+	}
+	return outputs, nil
+}
@@ -10,15 +10,13 @@ import (
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert/grpcapi"
 	"net/http"
-	"runtime"
 	"sort"
 	"time"
 
 	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
-	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
-	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 )
 
 // ClassifyHandler handles a classify request over HTTP.
@@ -33,7 +31,11 @@ func (s *Server) ClassifyHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	result := s.classify(body.Text, body.Text2)
+	result, err := s.classify(req.Context(), body.Text, body.Text2)
+	if err != nil {
+		http.Error(w, err.Error(), microbatching.HTTPStatusCode(err))
+		return
+	}
 	_, pretty := req.URL.Query()["pretty"]
 	response, err := Dump(result, pretty)
 	if err != nil {
@@ -65,8 +67,11 @@ type ClassifyResponse struct {
 
 // Classify handles a classification request over gRPC.
 // TODO(evanmcclure@gmail.com) Reuse the gRPC message type for HTTP requests.
-func (s *Server) Classify(_ context.Context, req *grpcapi.ClassifyRequest) (*grpcapi.ClassifyReply, error) {
-	result := s.classify(req.GetText(), req.GetText2())
+func (s *Server) Classify(ctx context.Context, req *grpcapi.ClassifyRequest) (*grpcapi.ClassifyReply, error) {
+	result, err := s.classify(ctx, req.GetText(), req.GetText2())
+	if err != nil {
+		return nil, microbatching.GRPCError(err)
+	}
 	return classificationFrom(result), nil
 }
 
@@ -87,31 +92,40 @@ func classificationFrom(resp *ClassifyResponse) *grpcapi.ClassifyReply {
 	}
 }
 
-func (s *Server) getTokenized(text, text2 string) []string {
-	cls := wordpiecetokenizer.DefaultClassToken
-	sep := wordpiecetokenizer.DefaultSequenceSeparator
-	tokenizer := wordpiecetokenizer.New(s.model.Vocabulary)
-	tokenized := append([]string{cls}, append(tokenizers.GetStrings(tokenizer.Tokenize(text)), sep)...)
-	if text2 != "" {
-		tokenized = append(tokenized, append(tokenizers.GetStrings(tokenizer.Tokenize(text2)), sep)...)
+func (s *Server) getTokenized(text, text2 string) ([]string, error) {
+	tokens, err := s.tokenizer.Tokenize(text)
+	if err != nil {
+		return nil, err
 	}
-	return tokenized
+	if text2 == "" {
+		return s.pad(tokenizers.GetStrings(tokens)), nil
+	}
+	tokens2, err := s.tokenizer.Tokenize(text2)
+	if err != nil {
+		return nil, err
+	}
+	return s.padPair(tokenizers.GetStrings(tokens), tokenizers.GetStrings(tokens2)), nil
 }
 
 // TODO: This method is too long; it needs to be refactored.
 // For the textual inference task, text is the premise and text2 is the hypothesis.
-func (s *Server) classify(text string, text2 string) *ClassifyResponse {
+func (s *Server) classify(ctx context.Context, text string, text2 string) (*ClassifyResponse, error) {
 	start := time.Now()
 
-	tokenized := s.getTokenized(text, text2)
+	tokenized, err := s.getTokenized(text, text2)
+	if err != nil {
+		return nil, err
+	}
 
-	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
-	defer g.Clear()
-	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
-	encoded := proc.Encode(tokenized)
+	result, err := s.submit(ctx, tokenized, func(proc *Model, encoded []ag.Node) interface{} {
+		logits := proc.SequenceClassification(encoded)
+		return floatutils.SoftMax(logits.Value().Data())
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	logits := proc.SequenceClassification(encoded)
-	probs := floatutils.SoftMax(logits.Value().Data())
+	probs := result.([]mat.Float)
 	best := floatutils.ArgMax(probs)
 	class := s.model.Classifier.Config.Labels[best]
 
@@ -132,5 +146,5 @@ func (s *Server) classify(text string, text2 string) *ClassifyResponse {
 		Confidence:   probs[best],
 		Distribution: distribution,
 		Took:         time.Since(start).Milliseconds(),
-	}
+	}, nil
 }
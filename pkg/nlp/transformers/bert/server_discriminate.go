@@ -8,14 +8,12 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"runtime"
 	"time"
 
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
-	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
-	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert/grpcapi"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 )
 
 // DiscriminateHandler handles a discriminate request over HTTP.
@@ -30,7 +28,11 @@ func (s *Server) DiscriminateHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	result := s.discriminate(body.Text)
+	result, err := s.discriminate(req.Context(), body.Text)
+	if err != nil {
+		http.Error(w, err.Error(), microbatching.HTTPStatusCode(err))
+		return
+	}
 	_, pretty := req.URL.Query()["pretty"]
 	response, err := Dump(result, pretty)
 	if err != nil {
@@ -48,7 +50,10 @@ func (s *Server) DiscriminateHandler(w http.ResponseWriter, req *http.Request) {
 // Discriminate handles a discriminate request over gRPC.
 // TODO(evanmcclure@gmail.com) Reuse the gRPC message type for HTTP requests.
 func (s *Server) Discriminate(ctx context.Context, req *grpcapi.DiscriminateRequest) (*grpcapi.DiscriminateReply, error) {
-	result := s.discriminate(req.GetText())
+	result, err := s.discriminate(ctx, req.GetText())
+	if err != nil {
+		return nil, microbatching.GRPCError(err)
+	}
 
 	return &grpcapi.DiscriminateReply{
 		Tokens: tokensFrom(result),
@@ -72,21 +77,25 @@ func tokensFrom(resp *Response) []*grpcapi.Token {
 }
 
 // TODO: This method is too long; it needs to be refactored.
-func (s *Server) discriminate(text string) *Response {
+func (s *Server) discriminate(ctx context.Context, text string) (*Response, error) {
 	start := time.Now()
 
-	tokenizer := wordpiecetokenizer.New(s.model.Vocabulary)
-	origTokens := tokenizer.Tokenize(text)
-	groupedTokens := wordpiecetokenizer.GroupPieces(origTokens)
-	tokenized := pad(tokenizers.GetStrings(origTokens))
+	origTokens, err := s.tokenizer.Tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	groupedTokens := groupWords(s.tokenizer, origTokens)
+	tokenized := s.pad(tokenizers.GetStrings(origTokens))
 
-	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
-	defer g.Clear()
-	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
-	encoded := proc.Encode(tokenized)
+	result, err := s.submit(ctx, tokenized, func(proc *Model, encoded []ag.Node) interface{} {
+		return proc.Discriminate(encoded)
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	fakeTokens := make(map[int]bool, 0)
-	for i, fake := range proc.Discriminate(encoded) {
+	for i, fake := range result.([]int) {
 		if i == 0 || i == len(tokenized)-1 {
 			continue // skip padding
 		}
@@ -120,5 +129,5 @@ func (s *Server) discriminate(text string) *Response {
 			Label: label,
 		})
 	}
-	return &Response{Tokens: retTokens, Took: time.Since(start).Milliseconds()}
+	return &Response{Tokens: retTokens, Took: time.Since(start).Milliseconds()}, nil
 }
@@ -10,13 +10,11 @@ import (
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert/grpcapi"
 	"net/http"
-	"runtime"
 	"time"
 
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
-	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
-	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 )
 
 // SentenceEncoderHandler handles a sentence encoding request over HTTP.
@@ -31,7 +29,11 @@ func (s *Server) SentenceEncoderHandler(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	result := s.encode(body.Text)
+	result, err := s.encode(req.Context(), body.Text)
+	if err != nil {
+		http.Error(w, err.Error(), microbatching.HTTPStatusCode(err))
+		return
+	}
 	_, pretty := req.URL.Query()["pretty"]
 	response, err := Dump(result, pretty)
 	if err != nil {
@@ -55,8 +57,11 @@ type EncodeResponse struct {
 
 // Encode handles an encoding request over gRPC.
 // TODO(evanmcclure@gmail.com) Reuse the gRPC message type for HTTP requests.
-func (s *Server) Encode(_ context.Context, req *grpcapi.EncodeRequest) (*grpcapi.EncodeReply, error) {
-	result := s.encode(req.GetText())
+func (s *Server) Encode(ctx context.Context, req *grpcapi.EncodeRequest) (*grpcapi.EncodeReply, error) {
+	result, err := s.encode(ctx, req.GetText())
+	if err != nil {
+		return nil, microbatching.GRPCError(err)
+	}
 
 	vector32 := make([]float32, len(result.Data))
 	for i, f64 := range result.Data {
@@ -70,22 +75,25 @@ func (s *Server) Encode(_ context.Context, req *grpcapi.EncodeRequest) (*grpcapi
 }
 
 // TODO: This method is too long; it needs to be refactored.
-func (s *Server) encode(text string) *EncodeResponse {
+func (s *Server) encode(ctx context.Context, text string) (*EncodeResponse, error) {
 	start := time.Now()
 
-	tokenizer := wordpiecetokenizer.New(s.model.Vocabulary)
-	origTokens := tokenizer.Tokenize(text)
-	tokenized := pad(tokenizers.GetStrings(origTokens))
+	origTokens, err := s.tokenizer.Tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	tokenized := s.pad(tokenizers.GetStrings(origTokens))
 
-	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
-	defer g.Clear()
-	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
-	encoded := proc.Encode(tokenized)
-	pooled := proc.Pool(encoded)
-	normalized := pooled.Value().(*mat.Dense).Normalize2()
+	result, err := s.submit(ctx, tokenized, func(proc *Model, encoded []ag.Node) interface{} {
+		pooled := proc.Pool(encoded)
+		return pooled.Value().(*mat.Dense).Normalize2()
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return &EncodeResponse{
-		Data: normalized.Data(),
+		Data: result.(*mat.Dense).Data(),
 		Took: time.Since(start).Milliseconds(),
-	}
+	}, nil
 }
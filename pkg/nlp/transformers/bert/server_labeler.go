@@ -5,6 +5,7 @@
 package bert
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
@@ -14,9 +15,9 @@ import (
 
 	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
-	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 )
 
 // LabelerOptionsType is a JSON-serializable set of options for BERT "tag" (labeler) requests.
@@ -43,7 +44,11 @@ func (s *Server) LabelerHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	result := s.label(body.Text, body.Options.MergeEntities, body.Options.FilterNotEntities)
+	result, err := s.label(req.Context(), body.Text, body.Options.MergeEntities, body.Options.FilterNotEntities)
+	if err != nil {
+		http.Error(w, err.Error(), microbatching.HTTPStatusCode(err))
+		return
+	}
 
 	_, pretty := req.URL.Query()["pretty"]
 	response, err := Dump(result, pretty)
@@ -60,38 +65,46 @@ func (s *Server) LabelerHandler(w http.ResponseWriter, req *http.Request) {
 }
 
 // TODO: This method is too long; it needs to be refactored.
-func (s *Server) label(text string, merge bool, filter bool) *Response {
+func (s *Server) label(ctx context.Context, text string, merge bool, filter bool) (*Response, error) {
 	start := time.Now()
 
-	tokenizer := wordpiecetokenizer.New(s.model.Vocabulary)
-	origTokens := tokenizer.Tokenize(text)
-	tokensRange := wordpiecetokenizer.GroupPieces(origTokens)
+	origTokens, err := s.tokenizer.Tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	tokensRange := groupWords(s.tokenizer, origTokens)
 	groupedTokens := wordpiecetokenizer.MakeOffsetPairsFromGroups(text, origTokens, tokensRange)
-	tokenized := pad(tokenizers.GetStrings(origTokens))
-
-	g := ag.NewGraph()
-	defer g.Clear()
-	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
-	encoded := proc.Encode(tokenized)
-	encoded = encoded[1 : len(encoded)-1] // trim [CLS] and [SEP]
-
-	// average pooling
-	avgEncoded := make([]ag.Node, len(tokensRange))
-	for i, group := range tokensRange {
-		cnt := 0
-		for j := group.Start; j <= group.End; j++ {
-			avgEncoded[i] = g.Add(avgEncoded[i], encoded[j])
-			cnt++
+	tokenized := s.pad(tokenizers.GetStrings(origTokens))
+
+	result, err := s.submit(ctx, tokenized, func(proc *Model, encoded []ag.Node) interface{} {
+		g := proc.Graph()
+		encoded = encoded[1 : len(encoded)-1] // trim [CLS] and [SEP]
+
+		// average pooling
+		avgEncoded := make([]ag.Node, len(tokensRange))
+		for i, group := range tokensRange {
+			cnt := 0
+			for j := group.Start; j <= group.End; j++ {
+				avgEncoded[i] = g.Add(avgEncoded[i], encoded[j])
+				cnt++
+			}
+			if cnt > 1 {
+				avgEncoded[i] = g.DivScalar(avgEncoded[i], g.NewScalar(mat.Float(cnt)))
+			}
 		}
-		if cnt > 1 {
-			avgEncoded[i] = g.DivScalar(avgEncoded[i], g.NewScalar(mat.Float(cnt)))
+
+		labels := make([]int, len(avgEncoded))
+		for i, logits := range proc.TokenClassification(avgEncoded) {
+			labels[i] = floatutils.ArgMax(floatutils.SoftMax(logits.Value().Data()))
 		}
+		return labels
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	retTokens := make([]Token, 0)
-	for i, logits := range proc.TokenClassification(avgEncoded) {
-		probs := floatutils.SoftMax(logits.Value().Data())
-		best := floatutils.ArgMax(probs)
+	for i, best := range result.([]int) {
 		retTokens = append(retTokens, Token{
 			Text:  groupedTokens[i].String,
 			Start: groupedTokens[i].Offsets.Start,
@@ -105,7 +118,7 @@ func (s *Server) label(text string, merge bool, filter bool) *Response {
 	if filter {
 		retTokens = filterNotEntities(retTokens)
 	}
-	return &Response{Tokens: retTokens, Took: time.Since(start).Milliseconds()}
+	return &Response{Tokens: retTokens, Took: time.Since(start).Milliseconds()}, nil
 }
 
 // TODO: make sure that the input label sequence is valid
@@ -8,15 +8,13 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"runtime"
 	"time"
 
 	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
-	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
-	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert/grpcapi"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
 )
 
 // PredictHandler handles a predict request over HTTP.
@@ -31,7 +29,11 @@ func (s *Server) PredictHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	result := s.predict(body.Text)
+	result, err := s.predict(req.Context(), body.Text)
+	if err != nil {
+		http.Error(w, err.Error(), microbatching.HTTPStatusCode(err))
+		return
+	}
 	_, pretty := req.URL.Query()["pretty"]
 	response, err := Dump(result, pretty)
 	if err != nil {
@@ -49,7 +51,10 @@ func (s *Server) PredictHandler(w http.ResponseWriter, req *http.Request) {
 // Predict handles a predict request over gRPC.
 // TODO(evanmcclure@gmail.com) Reuse the gRPC message type for HTTP requests.
 func (s *Server) Predict(ctx context.Context, req *grpcapi.PredictRequest) (*grpcapi.PredictReply, error) {
-	result := s.predict(req.GetText())
+	result, err := s.predict(ctx, req.GetText())
+	if err != nil {
+		return nil, microbatching.GRPCError(err)
+	}
 
 	return &grpcapi.PredictReply{
 		Tokens: tokensFrom(result),
@@ -58,31 +63,39 @@ func (s *Server) Predict(ctx context.Context, req *grpcapi.PredictRequest) (*grp
 }
 
 // TODO: This method is too long; it needs to be refactored.
-func (s *Server) predict(text string) *Response {
+func (s *Server) predict(ctx context.Context, text string) (*Response, error) {
 	start := time.Now()
 
-	tokenizer := wordpiecetokenizer.New(s.model.Vocabulary)
-	origTokens := tokenizer.Tokenize(text)
-	tokenized := pad(tokenizers.GetStrings(origTokens))
-
-	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
-	defer g.Clear()
-	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
-	encoded := proc.Encode(tokenized)
+	origTokens, err := s.tokenizer.Tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	tokenized := s.pad(tokenizers.GetStrings(origTokens))
+	specialTokens := s.model.Config.SpecialTokens()
 
 	masked := make([]int, 0)
 	for i := range tokenized {
-		if tokenized[i] == wordpiecetokenizer.DefaultMaskToken {
+		if tokenized[i] == specialTokens.Mask {
 			masked = append(masked, i)
 		}
 	}
 
+	result, err := s.submit(ctx, tokenized, func(proc *Model, encoded []ag.Node) interface{} {
+		best := make(map[int]int, len(masked))
+		for tokenID, prediction := range proc.PredictMasked(encoded, masked) {
+			best[tokenID] = floatutils.ArgMax(prediction.Value().Data())
+		}
+		return best
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	retTokens := make([]Token, 0)
-	for tokenID, prediction := range proc.PredictMasked(encoded, masked) {
-		bestPredictedWordIndex := floatutils.ArgMax(prediction.Value().Data())
+	for tokenID, bestPredictedWordIndex := range result.(map[int]int) {
 		word, ok := s.model.Vocabulary.Term(bestPredictedWordIndex)
 		if !ok {
-			word = wordpiecetokenizer.DefaultUnknownToken // if this is returned, there's a misalignment with the vocabulary
+			word = specialTokens.Unknown // if this is returned, there's a misalignment with the vocabulary
 		}
 		label := DefaultPredictedLabel
 		retTokens = append(retTokens, Token{
@@ -92,5 +105,5 @@ func (s *Server) predict(text string) *Response {
 			Label: label,
 		})
 	}
-	return &Response{Tokens: retTokens, Took: time.Since(start).Milliseconds()}
+	return &Response{Tokens: retTokens, Took: time.Since(start).Milliseconds()}, nil
 }
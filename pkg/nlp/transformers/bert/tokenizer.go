@@ -0,0 +1,208 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/bpetokenizer"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/sentencepiece"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
+	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const huggingFaceMergesFile = "merges.txt"
+
+// Tokenizer splits a text into the tokens of the vocabulary of a Model.
+type Tokenizer interface {
+	// Tokenize splits the text into tokens, without adding any special token.
+	Tokenize(text string) ([]tokenizers.StringOffsetsPair, error)
+	// IsContinuation reports whether the token continues the word of the previous one.
+	IsContinuation(token string) bool
+}
+
+// SpecialTokens contains the special tokens of the vocabulary of a Model.
+type SpecialTokens struct {
+	Class     string
+	Separator string
+	Mask      string
+	Pad       string
+	Unknown   string
+}
+
+// SpecialTokens returns the special tokens of the vocabulary of the model.
+func (c Config) SpecialTokens() SpecialTokens {
+	switch {
+	case c.IsRoBERTa():
+		return SpecialTokens{Class: "<s>", Separator: "</s>", Mask: "<mask>", Pad: "<pad>", Unknown: "<unk>"}
+	case c.ModelType == "albert":
+		return SpecialTokens{Class: "[CLS]", Separator: "[SEP]", Mask: "[MASK]", Pad: "<pad>", Unknown: "<unk>"}
+	default:
+		return SpecialTokens{
+			Class:     wordpiecetokenizer.DefaultClassToken,
+			Separator: wordpiecetokenizer.DefaultSequenceSeparator,
+			Mask:      wordpiecetokenizer.DefaultMaskToken,
+			Pad:       wordpiecetokenizer.DefaultPadToken,
+			Unknown:   wordpiecetokenizer.DefaultUnknownToken,
+		}
+	}
+}
+
+// IsSpecial reports whether the token is one of the special tokens.
+func (t SpecialTokens) IsSpecial(token string) bool {
+	switch token {
+	case t.Class, t.Separator, t.Mask, t.Pad, t.Unknown:
+		return true
+	default:
+		return false
+	}
+}
+
+// LoadTokenizer returns the tokenizer of the model, according to the files found in
+// the model folder: the byte-level BPE ("vocab.json" and "merges.txt") of RoBERTa, the
+// SentencePiece model of XLM-RoBERTa and ALBERT, or else the WordPiece tokenizer of
+// BERT over the given vocabulary. The special tokens of the configuration found in
+// the text are never split.
+func LoadTokenizer(modelPath string, config Config, vocab *vocabulary.Vocabulary) (Tokenizer, error) {
+	specialTokens := config.SpecialTokens()
+	if fileExists(path.Join(modelPath, huggingFaceVocabJSONFile)) && fileExists(path.Join(modelPath, huggingFaceMergesFile)) {
+		tokenizer, err := bpetokenizer.NewFromModelFolder(modelPath)
+		if err != nil {
+			return nil, err
+		}
+		return &bpeTokenizer{BPETokenizer: tokenizer, specialTokens: specialTokens}, nil
+	}
+	for _, filename := range []string{huggingFaceSentencePieceBPEFile, huggingFaceSentencePieceFile} {
+		if filename = path.Join(modelPath, filename); fileExists(filename) {
+			tokenizer, err := sentencepiece.NewFromFile(filename)
+			if err != nil {
+				return nil, err
+			}
+			return &sentencePieceTokenizer{Tokenizer: tokenizer, specialTokens: specialTokens}, nil
+		}
+	}
+	return NewWordPieceTokenizer(vocab), nil
+}
+
+// NewWordPieceTokenizer returns the WordPiece tokenizer of BERT over the given vocabulary.
+func NewWordPieceTokenizer(vocab *vocabulary.Vocabulary) Tokenizer {
+	return &wordPieceTokenizer{wordpiecetokenizer.New(vocab)}
+}
+
+type wordPieceTokenizer struct {
+	*wordpiecetokenizer.WordPieceTokenizer
+}
+
+func (t *wordPieceTokenizer) Tokenize(text string) ([]tokenizers.StringOffsetsPair, error) {
+	return t.WordPieceTokenizer.Tokenize(text), nil
+}
+
+func (t *wordPieceTokenizer) IsContinuation(token string) bool {
+	return strings.HasPrefix(token, wordpiecetokenizer.DefaultSplitPrefix)
+}
+
+type bpeTokenizer struct {
+	*bpetokenizer.BPETokenizer
+	specialTokens SpecialTokens
+}
+
+func (t *bpeTokenizer) Tokenize(text string) ([]tokenizers.StringOffsetsPair, error) {
+	return tokenizeAroundSpecialTokens(text, t.specialTokens, t.BPETokenizer.Tokenize)
+}
+
+// IsContinuation reports whether the token is part of a word without the
+// leading space, marked by "Ġ" in the byte-level vocabulary.
+func (t *bpeTokenizer) IsContinuation(token string) bool {
+	return !strings.HasPrefix(token, "Ġ") && startsWithLetterOrDigit(token)
+}
+
+type sentencePieceTokenizer struct {
+	*sentencepiece.Tokenizer
+	specialTokens SpecialTokens
+}
+
+func (t *sentencePieceTokenizer) Tokenize(text string) ([]tokenizers.StringOffsetsPair, error) {
+	return tokenizeAroundSpecialTokens(text, t.specialTokens, func(text string) ([]tokenizers.StringOffsetsPair, error) {
+		return t.Tokenizer.Tokenize(text), nil
+	})
+}
+
+// IsContinuation reports whether the token is part of a word without the
+// leading space, marked by "▁" in the SentencePiece vocabulary.
+func (t *sentencePieceTokenizer) IsContinuation(token string) bool {
+	return !strings.HasPrefix(token, "▁") && startsWithLetterOrDigit(token)
+}
+
+// tokenizeAroundSpecialTokens tokenizes the text between the special tokens, which are
+// kept as they are. The whitespace preceding a special token is dropped, as done by the
+// Hugging Face tokenizers for the mask token. The offsets refer to the runes of the text.
+func tokenizeAroundSpecialTokens(
+	text string,
+	specialTokens SpecialTokens,
+	tokenize func(text string) ([]tokenizers.StringOffsetsPair, error),
+) ([]tokenizers.StringOffsetsPair, error) {
+	result := make([]tokenizers.StringOffsetsPair, 0)
+	offset := 0
+	for text != "" {
+		i, special := indexOfSpecialToken(text, specialTokens)
+		if i < 0 {
+			i = len(text)
+		}
+		tokens, err := tokenize(strings.TrimRightFunc(text[:i], unicode.IsSpace))
+		if err != nil {
+			return nil, err
+		}
+		for _, token := range tokens {
+			token.Offsets.Start += offset
+			token.Offsets.End += offset
+			result = append(result, token)
+		}
+		offset += utf8.RuneCountInString(text[:i])
+		if special == "" {
+			break
+		}
+		size := utf8.RuneCountInString(special)
+		result = append(result, tokenizers.StringOffsetsPair{
+			String:  special,
+			Offsets: tokenizers.OffsetsType{Start: offset, End: offset + size},
+		})
+		offset += size
+		text = text[i+len(special):]
+	}
+	return result, nil
+}
+
+// indexOfSpecialToken returns the byte index of the first special token found in
+// the text and the token itself, or -1 if there is none.
+func indexOfSpecialToken(text string, specialTokens SpecialTokens) (int, string) {
+	index, found := -1, ""
+	for _, token := range []string{specialTokens.Class, specialTokens.Separator, specialTokens.Mask, specialTokens.Pad, specialTokens.Unknown} {
+		if i := strings.Index(text, token); i >= 0 && (index < 0 || i < index || (i == index && len(token) > len(found))) {
+			index, found = i, token
+		}
+	}
+	return index, found
+}
+
+func startsWithLetterOrDigit(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// groupWords returns the ranges of the tokens forming the same words.
+func groupWords(tokenizer Tokenizer, tokens []tokenizers.StringOffsetsPair) []wordpiecetokenizer.TokensRange {
+	groups := make([]wordpiecetokenizer.TokensRange, 0)
+	for i, token := range tokens {
+		if len(groups) > 0 && tokenizer.IsContinuation(token.String) {
+			groups[len(groups)-1].End = i
+			continue
+		}
+		groups = append(groups, wordpiecetokenizer.TokensRange{Start: i, End: i})
+	}
+	return groups
+}
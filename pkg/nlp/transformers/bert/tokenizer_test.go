@@ -0,0 +1,82 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
+	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func newTestModelDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "spago-bert-")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(path.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestConfig_SpecialTokens(t *testing.T) {
+	assert.Equal(t, "[CLS]", Config{ModelType: "bert"}.SpecialTokens().Class)
+	assert.Equal(t, "<mask>", Config{ModelType: "roberta"}.SpecialTokens().Mask)
+	assert.Equal(t, "</s>", Config{ModelType: "xlm-roberta"}.SpecialTokens().Separator)
+	assert.Equal(t, "<pad>", Config{ModelType: "albert"}.SpecialTokens().Pad)
+	assert.Equal(t, "[MASK]", Config{ModelType: "albert"}.SpecialTokens().Mask)
+	assert.Equal(t, "<unk>", Config{ModelType: "albert"}.UnknownToken())
+	assert.True(t, Config{ModelType: "roberta"}.SpecialTokens().IsSpecial("<s>"))
+	assert.False(t, Config{ModelType: "roberta"}.SpecialTokens().IsSpecial("[CLS]"))
+}
+
+func TestLoadTokenizer_WordPiece(t *testing.T) {
+	dir := newTestModelDir(t, nil)
+	vocab := vocabulary.New([]string{"[UNK]", "[CLS]", "[SEP]", "[MASK]", "the", "cat", "##s"})
+	tokenizer, err := LoadTokenizer(dir, Config{ModelType: "bert"}, vocab)
+	require.NoError(t, err)
+
+	tokens, err := tokenizer.Tokenize("the cats [MASK]")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"the", "cat", "##s", "[MASK]"}, tokenizers.GetStrings(tokens))
+	assert.Equal(t, []wordpiecetokenizer.TokensRange{{Start: 0, End: 0}, {Start: 1, End: 2}, {Start: 3, End: 3}}, groupWords(tokenizer, tokens))
+}
+
+func TestLoadTokenizer_BPE(t *testing.T) {
+	dir := newTestModelDir(t, map[string]string{
+		huggingFaceVocabJSONFile: `{"<s>": 0, "<pad>": 1, "</s>": 2, "<unk>": 3, "l": 4, "o": 5, "w": 6, "e": 7, "r": 8,
+			"Ġ": 9, "lo": 10, "low": 11, "Ġl": 12, "Ġlo": 13, "Ġlow": 14, "er": 15, ",": 16, "<mask>": 17}`,
+		huggingFaceMergesFile: "#version: 0.2\nĠ l\nĠl o\nĠlo w\nl o\nlo w\ne r\n",
+	})
+	tokenizer, err := LoadTokenizer(dir, Config{ModelType: "roberta"}, nil)
+	require.NoError(t, err)
+
+	tokens, err := tokenizer.Tokenize("low, lower <mask> low")
+	require.NoError(t, err)
+	assert.Equal(t, []tokenizers.StringOffsetsPair{
+		{String: "low", Offsets: tokenizers.OffsetsType{Start: 0, End: 3}},
+		{String: ",", Offsets: tokenizers.OffsetsType{Start: 3, End: 4}},
+		{String: "Ġlow", Offsets: tokenizers.OffsetsType{Start: 4, End: 8}},
+		{String: "er", Offsets: tokenizers.OffsetsType{Start: 8, End: 10}},
+		{String: "<mask>", Offsets: tokenizers.OffsetsType{Start: 11, End: 17}},
+		{String: "Ġlow", Offsets: tokenizers.OffsetsType{Start: 17, End: 21}},
+	}, tokens)
+	assert.Equal(t, []wordpiecetokenizer.TokensRange{{Start: 0, End: 0}, {Start: 1, End: 1}, {Start: 2, End: 3}, {Start: 4, End: 4}, {Start: 5, End: 5}}, groupWords(tokenizer, tokens))
+}
+
+func TestLoadVocabulary_VocabJSON(t *testing.T) {
+	dir := newTestModelDir(t, map[string]string{
+		huggingFaceVocabJSONFile: `{"<s>": 0, "<pad>": 1, "hello": 3}`,
+	})
+	vocab, err := loadVocabulary(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"<s>", "<pad>", "[unused2]", "hello"}, vocab.Items())
+	assert.NoFileExists(t, path.Join(dir, DefaultVocabularyFile))
+}
@@ -12,12 +12,12 @@ import (
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/mat32/rand"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/checkpoint"
 	"github.com/nlpodyssey/spago/pkg/ml/losses"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/gdmbuilder"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
-	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
 	"github.com/nlpodyssey/spago/pkg/utils"
 	"io"
 	"log"
@@ -33,8 +33,33 @@ type TrainingConfig struct {
 	UpdateMethod     gd.MethodConfig
 	CorpusPath       string
 	ModelPath        string
+	// SerializationInterval is the number of lines between two serializations
+	// of the model and of the checkpoints. Zero means 1000.
+	SerializationInterval int
+	// GradientCheckpointing sets whether to recompute the intermediate values of
+	// each encoder layer during the backward step, instead of keeping them, to
+	// reduce the memory required by long sequences (see ag.Graph.Checkpoint).
+	GradientCheckpointing bool
+	// NoDecayUpdateMethod optionally sets a distinct optimization method for the
+	// biases and the layer normalizations, usually without weight decay.
+	NoDecayUpdateMethod gd.MethodConfig
+	// LayerwiseLRDecay optionally decays the learning rate from the top encoder
+	// layer down to the embeddings (see ParamGroupsConfig).
+	LayerwiseLRDecay mat.Float
+	// FreezeEmbeddings sets whether the embeddings are not trained.
+	FreezeEmbeddings bool
+	// CheckpointDir optionally sets the directory of the training checkpoints,
+	// from which the training is resumed.
+	CheckpointDir string
+	// KeepCheckpoints is the number of most recent checkpoints to keep (zero keeps all).
+	KeepCheckpoints int
+	// Tokenizer optionally sets the tokenizer of the corpus (see LoadTokenizer).
+	// Nil means the WordPiece tokenizer over the vocabulary of the model.
+	Tokenizer Tokenizer
 }
 
+const defaultSerializationInterval = 1000
+
 // Trainer implements the training process for a BERT Model.
 type Trainer struct {
 	TrainingConfig
@@ -43,51 +68,136 @@ type Trainer struct {
 	bestLoss      mat.Float
 	lastBatchLoss mat.Float
 	model         *Model
+	tokenizer     Tokenizer
+	specialTokens SpecialTokens
 	countLine     int
+	checkpoints   *checkpoint.Manager
 }
 
 // NewTrainer returns a new BERT Trainer.
 func NewTrainer(model *Model, config TrainingConfig) *Trainer {
-	optimizer := gd.NewOptimizer(gdmbuilder.NewMethod(config.UpdateMethod), nn.NewDefaultParamsIterator(model))
+	groupsConfig := ParamGroupsConfig{
+		LayerwiseLRDecay: config.LayerwiseLRDecay,
+		FreezeEmbeddings: config.FreezeEmbeddings,
+	}
+	if config.NoDecayUpdateMethod != nil {
+		groupsConfig.NoDecayMethod = gdmbuilder.NewMethod(config.NoDecayUpdateMethod)
+	}
+	optimizer := gd.NewOptimizer(
+		gdmbuilder.NewMethod(config.UpdateMethod),
+		nn.NewDefaultParamsIterator(model),
+		gd.WithParamGroups(ParamGroups(model, groupsConfig)...),
+	)
 	if config.GradientClipping != 0.0 {
 		gd.ClipGradByNorm(config.GradientClipping, 2.0)(optimizer)
 	}
+	tokenizer := config.Tokenizer
+	if tokenizer == nil {
+		tokenizer = NewWordPieceTokenizer(model.Vocabulary)
+	}
 	return &Trainer{
 		TrainingConfig: config,
 		randGen:        rand.NewLockedRand(config.Seed),
 		optimizer:      optimizer,
 		model:          model,
+		tokenizer:      tokenizer,
+		specialTokens:  model.Config.SpecialTokens(),
 	}
 }
 
-// Train executes the training process.
+// Train executes the training process, resuming it from the latest checkpoint, if any.
 func (t *Trainer) Train() {
+	processed := t.resume()
 	t.forEachLine(func(i int, text string) {
+		if i < processed {
+			return // already processed before the last checkpoint
+		}
 		t.trainPassage(text)
 		t.optimizer.IncBatch()
 		t.optimizer.IncExample()
 		t.optimizer.Optimize()
+		t.countLine++
 
-		if i > 0 && i%1000 == 0 {
+		if t.countLine%t.serializationInterval() == 0 {
 			fmt.Println("=== MODEL SERIALIZATION")
 			err := utils.SerializeToFile(t.ModelPath, t.model)
 			if err != nil {
 				panic("bert: error during model serialization.")
 			}
+			t.saveCheckpoint()
 		}
-
-		t.countLine++
 	})
 }
 
-func (t *Trainer) tokenize(text string) []string {
-	tokenizer := wordpiecetokenizer.New(t.model.Vocabulary)
-	tokenized := append(tokenizers.GetStrings(tokenizer.Tokenize(text)), wordpiecetokenizer.DefaultSequenceSeparator)
-	return append([]string{wordpiecetokenizer.DefaultClassToken}, tokenized...)
+// serializationInterval returns the number of lines between two serializations
+// of the model.
+func (t *Trainer) serializationInterval() int {
+	if t.SerializationInterval > 0 {
+		return t.SerializationInterval
+	}
+	return defaultSerializationInterval
+}
+
+// newCheckpoint returns the checkpoint of the training process, whose position
+// is the number of lines already processed.
+func (t *Trainer) newCheckpoint() *checkpoint.Checkpoint {
+	return &checkpoint.Checkpoint{
+		Model:     t.model,
+		Optimizer: t.optimizer,
+		Rand:      t.randGen,
+		Step:      t.countLine,
+		Position:  t.countLine,
+	}
+}
+
+// resume restores the latest checkpoint, if any, and returns the number of
+// lines already processed.
+func (t *Trainer) resume() int {
+	if t.CheckpointDir == "" {
+		return 0
+	}
+	var err error
+	t.checkpoints, err = checkpoint.NewManager(t.CheckpointDir, t.KeepCheckpoints)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c := t.newCheckpoint()
+	found, err := t.checkpoints.LoadLatest(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if found {
+		fmt.Printf("=== RESUMED AFTER %d LINES\n", c.Position)
+	}
+	t.countLine = c.Position
+	return c.Position
+}
+
+// saveCheckpoint saves the checkpoint of the training process.
+func (t *Trainer) saveCheckpoint() {
+	if t.checkpoints == nil {
+		return
+	}
+	if _, err := t.checkpoints.Save(t.newCheckpoint()); err != nil {
+		panic("bert: error during checkpoint serialization.")
+	}
+}
+
+func (t *Trainer) tokenize(text string) ([]string, error) {
+	tokens, err := t.tokenizer.Tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	tokenized := append(tokenizers.GetStrings(tokens), t.specialTokens.Separator)
+	return append([]string{t.specialTokens.Class}, tokenized...), nil
 }
 
 func (t *Trainer) trainPassage(text string) {
-	tokenized := t.tokenize(text)
+	tokenized, err := t.tokenize(text)
+	if err != nil {
+		log.Printf("bert: skipping passage: %v", err)
+		return
+	}
 	if len(tokenized) > t.model.Embeddings.MaxPositions {
 		return // skip, sequence too long
 	}
@@ -101,7 +211,12 @@ func (t *Trainer) trainPassage(text string) {
 		return // skip, nothing to learn
 	}
 
-	encoded := proc.Encode(maskedTokens)
+	var encoded []ag.Node
+	if t.GradientCheckpointing {
+		encoded = proc.Encoder.ForwardCheckpointed(proc.Embeddings.Encode(maskedTokens)...)
+	} else {
+		encoded = proc.Encode(maskedTokens)
+	}
 	predicted := proc.PredictMasked(encoded, maskedIds)
 
 	var loss ag.Node
@@ -120,7 +235,7 @@ func (t *Trainer) trainPassage(text string) {
 
 func (t *Trainer) applyMask(tokens []string) (newTokens []string, maskedIds []int) {
 	for id, word := range tokens {
-		if wordpiecetokenizer.IsDefaultSpecial(word) { // don't mask special tokens
+		if t.specialTokens.IsSpecial(word) { // don't mask special tokens
 			newTokens = append(newTokens, word)
 			continue
 		}
@@ -138,7 +253,7 @@ func (t *Trainer) getMaskedForm(orig string) string {
 	prob := t.randGen.Float()
 	switch {
 	case prob < 0.80:
-		return wordpiecetokenizer.DefaultMaskToken
+		return t.specialTokens.Mask
 	case prob < 0.90:
 		randomID := int(mat.Floor(t.randGen.Float() * mat.Float(t.model.Vocabulary.Size())))
 		newWord, _ := t.model.Vocabulary.Term(randomID)
@@ -159,7 +274,7 @@ func (t *Trainer) forEachLine(callback func(i int, line string)) {
 		log.Fatal(err)
 	}
 	tarReader := tar.NewReader(uncompressedStream)
-	i := 0
+	i := 0 // the index of the first line is 0
 	for true {
 		header, err := tarReader.Next()
 		if err == io.EOF {
@@ -171,8 +286,8 @@ func (t *Trainer) forEachLine(callback func(i int, line string)) {
 		if header.Typeflag == tar.TypeReg {
 			scanner := bufio.NewScanner(tarReader)
 			for scanner.Scan() {
-				i++
 				callback(i, scanner.Text())
+				i++
 			}
 			if err := scanner.Err(); err != nil {
 				log.Fatal(err)
@@ -0,0 +1,144 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var trainerTestVocabulary = []string{"[PAD]", "[UNK]", "[CLS]", "[SEP]", "[MASK]", "the", "cat", "sat", "on", "mat"}
+
+// newTestModel returns a small BERT model, whose word embeddings are
+// stored into a new directory of dir.
+func newTestModel(t *testing.T, dir string) *Model {
+	storage, err := ioutil.TempDir(dir, "embeddings-")
+	require.NoError(t, err)
+	model := NewDefaultBERT(Config{
+		HiddenAct:             "gelu",
+		HiddenSize:            4,
+		IntermediateSize:      8,
+		MaxPositionEmbeddings: 16,
+		NumAttentionHeads:     2,
+		NumHiddenLayers:       1,
+		TypeVocabSize:         2,
+		VocabSize:             len(trainerTestVocabulary),
+	}, storage)
+	t.Cleanup(model.Embeddings.Words.Close)
+	model.Vocabulary = vocabulary.New(trainerTestVocabulary)
+
+	rndGen := rand.NewLockedRand(42)
+	for _, word := range trainerTestVocabulary {
+		embedding := mat.NewEmptyVecDense(4)
+		initializers.Normal(embedding, 0.0, 1.0, rndGen)
+		model.Embeddings.Words.SetEmbedding(word, embedding)
+	}
+	nn.ForEachParam(model, func(param nn.Param) {
+		if param.Type() == nn.Weights {
+			initializers.XavierUniform(param.Value(), 1.0, rndGen)
+		}
+	})
+	return model
+}
+
+// writeTrainerTestCorpus writes the lines into a gzip-compressed tar archive.
+func writeTrainerTestCorpus(t *testing.T, filename string, lines []string) {
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	content := strings.Join(lines, "\n") + "\n"
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     "corpus.txt",
+		Mode:     0644,
+		Size:     int64(len(content)),
+		Typeflag: tar.TypeReg,
+	}))
+	_, err = tw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+}
+
+func newTrainerTestConfig(dir, corpus, checkpointDir string) TrainingConfig {
+	return TrainingConfig{
+		Seed:                  3,
+		UpdateMethod:          sgd.NewConfig(0.1, 0.0, false),
+		CorpusPath:            corpus,
+		ModelPath:             filepath.Join(dir, "model.bin"),
+		SerializationInterval: 2,
+		FreezeEmbeddings:      true, // the word embeddings are not restored by the checkpoints
+		CheckpointDir:         checkpointDir,
+		KeepCheckpoints:       1,
+	}
+}
+
+func trainerTestParams(m *Model) [][]mat.Float {
+	var data [][]mat.Float
+	nn.ForEachParam(m.Encoder, func(param nn.Param) {
+		data = append(data, append([]mat.Float(nil), param.Value().Data()...))
+	})
+	return data
+}
+
+var trainerTestLines = []string{
+	"the cat sat on the mat the cat sat on the mat",
+	"the mat sat on the cat the mat sat on the cat",
+	"on the mat the cat sat on the mat the cat sat",
+	"the cat the mat the cat the mat the cat the mat",
+	"sat on the mat sat on the cat sat on the mat",
+}
+
+func TestTrainer_TrainFromScratch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-bert-")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) }) // after closing the embeddings storages
+	corpus := filepath.Join(dir, "corpus.tar.gz")
+	writeTrainerTestCorpus(t, corpus, trainerTestLines[:1])
+
+	model := newTestModel(t, dir)
+	initial := trainerTestParams(model)
+	NewTrainer(model, newTrainerTestConfig(dir, corpus, "")).Train()
+	assert.NotEqual(t, initial, trainerTestParams(model), "the first line must be consumed")
+}
+
+func TestTrainer_Resume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-bert-")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) }) // after closing the embeddings storages
+	checkpointDir := filepath.Join(dir, "checkpoints")
+	partialCorpus := filepath.Join(dir, "partial.tar.gz")
+	writeTrainerTestCorpus(t, partialCorpus, trainerTestLines[:3])
+	corpus := filepath.Join(dir, "corpus.tar.gz")
+	writeTrainerTestCorpus(t, corpus, trainerTestLines)
+
+	// interrupted after the third line, with a checkpoint after the second one
+	interrupted := newTestModel(t, dir)
+	NewTrainer(interrupted, newTrainerTestConfig(dir, partialCorpus, checkpointDir)).Train()
+
+	resumed := newTestModel(t, dir)
+	trainer := NewTrainer(resumed, newTrainerTestConfig(dir, corpus, checkpointDir))
+	trainer.Train()
+	assert.Equal(t, len(trainerTestLines), trainer.countLine)
+
+	uninterrupted := newTestModel(t, dir)
+	NewTrainer(uninterrupted, newTrainerTestConfig(dir, corpus, "")).Train()
+
+	assert.Equal(t, trainerTestParams(uninterrupted), trainerTestParams(resumed))
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/sentencepiece"
+	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+const (
+	huggingFaceVocabJSONFile        = "vocab.json"
+	huggingFaceSentencePieceFile    = "spiece.model"
+	huggingFaceSentencePieceBPEFile = "sentencepiece.bpe.model"
+)
+
+// loadVocabulary returns the vocabulary of the model, read from the "vocab.txt" file, one
+// term per line in the order of the IDs. When the model does not come with it, the vocabulary
+// is read from the files of the tokenizer ("vocab.json" of the byte-level BPE, or the
+// SentencePiece model).
+func loadVocabulary(modelPath string) (*vocabulary.Vocabulary, error) {
+	if filename := path.Join(modelPath, DefaultVocabularyFile); fileExists(filename) {
+		return vocabulary.NewFromFile(filename)
+	}
+	terms, err := readTokenizerVocabulary(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	return vocabulary.New(terms), nil
+}
+
+func readTokenizerVocabulary(modelPath string) ([]string, error) {
+	if filename := path.Join(modelPath, huggingFaceVocabJSONFile); fileExists(filename) {
+		return readVocabJSON(filename)
+	}
+	if filename := path.Join(modelPath, huggingFaceSentencePieceBPEFile); fileExists(filename) {
+		model, err := sentencepiece.LoadModel(filename)
+		if err != nil {
+			return nil, err
+		}
+		return fairseqVocabulary(model.Vocabulary()), nil
+	}
+	if filename := path.Join(modelPath, huggingFaceSentencePieceFile); fileExists(filename) {
+		model, err := sentencepiece.LoadModel(filename)
+		if err != nil {
+			return nil, err
+		}
+		return model.Vocabulary(), nil
+	}
+	return nil, fmt.Errorf("bert: no vocabulary found in `%s`", modelPath)
+}
+
+// readVocabJSON reads a vocabulary in the form of a JSON object mapping the terms to their IDs.
+// The missing IDs, if any, are filled with placeholders to preserve the positions of the terms.
+func readVocabJSON(filename string) ([]string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]int)
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, fmt.Errorf("bert: invalid vocabulary `%s`: %w", filename, err)
+	}
+	maxID := -1
+	for _, id := range ids {
+		if id > maxID {
+			maxID = id
+		}
+	}
+	terms := make([]string, maxID+1)
+	for term, id := range ids {
+		if id < 0 {
+			return nil, fmt.Errorf("bert: invalid ID %d for term `%s`", id, term)
+		}
+		terms[id] = term
+	}
+	for i, term := range terms {
+		if term == "" {
+			terms[i] = fmt.Sprintf("[unused%d]", i)
+		}
+	}
+	return terms, nil
+}
+
+// fairseqVocabulary aligns the SentencePiece vocabulary of XLM-RoBERTa to the IDs of the
+// original fairseq dictionary, which starts with "<s>", "<pad>", "</s>", "<unk>" and ends
+// with "<mask>". The first three pieces are the SentencePiece special symbols.
+func fairseqVocabulary(pieces []string) []string {
+	terms := []string{"<s>", "<pad>", "</s>", "<unk>"}
+	if len(pieces) > 3 {
+		terms = append(terms, pieces[3:]...)
+	}
+	return append(terms, "<mask>")
+}
+
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
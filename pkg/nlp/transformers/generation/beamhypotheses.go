@@ -0,0 +1,63 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generation
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+// beamHypotheses keeps the best finished hypotheses of a beam search.
+type beamHypotheses struct {
+	numBeams      int
+	lengthPenalty mat.Float
+	earlyStopping bool
+	hyps          []Hypothesis
+	worstScore    mat.Float
+}
+
+func newBeamHypotheses(numBeams int, lengthPenalty mat.Float, earlyStopping bool) *beamHypotheses {
+	return &beamHypotheses{
+		numBeams:      numBeams,
+		lengthPenalty: lengthPenalty,
+		earlyStopping: earlyStopping,
+		hyps:          make([]Hypothesis, 0, numBeams+1),
+		worstScore:    1e9,
+	}
+}
+
+// add adds a new hypothesis, possibly replacing the worst one.
+// The length is used to normalize the score according to the length penalty.
+func (b *beamHypotheses) add(tokenIDs []int, length int, sumLogProbs mat.Float) {
+	score := sumLogProbs / mat.Pow(mat.Float(length), b.lengthPenalty)
+	if len(b.hyps) >= b.numBeams && score <= b.worstScore {
+		return
+	}
+	b.hyps = append(b.hyps, Hypothesis{TokenIDs: tokenIDs, Score: score})
+	if len(b.hyps) > b.numBeams {
+		sortHypotheses(b.hyps)
+		b.hyps = b.hyps[:b.numBeams]
+		b.worstScore = b.hyps[len(b.hyps)-1].Score
+	} else if score < b.worstScore {
+		b.worstScore = score
+	}
+}
+
+// isDone reports whether the best running hypothesis can't improve the finished ones anymore.
+func (b *beamHypotheses) isDone(bestSumLogProbs mat.Float, curLen int) bool {
+	if len(b.hyps) < b.numBeams {
+		return false
+	}
+	if b.earlyStopping {
+		return true
+	}
+	return b.worstScore >= bestSumLogProbs/mat.Pow(mat.Float(curLen), b.lengthPenalty)
+}
+
+// sorted returns the finished hypotheses sorted by score, from the best one.
+func (b *beamHypotheses) sorted() []Hypothesis {
+	out := append([]Hypothesis{}, b.hyps...)
+	sortHypotheses(out)
+	return out
+}
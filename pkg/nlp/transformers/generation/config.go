@@ -0,0 +1,91 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generation
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+// Config provides configuration settings for the text generation.
+// The settings mirror those of the Hugging Face `generate()` method.
+type Config struct {
+	// MaxLength is the maximum length of the generated sequence, decoder start token included.
+	MaxLength int
+	// MinLength is the minimum length of the generated sequence, decoder start token included.
+	MinLength int
+	// NumBeams is the number of beams for beam search (1 means no beam search).
+	NumBeams int
+	// NumReturnSequences is the number of sequences to return, which can't be greater than
+	// NumBeams when using beam search.
+	NumReturnSequences int
+	// DecoderStartTokenID is the ID of the first token of each generated sequence.
+	DecoderStartTokenID int
+	// BOSTokenID is the ID of the beginning-of-sequence token.
+	BOSTokenID int
+	// EOSTokenID is the ID of the end-of-sequence token (a negative value means none).
+	EOSTokenID int
+	// ForceBOSTokenToBeGenerated forces the BOS token to be generated right after the decoder start token.
+	ForceBOSTokenToBeGenerated bool
+	// ForceEOSTokenAtMaxLength forces the EOS token to be generated when the max length is reached.
+	ForceEOSTokenAtMaxLength bool
+	// LengthPenalty is the exponential penalty to the length used in beam search:
+	// values < 1.0 encourage shorter sequences, values > 1.0 encourage longer sequences.
+	LengthPenalty mat.Float
+	// EarlyStopping makes the beam search stop as soon as NumBeams sentences are finished.
+	EarlyStopping bool
+	// NoRepeatNGramSize, if greater than 0, prevents all n-grams of that size from occurring twice.
+	NoRepeatNGramSize int
+	// DoSample enables sampling instead of greedy decoding (or plain beam search).
+	DoSample bool
+	// Temperature is used to module the next token probabilities when sampling (0 means 1).
+	Temperature mat.Float
+	// TopK is the number of highest probability tokens to keep when sampling (0 disables it).
+	TopK int
+	// TopP keeps the smallest set of most probable tokens with cumulative probability
+	// of at least TopP when sampling (0 or 1 disable it).
+	TopP mat.Float
+}
+
+// DefaultConfig returns a Config with the same default values used by Hugging Face.
+func DefaultConfig() Config {
+	return Config{
+		MaxLength:          20,
+		MinLength:          0,
+		NumBeams:           1,
+		NumReturnSequences: 1,
+		LengthPenalty:      1.0,
+		Temperature:        1.0,
+		TopK:               50,
+		TopP:               1.0,
+	}
+}
+
+// Validate returns an error if the configuration is not consistent.
+func (c Config) Validate() error {
+	if c.MaxLength < 1 {
+		return fmt.Errorf("generation: invalid max length %d", c.MaxLength)
+	}
+	if c.MinLength > c.MaxLength {
+		return fmt.Errorf("generation: min length %d greater than max length %d", c.MinLength, c.MaxLength)
+	}
+	if c.NumBeams < 1 {
+		return fmt.Errorf("generation: invalid number of beams %d", c.NumBeams)
+	}
+	if c.NumReturnSequences < 1 {
+		return fmt.Errorf("generation: invalid number of return sequences %d", c.NumReturnSequences)
+	}
+	if c.NumBeams > 1 && c.NumReturnSequences > c.NumBeams {
+		return fmt.Errorf("generation: number of return sequences %d greater than number of beams %d",
+			c.NumReturnSequences, c.NumBeams)
+	}
+	if !c.DoSample && c.NumBeams == 1 && c.NumReturnSequences > 1 {
+		return fmt.Errorf("generation: greedy decoding can't return more than one sequence")
+	}
+	if c.Temperature < 0 || c.TopK < 0 || c.TopP < 0 || c.TopP > 1 {
+		return fmt.Errorf("generation: invalid sampling parameters")
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package generation implements the autoregressive generation of sequences (e.g. text)
+// using greedy decoding, beam search and top-k/top-p sampling, on top of any model
+// which can decode the next token incrementally.
+package generation
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"sort"
+	"sync"
+)
+
+// Cache is the model-specific state carried between two decoding steps
+// (e.g. the keys and values of the past positions of a transformer).
+type Cache interface{}
+
+// Decoder is implemented by the models used for the autoregressive generation.
+type Decoder interface {
+	// Decode processes the given token IDs, following the ones already processed in the
+	// cache (nil at the first step), and returns the scores (i.e. logits) of the next
+	// token over the whole vocabulary, together with the updated cache.
+	// The cache passed in must not be modified, since it can be shared among several hypotheses.
+	Decode(tokenIDs []int, cache Cache) (mat.Matrix, Cache)
+}
+
+// Hypothesis is a generated sequence of token IDs with its score.
+type Hypothesis struct {
+	// TokenIDs is the generated sequence, decoder start token included.
+	TokenIDs []int
+	// Score is the sum of the log-probabilities of the generated tokens, normalized
+	// by the length penalty in case of beam search.
+	Score mat.Float
+}
+
+// Generator performs the autoregressive generation of sequences through a Decoder.
+type Generator struct {
+	config  Config
+	decoder Decoder
+	rndGen  *rand.LockedRand
+}
+
+// New returns a new Generator.
+// The random generator is used for sampling only; if nil, the global random source is used.
+func New(config Config, decoder Decoder, rndGen *rand.LockedRand) *Generator {
+	return &Generator{
+		config:  config,
+		decoder: decoder,
+		rndGen:  rndGen,
+	}
+}
+
+// Config returns the configuration of the Generator.
+func (g *Generator) Config() Config {
+	return g.config
+}
+
+// hypothesis is an unfinished sequence carried between the decoding steps.
+type hypothesis struct {
+	tokenIDs    []int
+	sumLogProbs mat.Float
+	cache       Cache
+	finished    bool
+}
+
+// StreamFunc receives each token as soon as it is generated, together with the index
+// of the sequence it belongs to.
+type StreamFunc func(sequenceIndex, tokenID int)
+
+// Generate generates the sequences starting from the given prefix and returns them sorted by score,
+// from the best one. If the prefix is empty, the generation starts from the decoder start token.
+func (g *Generator) Generate(prefix ...int) ([]Hypothesis, error) {
+	return g.GenerateStream(nil, prefix...)
+}
+
+// GenerateStream is the same as Generate, but it also passes each generated token to the
+// given function (if not nil), as soon as it is available.
+// Tokens are streamed by greedy decoding and sampling only, where the index of the sequence
+// refers to the order of generation rather than to the returned (sorted) hypotheses.
+// Beam search does not stream any token, since the best sequences are known at the end only.
+func (g *Generator) GenerateStream(fn StreamFunc, prefix ...int) ([]Hypothesis, error) {
+	if err := g.config.Validate(); err != nil {
+		return nil, err
+	}
+	if len(prefix) == 0 {
+		prefix = []int{g.config.DecoderStartTokenID}
+	}
+	if g.config.NumBeams > 1 {
+		return g.beamSearch(prefix), nil
+	}
+	return g.greedyOrSample(prefix, fn), nil
+}
+
+// greedyOrSample generates NumReturnSequences independent sequences choosing at each step
+// either the best next token or a sampled one.
+func (g *Generator) greedyOrSample(prefix []int, fn StreamFunc) []Hypothesis {
+	hyps := make([]*hypothesis, g.config.NumReturnSequences)
+	for i := range hyps {
+		hyps[i] = &hypothesis{tokenIDs: append([]int{}, prefix...)}
+	}
+	for curLen := len(prefix); curLen < g.config.MaxLength; curLen++ {
+		running := make([]*hypothesis, 0, len(hyps))
+		indices := make([]int, 0, len(hyps))
+		for i, h := range hyps {
+			if !h.finished {
+				running = append(running, h)
+				indices = append(indices, i)
+			}
+		}
+		if len(running) == 0 {
+			break
+		}
+		scores := g.nextScores(running)
+		for i, h := range running {
+			var next int
+			if g.config.DoSample {
+				warped := append([]mat.Float{}, scores[i]...)
+				g.warpScores(warped)
+				next = g.sample(floatutils.SoftMax(warped), 1)[0]
+			} else {
+				next = floatutils.ArgMax(scores[i])
+			}
+			h.sumLogProbs += scores[i][next]
+			h.tokenIDs = append(h.tokenIDs, next)
+			h.finished = next == g.config.EOSTokenID
+			if fn != nil {
+				fn(indices[i], next)
+			}
+		}
+	}
+	out := make([]Hypothesis, len(hyps))
+	for i, h := range hyps {
+		out[i] = Hypothesis{TokenIDs: h.tokenIDs, Score: h.sumLogProbs}
+	}
+	sortHypotheses(out)
+	return out
+}
+
+// beamSearch generates the sequences keeping the NumBeams best hypotheses at each step.
+func (g *Generator) beamSearch(prefix []int) []Hypothesis {
+	numBeams := g.config.NumBeams
+	finished := newBeamHypotheses(numBeams, g.config.LengthPenalty, g.config.EarlyStopping)
+	running := []*hypothesis{{tokenIDs: append([]int{}, prefix...)}}
+
+	curLen := len(prefix)
+	for ; curLen < g.config.MaxLength; curLen++ {
+		scores := g.nextScores(running)
+		for i, h := range running {
+			for j := range scores[i] {
+				scores[i][j] += h.sumLogProbs
+			}
+		}
+		next := make([]*hypothesis, 0, numBeams)
+		for _, c := range g.beamCandidates(scores, 2*numBeams) {
+			h := running[c.beam]
+			if c.tokenID == g.config.EOSTokenID {
+				if c.rank < numBeams {
+					finished.add(append(append([]int{}, h.tokenIDs...), c.tokenID), len(h.tokenIDs), c.score)
+				}
+				continue
+			}
+			next = append(next, &hypothesis{
+				tokenIDs:    append(append([]int{}, h.tokenIDs...), c.tokenID),
+				sumLogProbs: c.score,
+				cache:       h.cache,
+			})
+			if len(next) == numBeams {
+				break
+			}
+		}
+		running = next
+		if len(running) == 0 || finished.isDone(running[0].sumLogProbs, curLen+1) {
+			break
+		}
+	}
+
+	for _, h := range running {
+		finished.add(h.tokenIDs, len(h.tokenIDs), h.sumLogProbs)
+	}
+	out := finished.sorted()
+	if len(out) > g.config.NumReturnSequences {
+		out = out[:g.config.NumReturnSequences]
+	}
+	return out
+}
+
+// nextScores decodes the given hypotheses concurrently, updating their caches, and returns
+// the processed log-probabilities of the next token for each of them.
+func (g *Generator) nextScores(hyps []*hypothesis) [][]mat.Float {
+	scores := make([][]mat.Float, len(hyps))
+	var wg sync.WaitGroup
+	wg.Add(len(hyps))
+	for i, h := range hyps {
+		go func(i int, h *hypothesis) {
+			defer wg.Done()
+			input := h.tokenIDs
+			if h.cache != nil {
+				input = h.tokenIDs[len(h.tokenIDs)-1:]
+			}
+			var logits mat.Matrix
+			logits, h.cache = g.decoder.Decode(input, h.cache)
+			scores[i] = logSoftmax(logits.Data())
+			g.processScores(scores[i], h.tokenIDs)
+		}(i, h)
+	}
+	wg.Wait()
+	return scores
+}
+
+// beamCandidate is a possible continuation of a beam.
+type beamCandidate struct {
+	beam    int
+	tokenID int
+	score   mat.Float
+	rank    int
+}
+
+// beamCandidates returns the best (or sampled) n candidates among all the continuations of
+// all the beams, sorted by score.
+func (g *Generator) beamCandidates(scores [][]mat.Float, n int) []beamCandidate {
+	candidates := make([]beamCandidate, 0, n*len(scores))
+	if g.config.DoSample {
+		vocabSize := len(scores[0])
+		flat := make([]mat.Float, 0, vocabSize*len(scores))
+		for _, s := range scores {
+			warped := append([]mat.Float{}, s...)
+			g.warpScores(warped)
+			flat = append(flat, warped...)
+		}
+		for _, i := range g.sample(floatutils.SoftMax(flat), n) {
+			beam, tokenID := i/vocabSize, i%vocabSize
+			candidates = append(candidates, beamCandidate{beam: beam, tokenID: tokenID, score: scores[beam][tokenID]})
+		}
+	} else {
+		for beam, s := range scores {
+			for _, tokenID := range topKIndices(s, n) {
+				if mat.IsInf(s[tokenID], -1) {
+					break
+				}
+				candidates = append(candidates, beamCandidate{beam: beam, tokenID: tokenID, score: s[tokenID]})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	for i := range candidates {
+		candidates[i].rank = i
+	}
+	return candidates
+}
+
+// topKIndices returns the indices of the k highest values, sorted by value.
+func topKIndices(values []mat.Float, k int) []int {
+	indices := make([]int, len(values))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool { return values[indices[i]] > values[indices[j]] })
+	if k < len(indices) {
+		indices = indices[:k]
+	}
+	return indices
+}
+
+// sample draws n distinct indices (fewer if not enough of them have a non-zero
+// probability) from the given probability distribution, without replacement.
+func (g *Generator) sample(probs []mat.Float, n int) []int {
+	probs = append([]mat.Float{}, probs...)
+	out := make([]int, 0, n)
+	for len(out) < n {
+		total := floatutils.Sum(probs)
+		if total <= 0 {
+			break
+		}
+		rnd := g.randFloat() * total
+		choice := -1
+		var cumulative mat.Float = 0.0
+		for i, p := range probs {
+			if p == 0 {
+				continue
+			}
+			choice = i
+			cumulative += p
+			if rnd < cumulative {
+				break
+			}
+		}
+		out = append(out, choice)
+		probs[choice] = 0
+	}
+	return out
+}
+
+func (g *Generator) randFloat() mat.Float {
+	if g.rndGen != nil {
+		return g.rndGen.Float()
+	}
+	return rand.Float() // Warning: use global rand
+}
+
+func sortHypotheses(hyps []Hypothesis) {
+	sort.SliceStable(hyps, func(i, j int) bool { return hyps[i].Score > hyps[j].Score })
+}
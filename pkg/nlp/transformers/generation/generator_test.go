@@ -0,0 +1,177 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generation
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	startID = 0
+	aID     = 1
+	bID     = 2
+	eosID   = 3
+)
+
+// bigramDecoder is a toy Decoder whose next token probabilities depend on the last token only.
+// The cache holds the whole sequence processed so far.
+type bigramDecoder struct {
+	probs map[int][]mat.Float
+}
+
+func newBigramDecoder() *bigramDecoder {
+	return &bigramDecoder{
+		probs: map[int][]mat.Float{
+			startID: {0.0, 0.6, 0.4, 0.0},
+			aID:     {0.0, 0.3, 0.3, 0.4},
+			bID:     {0.0, 0.05, 0.05, 0.9},
+			eosID:   {0.0, 0.0, 0.0, 1.0},
+		},
+	}
+}
+
+func (d *bigramDecoder) Decode(tokenIDs []int, cache Cache) (mat.Matrix, Cache) {
+	var past []int
+	if cache != nil {
+		past = cache.([]int)
+	}
+	sequence := append(append([]int{}, past...), tokenIDs...)
+	probs := d.probs[sequence[len(sequence)-1]]
+	logits := make([]mat.Float, len(probs))
+	for i, p := range probs {
+		logits[i] = mat.Log(p + 1e-10)
+	}
+	return mat.NewVecDense(logits), sequence
+}
+
+func testConfig() Config {
+	config := DefaultConfig()
+	config.MaxLength = 10
+	config.DecoderStartTokenID = startID
+	config.EOSTokenID = eosID
+	return config
+}
+
+func TestGenerator_Greedy(t *testing.T) {
+	hyps, err := New(testConfig(), newBigramDecoder(), nil).Generate()
+	assert.NoError(t, err)
+	assert.Len(t, hyps, 1)
+	assert.Equal(t, []int{startID, aID, eosID}, hyps[0].TokenIDs)
+	assert.InDelta(t, mat.Log(0.6*0.4), hyps[0].Score, 1.0e-5)
+}
+
+func TestGenerator_BeamSearch(t *testing.T) {
+	config := testConfig()
+	config.NumBeams = 2
+	config.NumReturnSequences = 2
+	hyps, err := New(config, newBigramDecoder(), nil).Generate()
+	assert.NoError(t, err)
+	assert.Len(t, hyps, 2)
+	assert.Equal(t, []int{startID, bID, eosID}, hyps[0].TokenIDs)
+	assert.InDelta(t, mat.Log(0.4*0.9)/2, hyps[0].Score, 1.0e-5)
+	assert.Equal(t, []int{startID, aID, bID, eosID}, hyps[1].TokenIDs)
+	assert.InDelta(t, mat.Log(0.6*0.3*0.9)/3, hyps[1].Score, 1.0e-5)
+}
+
+func TestGenerator_MinLength(t *testing.T) {
+	config := testConfig()
+	config.MinLength = 4
+	hyps, err := New(config, newBigramDecoder(), nil).Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{startID, aID, aID, aID, eosID}, hyps[0].TokenIDs)
+}
+
+func TestGenerator_MaxLength(t *testing.T) {
+	config := testConfig()
+	config.MinLength = 3
+	config.MaxLength = 3
+	config.ForceEOSTokenAtMaxLength = false
+	hyps, err := New(config, newBigramDecoder(), nil).Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{startID, aID, aID}, hyps[0].TokenIDs)
+
+	config.ForceEOSTokenAtMaxLength = true
+	config.MinLength = 0
+	config.MaxLength = 2
+	hyps, err = New(config, newBigramDecoder(), nil).Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{startID, eosID}, hyps[0].TokenIDs)
+}
+
+func TestGenerator_ForceBOSTokenToBeGenerated(t *testing.T) {
+	config := testConfig()
+	config.BOSTokenID = bID
+	config.ForceBOSTokenToBeGenerated = true
+	hyps, err := New(config, newBigramDecoder(), nil).Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{startID, bID, eosID}, hyps[0].TokenIDs)
+}
+
+func TestGenerator_NoRepeatNGramSize(t *testing.T) {
+	config := testConfig()
+	config.MinLength = 5
+	config.NoRepeatNGramSize = 2
+	hyps, err := New(config, newBigramDecoder(), nil).Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{startID, aID, aID, bID, aID, eosID}, hyps[0].TokenIDs)
+}
+
+func TestGenerator_SampleWithTopK1IsGreedy(t *testing.T) {
+	config := testConfig()
+	config.DoSample = true
+	config.TopK = 1
+	config.NumReturnSequences = 3
+	hyps, err := New(config, newBigramDecoder(), rand.NewLockedRand(42)).Generate()
+	assert.NoError(t, err)
+	assert.Len(t, hyps, 3)
+	for _, hyp := range hyps {
+		assert.Equal(t, []int{startID, aID, eosID}, hyp.TokenIDs)
+	}
+}
+
+func TestGenerator_InvalidConfig(t *testing.T) {
+	config := testConfig()
+	config.NumBeams = 2
+	config.NumReturnSequences = 3
+	_, err := New(config, newBigramDecoder(), nil).Generate()
+	assert.Error(t, err)
+}
+
+func TestTopPFiltering(t *testing.T) {
+	scores := []mat.Float{mat.Log(0.5), mat.Log(0.1), mat.Log(0.3), mat.Log(0.1)}
+	topPFiltering(scores, 0.7)
+	assert.False(t, mat.IsInf(scores[0], -1))
+	assert.True(t, mat.IsInf(scores[1], -1))
+	assert.False(t, mat.IsInf(scores[2], -1))
+	assert.True(t, mat.IsInf(scores[3], -1))
+}
+
+func TestBannedNGramTokens(t *testing.T) {
+	assert.Equal(t, []int{2}, bannedNGramTokens([]int{1, 2, 3, 1}, 2))
+	assert.Equal(t, []int{3, 4}, bannedNGramTokens([]int{1, 2, 3, 1, 2, 4, 1, 2}, 3))
+	assert.Empty(t, bannedNGramTokens([]int{1, 2}, 4))
+}
+
+func TestGenerator_GenerateStream(t *testing.T) {
+	config := testConfig()
+	config.DoSample = true
+	config.NumReturnSequences = 2
+	streamed := make([][]int, 2)
+	hyps, err := New(config, newBigramDecoder(), rand.NewLockedRand(42)).GenerateStream(func(i, tokenID int) {
+		streamed[i] = append(streamed[i], tokenID)
+	})
+	assert.NoError(t, err)
+	assert.Len(t, hyps, 2)
+	for _, hyp := range hyps {
+		found := false
+		for _, s := range streamed {
+			found = found || assert.ObjectsAreEqual(hyp.TokenIDs[1:], s)
+		}
+		assert.True(t, found)
+	}
+}
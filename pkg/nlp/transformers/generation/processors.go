@@ -0,0 +1,126 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generation
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"sort"
+)
+
+// logSoftmax returns the log-probabilities of the given logits.
+func logSoftmax(logits []mat.Float) []mat.Float {
+	max := floatutils.Max(logits)
+	var sum mat.Float = 0.0
+	for _, v := range logits {
+		sum += mat.Exp(v - max)
+	}
+	logSum := max + mat.Log(sum)
+	out := make([]mat.Float, len(logits))
+	for i, v := range logits {
+		out[i] = v - logSum
+	}
+	return out
+}
+
+// processScores modifies the scores (i.e. log-probabilities) of the next token of the
+// given sequence according to the generation constraints.
+func (g *Generator) processScores(scores []mat.Float, sequence []int) {
+	curLen := len(sequence)
+	if curLen == 1 && g.config.ForceBOSTokenToBeGenerated {
+		forceToken(scores, g.config.BOSTokenID)
+	} else if curLen == g.config.MaxLength-1 && g.config.ForceEOSTokenAtMaxLength && g.config.EOSTokenID >= 0 {
+		forceToken(scores, g.config.EOSTokenID)
+	}
+	if curLen < g.config.MinLength && g.config.EOSTokenID >= 0 {
+		scores[g.config.EOSTokenID] = mat.Inf(-1)
+	}
+	if g.config.NoRepeatNGramSize > 0 {
+		for _, tokenID := range bannedNGramTokens(sequence, g.config.NoRepeatNGramSize) {
+			scores[tokenID] = mat.Inf(-1)
+		}
+	}
+}
+
+// forceToken sets all scores to -inf except the one of the given token, which is set to 0.
+func forceToken(scores []mat.Float, tokenID int) {
+	for i := range scores {
+		scores[i] = mat.Inf(-1)
+	}
+	scores[tokenID] = 0
+}
+
+// bannedNGramTokens returns the tokens which would complete an n-gram already present in the sequence.
+func bannedNGramTokens(sequence []int, n int) []int {
+	curLen := len(sequence)
+	if curLen+1 < n {
+		return nil
+	}
+	prefix := sequence[curLen-n+1:]
+	banned := make([]int, 0)
+	for start := 0; start+n <= curLen; start++ {
+		if equalInts(sequence[start:start+n-1], prefix) {
+			banned = append(banned, sequence[start+n-1])
+		}
+	}
+	return banned
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// warpScores applies temperature, top-k and top-p filtering to the scores before sampling.
+func (g *Generator) warpScores(scores []mat.Float) {
+	if t := g.config.Temperature; t > 0 && t != 1 {
+		for i := range scores {
+			scores[i] /= t
+		}
+	}
+	if k := g.config.TopK; k > 0 && k < len(scores) {
+		topKFiltering(scores, k)
+	}
+	if p := g.config.TopP; p > 0 && p < 1 {
+		topPFiltering(scores, p)
+	}
+}
+
+// topKFiltering sets to -inf all the scores lower than the k-th highest score.
+func topKFiltering(scores []mat.Float, k int) {
+	sorted := append([]mat.Float{}, scores...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+	threshold := sorted[k-1]
+	for i, v := range scores {
+		if v < threshold {
+			scores[i] = mat.Inf(-1)
+		}
+	}
+}
+
+// topPFiltering keeps the smallest set of the most probable scores whose cumulative
+// probability reaches p, setting all the others to -inf. At least one score is kept.
+func topPFiltering(scores []mat.Float, p mat.Float) {
+	probs := floatutils.SoftMax(scores)
+	indices := make([]int, len(scores))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool { return probs[indices[i]] > probs[indices[j]] })
+	var cumulative mat.Float = 0.0
+	for rank, i := range indices {
+		if rank > 0 && cumulative >= p {
+			scores[i] = mat.Inf(-1)
+		}
+		cumulative += probs[i]
+	}
+}
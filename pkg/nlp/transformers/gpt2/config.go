@@ -0,0 +1,79 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gpt2
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+const (
+	// DefaultConfigurationFile is the default GPT-2 JSON configuration filename.
+	DefaultConfigurationFile = "config.json"
+	// DefaultModelFile is the default GPT-2 spaGO model filename.
+	DefaultModelFile = "spago_model.bin"
+	// DefaultEmbeddingsStorage is the default directory name for GPT-2 model's embedding storage.
+	DefaultEmbeddingsStorage = "embeddings_storage"
+)
+
+// Config contains the global configuration of the GPT-2 model.
+// The configuration coincides with that of Hugging Face to facilitate compatibility between the two architectures.
+type Config struct {
+	ActivationFunction string   `json:"activation_function"`
+	Architectures      []string `json:"architectures"`
+	BosTokenID         int      `json:"bos_token_id"`
+	EosTokenID         int      `json:"eos_token_id"`
+	ModelType          string   `json:"model_type"`
+	NCtx               int      `json:"n_ctx"`
+	NEmbd              int      `json:"n_embd"`
+	NHead              int      `json:"n_head"`
+	// NInner is the size of the inner feed-forward layers (0 means 4 times NEmbd).
+	NInner             int                `json:"n_inner"`
+	NLayer             int                `json:"n_layer"`
+	NPositions         int                `json:"n_positions"`
+	TaskSpecificParams TaskSpecificParams `json:"task_specific_params"`
+	VocabSize          int                `json:"vocab_size"`
+	Training           bool               `json:"training"` // Custom for spaGO
+}
+
+// TaskSpecificParams contains the settings of the model for specific tasks.
+type TaskSpecificParams struct {
+	TextGeneration struct {
+		DoSample  bool `json:"do_sample"`
+		MaxLength int  `json:"max_length"`
+	} `json:"text-generation"`
+}
+
+// LoadConfig loads a GPT-2 model Config from file.
+// The missing values are set to the same defaults used by Hugging Face.
+func LoadConfig(file string) (Config, error) {
+	config := Config{
+		ActivationFunction: "gelu_new",
+		BosTokenID:         50256,
+		EosTokenID:         50256,
+		NCtx:               1024,
+		NEmbd:              768,
+		NHead:              12,
+		NLayer:             12,
+		NPositions:         1024,
+		VocabSize:          50257,
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// InnerSize returns the size of the inner feed-forward layers.
+func (c Config) InnerSize() int {
+	if c.NInner > 0 {
+		return c.NInner
+	}
+	return 4 * c.NEmbd
+}
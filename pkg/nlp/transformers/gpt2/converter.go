@@ -0,0 +1,225 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gpt2
+
+import (
+	"fmt"
+	"github.com/nlpodyssey/gopickle/pytorch"
+	"github.com/nlpodyssey/gopickle/types"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"github.com/nlpodyssey/spago/pkg/utils/gopickleutils"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const defaultHuggingFaceModelFile = "pytorch_model.bin"
+
+// ConvertHuggingFacePreTrained converts a HuggingFace pre-trained GPT-2
+// transformer model to a corresponding spaGO model.
+func ConvertHuggingFacePreTrained(modelPath string) error {
+	configFilename, err := exists(path.Join(modelPath, DefaultConfigurationFile))
+	if err != nil {
+		return err
+	}
+	pyTorchModelFilename, err := exists(path.Join(modelPath, defaultHuggingFaceModelFile))
+	if err != nil {
+		return err
+	}
+	config, err := LoadConfig(configFilename)
+	if err != nil {
+		return err
+	}
+
+	// Enable training mode, so that we have writing permissions
+	// (for example, for embeddings storage files).
+	config.Training = true
+
+	model := New(config, path.Join(modelPath, DefaultEmbeddingsStorage))
+	defer model.Close()
+
+	handler := &huggingFacePreTrainedConverter{
+		config:               config,
+		pyTorchModelFilename: pyTorchModelFilename,
+		modelFilename:        path.Join(modelPath, DefaultModelFile),
+		model:                model,
+	}
+	return handler.convert()
+}
+
+type huggingFacePreTrainedConverter struct {
+	config               Config
+	pyTorchModelFilename string
+	modelFilename        string
+	model                *Model
+}
+
+func exists(filename string) (string, error) {
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return filename, err
+	}
+	return filename, nil
+}
+
+func (c *huggingFacePreTrainedConverter) convert() error {
+	log.Printf("Start converting `%s`\nConfiguration: %+v\n", c.pyTorchModelFilename, c.config)
+	log.Printf("Extracting Hugging Face params from the PyTorch model...")
+	pyTorchParams, err := c.extractHuggingFaceParams()
+	if err != nil {
+		return err
+	}
+
+	if err := c.convertParams(pyTorchParams); err != nil {
+		return err
+	}
+
+	fmt.Printf("Serializing model to \"%s\"... ", c.modelFilename)
+	if err := utils.SerializeToFile(c.modelFilename, c.model); err != nil {
+		return fmt.Errorf("gpt2: error during model serialization: %w", err)
+	}
+	fmt.Println("ok")
+	fmt.Printf("GPT-2 has been converted successfully!\n")
+	return nil
+}
+
+// convertParams assigns the Hugging Face parameters, indexed by their names, to the
+// parameters of the model, after converting the weights of the Conv1D modules.
+func (c *huggingFacePreTrainedConverter) convertParams(pyTorchParams map[string][]mat.Float) error {
+	c.convertConv1DParams(pyTorchParams)
+
+	log.Printf("Convert embeddings... ")
+	wte, ok := pyTorchParams["wte.weight"]
+	if !ok || len(wte) != c.config.VocabSize*c.config.NEmbd {
+		return fmt.Errorf("gpt2: missing or invalid word embeddings")
+	}
+	for i := 0; i < c.config.VocabSize; i++ {
+		c.model.Embeddings.SetEmbeddingFromData(strconv.Itoa(i), wte[i*c.config.NEmbd:(i+1)*c.config.NEmbd])
+	}
+	wpe := pyTorchParams["wpe.weight"]
+	if len(wpe) != c.config.NPositions*c.config.NEmbd {
+		return fmt.Errorf("gpt2: missing or invalid position embeddings")
+	}
+	for i, param := range c.model.PositionEmbeddings {
+		param.Value().SetData(wpe[i*c.config.NEmbd : (i+1)*c.config.NEmbd])
+	}
+	log.Printf("Ok\n")
+
+	log.Printf("Search for matches with the mapped model to import weights...")
+	for paramName, dest := range mapModel(c.model) {
+		preTrainedWeights, ok := pyTorchParams[paramName]
+		if !ok {
+			log.Printf("WARNING!! `%s` not initialized", paramName)
+			continue
+		}
+		if dest.Size() != len(preTrainedWeights) {
+			return fmt.Errorf("gpt2: size mismatch for `%s`", paramName)
+		}
+		dest.SetData(preTrainedWeights)
+	}
+	return nil
+}
+
+// mapModel returns the parameters of the model indexed by the names of the corresponding
+// (converted) Hugging Face parameters.
+func mapModel(model *Model) map[string]mat.Matrix {
+	paramsMap := make(map[string]mat.Matrix)
+	for i, layer := range model.Layers {
+		prefixBase := fmt.Sprintf("h.%d", i)
+		paramsMap[prefixBase+".ln_1.weight"] = layer.AttentionLayerNorm.W.Value()
+		paramsMap[prefixBase+".ln_1.bias"] = layer.AttentionLayerNorm.B.Value()
+		for j, head := range layer.SelfAttention.Attention {
+			prefix := fmt.Sprintf("%s.attn.%d", prefixBase, j)
+			paramsMap[prefix+".query.weight"] = head.Query.W.Value()
+			paramsMap[prefix+".query.bias"] = head.Query.B.Value()
+			paramsMap[prefix+".key.weight"] = head.Key.W.Value()
+			paramsMap[prefix+".key.bias"] = head.Key.B.Value()
+			paramsMap[prefix+".value.weight"] = head.Value.W.Value()
+			paramsMap[prefix+".value.bias"] = head.Value.B.Value()
+		}
+		paramsMap[prefixBase+".attn.c_proj.weight"] = layer.SelfAttention.OutputMerge.W.Value()
+		paramsMap[prefixBase+".attn.c_proj.bias"] = layer.SelfAttention.OutputMerge.B.Value()
+		paramsMap[prefixBase+".ln_2.weight"] = layer.MLPLayerNorm.W.Value()
+		paramsMap[prefixBase+".ln_2.bias"] = layer.MLPLayerNorm.B.Value()
+		paramsMap[prefixBase+".mlp.c_fc.weight"] = layer.MLP.Layers[0].(*linear.Model).W.Value()
+		paramsMap[prefixBase+".mlp.c_fc.bias"] = layer.MLP.Layers[0].(*linear.Model).B.Value()
+		paramsMap[prefixBase+".mlp.c_proj.weight"] = layer.MLP.Layers[2].(*linear.Model).W.Value()
+		paramsMap[prefixBase+".mlp.c_proj.bias"] = layer.MLP.Layers[2].(*linear.Model).B.Value()
+	}
+	paramsMap["ln_f.weight"] = model.LayerNorm.W.Value()
+	paramsMap["ln_f.bias"] = model.LayerNorm.B.Value()
+	return paramsMap
+}
+
+func (c *huggingFacePreTrainedConverter) extractHuggingFaceParams() (map[string][]mat.Float, error) {
+	paramsMap := make(map[string][]mat.Float)
+	result, err := pytorch.Load(c.pyTorchModelFilename)
+	if err != nil {
+		return nil, err
+	}
+	od := result.(*types.OrderedDict)
+	for key, entry := range od.Map {
+		t := entry.Value.(*pytorch.Tensor)
+		paramName := normalizeParamName(key.(string))
+		fmt.Printf("Reading %s.... ", paramName)
+		switch t.Source.(type) {
+		case *pytorch.FloatStorage:
+			paramsMap[paramName] = gopickleutils.GetData(t)
+			fmt.Println("ok")
+		default:
+			fmt.Println("skip")
+		}
+	}
+	return paramsMap, nil
+}
+
+// normalizeParamName removes the "transformer." prefix of the parameters of the
+// GPT2LMHeadModel, so that they are named as the ones of the base GPT2Model.
+func normalizeParamName(orig string) string {
+	return strings.TrimPrefix(orig, "transformer.")
+}
+
+// convertConv1DParams transposes the weights of the Conv1D modules of Hugging Face,
+// which are stored as (input size, output size) matrices, and splits the combined
+// query-key-value projection of the attention into the projections of each head.
+func (c *huggingFacePreTrainedConverter) convertConv1DParams(paramsMap map[string][]mat.Float) {
+	size, inner := c.config.NEmbd, c.config.InnerSize()
+	dk := size / c.config.NHead
+	for i := 0; i < c.config.NLayer; i++ {
+		prefix := fmt.Sprintf("h.%d", i)
+		transposeParam(paramsMap, prefix+".attn.c_proj.weight", size, size)
+		transposeParam(paramsMap, prefix+".mlp.c_fc.weight", size, inner)
+		transposeParam(paramsMap, prefix+".mlp.c_proj.weight", inner, size)
+
+		if !transposeParam(paramsMap, prefix+".attn.c_attn.weight", size, 3*size) {
+			continue
+		}
+		weight := paramsMap[prefix+".attn.c_attn.weight"] // (3 * size, size)
+		bias := paramsMap[prefix+".attn.c_attn.bias"]
+		for j := 0; j < c.config.NHead; j++ {
+			headPrefix := fmt.Sprintf("%s.attn.%d", prefix, j)
+			for k, name := range []string{"query", "key", "value"} {
+				from := k*size + j*dk
+				to := from + dk
+				paramsMap[fmt.Sprintf("%s.%s.weight", headPrefix, name)] = weight[from*size : to*size]
+				paramsMap[fmt.Sprintf("%s.%s.bias", headPrefix, name)] = bias[from:to]
+			}
+		}
+	}
+}
+
+// transposeParam replaces the (rows, cols) weights of the given parameter with their
+// transpose, reporting whether the parameter exists.
+func transposeParam(paramsMap map[string][]mat.Float, name string, rows, cols int) bool {
+	data, ok := paramsMap[name]
+	if !ok || len(data) != rows*cols {
+		return false
+	}
+	paramsMap[name] = mat.NewDense(rows, cols, data).T().Data()
+	return true
+}
@@ -0,0 +1,153 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gpt2
+
+import (
+	"encoding/json"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+)
+
+func TestConverter_RoundTrip(t *testing.T) {
+	source := newTestModel(t)
+	defer source.Close()
+	config := source.Config
+	config.Training = false
+
+	dir, err := ioutil.TempDir("", "gpt2-converted")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	configData, err := json.Marshal(config)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, DefaultConfigurationFile), configData, 0644))
+
+	converted := New(source.Config, path.Join(dir, DefaultEmbeddingsStorage))
+	c := &huggingFacePreTrainedConverter{
+		config:        source.Config,
+		modelFilename: path.Join(dir, DefaultModelFile),
+		model:         converted,
+	}
+	require.NoError(t, c.convertParams(toHuggingFaceParams(source)))
+	require.NoError(t, utils.SerializeToFile(c.modelFilename, converted))
+	converted.Close()
+
+	loaded, err := LoadModel(dir)
+	require.NoError(t, err)
+	defer loaded.Close()
+
+	for name, expected := range mapModel(source) {
+		assert.Equal(t, expected.Data(), mapModel(loaded)[name].Data(), name)
+	}
+	for i, expected := range source.PositionEmbeddings {
+		assert.Equal(t, expected.Value().Data(), loaded.PositionEmbeddings[i].Value().Data())
+	}
+	for i := 0; i < config.VocabSize; i++ {
+		word := strconv.Itoa(i)
+		assert.Equal(t, source.Embeddings.GetStoredEmbedding(word).Value().Data(),
+			loaded.Embeddings.GetStoredEmbedding(word).Value().Data())
+	}
+
+	tokenIDs := []int{3, 1, 4}
+	expected := logits(source, tokenIDs)
+	actual := logits(loaded, tokenIDs)
+	for i := range expected {
+		assert.InDeltaSlice(t, expected[i], actual[i], 1.0e-6)
+	}
+}
+
+func TestConverter_InvalidEmbeddings(t *testing.T) {
+	source := newTestModel(t)
+	defer source.Close()
+	params := toHuggingFaceParams(source)
+	params["wpe.weight"] = params["wpe.weight"][1:]
+
+	dir, err := ioutil.TempDir("", "gpt2-converted")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	converted := New(source.Config, dir)
+	defer converted.Close()
+
+	c := &huggingFacePreTrainedConverter{config: source.Config, model: converted}
+	assert.EqualError(t, c.convertParams(params), "gpt2: missing or invalid position embeddings")
+}
+
+func TestNormalizeParamName(t *testing.T) {
+	assert.Equal(t, "h.0.ln_1.weight", normalizeParamName("transformer.h.0.ln_1.weight"))
+	assert.Equal(t, "ln_f.bias", normalizeParamName("ln_f.bias"))
+}
+
+// toHuggingFaceParams returns the parameters of the model as found in a Hugging Face
+// checkpoint: the weights of the Conv1D modules are transposed, and the projections
+// of the attention heads are combined into a single query-key-value projection.
+func toHuggingFaceParams(model *Model) map[string][]mat.Float {
+	config := model.Config
+	size, dk := config.NEmbd, config.NEmbd/config.NHead
+	params := make(map[string][]mat.Float)
+
+	wte := make([]mat.Float, 0, config.VocabSize*size)
+	for i := 0; i < config.VocabSize; i++ {
+		wte = append(wte, model.Embeddings.GetStoredEmbedding(strconv.Itoa(i)).Value().Data()...)
+	}
+	params["wte.weight"] = wte
+	wpe := make([]mat.Float, 0, config.NPositions*size)
+	for _, param := range model.PositionEmbeddings {
+		wpe = append(wpe, param.Value().Data()...)
+	}
+	params["wpe.weight"] = wpe
+
+	transposed := func(m mat.Matrix) []mat.Float {
+		return m.T().Data()
+	}
+	for i, layer := range model.Layers {
+		prefix := fmt.Sprintf("h.%d", i)
+		params[prefix+".ln_1.weight"] = layer.AttentionLayerNorm.W.Value().Data()
+		params[prefix+".ln_1.bias"] = layer.AttentionLayerNorm.B.Value().Data()
+		params[prefix+".ln_2.weight"] = layer.MLPLayerNorm.W.Value().Data()
+		params[prefix+".ln_2.bias"] = layer.MLPLayerNorm.B.Value().Data()
+
+		weight := make([]mat.Float, 3*size*size)
+		bias := make([]mat.Float, 3*size)
+		for j, head := range layer.SelfAttention.Attention {
+			for k, projection := range []*linear.Model{head.Query, head.Key, head.Value} {
+				from := k*size + j*dk
+				copy(weight[from*size:(from+dk)*size], projection.W.Value().Data())
+				copy(bias[from:from+dk], projection.B.Value().Data())
+			}
+		}
+		params[prefix+".attn.c_attn.weight"] = transposed(mat.NewDense(3*size, size, weight))
+		params[prefix+".attn.c_attn.bias"] = bias
+		params[prefix+".attn.c_proj.weight"] = transposed(layer.SelfAttention.OutputMerge.W.Value())
+		params[prefix+".attn.c_proj.bias"] = layer.SelfAttention.OutputMerge.B.Value().Data()
+		params[prefix+".mlp.c_fc.weight"] = transposed(layer.MLP.Layers[0].(*linear.Model).W.Value())
+		params[prefix+".mlp.c_fc.bias"] = layer.MLP.Layers[0].(*linear.Model).B.Value().Data()
+		params[prefix+".mlp.c_proj.weight"] = transposed(layer.MLP.Layers[2].(*linear.Model).W.Value())
+		params[prefix+".mlp.c_proj.bias"] = layer.MLP.Layers[2].(*linear.Model).B.Value().Data()
+	}
+	params["ln_f.weight"] = model.LayerNorm.W.Value().Data()
+	params["ln_f.bias"] = model.LayerNorm.B.Value().Data()
+	return params
+}
+
+func logits(model *Model, tokenIDs []int) [][]mat.Float {
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+	var out [][]mat.Float
+	for _, y := range proc.Forward(tokenIDs) {
+		out = append(out, proc.Logits(y).Value().Clone().Data()) // the values are released with the graph
+	}
+	return out
+}
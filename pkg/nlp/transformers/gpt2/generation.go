@@ -0,0 +1,71 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gpt2
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/generation"
+)
+
+var (
+	_ generation.Decoder = &Decoder{}
+)
+
+// NewGenerationConfig returns a new generation.Config initialized from the GPT-2
+// configuration, falling back to the Hugging Face defaults for the missing values.
+// The generation starts from the BOS token when the prompt is empty.
+func NewGenerationConfig(config Config) generation.Config {
+	c := generation.DefaultConfig()
+	c.DecoderStartTokenID = config.BosTokenID
+	c.BOSTokenID = config.BosTokenID
+	c.EOSTokenID = config.EosTokenID
+	c.DoSample = config.TaskSpecificParams.TextGeneration.DoSample
+	if maxLength := config.TaskSpecificParams.TextGeneration.MaxLength; maxLength > 0 {
+		c.MaxLength = maxLength
+	}
+	return c
+}
+
+// Decoder adapts a reified GPT-2 Model to the generation.Decoder interface.
+// It requires a graph with incremental forward (default), since the scores of
+// the next token are read as soon as they are defined.
+type Decoder struct {
+	// Model is the reified GPT-2 Model.
+	Model *Model
+}
+
+// Decode processes the given token IDs, following the ones already processed in the cache, and
+// returns the scores of the next token together with the updated cache of keys and values.
+func (d *Decoder) Decode(tokenIDs []int, cache generation.Cache) (mat.Matrix, generation.Cache) {
+	var past Cache
+	if cache != nil {
+		past = cache.(Cache)
+	}
+	ys, next := d.Model.ForwardWithCache(tokenIDs, past)
+	return d.Model.Logits(ys[len(ys)-1]).Value(), next
+}
+
+// Generate continues the given prompt according to the generation configuration, and returns
+// the generated sequences, prompt included. Use NewGenerationConfig to start from the default
+// settings of the model. The MaxLength can't exceed the maximum number of positions.
+// It returns an error if the prompt exceeds the maximum number of positions.
+// The processor must operate on a graph with incremental forward (default).
+func (m *Model) Generate(inputIDs []int, config generation.Config) ([]generation.Hypothesis, error) {
+	return m.GenerateStream(inputIDs, config, nil)
+}
+
+// GenerateStream is the same as Generate, but it also passes each generated token to the
+// given function as soon as it is available (see generation.Generator.GenerateStream).
+func (m *Model) GenerateStream(inputIDs []int, config generation.Config, fn generation.StreamFunc) ([]generation.Hypothesis, error) {
+	if len(inputIDs) > m.Config.NPositions {
+		return nil, fmt.Errorf("gpt2: prompt length %d exceeds the maximum number of positions %d",
+			len(inputIDs), m.Config.NPositions)
+	}
+	if config.MaxLength > m.Config.NPositions {
+		config.MaxLength = m.Config.NPositions
+	}
+	return generation.New(config, &Decoder{Model: m}, nil).GenerateStream(fn, inputIDs...)
+}
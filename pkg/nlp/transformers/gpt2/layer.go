@@ -0,0 +1,84 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gpt2
+
+import (
+	"encoding/gob"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/multiheadattention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/selfattention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/transformerutils"
+)
+
+var (
+	_ nn.Model = &Layer{}
+)
+
+// Layer implements a GPT-2 decoder layer, where the layer normalization is applied
+// before the masked self-attention and before the feed-forward block.
+type Layer struct {
+	nn.BaseModel
+	Config             Config
+	AttentionLayerNorm *layernorm.Model
+	SelfAttention      *multiheadattention.Model
+	MLPLayerNorm       *layernorm.Model
+	MLP                *stack.Model
+}
+
+func init() {
+	gob.Register(&Layer{})
+}
+
+// NewLayer returns a new GPT-2 Layer.
+func NewLayer(config Config) *Layer {
+	return &Layer{
+		Config:             config,
+		AttentionLayerNorm: layernorm.New(config.NEmbd),
+		SelfAttention: multiheadattention.New(
+			config.NEmbd,
+			config.NHead,
+			true, // use causal mask
+		),
+		MLPLayerNorm: layernorm.New(config.NEmbd),
+		MLP: stack.New(
+			linear.New(config.NEmbd, config.InnerSize()),
+			transformerutils.NewActivation(config.ActivationFunction),
+			linear.New(config.InnerSize(), config.NEmbd),
+		),
+	}
+}
+
+// Forward performs the forward step for each input and returns the result.
+func (m *Layer) Forward(xs ...ag.Node) []ag.Node {
+	ys, _ := m.ForwardWithCache(xs, nil)
+	return ys
+}
+
+// ForwardWithCache performs the forward step for each input and returns the result
+// together with the keys and values of the self-attention, one pair for each head.
+// The xs follow the positions whose keys and values are in the given cache (if any).
+func (m *Layer) ForwardWithCache(
+	xs []ag.Node,
+	past []selfattention.KeysValuesPair,
+) ([]ag.Node, []selfattention.KeysValuesPair) {
+	norm := m.AttentionLayerNorm.Forward(xs...)
+	att, keysValues := m.SelfAttention.ForwardWithPastKeysValues(attention.ToQKV(norm), past)
+	ys := m.add(xs, att)
+	ys = m.add(ys, m.MLP.Forward(m.MLPLayerNorm.Forward(ys...)...))
+	return ys, keysValues
+}
+
+func (m *Layer) add(a []ag.Node, b []ag.Node) []ag.Node {
+	c := make([]ag.Node, len(a))
+	for i := 0; i < len(a); i++ {
+		c[i] = m.Graph().Add(a[i], b[i])
+	}
+	return c
+}
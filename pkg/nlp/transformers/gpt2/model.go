@@ -0,0 +1,165 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gpt2 implements the decoder-only transformer language model introduced by Radford et al., 2019.
+// "Language Models are Unsupervised Multitask Learners"
+// https://d4mucfpksywv.cloudfront.net/better-language-models/language-models.pdf
+package gpt2
+
+import (
+	"encoding/gob"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/selfattention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
+	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/transformerutils"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"log"
+	"path"
+	"strconv"
+)
+
+var (
+	_ nn.Model = &Model{}
+)
+
+// Model implements a GPT-2 language model.
+// The language modeling head is tied to the word embeddings.
+type Model struct {
+	nn.BaseModel
+	Config Config
+	// Embeddings are the word embeddings, whose keys are the token IDs.
+	Embeddings         *embeddings.Model
+	PositionEmbeddings []nn.Param `spago:"type:weights"`
+	Layers             []*Layer
+	LayerNorm          *layernorm.Model
+	// LMHead lazily loads the word embeddings to be used as weights of the
+	// language modeling head. It is never serialized.
+	LMHead *transformerutils.TiedLMHead `spago:"scope:model"`
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new GPT-2 Model.
+func New(config Config, embeddingsStoragePath string) *Model {
+	positions := make([]nn.Param, config.NPositions)
+	for i := range positions {
+		positions[i] = nn.NewParam(mat.NewEmptyVecDense(config.NEmbd))
+	}
+	layers := make([]*Layer, config.NLayer)
+	for i := range layers {
+		layers[i] = NewLayer(config)
+	}
+	return &Model{
+		Config: config,
+		Embeddings: embeddings.New(embeddings.Config{
+			Size:       config.NEmbd,
+			DBPath:     embeddingsStoragePath,
+			ReadOnly:   !config.Training,
+			ForceNewDB: false,
+		}),
+		PositionEmbeddings: positions,
+		Layers:             layers,
+		LayerNorm:          layernorm.New(config.NEmbd),
+		LMHead:             &transformerutils.TiedLMHead{},
+	}
+}
+
+// Close closes the GPT-2 model's embeddings DB.
+func (m *Model) Close() {
+	m.Embeddings.Close()
+}
+
+// LoadModel loads a GPT-2 Model from file.
+func LoadModel(modelPath string) (*Model, error) {
+	configFilename := path.Join(modelPath, DefaultConfigurationFile)
+	embeddingsPath := path.Join(modelPath, DefaultEmbeddingsStorage)
+	modelFilename := path.Join(modelPath, DefaultModelFile)
+
+	fmt.Printf("Start loading pre-trained model from \"%s\"\n", modelPath)
+	fmt.Printf("[1/2] Loading configuration... ")
+	config, err := LoadConfig(configFilename)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("ok\n")
+	model := New(config, embeddingsPath)
+
+	fmt.Printf("[2/2] Loading model weights... ")
+	err = utils.DeserializeFromFile(modelFilename, model)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("gpt2: error during model deserialization (%s)", err.Error()))
+	}
+	if model.LMHead == nil {
+		model.LMHead = &transformerutils.TiedLMHead{}
+	}
+	fmt.Println("ok")
+
+	return model, nil
+}
+
+// Cache contains the keys and values of the self-attention of each Layer,
+// one pair for each head.
+type Cache [][]selfattention.KeysValuesPair
+
+// Len returns the number of positions already processed in the cache.
+func (c Cache) Len() int {
+	if len(c) == 0 || len(c[0]) == 0 {
+		return 0
+	}
+	return len(c[0][0].Keys)
+}
+
+// Forward performs the forward step for each token ID and returns the hidden states.
+func (m *Model) Forward(tokenIDs []int) []ag.Node {
+	ys, _ := m.ForwardWithCache(tokenIDs, nil)
+	return ys
+}
+
+// ForwardWithCache performs the forward step for the given token IDs, following the ones
+// already processed in the cache (nil at the first step), and returns the hidden states
+// of the new tokens together with the updated cache. This allows for incremental
+// (i.e. autoregressive) decoding without recomputing the past.
+// It panics if the sequence exceeds the maximum number of positions: validate the length
+// of the input beforehand, as Generate does returning an error.
+func (m *Model) ForwardWithCache(tokenIDs []int, cache Cache) ([]ag.Node, Cache) {
+	offset := cache.Len()
+	if offset+len(tokenIDs) > len(m.PositionEmbeddings) {
+		panic(fmt.Sprintf("gpt2: sequence length %d exceeds the maximum number of positions %d",
+			offset+len(tokenIDs), len(m.PositionEmbeddings)))
+	}
+	g := m.Graph()
+	ys := m.Embeddings.Encode(intToStringSlice(tokenIDs))
+	for i := range ys {
+		ys[i] = g.Add(ys[i], g.NewWrap(m.PositionEmbeddings[offset+i]))
+	}
+	nextCache := make(Cache, len(m.Layers))
+	for i, layer := range m.Layers {
+		var past []selfattention.KeysValuesPair
+		if cache != nil {
+			past = cache[i]
+		}
+		ys, nextCache[i] = layer.ForwardWithCache(ys, past)
+	}
+	return m.LayerNorm.Forward(ys...), nextCache
+}
+
+// Logits returns the scores over the vocabulary of the given hidden state.
+func (m *Model) Logits(x ag.Node) ag.Node {
+	g := m.Graph()
+	return g.Mul(g.NewVariable(m.LMHead.Weights(m.Embeddings, m.Config.VocabSize), false), x)
+}
+
+func intToStringSlice(a []int) []string {
+	out := make([]string, len(a))
+	for i, num := range a {
+		out[i] = strconv.Itoa(num)
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gpt2
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestModel_ForwardWithCache(t *testing.T) {
+	model := newTestModel(t)
+	defer model.Close()
+	tokenIDs := []int{3, 1, 4, 1, 5}
+
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+
+	full := proc.Forward(tokenIDs)
+
+	var cache Cache
+	var incremental []ag.Node
+	for _, id := range tokenIDs {
+		var ys []ag.Node
+		ys, cache = proc.ForwardWithCache([]int{id}, cache)
+		incremental = append(incremental, ys...)
+	}
+	assert.Equal(t, len(tokenIDs), cache.Len())
+	for i := range full {
+		assert.InDeltaSlice(t, full[i].Value().Data(), incremental[i].Value().Data(), 1.0e-5)
+	}
+	assert.Equal(t, model.Config.VocabSize, proc.Logits(full[0]).Value().Size())
+}
+
+func TestModel_Generate(t *testing.T) {
+	model := newTestModel(t)
+	defer model.Close()
+
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+
+	config := NewGenerationConfig(model.Config)
+	config.MaxLength = 100 // capped to the number of positions
+	hyps, err := proc.Generate([]int{3, 1}, config)
+	require.NoError(t, err)
+	require.Len(t, hyps, 1)
+	assert.Equal(t, []int{3, 1}, hyps[0].TokenIDs[:2])
+	assert.LessOrEqual(t, len(hyps[0].TokenIDs), model.Config.NPositions)
+}
+
+func TestModel_Generate_PromptTooLong(t *testing.T) {
+	model := newTestModel(t)
+	defer model.Close()
+
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+
+	prompt := make([]int, model.Config.NPositions+1)
+	_, err := proc.Generate(prompt, NewGenerationConfig(model.Config))
+	assert.EqualError(t, err, "gpt2: prompt length 11 exceeds the maximum number of positions 10")
+}
+
+func newTestModel(t *testing.T) *Model {
+	dir, err := ioutil.TempDir("", "gpt2")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	config := Config{
+		ActivationFunction: "gelu_new",
+		BosTokenID:         0,
+		EosTokenID:         0,
+		NEmbd:              8,
+		NHead:              2,
+		NLayer:             2,
+		NPositions:         10,
+		VocabSize:          6,
+		Training:           true,
+	}
+	model := New(config, dir)
+	rndGen := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		initializers.Normal(param.Value(), 0, 0.5, rndGen)
+	})
+	for i := 0; i < config.VocabSize; i++ {
+		embedding := mat.NewEmptyVecDense(config.NEmbd)
+		initializers.Normal(embedding, 0, 0.5, rndGen)
+		model.Embeddings.SetEmbedding(strconv.Itoa(i), embedding)
+	}
+	return model
+}
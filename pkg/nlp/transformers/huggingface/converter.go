@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/converter"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/gpt2"
 	"path"
 	"path/filepath"
 )
@@ -44,10 +45,12 @@ func (c *Converter) Convert() error {
 	}
 
 	switch config.ModelType {
-	case "bart":
+	case "bart", "marian":
 		return converter.ConvertHuggingFacePreTrained(c.modelPath)
-	case "bert", "electra":
+	case "bert", "electra", "roberta", "xlm-roberta", "distilbert", "albert":
 		return bert.ConvertHuggingFacePreTrained(c.modelPath)
+	case "gpt2":
+		return gpt2.ConvertHuggingFacePreTrained(c.modelPath)
 	case "":
 		fmt.Println("model type empty; assuming it is BERT.")
 		return bert.ConvertHuggingFacePreTrained(c.modelPath)
@@ -89,9 +89,15 @@ const (
 // supportedModelsFiles contains the set of all supported model types as keys,
 // mapped with the set of all related files to download.
 var supportedModelsFiles = map[string][]string{
-	"bart":    {"pytorch_model.bin", "vocab.json", "merges.txt"},
-	"bert":    {"pytorch_model.bin", "vocab.txt"},
-	"electra": {"pytorch_model.bin", "vocab.txt"},
+	"bart":        {"pytorch_model.bin", "vocab.json", "merges.txt"},
+	"marian":      {"pytorch_model.bin", "vocab.json", "source.spm", "target.spm"},
+	"bert":        {"pytorch_model.bin", "vocab.txt"},
+	"electra":     {"pytorch_model.bin", "vocab.txt"},
+	"roberta":     {"pytorch_model.bin", "vocab.json", "merges.txt"},
+	"xlm-roberta": {"pytorch_model.bin", "sentencepiece.bpe.model"},
+	"distilbert":  {"pytorch_model.bin", "vocab.txt"},
+	"albert":      {"pytorch_model.bin", "spiece.model"},
+	"gpt2":        {"pytorch_model.bin", "vocab.json", "merges.txt"},
 }
 
 func (d *Downloader) downloadFile(filename string) error {
@@ -0,0 +1,27 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transformerutils
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+)
+
+// NewActivation returns a new activation Model for the given Hugging Face activation
+// function name ("gelu", "relu", "swish", "silu", "tanh"), defaulting to GELU.
+func NewActivation(name string) *activation.Model {
+	switch name {
+	case "relu":
+		return activation.New(ag.OpReLU)
+	case "swish", "silu":
+		return activation.New(ag.OpSwish, nn.NewParam(mat.NewScalar(1.0), nn.RequiresGrad(false)))
+	case "tanh":
+		return activation.New(ag.OpTanh)
+	default:
+		return activation.New(ag.OpGELU)
+	}
+}
@@ -0,0 +1,7 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package transformerutils provides the building blocks shared by the transformer
+// models, such as BART and GPT-2.
+package transformerutils
@@ -0,0 +1,51 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transformerutils
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
+	"strconv"
+	"sync"
+)
+
+// TiedLMHead provides the weights of a language modeling head which are the same
+// as the word embeddings, like the shared embeddings of BART. The embeddings are read from the storage only
+// once, the first time they are needed, and then kept in memory.
+// Since the weights are shared by all the processors, no gradients are propagated.
+type TiedLMHead struct {
+	once    sync.Once
+	weights *mat.Dense
+}
+
+// Weights returns the matrix of the word embeddings, one row for each token ID
+// of the vocabulary, from 0 to vocabSize-1.
+func (h *TiedLMHead) Weights(words *embeddings.Model, vocabSize int) *mat.Dense {
+	h.once.Do(func() {
+		size := words.Config.Size
+		weights := mat.NewEmptyDense(vocabSize, size)
+		data := weights.Data()
+		for i := 0; i < vocabSize; i++ {
+			embedding := words.GetStoredEmbedding(strconv.Itoa(i))
+			if embedding == nil {
+				continue // the token has no embedding: leave a zero vector
+			}
+			copy(data[i*size:(i+1)*size], embedding.Value().Data())
+		}
+		h.weights = weights
+	})
+	return h.weights
+}
+
+// GobEncode satisfies the gob.GobEncoder interface.
+// The weights are never serialized, since they are shared with the embeddings.
+func (h *TiedLMHead) GobEncode() ([]byte, error) {
+	return []byte{}, nil
+}
+
+// GobDecode satisfies the gob.GobDecoder interface.
+func (h *TiedLMHead) GobDecode(_ []byte) error {
+	return nil
+}
@@ -0,0 +1,18 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transformerutils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTiedLMHead_Gob(t *testing.T) {
+	data, err := (&TiedLMHead{}).GobEncode()
+	require.NoError(t, err)
+	assert.Empty(t, data)
+	assert.NoError(t, (&TiedLMHead{}).GobDecode(data))
+}
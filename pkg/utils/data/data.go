@@ -47,10 +47,11 @@ func GenerateBatches(size, batchFactor int, class func(i int) int) [][]int {
 }
 
 // ForEachBatch divides the dataset into batches, returning the start-end of each batch with a callback.
+// The range of each batch is [start, end), so that the last batch may be smaller than the others.
 // This function assumes that the dataset has already been shuffled.
 func ForEachBatch(datasetSize, batchSize int, callback func(start, end int)) {
 	for start := 0; start < datasetSize; start += batchSize {
-		end := utils.MinInt(start+batchSize, datasetSize-1)
+		end := utils.MinInt(start+batchSize, datasetSize)
 		callback(start, end)
 	}
 }
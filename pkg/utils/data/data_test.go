@@ -0,0 +1,18 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestForEachBatch(t *testing.T) {
+	var batches [][2]int
+	ForEachBatch(7, 3, func(start, end int) {
+		batches = append(batches, [2]int{start, end})
+	})
+	assert.Equal(t, [][2]int{{0, 3}, {3, 6}, {6, 7}}, batches)
+}
@@ -0,0 +1,284 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package microbatching provides a dynamic micro-batching scheduler, which groups
+// concurrent requests into batches to be processed together (e.g. by a single
+// forward step of a neural model), trading a bounded latency for throughput.
+package microbatching
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned by Scheduler.Submit when the queue of pending
+// requests is full, so that the caller can apply back-pressure.
+var ErrQueueFull = errors.New("microbatching: too many pending requests")
+
+// ErrClosed is returned by Scheduler.Submit after the Scheduler has been closed.
+var ErrClosed = errors.New("microbatching: scheduler closed")
+
+// Config provides the settings of a Scheduler.
+type Config struct {
+	// MaxBatchSize is the maximum number of requests processed together.
+	MaxBatchSize int
+	// MaxWait is the maximum time a batch waits for further requests after
+	// the first one, before being processed even if not full.
+	MaxWait time.Duration
+	// QueueSize is the maximum number of requests waiting to be batched.
+	// When the queue is full, new requests are rejected with ErrQueueFull.
+	QueueSize int
+	// Workers is the number of batches processed concurrently.
+	// Each worker runs the forward step of a whole batch, so it should not be
+	// lower than the number of requests the model could serve at the same time.
+	Workers int
+}
+
+// DefaultConfig returns a Config with reasonable settings for the inference servers,
+// with one worker per CPU.
+func DefaultConfig() Config {
+	return Config{
+		MaxBatchSize: 16,
+		MaxWait:      5 * time.Millisecond,
+		QueueSize:    256,
+		Workers:      runtime.NumCPU(),
+	}
+}
+
+// Validate returns an error if the configuration is not valid.
+func (c Config) Validate() error {
+	switch {
+	case c.MaxBatchSize < 1:
+		return fmt.Errorf("microbatching: invalid max batch size %d", c.MaxBatchSize)
+	case c.MaxWait < 0:
+		return fmt.Errorf("microbatching: invalid max wait %s", c.MaxWait)
+	case c.QueueSize < 0:
+		return fmt.Errorf("microbatching: invalid queue size %d", c.QueueSize)
+	case c.Workers < 1:
+		return fmt.Errorf("microbatching: invalid number of workers %d", c.Workers)
+	default:
+		return nil
+	}
+}
+
+// ProcessFunc processes a batch of inputs and returns one output for each of them,
+// in the same order. The errors are either nil or one for each input as well, so
+// that an invalid input fails its own request only.
+// If the ProcessFunc panics, the inputs of the batch are processed again one at a
+// time, and only the requests whose processing panics again fail.
+type ProcessFunc func(inputs []interface{}) (outputs []interface{}, errs []error)
+
+// Scheduler collects the submitted inputs into batches and processes them with
+// a fixed number of workers, so that the number of goroutines never grows with
+// the load: the excess requests wait in a bounded queue, or are rejected.
+type Scheduler struct {
+	config  Config
+	process ProcessFunc
+	queue   chan *request
+	done    chan struct{}
+	closing sync.Once
+}
+
+type request struct {
+	ctx    context.Context
+	input  interface{}
+	result chan result
+}
+
+type result struct {
+	output interface{}
+	err    error
+}
+
+// New returns a new Scheduler and starts its workers.
+// It panics if the configuration is not valid.
+func New(config Config, process ProcessFunc) *Scheduler {
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
+	s := &Scheduler{
+		config:  config,
+		process: process,
+		queue:   make(chan *request, config.QueueSize),
+		done:    make(chan struct{}),
+	}
+	for i := 0; i < config.Workers; i++ {
+		go s.work()
+	}
+	return s
+}
+
+// Submit enqueues the input to be processed within a batch and waits for its output.
+// It returns ErrQueueFull immediately if there is no room left in the queue, or the
+// context error if the context is done before the output is available.
+func (s *Scheduler) Submit(ctx context.Context, input interface{}) (interface{}, error) {
+	select {
+	case <-s.done:
+		return nil, ErrClosed
+	default:
+	}
+
+	r := &request{
+		ctx:    ctx,
+		input:  input,
+		result: make(chan result, 1),
+	}
+	select {
+	case s.queue <- r:
+	default:
+		return nil, ErrQueueFull
+	}
+
+	select {
+	case res := <-r.result:
+		return res.output, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.done:
+		return nil, ErrClosed
+	}
+}
+
+// Close stops the workers. The pending requests fail with ErrClosed.
+// It is safe to call Close more than once.
+func (s *Scheduler) Close() {
+	s.closing.Do(func() { close(s.done) })
+}
+
+// work repeatedly collects a batch and processes it, until the Scheduler is closed.
+func (s *Scheduler) work() {
+	for {
+		batch := s.collect()
+		if batch == nil {
+			return
+		}
+		s.run(batch)
+	}
+}
+
+// collect waits for the first request, then gathers the following ones until either
+// the batch is full or the maximum wait has elapsed. The requests whose context is
+// already done are discarded. It returns nil when the Scheduler is closed.
+func (s *Scheduler) collect() []*request {
+	var first *request
+	select {
+	case first = <-s.queue:
+	case <-s.done:
+		return nil
+	}
+
+	batch := make([]*request, 0, s.config.MaxBatchSize)
+	batch = appendAlive(batch, first)
+
+	timer := time.NewTimer(s.config.MaxWait)
+	defer timer.Stop()
+	for len(batch) < s.config.MaxBatchSize {
+		select {
+		case r := <-s.queue:
+			batch = appendAlive(batch, r)
+		case <-timer.C:
+			return batch
+		case <-s.done:
+			return nil
+		}
+	}
+	return batch
+}
+
+func appendAlive(batch []*request, r *request) []*request {
+	if r.ctx.Err() != nil {
+		return batch
+	}
+	return append(batch, r)
+}
+
+// run processes the batch and delivers the outputs. If the whole batch fails (i.e.
+// the ProcessFunc panics), its requests are processed again one at a time, so that
+// the failure is reported to the culprit only rather than to all the requests.
+func (s *Scheduler) run(batch []*request) {
+	if len(batch) == 0 {
+		return
+	}
+	inputs := make([]interface{}, len(batch))
+	for i, r := range batch {
+		inputs[i] = r.input
+	}
+
+	outputs, errs, err := s.safeProcess(inputs)
+	if err != nil && len(batch) > 1 {
+		for _, r := range batch {
+			s.run(appendAlive(nil, r))
+		}
+		return
+	}
+	for i, r := range batch {
+		switch {
+		case err != nil:
+			r.result <- result{err: err}
+		case errs != nil && errs[i] != nil:
+			r.result <- result{err: errs[i]}
+		default:
+			r.result <- result{output: outputs[i]}
+		}
+	}
+}
+
+// safeProcess calls the ProcessFunc, and returns an error for the whole batch if
+// it panics or if it doesn't return one output (and error) for each input.
+func (s *Scheduler) safeProcess(inputs []interface{}) (outputs []interface{}, errs []error, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("microbatching: batch processing failed: %v", r)
+		}
+	}()
+	outputs, errs = s.process(inputs)
+	switch {
+	case len(outputs) != len(inputs):
+		err = fmt.Errorf("microbatching: expected %d outputs, got %d", len(inputs), len(outputs))
+	case errs != nil && len(errs) != len(inputs):
+		err = fmt.Errorf("microbatching: expected %d errors, got %d", len(inputs), len(errs))
+	}
+	return outputs, errs, err
+}
+
+// HTTPStatusCode returns the HTTP status code corresponding to an error returned
+// by Submit: 429 (Too Many Requests) for ErrQueueFull, 503 (Service Unavailable)
+// for ErrClosed, and 500 (Internal Server Error) otherwise.
+func HTTPStatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrQueueFull):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrClosed):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GRPCError converts an error returned by Submit into a gRPC status error:
+// RESOURCE_EXHAUSTED for ErrQueueFull, UNAVAILABLE for ErrClosed, the
+// corresponding codes for the context errors, and INTERNAL otherwise.
+func GRPCError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrQueueFull):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, ErrClosed):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package microbatching_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/nlpodyssey/spago/pkg/utils/microbatching"
+	"strings"
+	"sync"
+	"time"
+)
+
+func ExampleScheduler_Submit() {
+	config := microbatching.Config{
+		MaxBatchSize: 3,
+		MaxWait:      100 * time.Millisecond,
+		QueueSize:    10,
+		Workers:      1,
+	}
+	s := microbatching.New(config, func(inputs []interface{}) ([]interface{}, []error) {
+		// Process the whole batch at once, e.g. with a single forward step...
+		outputs := make([]interface{}, len(inputs))
+		for i, input := range inputs {
+			outputs[i] = strings.ToUpper(input.(string))
+		}
+		return outputs, nil
+	})
+	defer s.Close()
+
+	words := []string{"foo", "bar", "baz"}
+	results := make([]interface{}, len(words))
+	var wg sync.WaitGroup
+	wg.Add(len(words))
+	for i, word := range words {
+		go func(i int, word string) {
+			defer wg.Done()
+			result, err := s.Submit(context.Background(), word)
+			if err != nil {
+				panic(err)
+			}
+			results[i] = result
+		}(i, word)
+	}
+	wg.Wait()
+
+	fmt.Println(results...)
+
+	// Output:
+	// FOO BAR BAZ
+}
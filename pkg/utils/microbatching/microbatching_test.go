@@ -0,0 +1,219 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package microbatching
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("it panics if the configuration is not valid", func(t *testing.T) {
+		noop := func(inputs []interface{}) ([]interface{}, []error) { return inputs, nil }
+		assert.Panics(t, func() { New(Config{MaxBatchSize: 0, Workers: 1}, noop) })
+		assert.Panics(t, func() { New(Config{MaxBatchSize: 1, Workers: 0}, noop) })
+		assert.Panics(t, func() { New(Config{MaxBatchSize: 1, Workers: 1, QueueSize: -1}, noop) })
+		assert.Panics(t, func() { New(Config{MaxBatchSize: 1, Workers: 1, MaxWait: -1}, noop) })
+	})
+}
+
+func TestScheduler_Submit(t *testing.T) {
+	t.Run("it groups concurrent requests into batches", func(t *testing.T) {
+		var mu sync.Mutex
+		var sizes []int
+		s := New(Config{MaxBatchSize: 4, MaxWait: 200 * time.Millisecond, QueueSize: 8, Workers: 1},
+			func(inputs []interface{}) ([]interface{}, []error) {
+				mu.Lock()
+				sizes = append(sizes, len(inputs))
+				mu.Unlock()
+				outputs := make([]interface{}, len(inputs))
+				for i, x := range inputs {
+					outputs[i] = x.(int) * 2
+				}
+				return outputs, nil
+			})
+		defer s.Close()
+
+		var wg sync.WaitGroup
+		outputs := make([]interface{}, 8)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				out, err := s.Submit(context.Background(), i)
+				assert.NoError(t, err)
+				outputs[i] = out
+			}(i)
+		}
+		wg.Wait()
+
+		for i, out := range outputs {
+			assert.Equal(t, i*2, out)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		total := 0
+		for _, size := range sizes {
+			assert.LessOrEqual(t, size, 4)
+			total += size
+		}
+		assert.Equal(t, 8, total)
+		assert.Less(t, len(sizes), 8)
+	})
+
+	t.Run("it processes a partial batch after the max wait", func(t *testing.T) {
+		s := New(Config{MaxBatchSize: 10, MaxWait: 10 * time.Millisecond, QueueSize: 1, Workers: 1},
+			func(inputs []interface{}) ([]interface{}, []error) { return inputs, nil })
+		defer s.Close()
+
+		out, err := s.Submit(context.Background(), "a")
+		require.NoError(t, err)
+		assert.Equal(t, "a", out)
+	})
+
+	t.Run("it rejects the requests when the queue is full", func(t *testing.T) {
+		release := make(chan struct{})
+		started := make(chan struct{}, 1)
+		s := New(Config{MaxBatchSize: 1, MaxWait: 0, QueueSize: 1, Workers: 1},
+			func(inputs []interface{}) ([]interface{}, []error) {
+				started <- struct{}{}
+				<-release
+				return inputs, nil
+			})
+		defer s.Close()
+
+		go s.Submit(context.Background(), 1) // busy worker
+		<-started
+		go s.Submit(context.Background(), 2) // queued
+		time.Sleep(20 * time.Millisecond)
+
+		_, err := s.Submit(context.Background(), 3)
+		assert.True(t, errors.Is(err, ErrQueueFull))
+		close(release)
+	})
+
+	t.Run("it reports the processing errors to their own requests", func(t *testing.T) {
+		s := New(Config{MaxBatchSize: 3, MaxWait: 200 * time.Millisecond, QueueSize: 3, Workers: 1},
+			func(inputs []interface{}) ([]interface{}, []error) {
+				errs := make([]error, len(inputs))
+				for i, x := range inputs {
+					if x == "error" {
+						errs[i] = errors.New("failure")
+					}
+				}
+				return inputs, errs
+			})
+		defer s.Close()
+
+		results := submitAll(s, "a", "error", "b")
+		assert.Equal(t, "a", results[0].output)
+		assert.EqualError(t, results[1].err, "failure")
+		assert.Equal(t, "b", results[2].output)
+	})
+
+	t.Run("it isolates the request which makes the whole batch fail", func(t *testing.T) {
+		var mu sync.Mutex
+		var sizes []int
+		s := New(Config{MaxBatchSize: 3, MaxWait: 200 * time.Millisecond, QueueSize: 3, Workers: 1},
+			func(inputs []interface{}) ([]interface{}, []error) {
+				mu.Lock()
+				sizes = append(sizes, len(inputs))
+				mu.Unlock()
+				for _, x := range inputs {
+					if x == "panic" {
+						panic("boom")
+					}
+				}
+				return inputs, nil
+			})
+		defer s.Close()
+
+		results := submitAll(s, "a", "panic", "b")
+		assert.Equal(t, "a", results[0].output)
+		assert.EqualError(t, results[1].err, "microbatching: batch processing failed: boom")
+		assert.Equal(t, "b", results[2].output)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, []int{3, 1, 1, 1}, sizes)
+	})
+
+	t.Run("it fails the batch if the outputs don't match the inputs", func(t *testing.T) {
+		s := New(Config{MaxBatchSize: 1, QueueSize: 1, Workers: 1},
+			func(inputs []interface{}) ([]interface{}, []error) {
+				return nil, nil
+			})
+		defer s.Close()
+
+		_, err := s.Submit(context.Background(), "a")
+		assert.EqualError(t, err, "microbatching: expected 1 outputs, got 0")
+	})
+
+	t.Run("it returns the context error", func(t *testing.T) {
+		s := New(Config{MaxBatchSize: 1, QueueSize: 1, Workers: 1},
+			func(inputs []interface{}) ([]interface{}, []error) {
+				time.Sleep(50 * time.Millisecond)
+				return inputs, nil
+			})
+		defer s.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		_, err := s.Submit(ctx, 1)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("it fails after Close", func(t *testing.T) {
+		s := New(DefaultConfig(), func(inputs []interface{}) ([]interface{}, []error) { return inputs, nil })
+		s.Close()
+		_, err := s.Submit(context.Background(), 1)
+		assert.True(t, errors.Is(err, ErrClosed))
+		assert.NotPanics(t, s.Close)
+	})
+}
+
+// submitAll submits the inputs concurrently, within the same batch if the Scheduler
+// allows it, and returns their results in the same order.
+func submitAll(s *Scheduler, inputs ...interface{}) []result {
+	results := make([]result, len(inputs))
+	var wg sync.WaitGroup
+	wg.Add(len(inputs))
+	for i, input := range inputs {
+		go func(i int, input interface{}) {
+			defer wg.Done()
+			results[i].output, results[i].err = s.Submit(context.Background(), input)
+		}(i, input)
+	}
+	wg.Wait()
+	return results
+}
+
+func TestDefaultConfig(t *testing.T) {
+	config := DefaultConfig()
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, runtime.NumCPU(), config.Workers)
+}
+
+func TestHTTPStatusCode(t *testing.T) {
+	assert.Equal(t, http.StatusTooManyRequests, HTTPStatusCode(ErrQueueFull))
+	assert.Equal(t, http.StatusServiceUnavailable, HTTPStatusCode(ErrClosed))
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatusCode(errors.New("foo")))
+}
+
+func TestGRPCError(t *testing.T) {
+	assert.Nil(t, GRPCError(nil))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(GRPCError(ErrQueueFull)))
+	assert.Equal(t, codes.Unavailable, status.Code(GRPCError(ErrClosed)))
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(GRPCError(context.DeadlineExceeded)))
+	assert.Equal(t, codes.Internal, status.Code(GRPCError(errors.New("foo"))))
+}
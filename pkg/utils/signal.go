@@ -0,0 +1,23 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ExitOnSignal calls cleanup and exits the process as soon as an interrupt or
+// termination signal is received. It returns immediately.
+func ExitOnSignal(cleanup func()) {
+	termChan := make(chan os.Signal, 1)
+	signal.Notify(termChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-termChan
+		cleanup()
+		os.Exit(0)
+	}()
+}